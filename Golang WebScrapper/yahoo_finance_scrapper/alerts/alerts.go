@@ -0,0 +1,466 @@
+package alerts
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"go-webscraper/notify"
+	"go-webscraper/scraper"
+	"go-webscraper/watchlist"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	rulesKey   = "alerts:rules"
+	seenPrefix = "alerts:seen:"
+	seenTTL    = 7 * 24 * time.Hour
+
+	// The engine and the snooze API update when a rule last fired and
+	// how long it is snoozed for while the owner may be editing it, so
+	// they live in hashes of their own keyed by rule id rather than in
+	// the rule.
+	lastFiredKey = "alerts:last_fired"
+	snoozedKey   = "alerts:snoozed"
+)
+
+const (
+	TypeNews = "news"
+	// TypeWatchlistMove fires when a watchlist member moves MovePercent
+	// or more either way on the day.
+	TypeWatchlistMove = "watchlist_move"
+	// TypeWatchlistMover fires when a watchlist member enters the Category
+	// market movers list, e.g. gainers.
+	TypeWatchlistMover = "watchlist_mover"
+	// TypeCondition fires when a symbol, from Symbols or the WatchlistID
+	// watchlist, meets Condition; see ConditionFields.
+	TypeCondition = "condition"
+)
+
+// Rule is one alert policy. Watchlist alerts name a WatchlistID and are
+// evaluated against its members at the time of each scheduled scrape.
+//
+// By default a watchlist or condition alert fires once per symbol per
+// market day. Cooldown instead waits that long before firing for a symbol
+// again (for news alerts, before the next article), and Rearm waits until
+// the symbol has stopped matching, per RearmMargin or RearmCondition.
+// QuietHours, in Timezone, and MarketHoursOnly hold notifications back, as
+// does snoozing one of the rule's events until SnoozedUntil.
+type Rule struct {
+	ID          string    `json:"id"`
+	UserID      string    `json:"-"`
+	Type        string    `json:"type"`
+	Name        string    `json:"name,omitempty"`
+	Symbols     []string  `json:"symbols,omitempty"`
+	Keywords    []string  `json:"keywords,omitempty"`
+	WatchlistID string    `json:"watchlist_id,omitempty"`
+	MovePercent float64   `json:"move_percent,omitempty"`
+	Category    string    `json:"category,omitempty"`
+	Condition   string    `json:"condition,omitempty"`
+	LastFired   string    `json:"last_fired,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+
+	Cooldown        string  `json:"cooldown,omitempty"`
+	Rearm           bool    `json:"rearm,omitempty"`
+	RearmMargin     float64 `json:"rearm_margin,omitempty"`
+	RearmCondition  string  `json:"rearm_condition,omitempty"`
+	QuietHours      string  `json:"quiet_hours,omitempty"`
+	Timezone        string  `json:"timezone,omitempty"`
+	MarketHoursOnly bool    `json:"market_hours_only,omitempty"`
+	SnoozedUntil    string  `json:"snoozed_until,omitempty"`
+}
+
+// storedRule keeps UserID in Redis while the API never exposes it.
+type storedRule struct {
+	Rule
+	UserID string `json:"user_id"`
+}
+
+func (r *Rule) Validate() error {
+	switch r.Type {
+	case TypeNews:
+		if len(r.Symbols) == 0 && len(r.Keywords) == 0 {
+			return fmt.Errorf("news alerts need at least one symbol or keyword")
+		}
+	case TypeWatchlistMove:
+		if r.WatchlistID == "" {
+			return fmt.Errorf("watchlist alerts need a watchlist_id")
+		}
+		if r.MovePercent <= 0 {
+			return fmt.Errorf("move_percent must be positive")
+		}
+	case TypeWatchlistMover:
+		if r.WatchlistID == "" {
+			return fmt.Errorf("watchlist alerts need a watchlist_id")
+		}
+		if !scraper.IsMoverCategory(r.Category) {
+			return fmt.Errorf("category must be one of: most_active, gainers, losers, trending")
+		}
+	case TypeCondition:
+		if r.WatchlistID == "" && len(r.Symbols) == 0 {
+			return fmt.Errorf("condition alerts need a watchlist_id or at least one symbol")
+		}
+		if _, err := parseCondition(r.Condition); err != nil {
+			return fmt.Errorf("invalid condition: %v", err)
+		}
+	default:
+		return fmt.Errorf("type must be one of: %s, %s, %s, %s", TypeNews, TypeWatchlistMove, TypeWatchlistMover, TypeCondition)
+	}
+	return r.validateDelivery()
+}
+
+type Store struct {
+	redis *redis.Client
+	ctx   context.Context
+}
+
+func NewStore(rdb *redis.Client) *Store {
+	return &Store{
+		redis: rdb,
+		ctx:   context.Background(),
+	}
+}
+
+// Save stores the rule's settings. LastFired and SnoozedUntil are left
+// as they are; see setLastFired and snooze.
+func (s *Store) Save(rule *Rule) error {
+	stored := storedRule{Rule: *rule, UserID: rule.UserID}
+	stored.LastFired, stored.SnoozedUntil = "", ""
+	data, err := json.Marshal(stored)
+	if err != nil {
+		return err
+	}
+	return s.redis.HSet(s.ctx, rulesKey, rule.ID, data).Err()
+}
+
+func (s *Store) Get(id string) (*Rule, error) {
+	pipe := s.redis.Pipeline()
+	data := pipe.HGet(s.ctx, rulesKey, id)
+	lastFired := pipe.HGet(s.ctx, lastFiredKey, id)
+	snoozed := pipe.HGet(s.ctx, snoozedKey, id)
+	if _, err := pipe.Exec(s.ctx); err != nil && err != redis.Nil {
+		return nil, err
+	}
+	if err := data.Err(); err != nil {
+		if err == redis.Nil {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var stored storedRule
+	if err := json.Unmarshal([]byte(data.Val()), &stored); err != nil {
+		return nil, err
+	}
+	stored.Rule.UserID = stored.UserID
+	stored.Rule.applyState(lastFired.Val(), snoozed.Val())
+	return &stored.Rule, nil
+}
+
+// applyState sets the fields kept outside the rule, keeping the values
+// rules saved before they moved out when there are none.
+func (r *Rule) applyState(lastFired, snoozedUntil string) {
+	if lastFired != "" {
+		r.LastFired = lastFired
+	}
+	if snoozedUntil != "" {
+		r.SnoozedUntil = snoozedUntil
+	}
+}
+
+// setLastFired records when the rule last fired.
+func (s *Store) setLastFired(id string, at time.Time) error {
+	return s.setState(lastFiredKey, id, at.Format(time.RFC3339))
+}
+
+// snooze holds the rule back until until.
+func (s *Store) snooze(id, until string) error {
+	return s.setState(snoozedKey, id, until)
+}
+
+// setState sets the rule's field in key, unless the rule was deleted.
+func (s *Store) setState(key, id, value string) error {
+	exists, err := s.redis.HExists(s.ctx, rulesKey, id).Result()
+	if err != nil || !exists {
+		return err
+	}
+	return s.redis.HSet(s.ctx, key, id, value).Err()
+}
+
+func (s *Store) Delete(id string) error {
+	pipe := s.redis.TxPipeline()
+	pipe.HDel(s.ctx, rulesKey, id)
+	pipe.HDel(s.ctx, lastFiredKey, id)
+	pipe.HDel(s.ctx, snoozedKey, id)
+	pipe.Del(s.ctx, seenPrefix+id)
+	pipe.Del(s.ctx, firedPrefix+id)
+	pipe.Del(s.ctx, eventsPrefix+id, eventsOrderPrefix+id)
+	_, err := pipe.Exec(s.ctx)
+	return err
+}
+
+func (s *Store) All() ([]*Rule, error) {
+	pipe := s.redis.Pipeline()
+	values := pipe.HGetAll(s.ctx, rulesKey)
+	lastFired := pipe.HGetAll(s.ctx, lastFiredKey)
+	snoozed := pipe.HGetAll(s.ctx, snoozedKey)
+	if _, err := pipe.Exec(s.ctx); err != nil {
+		return nil, err
+	}
+
+	rules := make([]*Rule, 0, len(values.Val()))
+	for id, value := range values.Val() {
+		var stored storedRule
+		if err := json.Unmarshal([]byte(value), &stored); err != nil {
+			continue
+		}
+		stored.Rule.UserID = stored.UserID
+		stored.Rule.applyState(lastFired.Val()[id], snoozed.Val()[id])
+		rules = append(rules, &stored.Rule)
+	}
+	return rules, nil
+}
+
+func (s *Store) ByType(ruleType string) ([]*Rule, error) {
+	rules, err := s.All()
+	if err != nil {
+		return nil, err
+	}
+
+	matched := make([]*Rule, 0)
+	for _, rule := range rules {
+		if rule.Type == ruleType {
+			matched = append(matched, rule)
+		}
+	}
+	return matched, nil
+}
+
+func (s *Store) ListByUser(userID string) ([]*Rule, error) {
+	rules, err := s.All()
+	if err != nil {
+		return nil, err
+	}
+
+	owned := make([]*Rule, 0)
+	for _, rule := range rules {
+		if rule.UserID == userID {
+			owned = append(owned, rule)
+		}
+	}
+	return owned, nil
+}
+
+// markSeen records that rule has fired for item and reports whether this
+// is the first time, so repeated scrapes never notify twice.
+func (s *Store) markSeen(ruleID, item string) (bool, error) {
+	key := seenPrefix + ruleID
+	added, err := s.redis.SAdd(s.ctx, key, item).Result()
+	if err != nil {
+		return false, err
+	}
+	s.redis.Expire(s.ctx, key, seenTTL)
+	return added == 1, nil
+}
+
+// unmarkSeen forgets that rule fired for item, for a notification that
+// didn't get through.
+func (s *Store) unmarkSeen(ruleID, item string) error {
+	return s.redis.SRem(s.ctx, seenPrefix+ruleID, item).Err()
+}
+
+// Engine evaluates alert rules against refreshed data and notifies the
+// owners of rules that match. It is a refresh sink alongside webhooks.
+type Engine struct {
+	store      *Store
+	watchlists *watchlist.Store
+	notifier   *notify.Notifier
+
+	// mentions holds the recent articles about symbols condition alerts
+	// watch, by symbol and link, for their sentiment and news fields
+	mentionsMu sync.Mutex
+	mentions   map[string]map[string]mention
+}
+
+func NewEngine(store *Store, watchlists *watchlist.Store, notifier *notify.Notifier) *Engine {
+	return &Engine{
+		store:      store,
+		watchlists: watchlists,
+		notifier:   notifier,
+		mentions:   make(map[string]map[string]mention),
+	}
+}
+
+func randomID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// NewNewsRule returns a news rule owned by userID, for rules created
+// outside the API.
+func NewNewsRule(userID, name string, symbols, keywords []string) *Rule {
+	return &Rule{
+		ID:        randomID(),
+		UserID:    userID,
+		Type:      TypeNews,
+		Name:      name,
+		Symbols:   normalize(symbols, strings.ToUpper),
+		Keywords:  normalize(keywords, strings.TrimSpace),
+		CreatedAt: time.Now(),
+	}
+}
+
+type RuleRequest struct {
+	Type        string   `json:"type" binding:"required"`
+	Name        string   `json:"name"`
+	Symbols     []string `json:"symbols"`
+	Keywords    []string `json:"keywords"`
+	WatchlistID string   `json:"watchlist_id"`
+	MovePercent float64  `json:"move_percent"`
+	Category    string   `json:"category"`
+	Condition   string   `json:"condition"`
+
+	Cooldown        string  `json:"cooldown"`
+	Rearm           bool    `json:"rearm"`
+	RearmMargin     float64 `json:"rearm_margin"`
+	RearmCondition  string  `json:"rearm_condition"`
+	QuietHours      string  `json:"quiet_hours"`
+	Timezone        string  `json:"timezone"`
+	MarketHoursOnly bool    `json:"market_hours_only"`
+}
+
+func HandleCreateRule(store *Store, watchlists *watchlist.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req RuleRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		rule := &Rule{
+			ID:        randomID(),
+			UserID:    c.GetString("user_id"),
+			Type:      req.Type,
+			Name:      req.Name,
+			Symbols:   normalize(req.Symbols, strings.ToUpper),
+			Keywords:  normalize(req.Keywords, strings.TrimSpace),
+			CreatedAt: time.Now(),
+
+			Cooldown:        req.Cooldown,
+			QuietHours:      req.QuietHours,
+			Timezone:        req.Timezone,
+			MarketHoursOnly: req.MarketHoursOnly,
+		}
+		if req.Type != TypeNews {
+			rule.WatchlistID = req.WatchlistID
+			rule.MovePercent = req.MovePercent
+			rule.Category = req.Category
+			rule.Condition = strings.TrimSpace(req.Condition)
+			rule.Rearm = req.Rearm || req.RearmMargin != 0 || req.RearmCondition != ""
+			rule.RearmMargin = req.RearmMargin
+			rule.RearmCondition = strings.TrimSpace(req.RearmCondition)
+		}
+		if err := rule.Validate(); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		if rule.WatchlistID != "" {
+			list, err := watchlists.Get(rule.WatchlistID)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{
+					"error": err.Error(),
+				})
+				return
+			}
+			if list == nil || list.UserID != rule.UserID {
+				c.JSON(http.StatusNotFound, gin.H{
+					"error": "watchlist not found",
+				})
+				return
+			}
+		}
+
+		if err := store.Save(rule); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		c.Set("audit_target", rule.ID)
+		c.JSON(http.StatusCreated, gin.H{
+			"status": "success",
+			"data":   rule,
+		})
+	}
+}
+
+func normalize(values []string, fn func(string) string) []string {
+	out := make([]string, 0, len(values))
+	for _, v := range values {
+		if v = fn(strings.TrimSpace(v)); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+func HandleListRules(store *Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		rules, err := store.ListByUser(c.GetString("user_id"))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"status": "success",
+			"data":   rules,
+		})
+	}
+}
+
+func HandleDeleteRule(store *Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		rule, err := store.Get(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+		if rule == nil || rule.UserID != c.GetString("user_id") {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "alert not found",
+			})
+			return
+		}
+
+		if err := store.Delete(rule.ID); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"status": "success",
+		})
+	}
+}
@@ -0,0 +1,253 @@
+package alerts
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"strings"
+	"time"
+
+	"go-webscraper/scraper"
+)
+
+const (
+	cooldownPrefix = "alerts:cooldown:"
+	firedPrefix    = "alerts:fired:"
+
+	// defaultTimezone is what quiet hours are read in without a timezone
+	defaultTimezone = "America/New_York"
+)
+
+// validateDelivery checks the cooldown, re-arm and delivery window
+// settings every rule type shares.
+func (r *Rule) validateDelivery() error {
+	if r.Cooldown != "" {
+		if d, err := time.ParseDuration(r.Cooldown); err != nil || d <= 0 {
+			return fmt.Errorf("cooldown must be a positive Go duration, e.g. 2h")
+		}
+	}
+	if r.QuietHours != "" {
+		if _, _, err := parseQuietHours(r.QuietHours); err != nil {
+			return err
+		}
+	}
+	if r.Timezone != "" {
+		if _, err := time.LoadLocation(r.Timezone); err != nil {
+			return fmt.Errorf("unknown timezone: %s", r.Timezone)
+		}
+	}
+
+	if r.Type == TypeNews && (r.Rearm || r.RearmMargin != 0 || r.RearmCondition != "") {
+		return fmt.Errorf("rearm applies to watchlist and condition alerts, not news")
+	}
+	if r.RearmMargin != 0 {
+		if r.Type != TypeWatchlistMove {
+			return fmt.Errorf("rearm_margin applies to %s alerts", TypeWatchlistMove)
+		}
+		if r.RearmMargin < 0 || r.RearmMargin >= r.MovePercent {
+			return fmt.Errorf("rearm_margin must be between 0 and move_percent")
+		}
+	}
+	if r.RearmCondition != "" {
+		if r.Type != TypeCondition {
+			return fmt.Errorf("rearm_condition applies to %s alerts", TypeCondition)
+		}
+		if _, err := parseCondition(r.RearmCondition); err != nil {
+			return fmt.Errorf("invalid rearm_condition: %v", err)
+		}
+	}
+	return nil
+}
+
+// parseQuietHours reads "HH:MM-HH:MM" as minutes after midnight. The end
+// may be before the start, for quiet hours running past midnight.
+func parseQuietHours(value string) (int, int, error) {
+	from, to, found := strings.Cut(value, "-")
+	start, err := time.Parse("15:04", strings.TrimSpace(from))
+	if !found || err != nil {
+		return 0, 0, fmt.Errorf("quiet_hours must look like 22:00-07:00")
+	}
+	end, err := time.Parse("15:04", strings.TrimSpace(to))
+	if err != nil || start.Equal(end) {
+		return 0, 0, fmt.Errorf("quiet_hours must look like 22:00-07:00")
+	}
+	return start.Hour()*60 + start.Minute(), end.Hour()*60 + end.Minute(), nil
+}
+
+// cooldown is how long the rule waits before firing again for the same
+// symbol, zero when it isn't set.
+func (r *Rule) cooldown() time.Duration {
+	d, _ := time.ParseDuration(r.Cooldown)
+	return d
+}
+
+// deliverable reports whether the rule may notify at now: outside its
+// quiet hours in its timezone (Eastern by default), and during the regular
+// session when MarketHoursOnly is set, and not while snoozed. Matches held
+// back are not recorded, so they still notify once the window opens if
+// they hold then.
+func (r *Rule) deliverable(now time.Time) bool {
+	if until, err := time.Parse(time.RFC3339, r.SnoozedUntil); err == nil && now.Before(until) {
+		return false
+	}
+	if r.MarketHoursOnly && !scraper.MarketOpen(now) {
+		return false
+	}
+	if r.QuietHours == "" {
+		return true
+	}
+	start, end, err := parseQuietHours(r.QuietHours)
+	if err != nil {
+		return true
+	}
+
+	timezone := r.Timezone
+	if timezone == "" {
+		timezone = defaultTimezone
+	}
+	location, err := time.LoadLocation(timezone)
+	if err != nil {
+		return true
+	}
+	local := now.In(location)
+	minute := local.Hour()*60 + local.Minute()
+	if start < end {
+		return minute < start || minute >= end
+	}
+	return minute < start && minute >= end
+}
+
+// rearmed reports whether a symbol the rule fired for has moved back far
+// enough to fire again: for moves, below move_percent less rearm_margin;
+// for movers, off the list; for conditions, meeting rearm_condition or,
+// without one, no longer meeting the condition.
+func (e *Engine) rearmed(rule *Rule, quote scraper.StockData, listed bool, now time.Time) bool {
+	switch rule.Type {
+	case TypeWatchlistMove:
+		return math.Abs(quote.ChangePerc) < rule.MovePercent-rule.RearmMargin
+	case TypeWatchlistMover:
+		return !listed
+	case TypeCondition:
+		values := e.conditionValues(quote, now)
+		if rule.RearmCondition != "" {
+			cond, err := parseCondition(rule.RearmCondition)
+			return err == nil && matches(cond, values)
+		}
+		cond, err := parseCondition(rule.Condition)
+		return err == nil && !matches(cond, values)
+	}
+	return true
+}
+
+// armed drops from matched the symbols the rule fired for that haven't
+// re-armed since, first re-arming those that have. Movers rules fetch no
+// quotes, so they re-arm on the list alone.
+func (e *Engine) armed(rule *Rule, matched []scraper.StockData, quotes map[string]scraper.StockData, listed map[string]bool, now time.Time) ([]scraper.StockData, error) {
+	fired, err := e.store.firedSymbols(rule.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	rearmed := make([]string, 0)
+	for symbol := range fired {
+		quote, ok := quotes[symbol]
+		if !ok && rule.Type != TypeWatchlistMover {
+			continue
+		}
+		if e.rearmed(rule, quote, listed[symbol], now) {
+			rearmed = append(rearmed, symbol)
+			delete(fired, symbol)
+		}
+	}
+	if err := e.store.rearm(rule.ID, rearmed...); err != nil {
+		return nil, err
+	}
+
+	armed := make([]scraper.StockData, 0, len(matched))
+	for _, stock := range matched {
+		if !fired[stock.Symbol] {
+			armed = append(armed, stock)
+		}
+	}
+	return armed, nil
+}
+
+// claim reports whether the rule may fire for symbol now. A cooldown
+// allows it once per cooldown; re-arming leaves it to armed; otherwise it
+// fires once per market day.
+func (e *Engine) claim(rule *Rule, symbol, day string) (bool, error) {
+	if cooldown := rule.cooldown(); cooldown > 0 {
+		return e.store.claimCooldown(rule.ID, symbol, cooldown)
+	}
+	if rule.Rearm {
+		return true, nil
+	}
+	return e.store.markSeen(rule.ID, day+":"+symbol)
+}
+
+// release gives back the claims on symbols after delivering to them
+// failed, so the next evaluation tries again instead of waiting out the
+// day or the cooldown.
+func (e *Engine) release(rule *Rule, day string, symbols ...string) {
+	for _, symbol := range symbols {
+		var err error
+		if cooldown := rule.cooldown(); cooldown > 0 {
+			err = e.store.releaseCooldown(rule.ID, symbol)
+		} else if !rule.Rearm {
+			err = e.store.unmarkSeen(rule.ID, day+":"+symbol)
+		}
+		if err != nil {
+			log.Printf("Error releasing alert %s for %s: %v", rule.ID, symbol, err)
+		}
+	}
+}
+
+// claimCooldown reports whether the rule may fire for item now, starting
+// its cooldown if so.
+func (s *Store) claimCooldown(ruleID, item string, cooldown time.Duration) (bool, error) {
+	return s.redis.SetNX(s.ctx, cooldownPrefix+ruleID+":"+item, time.Now().Format(time.RFC3339), cooldown).Result()
+}
+
+func (s *Store) releaseCooldown(ruleID, item string) error {
+	return s.redis.Del(s.ctx, cooldownPrefix+ruleID+":"+item).Err()
+}
+
+// firedSymbols are the symbols a re-arming rule fired for that haven't
+// re-armed yet.
+func (s *Store) firedSymbols(ruleID string) (map[string]bool, error) {
+	members, err := s.redis.SMembers(s.ctx, firedPrefix+ruleID).Result()
+	if err != nil {
+		return nil, err
+	}
+	fired := make(map[string]bool, len(members))
+	for _, member := range members {
+		fired[member] = true
+	}
+	return fired, nil
+}
+
+func (s *Store) markFired(ruleID string, symbols ...string) error {
+	if len(symbols) == 0 {
+		return nil
+	}
+	members := make([]interface{}, len(symbols))
+	for i, symbol := range symbols {
+		members[i] = symbol
+	}
+	key := firedPrefix + ruleID
+	if err := s.redis.SAdd(s.ctx, key, members...).Err(); err != nil {
+		return err
+	}
+	return s.redis.Expire(s.ctx, key, seenTTL).Err()
+}
+
+func (s *Store) rearm(ruleID string, symbols ...string) error {
+	if len(symbols) == 0 {
+		return nil
+	}
+	members := make([]interface{}, len(symbols))
+	for i, symbol := range symbols {
+		members[i] = symbol
+	}
+	return s.redis.SRem(s.ctx, firedPrefix+ruleID, members...).Err()
+}
@@ -0,0 +1,152 @@
+package alerts
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"go-webscraper/notify"
+	"go-webscraper/scraper"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateDelivery(t *testing.T) {
+	move := func(r Rule) *Rule {
+		r.Type, r.WatchlistID, r.MovePercent = TypeWatchlistMove, "w1", 5
+		return &r
+	}
+	assert.NoError(t, move(Rule{Cooldown: "2h", QuietHours: "22:00-07:00", Timezone: "Europe/London"}).Validate())
+	assert.NoError(t, move(Rule{Rearm: true, RearmMargin: 2}).Validate())
+	assert.NoError(t, (&Rule{Type: TypeCondition, Symbols: []string{"AAPL"}, Condition: "price > 150",
+		Rearm: true, RearmCondition: "price < 145"}).Validate())
+
+	assert.Error(t, move(Rule{Cooldown: "soon"}).Validate())
+	assert.Error(t, move(Rule{Cooldown: "-1h"}).Validate())
+	assert.Error(t, move(Rule{QuietHours: "22:00"}).Validate())
+	assert.Error(t, move(Rule{QuietHours: "25:00-07:00"}).Validate())
+	assert.Error(t, move(Rule{Timezone: "Mars/Olympus"}).Validate())
+	assert.Error(t, move(Rule{Rearm: true, RearmMargin: 5}).Validate())
+	assert.Error(t, move(Rule{Rearm: true, RearmCondition: "price < 145"}).Validate())
+	assert.Error(t, (&Rule{Type: TypeNews, Symbols: []string{"AAPL"}, Rearm: true}).Validate())
+}
+
+func TestDeliverable(t *testing.T) {
+	at := func(value string) time.Time {
+		now, err := time.Parse(time.RFC3339, value)
+		require.NoError(t, err)
+		return now
+	}
+
+	overnight := &Rule{QuietHours: "22:00-07:00"}
+	assert.True(t, overnight.deliverable(at("2024-07-10T21:59:00-04:00")))
+	assert.False(t, overnight.deliverable(at("2024-07-10T22:00:00-04:00")))
+	assert.False(t, overnight.deliverable(at("2024-07-11T06:59:00-04:00")))
+	assert.True(t, overnight.deliverable(at("2024-07-11T07:00:00-04:00")))
+
+	lunch := &Rule{QuietHours: "12:00-13:00", Timezone: "Europe/London"}
+	assert.False(t, lunch.deliverable(at("2024-07-10T12:30:00+01:00")))
+	assert.True(t, lunch.deliverable(at("2024-07-10T12:30:00-04:00")))
+
+	session := &Rule{MarketHoursOnly: true}
+	assert.True(t, session.deliverable(at("2024-07-10T10:00:00-04:00")))
+	assert.False(t, session.deliverable(at("2024-07-10T18:00:00-04:00")))
+	assert.False(t, session.deliverable(at("2024-07-13T10:00:00-04:00")))
+}
+
+func TestRearm(t *testing.T) {
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer rdb.Close()
+	e := NewEngine(NewStore(rdb), nil, nil)
+	now := time.Now()
+
+	rule := &Rule{ID: "r1", Type: TypeWatchlistMove, MovePercent: 5, Rearm: true, RearmMargin: 2}
+	require.NoError(t, e.store.markFired(rule.ID, "AAPL", "MSFT"))
+
+	// AAPL is still up 4%, inside the hysteresis band, so it stays fired;
+	// MSFT fell back under 3% and re-arms
+	quotes := map[string]scraper.StockData{
+		"AAPL": {Symbol: "AAPL", ChangePerc: 4},
+		"MSFT": {Symbol: "MSFT", ChangePerc: 2.5},
+	}
+	matched := []scraper.StockData{{Symbol: "AAPL", ChangePerc: 6}, {Symbol: "NVDA", ChangePerc: 7}}
+	armed, err := e.armed(rule, matched, quotes, nil, now)
+	require.NoError(t, err)
+	assert.Equal(t, []scraper.StockData{{Symbol: "NVDA", ChangePerc: 7}}, armed)
+
+	fired, err := e.store.firedSymbols(rule.ID)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]bool{"AAPL": true}, fired)
+
+	first, err := e.claim(rule, "NVDA", "2024-07-10")
+	require.NoError(t, err)
+	assert.True(t, first, "re-arming rules aren't limited to once a day")
+}
+
+func TestClaimCooldown(t *testing.T) {
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer rdb.Close()
+	e := NewEngine(NewStore(rdb), nil, nil)
+
+	rule := &Rule{ID: "r1", Type: TypeWatchlistMove, MovePercent: 5, Cooldown: "2h"}
+	first, err := e.claim(rule, "AAPL", "2024-07-10")
+	require.NoError(t, err)
+	assert.True(t, first)
+	first, err = e.claim(rule, "AAPL", "2024-07-10")
+	require.NoError(t, err)
+	assert.False(t, first)
+
+	mr.FastForward(2 * time.Hour)
+	first, err = e.claim(rule, "AAPL", "2024-07-10")
+	require.NoError(t, err)
+	assert.True(t, first)
+
+	// Without a cooldown or re-arming, once per market day
+	daily := &Rule{ID: "r2", Type: TypeWatchlistMove, MovePercent: 5}
+	first, err = e.claim(daily, "AAPL", "2024-07-10")
+	require.NoError(t, err)
+	assert.True(t, first)
+	first, err = e.claim(daily, "AAPL", "2024-07-10")
+	require.NoError(t, err)
+	assert.False(t, first)
+}
+
+func TestFailedDeliveryIsRetried(t *testing.T) {
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer rdb.Close()
+
+	notifier := notify.NewNotifier(rdb)
+	down := true
+	sent := 0
+	notifier.Register("test", func(target string, msg notify.Message) error {
+		if down {
+			return errors.New("channel down")
+		}
+		sent++
+		return nil
+	})
+	require.NoError(t, notifier.SetChannel("alice", notify.Channel{Type: "test", Target: "alice"}))
+	e := NewEngine(NewStore(rdb), nil, notifier)
+
+	daily := &Rule{ID: "r1", UserID: "alice", Type: TypeCondition, Symbols: []string{"AAPL"}, Condition: "price > 100"}
+	cooling := &Rule{ID: "r2", UserID: "alice", Type: TypeCondition, Symbols: []string{"AAPL"}, Condition: "price > 100", Cooldown: "2h"}
+	require.NoError(t, e.store.Save(daily))
+	require.NoError(t, e.store.Save(cooling))
+
+	quotes := []scraper.StockData{{Symbol: "AAPL", Price: 190}}
+	now := time.Now()
+	e.EvaluateWatchlists(quotes, nil, now)
+	assert.Equal(t, 0, sent)
+
+	down = false
+	e.EvaluateWatchlists(quotes, nil, now)
+	assert.Equal(t, 2, sent, "both rules fire once the channel is back")
+	e.EvaluateWatchlists(quotes, nil, now)
+	assert.Equal(t, 2, sent)
+}
@@ -0,0 +1,356 @@
+package alerts
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go-webscraper/notify"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+// Every time a rule fires it leaves an event, whether or not the
+// notification got through:
+//
+//	alerts:events:<rule>        hash of event id to its JSON Event
+//	alerts:events:order:<rule>  sorted set of event ids by trigger time
+const (
+	eventsPrefix      = "alerts:events:"
+	eventsOrderPrefix = "alerts:events:order:"
+	// maxEvents is how many of a rule's latest events are kept
+	maxEvents = 500
+	eventsTTL = 30 * 24 * time.Hour
+
+	DefaultEventLimit = 50
+	MaxEventLimit     = maxEvents
+	// MaxSnooze bounds how long one snooze silences a rule.
+	MaxSnooze = 7 * 24 * time.Hour
+)
+
+const (
+	EventDelivered = "delivered"
+	EventFailed    = "failed"
+
+	StateOpen         = "open"
+	StateAcknowledged = "acknowledged"
+	StateSnoozed      = "snoozed"
+)
+
+// Event is one firing of a rule: the notification it sent, whether it was
+// delivered, and what the owner has done about it since. State is derived
+// when events are read.
+type Event struct {
+	ID             string      `json:"id"`
+	RuleID         string      `json:"rule_id"`
+	Subject        string      `json:"subject"`
+	Body           string      `json:"body"`
+	Payload        interface{} `json:"payload,omitempty"`
+	Status         string      `json:"status"`
+	Error          string      `json:"error,omitempty"`
+	TriggeredAt    string      `json:"triggered_at"`
+	AcknowledgedAt string      `json:"acknowledged_at,omitempty"`
+	AcknowledgedBy string      `json:"acknowledged_by,omitempty"`
+	SnoozedUntil   string      `json:"snoozed_until,omitempty"`
+	State          string      `json:"state"`
+}
+
+// state is acknowledged once acknowledged, snoozed while a snooze runs and
+// open otherwise.
+func (ev *Event) state(now time.Time) string {
+	if ev.AcknowledgedAt != "" {
+		return StateAcknowledged
+	}
+	if until, err := time.Parse(time.RFC3339, ev.SnoozedUntil); err == nil && now.Before(until) {
+		return StateSnoozed
+	}
+	return StateOpen
+}
+
+// SaveEvent stores ev, dropping the rule's oldest events past maxEvents.
+func (s *Store) SaveEvent(ev *Event) error {
+	data, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+	triggered, err := time.Parse(time.RFC3339Nano, ev.TriggeredAt)
+	if err != nil {
+		return fmt.Errorf("invalid triggered_at: %v", err)
+	}
+
+	key, order := eventsPrefix+ev.RuleID, eventsOrderPrefix+ev.RuleID
+	pipe := s.redis.TxPipeline()
+	pipe.HSet(s.ctx, key, ev.ID, data)
+	pipe.ZAddNX(s.ctx, order, redis.Z{Score: float64(triggered.UnixNano()), Member: ev.ID})
+	pipe.Expire(s.ctx, key, eventsTTL)
+	pipe.Expire(s.ctx, order, eventsTTL)
+	if _, err := pipe.Exec(s.ctx); err != nil {
+		return err
+	}
+
+	// Trim outside the transaction, which can't see the new size
+	stale, err := s.redis.ZRange(s.ctx, order, 0, -maxEvents-1).Result()
+	if err != nil || len(stale) == 0 {
+		return err
+	}
+	pipe = s.redis.TxPipeline()
+	pipe.HDel(s.ctx, key, stale...)
+	pipe.ZRem(s.ctx, order, stringsToMembers(stale)...)
+	_, err = pipe.Exec(s.ctx)
+	return err
+}
+
+func stringsToMembers(values []string) []interface{} {
+	members := make([]interface{}, len(values))
+	for i, value := range values {
+		members[i] = value
+	}
+	return members
+}
+
+func (s *Store) GetEvent(ruleID, eventID string) (*Event, error) {
+	data, err := s.redis.HGet(s.ctx, eventsPrefix+ruleID, eventID).Result()
+	if err != nil {
+		if err == redis.Nil {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var ev Event
+	if err := json.Unmarshal([]byte(data), &ev); err != nil {
+		return nil, err
+	}
+	ev.State = ev.state(time.Now())
+	return &ev, nil
+}
+
+// Events returns up to limit of the rule's events, newest first, only
+// those in state when it is set.
+func (s *Store) Events(ruleID, state string, limit int) ([]*Event, error) {
+	ids, err := s.redis.ZRevRange(s.ctx, eventsOrderPrefix+ruleID, 0, -1).Result()
+	if err != nil {
+		return nil, err
+	}
+	events := make([]*Event, 0)
+	if len(ids) == 0 {
+		return events, nil
+	}
+	values, err := s.redis.HMGet(s.ctx, eventsPrefix+ruleID, ids...).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	for _, value := range values {
+		data, ok := value.(string)
+		if !ok {
+			continue
+		}
+		var ev Event
+		if err := json.Unmarshal([]byte(data), &ev); err != nil {
+			continue
+		}
+		ev.State = ev.state(now)
+		if state != "" && ev.State != state {
+			continue
+		}
+		events = append(events, &ev)
+		if len(events) == limit {
+			break
+		}
+	}
+	return events, nil
+}
+
+// deliver sends msg to the rule's owner and records the firing as an
+// event, delivered or not.
+func (e *Engine) deliver(rule *Rule, msg notify.Message, now time.Time) error {
+	err := e.notifier.NotifyUser(rule.UserID, msg)
+
+	ev := &Event{
+		ID:          randomID(),
+		RuleID:      rule.ID,
+		Subject:     msg.Subject,
+		Body:        msg.Body,
+		Payload:     msg.Data,
+		Status:      EventDelivered,
+		TriggeredAt: now.Format(time.RFC3339Nano),
+	}
+	if err != nil {
+		ev.Status = EventFailed
+		ev.Error = err.Error()
+	}
+	if saveErr := e.store.SaveEvent(ev); saveErr != nil {
+		log.Printf("Error recording event of alert %s: %v", rule.ID, saveErr)
+	}
+	return err
+}
+
+// ownedRule loads the :id rule for the calling user, answering 404 for
+// rules that don't exist or belong to someone else.
+func ownedRule(c *gin.Context, store *Store) (*Rule, bool) {
+	rule, err := store.Get(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return nil, false
+	}
+	if rule == nil || rule.UserID != c.GetString("user_id") {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "alert not found",
+		})
+		return nil, false
+	}
+	return rule, true
+}
+
+// ownedEvent loads the :event event of the caller's :id rule.
+func ownedEvent(c *gin.Context, store *Store) (*Rule, *Event, bool) {
+	rule, ok := ownedRule(c, store)
+	if !ok {
+		return nil, nil, false
+	}
+	ev, err := store.GetEvent(rule.ID, c.Param("event"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return nil, nil, false
+	}
+	if ev == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "event not found",
+		})
+		return nil, nil, false
+	}
+	return rule, ev, true
+}
+
+// HandleListEvents serves GET /api/alerts/:id/events, newest first.
+// ?state= keeps only open, acknowledged or snoozed events.
+func HandleListEvents(store *Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		state := c.Query("state")
+		if state != "" && state != StateOpen && state != StateAcknowledged && state != StateSnoozed {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": fmt.Sprintf("state must be %s, %s or %s", StateOpen, StateAcknowledged, StateSnoozed),
+			})
+			return
+		}
+		limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(DefaultEventLimit)))
+		if err != nil || limit <= 0 || limit > MaxEventLimit {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": fmt.Sprintf("limit must be between 1 and %d", MaxEventLimit),
+			})
+			return
+		}
+
+		rule, ok := ownedRule(c, store)
+		if !ok {
+			return
+		}
+		events, err := store.Events(rule.ID, state, limit)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"status": "success",
+			"data":   events,
+		})
+	}
+}
+
+// HandleAcknowledgeEvent serves POST /api/alerts/:id/events/:event/ack.
+// Acknowledging again keeps the first acknowledgment.
+func HandleAcknowledgeEvent(store *Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		_, ev, ok := ownedEvent(c, store)
+		if !ok {
+			return
+		}
+
+		if ev.AcknowledgedAt == "" {
+			ev.AcknowledgedAt = time.Now().Format(time.RFC3339)
+			ev.AcknowledgedBy = c.GetString("user_id")
+			if err := store.SaveEvent(ev); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{
+					"error": err.Error(),
+				})
+				return
+			}
+		}
+
+		ev.State = ev.state(time.Now())
+		c.Set("audit_target", ev.ID)
+		c.JSON(http.StatusOK, gin.H{
+			"status": "success",
+			"data":   ev,
+		})
+	}
+}
+
+type SnoozeRequest struct {
+	Duration string `json:"duration" binding:"required"`
+}
+
+// HandleSnoozeEvent serves POST /api/alerts/:id/events/:event/snooze. The
+// rule stays silent until the snooze ends, then fires again if its
+// condition still holds.
+func HandleSnoozeEvent(store *Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req SnoozeRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+		duration, err := time.ParseDuration(req.Duration)
+		if err != nil || duration <= 0 || duration > MaxSnooze {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": fmt.Sprintf("duration must be a positive Go duration up to %s, e.g. 2h", MaxSnooze),
+			})
+			return
+		}
+
+		rule, ev, ok := ownedEvent(c, store)
+		if !ok {
+			return
+		}
+
+		now := time.Now()
+		until := now.Add(duration).Format(time.RFC3339)
+		ev.SnoozedUntil = until
+		if err := store.SaveEvent(ev); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+		if current, err := time.Parse(time.RFC3339, rule.SnoozedUntil); err != nil || current.Before(now.Add(duration)) {
+			if err := store.snooze(rule.ID, until); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{
+					"error": err.Error(),
+				})
+				return
+			}
+		}
+
+		ev.State = ev.state(now)
+		c.Set("audit_target", ev.ID)
+		c.JSON(http.StatusOK, gin.H{
+			"status": "success",
+			"data":   ev,
+		})
+	}
+}
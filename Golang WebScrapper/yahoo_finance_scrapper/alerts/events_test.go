@@ -0,0 +1,157 @@
+package alerts
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEvents(t *testing.T) {
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer rdb.Close()
+	store := NewStore(rdb)
+
+	start := time.Now().Add(-time.Hour)
+	for i := 0; i < maxEvents+2; i++ {
+		require.NoError(t, store.SaveEvent(&Event{
+			ID:          fmt.Sprintf("e%d", i),
+			RuleID:      "r1",
+			Status:      EventDelivered,
+			TriggeredAt: start.Add(time.Duration(i) * time.Second).Format(time.RFC3339Nano),
+		}))
+	}
+
+	// The two oldest were trimmed
+	first, err := store.GetEvent("r1", "e0")
+	require.NoError(t, err)
+	assert.Nil(t, first)
+
+	events, err := store.Events("r1", "", 3)
+	require.NoError(t, err)
+	require.Len(t, events, 3)
+	assert.Equal(t, fmt.Sprintf("e%d", maxEvents+1), events[0].ID)
+	assert.Equal(t, StateOpen, events[0].State)
+
+	// Updating an event keeps its place in the history
+	latest := events[0]
+	latest.AcknowledgedAt = time.Now().Format(time.RFC3339)
+	require.NoError(t, store.SaveEvent(latest))
+	snoozed := events[1]
+	snoozed.SnoozedUntil = time.Now().Add(time.Hour).Format(time.RFC3339)
+	require.NoError(t, store.SaveEvent(snoozed))
+
+	events, err = store.Events("r1", StateAcknowledged, 10)
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, latest.ID, events[0].ID)
+	events, err = store.Events("r1", StateSnoozed, 10)
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, snoozed.ID, events[0].ID)
+
+	require.NoError(t, store.Delete("r1"))
+	events, err = store.Events("r1", "", 10)
+	require.NoError(t, err)
+	assert.Empty(t, events)
+}
+
+func TestHandleEvents(t *testing.T) {
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer rdb.Close()
+	store := NewStore(rdb)
+
+	rule := &Rule{ID: "r1", UserID: "alice", Type: TypeWatchlistMove, WatchlistID: "w1", MovePercent: 5}
+	require.NoError(t, store.Save(rule))
+	require.NoError(t, store.SaveEvent(&Event{
+		ID: "e1", RuleID: "r1", Status: EventFailed, Error: "smtp down",
+		TriggeredAt: time.Now().Format(time.RFC3339Nano),
+	}))
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set("user_id", c.GetHeader("X-User"))
+	})
+	r.GET("/alerts/:id/events", HandleListEvents(store))
+	r.POST("/alerts/:id/events/:event/ack", HandleAcknowledgeEvent(store))
+	r.POST("/alerts/:id/events/:event/snooze", HandleSnoozeEvent(store))
+	serve := func(method, path, user, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("X-User", user)
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := serve("GET", "/alerts/r1/events", "alice", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"error":"smtp down"`)
+	assert.Equal(t, http.StatusNotFound, serve("GET", "/alerts/r1/events", "bob", "").Code)
+	assert.Equal(t, http.StatusBadRequest, serve("GET", "/alerts/r1/events?state=closed", "alice", "").Code)
+	assert.Equal(t, http.StatusNotFound, serve("POST", "/alerts/r1/events/e2/ack", "alice", "").Code)
+
+	assert.Equal(t, http.StatusBadRequest, serve("POST", "/alerts/r1/events/e1/snooze", "alice", `{"duration":"30d"}`).Code)
+	w = serve("POST", "/alerts/r1/events/e1/snooze", "alice", `{"duration":"2h"}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"state":"snoozed"`)
+
+	// The snooze holds the whole rule back
+	saved, err := store.Get("r1")
+	require.NoError(t, err)
+	assert.False(t, saved.deliverable(time.Now()))
+	assert.True(t, saved.deliverable(time.Now().Add(3*time.Hour)))
+
+	w = serve("POST", "/alerts/r1/events/e1/ack", "alice", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"acknowledged_by":"alice"`)
+	assert.Contains(t, w.Body.String(), `"state":"acknowledged"`)
+}
+
+func TestFiringKeepsSnoozeAndDeletion(t *testing.T) {
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer rdb.Close()
+	store := NewStore(rdb)
+
+	rule := &Rule{ID: "r1", UserID: "alice", Type: TypeWatchlistMove, WatchlistID: "w1", MovePercent: 5}
+	require.NoError(t, store.Save(rule))
+
+	// The engine loaded the rule before the owner snoozed it
+	until := time.Now().Add(time.Hour).Format(time.RFC3339)
+	require.NoError(t, store.snooze("r1", until))
+	fired := time.Now()
+	require.NoError(t, store.setLastFired(rule.ID, fired))
+
+	saved, err := store.Get("r1")
+	require.NoError(t, err)
+	assert.Equal(t, until, saved.SnoozedUntil)
+	assert.Equal(t, fired.Format(time.RFC3339), saved.LastFired)
+
+	// Editing the rule leaves both alone
+	saved.Name = "Tech"
+	require.NoError(t, store.Save(saved))
+	all, err := store.All()
+	require.NoError(t, err)
+	require.Len(t, all, 1)
+	assert.Equal(t, until, all[0].SnoozedUntil)
+
+	// Firing after the rule was deleted doesn't bring it back
+	require.NoError(t, store.Delete("r1"))
+	require.NoError(t, store.setLastFired("r1", fired))
+	gone, err := store.Get("r1")
+	require.NoError(t, err)
+	assert.Nil(t, gone)
+	assert.False(t, mr.Exists(lastFiredKey))
+}
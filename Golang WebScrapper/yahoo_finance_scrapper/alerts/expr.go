@@ -0,0 +1,353 @@
+package alerts
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// ConditionFields are the values a condition can compare, per symbol:
+//
+//	price           last price
+//	change          day change
+//	change_percent  day change in percent
+//	move            size of the day's move in percent, either way
+//	volume          shares traded today
+//	sentiment       mean news sentiment over the last day, -1 to 1; missing
+//	                without news
+//	news            articles mentioning the symbol over the last day
+var ConditionFields = map[string]bool{
+	"price":          true,
+	"change":         true,
+	"change_percent": true,
+	"move":           true,
+	"volume":         true,
+	"sentiment":      true,
+	"news":           true,
+}
+
+// condition is a parsed alert condition such as
+//
+//	price > 150 AND (volume >= 5M OR sentiment < -0.3)
+//
+// AND binds tighter than OR, NOT tighter than both; &&, || and ! are
+// accepted too. Numbers may carry a K, M or B suffix.
+//
+// A comparison on a field missing from the values is unknown rather than
+// false, and stays unknown through NOT, so a condition only matches when
+// the values it depends on are there.
+type condition interface {
+	// eval returns the condition's result and whether it is known.
+	eval(values map[string]float64) (result, known bool)
+}
+
+// matches reports whether cond is known to hold for values.
+func matches(cond condition, values map[string]float64) bool {
+	result, known := cond.eval(values)
+	return known && result
+}
+
+type andCondition struct{ left, right condition }
+type orCondition struct{ left, right condition }
+type notCondition struct{ inner condition }
+
+type comparison struct {
+	field string
+	op    string
+	value float64
+}
+
+func (c andCondition) eval(values map[string]float64) (bool, bool) {
+	left, leftKnown := c.left.eval(values)
+	right, rightKnown := c.right.eval(values)
+	if (leftKnown && !left) || (rightKnown && !right) {
+		return false, true
+	}
+	return true, leftKnown && rightKnown
+}
+
+func (c orCondition) eval(values map[string]float64) (bool, bool) {
+	left, leftKnown := c.left.eval(values)
+	right, rightKnown := c.right.eval(values)
+	if (leftKnown && left) || (rightKnown && right) {
+		return true, true
+	}
+	return false, leftKnown && rightKnown
+}
+
+func (c notCondition) eval(values map[string]float64) (bool, bool) {
+	inner, known := c.inner.eval(values)
+	return !inner, known
+}
+
+func (c comparison) eval(values map[string]float64) (bool, bool) {
+	v, ok := values[c.field]
+	if !ok {
+		return false, false
+	}
+	switch c.op {
+	case ">":
+		return v > c.value, true
+	case ">=":
+		return v >= c.value, true
+	case "<":
+		return v < c.value, true
+	case "<=":
+		return v <= c.value, true
+	case "==":
+		return v == c.value, true
+	case "!=":
+		return v != c.value, true
+	}
+	return false, true
+}
+
+// Conditions are parsed recursively inside request handlers, so both their
+// length and how deeply NOT and parentheses nest are capped.
+const (
+	maxConditionLength = 512
+	maxConditionDepth  = 32
+)
+
+type token struct {
+	text string
+	pos  int
+}
+
+// operators are the multi-character operators; any other operator
+// character is a token of its own, so "&&!(" is "&&", "!" and "(".
+var operators = []string{">=", "<=", "==", "!=", "&&", "||"}
+
+// tokenize splits src into words, numbers, operators and parentheses,
+// remembering each one's column for error messages.
+func tokenize(src string) ([]token, error) {
+	tokens := make([]token, 0)
+	for i := 0; i < len(src); {
+		r := rune(src[i])
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(' || r == ')':
+			tokens = append(tokens, token{text: string(r), pos: i + 1})
+			i++
+		case strings.ContainsRune("<>=!&|", r):
+			text := string(r)
+			for _, op := range operators {
+				if strings.HasPrefix(src[i:], op) {
+					text = op
+					break
+				}
+			}
+			tokens = append(tokens, token{text: text, pos: i + 1})
+			i += len(text)
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '.' || r == '-' || r == '+':
+			start := i
+			i++
+			for i < len(src) && (unicode.IsLetter(rune(src[i])) || unicode.IsDigit(rune(src[i])) || src[i] == '_' || src[i] == '.' || src[i] == '%') {
+				i++
+			}
+			tokens = append(tokens, token{text: src[start:i], pos: start + 1})
+		default:
+			return nil, fmt.Errorf("column %d: unexpected %q", i+1, r)
+		}
+	}
+	return tokens, nil
+}
+
+type conditionParser struct {
+	tokens []token
+	next   int
+	// end is the column just past the source, for errors at its end
+	end int
+	// depth counts the NOTs and groups enclosing the next token
+	depth int
+}
+
+func (p *conditionParser) peek() (token, bool) {
+	if p.next >= len(p.tokens) {
+		return token{pos: p.end}, false
+	}
+	return p.tokens[p.next], true
+}
+
+func (p *conditionParser) errorf(tok token, format string, args ...interface{}) error {
+	return fmt.Errorf("column %d: %s", tok.pos, fmt.Sprintf(format, args...))
+}
+
+// keyword reports whether the next token is one of words, consuming it.
+func (p *conditionParser) keyword(words ...string) bool {
+	tok, ok := p.peek()
+	if !ok {
+		return false
+	}
+	for _, word := range words {
+		if strings.EqualFold(tok.text, word) {
+			p.next++
+			return true
+		}
+	}
+	return false
+}
+
+func (p *conditionParser) parseOr() (condition, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("OR", "||") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = orCondition{left, right}
+	}
+	return left, nil
+}
+
+func (p *conditionParser) parseAnd() (condition, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("AND", "&&") {
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = andCondition{left, right}
+	}
+	return left, nil
+}
+
+// nest enters a NOT or group opened at tok, failing past maxConditionDepth.
+func (p *conditionParser) nest(tok token) error {
+	p.depth++
+	if p.depth > maxConditionDepth {
+		return p.errorf(tok, "condition nests deeper than %d levels", maxConditionDepth)
+	}
+	return nil
+}
+
+func (p *conditionParser) parseUnary() (condition, error) {
+	tok, _ := p.peek()
+	if p.keyword("NOT", "!") {
+		if err := p.nest(tok); err != nil {
+			return nil, err
+		}
+		defer func() { p.depth-- }()
+		inner, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return notCondition{inner}, nil
+	}
+	if p.keyword("(") {
+		if err := p.nest(tok); err != nil {
+			return nil, err
+		}
+		defer func() { p.depth-- }()
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if !p.keyword(")") {
+			tok, _ := p.peek()
+			return nil, p.errorf(tok, "expected ) to close the group")
+		}
+		return inner, nil
+	}
+	return p.parseComparison()
+}
+
+func (p *conditionParser) parseComparison() (condition, error) {
+	tok, ok := p.peek()
+	if !ok {
+		return nil, p.errorf(tok, "expected a field, got the end of the condition")
+	}
+	field := strings.ToLower(tok.text)
+	if !ConditionFields[field] {
+		return nil, p.errorf(tok, "unknown field %q, expected one of: %s", tok.text, strings.Join(conditionFieldNames(), ", "))
+	}
+	p.next++
+
+	opTok, ok := p.peek()
+	switch opTok.text {
+	case ">", ">=", "<", "<=", "==", "!=":
+		p.next++
+	case "=":
+		opTok.text = "=="
+		p.next++
+	default:
+		if !ok {
+			return nil, p.errorf(opTok, "expected a comparison after %s, got the end of the condition", field)
+		}
+		return nil, p.errorf(opTok, "expected a comparison (>, >=, <, <=, ==, !=) after %s, got %q", field, opTok.text)
+	}
+
+	valueTok, ok := p.peek()
+	if !ok {
+		return nil, p.errorf(valueTok, "expected a number after %s %s, got the end of the condition", field, opTok.text)
+	}
+	value, err := parseConditionNumber(valueTok.text)
+	if err != nil {
+		return nil, p.errorf(valueTok, "expected a number after %s %s, got %q", field, opTok.text, valueTok.text)
+	}
+	p.next++
+	return comparison{field: field, op: opTok.text, value: value}, nil
+}
+
+// parseConditionNumber reads numbers like 150, -0.3, 5%, 2.5M or 1B.
+func parseConditionNumber(text string) (float64, error) {
+	multiplier := 1.0
+	text = strings.TrimSuffix(text, "%")
+	if n := len(text); n > 0 {
+		switch text[n-1] {
+		case 'k', 'K':
+			multiplier, text = 1e3, text[:n-1]
+		case 'm', 'M':
+			multiplier, text = 1e6, text[:n-1]
+		case 'b', 'B':
+			multiplier, text = 1e9, text[:n-1]
+		}
+	}
+	value, err := strconv.ParseFloat(text, 64)
+	if err != nil {
+		return 0, err
+	}
+	return value * multiplier, nil
+}
+
+func conditionFieldNames() []string {
+	names := make([]string, 0, len(ConditionFields))
+	for name := range ConditionFields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// parseCondition parses src, reporting the column of the first problem.
+func parseCondition(src string) (condition, error) {
+	if strings.TrimSpace(src) == "" {
+		return nil, fmt.Errorf("condition is empty")
+	}
+	if len(src) > maxConditionLength {
+		return nil, fmt.Errorf("condition is longer than %d characters", maxConditionLength)
+	}
+	tokens, err := tokenize(src)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &conditionParser{tokens: tokens, end: len(src) + 1}
+	cond, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if tok, ok := p.peek(); ok {
+		return nil, p.errorf(tok, "expected AND, OR or the end of the condition, got %q", tok.text)
+	}
+	return cond, nil
+}
@@ -0,0 +1,111 @@
+package alerts
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCondition(t *testing.T) {
+	values := map[string]float64{"price": 190, "volume": 6e6, "sentiment": 0.4, "move": 2}
+
+	for src, want := range map[string]bool{
+		"price > 150":                                       true,
+		"price >= 190 AND volume >= 5M":                     true,
+		"price > 150 and volume > 10m":                      false,
+		"price > 200 OR sentiment > 0.2":                    true,
+		"price > 150 AND (volume > 10M OR sentiment > 0.3)": true,
+		// AND binds tighter than OR
+		"price > 200 AND volume > 1M OR move >= 2":   true,
+		"price > 200 AND (volume > 1M OR move >= 2)": false,
+		"NOT price < 100":                   true,
+		"!(sentiment <= -0.3) && move < 5%": true,
+		"price == 190 || price != 190":      true,
+		"price = 150":                       false,
+		"volume > 5.5M":                     true,
+		"volume < 1B":                       true,
+		"news > 0":                          false,
+		"price > 150 &&!(volume < 1M)":      true,
+		"price>150||!(move<1)":              true,
+	} {
+		cond, err := parseCondition(src)
+		require.NoError(t, err, src)
+		assert.Equal(t, want, matches(cond, values), src)
+	}
+}
+
+func TestMissingValuesNeverMatch(t *testing.T) {
+	// No news, so no sentiment
+	values := map[string]float64{"price": 190, "news": 0}
+
+	for src, want := range map[string]bool{
+		"sentiment < 0.1":                       false,
+		"sentiment >= 0.1":                      false,
+		"NOT sentiment < 0.1":                   false,
+		"price > 150 AND sentiment < 0.1":       false,
+		"price > 150 OR sentiment < 0.1":        true,
+		"price > 200 AND sentiment < 0.1":       false,
+		"NOT (price > 200 AND sentiment < 0.1)": true,
+	} {
+		cond, err := parseCondition(src)
+		require.NoError(t, err, src)
+		assert.Equal(t, want, matches(cond, values), src)
+	}
+}
+
+func TestParseConditionErrors(t *testing.T) {
+	for src, want := range map[string]string{
+		"":                           "condition is empty",
+		"pric > 150":                 `column 1: unknown field "pric"`,
+		"price 150":                  `column 7: expected a comparison (>, >=, <, <=, ==, !=) after price, got "150"`,
+		"price >":                    "column 8: expected a number after price >, got the end of the condition",
+		"price > lots":               `column 9: expected a number after price >, got "lots"`,
+		"price > 150 AND":            "column 16: expected a field, got the end of the condition",
+		"(price > 150":               "column 13: expected ) to close the group",
+		"price > 150 volume > 1M":    `column 13: expected AND, OR or the end of the condition, got "volume"`,
+		"price > 150 AND volume ~ 1": "column 24: unexpected '~'",
+	} {
+		_, err := parseCondition(src)
+		require.Error(t, err, src)
+		assert.Contains(t, err.Error(), want, src)
+	}
+}
+
+func TestParseConditionLimits(t *testing.T) {
+	nested := func(depth int, open, close string) string {
+		return strings.Repeat(open, depth) + "price > 1" + strings.Repeat(close, depth)
+	}
+
+	_, err := parseCondition(nested(maxConditionDepth, "(", ")"))
+	assert.NoError(t, err)
+	_, err = parseCondition(nested(maxConditionDepth, "NOT ", ""))
+	assert.NoError(t, err)
+	_, err = parseCondition(nested(16, "NOT (", ")"))
+	assert.NoError(t, err)
+
+	_, err = parseCondition(nested(maxConditionDepth+1, "(", ")"))
+	assert.ErrorContains(t, err, "nests deeper than 32 levels")
+	_, err = parseCondition(nested(maxConditionDepth+1, "NOT ", ""))
+	assert.ErrorContains(t, err, "nests deeper than 32 levels")
+	_, err = parseCondition(nested(17, "NOT (", ")"))
+	assert.ErrorContains(t, err, "nests deeper than 32 levels")
+
+	// Siblings don't add up to depth
+	_, err = parseCondition(strings.TrimSuffix(strings.Repeat("(price > 1) AND ", 20), " AND "))
+	assert.NoError(t, err)
+
+	_, err = parseCondition(strings.Repeat("(", 1<<20))
+	assert.ErrorContains(t, err, "longer than 512 characters")
+}
+
+func TestValidateConditionRules(t *testing.T) {
+	assert.NoError(t, (&Rule{Type: TypeCondition, Symbols: []string{"AAPL"}, Condition: "price > 150"}).Validate())
+	assert.NoError(t, (&Rule{Type: TypeCondition, WatchlistID: "w1", Condition: "move >= 5 AND sentiment < 0"}).Validate())
+
+	assert.Error(t, (&Rule{Type: TypeCondition, Condition: "price > 150"}).Validate())
+	err := (&Rule{Type: TypeCondition, Symbols: []string{"AAPL"}, Condition: "price >"}).Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid condition: column 8")
+}
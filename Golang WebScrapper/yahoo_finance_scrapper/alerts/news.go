@@ -0,0 +1,133 @@
+package alerts
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"go-webscraper/notify"
+	"go-webscraper/scraper"
+)
+
+// matchArticle reports which of the rule's symbols and keywords the article
+// mentions. Symbols must appear as whole words (optionally $-prefixed) so
+// short tickers don't match inside ordinary words; keywords match
+// case-insensitively anywhere.
+func matchArticle(rule *Rule, article scraper.Article) []string {
+	text := article.Title + " " + article.Snippet
+	lower := strings.ToLower(text)
+
+	matched := make([]string, 0)
+	for _, symbol := range rule.Symbols {
+		if mentionsSymbol(text, symbol) {
+			matched = append(matched, symbol)
+		}
+	}
+	for _, keyword := range rule.Keywords {
+		if strings.Contains(lower, strings.ToLower(keyword)) {
+			matched = append(matched, keyword)
+		}
+	}
+	return matched
+}
+
+func formatNewsAlert(rule *Rule, article scraper.Article, terms []string) notify.Message {
+	name := rule.Name
+	if name == "" {
+		name = strings.Join(append(append([]string{}, rule.Symbols...), rule.Keywords...), ", ")
+	}
+
+	return notify.Message{
+		Subject: fmt.Sprintf("GoFinance news alert: %s", name),
+		Body: fmt.Sprintf("%s\n\n%s\n\nMatched: %s\n%s",
+			article.Title, article.Snippet, strings.Join(terms, ", "), article.Link),
+		Data: article,
+	}
+}
+
+// Publish implements the refresh sink. Only news refreshes are evaluated;
+// each article notifies a rule's owner at most once, and articles matched
+// during a rule's cooldown are dropped. The articles also feed the
+// sentiment of the symbols condition alerts watch.
+func (e *Engine) Publish(source string, data interface{}) {
+	articles, ok := data.([]scraper.Article)
+	if source != "news" || !ok {
+		return
+	}
+	now := time.Now()
+
+	if symbols, err := e.conditionSymbols(); err != nil {
+		log.Printf("Error loading condition alerts: %v", err)
+	} else {
+		e.recordMentions(symbols, articles, now)
+	}
+
+	rules, err := e.store.ByType(TypeNews)
+	if err != nil {
+		log.Printf("Error loading news alerts: %v", err)
+		return
+	}
+
+	for _, rule := range rules {
+		if !rule.deliverable(now) {
+			continue
+		}
+		fired := false
+		for _, article := range articles {
+			terms := matchArticle(rule, article)
+			if len(terms) == 0 {
+				continue
+			}
+
+			first, err := e.store.markSeen(rule.ID, article.Link)
+			if err != nil {
+				log.Printf("Error recording alert %s for %s: %v", rule.ID, article.Link, err)
+				continue
+			}
+			if !first {
+				continue
+			}
+			cooldown := rule.cooldown()
+			if cooldown > 0 {
+				claimed, err := e.store.claimCooldown(rule.ID, "", cooldown)
+				if err != nil {
+					log.Printf("Error starting cooldown of alert %s: %v", rule.ID, err)
+					e.unclaimArticle(rule, article.Link, false)
+					continue
+				}
+				if !claimed {
+					continue
+				}
+			}
+
+			if err := e.deliver(rule, formatNewsAlert(rule, article, terms), now); err != nil {
+				log.Printf("Error delivering alert %s to %s: %v", rule.ID, rule.UserID, err)
+				e.unclaimArticle(rule, article.Link, cooldown > 0)
+				continue
+			}
+			fired = true
+		}
+
+		if fired {
+			if err := e.store.setLastFired(rule.ID, now); err != nil {
+				log.Printf("Error recording alert %s as fired: %v", rule.ID, err)
+			}
+		}
+	}
+}
+
+// unclaimArticle forgets that rule fired for link, and ends the cooldown
+// the article started, so a failed notification is tried again when the
+// article next comes in.
+func (e *Engine) unclaimArticle(rule *Rule, link string, cooldown bool) {
+	if err := e.store.unmarkSeen(rule.ID, link); err != nil {
+		log.Printf("Error releasing alert %s for %s: %v", rule.ID, link, err)
+	}
+	if !cooldown {
+		return
+	}
+	if err := e.store.releaseCooldown(rule.ID, ""); err != nil {
+		log.Printf("Error ending cooldown of alert %s: %v", rule.ID, err)
+	}
+}
@@ -0,0 +1,32 @@
+package alerts
+
+import (
+	"testing"
+
+	"go-webscraper/scraper"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatchArticle(t *testing.T) {
+	rule := &Rule{
+		Type:     TypeNews,
+		Symbols:  []string{"NVDA", "AI"},
+		Keywords: []string{"rate cut"},
+	}
+
+	t.Run("Symbol Word Boundary", func(t *testing.T) {
+		article := scraper.Article{Title: "$NVDA rallies after earnings", Snippet: "Analysts said demand was strong"}
+		assert.Equal(t, []string{"NVDA"}, matchArticle(rule, article))
+	})
+
+	t.Run("Keyword Case Insensitive", func(t *testing.T) {
+		article := scraper.Article{Title: "Markets price in a Rate Cut", Snippet: ""}
+		assert.Equal(t, []string{"rate cut"}, matchArticle(rule, article))
+	})
+
+	t.Run("No Match", func(t *testing.T) {
+		article := scraper.Article{Title: "Oil prices slip", Snippet: "Crude falls on supply"}
+		assert.Empty(t, matchArticle(rule, article))
+	})
+}
@@ -0,0 +1,118 @@
+package alerts
+
+import (
+	"regexp"
+	"strings"
+	"time"
+
+	"go-webscraper/scraper"
+)
+
+// sentimentWindow is how long an article counts towards a symbol's news
+// sentiment and news count.
+const sentimentWindow = 24 * time.Hour
+
+// Headline words that lean one way. The lexicon is deliberately small:
+// it only has to tell a rally from a sell-off well enough for an alert.
+var (
+	positiveWords = wordSet("beat", "beats", "surge", "surges", "soar", "soars", "rally", "rallies",
+		"jump", "jumps", "gain", "gains", "rise", "rises", "record", "upgrade", "upgraded", "upgrades",
+		"outperform", "bullish", "strong", "growth", "raises", "boost", "boosts", "profit", "approval", "wins")
+	negativeWords = wordSet("miss", "misses", "plunge", "plunges", "slump", "slumps", "fall", "falls",
+		"drop", "drops", "sink", "sinks", "downgrade", "downgraded", "downgrades", "underperform",
+		"bearish", "weak", "cut", "cuts", "loss", "losses", "lawsuit", "probe", "recall", "layoffs", "warns")
+)
+
+var wordPattern = regexp.MustCompile(`[a-z]+`)
+
+func wordSet(words ...string) map[string]bool {
+	set := make(map[string]bool, len(words))
+	for _, word := range words {
+		set[word] = true
+	}
+	return set
+}
+
+// scoreSentiment rates text from -1 (all negative words) to 1 (all
+// positive), 0 when it has neither.
+func scoreSentiment(text string) float64 {
+	var positive, negative int
+	for _, word := range wordPattern.FindAllString(strings.ToLower(text), -1) {
+		if positiveWords[word] {
+			positive++
+		} else if negativeWords[word] {
+			negative++
+		}
+	}
+	if positive+negative == 0 {
+		return 0
+	}
+	return float64(positive-negative) / float64(positive+negative)
+}
+
+// mentionsSymbol reports whether text names symbol as a whole word,
+// optionally $-prefixed, so short tickers don't match inside words.
+func mentionsSymbol(text, symbol string) bool {
+	pattern := `(^|[^A-Za-z0-9])\$?` + regexp.QuoteMeta(symbol) + `($|[^A-Za-z0-9])`
+	ok, _ := regexp.MatchString(pattern, text)
+	return ok
+}
+
+// mention is one article about a symbol.
+type mention struct {
+	at    time.Time
+	score float64
+}
+
+// recordMentions scores the articles that mention each of symbols,
+// counting each article once however often news is refreshed, and
+// forgets those older than sentimentWindow.
+func (e *Engine) recordMentions(symbols []string, articles []scraper.Article, now time.Time) {
+	e.mentionsMu.Lock()
+	defer e.mentionsMu.Unlock()
+
+	for _, symbol := range symbols {
+		for _, article := range articles {
+			text := article.Title + " " + article.Snippet
+			if !mentionsSymbol(text, symbol) {
+				continue
+			}
+			if e.mentions[symbol] == nil {
+				e.mentions[symbol] = make(map[string]mention)
+			}
+			if _, seen := e.mentions[symbol][article.Link]; !seen {
+				e.mentions[symbol][article.Link] = mention{at: now, score: scoreSentiment(text)}
+			}
+		}
+	}
+	for symbol, articles := range e.mentions {
+		for link, m := range articles {
+			if now.Sub(m.at) > sentimentWindow {
+				delete(articles, link)
+			}
+		}
+		if len(articles) == 0 {
+			delete(e.mentions, symbol)
+		}
+	}
+}
+
+// newsSentiment returns symbol's mean sentiment and article count over
+// the last sentimentWindow.
+func (e *Engine) newsSentiment(symbol string, now time.Time) (float64, int) {
+	e.mentionsMu.Lock()
+	defer e.mentionsMu.Unlock()
+
+	var total float64
+	var count int
+	for _, m := range e.mentions[symbol] {
+		if now.Sub(m.at) <= sentimentWindow {
+			total += m.score
+			count++
+		}
+	}
+	if count == 0 {
+		return 0, 0
+	}
+	return total / float64(count), count
+}
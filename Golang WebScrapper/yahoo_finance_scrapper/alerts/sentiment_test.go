@@ -0,0 +1,54 @@
+package alerts
+
+import (
+	"testing"
+	"time"
+
+	"go-webscraper/scraper"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScoreSentiment(t *testing.T) {
+	assert.Equal(t, 1.0, scoreSentiment("Apple beats estimates as iPhone sales surge"))
+	assert.Equal(t, -1.0, scoreSentiment("Tesla shares plunge after analyst downgrade"))
+	assert.Equal(t, 0.0, scoreSentiment("Nvidia gains despite weak guidance"))
+	assert.Equal(t, 0.0, scoreSentiment("Microsoft to hold annual meeting"))
+}
+
+func TestMatchCondition(t *testing.T) {
+	e := NewEngine(nil, nil, nil)
+	now := time.Date(2024, 7, 10, 14, 0, 0, 0, time.UTC)
+	articles := []scraper.Article{
+		{Title: "AAPL beats estimates", Link: "https://example.com/1"},
+		{Title: "Apple ($AAPL) shares surge to a record", Link: "https://example.com/2"},
+		{Title: "TSLA shares slump", Link: "https://example.com/3"},
+	}
+	e.recordMentions([]string{"AAPL", "TSLA"}, articles, now.Add(-time.Hour))
+	// A repeated refresh doesn't count the same article twice
+	e.recordMentions([]string{"AAPL", "TSLA"}, articles[:1], now)
+
+	sentiment, news := e.newsSentiment("AAPL", now)
+	assert.Equal(t, 1.0, sentiment)
+	assert.Equal(t, 2, news)
+
+	quotes := map[string]scraper.StockData{
+		"AAPL": {Symbol: "AAPL", Price: 190, ChangePerc: 3, Volume: 8e6},
+		"TSLA": {Symbol: "TSLA", Price: 240, ChangePerc: -4, Volume: 9e6},
+		"NVDA": {Symbol: "NVDA", Price: 120, ChangePerc: 5, Volume: 9e6, Suspect: true},
+	}
+	rule := &Rule{Type: TypeCondition, Condition: "move >= 3 AND volume > 5M AND sentiment > 0.5"}
+	matched := e.matchCondition(rule, []string{"AAPL", "TSLA", "NVDA", "AMZN"}, quotes, now)
+	assert.Len(t, matched, 1)
+	assert.Equal(t, "AAPL", matched[0].Symbol)
+
+	rule.Condition = "sentiment < 0 AND news >= 1"
+	matched = e.matchCondition(rule, []string{"AAPL", "TSLA"}, quotes, now)
+	assert.Len(t, matched, 1)
+	assert.Equal(t, "TSLA", matched[0].Symbol)
+
+	// Articles fall out of the window a day after they were first seen
+	e.recordMentions(nil, nil, now.Add(sentimentWindow))
+	_, news = e.newsSentiment("AAPL", now.Add(sentimentWindow))
+	assert.Equal(t, 0, news)
+}
@@ -0,0 +1,275 @@
+package alerts
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"go-webscraper/notify"
+	"go-webscraper/scraper"
+	"go-webscraper/watchlist"
+)
+
+// watchlistRule pairs a watchlist alert with the members it watches.
+type watchlistRule struct {
+	rule *Rule
+	list *watchlist.Watchlist
+}
+
+// watchlistRules loads every watchlist and condition alert with its
+// watchlist, skipping alerts whose watchlist was deleted or changed hands.
+// Condition alerts on plain symbols get a watchlist of those symbols.
+func (e *Engine) watchlistRules() ([]watchlistRule, error) {
+	rules, err := e.store.All()
+	if err != nil {
+		return nil, err
+	}
+
+	watched := make([]watchlistRule, 0)
+	for _, rule := range rules {
+		if rule.Type != TypeWatchlistMove && rule.Type != TypeWatchlistMover && rule.Type != TypeCondition {
+			continue
+		}
+		if rule.Type == TypeCondition && rule.WatchlistID == "" {
+			list := &watchlist.Watchlist{Name: strings.Join(rule.Symbols, ", "), UserID: rule.UserID, Symbols: rule.Symbols}
+			watched = append(watched, watchlistRule{rule: rule, list: list})
+			continue
+		}
+		list, err := e.watchlists.Get(rule.WatchlistID)
+		if err != nil {
+			return nil, err
+		}
+		if list == nil || list.UserID != rule.UserID {
+			continue
+		}
+		watched = append(watched, watchlistRule{rule: rule, list: list})
+	}
+	return watched, nil
+}
+
+// Watched returns the symbols whose quotes and the movers categories that
+// watchlist alerts are evaluated against, so a scheduled scrape fetches
+// only what some alert needs.
+func (e *Engine) Watched() ([]string, []string, error) {
+	watched, err := e.watchlistRules()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	symbols := make(map[string]bool)
+	categories := make(map[string]bool)
+	for _, w := range watched {
+		switch w.rule.Type {
+		case TypeWatchlistMove, TypeCondition:
+			for _, symbol := range w.list.Symbols {
+				symbols[symbol] = true
+			}
+		case TypeWatchlistMover:
+			categories[w.rule.Category] = true
+		}
+	}
+	return sortedKeys(symbols), sortedKeys(categories), nil
+}
+
+// conditionSymbols returns the symbols condition alerts watch, whose news
+// sentiment the engine tracks.
+func (e *Engine) conditionSymbols() ([]string, error) {
+	watched, err := e.watchlistRules()
+	if err != nil {
+		return nil, err
+	}
+
+	symbols := make(map[string]bool)
+	for _, w := range watched {
+		if w.rule.Type == TypeCondition {
+			for _, symbol := range w.list.Symbols {
+				symbols[symbol] = true
+			}
+		}
+	}
+	return sortedKeys(symbols), nil
+}
+
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// matchMoves returns the members whose quote moved at least the rule's
+// MovePercent either way.
+func matchMoves(rule *Rule, members []string, quotes map[string]scraper.StockData) []scraper.StockData {
+	matched := make([]scraper.StockData, 0)
+	for _, symbol := range members {
+		quote, ok := quotes[symbol]
+		if ok && !quote.Suspect && math.Abs(quote.ChangePerc) >= rule.MovePercent {
+			matched = append(matched, quote)
+		}
+	}
+	return matched
+}
+
+// matchMovers returns the members listed in a movers category.
+func matchMovers(members []string, movers []scraper.StockData) []scraper.StockData {
+	listed := make(map[string]scraper.StockData, len(movers))
+	for _, stock := range movers {
+		listed[stock.Symbol] = stock
+	}
+
+	matched := make([]scraper.StockData, 0)
+	for _, symbol := range members {
+		if stock, ok := listed[symbol]; ok {
+			matched = append(matched, stock)
+		}
+	}
+	return matched
+}
+
+// conditionValues are the fields a condition sees for quote. Sentiment is
+// left out when there's no news to take it from.
+func (e *Engine) conditionValues(quote scraper.StockData, now time.Time) map[string]float64 {
+	sentiment, news := e.newsSentiment(quote.Symbol, now)
+	values := map[string]float64{
+		"price":          quote.Price,
+		"change":         quote.Change,
+		"change_percent": quote.ChangePerc,
+		"move":           math.Abs(quote.ChangePerc),
+		"volume":         float64(quote.Volume),
+		"news":           float64(news),
+	}
+	if news > 0 {
+		values["sentiment"] = sentiment
+	}
+	return values
+}
+
+// matchCondition returns the members whose quote meets the rule's
+// condition. A condition that no longer parses matches nothing.
+func (e *Engine) matchCondition(rule *Rule, members []string, quotes map[string]scraper.StockData, now time.Time) []scraper.StockData {
+	matched := make([]scraper.StockData, 0)
+	cond, err := parseCondition(rule.Condition)
+	if err != nil {
+		log.Printf("Skipping alert %s: invalid condition: %v", rule.ID, err)
+		return matched
+	}
+	for _, symbol := range members {
+		quote, ok := quotes[symbol]
+		if ok && !quote.Suspect && matches(cond, e.conditionValues(quote, now)) {
+			matched = append(matched, quote)
+		}
+	}
+	return matched
+}
+
+func formatWatchlistAlert(rule *Rule, list *watchlist.Watchlist, stocks []scraper.StockData) notify.Message {
+	name := rule.Name
+	if name == "" {
+		name = list.Name
+	}
+
+	lines := make([]string, 0, len(stocks))
+	for _, stock := range stocks {
+		line := fmt.Sprintf("%s %+.2f%% at %.2f", stock.Symbol, stock.ChangePerc, stock.Price)
+		switch rule.Type {
+		case TypeWatchlistMover:
+			line = fmt.Sprintf("%s entered %s (%+.2f%%)", stock.Symbol, strings.ReplaceAll(rule.Category, "_", " "), stock.ChangePerc)
+		case TypeCondition:
+			line = fmt.Sprintf("%s met %s: %.2f (%+.2f%%), volume %d", stock.Symbol, rule.Condition, stock.Price, stock.ChangePerc, stock.Volume)
+		}
+		lines = append(lines, line)
+	}
+
+	return notify.Message{
+		Subject: fmt.Sprintf("GoFinance watchlist alert: %s", name),
+		Body:    strings.Join(lines, "\n"),
+		Data:    stocks,
+	}
+}
+
+// EvaluateWatchlists checks watchlist and condition alerts against
+// freshly scraped quotes and movers lists, keyed by category. A member
+// fires a rule at most once per market day, or as the rule's cooldown and
+// re-arming allow, and one message covers every member that fired
+// together. A message that fails to deliver is tried again on the next
+// evaluation.
+func (e *Engine) EvaluateWatchlists(quotes []scraper.StockData, movers map[string][]scraper.StockData, now time.Time) {
+	watched, err := e.watchlistRules()
+	if err != nil {
+		log.Printf("Error loading watchlist alerts: %v", err)
+		return
+	}
+
+	bySymbol := make(map[string]scraper.StockData, len(quotes))
+	for _, quote := range quotes {
+		bySymbol[quote.Symbol] = quote
+	}
+	day := scraper.MarketMidnight(now).Format("2006-01-02")
+
+	for _, w := range watched {
+		var matched []scraper.StockData
+		listed := make(map[string]bool)
+		switch w.rule.Type {
+		case TypeWatchlistMove:
+			matched = matchMoves(w.rule, w.list.Symbols, bySymbol)
+		case TypeWatchlistMover:
+			list, ok := movers[w.rule.Category]
+			if !ok {
+				continue
+			}
+			for _, stock := range list {
+				listed[stock.Symbol] = true
+			}
+			matched = matchMovers(w.list.Symbols, list)
+		case TypeCondition:
+			matched = e.matchCondition(w.rule, w.list.Symbols, bySymbol, now)
+		}
+
+		if w.rule.Rearm {
+			matched, err = e.armed(w.rule, matched, bySymbol, listed, now)
+			if err != nil {
+				log.Printf("Error re-arming alert %s: %v", w.rule.ID, err)
+				continue
+			}
+		}
+		if !w.rule.deliverable(now) {
+			continue
+		}
+
+		fresh := make([]scraper.StockData, 0, len(matched))
+		symbols := make([]string, 0, len(matched))
+		for _, stock := range matched {
+			first, err := e.claim(w.rule, stock.Symbol, day)
+			if err != nil {
+				log.Printf("Error recording alert %s for %s: %v", w.rule.ID, stock.Symbol, err)
+				continue
+			}
+			if first {
+				fresh = append(fresh, stock)
+				symbols = append(symbols, stock.Symbol)
+			}
+		}
+		if len(fresh) == 0 {
+			continue
+		}
+
+		if err := e.deliver(w.rule, formatWatchlistAlert(w.rule, w.list, fresh), now); err != nil {
+			log.Printf("Error delivering alert %s to %s: %v", w.rule.ID, w.rule.UserID, err)
+			e.release(w.rule, day, symbols...)
+			continue
+		}
+		if w.rule.Rearm {
+			if err := e.store.markFired(w.rule.ID, symbols...); err != nil {
+				log.Printf("Error recording alert %s as fired: %v", w.rule.ID, err)
+			}
+		}
+		if err := e.store.setLastFired(w.rule.ID, now); err != nil {
+			log.Printf("Error recording alert %s as fired: %v", w.rule.ID, err)
+		}
+	}
+}
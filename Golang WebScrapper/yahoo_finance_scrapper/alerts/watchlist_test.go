@@ -0,0 +1,56 @@
+package alerts
+
+import (
+	"testing"
+
+	"go-webscraper/scraper"
+	"go-webscraper/watchlist"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateWatchlistRules(t *testing.T) {
+	assert.NoError(t, (&Rule{Type: TypeWatchlistMove, WatchlistID: "w1", MovePercent: 5}).Validate())
+	assert.NoError(t, (&Rule{Type: TypeWatchlistMover, WatchlistID: "w1", Category: "gainers"}).Validate())
+
+	assert.Error(t, (&Rule{Type: TypeWatchlistMove, MovePercent: 5}).Validate())
+	assert.Error(t, (&Rule{Type: TypeWatchlistMove, WatchlistID: "w1"}).Validate())
+	assert.Error(t, (&Rule{Type: TypeWatchlistMover, WatchlistID: "w1", Category: "52_week_highs"}).Validate())
+}
+
+func TestMatchMoves(t *testing.T) {
+	rule := &Rule{Type: TypeWatchlistMove, MovePercent: 5}
+	quotes := map[string]scraper.StockData{
+		"AAPL": {Symbol: "AAPL", ChangePerc: 5.2},
+		"MSFT": {Symbol: "MSFT", ChangePerc: -6},
+		"NVDA": {Symbol: "NVDA", ChangePerc: 4.9},
+		"GME":  {Symbol: "GME", ChangePerc: 80, Suspect: true},
+		"TSLA": {Symbol: "TSLA", ChangePerc: 9},
+	}
+
+	matched := matchMoves(rule, []string{"AAPL", "MSFT", "NVDA", "GME", "AMZN"}, quotes)
+	assert.Len(t, matched, 2)
+	assert.Equal(t, "AAPL", matched[0].Symbol)
+	assert.Equal(t, "MSFT", matched[1].Symbol)
+}
+
+func TestMatchMovers(t *testing.T) {
+	gainers := []scraper.StockData{{Symbol: "SMCI", ChangePerc: 12}, {Symbol: "AAPL", ChangePerc: 7}}
+
+	matched := matchMovers([]string{"AAPL", "MSFT"}, gainers)
+	assert.Len(t, matched, 1)
+	assert.Equal(t, "AAPL", matched[0].Symbol)
+}
+
+func TestFormatWatchlistAlert(t *testing.T) {
+	list := &watchlist.Watchlist{Name: "Tech"}
+	stocks := []scraper.StockData{{Symbol: "AAPL", Price: 190.5, ChangePerc: 5.25}}
+
+	msg := formatWatchlistAlert(&Rule{Type: TypeWatchlistMove, MovePercent: 5}, list, stocks)
+	assert.Equal(t, "GoFinance watchlist alert: Tech", msg.Subject)
+	assert.Equal(t, "AAPL +5.25% at 190.50", msg.Body)
+
+	msg = formatWatchlistAlert(&Rule{Type: TypeWatchlistMover, Category: "gainers", Name: "Breakouts"}, list, stocks)
+	assert.Equal(t, "GoFinance watchlist alert: Breakouts", msg.Subject)
+	assert.Equal(t, "AAPL entered gainers (+5.25%)", msg.Body)
+}
@@ -0,0 +1,184 @@
+package audit
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+// auditKey is a sorted set of JSON entries scored by their Unix time in
+// milliseconds, so a ?since= query is a single range read.
+const auditKey = "audit:log"
+
+type Entry struct {
+	ID        string    `json:"id"`
+	Timestamp time.Time `json:"timestamp"`
+	Actor     string    `json:"actor"`
+	ClientIP  string    `json:"client_ip"`
+	Action    string    `json:"action"`
+	Target    string    `json:"target,omitempty"`
+	Method    string    `json:"method"`
+	Path      string    `json:"path"`
+	Status    int       `json:"status"`
+}
+
+type Logger struct {
+	redis     *redis.Client
+	ctx       context.Context
+	retainFor time.Duration
+}
+
+func NewLogger(rdb *redis.Client, retainFor time.Duration) *Logger {
+	if retainFor == 0 {
+		retainFor = 90 * 24 * time.Hour
+	}
+
+	return &Logger{
+		redis:     rdb,
+		ctx:       context.Background(),
+		retainFor: retainFor,
+	}
+}
+
+// Log stores entry and drops anything older than the retention window.
+func (l *Logger) Log(entry Entry) error {
+	if entry.ID == "" {
+		entry.ID = randomID()
+	}
+	if entry.Timestamp.IsZero() {
+		entry.Timestamp = time.Now().UTC()
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	pipe := l.redis.TxPipeline()
+	pipe.ZAdd(l.ctx, auditKey, redis.Z{Score: float64(entry.Timestamp.UnixMilli()), Member: data})
+	cutoff := time.Now().Add(-l.retainFor).UnixMilli()
+	pipe.ZRemRangeByScore(l.ctx, auditKey, "-inf", "("+strconv.FormatInt(cutoff, 10))
+	_, err = pipe.Exec(l.ctx)
+	return err
+}
+
+// Since returns up to limit entries at or after since, newest first.
+func (l *Logger) Since(since time.Time, limit int64) ([]Entry, error) {
+	values, err := l.redis.ZRevRangeByScore(l.ctx, auditKey, &redis.ZRangeBy{
+		Min:   strconv.FormatInt(since.UnixMilli(), 10),
+		Max:   "+inf",
+		Count: limit,
+	}).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]Entry, 0, len(values))
+	for _, value := range values {
+		var entry Entry
+		if err := json.Unmarshal([]byte(value), &entry); err != nil {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+func randomID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// actor names the caller: the authenticated user when there is one,
+// otherwise whatever identity earlier middleware set as "actor".
+func actor(c *gin.Context) string {
+	if userID := c.GetString("user_id"); userID != "" {
+		return "user:" + userID
+	}
+	if name := c.GetString("actor"); name != "" {
+		return name
+	}
+	return "anonymous"
+}
+
+// Record logs action once the handler has finished, as long as it
+// succeeded. The target is taken from the first non-empty URL parameter.
+func Record(logger *Logger, action string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		status := c.Writer.Status()
+		if status >= http.StatusBadRequest {
+			return
+		}
+
+		entry := Entry{
+			Actor:    actor(c),
+			ClientIP: c.ClientIP(),
+			Action:   action,
+			Method:   c.Request.Method,
+			Path:     c.Request.URL.Path,
+			Status:   status,
+		}
+		for _, param := range c.Params {
+			if param.Value != "" {
+				entry.Target = param.Value
+				break
+			}
+		}
+		if target := c.GetString("audit_target"); target != "" {
+			entry.Target = target
+		}
+
+		if err := logger.Log(entry); err != nil {
+			log.Printf("Error writing audit entry for %s: %v", action, err)
+		}
+	}
+}
+
+func HandleListAudit(logger *Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		since := time.Now().Add(-24 * time.Hour)
+		if param := c.Query("since"); param != "" {
+			parsed, err := time.Parse(time.RFC3339, param)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{
+					"error": "since must be an RFC3339 timestamp",
+				})
+				return
+			}
+			since = parsed
+		}
+
+		limit, err := strconv.ParseInt(c.DefaultQuery("limit", "100"), 10, 64)
+		if err != nil || limit <= 0 || limit > 1000 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "limit must be between 1 and 1000",
+			})
+			return
+		}
+
+		entries, err := logger.Since(since, limit)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"status": "success",
+			"since":  since.Format(time.RFC3339),
+			"data":   entries,
+		})
+	}
+}
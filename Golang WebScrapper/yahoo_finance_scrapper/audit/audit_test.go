@@ -0,0 +1,152 @@
+package audit
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestLogger(t *testing.T) *Logger {
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { rdb.Close() })
+	return NewLogger(rdb, 0)
+}
+
+func TestRecord(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logger := newTestLogger(t)
+
+	r := gin.New()
+	r.DELETE("/api/portfolios/:id", func(c *gin.Context) {
+		c.Set("user_id", "u1")
+		c.Next()
+	}, Record(logger, "portfolio.delete"), func(c *gin.Context) {
+		if c.Param("id") == "missing" {
+			c.Status(http.StatusNotFound)
+			return
+		}
+		c.Status(http.StatusOK)
+	})
+	r.POST("/api/reports", func(c *gin.Context) {
+		c.Set("actor", "key:ops")
+		c.Next()
+	}, Record(logger, "report.create"), func(c *gin.Context) {
+		c.Set("audit_target", "r42")
+		c.Status(http.StatusCreated)
+	})
+	serve := func(method, target string) {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(method, target, nil))
+	}
+
+	serve(http.MethodDelete, "/api/portfolios/p1")
+	serve(http.MethodDelete, "/api/portfolios/missing")
+	serve(http.MethodPost, "/api/reports")
+
+	entries, err := logger.Since(time.Now().Add(-time.Minute), 10)
+	require.NoError(t, err)
+	require.Len(t, entries, 2, "failed requests are not recorded")
+
+	created, deleted := entries[0], entries[1]
+	if created.Action != "report.create" {
+		created, deleted = deleted, created
+	}
+
+	assert.Equal(t, "portfolio.delete", deleted.Action)
+	assert.Equal(t, "user:u1", deleted.Actor)
+	assert.Equal(t, "p1", deleted.Target)
+	assert.Equal(t, http.MethodDelete, deleted.Method)
+	assert.Equal(t, "/api/portfolios/p1", deleted.Path)
+	assert.Equal(t, http.StatusOK, deleted.Status)
+	assert.Equal(t, "192.0.2.1", deleted.ClientIP)
+	assert.NotEmpty(t, deleted.ID)
+
+	assert.Equal(t, "key:ops", created.Actor)
+	assert.Equal(t, "r42", created.Target)
+	assert.Equal(t, http.StatusCreated, created.Status)
+}
+
+func TestHandleListAudit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logger := newTestLogger(t)
+
+	now := time.Now().UTC().Truncate(time.Second)
+	for _, entry := range []Entry{
+		{Action: "old", Timestamp: now.Add(-48 * time.Hour)},
+		{Action: "yesterday", Timestamp: now.Add(-2 * time.Hour)},
+		{Action: "recent", Timestamp: now.Add(-10 * time.Minute)},
+		{Action: "latest", Timestamp: now.Add(-time.Minute)},
+	} {
+		require.NoError(t, logger.Log(entry))
+	}
+
+	r := gin.New()
+	r.GET("/api/admin/audit", HandleListAudit(logger))
+	list := func(query url.Values) (int, []string) {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/admin/audit?"+query.Encode(), nil))
+
+		var body struct {
+			Data []Entry `json:"data"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &body)
+		actions := make([]string, len(body.Data))
+		for i, entry := range body.Data {
+			actions[i] = entry.Action
+		}
+		return w.Code, actions
+	}
+
+	t.Run("Default Window", func(t *testing.T) {
+		code, actions := list(url.Values{})
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, []string{"latest", "recent", "yesterday"}, actions)
+	})
+
+	t.Run("Since", func(t *testing.T) {
+		code, actions := list(url.Values{"since": {now.Add(-30 * time.Minute).Format(time.RFC3339)}})
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, []string{"latest", "recent"}, actions)
+	})
+
+	t.Run("Since Is Inclusive", func(t *testing.T) {
+		_, actions := list(url.Values{"since": {now.Add(-10 * time.Minute).Format(time.RFC3339)}})
+		assert.Equal(t, []string{"latest", "recent"}, actions)
+	})
+
+	t.Run("Limit", func(t *testing.T) {
+		_, actions := list(url.Values{"since": {now.Add(-72 * time.Hour).Format(time.RFC3339)}, "limit": {"2"}})
+		assert.Equal(t, []string{"latest", "recent"}, actions)
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		code, _ := list(url.Values{"since": {"yesterday"}})
+		assert.Equal(t, http.StatusBadRequest, code)
+		code, _ = list(url.Values{"limit": {"0"}})
+		assert.Equal(t, http.StatusBadRequest, code)
+		code, _ = list(url.Values{"limit": {"1001"}})
+		assert.Equal(t, http.StatusBadRequest, code)
+	})
+}
+
+func TestLogRetention(t *testing.T) {
+	logger := newTestLogger(t)
+	logger.retainFor = time.Hour
+
+	require.NoError(t, logger.Log(Entry{Action: "expired", Timestamp: time.Now().Add(-2 * time.Hour)}))
+	require.NoError(t, logger.Log(Entry{Action: "kept"}))
+
+	entries, err := logger.Since(time.Time{}, 10)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "kept", entries[0].Action)
+}
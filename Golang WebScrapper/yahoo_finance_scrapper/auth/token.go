@@ -0,0 +1,72 @@
+package auth
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const issuer = "gofinance"
+
+type Claims struct {
+	Username string `json:"username"`
+	jwt.RegisteredClaims
+}
+
+// Tokens issues and verifies HS256-signed access tokens.
+type Tokens struct {
+	secret []byte
+	ttl    time.Duration
+}
+
+func NewTokens(secret string, ttl time.Duration) *Tokens {
+	if ttl == 0 {
+		ttl = 24 * time.Hour
+	}
+
+	return &Tokens{
+		secret: []byte(secret),
+		ttl:    ttl,
+	}
+}
+
+func (t *Tokens) Issue(user *User) (string, time.Time, error) {
+	now := time.Now()
+	expiresAt := now.Add(t.ttl)
+
+	claims := Claims{
+		Username: user.Username,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    issuer,
+			Subject:   user.ID,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+	}
+
+	signed, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(t.secret)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to sign token: %v", err)
+	}
+	return signed, expiresAt, nil
+}
+
+// Parse verifies the signature and expiry of token and returns its claims.
+func (t *Tokens) Parse(token string) (*Claims, error) {
+	var claims Claims
+	_, err := jwt.ParseWithClaims(token, &claims, func(*jwt.Token) (interface{}, error) {
+		return t.secret, nil
+	},
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+		jwt.WithIssuer(issuer),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	if claims.Subject == "" {
+		return nil, fmt.Errorf("token has no subject")
+	}
+	return &claims, nil
+}
@@ -0,0 +1,44 @@
+package auth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokens(t *testing.T) {
+	user := &User{ID: "u1", Username: "alice"}
+
+	t.Run("Round Trip", func(t *testing.T) {
+		tokens := NewTokens("secret", time.Hour)
+
+		token, expiresAt, err := tokens.Issue(user)
+		require.NoError(t, err)
+		assert.WithinDuration(t, time.Now().Add(time.Hour), expiresAt, time.Second)
+
+		claims, err := tokens.Parse(token)
+		require.NoError(t, err)
+		assert.Equal(t, "u1", claims.Subject)
+		assert.Equal(t, "alice", claims.Username)
+	})
+
+	t.Run("Wrong Secret", func(t *testing.T) {
+		token, _, err := NewTokens("secret", time.Hour).Issue(user)
+		require.NoError(t, err)
+
+		_, err = NewTokens("other", time.Hour).Parse(token)
+		assert.Error(t, err)
+	})
+
+	t.Run("Expired", func(t *testing.T) {
+		tokens := NewTokens("secret", -time.Minute)
+
+		token, _, err := tokens.Issue(user)
+		require.NoError(t, err)
+
+		_, err = tokens.Parse(token)
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,252 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	usersKey     = "auth:users"
+	usernamesKey = "auth:usernames"
+)
+
+var (
+	ErrUsernameTaken      = errors.New("username already taken")
+	ErrInvalidCredentials = errors.New("invalid username or password")
+)
+
+var validUsername = regexp.MustCompile(`^[a-z0-9_.-]{3,32}$`)
+
+type User struct {
+	ID           string    `json:"id"`
+	Username     string    `json:"username"`
+	PasswordHash string    `json:"-"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// storedUser keeps the password hash in Redis while the API never exposes it.
+type storedUser struct {
+	User
+	PasswordHash string `json:"password_hash"`
+}
+
+type Store struct {
+	redis *redis.Client
+	ctx   context.Context
+}
+
+func NewStore(rdb *redis.Client) *Store {
+	return &Store{
+		redis: rdb,
+		ctx:   context.Background(),
+	}
+}
+
+func normalizeUsername(username string) string {
+	return strings.ToLower(strings.TrimSpace(username))
+}
+
+// Register creates a user, reserving the username first so two concurrent
+// registrations can't both succeed.
+func (s *Store) Register(username, password string) (*User, error) {
+	username = normalizeUsername(username)
+	if !validUsername.MatchString(username) {
+		return nil, fmt.Errorf("username must be 3-32 characters of a-z, 0-9, _ . -")
+	}
+	if len(password) < 8 {
+		return nil, fmt.Errorf("password must be at least 8 characters")
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash password: %v", err)
+	}
+
+	user := &User{
+		ID:           randomID(),
+		Username:     username,
+		PasswordHash: string(hash),
+		CreatedAt:    time.Now(),
+	}
+
+	reserved, err := s.redis.HSetNX(s.ctx, usernamesKey, username, user.ID).Result()
+	if err != nil {
+		return nil, err
+	}
+	if !reserved {
+		return nil, ErrUsernameTaken
+	}
+
+	if err := s.save(user); err != nil {
+		s.redis.HDel(s.ctx, usernamesKey, username)
+		return nil, err
+	}
+	return user, nil
+}
+
+func (s *Store) save(user *User) error {
+	data, err := json.Marshal(storedUser{User: *user, PasswordHash: user.PasswordHash})
+	if err != nil {
+		return err
+	}
+	return s.redis.HSet(s.ctx, usersKey, user.ID, data).Err()
+}
+
+func (s *Store) Get(id string) (*User, error) {
+	data, err := s.redis.HGet(s.ctx, usersKey, id).Result()
+	if err != nil {
+		if err == redis.Nil {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var stored storedUser
+	if err := json.Unmarshal([]byte(data), &stored); err != nil {
+		return nil, err
+	}
+	stored.User.PasswordHash = stored.PasswordHash
+	return &stored.User, nil
+}
+
+func (s *Store) Authenticate(username, password string) (*User, error) {
+	id, err := s.redis.HGet(s.ctx, usernamesKey, normalizeUsername(username)).Result()
+	if err != nil {
+		if err == redis.Nil {
+			return nil, ErrInvalidCredentials
+		}
+		return nil, err
+	}
+
+	user, err := s.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrInvalidCredentials
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)); err != nil {
+		return nil, ErrInvalidCredentials
+	}
+	return user, nil
+}
+
+func randomID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+type CredentialsRequest struct {
+	Username string `json:"username" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+func tokenResponse(c *gin.Context, status int, tokens *Tokens, user *User) {
+	token, expiresAt, err := tokens.Issue(user)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(status, gin.H{
+		"status": "success",
+		"data": gin.H{
+			"user":       user,
+			"token":      token,
+			"token_type": "Bearer",
+			"expires_at": expiresAt.Format(time.RFC3339),
+		},
+	})
+}
+
+func HandleRegister(store *Store, tokens *Tokens) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req CredentialsRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		user, err := store.Register(req.Username, req.Password)
+		if err != nil {
+			status := http.StatusBadRequest
+			if errors.Is(err, ErrUsernameTaken) {
+				status = http.StatusConflict
+			}
+			c.JSON(status, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		c.Set("audit_target", user.ID)
+		tokenResponse(c, http.StatusCreated, tokens, user)
+	}
+}
+
+func HandleLogin(store *Store, tokens *Tokens) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req CredentialsRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		user, err := store.Authenticate(req.Username, req.Password)
+		if err != nil {
+			status := http.StatusInternalServerError
+			if errors.Is(err, ErrInvalidCredentials) {
+				status = http.StatusUnauthorized
+			}
+			c.JSON(status, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		tokenResponse(c, http.StatusOK, tokens, user)
+	}
+}
+
+func HandleMe(store *Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, err := store.Get(c.GetString("user_id"))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+		if user == nil {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "user not found",
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"status": "success",
+			"data":   user,
+		})
+	}
+}
@@ -0,0 +1,177 @@
+package discord
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"go-webscraper/alerts"
+	"go-webscraper/notify"
+	"go-webscraper/scraper"
+)
+
+const (
+	defaultGainers = 10
+	maxGainers     = 25
+)
+
+// Application command option types from the Discord API.
+const (
+	optionString  = 3
+	optionInteger = 4
+	optionBoolean = 5
+)
+
+type commandOption struct {
+	Type        int    `json:"type"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Required    bool   `json:"required,omitempty"`
+	MinValue    int    `json:"min_value,omitempty"`
+	MaxValue    int    `json:"max_value,omitempty"`
+}
+
+type command struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description"`
+	Options     []commandOption `json:"options,omitempty"`
+}
+
+var commands = []command{
+	{
+		Name:        "quote",
+		Description: "Latest quote for a symbol",
+		Options: []commandOption{
+			{Type: optionString, Name: "symbol", Description: "Ticker, e.g. AAPL", Required: true},
+		},
+	},
+	{
+		Name:        "gainers",
+		Description: "Today's top gainers",
+		Options: []commandOption{
+			{Type: optionInteger, Name: "count", Description: "How many to list", MinValue: 1, MaxValue: maxGainers},
+		},
+	},
+	{
+		Name:        "alert",
+		Description: "Post news mentioning a symbol in this channel",
+		Options: []commandOption{
+			{Type: optionString, Name: "symbol", Description: "Ticker, e.g. AAPL", Required: true},
+			{Type: optionBoolean, Name: "off", Description: "Stop the symbol's alerts instead"},
+		},
+	},
+}
+
+// alertOwner is the alert rule owner for a Discord user, kept apart from
+// GoFinance accounts.
+func alertOwner(userID string) string {
+	return "discord:" + userID
+}
+
+func (b *Bot) quote(ctx context.Context, symbol string) (string, error) {
+	symbol = strings.ToUpper(strings.TrimSpace(symbol))
+	if !scraper.ValidSymbol(symbol) {
+		return "", fmt.Errorf("invalid symbol %q", symbol)
+	}
+
+	stockScraper := scraper.NewStockScraper(scraper.StockScraperOption{
+		Context: ctx,
+		Pool:    b.pool,
+	})
+	defer stockScraper.Close()
+
+	quotes, err := stockScraper.CachedQuotes([]string{symbol})
+	if err != nil {
+		return "", err
+	}
+	if len(quotes) == 0 {
+		return "", fmt.Errorf("no quote found for %s", symbol)
+	}
+	return formatQuote(quotes[0]), nil
+}
+
+func formatQuote(q scraper.StockData) string {
+	return fmt.Sprintf("**%s** %s\n%.2f %s  %+.2f (%+.2f%%)", q.Symbol, q.Name, q.Price, q.Currency, q.Change, q.ChangePerc)
+}
+
+func (b *Bot) gainers(ctx context.Context, count int) (string, error) {
+	if count < 1 || count > maxGainers {
+		count = defaultGainers
+	}
+
+	stockScraper := scraper.NewStockScraper(scraper.StockScraperOption{
+		Context: ctx,
+		Pool:    b.pool,
+	})
+	defer stockScraper.Close()
+
+	stocks, err := stockScraper.ScrapeMovers("gainers")
+	if err != nil {
+		return "", err
+	}
+	if len(stocks) == 0 {
+		return "", fmt.Errorf("no gainers listed right now")
+	}
+	return formatMovers("Top gainers", stocks, count), nil
+}
+
+func formatMovers(title string, stocks []scraper.StockData, count int) string {
+	if len(stocks) > count {
+		stocks = stocks[:count]
+	}
+	lines := make([]string, 0, len(stocks)+1)
+	lines = append(lines, fmt.Sprintf("**%s**", title))
+	for i, stock := range stocks {
+		lines = append(lines, fmt.Sprintf("%d. **%s** %.2f (%+.2f%%)", i+1, stock.Symbol, stock.Price, stock.ChangePerc))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// alert starts or stops news alerts for symbol. Alerts post to the channel
+// of the user's latest /alert, since notify keeps one channel per user.
+func (b *Bot) alert(userID, channelID, symbol string, off bool) (string, error) {
+	symbol = strings.ToUpper(strings.TrimSpace(symbol))
+	if !scraper.ValidSymbol(symbol) {
+		return "", fmt.Errorf("invalid symbol %q", symbol)
+	}
+	if userID == "" || channelID == "" {
+		return "", fmt.Errorf("alerts need a user and a channel")
+	}
+	owner := alertOwner(userID)
+
+	rules, err := b.rules.ListByUser(owner)
+	if err != nil {
+		return "", err
+	}
+	var existing []*alerts.Rule
+	for _, rule := range rules {
+		if rule.Type == alerts.TypeNews && len(rule.Symbols) == 1 && rule.Symbols[0] == symbol {
+			existing = append(existing, rule)
+		}
+	}
+
+	if off {
+		if len(existing) == 0 {
+			return fmt.Sprintf("You have no %s alert.", symbol), nil
+		}
+		for _, rule := range existing {
+			if err := b.rules.Delete(rule.ID); err != nil {
+				return "", err
+			}
+		}
+		return fmt.Sprintf("Stopped %s news alerts.", symbol), nil
+	}
+
+	if err := b.notifier.SetChannel(owner, notify.Channel{Type: ChannelType, Target: channelID}); err != nil {
+		return "", err
+	}
+	if len(existing) > 0 {
+		return fmt.Sprintf("You already have a %s alert; news will post in this channel.", symbol), nil
+	}
+
+	rule := alerts.NewNewsRule(owner, "Discord "+symbol, []string{symbol}, nil)
+	if err := b.rules.Save(rule); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("News mentioning %s will post in this channel. Use `/alert symbol:%s off:true` to stop.", symbol, symbol), nil
+}
@@ -0,0 +1,302 @@
+// Package discord serves GoFinance as a Discord application: /quote,
+// /gainers and /alert slash commands answered from the scraper services.
+// Discord posts each interaction to HandleInteraction; replies and alerts
+// go back through Discord's REST API.
+package discord
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"go-webscraper/alerts"
+	"go-webscraper/notify"
+	"go-webscraper/queue"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	apiBase = "https://discord.com/api/v10"
+	// ChannelType is the notify channel type alerts created from Discord
+	// are delivered over; its target is a Discord channel ID.
+	ChannelType = "discord"
+	// maxContent is the longest message Discord accepts.
+	maxContent = 2000
+	// commandTimeout bounds a deferred reply; Discord's interaction token
+	// itself is valid for 15 minutes.
+	commandTimeout = 2 * time.Minute
+)
+
+// Interaction and response types from the Discord API.
+const (
+	interactionPing    = 1
+	interactionCommand = 2
+
+	responsePong     = 1
+	responseDeferred = 5
+
+	flagEphemeral = 64
+)
+
+type BotOption struct {
+	ApplicationID string
+	// PublicKey is the application's hex-encoded Ed25519 key, which signs
+	// every interaction.
+	PublicKey string
+	BotToken  string
+	// GuildID registers the commands in one server, where they appear at
+	// once, instead of globally.
+	GuildID string
+	Pool    *queue.Pool
+}
+
+type Bot struct {
+	appID     string
+	publicKey ed25519.PublicKey
+	token     string
+	guildID   string
+	api       string
+	client    *http.Client
+	pool      *queue.Pool
+	rules     *alerts.Store
+	notifier  *notify.Notifier
+}
+
+func NewBot(rules *alerts.Store, notifier *notify.Notifier, opts BotOption) (*Bot, error) {
+	key, err := hex.DecodeString(opts.PublicKey)
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("invalid discord public key")
+	}
+	if opts.ApplicationID == "" {
+		return nil, fmt.Errorf("discord application_id is required")
+	}
+
+	return &Bot{
+		appID:     opts.ApplicationID,
+		publicKey: ed25519.PublicKey(key),
+		token:     opts.BotToken,
+		guildID:   opts.GuildID,
+		api:       apiBase,
+		client:    &http.Client{Timeout: 10 * time.Second},
+		pool:      opts.Pool,
+		rules:     rules,
+		notifier:  notifier,
+	}, nil
+}
+
+type user struct {
+	ID string `json:"id"`
+}
+
+type option struct {
+	Name  string      `json:"name"`
+	Value interface{} `json:"value"`
+}
+
+type interaction struct {
+	Type      int    `json:"type"`
+	Token     string `json:"token"`
+	ChannelID string `json:"channel_id"`
+	Data      struct {
+		Name    string   `json:"name"`
+		Options []option `json:"options"`
+	} `json:"data"`
+	// Member is set in servers, User in direct messages.
+	Member *struct {
+		User user `json:"user"`
+	} `json:"member"`
+	User *user `json:"user"`
+}
+
+func (in *interaction) userID() string {
+	if in.Member != nil {
+		return in.Member.User.ID
+	}
+	if in.User != nil {
+		return in.User.ID
+	}
+	return ""
+}
+
+func (in *interaction) stringOption(name string) string {
+	for _, opt := range in.Data.Options {
+		if s, ok := opt.Value.(string); opt.Name == name && ok {
+			return s
+		}
+	}
+	return ""
+}
+
+// intOption returns the named integer option, or fallback if it wasn't
+// given. JSON numbers decode as float64.
+func (in *interaction) intOption(name string, fallback int) int {
+	for _, opt := range in.Data.Options {
+		if n, ok := opt.Value.(float64); opt.Name == name && ok {
+			return int(n)
+		}
+	}
+	return fallback
+}
+
+func (in *interaction) boolOption(name string) bool {
+	for _, opt := range in.Data.Options {
+		if b, ok := opt.Value.(bool); opt.Name == name && ok {
+			return b
+		}
+	}
+	return false
+}
+
+// verify checks Discord's signature over the timestamp and raw body.
+func (b *Bot) verify(signature, timestamp string, body []byte) bool {
+	sig, err := hex.DecodeString(signature)
+	if err != nil || len(sig) != ed25519.SignatureSize {
+		return false
+	}
+	return ed25519.Verify(b.publicKey, append([]byte(timestamp), body...), sig)
+}
+
+// HandleInteraction answers Discord's interaction webhook. Commands are
+// acknowledged at once and answered by editing that reply, since scrapes
+// can outlast the three seconds Discord waits.
+func HandleInteraction(b *Bot) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		body, err := c.GetRawData()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+		if !b.verify(c.GetHeader("X-Signature-Ed25519"), c.GetHeader("X-Signature-Timestamp"), body) {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error": "invalid request signature",
+			})
+			return
+		}
+
+		var in interaction
+		if err := json.Unmarshal(body, &in); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		switch in.Type {
+		case interactionPing:
+			c.JSON(http.StatusOK, gin.H{"type": responsePong})
+		case interactionCommand:
+			response := gin.H{"type": responseDeferred}
+			if in.Data.Name == "alert" {
+				response["data"] = gin.H{"flags": flagEphemeral}
+			}
+			c.JSON(http.StatusOK, response)
+
+			go func() {
+				ctx, cancel := context.WithTimeout(context.Background(), commandTimeout)
+				defer cancel()
+				if err := b.reply(ctx, in.Token, b.run(ctx, &in)); err != nil {
+					log.Printf("Error replying to discord /%s: %v", in.Data.Name, err)
+				}
+			}()
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": fmt.Sprintf("unsupported interaction type: %d", in.Type),
+			})
+		}
+	}
+}
+
+// run executes the command, turning failures into a reply the user sees.
+func (b *Bot) run(ctx context.Context, in *interaction) string {
+	var content string
+	var err error
+	switch in.Data.Name {
+	case "quote":
+		content, err = b.quote(ctx, in.stringOption("symbol"))
+	case "gainers":
+		content, err = b.gainers(ctx, in.intOption("count", defaultGainers))
+	case "alert":
+		content, err = b.alert(in.userID(), in.ChannelID, in.stringOption("symbol"), in.boolOption("off"))
+	default:
+		err = fmt.Errorf("unknown command /%s", in.Data.Name)
+	}
+	if err != nil {
+		return fmt.Sprintf("Sorry, that didn't work: %v", err)
+	}
+	return content
+}
+
+func truncate(content string) string {
+	if runes := []rune(content); len(runes) > maxContent {
+		return string(runes[:maxContent-1]) + "…"
+	}
+	return content
+}
+
+// reply replaces the deferred "thinking" reply with content.
+func (b *Bot) reply(ctx context.Context, token, content string) error {
+	url := fmt.Sprintf("%s/webhooks/%s/%s/messages/@original", b.api, b.appID, token)
+	return b.do(ctx, http.MethodPatch, url, gin.H{"content": truncate(content)}, false)
+}
+
+// Send posts msg to a Discord channel. It is the notify Sender for
+// ChannelType.
+func (b *Bot) Send(channelID string, msg notify.Message) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	url := fmt.Sprintf("%s/channels/%s/messages", b.api, channelID)
+	content := fmt.Sprintf("**%s**\n%s", msg.Subject, msg.Body)
+	return b.do(ctx, http.MethodPost, url, gin.H{"content": truncate(content)}, true)
+}
+
+// RegisterCommands replaces the application's slash commands with
+// GoFinance's, in GuildID if set.
+func (b *Bot) RegisterCommands(ctx context.Context) error {
+	url := fmt.Sprintf("%s/applications/%s/commands", b.api, b.appID)
+	if b.guildID != "" {
+		url = fmt.Sprintf("%s/applications/%s/guilds/%s/commands", b.api, b.appID, b.guildID)
+	}
+	return b.do(ctx, http.MethodPut, url, commands, true)
+}
+
+func (b *Bot) do(ctx context.Context, method, url string, payload interface{}, authorize bool) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if authorize {
+		if b.token == "" {
+			return fmt.Errorf("discord bot_token is not configured")
+		}
+		req.Header.Set("Authorization", "Bot "+b.token)
+	}
+
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("discord returned %d: %s", resp.StatusCode, bytes.TrimSpace(detail))
+	}
+	return nil
+}
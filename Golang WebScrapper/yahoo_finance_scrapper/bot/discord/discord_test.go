@@ -0,0 +1,124 @@
+package discord
+
+import (
+	"crypto/ed25519"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"go-webscraper/scraper"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestBot(t *testing.T) (*Bot, ed25519.PrivateKey) {
+	public, private, err := ed25519.GenerateKey(nil)
+	assert.NoError(t, err)
+
+	bot, err := NewBot(nil, nil, BotOption{ApplicationID: "app", PublicKey: hex.EncodeToString(public)})
+	assert.NoError(t, err)
+	return bot, private
+}
+
+func signedRequest(key ed25519.PrivateKey, body string) *http.Request {
+	timestamp := "1720800000"
+	req := httptest.NewRequest(http.MethodPost, "/interactions", strings.NewReader(body))
+	req.Header.Set("X-Signature-Timestamp", timestamp)
+	req.Header.Set("X-Signature-Ed25519", hex.EncodeToString(ed25519.Sign(key, []byte(timestamp+body))))
+	return req
+}
+
+func TestNewBot(t *testing.T) {
+	_, err := NewBot(nil, nil, BotOption{ApplicationID: "app", PublicKey: "not-hex"})
+	assert.Error(t, err)
+
+	_, err = NewBot(nil, nil, BotOption{PublicKey: strings.Repeat("ab", ed25519.PublicKeySize)})
+	assert.Error(t, err)
+}
+
+func TestHandleInteraction(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	bot, key := newTestBot(t)
+
+	edited := make(chan string, 1)
+	discordAPI := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		assert.Equal(t, http.MethodPatch, r.Method)
+		assert.Equal(t, "/webhooks/app/tok/messages/@original", r.URL.Path)
+		edited <- string(body)
+	}))
+	defer discordAPI.Close()
+	bot.api = discordAPI.URL
+
+	r := gin.New()
+	r.POST("/interactions", HandleInteraction(bot))
+
+	t.Run("Ping", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, signedRequest(key, `{"type":1}`))
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"type":1}`, w.Body.String())
+	})
+
+	t.Run("Bad signature", func(t *testing.T) {
+		req := signedRequest(key, `{"type":1}`)
+		req.Header.Set("X-Signature-Timestamp", "1720800001")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("Deferred command", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, signedRequest(key, `{"type":2,"token":"tok","data":{"name":"portfolio"}}`))
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"type":5}`, w.Body.String())
+
+		select {
+		case body := <-edited:
+			var reply map[string]string
+			assert.NoError(t, json.Unmarshal([]byte(body), &reply))
+			assert.Equal(t, "Sorry, that didn't work: unknown command /portfolio", reply["content"])
+		case <-time.After(5 * time.Second):
+			t.Fatal("deferred reply was never edited")
+		}
+	})
+}
+
+func TestInteractionOptions(t *testing.T) {
+	var in interaction
+	assert.NoError(t, json.Unmarshal([]byte(`{
+		"data": {"name": "alert", "options": [
+			{"name": "symbol", "type": 3, "value": "aapl"},
+			{"name": "count", "type": 4, "value": 5},
+			{"name": "off", "type": 5, "value": true}
+		]},
+		"member": {"user": {"id": "42"}}
+	}`), &in))
+
+	assert.Equal(t, "aapl", in.stringOption("symbol"))
+	assert.Equal(t, 5, in.intOption("count", defaultGainers))
+	assert.Equal(t, defaultGainers, in.intOption("limit", defaultGainers))
+	assert.True(t, in.boolOption("off"))
+	assert.Equal(t, "42", in.userID())
+}
+
+func TestFormat(t *testing.T) {
+	quote := scraper.StockData{Symbol: "AAPL", Name: "Apple Inc.", Price: 189.84, Change: 1.2, ChangePerc: 0.64, Currency: "USD"}
+	assert.Equal(t, "**AAPL** Apple Inc.\n189.84 USD  +1.20 (+0.64%)", formatQuote(quote))
+
+	stocks := []scraper.StockData{
+		{Symbol: "SMCI", Price: 800, ChangePerc: 12.5},
+		{Symbol: "NVDA", Price: 120.5, ChangePerc: 7},
+		{Symbol: "AMD", Price: 160, ChangePerc: 5},
+	}
+	assert.Equal(t, "**Top gainers**\n1. **SMCI** 800.00 (+12.50%)\n2. **NVDA** 120.50 (+7.00%)", formatMovers("Top gainers", stocks, 2))
+
+	assert.Len(t, []rune(truncate(strings.Repeat("é", 3000))), maxContent)
+}
@@ -0,0 +1,260 @@
+package bus
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"go-webscraper/scraper"
+
+	"github.com/nats-io/nats.go"
+	"github.com/segmentio/kafka-go"
+)
+
+type Message struct {
+	Key   string
+	Value []byte
+}
+
+type Publisher interface {
+	Publish(ctx context.Context, topic string, messages []Message) error
+	Close() error
+}
+
+type BusOption struct {
+	Driver  string
+	Brokers []string
+	NatsURL string
+	Topics  map[string]string
+}
+
+const (
+	// queueSize bounds the refreshes waiting on the broker. A refresh
+	// that finds the queue full is dropped rather than holding up the
+	// scheduler's other sinks.
+	queueSize      = 64
+	publishTimeout = 30 * time.Second
+)
+
+// batch is one refresh's records on their way to topic.
+type batch struct {
+	source   string
+	topic    string
+	messages []Message
+}
+
+// Emitter splits a refresh result into individual records and publishes
+// each one to the topic configured for its source. Publishing happens in
+// the background, one refresh at a time, so a slow broker never blocks the
+// caller.
+type Emitter struct {
+	publisher Publisher
+	topics    map[string]string
+
+	mu     sync.RWMutex
+	closed bool
+	queue  chan batch
+	done   chan struct{}
+}
+
+func NewEmitter(opts BusOption) (*Emitter, error) {
+	var (
+		publisher Publisher
+		err       error
+	)
+
+	switch opts.Driver {
+	case "":
+		return nil, nil
+	case "kafka":
+		publisher, err = newKafkaPublisher(opts.Brokers)
+	case "nats":
+		publisher, err = newNatsPublisher(opts.NatsURL)
+	default:
+		return nil, fmt.Errorf("unsupported bus driver: %s", opts.Driver)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return newEmitter(publisher, opts.Topics), nil
+}
+
+// newEmitter starts an emitter publishing through publisher, with topics
+// overriding the default topic of each source.
+func newEmitter(publisher Publisher, overrides map[string]string) *Emitter {
+	topics := map[string]string{
+		"stocks":   "gofinance.stocks",
+		"sectors":  "gofinance.sectors",
+		"news":     "gofinance.news",
+		"calendar": "gofinance.calendar",
+	}
+	for source, topic := range overrides {
+		topics[source] = topic
+	}
+
+	e := &Emitter{
+		publisher: publisher,
+		topics:    topics,
+		queue:     make(chan batch, queueSize),
+		done:      make(chan struct{}),
+	}
+	go e.run()
+	return e
+}
+
+// Publish queues data's records for publishing and returns at once.
+func (e *Emitter) Publish(source string, data interface{}) {
+	topic, ok := e.topics[source]
+	if !ok {
+		return
+	}
+
+	messages := split(source, data)
+	if len(messages) == 0 {
+		return
+	}
+
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	if e.closed {
+		return
+	}
+	select {
+	case e.queue <- batch{source: source, topic: topic, messages: messages}:
+	default:
+		log.Printf("Dropping %d %s records for %s: publish queue is full", len(messages), source, topic)
+	}
+}
+
+func (e *Emitter) run() {
+	defer close(e.done)
+	for b := range e.queue {
+		ctx, cancel := context.WithTimeout(context.Background(), publishTimeout)
+		err := e.publisher.Publish(ctx, b.topic, b.messages)
+		cancel()
+		if err != nil {
+			log.Printf("Error publishing %d %s records to %s: %v", len(b.messages), b.source, b.topic, err)
+			continue
+		}
+		log.Printf("Published %d %s records to %s", len(b.messages), b.source, b.topic)
+	}
+}
+
+// split turns a refresh result into one message per record, keyed so a
+// record's updates land on the same partition.
+func split(source string, data interface{}) []Message {
+	var messages []Message
+	emit := func(key string, record interface{}) {
+		payload, err := json.Marshal(record)
+		if err != nil {
+			log.Printf("Error marshaling %s record %s: %v", source, key, err)
+			return
+		}
+		messages = append(messages, Message{Key: key, Value: payload})
+	}
+
+	switch v := data.(type) {
+	case []scraper.StockData:
+		for _, stock := range v {
+			emit(stock.Symbol, stock)
+		}
+	case map[string]*scraper.SectorData:
+		for name, sector := range v {
+			emit(name, sector)
+		}
+	case []scraper.Article:
+		for _, article := range v {
+			emit(article.Link, article)
+		}
+	case []scraper.EconomicEvent:
+		for _, event := range v {
+			emit(event.Country+":"+event.Event, event)
+		}
+	default:
+		emit(source, data)
+	}
+	return messages
+}
+
+// Close publishes what is still queued, then closes the publisher.
+func (e *Emitter) Close() error {
+	e.mu.Lock()
+	if !e.closed {
+		e.closed = true
+		close(e.queue)
+	}
+	e.mu.Unlock()
+
+	<-e.done
+	return e.publisher.Close()
+}
+
+type kafkaPublisher struct {
+	writer *kafka.Writer
+}
+
+func newKafkaPublisher(brokers []string) (*kafkaPublisher, error) {
+	if len(brokers) == 0 {
+		return nil, fmt.Errorf("kafka driver requires at least one broker")
+	}
+
+	return &kafkaPublisher{
+		writer: &kafka.Writer{
+			Addr:                   kafka.TCP(brokers...),
+			Balancer:               &kafka.Hash{},
+			AllowAutoTopicCreation: true,
+		},
+	}, nil
+}
+
+func (p *kafkaPublisher) Publish(ctx context.Context, topic string, messages []Message) error {
+	batch := make([]kafka.Message, 0, len(messages))
+	for _, m := range messages {
+		batch = append(batch, kafka.Message{
+			Topic: topic,
+			Key:   []byte(m.Key),
+			Value: m.Value,
+		})
+	}
+	return p.writer.WriteMessages(ctx, batch...)
+}
+
+func (p *kafkaPublisher) Close() error {
+	return p.writer.Close()
+}
+
+type natsPublisher struct {
+	conn *nats.Conn
+}
+
+func newNatsPublisher(url string) (*natsPublisher, error) {
+	if url == "" {
+		url = nats.DefaultURL
+	}
+
+	conn, err := nats.Connect(url, nats.Name("gofinance"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to nats: %v", err)
+	}
+	return &natsPublisher{conn: conn}, nil
+}
+
+func (p *natsPublisher) Publish(ctx context.Context, topic string, messages []Message) error {
+	for _, m := range messages {
+		msg := nats.NewMsg(topic)
+		msg.Header.Set("Key", m.Key)
+		msg.Data = m.Value
+		if err := p.conn.PublishMsg(msg); err != nil {
+			return err
+		}
+	}
+	return p.conn.FlushWithContext(ctx)
+}
+
+func (p *natsPublisher) Close() error {
+	return p.conn.Drain()
+}
@@ -0,0 +1,117 @@
+package bus
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"go-webscraper/scraper"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type published struct {
+	topic    string
+	messages []Message
+}
+
+// fakePublisher records what it is asked to publish, holding each call
+// until release is closed when it is set.
+type fakePublisher struct {
+	mu      sync.Mutex
+	calls   []published
+	release chan struct{}
+	closed  bool
+}
+
+func (p *fakePublisher) Publish(ctx context.Context, topic string, messages []Message) error {
+	if p.release != nil {
+		<-p.release
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.calls = append(p.calls, published{topic: topic, messages: messages})
+	return nil
+}
+
+func (p *fakePublisher) Close() error {
+	p.closed = true
+	return nil
+}
+
+func keys(messages []Message) []string {
+	result := make([]string, len(messages))
+	for i, m := range messages {
+		result[i] = m.Key
+	}
+	sort.Strings(result)
+	return result
+}
+
+func TestEmitterSplitsRecords(t *testing.T) {
+	publisher := &fakePublisher{}
+	e := newEmitter(publisher, map[string]string{"news": "custom.news"})
+
+	e.Publish("stocks", []scraper.StockData{{Symbol: "AAPL"}, {Symbol: "MSFT"}})
+	e.Publish("sectors", map[string]*scraper.SectorData{"technology": {Name: "Technology"}})
+	e.Publish("news", []scraper.Article{{Link: "https://example.com/a", Title: "A"}})
+	e.Publish("calendar", []scraper.EconomicEvent{{Country: "US", Event: "CPI"}})
+	e.Publish("stocks", []scraper.StockData{})
+	e.Publish("unconfigured", []scraper.StockData{{Symbol: "AAPL"}})
+	require.NoError(t, e.Close())
+
+	assert.True(t, publisher.closed)
+	require.Len(t, publisher.calls, 4)
+
+	assert.Equal(t, "gofinance.stocks", publisher.calls[0].topic)
+	assert.Equal(t, []string{"AAPL", "MSFT"}, keys(publisher.calls[0].messages))
+	var stock scraper.StockData
+	require.NoError(t, json.Unmarshal(publisher.calls[0].messages[0].Value, &stock))
+	assert.Equal(t, "AAPL", stock.Symbol)
+
+	assert.Equal(t, "gofinance.sectors", publisher.calls[1].topic)
+	assert.Equal(t, []string{"technology"}, keys(publisher.calls[1].messages))
+	assert.Equal(t, "custom.news", publisher.calls[2].topic, "topics override the defaults")
+	assert.Equal(t, []string{"https://example.com/a"}, keys(publisher.calls[2].messages))
+	assert.Equal(t, "gofinance.calendar", publisher.calls[3].topic)
+	assert.Equal(t, []string{"US:CPI"}, keys(publisher.calls[3].messages))
+}
+
+func TestEmitterDefaultRecord(t *testing.T) {
+	publisher := &fakePublisher{}
+	e := newEmitter(publisher, map[string]string{"overview": "gofinance.overview"})
+
+	e.Publish("overview", map[string]int{"advancers": 3})
+	require.NoError(t, e.Close())
+
+	require.Len(t, publisher.calls, 1)
+	assert.Equal(t, []string{"overview"}, keys(publisher.calls[0].messages))
+	assert.JSONEq(t, `{"advancers":3}`, string(publisher.calls[0].messages[0].Value))
+}
+
+func TestEmitterDoesNotBlockOnSlowBroker(t *testing.T) {
+	publisher := &fakePublisher{release: make(chan struct{})}
+	e := newEmitter(publisher, nil)
+
+	// The first batch goes to the broker, which holds on to it
+	e.Publish("stocks", []scraper.StockData{{Symbol: "AAPL"}})
+	require.Eventually(t, func() bool { return len(e.queue) == 0 }, time.Second, time.Millisecond)
+
+	start := time.Now()
+	for i := 0; i < queueSize+10; i++ {
+		e.Publish("stocks", []scraper.StockData{{Symbol: "AAPL"}})
+	}
+	assert.Less(t, time.Since(start), time.Second, "publishing waits on nobody")
+
+	close(publisher.release)
+	require.NoError(t, e.Close())
+	// The batches past the queue were dropped
+	assert.Equal(t, queueSize+1, len(publisher.calls))
+
+	e.Publish("stocks", []scraper.StockData{{Symbol: "AAPL"}})
+	assert.Equal(t, queueSize+1, len(publisher.calls), "publishing after Close is a no-op")
+}
@@ -0,0 +1,88 @@
+// Package cache holds the scrapers' cached responses. Redis is the default
+// backend; small deployments can keep them in process memory instead, or
+// not cache at all and scrape every request.
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ErrMiss is returned for keys that aren't cached or have expired.
+var ErrMiss = errors.New("cache miss")
+
+// Cache stores values under keys for a TTL. A zero TTL keeps a value until
+// it is evicted.
+type Cache interface {
+	Get(ctx context.Context, key string) ([]byte, error)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	// Expire resets a cached key's TTL, reporting false when it isn't
+	// cached.
+	Expire(ctx context.Context, key string, ttl time.Duration) (bool, error)
+	// TTL returns how long key has left, ErrMiss when it isn't cached.
+	TTL(ctx context.Context, key string) (time.Duration, error)
+}
+
+const (
+	BackendRedis  = "redis"
+	BackendMemory = "memory"
+	BackendNone   = "none"
+)
+
+// Option selects the backend New returns. MaxEntries bounds the memory
+// backend.
+type Option struct {
+	Backend    string
+	MaxEntries int
+}
+
+var (
+	mutex   sync.RWMutex
+	backend = BackendRedis
+	shared  Cache
+)
+
+// Configure selects the backend for every cache made by New afterwards.
+// The memory backend is one LRU shared by the whole process.
+func Configure(opts Option) error {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	switch opts.Backend {
+	case "", BackendRedis:
+		backend, shared = BackendRedis, nil
+	case BackendMemory:
+		if opts.MaxEntries <= 0 {
+			return fmt.Errorf("memory cache needs max_entries above 0")
+		}
+		backend, shared = BackendMemory, NewMemory(opts.MaxEntries)
+	case BackendNone:
+		backend, shared = BackendNone, None{}
+	default:
+		return fmt.Errorf("unknown cache backend: %s", opts.Backend)
+	}
+	return nil
+}
+
+// Backend names the configured backend.
+func Backend() string {
+	mutex.RLock()
+	defer mutex.RUnlock()
+	return backend
+}
+
+// New returns the configured cache, storing in rdb when that's Redis.
+func New(rdb *redis.Client) Cache {
+	mutex.RLock()
+	defer mutex.RUnlock()
+
+	if shared != nil {
+		return shared
+	}
+	return NewRedis(rdb)
+}
@@ -0,0 +1,82 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go-webscraper/keyspace"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemory(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 10, 16, 14, 0, 0, 0, time.UTC)
+	m := NewMemory(2)
+	m.now = func() time.Time { return now }
+
+	_, err := m.Get(ctx, "a")
+	assert.ErrorIs(t, err, ErrMiss)
+
+	m.Set(ctx, "a", []byte("1"), time.Minute)
+	m.Set(ctx, "b", []byte("2"), 0)
+	value, err := m.Get(ctx, "a")
+	assert.NoError(t, err)
+	assert.Equal(t, "1", string(value))
+
+	// a was used more recently, so c evicts b
+	m.Set(ctx, "c", []byte("3"), time.Minute)
+	_, err = m.Get(ctx, "b")
+	assert.ErrorIs(t, err, ErrMiss)
+
+	ttl, err := m.TTL(ctx, "a")
+	assert.NoError(t, err)
+	assert.Equal(t, time.Minute, ttl)
+
+	ok, err := m.Expire(ctx, "a", 2*time.Minute)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	ok, _ = m.Expire(ctx, "b", time.Minute)
+	assert.False(t, ok)
+
+	now = now.Add(90 * time.Second)
+	_, err = m.Get(ctx, "c")
+	assert.ErrorIs(t, err, ErrMiss)
+	_, err = m.Get(ctx, "a")
+	assert.NoError(t, err)
+}
+
+func TestMemoryNamespace(t *testing.T) {
+	assert.NoError(t, keyspace.Configure(keyspace.Option{Namespace: "acme"}))
+	defer keyspace.Configure(keyspace.Option{})
+
+	m := NewMemory(10)
+	m.Set(context.Background(), "quote", []byte("1"), 0)
+
+	value, err := m.Get(context.Background(), "quote")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("1"), value)
+	assert.NotNil(t, m.lookup("acme:quote"))
+}
+
+func TestConfigure(t *testing.T) {
+	defer Configure(Option{})
+
+	assert.EqualError(t, Configure(Option{Backend: "memcached"}), "unknown cache backend: memcached")
+	assert.Error(t, Configure(Option{Backend: BackendMemory}))
+
+	assert.NoError(t, Configure(Option{Backend: BackendMemory, MaxEntries: 10}))
+	assert.Equal(t, BackendMemory, Backend())
+	assert.Same(t, New(nil), New(nil))
+
+	assert.NoError(t, Configure(Option{Backend: BackendNone}))
+	none := New(nil)
+	none.Set(context.Background(), "a", []byte("1"), 0)
+	_, err := none.Get(context.Background(), "a")
+	assert.ErrorIs(t, err, ErrMiss)
+
+	assert.NoError(t, Configure(Option{}))
+	assert.Equal(t, BackendRedis, Backend())
+	assert.IsType(t, &Redis{}, New(nil))
+}
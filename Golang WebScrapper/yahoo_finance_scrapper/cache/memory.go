@@ -0,0 +1,120 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+
+	"go-webscraper/keyspace"
+)
+
+type memoryEntry struct {
+	key       string
+	value     []byte
+	expiresAt time.Time
+}
+
+func (e *memoryEntry) expired(now time.Time) bool {
+	return !e.expiresAt.IsZero() && !now.Before(e.expiresAt)
+}
+
+// Memory is an in-process LRU holding at most maxEntries values. Keys get
+// the namespace like the scrapers' Redis keys.
+type Memory struct {
+	mu         sync.Mutex
+	maxEntries int
+	order      *list.List
+	entries    map[string]*list.Element
+	now        func() time.Time
+}
+
+func NewMemory(maxEntries int) *Memory {
+	return &Memory{
+		maxEntries: maxEntries,
+		order:      list.New(),
+		entries:    make(map[string]*list.Element),
+		now:        time.Now,
+	}
+}
+
+// lookup returns key's live entry, marking it recently used and dropping
+// it if it has expired. Callers hold m.mu.
+func (m *Memory) lookup(key string) *memoryEntry {
+	elem, ok := m.entries[key]
+	if !ok {
+		return nil
+	}
+	entry := elem.Value.(*memoryEntry)
+	if entry.expired(m.now()) {
+		m.order.Remove(elem)
+		delete(m.entries, key)
+		return nil
+	}
+	m.order.MoveToFront(elem)
+	return entry
+}
+
+func (m *Memory) Get(ctx context.Context, key string) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry := m.lookup(keyspace.Prefix(ctx) + key)
+	if entry == nil {
+		return nil, ErrMiss
+	}
+	return entry.value, nil
+}
+
+func (m *Memory) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	key = keyspace.Prefix(ctx) + key
+	entry := &memoryEntry{key: key, value: value}
+	if ttl > 0 {
+		entry.expiresAt = m.now().Add(ttl)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if elem, ok := m.entries[key]; ok {
+		elem.Value = entry
+		m.order.MoveToFront(elem)
+		return nil
+	}
+	m.entries[key] = m.order.PushFront(entry)
+	for m.order.Len() > m.maxEntries {
+		oldest := m.order.Back()
+		m.order.Remove(oldest)
+		delete(m.entries, oldest.Value.(*memoryEntry).key)
+	}
+	return nil
+}
+
+func (m *Memory) Expire(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry := m.lookup(keyspace.Prefix(ctx) + key)
+	if entry == nil {
+		return false, nil
+	}
+	entry.expiresAt = time.Time{}
+	if ttl > 0 {
+		entry.expiresAt = m.now().Add(ttl)
+	}
+	return true, nil
+}
+
+func (m *Memory) TTL(ctx context.Context, key string) (time.Duration, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry := m.lookup(keyspace.Prefix(ctx) + key)
+	if entry == nil {
+		return 0, ErrMiss
+	}
+	if entry.expiresAt.IsZero() {
+		return 0, nil
+	}
+	return entry.expiresAt.Sub(m.now()), nil
+}
@@ -0,0 +1,25 @@
+package cache
+
+import (
+	"context"
+	"time"
+)
+
+// None caches nothing: every Get misses and Set is dropped.
+type None struct{}
+
+func (None) Get(context.Context, string) ([]byte, error) {
+	return nil, ErrMiss
+}
+
+func (None) Set(context.Context, string, []byte, time.Duration) error {
+	return nil
+}
+
+func (None) Expire(context.Context, string, time.Duration) (bool, error) {
+	return false, nil
+}
+
+func (None) TTL(context.Context, string) (time.Duration, error) {
+	return 0, ErrMiss
+}
@@ -0,0 +1,49 @@
+package cache
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Redis stores values as Redis strings, under the key prefixes applied by
+// the client's hooks.
+type Redis struct {
+	redis *redis.Client
+}
+
+func NewRedis(rdb *redis.Client) *Redis {
+	return &Redis{redis: rdb}
+}
+
+func (r *Redis) Get(ctx context.Context, key string) ([]byte, error) {
+	value, err := r.redis.Get(ctx, key).Bytes()
+	if err == redis.Nil {
+		return nil, ErrMiss
+	}
+	return value, err
+}
+
+func (r *Redis) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return r.redis.Set(ctx, key, value, ttl).Err()
+}
+
+func (r *Redis) Expire(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	return r.redis.Expire(ctx, key, ttl).Result()
+}
+
+func (r *Redis) TTL(ctx context.Context, key string) (time.Duration, error) {
+	ttl, err := r.redis.PTTL(ctx, key).Result()
+	if err != nil {
+		return 0, err
+	}
+	// Redis answers -2 for a missing key and -1 for one without a TTL
+	switch ttl {
+	case -2:
+		return 0, ErrMiss
+	case -1:
+		return 0, nil
+	}
+	return ttl, nil
+}
@@ -0,0 +1,144 @@
+package cachekey
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Class is a family of cached JSON blobs sharing one shape. Keys are
+// written as "v<Version>:<Name>[:part...]", so bumping Version when the
+// cached struct changes makes readers miss instead of failing to
+// unmarshal a stale blob.
+type Class struct {
+	Name    string
+	Version int
+	// Source names the TTL policy the class is cached under.
+	Source string
+}
+
+var classes []Class
+
+func register(name string, version int, source string) Class {
+	class := Class{Name: name, Version: version, Source: source}
+	classes = append(classes, class)
+	return class
+}
+
+var (
+	MostActive        = register("stocks:most_active", 1, "quotes")
+	Quote             = register("stocks:quote", 1, "quotes")
+	MarketOverview    = register("stocks:market_overview", 1, "quotes")
+	Gainers           = register("stocks:gainers", 1, "quotes")
+	Losers            = register("stocks:losers", 1, "quotes")
+	Trending          = register("stocks:trending", 1, "quotes")
+	MostShorted       = register("stocks:most_shorted", 1, "quotes")
+	ShortInterest     = register("stocks:short_interest", 1, "statistics")
+	Peers             = register("stocks:peers", 1, "statistics")
+	MajorIndices      = register("indices:major", 1, "quotes")
+	IndexComponents   = register("indices:components", 1, "quotes")
+	Breadth           = register("market:breadth", 1, "quotes")
+	Commodities       = register("commodities", 1, "quotes")
+	Sector            = register("sector", 1, "sectors")
+	SectorMembers     = register("constituents:sector", 1, "sectors")
+	EconomicCalendar  = register("calendar:economic", 1, "calendar")
+	DividendCalendar  = register("calendar:dividends", 1, "calendar")
+	Dividend          = register("dividend", 1, "dividends")
+	OptionsMostActive = register("options:most_active", 1, "options")
+	Article           = register("news:article", 4, "news")
+	FXRate            = register("fx", 1, "fx")
+	SheetQuotes       = register("sheets:quotes", 1, "sheets")
+)
+
+// ProvenancePattern matches every provenance record; see Provenance.
+const ProvenancePattern = "provenance:*"
+
+// Provenance names the record describing how the blob at key was scraped.
+func Provenance(key string) string {
+	return "provenance:" + key
+}
+
+// Validators names the record of the upstream validators and last full
+// copy of the blob at key, kept past key's TTL for conditional scrapes.
+func Validators(key string) string {
+	return "validators:" + key
+}
+
+// StaleCopy names the copy of the blob at key kept past key's TTL, served
+// when the request that would rescrape it is shed for load.
+func StaleCopy(key string) string {
+	return "stale:" + key
+}
+
+// Classes lists every registered class.
+func Classes() []Class {
+	return append([]Class(nil), classes...)
+}
+
+func (c Class) prefix() string {
+	return fmt.Sprintf("v%d:%s", c.Version, c.Name)
+}
+
+// Key names one blob of the class, e.g. Sector.Key("technology") is
+// "v1:sector:technology".
+func (c Class) Key(parts ...string) string {
+	if len(parts) == 0 {
+		return c.prefix()
+	}
+	return c.prefix() + ":" + strings.Join(parts, ":")
+}
+
+// Pattern matches every current-version key of the class, including
+// region-suffixed ones.
+func (c Class) Pattern() string {
+	return c.prefix() + "*"
+}
+
+var versioned = regexp.MustCompile(`^v(\d+):(.+)$`)
+
+// lookup finds the registered class key belongs to, along with the
+// version it was written under.
+func lookup(key string) (Class, string, bool) {
+	m := versioned.FindStringSubmatch(key)
+	if m == nil {
+		return Class{}, "", false
+	}
+	for _, class := range classes {
+		if m[2] == class.Name || strings.HasPrefix(m[2], class.Name+":") {
+			return class, m[1], true
+		}
+	}
+	return Class{}, "", false
+}
+
+// stale reports whether key belongs to a registered class under a version
+// other than the current one.
+func stale(key string) bool {
+	class, version, ok := lookup(key)
+	return ok && version != fmt.Sprint(class.Version)
+}
+
+// PurgeStale deletes keys written under an older version of their class.
+// Readers already ignore them; this just frees the memory before their
+// TTLs run out.
+func PurgeStale(ctx context.Context, rdb *redis.Client) (int, error) {
+	purged := 0
+	iter := rdb.Scan(ctx, 0, "v*:*", 100).Iterator()
+	for iter.Next(ctx) {
+		key := iter.Val()
+		if !stale(key) {
+			continue
+		}
+		if err := rdb.Del(ctx, key).Err(); err != nil {
+			return purged, fmt.Errorf("failed to delete %s: %v", key, err)
+		}
+		purged++
+	}
+	if err := iter.Err(); err != nil {
+		return purged, fmt.Errorf("failed to scan cache keys: %v", err)
+	}
+	return purged, nil
+}
@@ -0,0 +1,34 @@
+package cachekey
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKey(t *testing.T) {
+	assert.Equal(t, "v1:stocks:most_active", MostActive.Key())
+	assert.Equal(t, "v1:sector:technology", Sector.Key("technology"))
+	assert.Equal(t, "v1:stocks:peers:MSFT:5", Peers.Key("MSFT", "5"))
+	assert.Equal(t, "v1:sector*", Sector.Pattern())
+}
+
+func TestStale(t *testing.T) {
+	defer func(saved []Class) { classes = saved }(classes)
+	bumped := register("test:bumped", 3, "quotes")
+
+	assert.False(t, stale(bumped.Key("x")))
+	assert.True(t, stale("v2:test:bumped:x"))
+	assert.True(t, stale("v2:test:bumped"))
+	assert.False(t, stale("v2:test:bumpedx"), "different class")
+	assert.False(t, stale("v1:unregistered:x"))
+	assert.False(t, stale("most_active_stocks"), "legacy keys expire on their own")
+}
+
+func TestClassNamesUnique(t *testing.T) {
+	seen := make(map[string]bool)
+	for _, class := range Classes() {
+		assert.False(t, seen[class.Name], class.Name)
+		seen[class.Name] = true
+	}
+}
@@ -0,0 +1,88 @@
+package cachekey
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// DefaultTTL applies to keys outside any registered class.
+const DefaultTTL = 1 * time.Hour
+
+// Policy is how long a source's blobs are cached. Closed, when set, is
+// used outside regular market hours, when prices stop moving.
+type Policy struct {
+	Open   time.Duration
+	Closed time.Duration
+}
+
+var (
+	policyMu sync.RWMutex
+	policies = defaultPolicies()
+)
+
+func defaultPolicies() map[string]Policy {
+	return map[string]Policy{
+		"quotes":     {Open: 1 * time.Minute, Closed: 15 * time.Minute},
+		"sectors":    {Open: 30 * time.Minute},
+		"news":       {Open: 15 * time.Minute},
+		"profiles":   {Open: 7 * 24 * time.Hour},
+		"statistics": {Open: 12 * time.Hour},
+		"calendar":   {Open: 30 * time.Minute},
+		"dividends":  {Open: 6 * time.Hour},
+		"options":    {Open: 15 * time.Minute},
+		"fx":         {Open: 1 * time.Hour},
+		"sheets":     {Open: 30 * time.Minute, Closed: 12 * time.Hour},
+	}
+}
+
+// ConfigureTTLs overrides the policies of the named sources. Durations left
+// at zero keep their default.
+func ConfigureTTLs(overrides map[string]Policy) error {
+	policyMu.Lock()
+	defer policyMu.Unlock()
+
+	next := defaultPolicies()
+	for source, override := range overrides {
+		policy, ok := next[source]
+		if !ok {
+			return fmt.Errorf("unknown cache source %q", source)
+		}
+		if override.Open < 0 || override.Closed < 0 {
+			return fmt.Errorf("cache source %s: TTLs must not be negative", source)
+		}
+		if override.Open > 0 {
+			policy.Open = override.Open
+		}
+		if override.Closed > 0 {
+			policy.Closed = override.Closed
+		}
+		next[source] = policy
+	}
+	policies = next
+	return nil
+}
+
+// TTL is the class's cache lifetime given whether the market is open.
+func (c Class) TTL(marketOpen bool) time.Duration {
+	policyMu.RLock()
+	defer policyMu.RUnlock()
+
+	policy, ok := policies[c.Source]
+	if !ok {
+		return DefaultTTL
+	}
+	if !marketOpen && policy.Closed > 0 {
+		return policy.Closed
+	}
+	return policy.Open
+}
+
+// TTLFor selects the TTL of key by its class.
+func TTLFor(key string, marketOpen bool) time.Duration {
+	class, _, ok := lookup(key)
+	if !ok {
+		return DefaultTTL
+	}
+	return class.TTL(marketOpen)
+}
@@ -0,0 +1,31 @@
+package cachekey
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTTLFor(t *testing.T) {
+	defer ConfigureTTLs(nil)
+
+	assert.Equal(t, 1*time.Minute, TTLFor(MostActive.Key(), true))
+	assert.Equal(t, 15*time.Minute, TTLFor(MostActive.Key(), false))
+	assert.Equal(t, 30*time.Minute, TTLFor(Sector.Key("technology", "eu"), false), "no closed TTL")
+	assert.Equal(t, 15*time.Minute, TTLFor(Article.Key("https://finance.yahoo.com/news/x"), true))
+	assert.Equal(t, DefaultTTL, TTLFor("unversioned", true))
+
+	assert.NoError(t, ConfigureTTLs(map[string]Policy{"quotes": {Open: 30 * time.Second}}))
+	assert.Equal(t, 30*time.Second, TTLFor(MostActive.Key(), true))
+	assert.Equal(t, 15*time.Minute, TTLFor(MostActive.Key(), false), "closed keeps its default")
+
+	assert.Error(t, ConfigureTTLs(map[string]Policy{"unknown": {Open: time.Minute}}))
+}
+
+func TestClassSourcesHavePolicies(t *testing.T) {
+	for _, class := range Classes() {
+		_, ok := defaultPolicies()[class.Source]
+		assert.True(t, ok, class.Name)
+	}
+}
@@ -0,0 +1,126 @@
+package changes
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const keyPrefix = "changes:"
+
+// volatileFields are stamped on every scrape and would otherwise make
+// identical data hash differently.
+var volatileFields = map[string]bool{
+	"timestamp": true,
+}
+
+type Tracker struct {
+	redis *redis.Client
+	ctx   context.Context
+}
+
+func NewTracker(rdb *redis.Client) *Tracker {
+	return &Tracker{
+		redis: rdb,
+		ctx:   context.Background(),
+	}
+}
+
+// Hash returns a stable digest of data after dropping volatile fields.
+// encoding/json sorts map keys, so equal content always hashes the same.
+func Hash(data interface{}) (string, error) {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return "", err
+	}
+
+	var normalized interface{}
+	if err := json.Unmarshal(raw, &normalized); err != nil {
+		return "", err
+	}
+	normalized = strip(normalized)
+
+	raw, err = json.Marshal(normalized)
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(raw)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+func strip(v interface{}) interface{} {
+	switch value := v.(type) {
+	case map[string]interface{}:
+		for k, child := range value {
+			if volatileFields[strings.ToLower(k)] {
+				delete(value, k)
+				continue
+			}
+			value[k] = strip(child)
+		}
+		return value
+	case []interface{}:
+		for i, child := range value {
+			value[i] = strip(child)
+		}
+		return value
+	}
+	return v
+}
+
+// Record stores the hash of data under key and reports whether it differs
+// from the previously recorded hash, along with when it last changed. The
+// record expires ttl after the last call, so it goes once the data it
+// tracks does; a zero ttl keeps it until overwritten.
+func (t *Tracker) Record(key string, data interface{}, ttl time.Duration) (bool, time.Time, error) {
+	hash, err := Hash(data)
+	if err != nil {
+		return false, time.Time{}, err
+	}
+
+	redisKey := keyPrefix + key
+	previous, err := t.redis.HGet(t.ctx, redisKey, "hash").Result()
+	if err != nil && err != redis.Nil {
+		return false, time.Time{}, err
+	}
+
+	if previous == hash {
+		if ttl > 0 {
+			t.redis.Expire(t.ctx, redisKey, ttl)
+		}
+		changedAt, _ := t.ChangedAt(key)
+		return false, changedAt, nil
+	}
+
+	now := time.Now().UTC()
+	_, err = t.redis.TxPipelined(t.ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(t.ctx, redisKey, "hash", hash, "changed_at", now.Format(time.RFC3339Nano))
+		if ttl > 0 {
+			pipe.Expire(t.ctx, redisKey, ttl)
+		}
+		return nil
+	})
+	if err != nil {
+		return false, time.Time{}, err
+	}
+	return true, now, nil
+}
+
+func (t *Tracker) ChangedAt(key string) (time.Time, bool) {
+	value, err := t.redis.HGet(t.ctx, keyPrefix+key, "changed_at").Result()
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	changedAt, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return changedAt, true
+}
@@ -0,0 +1,62 @@
+package changes
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type quote struct {
+	Symbol    string  `json:"symbol"`
+	Price     float64 `json:"price"`
+	Timestamp string  `json:"timestamp"`
+}
+
+func TestHash(t *testing.T) {
+	t.Run("Ignores Timestamps", func(t *testing.T) {
+		a, err := Hash([]quote{{Symbol: "AAPL", Price: 190.5, Timestamp: "2024-01-01T10:00:00Z"}})
+		assert.NoError(t, err)
+		b, err := Hash([]quote{{Symbol: "AAPL", Price: 190.5, Timestamp: "2024-01-01T10:05:00Z"}})
+		assert.NoError(t, err)
+		assert.Equal(t, a, b)
+	})
+
+	t.Run("Detects Value Changes", func(t *testing.T) {
+		a, _ := Hash([]quote{{Symbol: "AAPL", Price: 190.5}})
+		b, _ := Hash([]quote{{Symbol: "AAPL", Price: 190.6}})
+		assert.NotEqual(t, a, b)
+	})
+
+	t.Run("Stable Map Ordering", func(t *testing.T) {
+		a, _ := Hash(map[string]int{"a": 1, "b": 2, "c": 3})
+		b, _ := Hash(map[string]int{"c": 3, "b": 2, "a": 1})
+		assert.Equal(t, a, b)
+	})
+}
+
+func TestRecordExpires(t *testing.T) {
+	mr := miniredis.RunT(t)
+	tracker := NewTracker(redis.NewClient(&redis.Options{Addr: mr.Addr()}))
+
+	changed, _, err := tracker.Record("quote:AAPL", quote{Symbol: "AAPL", Price: 190.5}, time.Hour)
+	require.NoError(t, err)
+	assert.True(t, changed)
+	assert.Equal(t, time.Hour, mr.TTL("changes:quote:AAPL"))
+
+	mr.FastForward(30 * time.Minute)
+	changed, _, err = tracker.Record("quote:AAPL", quote{Symbol: "AAPL", Price: 190.5}, time.Hour)
+	require.NoError(t, err)
+	assert.False(t, changed)
+	assert.Equal(t, time.Hour, mr.TTL("changes:quote:AAPL"), "unchanged data extends the record")
+
+	mr.FastForward(2 * time.Hour)
+	assert.False(t, mr.Exists("changes:quote:AAPL"))
+
+	_, _, err = tracker.Record("published:movers", quote{Symbol: "AAPL"}, 0)
+	require.NoError(t, err)
+	assert.Zero(t, mr.TTL("changes:published:movers"))
+}
@@ -0,0 +1,216 @@
+// Package client is a Go client for the GoFinance HTTP API.
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-webscraper/model"
+)
+
+type (
+	StockData  = model.StockData
+	SectorData = model.SectorData
+	Article    = model.Article
+)
+
+// Client calls one GoFinance server. The exported fields may be adjusted
+// after New and before the first request.
+type Client struct {
+	HTTPClient *http.Client
+	// MaxRetries is how many times a failed request is retried. Only
+	// transport errors, 429 and 5xx responses are retried.
+	MaxRetries int
+	// Backoff is the first retry delay; it doubles on each attempt unless
+	// the server sends Retry-After.
+	Backoff time.Duration
+
+	baseURL string
+	apiKey  string
+}
+
+// New builds a client for the server at baseURL. apiKey is sent as
+// X-API-Key and may be empty.
+func New(baseURL, apiKey string) *Client {
+	return &Client{
+		HTTPClient: &http.Client{Timeout: 2 * time.Minute},
+		MaxRetries: 3,
+		Backoff:    500 * time.Millisecond,
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		apiKey:     apiKey,
+	}
+}
+
+// APIError is a non-2xx response from the server.
+type APIError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("gofinance: %d: %s", e.StatusCode, e.Message)
+}
+
+// Quotes returns the latest quotes of symbols. Symbols the server has no
+// quote for are left out.
+func (c *Client) Quotes(ctx context.Context, symbols ...string) ([]StockData, error) {
+	var quotes []StockData
+	query := url.Values{"symbols": {strings.Join(symbols, ",")}}
+	if err := c.get(ctx, "/api/stock/quotes", query, &quotes); err != nil {
+		return nil, err
+	}
+	return quotes, nil
+}
+
+// Sector returns one sector's performance and top stocks.
+func (c *Client) Sector(ctx context.Context, name string) (*SectorData, error) {
+	var sector SectorData
+	if err := c.get(ctx, "/api/sector", url.Values{"sector": {name}}, &sector); err != nil {
+		return nil, err
+	}
+	return &sector, nil
+}
+
+// News returns articles published since the given time, or the full feed
+// when since is zero.
+func (c *Client) News(ctx context.Context, since time.Time) ([]Article, error) {
+	query := url.Values{}
+	if !since.IsZero() {
+		query.Set("since", since.Format(time.RFC3339))
+	}
+
+	var articles []Article
+	if err := c.get(ctx, "/api/news", query, &articles); err != nil {
+		return nil, err
+	}
+	return articles, nil
+}
+
+// StreamQuotes polls symbols' quotes every interval and passes each batch
+// to fn until ctx is done or fn returns an error. Polls that fail after
+// their retries are skipped rather than ending the stream, so a brief
+// outage only costs the updates it spans.
+func (c *Client) StreamQuotes(ctx context.Context, symbols []string, interval time.Duration, fn func([]StockData) error) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		quotes, err := c.Quotes(ctx, symbols...)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && !retryable(apiErr.StatusCode) {
+			return err
+		}
+		if err == nil {
+			if err := fn(quotes); err != nil {
+				return err
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+func retryable(status int) bool {
+	return status == http.StatusTooManyRequests || status >= 500
+}
+
+func (c *Client) get(ctx context.Context, path string, query url.Values, out interface{}) error {
+	target := c.baseURL + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+
+	backoff := c.Backoff
+	var lastErr error
+	for attempt := 0; attempt <= c.MaxRetries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(backoff):
+			}
+			backoff *= 2
+		}
+
+		wait, err := c.do(ctx, target, out)
+		if err == nil {
+			return nil
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && !retryable(apiErr.StatusCode) {
+			return err
+		}
+		if wait > 0 {
+			backoff = wait
+		}
+		lastErr = err
+	}
+	return lastErr
+}
+
+// do makes one request, returning the server's Retry-After on failure.
+func (c *Client) do(ctx context.Context, target string, out interface{}) (time.Duration, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if c.apiKey != "" {
+		req.Header.Set("X-API-Key", c.apiKey)
+	}
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read response: %v", err)
+	}
+
+	var envelope struct {
+		Status string          `json:"status"`
+		Error  string          `json:"error"`
+		Data   json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(body, &envelope); err != nil && resp.StatusCode < 300 {
+		return 0, fmt.Errorf("failed to decode response: %v", err)
+	}
+
+	if resp.StatusCode >= 300 {
+		message := envelope.Error
+		if message == "" {
+			message = http.StatusText(resp.StatusCode)
+		}
+		var wait time.Duration
+		if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+			wait = time.Duration(seconds) * time.Second
+		}
+		return wait, &APIError{StatusCode: resp.StatusCode, Message: message}
+	}
+
+	if err := json.Unmarshal(envelope.Data, out); err != nil {
+		return 0, fmt.Errorf("failed to decode data: %v", err)
+	}
+	return 0, nil
+}
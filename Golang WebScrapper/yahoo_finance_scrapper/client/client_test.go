@@ -0,0 +1,92 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQuotes(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/stock/quotes", r.URL.Path)
+		assert.Equal(t, "AAPL,MSFT", r.URL.Query().Get("symbols"))
+		assert.Equal(t, "secret", r.Header.Get("X-API-Key"))
+
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte(`{"status":"error","error":"server is busy, try again later"}`))
+			return
+		}
+		w.Write([]byte(`{"status":"success","data":[{"symbol":"AAPL","price":190.5},{"symbol":"MSFT","price":410}]}`))
+	}))
+	defer srv.Close()
+
+	c := New(srv.URL+"/", "secret")
+	c.Backoff = time.Millisecond
+
+	quotes, err := c.Quotes(context.Background(), "AAPL", "MSFT")
+	assert.NoError(t, err)
+	assert.Equal(t, int32(2), calls)
+	assert.Len(t, quotes, 2)
+	assert.Equal(t, 190.5, quotes[0].Price)
+}
+
+func TestErrors(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		if r.URL.Path == "/api/sector" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":"unknown sector"}`))
+			return
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	c := New(srv.URL, "")
+	c.Backoff = time.Millisecond
+
+	_, err := c.Sector(context.Background(), "nope")
+	var apiErr *APIError
+	assert.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, http.StatusBadRequest, apiErr.StatusCode)
+	assert.Equal(t, "unknown sector", apiErr.Message)
+	assert.Equal(t, int32(1), calls, "client errors are not retried")
+
+	calls = 0
+	_, err = c.News(context.Background(), time.Time{})
+	assert.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, http.StatusInternalServerError, apiErr.StatusCode)
+	assert.Equal(t, int32(c.MaxRetries+1), calls)
+}
+
+func TestStreamQuotes(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"status":"success","data":[{"symbol":"AAPL","price":190.5}]}`))
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	batches := 0
+	err := New(srv.URL, "").StreamQuotes(ctx, []string{"AAPL"}, time.Millisecond, func(quotes []StockData) error {
+		assert.Equal(t, "AAPL", quotes[0].Symbol)
+		batches++
+		if batches == 3 {
+			cancel()
+		}
+		return nil
+	})
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, 3, batches)
+}
@@ -0,0 +1,145 @@
+// Package compat serves responses under other APIs' field names, so
+// integrators moving from them keep their clients unchanged. A profile's
+// names come from struct tags named after it, e.g.
+// `yahoo:"regularMarketPrice"` on a field tagged `json:"price"`; fields
+// without one keep their JSON name.
+package compat
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"go-webscraper/model"
+)
+
+const (
+	ProfileDefault = "default"
+	ProfileYahoo   = "yahoo"
+)
+
+// Profiles lists the accepted ?profile= values.
+var Profiles = []string{ProfileDefault, ProfileYahoo}
+
+// types are the response types profiles rename.
+var types = []interface{}{
+	model.StockData{},
+	model.SectorData{},
+	model.SubSector{},
+	model.Article{},
+	model.Entity{},
+}
+
+// shape is how one type is written under a profile. JSON objects are
+// matched to a type by their keys: every key must be one of its fields,
+// and every field encoding/json always writes must be present.
+type shape struct {
+	fields   map[string]string
+	required []string
+}
+
+var shapes = map[string][]shape{
+	ProfileYahoo: shapesFor(ProfileYahoo, types),
+}
+
+func shapesFor(profile string, types []interface{}) []shape {
+	result := make([]shape, 0, len(types))
+	for _, v := range types {
+		t := reflect.TypeOf(v)
+		s := shape{fields: make(map[string]string)}
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			name, opts, _ := strings.Cut(field.Tag.Get("json"), ",")
+			if name == "-" || !field.IsExported() {
+				continue
+			}
+			if name == "" {
+				name = field.Name
+			}
+			renamed := field.Tag.Get(profile)
+			if renamed == "" {
+				renamed = name
+			}
+			s.fields[name] = renamed
+			if !strings.Contains(opts, "omitempty") {
+				s.required = append(s.required, name)
+			}
+		}
+		result = append(result, s)
+	}
+	return result
+}
+
+func (s shape) matches(object map[string]interface{}) bool {
+	for key := range object {
+		if _, ok := s.fields[key]; !ok {
+			return false
+		}
+	}
+	for _, name := range s.required {
+		if _, ok := object[name]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// Valid reports whether profile is one of Profiles.
+func Valid(profile string) bool {
+	for _, p := range Profiles {
+		if p == profile {
+			return true
+		}
+	}
+	return false
+}
+
+// Rename rewrites a JSON document's objects that match a response type to
+// use profile's field names. Values, numbers included, are kept as is,
+// though object keys come out sorted.
+func Rename(data []byte, profile string) ([]byte, error) {
+	profileShapes, ok := shapes[profile]
+	if !ok {
+		if profile == ProfileDefault || profile == "" {
+			return data, nil
+		}
+		return nil, fmt.Errorf("unknown profile: %s", profile)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var doc interface{}
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(rename(doc, profileShapes))
+}
+
+func rename(v interface{}, profileShapes []shape) interface{} {
+	switch v := v.(type) {
+	case []interface{}:
+		for i, item := range v {
+			v[i] = rename(item, profileShapes)
+		}
+		return v
+	case map[string]interface{}:
+		for key, item := range v {
+			v[key] = rename(item, profileShapes)
+		}
+		for _, s := range profileShapes {
+			if !s.matches(v) {
+				continue
+			}
+			renamed := make(map[string]interface{}, len(v))
+			for key, item := range v {
+				renamed[s.fields[key]] = item
+			}
+			return renamed
+		}
+		return v
+	}
+	return v
+}
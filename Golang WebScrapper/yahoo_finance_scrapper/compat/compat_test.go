@@ -0,0 +1,102 @@
+package compat
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go-webscraper/model"
+)
+
+func TestShapesHaveDistinctNames(t *testing.T) {
+	for profile, profileShapes := range shapes {
+		for _, s := range profileShapes {
+			seen := make(map[string]bool)
+			for _, renamed := range s.fields {
+				assert.False(t, seen[renamed], "%s renames two fields to %s", profile, renamed)
+				seen[renamed] = true
+			}
+		}
+	}
+}
+
+func TestRename(t *testing.T) {
+	stock := model.StockData{Symbol: "AAPL", Name: "Apple Inc.", Price: 189.25, Volume: 1000, Timestamp: "2024-01-02"}
+	data, err := json.Marshal(gin.H{
+		"status": "success",
+		"data":   []model.StockData{stock},
+		"news":   []model.Article{{DatePublished: "2024-01-02", Title: "Apple <up>"}},
+	})
+	require.NoError(t, err)
+
+	renamed, err := Rename(data, ProfileYahoo)
+	require.NoError(t, err)
+
+	var got struct {
+		Status string                   `json:"status"`
+		Data   []map[string]interface{} `json:"data"`
+		News   []map[string]interface{} `json:"news"`
+	}
+	require.NoError(t, json.Unmarshal(renamed, &got))
+	assert.Equal(t, "success", got.Status)
+	require.Len(t, got.Data, 1)
+	assert.Equal(t, "AAPL", got.Data[0]["symbol"])
+	assert.Equal(t, "Apple Inc.", got.Data[0]["shortName"])
+	assert.Equal(t, 189.25, got.Data[0]["regularMarketPrice"])
+	assert.Equal(t, "2024-01-02", got.Data[0]["regularMarketTime"])
+	assert.NotContains(t, got.Data[0], "price")
+	require.Len(t, got.News, 1)
+	assert.Equal(t, "2024-01-02", got.News[0]["providerPublishTime"])
+	assert.Equal(t, "Apple <up>", got.News[0]["title"])
+
+	unchanged, err := Rename(data, ProfileDefault)
+	require.NoError(t, err)
+	assert.Equal(t, data, unchanged)
+
+	_, err = Rename(data, "bloomberg")
+	assert.Error(t, err)
+}
+
+func TestRenameLeavesOtherObjects(t *testing.T) {
+	data := []byte(`{"price":1.50,"name":"x","extra":true}`)
+	renamed, err := Rename(data, ProfileYahoo)
+	require.NoError(t, err)
+	assert.JSONEq(t, string(data), string(renamed))
+	assert.Contains(t, string(renamed), "1.50")
+}
+
+func TestMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(Middleware())
+	r.GET("/quote", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"status": "success", "data": model.StockData{Symbol: "AAPL", Price: 10}})
+	})
+	r.GET("/text", func(c *gin.Context) {
+		c.String(http.StatusOK, "price")
+	})
+
+	serve := func(target string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+		return w
+	}
+
+	w := serve("/quote?profile=yahoo")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"regularMarketPrice":10`)
+
+	w = serve("/quote")
+	assert.Contains(t, w.Body.String(), `"price":10`)
+
+	w = serve("/text?profile=yahoo")
+	assert.Equal(t, "price", w.Body.String())
+
+	w = serve("/quote?profile=bloomberg")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
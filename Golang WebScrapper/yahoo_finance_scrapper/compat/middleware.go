@@ -0,0 +1,138 @@
+package compat
+
+import (
+	"bytes"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// profileWriter buffers a response so its JSON can be renamed once the
+// handler is done. A handler that flushes is streaming, and is passed
+// through unchanged from then on.
+type profileWriter struct {
+	gin.ResponseWriter
+	header    http.Header
+	body      bytes.Buffer
+	status    int
+	streaming bool
+}
+
+func (w *profileWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *profileWriter) WriteHeader(code int) {
+	if w.status != 0 {
+		return
+	}
+	w.status = code
+}
+
+func (w *profileWriter) WriteHeaderNow() {
+	w.WriteHeader(http.StatusOK)
+}
+
+func (w *profileWriter) Write(data []byte) (int, error) {
+	if w.streaming {
+		return w.ResponseWriter.Write(data)
+	}
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.body.Write(data)
+}
+
+func (w *profileWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+func (w *profileWriter) Status() int {
+	if w.status == 0 {
+		return http.StatusOK
+	}
+	return w.status
+}
+
+func (w *profileWriter) Size() int {
+	if w.streaming {
+		return w.ResponseWriter.Size()
+	}
+	if w.status == 0 {
+		return -1
+	}
+	return w.body.Len()
+}
+
+func (w *profileWriter) Written() bool {
+	return w.status != 0
+}
+
+func (w *profileWriter) Flush() {
+	if !w.streaming {
+		w.commit(w.body.Bytes())
+		w.streaming = true
+	}
+	w.ResponseWriter.Flush()
+}
+
+// commit writes the buffered headers and body to the underlying writer.
+func (w *profileWriter) commit(body []byte) {
+	dst := w.ResponseWriter.Header()
+	for k, vv := range w.header {
+		dst[k] = vv
+	}
+	if w.status == 0 && len(body) == 0 {
+		return
+	}
+	w.ResponseWriter.WriteHeader(w.Status())
+	w.ResponseWriter.Write(body)
+	w.body.Reset()
+}
+
+// Middleware applies the ?profile= response profile to JSON responses.
+// Without one, or with the default profile, responses are left alone.
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		profile := c.Query("profile")
+		if !Valid(profile) && profile != "" {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"status": "error",
+				"error":  "profile must be one of " + strings.Join(Profiles, ", "),
+			})
+			c.Abort()
+			return
+		}
+		if _, ok := shapes[profile]; !ok {
+			c.Next()
+			return
+		}
+
+		w := c.Writer
+		pw := &profileWriter{ResponseWriter: w, header: make(http.Header)}
+		c.Writer = pw
+		defer func() { c.Writer = w }()
+
+		c.Next()
+
+		if pw.streaming {
+			return
+		}
+		body := pw.body.Bytes()
+		if strings.HasPrefix(pw.header.Get("Content-Type"), "application/json") && len(body) > 0 {
+			renamed, err := Rename(body, profile)
+			if err != nil {
+				log.Printf("Serving %s without the %s profile: %v", c.Request.URL.Path, profile, err)
+			} else {
+				body = renamed
+				if pw.header.Get("Content-Length") != "" {
+					pw.header.Set("Content-Length", strconv.Itoa(len(body)))
+				}
+			}
+		}
+		pw.commit(body)
+	}
+}
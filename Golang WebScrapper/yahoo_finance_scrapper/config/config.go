@@ -0,0 +1,597 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+type Config struct {
+	Admin   AdminConfig   `mapstructure:"admin"`
+	Auth    AuthConfig    `mapstructure:"auth"`
+	Audit   AuditConfig   `mapstructure:"audit"`
+	Usage   UsageConfig   `mapstructure:"usage"`
+	Abuse   AbuseConfig   `mapstructure:"abuse"`
+	CORS    CORSConfig    `mapstructure:"cors"`
+	Tracing TracingConfig `mapstructure:"tracing"`
+	Server  ServerConfig  `mapstructure:"server"`
+	Jobs    JobsConfig    `mapstructure:"jobs"`
+	Pool    PoolConfig    `mapstructure:"pool"`
+
+	Scheduler SchedulerConfig `mapstructure:"scheduler"`
+
+	Upstream  UpstreamConfig  `mapstructure:"upstream"`
+	WarmStart WarmStartConfig `mapstructure:"warm_start"`
+	Proxy     ProxyConfig     `mapstructure:"proxy"`
+	Redis     RedisConfig     `mapstructure:"redis"`
+	Storage   StorageConfig   `mapstructure:"storage"`
+	Refresh   RefreshConfig   `mapstructure:"refresh"`
+	Cache     CacheConfig     `mapstructure:"cache"`
+	Archive   ArchiveConfig   `mapstructure:"archive"`
+	Output    OutputConfig    `mapstructure:"output"`
+	Webhook   WebhookConfig   `mapstructure:"webhook"`
+	Bus       BusConfig       `mapstructure:"bus"`
+	Demo      DemoConfig      `mapstructure:"demo"`
+	UI        UIConfig        `mapstructure:"ui"`
+	Discord   DiscordConfig   `mapstructure:"discord"`
+	News      NewsConfig      `mapstructure:"news"`
+
+	Summarizer SummarizerConfig `mapstructure:"summarizer"`
+	Migrations MigrationsConfig `mapstructure:"migrations"`
+	Checks     ChecksConfig     `mapstructure:"checks"`
+
+	RateLimits map[string]RateLimitConfig `mapstructure:"rate_limits"`
+
+	// Selectors overrides the candidate CSS selector chain per parsed
+	// field, e.g. selectors.quote_table.price: ["td:nth-child(3)"].
+	Selectors map[string]map[string][]string `mapstructure:"selectors"`
+}
+
+// RateLimitConfig is one named profile; Key is ip, api_key or ip_sector.
+type RateLimitConfig struct {
+	RPS        float64       `mapstructure:"rps"`
+	Burst      int           `mapstructure:"burst"`
+	Expiration time.Duration `mapstructure:"expiration"`
+	Key        string        `mapstructure:"key"`
+}
+
+type AdminConfig struct {
+	Token      string   `mapstructure:"token"`
+	AllowCIDRs []string `mapstructure:"allow_cidrs"`
+	DenyCIDRs  []string `mapstructure:"deny_cidrs"`
+}
+
+// AuthConfig signs user tokens; without a secret one is generated at
+// startup and tokens stop working across restarts.
+type AuthConfig struct {
+	JWTSecret string        `mapstructure:"jwt_secret"`
+	TokenTTL  time.Duration `mapstructure:"token_ttl"`
+}
+
+type AuditConfig struct {
+	RetainFor time.Duration `mapstructure:"retain_for"`
+}
+
+// AbuseConfig bans a client for BanFor once it has been rate limited
+// MaxRateLimited times, or asked for MaxNotFound unknown endpoints, within
+// Window. Bans are kept in Redis and reloaded every SyncInterval, so every
+// instance enforces them.
+type AbuseConfig struct {
+	Window         time.Duration `mapstructure:"window"`
+	MaxRateLimited int           `mapstructure:"max_rate_limited"`
+	MaxNotFound    int           `mapstructure:"max_not_found"`
+	BanFor         time.Duration `mapstructure:"ban_for"`
+	SyncInterval   time.Duration `mapstructure:"sync_interval"`
+}
+
+// CORSPolicy sets which browser origins may call a set of routes. An
+// origin may be "*" for any, or hold one * such as
+// "https://*.example.com". Credentials can't be allowed with "*".
+type CORSPolicy struct {
+	AllowOrigins     []string      `mapstructure:"allow_origins"`
+	AllowMethods     []string      `mapstructure:"allow_methods"`
+	AllowHeaders     []string      `mapstructure:"allow_headers"`
+	ExposeHeaders    []string      `mapstructure:"expose_headers"`
+	AllowCredentials bool          `mapstructure:"allow_credentials"`
+	MaxAge           time.Duration `mapstructure:"max_age"`
+}
+
+// CORSConfig is the default policy, plus Routes keyed by path prefix, e.g.
+// "/api/sheets", whose policies replace it under that prefix. Route
+// policies take the fields they leave empty from the default.
+type CORSConfig struct {
+	CORSPolicy `mapstructure:",squash"`
+	Routes     map[string]CORSPolicy `mapstructure:"routes"`
+}
+
+// UsageConfig keeps per-API-key request counts for RetainFor, for up to
+// MaxKeys distinct keys a day.
+type UsageConfig struct {
+	RetainFor time.Duration `mapstructure:"retain_for"`
+	MaxKeys   int           `mapstructure:"max_keys"`
+}
+
+// ServerConfig bounds each request. Timeouts is keyed by route group
+// (the same names as rate_limits) with "default" applying to the rest.
+// Addr is where the API listens, over HTTPS when TLS is configured: a TCP
+// address, "unix:/path" for a Unix socket created with SocketMode, or
+// "systemd" ("systemd:name") for a socket passed by systemd activation.
+type ServerConfig struct {
+	MaxBodyBytes   int64                    `mapstructure:"max_body_bytes"`
+	MaxQueryLength int                      `mapstructure:"max_query_length"`
+	MaxQueryParams int                      `mapstructure:"max_query_params"`
+	MaxParamLength int                      `mapstructure:"max_param_length"`
+	Timeouts       map[string]time.Duration `mapstructure:"timeouts"`
+
+	Addr       string    `mapstructure:"addr"`
+	SocketMode uint32    `mapstructure:"socket_mode"`
+	TLS        TLSConfig `mapstructure:"tls"`
+}
+
+// TLSConfig serves HTTPS from CertFile and KeyFile, or, with Autocert,
+// from Let's Encrypt certificates for Domains cached in CacheDir. HTTPAddr,
+// in any form Addr takes, then serves plain HTTP for ACME challenges and,
+// with Redirect, sends everything else to HTTPS.
+type TLSConfig struct {
+	CertFile string   `mapstructure:"cert_file"`
+	KeyFile  string   `mapstructure:"key_file"`
+	Autocert bool     `mapstructure:"autocert"`
+	Domains  []string `mapstructure:"domains"`
+	Email    string   `mapstructure:"email"`
+	CacheDir string   `mapstructure:"cache_dir"`
+	HTTPAddr string   `mapstructure:"http_addr"`
+	Redirect bool     `mapstructure:"redirect"`
+}
+
+// JobsConfig sizes the worker pool behind ?async=true requests.
+type JobsConfig struct {
+	Workers    int           `mapstructure:"workers"`
+	ResultTTL  time.Duration `mapstructure:"result_ttl"`
+	MaxPending int64         `mapstructure:"max_pending"`
+}
+
+// PoolConfig bounds concurrent Yahoo scrapes. Interactive (API) scrapes
+// jump ahead of background refreshes; a full queue sheds with 503.
+// MaxInflight caps the API requests scraping at once: past it, requests
+// that miss the cache get a stale copy or a 503, and 0 turns that off.
+type PoolConfig struct {
+	Size                 int           `mapstructure:"size"`
+	MaxQueuedInteractive int           `mapstructure:"max_queued_interactive"`
+	MaxQueuedBackground  int           `mapstructure:"max_queued_background"`
+	RetryAfter           time.Duration `mapstructure:"retry_after"`
+	MaxInflight          int           `mapstructure:"max_inflight"`
+}
+
+// WarmStartConfig persists cached scrapes to File every Interval and loads
+// them back into Redis on boot.
+type WarmStartConfig struct {
+	Enabled  bool          `mapstructure:"enabled"`
+	File     string        `mapstructure:"file"`
+	Interval time.Duration `mapstructure:"interval"`
+	MaxAge   time.Duration `mapstructure:"max_age"`
+	MinTTL   time.Duration `mapstructure:"min_ttl"`
+}
+
+// TracingConfig exports OpenTelemetry spans to an OTLP/gRPC collector.
+type TracingConfig struct {
+	Enabled     bool    `mapstructure:"enabled"`
+	Endpoint    string  `mapstructure:"endpoint"`
+	Insecure    bool    `mapstructure:"insecure"`
+	ServiceName string  `mapstructure:"service_name"`
+	SampleRatio float64 `mapstructure:"sample_ratio"`
+}
+
+// UpstreamConfig tunes the shared HTTP transport used for every scrape.
+// Proxy is an outbound proxy URL, not to be confused with ProxyConfig.
+type UpstreamConfig struct {
+	MaxIdleConns          int           `mapstructure:"max_idle_conns"`
+	MaxConnsPerHost       int           `mapstructure:"max_conns_per_host"`
+	IdleConnTimeout       time.Duration `mapstructure:"idle_conn_timeout"`
+	TLSHandshakeTimeout   time.Duration `mapstructure:"tls_handshake_timeout"`
+	ResponseHeaderTimeout time.Duration `mapstructure:"response_header_timeout"`
+	HTTP2                 bool          `mapstructure:"http2"`
+	Proxy                 string        `mapstructure:"proxy"`
+	UserAgent             string        `mapstructure:"user_agent"`
+	RequestsPerMinute     int           `mapstructure:"requests_per_minute"`
+	MaxPageBytes          int64         `mapstructure:"max_page_bytes"`
+	PageTimeout           time.Duration `mapstructure:"page_timeout"`
+}
+
+// ProxyConfig controls which forwarding headers are believed when resolving
+// the client IP. With no trusted proxies the socket address is always used.
+type ProxyConfig struct {
+	TrustedProxies  []string `mapstructure:"trusted_proxies"`
+	RealIPHeaders   []string `mapstructure:"real_ip_headers"`
+	TrustedPlatform string   `mapstructure:"trusted_platform"`
+}
+
+// RedisConfig's Namespace prefixes every key so deployments can share one
+// Redis. MigrateKeys moves keys written without the namespace into it at
+// startup.
+type RedisConfig struct {
+	Addr        string `mapstructure:"addr"`
+	Password    string `mapstructure:"password"`
+	DB          int    `mapstructure:"db"`
+	Namespace   string `mapstructure:"namespace"`
+	MigrateKeys bool   `mapstructure:"migrate_keys"`
+}
+
+// StorageConfig's Driver is redis for an external Redis server, or sqlite
+// for the all-in-one mode: an embedded Redis-compatible server on
+// redis.addr whose keys are saved to the SQLite file at Path every
+// FlushInterval. sqlite pairs with the memory or none cache backend.
+type StorageConfig struct {
+	Driver        string        `mapstructure:"driver"`
+	Path          string        `mapstructure:"path"`
+	FlushInterval time.Duration `mapstructure:"flush_interval"`
+}
+
+// MigrationsConfig runs pending data migrations at startup when Auto is
+// set; otherwise run them with "gofinance migrate" before upgrading.
+type MigrationsConfig struct {
+	Auto bool `mapstructure:"auto"`
+}
+
+// ChecksConfig bounds the startup dependency checks. With Ignore set,
+// failed checks are logged and startup carries on; --ignore-checks sets
+// it for one run.
+type ChecksConfig struct {
+	Ignore  bool          `mapstructure:"ignore"`
+	Timeout time.Duration `mapstructure:"timeout"`
+}
+
+// CacheConfig selects where scraped responses are cached (redis, memory
+// or none) and sets their lifetimes per source: quotes, sectors, news,
+// profiles, statistics, calendar, dividends, options, fx and sheets.
+// Closed, if set, applies outside regular market hours.
+type CacheConfig struct {
+	Backend    string                    `mapstructure:"backend"`
+	MaxEntries int                       `mapstructure:"max_entries"`
+	TTL        map[string]CacheTTLConfig `mapstructure:"ttl"`
+}
+
+type CacheTTLConfig struct {
+	Open   time.Duration `mapstructure:"open"`
+	Closed time.Duration `mapstructure:"closed"`
+}
+
+type RefreshConfig struct {
+	Stocks   time.Duration `mapstructure:"stocks"`
+	Sectors  time.Duration `mapstructure:"sectors"`
+	News     time.Duration `mapstructure:"news"`
+	Calendar time.Duration `mapstructure:"calendar"`
+	Breadth  time.Duration `mapstructure:"breadth"`
+	Universe time.Duration `mapstructure:"universe"`
+	// WatchlistAlerts is how often watchlist alert policies are evaluated
+	// during the regular session
+	WatchlistAlerts time.Duration `mapstructure:"watchlist_alerts"`
+	// EODDelay is how long after the 16:00 ET close the end-of-day
+	// snapshot is taken, giving Yahoo time to settle the official close
+	EODDelay time.Duration `mapstructure:"eod_delay"`
+	// CoalesceWindow is how long one scrape of a symbol's quote answers
+	// every refresh of it
+	CoalesceWindow time.Duration `mapstructure:"coalesce_window"`
+}
+
+type ArchiveConfig struct {
+	Enabled       bool          `mapstructure:"enabled"`
+	Dir           string        `mapstructure:"dir"`
+	Layout        string        `mapstructure:"layout"`
+	Interval      time.Duration `mapstructure:"interval"`
+	CompressAfter time.Duration `mapstructure:"compress_after"`
+	RetainFor     time.Duration `mapstructure:"retain_for"`
+}
+
+// OutputConfig bounds the stock scrapers' output directory: every
+// Interval, files older than MaxAge are removed, then the oldest until the
+// directory fits in MaxSize bytes. Zero limits are off.
+type OutputConfig struct {
+	Dir      string        `mapstructure:"dir"`
+	MaxAge   time.Duration `mapstructure:"max_age"`
+	MaxSize  int64         `mapstructure:"max_size"`
+	Interval time.Duration `mapstructure:"interval"`
+}
+
+type WebhookConfig struct {
+	Timeout    time.Duration `mapstructure:"timeout"`
+	MaxRetries int           `mapstructure:"max_retries"`
+}
+
+// BusConfig selects the optional message bus; an empty driver disables it.
+type BusConfig struct {
+	Driver  string            `mapstructure:"driver"`
+	Brokers []string          `mapstructure:"brokers"`
+	NatsURL string            `mapstructure:"nats_url"`
+	Topics  map[string]string `mapstructure:"topics"`
+}
+
+// SchedulerConfig alerts operators over AlertChannels once a scheduled job
+// fails FailureThreshold runs in a row, and again when it recovers. Zero
+// disables alerting.
+type SchedulerConfig struct {
+	FailureThreshold int                  `mapstructure:"failure_threshold"`
+	AlertChannels    []AlertChannelConfig `mapstructure:"alert_channels"`
+}
+
+// AlertChannelConfig is a notify channel: Type is email or webhook.
+type AlertChannelConfig struct {
+	Type   string `mapstructure:"type"`
+	Target string `mapstructure:"target"`
+}
+
+// DemoConfig runs the server on synthetic data only: no Redis, no scheduled
+// refreshes and no Yahoo requests, with just the public read endpoints
+// behind the "demo" rate limit profile.
+type DemoConfig struct {
+	Enabled bool  `mapstructure:"enabled"`
+	Seed    int64 `mapstructure:"seed"`
+}
+
+// UIConfig serves the embedded web dashboard at /ui.
+type UIConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+}
+
+// DiscordConfig serves the Discord slash commands at
+// /api/discord/interactions, the application's Interactions Endpoint URL.
+// BotToken registers the commands at startup and posts alerts.
+type DiscordConfig struct {
+	Enabled       bool   `mapstructure:"enabled"`
+	ApplicationID string `mapstructure:"application_id"`
+	PublicKey     string `mapstructure:"public_key"`
+	BotToken      string `mapstructure:"bot_token"`
+	GuildID       string `mapstructure:"guild_id"`
+}
+
+// NewsConfig picks how news scrapes discover articles: "crawl" spiders
+// the news hub, "feeds" reads Feeds, RSS feeds or sitemaps given as URLs
+// or paths on the region's site. No feeds reads Yahoo's default feeds.
+type NewsConfig struct {
+	Source string   `mapstructure:"source"`
+	Feeds  []string `mapstructure:"feeds"`
+}
+
+// SummarizerConfig adds a summary of Sentences sentences to the articles
+// the scheduled news refresh scrapes and serves the news digest. Provider
+// is extractive, llm or none; llm posts to the OpenAI-compatible chat
+// completions URL.
+type SummarizerConfig struct {
+	Provider  string        `mapstructure:"provider"`
+	Sentences int           `mapstructure:"sentences"`
+	URL       string        `mapstructure:"url"`
+	Model     string        `mapstructure:"model"`
+	APIKey    string        `mapstructure:"api_key"`
+	Timeout   time.Duration `mapstructure:"timeout"`
+}
+
+func setDefaults(v *viper.Viper) {
+	v.SetDefault("cache.backend", "redis")
+	v.SetDefault("cache.max_entries", 10000)
+
+	v.SetDefault("admin.token", "")
+	v.SetDefault("admin.allow_cidrs", []string{})
+	v.SetDefault("admin.deny_cidrs", []string{})
+
+	v.SetDefault("auth.jwt_secret", "")
+	v.SetDefault("auth.token_ttl", 24*time.Hour)
+
+	v.SetDefault("audit.retain_for", 90*24*time.Hour)
+	v.SetDefault("usage.retain_for", 90*24*time.Hour)
+	v.SetDefault("usage.max_keys", 10000)
+	v.SetDefault("cors.allow_origins", []string{"*"})
+	v.SetDefault("cors.allow_methods", []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"})
+	v.SetDefault("cors.allow_headers", []string{"Origin", "Content-Type", "Accept", "Authorization", "X-API-Key"})
+	v.SetDefault("cors.expose_headers", []string{"Content-Length", "X-GoFinance-Demo", "X-GoFinance-Mode"})
+	v.SetDefault("cors.allow_credentials", false)
+	v.SetDefault("cors.max_age", 12*time.Hour)
+	v.SetDefault("abuse.window", 10*time.Minute)
+	v.SetDefault("abuse.max_rate_limited", 50)
+	v.SetDefault("abuse.max_not_found", 30)
+	v.SetDefault("abuse.ban_for", time.Hour)
+	v.SetDefault("abuse.sync_interval", time.Minute)
+
+	v.SetDefault("server.addr", ":8080")
+	v.SetDefault("server.socket_mode", 0o660)
+	v.SetDefault("server.tls.autocert", false)
+	v.SetDefault("server.tls.cache_dir", "certs")
+	v.SetDefault("server.tls.http_addr", ":80")
+	v.SetDefault("server.tls.redirect", true)
+	v.SetDefault("server.max_body_bytes", 1<<20)
+	v.SetDefault("server.max_query_length", 2048)
+	v.SetDefault("server.max_query_params", 32)
+	v.SetDefault("server.max_param_length", 256)
+	v.SetDefault("server.timeouts.default", 30*time.Second)
+	v.SetDefault("server.timeouts.sector", 2*time.Minute)
+	v.SetDefault("server.timeouts.news", 2*time.Minute)
+	v.SetDefault("server.timeouts.mcp", 2*time.Minute)
+	v.SetDefault("server.timeouts.query", 2*time.Minute)
+	v.SetDefault("server.timeouts.archive", 10*time.Minute)
+
+	v.SetDefault("jobs.workers", 4)
+	v.SetDefault("jobs.result_ttl", 1*time.Hour)
+	v.SetDefault("jobs.max_pending", 100)
+
+	v.SetDefault("scheduler.failure_threshold", 3)
+
+	v.SetDefault("pool.size", 8)
+	v.SetDefault("pool.max_queued_interactive", 32)
+	v.SetDefault("pool.max_queued_background", 8)
+	v.SetDefault("pool.retry_after", 5*time.Second)
+	v.SetDefault("pool.max_inflight", 24)
+
+	v.SetDefault("upstream.max_idle_conns", 100)
+	v.SetDefault("upstream.max_conns_per_host", 16)
+	v.SetDefault("upstream.idle_conn_timeout", 90*time.Second)
+	v.SetDefault("upstream.tls_handshake_timeout", 10*time.Second)
+	v.SetDefault("upstream.response_header_timeout", 30*time.Second)
+	v.SetDefault("upstream.http2", true)
+	v.SetDefault("upstream.proxy", "")
+	v.SetDefault("upstream.user_agent", "")
+	v.SetDefault("upstream.requests_per_minute", 0)
+	v.SetDefault("upstream.max_page_bytes", 10<<20)
+	v.SetDefault("upstream.page_timeout", 60*time.Second)
+
+	v.SetDefault("warm_start.enabled", true)
+	v.SetDefault("warm_start.file", "snapshots/boot.json")
+	v.SetDefault("warm_start.interval", 10*time.Minute)
+	v.SetDefault("warm_start.max_age", 24*time.Hour)
+	v.SetDefault("warm_start.min_ttl", 5*time.Minute)
+
+	v.SetDefault("tracing.enabled", false)
+	v.SetDefault("tracing.endpoint", "localhost:4317")
+	v.SetDefault("tracing.insecure", true)
+	v.SetDefault("tracing.service_name", "gofinance")
+	v.SetDefault("tracing.sample_ratio", 1.0)
+
+	v.SetDefault("proxy.trusted_proxies", []string{})
+	v.SetDefault("proxy.real_ip_headers", []string{"X-Forwarded-For", "X-Real-IP"})
+	v.SetDefault("proxy.trusted_platform", "")
+
+	v.SetDefault("redis.addr", "localhost:6379")
+	v.SetDefault("redis.password", "")
+	v.SetDefault("redis.db", 0)
+	v.SetDefault("redis.namespace", "")
+	v.SetDefault("redis.migrate_keys", false)
+
+	v.SetDefault("storage.driver", "redis")
+	v.SetDefault("storage.path", "gofinance.db")
+	v.SetDefault("checks.ignore", false)
+	v.SetDefault("checks.timeout", 10*time.Second)
+	v.SetDefault("storage.flush_interval", 30*time.Second)
+
+	v.SetDefault("migrations.auto", true)
+
+	v.SetDefault("refresh.stocks", 5*time.Minute)
+	v.SetDefault("refresh.sectors", 30*time.Minute)
+	v.SetDefault("refresh.news", 15*time.Minute)
+	v.SetDefault("refresh.calendar", 1*time.Hour)
+	v.SetDefault("refresh.breadth", 5*time.Minute)
+	v.SetDefault("refresh.universe", 5*time.Minute)
+	v.SetDefault("refresh.watchlist_alerts", 5*time.Minute)
+	v.SetDefault("refresh.eod_delay", 15*time.Minute)
+	v.SetDefault("refresh.coalesce_window", 5*time.Second)
+
+	v.SetDefault("archive.enabled", true)
+	v.SetDefault("archive.dir", "exports")
+	v.SetDefault("archive.layout", "{source}/2006/01")
+	v.SetDefault("archive.interval", 24*time.Hour)
+	v.SetDefault("archive.compress_after", 7*24*time.Hour)
+	v.SetDefault("archive.retain_for", 90*24*time.Hour)
+
+	v.SetDefault("output.dir", "stock_data")
+	v.SetDefault("output.max_age", 0)
+	v.SetDefault("output.max_size", 0)
+	v.SetDefault("output.interval", 10*time.Minute)
+
+	v.SetDefault("webhook.timeout", 10*time.Second)
+	v.SetDefault("webhook.max_retries", 3)
+
+	v.SetDefault("bus.driver", "")
+	v.SetDefault("bus.brokers", []string{"localhost:9092"})
+	v.SetDefault("bus.nats_url", "nats://localhost:4222")
+
+	v.SetDefault("demo.enabled", false)
+	v.SetDefault("demo.seed", 1)
+	v.SetDefault("ui.enabled", true)
+
+	v.SetDefault("discord.enabled", false)
+	v.SetDefault("discord.application_id", "")
+	v.SetDefault("discord.public_key", "")
+	v.SetDefault("discord.bot_token", "")
+	v.SetDefault("discord.guild_id", "")
+
+	v.SetDefault("news.source", "crawl")
+	v.SetDefault("news.feeds", []string{})
+
+	v.SetDefault("summarizer.provider", "extractive")
+	v.SetDefault("summarizer.sentences", 3)
+	v.SetDefault("summarizer.url", "https://api.openai.com/v1/chat/completions")
+	v.SetDefault("summarizer.model", "gpt-4o-mini")
+	v.SetDefault("summarizer.api_key", "")
+	v.SetDefault("summarizer.timeout", 20*time.Second)
+
+	v.SetDefault("rate_limits.demo", map[string]interface{}{"rps": 1, "burst": 5, "expiration": time.Hour, "key": "ip"})
+	v.SetDefault("rate_limits.news", map[string]interface{}{"rps": 5, "burst": 10, "expiration": time.Hour, "key": "ip"})
+	v.SetDefault("rate_limits.stock", map[string]interface{}{"rps": 5, "burst": 10, "expiration": time.Hour, "key": "ip"})
+	v.SetDefault("rate_limits.sector", map[string]interface{}{"rps": 2, "burst": 5, "expiration": time.Hour, "key": "ip_sector"})
+}
+
+// Load reads config.yaml from the working directory (if present) and lets
+// GOFINANCE_* environment variables override any key, e.g.
+// GOFINANCE_ARCHIVE_DIR overrides archive.dir.
+func Load() (*Config, error) {
+	v := viper.New()
+	v.SetConfigName("config")
+	v.SetConfigType("yaml")
+	v.AddConfigPath(".")
+	v.SetEnvPrefix("GOFINANCE")
+	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	v.AutomaticEnv()
+
+	setDefaults(v)
+
+	if err := v.ReadInConfig(); err != nil {
+		var notFound viper.ConfigFileNotFoundError
+		if !errors.As(err, &notFound) {
+			return nil, err
+		}
+	}
+
+	var cfg Config
+	if err := v.Unmarshal(&cfg); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+// ApplyFlags overrides cfg with the command line flags in args and
+// returns the arguments after them, such as a command like "migrate".
+// --storage sets storage.driver and --cache sets cache.backend, so
+// --storage=sqlite --cache=memory runs the all-in-one mode, --verify is
+// the same as the "verify" command and --ignore-checks starts despite
+// failed startup checks.
+func ApplyFlags(cfg *Config, args []string) ([]string, error) {
+	flags := flag.NewFlagSet("gofinance", flag.ContinueOnError)
+	storage := flags.String("storage", cfg.Storage.Driver, "storage driver: redis or sqlite")
+	cache := flags.String("cache", cfg.Cache.Backend, "cache backend: redis, memory or none")
+	verify := flags.Bool("verify", false, "check the parsers against their stored fixtures and exit")
+	ignoreChecks := flags.Bool("ignore-checks", cfg.Checks.Ignore, "start even if the startup dependency checks fail")
+	if err := flags.Parse(args); err != nil {
+		return nil, err
+	}
+	cfg.Storage.Driver = *storage
+	cfg.Cache.Backend = *cache
+	cfg.Checks.Ignore = *ignoreChecks
+	if *verify {
+		return append([]string{"verify"}, flags.Args()...), nil
+	}
+	return flags.Args(), nil
+}
+
+type EmailConfig struct {
+	Email    string `mapstructure:"EMAIL"`
+	Password string `mapstructure:"PASSWORD"`
+	SMTPHost string `mapstructure:"SMTP_HOST"`
+	SMTPPort int    `mapstructure:"SMTP_PORT"`
+}
+
+// LoadEmail reads SMTP credentials from config.email.env, the format
+// described by error.NoEmailConfigFound.
+func LoadEmail() (*EmailConfig, error) {
+	v := viper.New()
+	v.SetConfigFile("config.email.env")
+	v.SetConfigType("env")
+
+	if err := v.ReadInConfig(); err != nil {
+		return nil, err
+	}
+
+	var cfg EmailConfig
+	if err := v.Unmarshal(&cfg); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
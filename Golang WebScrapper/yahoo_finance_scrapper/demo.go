@@ -0,0 +1,37 @@
+package main
+
+import (
+	"log"
+	"net/http"
+
+	"go-webscraper/config"
+	"go-webscraper/demo"
+	"go-webscraper/middleware"
+	"go-webscraper/ui"
+
+	"github.com/gin-gonic/gin"
+)
+
+// serveDemo runs the public demo server. It sets up nothing that scrapes
+// or needs Redis, so an instance can be hosted without Yahoo traffic.
+func serveDemo(cfg *config.Config) {
+	log.Printf("Demo mode: serving synthetic data only")
+
+	r := newRouter(cfg)
+	api := r.Group("/api")
+	api.Use(middleware.RateLimitProfile("demo"), middleware.Timeout(cfg.Server.Timeouts["default"]))
+	demo.Register(api, demo.NewMarket(cfg.Demo.Seed))
+	if cfg.UI.Enabled {
+		ui.Register(r)
+	}
+
+	r.NoRoute(func(c *gin.Context) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "not available in demo mode",
+		})
+	})
+
+	if err := r.Run(":8080"); err != nil {
+		panic(err)
+	}
+}
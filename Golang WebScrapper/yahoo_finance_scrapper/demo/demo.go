@@ -0,0 +1,153 @@
+package demo
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxSymbols and validSymbol mirror the live quotes endpoint.
+const maxSymbols = 100
+
+var validSymbol = regexp.MustCompile(`^[A-Za-z0-9.\-^=]{1,12}$`)
+
+// now is swapped out by tests.
+var now = time.Now
+
+// respond writes data in the live API's envelope, marked as demo data in
+// the body and in a header for clients that only look at headers.
+func respond(c *gin.Context, data interface{}) {
+	c.Header("X-GoFinance-Demo", "true")
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"demo":   true,
+		"data":   data,
+	})
+}
+
+// Register mounts the demo versions of the public read endpoints on api,
+// at the same paths and with the same parameters as the live ones.
+func Register(api *gin.RouterGroup, market *Market) {
+	api.GET("/stock", HandleStock(market))
+	api.GET("/stock/quotes", HandleQuotes(market))
+	api.GET("/sector", HandleSector(market))
+	api.GET("/news", HandleNews(market))
+}
+
+func HandleStock(market *Market) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		at := now()
+		switch category := c.DefaultQuery("category", "most_active"); category {
+		case "most_active", "gainers", "losers", "trending":
+			stocks, _ := market.Movers(category, at)
+			respond(c, stocks)
+		case "overview":
+			overview := make(map[string]interface{})
+			for _, movers := range []string{"most_active", "gainers", "losers"} {
+				overview[movers], _ = market.Movers(movers, at)
+			}
+			respond(c, overview)
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "invalid category",
+			})
+		}
+	}
+}
+
+func HandleQuotes(market *Market) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		symbols, err := parseSymbols(c.Query("symbols"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+		respond(c, market.Quotes(symbols, now()))
+	}
+}
+
+func HandleSector(market *Market) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		at := now()
+		if c.Query("all") == "true" {
+			sectors := make(map[string]interface{})
+			for _, l := range listings {
+				if _, ok := sectors[l.Sector]; !ok {
+					sectors[l.Sector], _ = market.Sector(l.Sector, at)
+				}
+			}
+			respond(c, sectors)
+			return
+		}
+
+		name := c.Query("sector")
+		if name == "" {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "please specify sector parameter or use all=true",
+			})
+			return
+		}
+		sector, err := market.Sector(name, at)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+		respond(c, sector)
+	}
+}
+
+func HandleNews(market *Market) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		at := now()
+		articles := market.News(at)
+		if raw := c.Query("since"); raw != "" {
+			since, err := time.Parse(time.RFC3339, raw)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{
+					"status": "error",
+					"error":  "since must be an RFC3339 timestamp",
+				})
+				return
+			}
+			kept := articles[:0]
+			for _, article := range articles {
+				if published, err := time.Parse(time.RFC3339, article.DatePublished); err == nil && !published.Before(since) {
+					kept = append(kept, article)
+				}
+			}
+			articles = kept
+		}
+		respond(c, articles)
+	}
+}
+
+func parseSymbols(raw string) ([]string, error) {
+	symbols := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, symbol := range strings.Split(raw, ",") {
+		symbol = strings.ToUpper(strings.TrimSpace(symbol))
+		if symbol == "" || seen[symbol] {
+			continue
+		}
+		if !validSymbol.MatchString(symbol) {
+			return nil, fmt.Errorf("invalid symbol: %s", symbol)
+		}
+		seen[symbol] = true
+		symbols = append(symbols, symbol)
+	}
+	if len(symbols) == 0 {
+		return nil, fmt.Errorf("symbols is required")
+	}
+	if len(symbols) > maxSymbols {
+		return nil, fmt.Errorf("at most %d symbols per request", maxSymbols)
+	}
+	return symbols, nil
+}
@@ -0,0 +1,94 @@
+package demo
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go-webscraper/model"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMarketQuote(t *testing.T) {
+	market := NewMarket(1)
+	// A Tuesday, an hour after the open
+	at := time.Date(2024, 3, 5, 15, 30, 0, 0, time.UTC)
+
+	quote := market.Quote("aapl", at)
+	assert.Equal(t, "AAPL", quote.Symbol)
+	assert.Equal(t, "Apple Inc.", quote.Name)
+	assert.Greater(t, quote.Price, 0.0)
+	assert.Equal(t, quote, market.Quote("AAPL", at), "quotes are deterministic")
+	assert.Equal(t, quote.Price, market.Quote("AAPL", at.Add(time.Minute)).Price, "prices step every five minutes")
+	assert.NotEqual(t, quote.Price, NewMarket(2).Quote("AAPL", at).Price)
+
+	closed := market.Quote("AAPL", time.Date(2024, 3, 5, 23, 0, 0, 0, time.UTC))
+	assert.Equal(t, closed.Price, market.Quote("AAPL", time.Date(2024, 3, 6, 1, 0, 0, 0, time.UTC)).Price, "prices hold after the close")
+}
+
+func TestMarketMovers(t *testing.T) {
+	at := time.Date(2024, 3, 5, 18, 0, 0, 0, time.UTC)
+	gainers, err := NewMarket(1).Movers("gainers", at)
+	assert.NoError(t, err)
+	assert.Len(t, gainers, 10)
+	for i := 1; i < len(gainers); i++ {
+		assert.GreaterOrEqual(t, gainers[i-1].ChangePerc, gainers[i].ChangePerc)
+	}
+
+	trending, err := NewMarket(1).Movers("trending", at)
+	assert.NoError(t, err)
+	for i := 1; i < len(trending); i++ {
+		assert.GreaterOrEqual(t, math.Abs(trending[i-1].ChangePerc), math.Abs(trending[i].ChangePerc))
+	}
+
+	_, err = NewMarket(1).Movers("52_week_highs", at)
+	assert.Error(t, err)
+}
+
+func TestHandlers(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	now = func() time.Time { return time.Date(2024, 3, 5, 18, 0, 0, 0, time.UTC) }
+	defer func() { now = time.Now }()
+
+	r := gin.New()
+	Register(r.Group("/api"), NewMarket(1))
+
+	t.Run("Quotes", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("GET", "/api/stock/quotes?symbols=msft,AAPL,msft", nil))
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "true", w.Header().Get("X-GoFinance-Demo"))
+
+		var body struct {
+			Demo bool              `json:"demo"`
+			Data []model.StockData `json:"data"`
+		}
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		assert.True(t, body.Demo)
+		assert.Len(t, body.Data, 2)
+		assert.Equal(t, "MSFT", body.Data[0].Symbol)
+	})
+
+	t.Run("Invalid Sector", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("GET", "/api/sector?sector=crypto", nil))
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("News Since", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("GET", "/api/news?since=2024-03-05T15:00:00Z", nil))
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var body struct {
+			Data []model.Article `json:"data"`
+		}
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		assert.Len(t, body.Data, 4)
+	})
+}
@@ -0,0 +1,273 @@
+// Package demo serves synthetic market data for public demo instances. It
+// never contacts Yahoo: prices are a deterministic random walk seeded by
+// symbol and day, so every replica of a demo server agrees on the numbers
+// and they move through the trading day like real quotes.
+package demo
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math"
+	"math/rand"
+	"sort"
+	"strings"
+	"time"
+
+	"go-webscraper/model"
+	"go-webscraper/pkg/yahoo"
+)
+
+// stepInterval is how often synthetic prices move during market hours.
+const stepInterval = 5 * time.Minute
+
+var eastern = func() *time.Location {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		return time.FixedZone("EST", -5*60*60)
+	}
+	return loc
+}()
+
+type listing struct {
+	Symbol string
+	Name   string
+	Sector string
+}
+
+// listings is the demo universe. Other symbols are still quoted, under a
+// generated name.
+var listings = []listing{
+	{"AAPL", "Apple Inc.", "technology"},
+	{"MSFT", "Microsoft Corporation", "technology"},
+	{"NVDA", "NVIDIA Corporation", "technology"},
+	{"AMD", "Advanced Micro Devices, Inc.", "technology"},
+	{"JNJ", "Johnson & Johnson", "healthcare"},
+	{"PFE", "Pfizer Inc.", "healthcare"},
+	{"UNH", "UnitedHealth Group Incorporated", "healthcare"},
+	{"JPM", "JPMorgan Chase & Co.", "financial"},
+	{"BAC", "Bank of America Corporation", "financial"},
+	{"GS", "The Goldman Sachs Group, Inc.", "financial"},
+	{"XOM", "Exxon Mobil Corporation", "energy"},
+	{"CVX", "Chevron Corporation", "energy"},
+	{"AMZN", "Amazon.com, Inc.", "consumer"},
+	{"TSLA", "Tesla, Inc.", "consumer"},
+	{"HD", "The Home Depot, Inc.", "consumer"},
+	{"CAT", "Caterpillar Inc.", "industrial"},
+	{"BA", "The Boeing Company", "industrial"},
+	{"LIN", "Linde plc", "materials"},
+	{"NEE", "NextEra Energy, Inc.", "utilities"},
+	{"PLD", "Prologis, Inc.", "real_estate"},
+	{"GOOGL", "Alphabet Inc.", "communication"},
+	{"META", "Meta Platforms, Inc.", "communication"},
+}
+
+// Market generates synthetic data. The zero seed is valid; different seeds
+// give unrelated price histories.
+type Market struct {
+	seed int64
+}
+
+func NewMarket(seed int64) *Market {
+	return &Market{seed: seed}
+}
+
+// rng returns a generator determined by the seed and parts.
+func (m *Market) rng(parts ...string) *rand.Rand {
+	h := fnv.New64a()
+	fmt.Fprintf(h, "%d", m.seed)
+	for _, part := range parts {
+		h.Write([]byte{0})
+		h.Write([]byte(part))
+	}
+	return rand.New(rand.NewSource(int64(h.Sum64())))
+}
+
+// previousClose is the symbol's close before day, drifting a few percent
+// a day around a base price fixed per symbol.
+func (m *Market) previousClose(symbol string, day time.Time) float64 {
+	base := 20 + m.rng(symbol).Float64()*480
+	drift := m.rng(symbol, day.AddDate(0, 0, -1).Format("2006-01-02")).NormFloat64() * 0.02
+	return base * math.Exp(drift)
+}
+
+// price walks from the previous close in stepInterval steps from the open
+// to at, holding still outside market hours.
+func (m *Market) price(symbol string, at time.Time) (price, prevClose float64) {
+	local := at.In(eastern)
+	day := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, eastern)
+	prevClose = m.previousClose(symbol, day)
+
+	open := day.Add(9*time.Hour + 30*time.Minute)
+	closing := day.Add(16 * time.Hour)
+	if local.After(closing) {
+		local = closing
+	}
+	steps := 0
+	if weekday := day.Weekday(); weekday != time.Saturday && weekday != time.Sunday && local.After(open) {
+		steps = int(local.Sub(open) / stepInterval)
+	}
+
+	r := m.rng(symbol, day.Format("2006-01-02"), "intraday")
+	logReturn := 0.0
+	for i := 0; i < steps; i++ {
+		logReturn += r.NormFloat64() * 0.002
+	}
+	return prevClose * math.Exp(logReturn), prevClose
+}
+
+// Quote is the symbol's synthetic quote at the given time.
+func (m *Market) Quote(symbol string, at time.Time) model.StockData {
+	symbol = strings.ToUpper(symbol)
+	price, prevClose := m.price(symbol, at)
+
+	r := m.rng(symbol, "profile")
+	shares := int64(1e8 + r.Float64()*5e9)
+	volume := int64(float64(shares) * (0.002 + m.rng(symbol, at.Format("2006-01-02T15")).Float64()*0.01))
+
+	return model.StockData{
+		Symbol:     symbol,
+		Name:       nameOf(symbol),
+		Price:      round(price, 2),
+		Change:     round(price-prevClose, 2),
+		ChangePerc: round((price-prevClose)/prevClose*100, 2),
+		Volume:     volume,
+		MarketCap:  formatCap(price * float64(shares)),
+		Currency:   "USD",
+		Timestamp:  at.Format(time.RFC3339),
+	}
+}
+
+// Quotes quotes each symbol in order.
+func (m *Market) Quotes(symbols []string, at time.Time) []model.StockData {
+	quotes := make([]model.StockData, 0, len(symbols))
+	for _, symbol := range symbols {
+		quotes = append(quotes, m.Quote(symbol, at))
+	}
+	return quotes
+}
+
+// Movers ranks the demo universe like Yahoo's market movers pages:
+// most_active, gainers, losers or trending, where trending is the biggest
+// moves either way.
+func (m *Market) Movers(category string, at time.Time) ([]model.StockData, error) {
+	quotes := make([]model.StockData, 0, len(listings))
+	for _, l := range listings {
+		quotes = append(quotes, m.Quote(l.Symbol, at))
+	}
+
+	switch category {
+	case "most_active":
+		sort.Slice(quotes, func(i, j int) bool { return quotes[i].Volume > quotes[j].Volume })
+	case "gainers":
+		sort.Slice(quotes, func(i, j int) bool { return quotes[i].ChangePerc > quotes[j].ChangePerc })
+	case "losers":
+		sort.Slice(quotes, func(i, j int) bool { return quotes[i].ChangePerc < quotes[j].ChangePerc })
+	case "trending":
+		sort.Slice(quotes, func(i, j int) bool { return math.Abs(quotes[i].ChangePerc) > math.Abs(quotes[j].ChangePerc) })
+	default:
+		return nil, fmt.Errorf("unknown movers category: %s", category)
+	}
+	return quotes[:10], nil
+}
+
+// Sector summarizes the demo listings in the named sector, one of the
+// keys of yahoo.SectorURLs.
+func (m *Market) Sector(name string, at time.Time) (*model.SectorData, error) {
+	name = strings.ToLower(name)
+	if _, ok := yahoo.SectorURLs[name]; !ok {
+		return nil, fmt.Errorf("invalid sector: %s", name)
+	}
+
+	sector := &model.SectorData{
+		Name:          name,
+		TopStocks:     make([]model.StockData, 0),
+		SubIndustries: make([]model.SubSector, 0),
+		Timestamp:     at.Format(time.RFC3339),
+	}
+	for _, l := range listings {
+		if l.Sector != name {
+			continue
+		}
+		quote := m.Quote(l.Symbol, at)
+		sector.TopStocks = append(sector.TopStocks, quote)
+		sector.Performance += quote.ChangePerc
+		sector.Volume += quote.Volume
+	}
+	if len(sector.TopStocks) > 0 {
+		sector.Performance = round(sector.Performance/float64(len(sector.TopStocks)), 2)
+	}
+
+	r := m.rng(name, at.In(eastern).Format("2006-01-02"))
+	sector.Performance1M = round(r.NormFloat64()*4, 2)
+	sector.Performance3M = round(r.NormFloat64()*8, 2)
+	sector.Performance1Y = round(r.NormFloat64()*15+8, 2)
+	sector.AveragePE = round(12+r.Float64()*25, 2)
+	sector.Volatility = round(10+r.Float64()*30, 2)
+	sector.MarketCap = formatCap(1e12 + r.Float64()*9e12)
+	for i := 1; i <= 3; i++ {
+		sector.SubIndustries = append(sector.SubIndustries, model.SubSector{
+			Name:        fmt.Sprintf("%s %d", strings.ReplaceAll(name, "_", " "), i),
+			Performance: round(r.NormFloat64()*2, 2),
+			StockCount:  10 + r.Intn(90),
+			MarketCap:   formatCap(1e11 + r.Float64()*9e11),
+		})
+	}
+	return sector, nil
+}
+
+var headlines = []string{
+	"%s shares move as traders weigh quarterly guidance",
+	"Analysts revisit price targets on %s after investor day",
+	"%s announces expanded buyback program",
+	"What %s's latest filing says about margins",
+	"Options traders position for volatility in %s",
+}
+
+var articleTypes = []string{"editorial", "press_release", "editorial", "video", "editorial"}
+
+// News is a feed of hourly synthetic headlines about the demo listings,
+// newest first, covering the day before at.
+func (m *Market) News(at time.Time) []model.Article {
+	hour := at.Truncate(time.Hour)
+	articles := make([]model.Article, 0, 24)
+	for i := 0; i < 24; i++ {
+		published := hour.Add(-time.Duration(i) * time.Hour)
+		r := m.rng("news", published.Format(time.RFC3339))
+		l := listings[r.Intn(len(listings))]
+		n := r.Intn(len(headlines))
+		articles = append(articles, model.Article{
+			DatePublished: published.Format(time.RFC3339),
+			Title:         fmt.Sprintf(headlines[n], l.Name),
+			Link:          fmt.Sprintf("https://example.com/demo/news/%s-%d", strings.ToLower(l.Symbol), published.Unix()),
+			Snippet:       "Synthetic article generated for the GoFinance demo. Not real market news.",
+			Type:          articleTypes[n],
+		})
+	}
+	return articles
+}
+
+func nameOf(symbol string) string {
+	for _, l := range listings {
+		if l.Symbol == symbol {
+			return l.Name
+		}
+	}
+	return symbol + " Demo Corp."
+}
+
+// formatCap formats a market cap the way Yahoo does, e.g. 2.87T.
+func formatCap(value float64) string {
+	switch {
+	case value >= 1e12:
+		return fmt.Sprintf("%.2fT", value/1e12)
+	case value >= 1e9:
+		return fmt.Sprintf("%.2fB", value/1e9)
+	default:
+		return fmt.Sprintf("%.2fM", value/1e6)
+	}
+}
+
+func round(value float64, places int) float64 {
+	scale := math.Pow(10, float64(places))
+	return math.Round(value*scale) / scale
+}
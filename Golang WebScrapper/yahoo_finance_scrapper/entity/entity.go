@@ -0,0 +1,185 @@
+// Package entity tags news articles with the companies, people and
+// tickers they mention. It is rules-based rather than a trained model: it
+// finds cashtags and exchange tickers, names followed by a ticker or a
+// corporate suffix, well-known companies, and names introduced by a role
+// such as "CEO" or "Fed Chair".
+package entity
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+
+	"go-webscraper/model"
+)
+
+const (
+	TypeCompany = "company"
+	TypePerson  = "person"
+	TypeTicker  = "ticker"
+)
+
+// Types are the entity types, in the order Extract lists them.
+var Types = []string{TypeCompany, TypePerson, TypeTicker}
+
+const (
+	namePart   = `[A-Z][A-Za-z0-9&'-]*(?:\.[A-Z]\.?)?`
+	personPart = `[A-Z][a-z]+(?:-[A-Z][a-z]+)?`
+	symbol     = `[A-Z]{1,5}(?:\.[A-Z])?`
+)
+
+var (
+	cashtag        = regexp.MustCompile(`\$(` + symbol + `)\b`)
+	exchangeTicker = regexp.MustCompile(`(?:(` + namePart + `(?:\s+` + namePart + `){0,3})\.?,?\s+)?\((?:NYSE|NASDAQ|Nasdaq|NYSEARCA|NYSE American|AMEX|OTC|TSX|LSE)\s*:\s*(` + symbol + `)\)`)
+	parenTicker    = regexp.MustCompile(`(` + namePart + `(?:\s+` + namePart + `){0,3})\.?,?\s+\((` + symbol + `)\)`)
+	suffixed       = regexp.MustCompile(`((?:` + namePart + `\s+){0,3}` + namePart + `)\s+(?:Inc|Corp|Corporation|Co|Ltd|Limited|PLC|plc|LLC|Group|Holdings|Bancorp|Technologies)\b\.?`)
+	// Longer roles come first so "Chief Executive Officer" isn't cut
+	// short at "Chief Executive"
+	role = regexp.MustCompile(`\b(?:Chief Executive Officer|Chief Executive|Chief Financial Officer|CEO|CFO|Chairman|Chairwoman|Chair|President|Secretary|Governor|Senator|Sen\.|Rep\.|Mr\.|Ms\.|Mrs\.|Dr\.|founder|analyst|economist|strategist)\s+(` +
+		personPart + `(?:\s+[A-Z]\.)?(?:\s+` + personPart + `){1,2})`)
+	appositive = regexp.MustCompile(`\b(` + personPart + `(?:\s+` + personPart + `){1,2}),\s+(?:the\s+)?(?:[a-z]+\s+)?(?:chief|CEO|CFO|chair|chairman|president|head|founder|analyst|economist|strategist|portfolio manager)\b`)
+)
+
+// knownCompanies are matched by name alone, since news often drops the
+// ticker and suffix for the largest names.
+var knownCompanies = []string{
+	"Apple", "Microsoft", "Nvidia", "Amazon", "Alphabet", "Google", "Meta Platforms",
+	"Tesla", "Berkshire Hathaway", "JPMorgan", "Goldman Sachs", "Morgan Stanley",
+	"Bank of America", "Wells Fargo", "Citigroup", "Netflix", "Intel", "Broadcom",
+	"Walmart", "Costco", "ExxonMobil", "Exxon Mobil", "Chevron", "Boeing", "Pfizer",
+	"Eli Lilly", "Johnson & Johnson", "UnitedHealth", "Visa", "Mastercard", "OpenAI",
+	"Oracle", "Salesforce", "Adobe", "Disney", "Starbucks", "Nike", "McDonald's",
+}
+
+var knownCompanyPatterns = func() []*regexp.Regexp {
+	patterns := make([]*regexp.Regexp, len(knownCompanies))
+	for i, name := range knownCompanies {
+		patterns[i] = regexp.MustCompile(`\b` + regexp.QuoteMeta(name) + `\b`)
+	}
+	return patterns
+}()
+
+// notTickers are uppercase words news puts in parentheses or after a $
+// that aren't symbols.
+var notTickers = map[string]bool{
+	"AI": true, "CEO": true, "CFO": true, "ETF": true, "ETFS": true, "IPO": true, "GDP": true,
+	"CPI": true, "PPI": true, "PCE": true, "FED": true, "FOMC": true, "SEC": true, "FTC": true,
+	"DOJ": true, "EU": true, "UK": true, "US": true, "USA": true, "USD": true, "EUR": true,
+	"EPS": true, "EV": true, "EVS": true, "OPEC": true, "IMF": true, "ECB": true, "BOJ": true,
+	"FDA": true, "NYSE": true, "NASDAQ": true, "M": true, "B": true, "K": true, "T": true,
+}
+
+// leadingWords open sentences and headlines and aren't part of the name
+// they precede.
+var leadingWords = map[string]bool{
+	"The": true, "A": true, "An": true, "Shares": true, "Stock": true, "Stocks": true,
+	"Why": true, "How": true, "What": true, "When": true, "After": true, "Before": true,
+	"As": true, "While": true, "Buy": true, "Sell": true, "Is": true, "Are": true,
+	"Should": true, "Will": true, "Can": true, "And": true, "But": true, "Analysts": true,
+}
+
+// Extract returns the entities in an article's title and text, each once,
+// grouped by type.
+func Extract(title, text string) []model.Entity {
+	content := title + ".\n" + text
+
+	var found []model.Entity
+	seen := make(map[string]bool)
+	add := func(entityType, name string) {
+		name = strings.Join(strings.Fields(name), " ")
+		if name == "" {
+			return
+		}
+		key := entityType + ":" + strings.ToLower(name)
+		if seen[key] {
+			return
+		}
+		seen[key] = true
+		found = append(found, model.Entity{Name: name, Type: entityType})
+	}
+	ticker := func(s string) {
+		if !notTickers[s] {
+			add(TypeTicker, s)
+		}
+	}
+
+	for _, m := range cashtag.FindAllStringSubmatch(content, -1) {
+		ticker(m[1])
+	}
+	for _, m := range exchangeTicker.FindAllStringSubmatch(content, -1) {
+		ticker(m[2])
+		add(TypeCompany, companyName(m[1]))
+	}
+	for _, m := range parenTicker.FindAllStringSubmatch(content, -1) {
+		if notTickers[m[2]] {
+			continue
+		}
+		ticker(m[2])
+		add(TypeCompany, companyName(m[1]))
+	}
+	for _, m := range suffixed.FindAllStringSubmatch(content, -1) {
+		add(TypeCompany, companyName(m[1]))
+	}
+	for _, pattern := range knownCompanyPatterns {
+		if name := pattern.FindString(content); name != "" {
+			add(TypeCompany, name)
+		}
+	}
+	for _, m := range role.FindAllStringSubmatch(content, -1) {
+		add(TypePerson, personName(m[1]))
+	}
+	for _, m := range appositive.FindAllStringSubmatch(content, -1) {
+		if !leadingWords[strings.Fields(m[1])[0]] {
+			add(TypePerson, personName(m[1]))
+		}
+	}
+
+	sort.SliceStable(found, func(i, j int) bool { return typeOrder(found[i].Type) < typeOrder(found[j].Type) })
+	return found
+}
+
+func typeOrder(entityType string) int {
+	for i, t := range Types {
+		if t == entityType {
+			return i
+		}
+	}
+	return len(Types)
+}
+
+// companyName drops leading sentence words, corporate suffixes and
+// trailing punctuation, so "The Walt Disney Co." and "Walt Disney (DIS)"
+// name the same company.
+func companyName(name string) string {
+	words := strings.Fields(name)
+	for len(words) > 0 && leadingWords[words[0]] {
+		words = words[1:]
+	}
+	for len(words) > 1 {
+		last := strings.TrimSuffix(strings.TrimSuffix(words[len(words)-1], ","), ".")
+		switch last {
+		case "Inc", "Corp", "Corporation", "Co", "Ltd", "Limited", "PLC", "plc", "LLC", "Holdings", "Bancorp", "Group":
+			words = words[:len(words)-1]
+			continue
+		}
+		break
+	}
+	name = strings.Join(words, " ")
+	name = strings.TrimRight(name, ".,'")
+	if len(name) < 2 || notTickers[name] {
+		return ""
+	}
+	return name
+}
+
+// personName drops a trailing possessive.
+func personName(name string) string {
+	name = strings.TrimSuffix(name, "'s")
+	return strings.TrimSuffix(name, "’s")
+}
+
+// Key normalizes an entity name for lookups, so queries match whatever
+// case the article used.
+func Key(name string) string {
+	return strings.ToLower(strings.Join(strings.Fields(name), " "))
+}
@@ -0,0 +1,44 @@
+package entity
+
+import (
+	"testing"
+
+	"go-webscraper/model"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExtract(t *testing.T) {
+	entities := Extract(
+		"Fed Chair Jerome Powell signals patience as Nvidia (NVDA) slides",
+		"Shares of Apple Inc. (NASDAQ: AAPL) rose after CEO Tim Cook's keynote. "+
+			"The Goldman Sachs Group Inc. raised its target, said Jane Smith, an analyst at the bank. "+
+			"Traders piled into $TSLA while the ETF (SPY) was flat and the AI (AI) trade cooled. "+
+			"Powell said the FOMC would watch the CPI.")
+
+	assert.Equal(t, []model.Entity{
+		{Name: "Apple", Type: TypeCompany},
+		{Name: "Nvidia", Type: TypeCompany},
+		{Name: "Goldman Sachs", Type: TypeCompany},
+		{Name: "Jerome Powell", Type: TypePerson},
+		{Name: "Tim Cook", Type: TypePerson},
+		{Name: "Jane Smith", Type: TypePerson},
+		{Name: "TSLA", Type: TypeTicker},
+		{Name: "AAPL", Type: TypeTicker},
+		{Name: "NVDA", Type: TypeTicker},
+		{Name: "SPY", Type: TypeTicker},
+	}, entities)
+
+	assert.Empty(t, Extract("Markets close higher", "stocks rose on thursday."))
+}
+
+func TestCompanyName(t *testing.T) {
+	assert.Equal(t, "Walt Disney", companyName("The Walt Disney Co."))
+	assert.Equal(t, "Meta Platforms", companyName("Meta Platforms, Inc."))
+	assert.Equal(t, "U.S. Steel", companyName("U.S. Steel Corp"))
+	assert.Equal(t, "", companyName("The"))
+}
+
+func TestKey(t *testing.T) {
+	assert.Equal(t, "jerome powell", Key("  Jerome   POWELL "))
+}
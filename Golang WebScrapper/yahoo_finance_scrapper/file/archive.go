@@ -0,0 +1,440 @@
+package file
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"go-webscraper/stream"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ArchiveEntry struct {
+	Path       string    `json:"path"`
+	Source     string    `json:"source"`
+	Size       int64     `json:"size"`
+	Compressed bool      `json:"compressed"`
+	ModifiedAt time.Time `json:"modified_at"`
+}
+
+type Archiver struct {
+	baseDir       string
+	layout        string
+	compressAfter time.Duration
+	retainFor     time.Duration
+}
+
+type ArchiveOption struct {
+	BaseDir       string
+	Layout        string
+	CompressAfter time.Duration
+	RetainFor     time.Duration
+}
+
+func NewArchiver(opts ArchiveOption) *Archiver {
+	if opts.BaseDir == "" {
+		opts.BaseDir = "exports"
+	}
+	if opts.Layout == "" {
+		opts.Layout = "{source}/2006/01"
+	}
+	if opts.CompressAfter == 0 {
+		opts.CompressAfter = 7 * 24 * time.Hour
+	}
+	if opts.RetainFor == 0 {
+		opts.RetainFor = 90 * 24 * time.Hour
+	}
+
+	return &Archiver{
+		baseDir:       opts.BaseDir,
+		layout:        opts.Layout,
+		compressAfter: opts.CompressAfter,
+		retainFor:     opts.RetainFor,
+	}
+}
+
+// Save writes one date-stamped CSV for source under the configured layout,
+// replacing any file already written for the same day.
+func (a *Archiver) Save(source string, headers []string, data [][]string) (string, error) {
+	now := time.Now()
+	dir := filepath.Join(a.baseDir, strings.ReplaceAll(now.Format(a.layout), "{source}", source))
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create archive directory: %v", err)
+	}
+
+	path := filepath.Join(dir, fmt.Sprintf("%s_%s.csv", source, now.Format("2006-01-02")))
+	file, err := os.Create(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to create archive file: %v", err)
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+	if err := writer.Write(headers); err != nil {
+		return "", fmt.Errorf("failed to write CSV headers: %v", err)
+	}
+	if err := writer.WriteAll(data); err != nil {
+		return "", fmt.Errorf("failed to write CSV rows: %v", err)
+	}
+
+	log.Printf("Archived %d %s rows to %s", len(data), source, path)
+	return path, nil
+}
+
+// Rotate gzips archives older than compressAfter and removes those older
+// than retainFor.
+func (a *Archiver) Rotate() error {
+	return filepath.WalkDir(a.baseDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		age := time.Since(info.ModTime())
+
+		switch {
+		case age > a.retainFor:
+			if err := os.Remove(path); err != nil {
+				return fmt.Errorf("failed to remove %s: %v", path, err)
+			}
+		case age > a.compressAfter && strings.HasSuffix(path, ".csv"):
+			if err := compressFile(path, info.ModTime()); err != nil {
+				return fmt.Errorf("failed to compress %s: %v", path, err)
+			}
+		}
+		return nil
+	})
+}
+
+func compressFile(path string, modTime time.Time) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.Create(path + ".gz")
+	if err != nil {
+		return err
+	}
+	defer dst.Close()
+
+	gz := gzip.NewWriter(dst)
+	gz.Name = filepath.Base(path)
+	gz.ModTime = modTime
+	if _, err := io.Copy(gz, src); err != nil {
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		return err
+	}
+
+	// Keep the original timestamp so retention still counts from the scrape date
+	if err := os.Chtimes(path+".gz", modTime, modTime); err != nil {
+		return err
+	}
+	return os.Remove(path)
+}
+
+func (a *Archiver) List(source string) ([]ArchiveEntry, error) {
+	entries := make([]ArchiveEntry, 0)
+
+	err := filepath.WalkDir(a.baseDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+
+		name := d.Name()
+		if !strings.HasSuffix(name, ".csv") && !strings.HasSuffix(name, ".csv.gz") {
+			return nil
+		}
+
+		entrySource := name
+		if i := strings.LastIndex(name, "_"); i > 0 {
+			entrySource = name[:i]
+		}
+		if source != "" && entrySource != source {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(a.baseDir, path)
+		if err != nil {
+			return err
+		}
+
+		entries = append(entries, ArchiveEntry{
+			Path:       filepath.ToSlash(rel),
+			Source:     entrySource,
+			Size:       info.Size(),
+			Compressed: strings.HasSuffix(name, ".gz"),
+			ModifiedAt: info.ModTime(),
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].ModifiedAt.After(entries[j].ModifiedAt)
+	})
+	return entries, nil
+}
+
+// Resolve maps an archive-relative path to a file on disk, rejecting paths
+// that escape the archive directory.
+func (a *Archiver) Resolve(rel string) (string, error) {
+	path := filepath.Join(a.baseDir, filepath.FromSlash(filepath.Clean("/"+rel)))
+	if r, err := filepath.Rel(a.baseDir, path); err != nil || strings.HasPrefix(r, "..") {
+		return "", fmt.Errorf("invalid archive path: %s", rel)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil || info.IsDir() {
+		return "", fmt.Errorf("archive not found: %s", rel)
+	}
+	return path, nil
+}
+
+// archiveDate reads the date stamp Save puts in every file name.
+func archiveDate(path string) (time.Time, error) {
+	name := strings.TrimSuffix(strings.TrimSuffix(filepath.Base(path), ".gz"), ".csv")
+	i := strings.LastIndex(name, "_")
+	if i < 0 {
+		return time.Time{}, fmt.Errorf("undated archive: %s", path)
+	}
+	return time.Parse("2006-01-02", name[i+1:])
+}
+
+// Range lists the archives of source dated from..to inclusive, oldest
+// first. A zero bound leaves that side open.
+func (a *Archiver) Range(source string, from, to time.Time) ([]ArchiveEntry, error) {
+	entries, err := a.List(source)
+	if err != nil {
+		return nil, err
+	}
+
+	dates := make(map[string]time.Time, len(entries))
+	matched := make([]ArchiveEntry, 0, len(entries))
+	for _, entry := range entries {
+		date, err := archiveDate(entry.Path)
+		if err != nil {
+			continue
+		}
+		if (!from.IsZero() && date.Before(from)) || (!to.IsZero() && date.After(to)) {
+			continue
+		}
+		dates[entry.Path] = date
+		matched = append(matched, entry)
+	}
+
+	sort.SliceStable(matched, func(i, j int) bool {
+		return dates[matched[i].Path].Before(dates[matched[j].Path])
+	})
+	return matched, nil
+}
+
+// Rows reads entries one record at a time, passing each with its file's
+// header row to fn. Only one file is open at once, so memory stays flat
+// however many archives are read; it stops when ctx is done or fn fails.
+func (a *Archiver) Rows(ctx context.Context, entries []ArchiveEntry, fn func(headers, record []string) error) error {
+	for _, entry := range entries {
+		if err := a.readRows(ctx, entry, fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (a *Archiver) readRows(ctx context.Context, entry ArchiveEntry, fn func(headers, record []string) error) error {
+	path, err := a.Resolve(entry.Path)
+	if err != nil {
+		return err
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %v", entry.Path, err)
+	}
+	defer file.Close()
+
+	var src io.Reader = file
+	if entry.Compressed {
+		gz, err := gzip.NewReader(file)
+		if err != nil {
+			return fmt.Errorf("failed to decompress %s: %v", entry.Path, err)
+		}
+		defer gz.Close()
+		src = gz
+	}
+
+	reader := csv.NewReader(src)
+	headers, err := reader.Read()
+	if err == io.EOF {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read headers of %s: %v", entry.Path, err)
+	}
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		record, err := reader.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %v", entry.Path, err)
+		}
+		if err := fn(headers, record); err != nil {
+			return err
+		}
+	}
+}
+
+func HandleListExports(a *Archiver) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		entries, err := a.List(c.Query("source"))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"status": "success",
+			"data":   entries,
+		})
+	}
+}
+
+func HandleDownloadExport(a *Archiver) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		path, err := a.Resolve(c.Param("path"))
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		c.FileAttachment(path, filepath.Base(path))
+	}
+}
+
+// HandleStreamArchive streams every archived row of a source between
+// ?from= and ?to= (YYYY-MM-DD) as CSV or JSON without loading the files
+// into memory.
+func HandleStreamArchive(a *Archiver) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		source := c.Param("source")
+
+		var bounds [2]time.Time
+		for i, name := range []string{"from", "to"} {
+			value := c.Query(name)
+			if value == "" {
+				continue
+			}
+			date, err := time.Parse("2006-01-02", value)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{
+					"error": fmt.Sprintf("invalid %s date: %s", name, value),
+				})
+				return
+			}
+			bounds[i] = date
+		}
+
+		format := c.DefaultQuery("format", "json")
+		if format != "json" && format != "csv" {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "format must be json or csv",
+			})
+			return
+		}
+
+		entries, err := a.Range(source, bounds[0], bounds[1])
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+		if len(entries) == 0 {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "no archives for " + source,
+			})
+			return
+		}
+
+		if format == "csv" {
+			w := stream.NewCSV(c, fmt.Sprintf("%s_archive.csv", source))
+			wroteHeaders := false
+			err = a.Rows(c.Request.Context(), entries, func(headers, record []string) error {
+				if !wroteHeaders {
+					wroteHeaders = true
+					if err := w.Write(headers); err != nil {
+						return err
+					}
+				}
+				return w.Write(record)
+			})
+			if err == nil {
+				err = w.Close()
+			}
+		} else {
+			var w *stream.JSON
+			w, err = stream.NewJSON(c)
+			if err == nil {
+				err = a.Rows(c.Request.Context(), entries, func(headers, record []string) error {
+					row := make(map[string]string, len(headers))
+					for i, header := range headers {
+						if i < len(record) {
+							row[header] = record[i]
+						}
+					}
+					return w.Write(row)
+				})
+			}
+			if err == nil {
+				err = w.Close()
+			}
+		}
+
+		if err != nil {
+			log.Printf("Archive stream of %s ended early: %v", source, err)
+		}
+	}
+}
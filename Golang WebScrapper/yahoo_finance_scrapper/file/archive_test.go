@@ -0,0 +1,157 @@
+package file
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func writeArchive(t *testing.T, dir, name, body string) {
+	t.Helper()
+	path := filepath.Join(dir, "stocks", name)
+	assert.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	assert.NoError(t, os.WriteFile(path, []byte(body), 0644))
+}
+
+func TestArchiveRows(t *testing.T) {
+	dir := t.TempDir()
+	writeArchive(t, dir, "stocks_2024-01-03.csv", "symbol,price\nMSFT,2\n")
+	writeArchive(t, dir, "stocks_2024-01-01.csv", "symbol,price\nAAPL,1\n")
+	writeArchive(t, dir, "stocks_2024-01-02.csv", "symbol,price\nGOOG,3\n")
+	assert.NoError(t, compressFile(filepath.Join(dir, "stocks", "stocks_2024-01-02.csv"), time.Now()))
+
+	a := NewArchiver(ArchiveOption{BaseDir: dir})
+
+	t.Run("Range", func(t *testing.T) {
+		from, _ := time.Parse("2006-01-02", "2024-01-02")
+		entries, err := a.Range("stocks", from, time.Time{})
+		assert.NoError(t, err)
+		assert.Len(t, entries, 2)
+		assert.True(t, entries[0].Compressed)
+	})
+
+	t.Run("Oldest First", func(t *testing.T) {
+		entries, err := a.Range("stocks", time.Time{}, time.Time{})
+		assert.NoError(t, err)
+
+		symbols := make([]string, 0)
+		err = a.Rows(context.Background(), entries, func(headers, record []string) error {
+			assert.Equal(t, []string{"symbol", "price"}, headers)
+			symbols = append(symbols, record[0])
+			return nil
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"AAPL", "GOOG", "MSFT"}, symbols)
+	})
+
+	t.Run("Cancelled", func(t *testing.T) {
+		entries, _ := a.Range("stocks", time.Time{}, time.Time{})
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		err := a.Rows(ctx, entries, func(headers, record []string) error {
+			t.Fatal("read a row after cancellation")
+			return nil
+		})
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+
+	t.Run("Handler", func(t *testing.T) {
+		gin.SetMode(gin.TestMode)
+		r := gin.New()
+		r.GET("/archive/:source", HandleStreamArchive(a))
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("GET", "/archive/stocks?to=2024-01-02", nil))
+		assert.Equal(t, http.StatusOK, w.Code)
+		var body struct {
+			Data []map[string]string `json:"data"`
+		}
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		assert.Equal(t, []map[string]string{{"symbol": "AAPL", "price": "1"}, {"symbol": "GOOG", "price": "3"}}, body.Data)
+
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("GET", "/archive/stocks?format=csv", nil))
+		assert.Equal(t, "symbol,price\nAAPL,1\nGOOG,3\nMSFT,2", strings.TrimSpace(w.Body.String()))
+
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("GET", "/archive/news", nil))
+		assert.Equal(t, http.StatusNotFound, w.Code)
+
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("GET", "/archive/stocks?from=yesterday", nil))
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+func TestArchiveRotate(t *testing.T) {
+	dir := t.TempDir()
+	writeArchive(t, dir, "stocks_2024-01-01.csv", "symbol,price\nAAPL,1\n")
+	writeArchive(t, dir, "stocks_2024-02-01.csv", "symbol,price\nMSFT,2\n")
+	writeArchive(t, dir, "stocks_2024-03-01.csv", "symbol,price\nGOOG,3\n")
+
+	now := time.Now()
+	expired := now.Add(-100 * 24 * time.Hour)
+	stale := now.Add(-10 * 24 * time.Hour)
+	assert.NoError(t, os.Chtimes(filepath.Join(dir, "stocks", "stocks_2024-01-01.csv"), expired, expired))
+	assert.NoError(t, os.Chtimes(filepath.Join(dir, "stocks", "stocks_2024-02-01.csv"), stale, stale))
+
+	a := NewArchiver(ArchiveOption{BaseDir: dir, CompressAfter: 7 * 24 * time.Hour, RetainFor: 90 * 24 * time.Hour})
+	assert.NoError(t, a.Rotate())
+
+	entries, err := a.List("stocks")
+	assert.NoError(t, err)
+	assert.Len(t, entries, 2)
+	assert.Equal(t, "stocks/stocks_2024-03-01.csv", entries[0].Path)
+	assert.False(t, entries[0].Compressed)
+	assert.Equal(t, "stocks/stocks_2024-02-01.csv.gz", entries[1].Path)
+	assert.True(t, entries[1].Compressed)
+	assert.WithinDuration(t, stale, entries[1].ModifiedAt, time.Second, "compression keeps the scrape time")
+
+	_, err = os.Stat(filepath.Join(dir, "stocks", "stocks_2024-02-01.csv"))
+	assert.True(t, os.IsNotExist(err))
+
+	var symbols []string
+	assert.NoError(t, a.Rows(context.Background(), entries[1:], func(headers, record []string) error {
+		symbols = append(symbols, record[0])
+		return nil
+	}))
+	assert.Equal(t, []string{"MSFT"}, symbols)
+
+	// A missing archive directory isn't an error
+	assert.NoError(t, NewArchiver(ArchiveOption{BaseDir: filepath.Join(dir, "missing")}).Rotate())
+}
+
+func TestArchiveResolve(t *testing.T) {
+	dir := t.TempDir()
+	writeArchive(t, dir, "stocks_2024-01-01.csv", "symbol,price\n")
+	assert.NoError(t, os.WriteFile(filepath.Join(filepath.Dir(dir), "secret.txt"), []byte("x"), 0644))
+	t.Cleanup(func() { os.Remove(filepath.Join(filepath.Dir(dir), "secret.txt")) })
+
+	a := NewArchiver(ArchiveOption{BaseDir: dir})
+
+	path, err := a.Resolve("stocks/stocks_2024-01-01.csv")
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "stocks", "stocks_2024-01-01.csv"), path)
+
+	for _, rel := range []string{
+		"../secret.txt",
+		"stocks/../../secret.txt",
+		"/../secret.txt",
+		"..%2fsecret.txt",
+		"stocks",
+		"stocks/stocks_2024-01-02.csv",
+	} {
+		_, err := a.Resolve(rel)
+		assert.Error(t, err, rel)
+	}
+}
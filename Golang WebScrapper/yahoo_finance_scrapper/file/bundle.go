@@ -0,0 +1,136 @@
+package file
+
+import (
+	"archive/zip"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// BundleSources are the archived sources a market snapshot bundle holds,
+// in zip order.
+var BundleSources = []string{"stocks", "sectors", "indices", "news"}
+
+// Bundle returns the archives dated date of each BundleSources source
+// that has one.
+func (a *Archiver) Bundle(date time.Time) ([]ArchiveEntry, error) {
+	var entries []ArchiveEntry
+	for _, source := range BundleSources {
+		matched, err := a.Range(source, date, date)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, matched...)
+	}
+	return entries, nil
+}
+
+// WriteBundle zips entries into w as plain CSVs named
+// {source}_{date}.csv, decompressing rotated archives on the way. Only one
+// archive is open at once.
+func (a *Archiver) WriteBundle(ctx context.Context, w io.Writer, entries []ArchiveEntry) error {
+	zw := zip.NewWriter(w)
+	written := make(map[string]bool, len(entries))
+	for _, entry := range entries {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		date, err := archiveDate(entry.Path)
+		if err != nil {
+			return err
+		}
+		// A day caught mid-compression can have both a .csv and a .csv.gz
+		name := fmt.Sprintf("%s_%s.csv", entry.Source, date.Format("2006-01-02"))
+		if written[name] {
+			continue
+		}
+		written[name] = true
+		if err := a.addToZip(zw, name, entry); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+func (a *Archiver) addToZip(zw *zip.Writer, name string, entry ArchiveEntry) error {
+	path, err := a.Resolve(entry.Path)
+	if err != nil {
+		return err
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %v", entry.Path, err)
+	}
+	defer file.Close()
+
+	var src io.Reader = file
+	if entry.Compressed {
+		gz, err := gzip.NewReader(file)
+		if err != nil {
+			return fmt.Errorf("failed to decompress %s: %v", entry.Path, err)
+		}
+		defer gz.Close()
+		src = gz
+	}
+
+	dst, err := zw.CreateHeader(&zip.FileHeader{
+		Name:     name,
+		Method:   zip.Deflate,
+		Modified: entry.ModifiedAt,
+	})
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		return fmt.Errorf("failed to bundle %s: %v", entry.Path, err)
+	}
+	return nil
+}
+
+// HandleBundle streams GET /api/export/bundle?date=today as a zip of the
+// stocks, sectors, indices and news archived that day. date is today or a
+// YYYY-MM-DD date.
+func HandleBundle(a *Archiver) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		value := c.DefaultQuery("date", "today")
+		date, err := time.Parse("2006-01-02", time.Now().Format("2006-01-02"))
+		if value != "today" {
+			date, err = time.Parse("2006-01-02", value)
+		}
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "date must be today or a YYYY-MM-DD date",
+			})
+			return
+		}
+
+		entries, err := a.Bundle(date)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+		if len(entries) == 0 {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "no snapshots archived on " + date.Format("2006-01-02"),
+			})
+			return
+		}
+
+		c.Header("Content-Type", "application/zip")
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=market_%s.zip", date.Format("2006-01-02")))
+		c.Status(http.StatusOK)
+
+		if err := a.WriteBundle(c.Request.Context(), c.Writer, entries); err != nil {
+			log.Printf("Bundle of %s ended early: %v", date.Format("2006-01-02"), err)
+		}
+	}
+}
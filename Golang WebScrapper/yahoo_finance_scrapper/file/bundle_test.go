@@ -0,0 +1,62 @@
+package file
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandleBundle(t *testing.T) {
+	dir := t.TempDir()
+	write := func(rel, body string) {
+		path := filepath.Join(dir, rel)
+		assert.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		assert.NoError(t, os.WriteFile(path, []byte(body), 0644))
+	}
+	write("stocks/2024/01/stocks_2024-01-02.csv", "symbol,price\nAAPL,1\n")
+	write("stocks/2024/01/stocks_2024-01-03.csv", "symbol,price\nMSFT,2\n")
+	write("news/2024/01/news_2024-01-02.csv", "title\nFed holds\n")
+	write("reports/2024/01/reports_2024-01-02.csv", "name\nignored\n")
+	assert.NoError(t, compressFile(filepath.Join(dir, "news/2024/01/news_2024-01-02.csv"), time.Now()))
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/bundle", HandleBundle(NewArchiver(ArchiveOption{BaseDir: dir})))
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/bundle?date=2024-01-02", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/zip", w.Header().Get("Content-Type"))
+
+	zr, err := zip.NewReader(bytes.NewReader(w.Body.Bytes()), int64(w.Body.Len()))
+	assert.NoError(t, err)
+	files := make(map[string]string)
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		assert.NoError(t, err)
+		body, _ := io.ReadAll(rc)
+		rc.Close()
+		files[f.Name] = string(body)
+	}
+	assert.Equal(t, map[string]string{
+		"stocks_2024-01-02.csv": "symbol,price\nAAPL,1\n",
+		"news_2024-01-02.csv":   "title\nFed holds\n",
+	}, files)
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/bundle?date=2024-02-01", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/bundle?date=yesterday", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
@@ -0,0 +1,165 @@
+package file
+
+import (
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"go-webscraper/metrics"
+)
+
+// Janitor enforces a retention policy on output directories: files older
+// than MaxAge are removed, then the oldest files until each directory fits
+// in MaxBytes. A zero limit disables it.
+type Janitor struct {
+	dirs     []string
+	maxAge   time.Duration
+	maxBytes int64
+	interval time.Duration
+
+	stop chan struct{}
+	done sync.WaitGroup
+}
+
+type JanitorOption struct {
+	Dirs     []string
+	MaxAge   time.Duration
+	MaxBytes int64
+	Interval time.Duration
+}
+
+// Usage is what a directory holds after a sweep.
+type Usage struct {
+	Dir     string
+	Files   int
+	Bytes   int64
+	Removed int
+}
+
+func NewJanitor(opts JanitorOption) *Janitor {
+	if opts.Interval == 0 {
+		opts.Interval = 10 * time.Minute
+	}
+	return &Janitor{
+		dirs:     opts.Dirs,
+		maxAge:   opts.MaxAge,
+		maxBytes: opts.MaxBytes,
+		interval: opts.Interval,
+		stop:     make(chan struct{}),
+	}
+}
+
+// Start sweeps once, then every interval until Stop.
+func (j *Janitor) Start() {
+	j.done.Add(1)
+	go func() {
+		defer j.done.Done()
+		ticker := time.NewTicker(j.interval)
+		defer ticker.Stop()
+		for {
+			j.sweepAll(time.Now())
+			select {
+			case <-ticker.C:
+			case <-j.stop:
+				return
+			}
+		}
+	}()
+}
+
+func (j *Janitor) Stop() {
+	close(j.stop)
+	j.done.Wait()
+}
+
+func (j *Janitor) sweepAll(now time.Time) {
+	for _, dir := range j.dirs {
+		usage, err := j.Sweep(dir, now)
+		if err != nil {
+			log.Printf("Error cleaning %s: %v", dir, err)
+			continue
+		}
+		if usage.Removed > 0 {
+			log.Printf("Removed %d files from %s, %d files (%d bytes) left", usage.Removed, dir, usage.Files, usage.Bytes)
+		}
+	}
+}
+
+type outputFile struct {
+	path    string
+	size    int64
+	modTime time.Time
+}
+
+// Sweep applies the retention policy to dir as of now and records its disk
+// usage.
+func (j *Janitor) Sweep(dir string, now time.Time) (Usage, error) {
+	usage := Usage{Dir: dir}
+	var files []outputFile
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		files = append(files, outputFile{path: path, size: info.Size(), modTime: info.ModTime()})
+		return nil
+	})
+	if err != nil {
+		return usage, err
+	}
+
+	keep, expired, overQuota := retain(files, j.maxAge, j.maxBytes, now)
+	for reason, remove := range map[string][]outputFile{"age": expired, "size": overQuota} {
+		for _, f := range remove {
+			if err := os.Remove(f.path); err != nil && !os.IsNotExist(err) {
+				return usage, fmt.Errorf("failed to remove %s: %v", f.path, err)
+			}
+			usage.Removed++
+			metrics.OutputFilesRemoved.WithLabelValues(dir, reason).Inc()
+		}
+	}
+
+	for _, f := range keep {
+		usage.Files++
+		usage.Bytes += f.size
+	}
+	metrics.OutputDirBytes.WithLabelValues(dir).Set(float64(usage.Bytes))
+	metrics.OutputDirFiles.WithLabelValues(dir).Set(float64(usage.Files))
+	return usage, nil
+}
+
+// retain splits files into those kept, those older than maxAge, and the
+// oldest of the rest that must go for the kept ones to fit in maxBytes.
+func retain(files []outputFile, maxAge time.Duration, maxBytes int64, now time.Time) (keep, expired, overQuota []outputFile) {
+	sort.Slice(files, func(i, k int) bool { return files[i].modTime.Before(files[k].modTime) })
+
+	var total int64
+	for _, f := range files {
+		if maxAge > 0 && now.Sub(f.modTime) > maxAge {
+			expired = append(expired, f)
+			continue
+		}
+		keep = append(keep, f)
+		total += f.size
+	}
+	for maxBytes > 0 && total > maxBytes && len(keep) > 0 {
+		overQuota = append(overQuota, keep[0])
+		total -= keep[0].size
+		keep = keep[1:]
+	}
+	return keep, expired, overQuota
+}
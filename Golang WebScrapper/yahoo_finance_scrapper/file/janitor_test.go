@@ -0,0 +1,47 @@
+package file
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJanitorSweep(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	write := func(name string, size int, age time.Duration) {
+		path := filepath.Join(dir, name)
+		assert.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		assert.NoError(t, os.WriteFile(path, []byte(strings.Repeat("x", size)), 0644))
+		assert.NoError(t, os.Chtimes(path, now.Add(-age), now.Add(-age)))
+	}
+	write("stale.csv", 10, 48*time.Hour)
+	write("old.csv", 40, 3*time.Hour)
+	write("nested/mid.csv", 40, 2*time.Hour)
+	write("new.csv", 40, time.Hour)
+
+	j := NewJanitor(JanitorOption{MaxAge: 24 * time.Hour, MaxBytes: 100})
+	usage, err := j.Sweep(dir, now)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, usage.Removed)
+	assert.Equal(t, 2, usage.Files)
+	assert.Equal(t, int64(80), usage.Bytes)
+
+	for name, exists := range map[string]bool{"stale.csv": false, "old.csv": false, "nested/mid.csv": true, "new.csv": true} {
+		_, err := os.Stat(filepath.Join(dir, name))
+		assert.Equal(t, exists, err == nil, name)
+	}
+
+	// Without limits a sweep only measures
+	usage, err = NewJanitor(JanitorOption{}).Sweep(dir, now.Add(365*24*time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, Usage{Dir: dir, Files: 2, Bytes: 80}, usage)
+
+	usage, err = j.Sweep(filepath.Join(dir, "missing"), now)
+	assert.NoError(t, err)
+	assert.Zero(t, usage.Files)
+}
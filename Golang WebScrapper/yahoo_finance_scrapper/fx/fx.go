@@ -0,0 +1,192 @@
+package fx
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go-webscraper/cache"
+	"go-webscraper/cachekey"
+	"go-webscraper/keyspace"
+	"go-webscraper/pkg/parse"
+	"go-webscraper/pkg/yahoo"
+	"go-webscraper/upstream"
+
+	"github.com/gocolly/colly"
+	"github.com/redis/go-redis/v9"
+)
+
+type Rate struct {
+	From      string  `json:"from"`
+	To        string  `json:"to"`
+	Rate      float64 `json:"rate"`
+	Timestamp string  `json:"timestamp"`
+}
+
+type Converter struct {
+	redis     *redis.Client
+	cache     cache.Cache
+	ctx       context.Context
+	ttl       time.Duration
+	collector *colly.Collector
+}
+
+type ConverterOption struct {
+	CacheTTL      time.Duration
+	RedisAddr     string
+	RedisPassword string
+	RedisDB       int
+}
+
+var currencyCode = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// ErrInvalidCurrency is returned for codes that aren't three letters, as
+// opposed to failures fetching a valid pair's rate.
+var ErrInvalidCurrency = errors.New("invalid currency")
+
+// ValidCode reports whether code looks like an ISO 4217 currency code.
+func ValidCode(code string) bool {
+	return currencyCode.MatchString(strings.ToUpper(strings.TrimSpace(code)))
+}
+
+func NewConverter(opts ConverterOption) *Converter {
+	if opts.RedisAddr == "" {
+		opts.RedisAddr = "localhost:6379"
+	}
+
+	rdb := redis.NewClient(&redis.Options{
+		Addr:     opts.RedisAddr,
+		Password: opts.RedisPassword,
+		DB:       opts.RedisDB,
+	})
+	keyspace.Instrument(rdb)
+
+	c := upstream.NewCollector(
+		colly.AllowedDomains("finance.yahoo.com"),
+		colly.MaxDepth(1),
+	)
+
+	return &Converter{
+		redis:     rdb,
+		cache:     cache.New(rdb),
+		ctx:       context.Background(),
+		ttl:       opts.CacheTTL,
+		collector: c,
+	}
+}
+
+// Rate returns how many units of to one unit of from buys, scraping the
+// Yahoo currency pair quote (e.g. USDEUR=X) when it isn't cached.
+func (cv *Converter) Rate(from, to string) (*Rate, error) {
+	from = strings.ToUpper(strings.TrimSpace(from))
+	to = strings.ToUpper(strings.TrimSpace(to))
+	if !currencyCode.MatchString(from) || !currencyCode.MatchString(to) {
+		return nil, fmt.Errorf("%w pair: %s/%s", ErrInvalidCurrency, from, to)
+	}
+
+	if from == to {
+		return &Rate{
+			From:      from,
+			To:        to,
+			Rate:      1,
+			Timestamp: time.Now().Format(time.RFC3339),
+		}, nil
+	}
+
+	cacheKey := cachekey.FXRate.Key(from + to)
+	if cached, err := cv.cache.Get(cv.ctx, cacheKey); err == nil {
+		var rate Rate
+		if err := json.Unmarshal(cached, &rate); err == nil {
+			return &rate, nil
+		}
+	}
+
+	var (
+		price string
+		mu    sync.Mutex
+	)
+
+	c := yahoo.Clone(cv.ctx, cv.collector)
+	c.OnHTML("fin-streamer[data-field='regularMarketPrice']", func(e *colly.HTMLElement) {
+		mu.Lock()
+		defer mu.Unlock()
+		if price == "" {
+			price = strings.TrimSpace(e.Text)
+		}
+	})
+
+	url := fmt.Sprintf("https://finance.yahoo.com/quote/%s%s=X/", from, to)
+	if err := c.Visit(url); err != nil {
+		return nil, fmt.Errorf("failed to scrape exchange rate %s/%s: %v", from, to, err)
+	}
+	c.Wait()
+
+	value, err := parse.US.Float(price)
+	if !parse.OK("fx.rate", err) || value <= 0 {
+		return nil, fmt.Errorf("exchange rate %s/%s not found", from, to)
+	}
+
+	rate := &Rate{
+		From:      from,
+		To:        to,
+		Rate:      value,
+		Timestamp: time.Now().Format(time.RFC3339),
+	}
+	if jsonData, err := json.Marshal(rate); err == nil {
+		ttl := cv.ttl
+		if ttl == 0 {
+			ttl = cachekey.FXRate.TTL(true)
+		}
+		cv.cache.Set(cv.ctx, cacheKey, jsonData, ttl)
+	}
+
+	return rate, nil
+}
+
+func (cv *Converter) Close() {
+	cv.redis.Close()
+}
+
+var marketCapSuffixes = map[string]float64{
+	"K": 1e3,
+	"M": 1e6,
+	"B": 1e9,
+	"T": 1e12,
+}
+
+// ConvertMarketCap scales a Yahoo market cap string such as "2.35T" by
+// rate, keeping the most readable suffix. Unparseable values are returned
+// unchanged.
+func ConvertMarketCap(marketCap string, rate float64) string {
+	s := strings.TrimSpace(strings.ReplaceAll(marketCap, ",", ""))
+	if s == "" {
+		return marketCap
+	}
+
+	suffix := strings.ToUpper(s[len(s)-1:])
+	multiplier, ok := marketCapSuffixes[suffix]
+	if ok {
+		s = s[:len(s)-1]
+	} else {
+		multiplier = 1
+	}
+
+	value, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return marketCap
+	}
+	value *= multiplier * rate
+
+	for _, unit := range []string{"T", "B", "M", "K"} {
+		if value >= marketCapSuffixes[unit] {
+			return strconv.FormatFloat(value/marketCapSuffixes[unit], 'f', 3, 64) + unit
+		}
+	}
+	return strconv.FormatFloat(value, 'f', 2, 64)
+}
@@ -0,0 +1,35 @@
+package fx
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConvertMarketCap(t *testing.T) {
+	cases := []struct {
+		marketCap string
+		rate      float64
+		want      string
+	}{
+		{"2.35T", 1, "2.350T"},
+		{"2.35T", 0.5, "1.175T"},
+		{"800B", 2, "1.600T"},
+		{"1.2B", 0.5, "600.000M"},
+		{"950.5M", 1.1, "1.046B"},
+		{"12K", 0.01, "120.00"},
+		{"1,234,567", 1, "1.235M"},
+		{"", 2, ""},
+		{"N/A", 2, "N/A"},
+	}
+	for _, tc := range cases {
+		assert.Equal(t, tc.want, ConvertMarketCap(tc.marketCap, tc.rate), tc.marketCap)
+	}
+}
+
+func TestValidCode(t *testing.T) {
+	assert.True(t, ValidCode("EUR"))
+	assert.True(t, ValidCode(" jpy "))
+	assert.False(t, ValidCode("EURO"))
+	assert.False(t, ValidCode("E1R"))
+}
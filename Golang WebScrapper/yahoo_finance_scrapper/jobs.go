@@ -0,0 +1,468 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"go-webscraper/alerts"
+	"go-webscraper/changes"
+	"go-webscraper/file"
+	"go-webscraper/model"
+	"go-webscraper/newsarchive"
+	"go-webscraper/notify"
+	"go-webscraper/portfolio"
+	"go-webscraper/queue"
+	"go-webscraper/scheduler"
+	"go-webscraper/scraper"
+	"go-webscraper/universe"
+)
+
+// sink receives the result of every scheduled refresh.
+type sink interface {
+	Publish(source string, data interface{})
+}
+
+// publish forwards data to every sink, skipping refreshes whose content is
+// identical to what was last published for source.
+func publish(sinks []sink, tracker *changes.Tracker, source string, data interface{}) {
+	changed, _, err := tracker.Record("published:"+source, data, 0)
+	if err != nil {
+		log.Printf("Error hashing %s refresh, publishing anyway: %v", source, err)
+	} else if !changed {
+		log.Printf("No changes in %s since last refresh, skipping publish", source)
+		return
+	}
+
+	for _, s := range sinks {
+		s.Publish(source, data)
+	}
+}
+
+// jobAlerter sends scheduler failure and recovery alerts to every
+// operator channel.
+func jobAlerter(notifier *notify.Notifier, channels []notify.Channel) func(scheduler.Alert) {
+	return func(alert scheduler.Alert) {
+		msg := notify.Message{
+			Subject: fmt.Sprintf("Job %s has failed %d runs in a row", alert.Job, alert.Failures),
+			Body:    fmt.Sprintf("The last run started at %s and failed: %s", alert.Run.StartedAt.Format(time.RFC3339), alert.Run.Error),
+			Data:    alert.Run,
+		}
+		if alert.Recovered {
+			msg.Subject = fmt.Sprintf("Job %s recovered", alert.Job)
+			msg.Body = fmt.Sprintf("The run at %s succeeded after %d failures.", alert.Run.StartedAt.Format(time.RFC3339), alert.Failures)
+		}
+		log.Printf("%s", msg.Subject)
+
+		for _, channel := range channels {
+			if err := notifier.SendOperator(channel, msg); err != nil {
+				log.Printf("Error sending job alert to %s %s: %v", channel.Type, channel.Target, err)
+			}
+		}
+	}
+}
+
+func archiveJob(archiver *file.Archiver, pool *queue.Pool) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		stockScraper := scraper.NewStockScraper(scraper.StockScraperOption{
+			Context:  ctx,
+			Pool:     pool,
+			Priority: queue.Background,
+		})
+		defer stockScraper.Close()
+
+		stocks, err := stockScraper.ScrapeMostActive()
+		if err != nil {
+			return fmt.Errorf("failed to archive stocks: %v", err)
+		}
+		scheduler.AddRows(ctx, len(stocks))
+		stockRows := make([][]string, 0, len(stocks))
+		for _, stock := range stocks {
+			stockRows = append(stockRows, scraper.StockRecord(stock, "most_active"))
+		}
+		if _, err := archiver.Save("stocks", scraper.StockCSVHeaders, stockRows); err != nil {
+			return err
+		}
+
+		indices, err := stockScraper.ScrapeMajorIndices()
+		if err != nil {
+			return fmt.Errorf("failed to archive indices: %v", err)
+		}
+		scheduler.AddRows(ctx, len(indices))
+		indexRows := make([][]string, 0, len(indices))
+		for _, index := range indices {
+			indexRows = append(indexRows, scraper.StockRecord(index, "index"))
+		}
+		if _, err := archiver.Save("indices", scraper.StockCSVHeaders, indexRows); err != nil {
+			return err
+		}
+
+		sectorScraper := scraper.NewSectorScraper(scraper.ScraperOption{
+			Context:  ctx,
+			Pool:     pool,
+			Priority: queue.Background,
+		})
+		defer sectorScraper.Close()
+		sectors, err := sectorScraper.ScrapeAllSectors()
+		if err != nil {
+			return fmt.Errorf("failed to archive sectors: %v", err)
+		}
+		scheduler.AddRows(ctx, len(sectors))
+		names := make([]string, 0, len(sectors))
+		for name := range sectors {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		sectorRows := make([][]string, 0, len(sectors))
+		for _, name := range names {
+			sectorRows = append(sectorRows, scraper.SectorRecord(sectors[name]))
+		}
+		if _, err := archiver.Save("sectors", scraper.SectorCSVHeaders, sectorRows); err != nil {
+			return err
+		}
+
+		newsScraper := scraper.NewScraper(scraper.ScraperOption{
+			Context:  ctx,
+			Pool:     pool,
+			Priority: queue.Background,
+		})
+		defer newsScraper.Close()
+
+		articles, err := newsScraper.ScrapeNews(scraper.MarketMidnight(time.Now()))
+		if err != nil {
+			return fmt.Errorf("failed to archive news: %v", err)
+		}
+		scheduler.AddRows(ctx, len(articles))
+		articleRows := make([][]string, 0, len(articles))
+		for _, article := range articles {
+			articleRows = append(articleRows, scraper.ArticleRecord(article))
+		}
+		if _, err := archiver.Save("news", scraper.ArticleCSVHeaders, articleRows); err != nil {
+			return err
+		}
+
+		return archiver.Rotate()
+	}
+}
+
+func refreshStocksJob(sinks []sink, tracker *changes.Tracker, pool *queue.Pool) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		stockScraper := scraper.NewStockScraper(scraper.StockScraperOption{
+			Context:  ctx,
+			Pool:     pool,
+			Priority: queue.Background,
+		})
+		defer stockScraper.Close()
+
+		stocks, err := stockScraper.ScrapeMostActive()
+		if err != nil {
+			return fmt.Errorf("failed to refresh stocks: %v", err)
+		}
+		scheduler.AddRows(ctx, len(stocks))
+
+		publish(sinks, tracker, "stocks", stocks)
+		return nil
+	}
+}
+
+// refreshBreadthJob only scrapes during the regular session; outside it
+// breadth doesn't move and the last closes are already stored.
+func refreshBreadthJob(sinks []sink, tracker *changes.Tracker, pool *queue.Pool) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		if !scraper.MarketOpen(time.Now()) {
+			return nil
+		}
+
+		stockScraper := scraper.NewStockScraper(scraper.StockScraperOption{
+			Context:  ctx,
+			Pool:     pool,
+			Priority: queue.Background,
+		})
+		defer stockScraper.Close()
+
+		breadth, err := stockScraper.ScrapeBreadth()
+		if err != nil {
+			return fmt.Errorf("failed to refresh breadth: %v", err)
+		}
+
+		publish(sinks, tracker, "breadth", breadth)
+		return nil
+	}
+}
+
+// watchlistAlertsJob scrapes the quotes and movers lists that watchlist
+// and condition alerts watch and evaluates them while the market is open.
+func watchlistAlertsJob(engine *alerts.Engine, pool *queue.Pool) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		now := time.Now()
+		if !scraper.MarketOpen(now) {
+			return nil
+		}
+
+		symbols, categories, err := engine.Watched()
+		if err != nil {
+			return fmt.Errorf("failed to load watchlist alerts: %v", err)
+		}
+		if len(symbols) == 0 && len(categories) == 0 {
+			return nil
+		}
+
+		stockScraper := scraper.NewStockScraper(scraper.StockScraperOption{
+			Context:  ctx,
+			Pool:     pool,
+			Priority: queue.Background,
+		})
+		defer stockScraper.Close()
+
+		var quotes []scraper.StockData
+		if len(symbols) > 0 {
+			quotes, err = stockScraper.CachedQuotes(symbols)
+			if err != nil {
+				return fmt.Errorf("failed to refresh watchlist quotes: %v", err)
+			}
+		}
+		movers := make(map[string][]scraper.StockData, len(categories))
+		for _, category := range categories {
+			stocks, err := stockScraper.ScrapeMovers(category)
+			if err != nil {
+				return fmt.Errorf("failed to refresh %s: %v", category, err)
+			}
+			movers[category] = stocks
+		}
+		scheduler.AddRows(ctx, len(quotes))
+
+		engine.EvaluateWatchlists(quotes, movers, now)
+		return nil
+	}
+}
+
+// eodJob takes the end-of-day snapshot of the universe, portfolio holdings,
+// the default benchmark, every sector and the day's ex-dividends once per
+// market day, delay after the close. It runs frequently so a restart
+// around the close still finalizes the day.
+func eodJob(store *universe.Store, portfolios *portfolio.Store, pool *queue.Pool, delay time.Duration) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		now := time.Now()
+		if !scraper.EODDue(now, delay) {
+			return nil
+		}
+
+		stockScraper := scraper.NewStockScraper(scraper.StockScraperOption{
+			Context:  ctx,
+			Pool:     pool,
+			Priority: queue.Background,
+		})
+		defer stockScraper.Close()
+
+		day := scraper.MarketMidnight(now)
+		finalized, err := stockScraper.EODFinalized(day)
+		if err != nil {
+			return fmt.Errorf("failed to check end-of-day snapshot: %v", err)
+		}
+		if finalized {
+			return nil
+		}
+
+		symbols, err := store.Symbols()
+		if err != nil {
+			return fmt.Errorf("failed to load symbol universe: %v", err)
+		}
+		held, err := portfolios.Symbols()
+		if err != nil {
+			return fmt.Errorf("failed to load portfolio symbols: %v", err)
+		}
+		closes, err := stockScraper.FinalizeEOD(mergeSymbols(symbols, held, []string{scraper.DefaultBenchmark}), now)
+		if err != nil {
+			return fmt.Errorf("failed to record closes: %v", err)
+		}
+
+		sectorScraper := scraper.NewSectorScraper(scraper.ScraperOption{
+			Context:  ctx,
+			Pool:     pool,
+			Priority: queue.Background,
+		})
+		defer sectorScraper.Close()
+
+		sectors, err := sectorScraper.FinalizeEOD(now)
+		if err != nil {
+			return fmt.Errorf("failed to record sector closes: %v", err)
+		}
+
+		dividendScraper := scraper.NewDividendScraper(scraper.ScraperOption{
+			Context:  ctx,
+			Pool:     pool,
+			Priority: queue.Background,
+		})
+		defer dividendScraper.Close()
+
+		dividends, err := dividendScraper.RecordExDividends(day.Format("2006-01-02"))
+		if err != nil {
+			return fmt.Errorf("failed to record dividends: %v", err)
+		}
+		scheduler.AddRows(ctx, closes+sectors+dividends)
+		log.Printf("Finalized end-of-day snapshot for %s: %d symbols, %d sectors, %d dividends", day.Format("2006-01-02"), closes, sectors, dividends)
+
+		return stockScraper.MarkEODFinalized(day)
+	}
+}
+
+// mergeSymbols concatenates symbol lists, dropping repeats.
+func mergeSymbols(lists ...[]string) []string {
+	seen := make(map[string]bool)
+	merged := make([]string, 0)
+	for _, list := range lists {
+		for _, symbol := range list {
+			if !seen[symbol] {
+				seen[symbol] = true
+				merged = append(merged, symbol)
+			}
+		}
+	}
+	return merged
+}
+
+// refreshUniverseJob keeps quotes and intraday histories current for every
+// symbol in the managed universe while the market is open.
+func refreshUniverseJob(store *universe.Store, pool *queue.Pool) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		if !scraper.MarketOpen(time.Now()) {
+			return nil
+		}
+
+		symbols, err := store.Symbols()
+		if err != nil {
+			return fmt.Errorf("failed to load symbol universe: %v", err)
+		}
+		if len(symbols) == 0 {
+			return nil
+		}
+
+		stockScraper := scraper.NewStockScraper(scraper.StockScraperOption{
+			Context:  ctx,
+			Pool:     pool,
+			Priority: queue.Background,
+		})
+		defer stockScraper.Close()
+
+		stocks, err := stockScraper.ScrapeQuotes(symbols)
+		if err != nil {
+			return fmt.Errorf("failed to refresh universe quotes: %v", err)
+		}
+		scheduler.AddRows(ctx, len(stocks))
+		log.Printf("Refreshed %d of %d universe quotes", len(stocks), len(symbols))
+		return nil
+	}
+}
+
+func refreshSectorsJob(sinks []sink, tracker *changes.Tracker, pool *queue.Pool) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		sectorScraper := scraper.NewSectorScraper(scraper.ScraperOption{
+			Context:  ctx,
+			Pool:     pool,
+			Priority: queue.Background,
+		})
+		defer sectorScraper.Close()
+
+		sectors, err := sectorScraper.ScrapeAllSectors()
+		if err != nil {
+			return fmt.Errorf("failed to refresh sectors: %v", err)
+		}
+		scheduler.AddRows(ctx, len(sectors))
+
+		publish(sinks, tracker, "sectors", sectors)
+		return nil
+	}
+}
+
+func refreshNewsJob(sinks []sink, tracker *changes.Tracker, archive *newsarchive.Store, pool *queue.Pool) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		// Incremental, so each refresh visits and publishes only the
+		// articles earlier refreshes haven't
+		newsScraper := scraper.NewScraper(scraper.ScraperOption{
+			Context:     ctx,
+			Pool:        pool,
+			Priority:    queue.Background,
+			Incremental: true,
+		})
+		defer newsScraper.Close()
+
+		articles, err := newsScraper.ScrapeNews(scraper.MarketMidnight(time.Now()))
+		if err != nil {
+			return fmt.Errorf("failed to refresh news: %v", err)
+		}
+		scheduler.AddRows(ctx, len(articles))
+		if len(articles) == 0 {
+			return nil
+		}
+		newsScraper.Summarize(articles)
+		if added, err := archive.Add(articles); err != nil {
+			log.Printf("Error archiving news: %v", err)
+		} else if added > 0 {
+			log.Printf("Archived %d new articles", added)
+		}
+		trackImpacts(ctx, articles, pool)
+
+		publish(sinks, tracker, "news", articles)
+		return nil
+	}
+}
+
+// trackImpacts starts recording the price impact of the articles tagged
+// with tickers. Failures only cost the impact, so the refresh goes on.
+func trackImpacts(ctx context.Context, articles []model.Article, pool *queue.Pool) {
+	stockScraper := scraper.NewStockScraper(scraper.StockScraperOption{
+		Context:  ctx,
+		Pool:     pool,
+		Priority: queue.Background,
+	})
+	defer stockScraper.Close()
+
+	if tracked, err := stockScraper.TrackImpacts(articles); err != nil {
+		log.Printf("Error tracking news impact: %v", err)
+	} else if tracked > 0 {
+		log.Printf("Tracking price impact of %d articles", tracked)
+	}
+}
+
+// newsImpactJob prices tracked articles' tickers as their publish, +1h and
+// +1d horizons pass. It runs more often than the tolerance around each
+// horizon so one always gets a fresh quote.
+func newsImpactJob(pool *queue.Pool) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		stockScraper := scraper.NewStockScraper(scraper.StockScraperOption{
+			Context:  ctx,
+			Pool:     pool,
+			Priority: queue.Background,
+		})
+		defer stockScraper.Close()
+
+		recorded, err := stockScraper.RecordImpacts(time.Now())
+		if err != nil {
+			return fmt.Errorf("failed to record news impact: %v", err)
+		}
+		scheduler.AddRows(ctx, recorded)
+		return nil
+	}
+}
+
+func refreshCalendarJob(sinks []sink, tracker *changes.Tracker, pool *queue.Pool) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		calendarScraper := scraper.NewCalendarScraper(scraper.ScraperOption{
+			Context:  ctx,
+			Pool:     pool,
+			Priority: queue.Background,
+		})
+		defer calendarScraper.Close()
+
+		events, err := calendarScraper.ScrapeEconomicCalendar(time.Now().Format("2006-01-02"))
+		if err != nil {
+			return fmt.Errorf("failed to refresh economic calendar: %v", err)
+		}
+		scheduler.AddRows(ctx, len(events))
+
+		publish(sinks, tracker, "calendar", events)
+		return nil
+	}
+}
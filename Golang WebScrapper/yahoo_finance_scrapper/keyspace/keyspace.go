@@ -0,0 +1,293 @@
+package keyspace
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Option selects how keys are prefixed. With Namespace "acme",
+// "most_active_stocks" is stored as "acme:most_active_stocks".
+type Option struct {
+	Namespace string
+}
+
+var (
+	mutex     sync.RWMutex
+	namespace string
+)
+
+// Configure sets the namespace applied by every instrumented client.
+func Configure(opts Option) error {
+	if strings.ContainsAny(opts.Namespace, ":*?[] ") {
+		return fmt.Errorf("invalid namespace %q: must not contain ':', spaces or glob characters", opts.Namespace)
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	namespace = opts.Namespace
+	return nil
+}
+
+type contextKey int
+
+const rawKey contextKey = iota
+
+// Raw disables prefixing for calls made under ctx, for code that works on
+// the physical keys such as Migrate.
+func Raw(ctx context.Context) context.Context {
+	return context.WithValue(ctx, rawKey, true)
+}
+
+// Prefix returns the prefix applied to keys under ctx, empty when no
+// namespace is in effect.
+func Prefix(ctx context.Context) string {
+	if raw, _ := ctx.Value(rawKey).(bool); raw {
+		return ""
+	}
+
+	mutex.RLock()
+	defer mutex.RUnlock()
+	if namespace == "" {
+		return ""
+	}
+	return namespace + ":"
+}
+
+// Instrument adds the prefixing hook to rdb.
+func Instrument(rdb *redis.Client) {
+	rdb.AddHook(hook{})
+}
+
+// Commands that take no keys, or whose arguments aren't keys.
+var keyless = map[string]bool{
+	"auth": true, "client": true, "command": true, "dbsize": true,
+	"discard": true, "exec": true, "flushall": true, "flushdb": true,
+	"hello": true, "info": true, "multi": true, "ping": true,
+	"publish": true, "psubscribe": true, "punsubscribe": true,
+	"quit": true, "select": true, "subscribe": true, "unsubscribe": true,
+}
+
+// Commands where every argument is a key.
+var allKeys = map[string]bool{
+	"del": true, "exists": true, "mget": true, "touch": true,
+	"unlink": true, "watch": true, "pfcount": true, "pfmerge": true,
+	"sdiff": true, "sdiffstore": true, "sinter": true, "sinterstore": true,
+	"sunion": true, "sunionstore": true,
+}
+
+// Commands taking two keys, source then destination, before any other
+// arguments.
+var twoKeys = map[string]bool{
+	"rename": true, "renamenx": true, "copy": true, "rpoplpush": true,
+	"lmove": true, "smove": true, "brpoplpush": true, "blmove": true,
+	"zrangestore": true,
+}
+
+// Blocking pops take keys followed by a timeout.
+var blockingPop = map[string]bool{
+	"blpop": true, "brpop": true, "bzpopmin": true, "bzpopmax": true,
+}
+
+// Commands whose keys are counted by a numkeys argument at the given
+// index, optionally after a destination key at index 1.
+var numKeys = map[string]struct {
+	at          int
+	destination bool
+}{
+	"eval": {2, false}, "evalsha": {2, false}, "eval_ro": {2, false}, "evalsha_ro": {2, false},
+	"fcall": {2, false}, "fcall_ro": {2, false},
+	"zunion": {1, false}, "zinter": {1, false}, "zdiff": {1, false}, "zintercard": {1, false},
+	"sintercard": {1, false}, "lmpop": {1, false}, "zmpop": {1, false},
+	"zunionstore": {2, true}, "zinterstore": {2, true}, "zdiffstore": {2, true},
+	"blmpop": {2, false}, "bzmpop": {2, false},
+}
+
+// keyPositions returns the indexes into args holding key names, or an
+// error for a multi-key command it can't place the keys of, which would
+// otherwise reach keys outside the namespace.
+func keyPositions(name string, args []interface{}) ([]int, error) {
+	switch {
+	case keyless[name], len(args) < 2:
+		return nil, nil
+	case allKeys[name]:
+		return span(1, len(args)), nil
+	case twoKeys[name]:
+		return span(1, min(3, len(args))), nil
+	case blockingPop[name]:
+		return span(1, len(args)-1), nil
+	case name == "mset" || name == "msetnx":
+		positions := make([]int, 0, len(args)/2)
+		for i := 1; i < len(args); i += 2 {
+			positions = append(positions, i)
+		}
+		return positions, nil
+	case name == "scan":
+		return nil, nil
+	}
+
+	if counted, ok := numKeys[name]; ok {
+		if counted.at >= len(args) {
+			return nil, fmt.Errorf("keyspace: %s without numkeys", name)
+		}
+		n, err := strconv.Atoi(fmt.Sprint(args[counted.at]))
+		if err != nil || n < 0 || counted.at+n >= len(args) {
+			return nil, fmt.Errorf("keyspace: %s with invalid numkeys %v", name, args[counted.at])
+		}
+		positions := span(counted.at+1, counted.at+1+n)
+		if counted.destination {
+			positions = append([]int{1}, positions...)
+		}
+		return positions, nil
+	}
+	if multiKey[name] {
+		return nil, fmt.Errorf("keyspace: can't namespace the keys of %s", name)
+	}
+	return []int{1}, nil
+}
+
+// Multi-key commands the hook doesn't map, refused rather than only
+// having their first key prefixed.
+var multiKey = map[string]bool{
+	"georadius": true, "georadiusbymember": true, "migrate": true,
+	"object": true, "sort": true, "sort_ro": true, "xread": true,
+	"xreadgroup": true, "geosearchstore": true, "lcs": true,
+}
+
+func span(from, to int) []int {
+	positions := make([]int, 0, to-from)
+	for i := from; i < to; i++ {
+		positions = append(positions, i)
+	}
+	return positions
+}
+
+// rewrite prefixes cmd's key arguments in place and returns the original
+// arguments for restore. SCAN gets its MATCH pattern prefixed; a SCAN
+// without one still walks every key, since the command's arguments can't
+// grow here, and strip drops the keys outside this keyspace from its
+// replies.
+func rewrite(cmd redis.Cmder, prefix string) ([]interface{}, error) {
+	args := cmd.Args()
+	name := cmd.Name()
+	positions, err := keyPositions(name, args)
+	if err != nil {
+		return nil, err
+	}
+	original := append([]interface{}(nil), args...)
+
+	for _, i := range positions {
+		args[i] = prefix + fmt.Sprint(args[i])
+	}
+
+	if name == "scan" {
+		for i := 2; i+1 < len(args); i++ {
+			if strings.EqualFold(fmt.Sprint(args[i]), "match") {
+				args[i+1] = prefix + fmt.Sprint(args[i+1])
+				break
+			}
+		}
+	}
+	return original, nil
+}
+
+// restore puts back the arguments rewrite replaced. ScanIterator sends the
+// same command again for every page, so a pattern left prefixed would be
+// prefixed a second time from the second page on and match nothing.
+func restore(cmd redis.Cmder, original []interface{}) {
+	copy(cmd.Args(), original)
+}
+
+// strip removes prefix from replies that carry key names back.
+func strip(cmd redis.Cmder, prefix string) {
+	switch cmd := cmd.(type) {
+	case *redis.ScanCmd:
+		page, cursor := cmd.Val()
+		keys := page[:0]
+		for _, key := range page {
+			if trimmed, ok := strings.CutPrefix(key, prefix); ok {
+				keys = append(keys, trimmed)
+			}
+		}
+		cmd.SetVal(keys, cursor)
+	case *redis.ZWithKeyCmd:
+		if val := cmd.Val(); val != nil {
+			val.Key = strings.TrimPrefix(val.Key, prefix)
+		}
+	case *redis.StringSliceCmd:
+		if !blockingPop[cmd.Name()] && cmd.Name() != "keys" {
+			return
+		}
+		val := cmd.Val()
+		if blockingPop[cmd.Name()] && len(val) > 0 {
+			val[0] = strings.TrimPrefix(val[0], prefix)
+		}
+		if cmd.Name() == "keys" {
+			for i, key := range val {
+				val[i] = strings.TrimPrefix(key, prefix)
+			}
+		}
+		cmd.SetVal(val)
+	}
+}
+
+// hook prefixes keys with the namespace.
+type hook struct{}
+
+func (hook) DialHook(next redis.DialHook) redis.DialHook {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		return next(ctx, network, addr)
+	}
+}
+
+func (hook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		prefix := Prefix(ctx)
+		if prefix == "" {
+			return next(ctx, cmd)
+		}
+
+		original, err := rewrite(cmd, prefix)
+		if err != nil {
+			cmd.SetErr(err)
+			return err
+		}
+		err = next(ctx, cmd)
+		restore(cmd, original)
+		strip(cmd, prefix)
+		return err
+	}
+}
+
+func (hook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		prefix := Prefix(ctx)
+		if prefix == "" {
+			return next(ctx, cmds)
+		}
+
+		originals := make([][]interface{}, len(cmds))
+		for i, cmd := range cmds {
+			original, err := rewrite(cmd, prefix)
+			if err != nil {
+				for j, rewritten := range cmds[:i] {
+					restore(rewritten, originals[j])
+				}
+				cmd.SetErr(err)
+				return err
+			}
+			originals[i] = original
+		}
+		err := next(ctx, cmds)
+		for i, cmd := range cmds {
+			restore(cmd, originals[i])
+			strip(cmd, prefix)
+		}
+		return err
+	}
+}
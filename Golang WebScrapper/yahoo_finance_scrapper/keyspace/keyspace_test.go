@@ -0,0 +1,163 @@
+package keyspace
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+func configure(t *testing.T, opts Option) {
+	assert.NoError(t, Configure(opts))
+	t.Cleanup(func() { Configure(Option{}) })
+}
+
+func TestPrefix(t *testing.T) {
+	ctx := context.Background()
+
+	assert.Equal(t, "", Prefix(ctx), "no namespace")
+
+	configure(t, Option{Namespace: "acme"})
+	assert.Equal(t, "acme:", Prefix(ctx))
+	assert.Equal(t, "", Prefix(Raw(ctx)))
+
+	assert.Error(t, Configure(Option{Namespace: "a:b"}))
+}
+
+func process(ctx context.Context, cmd redis.Cmder, reply func(redis.Cmder)) []interface{} {
+	var sent []interface{}
+	hook{}.ProcessHook(func(ctx context.Context, cmd redis.Cmder) error {
+		sent = append(sent, cmd.Args()...)
+		if reply != nil {
+			reply(cmd)
+		}
+		return nil
+	})(ctx, cmd)
+	return sent
+}
+
+func TestProcessHook(t *testing.T) {
+	configure(t, Option{Namespace: "acme"})
+	ctx := context.Background()
+
+	assert.Equal(t, []interface{}{"hset", "acme:alerts", "id", "rule"},
+		process(ctx, redis.NewIntCmd(ctx, "hset", "alerts", "id", "rule"), nil))
+	assert.Equal(t, []interface{}{"del", "acme:a", "acme:b"},
+		process(ctx, redis.NewIntCmd(ctx, "del", "a", "b"), nil))
+	assert.Equal(t, []interface{}{"ping"},
+		process(ctx, redis.NewStatusCmd(ctx, "ping"), nil))
+
+	pop := redis.NewStringSliceCmd(ctx, "brpop", "jobs:pending", 5)
+	sent := process(ctx, pop, func(cmd redis.Cmder) {
+		cmd.(*redis.StringSliceCmd).SetVal([]string{"acme:jobs:pending", "42"})
+	})
+	assert.Equal(t, []interface{}{"brpop", "acme:jobs:pending", 5}, sent)
+	assert.Equal(t, []string{"jobs:pending", "42"}, pop.Val())
+
+	scan := redis.NewScanCmd(ctx, nil, "scan", 0, "match", "sector:*", "count", 100)
+	sent = process(ctx, scan, func(cmd redis.Cmder) {
+		cmd.(*redis.ScanCmd).SetVal([]string{"acme:sector:tech"}, 0)
+	})
+	assert.Equal(t, "acme:sector:*", sent[3])
+	keys, _ := scan.Val()
+	assert.Equal(t, []string{"sector:tech"}, keys)
+}
+
+func TestScanWithoutMatchOnlyReturnsOwnKeys(t *testing.T) {
+	configure(t, Option{Namespace: "acme"})
+	ctx := context.Background()
+
+	scan := redis.NewScanCmd(ctx, nil, "scan", 0, "count", 100)
+	process(ctx, scan, func(cmd redis.Cmder) {
+		cmd.(*redis.ScanCmd).SetVal([]string{"acme:sector:tech", "other:sector:tech", "acme:v1:quote"}, 7)
+	})
+	keys, cursor := scan.Val()
+	assert.Equal(t, []string{"sector:tech", "v1:quote"}, keys)
+	assert.Equal(t, uint64(7), cursor)
+}
+
+func TestMultiKeyCommands(t *testing.T) {
+	configure(t, Option{Namespace: "acme"})
+	ctx := context.Background()
+
+	tests := []struct {
+		args []interface{}
+		want []interface{}
+	}{
+		{
+			[]interface{}{"mset", "a", "1", "b", "2"},
+			[]interface{}{"mset", "acme:a", "1", "acme:b", "2"},
+		},
+		{
+			[]interface{}{"lmove", "src", "dst", "left", "right"},
+			[]interface{}{"lmove", "acme:src", "acme:dst", "left", "right"},
+		},
+		{
+			[]interface{}{"rpoplpush", "src", "dst"},
+			[]interface{}{"rpoplpush", "acme:src", "acme:dst"},
+		},
+		{
+			[]interface{}{"smove", "src", "dst", "member"},
+			[]interface{}{"smove", "acme:src", "acme:dst", "member"},
+		},
+		{
+			[]interface{}{"sunionstore", "dst", "a", "b"},
+			[]interface{}{"sunionstore", "acme:dst", "acme:a", "acme:b"},
+		},
+		{
+			[]interface{}{"zunionstore", "dst", 2, "a", "b", "weights", 1, 2},
+			[]interface{}{"zunionstore", "acme:dst", 2, "acme:a", "acme:b", "weights", 1, 2},
+		},
+		{
+			[]interface{}{"evalsha", "sha", 2, "a", "b", "arg"},
+			[]interface{}{"evalsha", "sha", 2, "acme:a", "acme:b", "arg"},
+		},
+		{
+			[]interface{}{"eval", "return 1", 0, "arg"},
+			[]interface{}{"eval", "return 1", 0, "arg"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.args[0].(string), func(t *testing.T) {
+			assert.Equal(t, tt.want, process(ctx, redis.NewCmd(ctx, tt.args...), nil))
+		})
+	}
+}
+
+func TestUnmappedMultiKeyCommandsFail(t *testing.T) {
+	configure(t, Option{Namespace: "acme"})
+	ctx := context.Background()
+
+	cmd := redis.NewCmd(ctx, "sort", "list", "by", "weight_*", "store", "dst")
+	assert.Nil(t, process(ctx, cmd, nil), "not sent")
+	assert.Error(t, cmd.Err())
+
+	cmd = redis.NewCmd(ctx, "eval", "return 1", 3, "a")
+	assert.Nil(t, process(ctx, cmd, nil), "numkeys past the arguments")
+	assert.Error(t, cmd.Err())
+}
+
+func TestScanIteratorWalksEveryPage(t *testing.T) {
+	configure(t, Option{Namespace: "acme"})
+	ctx := context.Background()
+
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	Instrument(rdb)
+	for i := 0; i < 500; i++ {
+		assert.NoError(t, rdb.Set(ctx, fmt.Sprintf("v%d:quote", i), "1", 0).Err())
+	}
+	assert.NoError(t, mr.Set("other:v1:quote", "1"))
+
+	seen := 0
+	iter := rdb.Scan(ctx, 0, "v*:*", 10).Iterator()
+	for iter.Next(ctx) {
+		assert.NotContains(t, iter.Val(), "acme:")
+		seen++
+	}
+	assert.NoError(t, iter.Err())
+	assert.Equal(t, 500, seen)
+}
@@ -0,0 +1,42 @@
+package keyspace
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Migrate moves keys written before a namespace was configured under it,
+// so turning namespacing on doesn't strand existing alerts, users and
+// caches. Keys already in the namespace are left alone, and RENAMENX never
+// overwrites a key written since. Only run it against a Redis holding this
+// deployment's keys alone: anything unprefixed is assumed to be ours.
+func Migrate(ctx context.Context, rdb *redis.Client) (int, error) {
+	prefix := Prefix(context.Background())
+	if prefix == "" {
+		return 0, fmt.Errorf("no namespace configured")
+	}
+
+	ctx = Raw(ctx)
+	moved := 0
+	iter := rdb.Scan(ctx, 0, "*", 100).Iterator()
+	for iter.Next(ctx) {
+		key := iter.Val()
+		if strings.HasPrefix(key, prefix) {
+			continue
+		}
+		ok, err := rdb.RenameNX(ctx, key, prefix+key).Result()
+		if err != nil && err != redis.Nil {
+			return moved, fmt.Errorf("failed to move %s: %v", key, err)
+		}
+		if ok {
+			moved++
+		}
+	}
+	if err := iter.Err(); err != nil {
+		return moved, fmt.Errorf("failed to scan keys: %v", err)
+	}
+	return moved, nil
+}
@@ -1,50 +1,852 @@
 package main
 
 import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"go-webscraper/alerts"
+	"go-webscraper/audit"
+	"go-webscraper/auth"
+	"go-webscraper/bot/discord"
+	"go-webscraper/bus"
+	"go-webscraper/cache"
+	"go-webscraper/cachekey"
+	"go-webscraper/changes"
+	"go-webscraper/compat"
+	"go-webscraper/config"
+	"go-webscraper/file"
+	"go-webscraper/fx"
+	"go-webscraper/keyspace"
+	"go-webscraper/mcp"
+	"go-webscraper/metrics"
 	"go-webscraper/middleware"
+	"go-webscraper/migrations"
+	"go-webscraper/mode"
+	"go-webscraper/newsarchive"
+	"go-webscraper/notify"
+	"go-webscraper/pkg/yahoo"
+	"go-webscraper/portfolio"
+	"go-webscraper/preflight"
+	"go-webscraper/query"
+	"go-webscraper/queue"
+	"go-webscraper/reports"
+	"go-webscraper/scheduler"
 	"go-webscraper/scraper"
+	"go-webscraper/screener"
+	"go-webscraper/server"
+	"go-webscraper/snapshot"
+	"go-webscraper/storage"
+	"go-webscraper/summarize"
+	"go-webscraper/tracing"
+	"go-webscraper/ui"
+	"go-webscraper/universe"
+	"go-webscraper/upstream"
+	"go-webscraper/usage"
+	"go-webscraper/watchlist"
+	"go-webscraper/webhook"
 
-	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/extra/redisotel/v9"
+	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 )
 
 func main() {
-	gin.SetMode(gin.DebugMode)
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+	args, err := config.ApplyFlags(cfg, os.Args[1:])
+	if err != nil {
+		log.Fatalf("Invalid flags: %v", err)
+	}
+	command := ""
+	if len(args) > 0 {
+		command = args[0]
+	}
+	if command != "" && command != "migrate" && command != "verify" {
+		log.Fatalf("Unknown command %q, expected migrate or verify", command)
+	}
+	if command == "verify" {
+		verifyParsers()
+		return
+	}
 
-	r := gin.Default()
+	shutdownTracing, err := tracing.Setup(tracing.TracingOption{
+		Enabled:     cfg.Tracing.Enabled,
+		Endpoint:    cfg.Tracing.Endpoint,
+		Insecure:    cfg.Tracing.Insecure,
+		ServiceName: cfg.Tracing.ServiceName,
+		SampleRatio: cfg.Tracing.SampleRatio,
+	})
+	if err != nil {
+		log.Fatalf("Failed to set up tracing: %v", err)
+	}
+	defer shutdownTracing(context.Background())
 
-	r.Use(cors.New(cors.Config{
-		AllowOrigins:     []string{"*"},
-		AllowMethods:     []string{"GET", "POST", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "X-API-Key"},
-		ExposeHeaders:    []string{"Content-Length"},
-		AllowCredentials: true,
-	}))
+	if cfg.Demo.Enabled {
+		serveDemo(cfg)
+		return
+	}
 
-	r.Use(gin.Recovery())
+	if err := keyspace.Configure(keyspace.Option{
+		Namespace: cfg.Redis.Namespace,
+	}); err != nil {
+		log.Fatalf("Invalid redis namespace: %v", err)
+	}
+
+	if err := storage.Validate(cfg.Storage.Driver, cfg.Cache.Backend); err != nil {
+		log.Fatalf("Invalid storage config: %v", err)
+	}
+	var embedded *storage.Server
+	if cfg.Storage.Driver == storage.DriverSQLite {
+		embedded, err = storage.Start(storage.Option{
+			Addr:          cfg.Redis.Addr,
+			Path:          cfg.Storage.Path,
+			FlushInterval: cfg.Storage.FlushInterval,
+		})
+		if err != nil {
+			log.Fatalf("Failed to start embedded storage: %v", err)
+		}
+		// The server never returns, so save the keyspace on the way out
+		// rather than in a defer
+		go func() {
+			stop := make(chan os.Signal, 1)
+			signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+			<-stop
+			if err := embedded.Close(); err != nil {
+				log.Printf("Error closing embedded storage: %v", err)
+			}
+			os.Exit(0)
+		}()
+	}
+
+	if err := cache.Configure(cache.Option{
+		Backend:    cfg.Cache.Backend,
+		MaxEntries: cfg.Cache.MaxEntries,
+	}); err != nil {
+		log.Fatalf("Invalid cache config: %v", err)
+	}
+	ttls := make(map[string]cachekey.Policy, len(cfg.Cache.TTL))
+	for source, ttl := range cfg.Cache.TTL {
+		ttls[source] = cachekey.Policy{Open: ttl.Open, Closed: ttl.Closed}
+	}
+	if err := cachekey.ConfigureTTLs(ttls); err != nil {
+		log.Fatalf("Invalid cache TTL config: %v", err)
+	}
+
+	if err := upstream.Configure(upstream.Option{
+		MaxIdleConns:          cfg.Upstream.MaxIdleConns,
+		MaxConnsPerHost:       cfg.Upstream.MaxConnsPerHost,
+		IdleConnTimeout:       cfg.Upstream.IdleConnTimeout,
+		TLSHandshakeTimeout:   cfg.Upstream.TLSHandshakeTimeout,
+		ResponseHeaderTimeout: cfg.Upstream.ResponseHeaderTimeout,
+		HTTP2:                 cfg.Upstream.HTTP2,
+		Proxy:                 cfg.Upstream.Proxy,
+		UserAgent:             cfg.Upstream.UserAgent,
+		RequestsPerMinute:     cfg.Upstream.RequestsPerMinute,
+		MaxPageBytes:          cfg.Upstream.MaxPageBytes,
+		PageTimeout:           cfg.Upstream.PageTimeout,
+	}); err != nil {
+		log.Fatalf("Failed to configure upstream client: %v", err)
+	}
+	if err := yahoo.ConfigureSelectors(cfg.Selectors); err != nil {
+		log.Fatalf("Invalid selector config: %v", err)
+	}
+	if err := scraper.ConfigureCoalescing(cfg.Refresh.CoalesceWindow); err != nil {
+		log.Fatalf("Invalid refresh config: %v", err)
+	}
+	if err := scraper.ConfigureNewsDiscovery(cfg.News.Source, cfg.News.Feeds); err != nil {
+		log.Fatalf("Invalid news config: %v", err)
+	}
+	summarizer, err := summarize.New(summarize.Option{
+		Provider: cfg.Summarizer.Provider,
+		URL:      cfg.Summarizer.URL,
+		Model:    cfg.Summarizer.Model,
+		APIKey:   cfg.Summarizer.APIKey,
+		Timeout:  cfg.Summarizer.Timeout,
+	})
+	if err != nil {
+		log.Fatalf("Invalid summarizer config: %v", err)
+	}
+	if err := scraper.ConfigureSummarizer(summarizer, cfg.Summarizer.Sentences); err != nil {
+		log.Fatalf("Invalid summarizer config: %v", err)
+	}
+
+	if err := scraper.ConfigureOutputDir(cfg.Output.Dir); err != nil {
+		log.Fatalf("Invalid output config: %v", err)
+	}
+	scraper.ConfigureRedis(cfg.Redis.Addr, cfg.Redis.Password, cfg.Redis.DB)
+	converter := fx.NewConverter(fx.ConverterOption{
+		RedisAddr:     cfg.Redis.Addr,
+		RedisPassword: cfg.Redis.Password,
+		RedisDB:       cfg.Redis.DB,
+	})
+	defer converter.Close()
+	scraper.ConfigureCurrency(converter)
+
+	archiver := file.NewArchiver(file.ArchiveOption{
+		BaseDir:       cfg.Archive.Dir,
+		Layout:        cfg.Archive.Layout,
+		CompressAfter: cfg.Archive.CompressAfter,
+		RetainFor:     cfg.Archive.RetainFor,
+	})
+
+	rdb := redis.NewClient(&redis.Options{
+		Addr:     cfg.Redis.Addr,
+		Password: cfg.Redis.Password,
+		DB:       cfg.Redis.DB,
+	})
+	defer rdb.Close()
+	if err := redisotel.InstrumentTracing(rdb); err != nil {
+		log.Printf("Error instrumenting redis client: %v", err)
+	}
+	keyspace.Instrument(rdb)
+	mode.Configure(rdb)
+	runChecks(cfg, rdb)
+	if cfg.Redis.MigrateKeys {
+		moved, err := keyspace.Migrate(context.Background(), rdb)
+		if err != nil {
+			log.Fatalf("Failed to migrate keys into namespace %s: %v", cfg.Redis.Namespace, err)
+		}
+		log.Printf("Moved %d keys into namespace %s", moved, cfg.Redis.Namespace)
+	}
+	if cfg.Migrations.Auto || command == "migrate" {
+		applied, err := migrations.Run(context.Background(), rdb)
+		if err != nil {
+			log.Fatalf("Failed to migrate: %v", err)
+		}
+		if command == "migrate" {
+			log.Printf("Applied %d migrations, schema is at version %d", len(applied), len(migrations.All))
+			if embedded != nil {
+				if err := embedded.Close(); err != nil {
+					log.Fatalf("Failed to save embedded storage: %v", err)
+				}
+			}
+			return
+		}
+	}
+
+	subscriptions := webhook.NewStore(rdb)
+	dispatcher := webhook.NewDispatcher(subscriptions, webhook.DispatcherOption{
+		Timeout:    cfg.Webhook.Timeout,
+		MaxRetries: cfg.Webhook.MaxRetries,
+	})
+
+	notifier := notify.NewNotifier(rdb)
+	alertRules := alerts.NewStore(rdb)
+	watchlistStore := watchlist.NewStore(rdb)
+	alertEngine := alerts.NewEngine(alertRules, watchlistStore, notifier)
+
+	sinks := []sink{dispatcher, alertEngine}
+
+	emitter, err := bus.NewEmitter(bus.BusOption{
+		Driver:  cfg.Bus.Driver,
+		Brokers: cfg.Bus.Brokers,
+		NatsURL: cfg.Bus.NatsURL,
+		Topics:  cfg.Bus.Topics,
+	})
+	if err != nil {
+		log.Fatalf("Failed to set up message bus: %v", err)
+	}
+	if emitter != nil {
+		defer emitter.Close()
+		sinks = append(sinks, emitter)
+	}
+
+	jwtSecret := cfg.Auth.JWTSecret
+	if jwtSecret == "" {
+		log.Printf("auth.jwt_secret is not set, generating an ephemeral secret")
+		jwtSecret = randomSecret()
+	}
+	users := auth.NewStore(rdb)
+	tokens := auth.NewTokens(jwtSecret, cfg.Auth.TokenTTL)
+
+	auditLog := audit.NewLogger(rdb, cfg.Audit.RetainFor)
+	usageStore := usage.NewStore(rdb, cfg.Usage.RetainFor, cfg.Usage.MaxKeys)
+	stopBans, err := middleware.PersistBans(rdb, cfg.Abuse.SyncInterval)
+	if err != nil {
+		log.Fatalf("Failed to load bans: %v", err)
+	}
+	defer stopBans()
+
+	tracker := changes.NewTracker(rdb)
+	screens := screener.NewStore(rdb)
+	universeStore := universe.NewStore(rdb)
+	portfolioStore := portfolio.NewStore(rdb)
+	reportSubscriptions := reports.NewStore(rdb)
+	newsArchive := newsarchive.NewStore(rdb)
+
+	scrapePool := queue.NewPool(queue.PoolOption{
+		Size:                 cfg.Pool.Size,
+		MaxQueuedInteractive: cfg.Pool.MaxQueuedInteractive,
+		MaxQueuedBackground:  cfg.Pool.MaxQueuedBackground,
+		RetryAfter:           cfg.Pool.RetryAfter,
+	})
+
+	var discordBot *discord.Bot
+	if cfg.Discord.Enabled {
+		discordBot, err = discord.NewBot(alertRules, notifier, discord.BotOption{
+			ApplicationID: cfg.Discord.ApplicationID,
+			PublicKey:     cfg.Discord.PublicKey,
+			BotToken:      cfg.Discord.BotToken,
+			GuildID:       cfg.Discord.GuildID,
+			Pool:          scrapePool,
+		})
+		if err != nil {
+			log.Fatalf("Invalid discord config: %v", err)
+		}
+		notifier.Register(discord.ChannelType, discordBot.Send)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		if err := discordBot.RegisterCommands(ctx); err != nil {
+			log.Printf("Error registering discord commands: %v", err)
+		}
+		cancel()
+	}
+
+	jobQueue := queue.New(rdb, queue.QueueOption{
+		Workers:    cfg.Jobs.Workers,
+		ResultTTL:  cfg.Jobs.ResultTTL,
+		MaxPending: cfg.Jobs.MaxPending,
+	})
+	scraper.RegisterJobs(jobQueue, scrapePool)
+	jobQueue.Start()
+	defer jobQueue.Stop()
+
+	// Snapshots scan Redis for cached blobs, so other backends have none
+	var snapshots *snapshot.Snapshotter
+	if cfg.WarmStart.Enabled && cache.Backend() == cache.BackendRedis {
+		snapshots = snapshot.New(rdb, snapshot.SnapshotOption{
+			Path:   cfg.WarmStart.File,
+			MaxAge: cfg.WarmStart.MaxAge,
+			MinTTL: cfg.WarmStart.MinTTL,
+		})
+		if restored, err := snapshots.Load(); err != nil {
+			log.Printf("Warm start skipped: %v", err)
+		} else {
+			log.Printf("Warm start restored %d cache entries from %s", restored, cfg.WarmStart.File)
+		}
+	}
+
+	// Runs after the warm start, which may restore blobs cached by an
+	// older build
+	if purged, err := cachekey.PurgeStale(context.Background(), rdb); err != nil {
+		log.Printf("Error purging stale cache keys: %v", err)
+	} else if purged > 0 {
+		log.Printf("Purged %d cache entries from older key versions", purged)
+	}
+
+	reportBuilder := reports.MarketBuilder(portfolioStore, scrapePool)
+
+	sched := scheduler.New()
+	sched.Add("refresh_stocks", cfg.Refresh.Stocks, refreshStocksJob(sinks, tracker, scrapePool))
+	sched.Add("refresh_sectors", cfg.Refresh.Sectors, refreshSectorsJob(sinks, tracker, scrapePool))
+	sched.Add("refresh_news", cfg.Refresh.News, refreshNewsJob(sinks, tracker, newsArchive, scrapePool))
+	sched.Add("news_impact", 5*time.Minute, newsImpactJob(scrapePool))
+	sched.Add("refresh_calendar", cfg.Refresh.Calendar, refreshCalendarJob(sinks, tracker, scrapePool))
+	sched.Add("refresh_breadth", cfg.Refresh.Breadth, refreshBreadthJob(sinks, tracker, scrapePool))
+	sched.Add("refresh_universe", cfg.Refresh.Universe, refreshUniverseJob(universeStore, scrapePool))
+	sched.Add("eod", 5*time.Minute, eodJob(universeStore, portfolioStore, scrapePool, cfg.Refresh.EODDelay))
+	sched.Add("watchlist_alerts", cfg.Refresh.WatchlistAlerts, watchlistAlertsJob(alertEngine, scrapePool))
+	sched.Add("screens", 1*time.Minute, screener.RunDueScreens(screens, screener.MarketSource, notifier))
+	sched.Add("reports", 1*time.Minute, reports.RunDue(reportSubscriptions, reportBuilder, notifier))
+	if cfg.Archive.Enabled {
+		sched.Add("archive", cfg.Archive.Interval, archiveJob(archiver, scrapePool))
+	}
+	if snapshots != nil {
+		sched.Add("snapshot", cfg.WarmStart.Interval, snapshots.Job())
+	}
+	if cfg.Scheduler.FailureThreshold > 0 {
+		channels := make([]notify.Channel, 0, len(cfg.Scheduler.AlertChannels))
+		for _, channel := range cfg.Scheduler.AlertChannels {
+			channels = append(channels, notify.Channel{Type: channel.Type, Target: channel.Target})
+		}
+		sched.AlertAfter(cfg.Scheduler.FailureThreshold, jobAlerter(notifier, channels))
+	}
+	sched.SkipWhen(mode.PausesJobs)
+	if err := sched.Restore(scheduler.NewStore(rdb)); err != nil {
+		log.Printf("Error restoring scheduler overrides: %v", err)
+	}
+	sched.Start()
+	defer sched.Stop()
+
+	// Exports rotate with the archive job, so their janitor only reports
+	// disk usage
+	janitors := []*file.Janitor{file.NewJanitor(file.JanitorOption{
+		Dirs:     []string{cfg.Output.Dir},
+		MaxAge:   cfg.Output.MaxAge,
+		MaxBytes: cfg.Output.MaxSize,
+		Interval: cfg.Output.Interval,
+	})}
+	if cfg.Archive.Enabled {
+		janitors = append(janitors, file.NewJanitor(file.JanitorOption{
+			Dirs:     []string{cfg.Archive.Dir},
+			Interval: cfg.Output.Interval,
+		}))
+	}
+	for _, janitor := range janitors {
+		janitor.Start()
+		defer janitor.Stop()
+	}
+
+	r := newRouter(cfg)
+
+	timeoutFor := func(group string) gin.HandlerFunc {
+		d, exists := cfg.Server.Timeouts[group]
+		if !exists {
+			d = cfg.Server.Timeouts["default"]
+		}
+		return middleware.Timeout(d)
+	}
+
+	if cfg.UI.Enabled {
+		ui.Register(r)
+	}
 
 	api := r.Group("/api")
+	api.Use(queue.NewInflight(cfg.Pool.MaxInflight).Middleware(), usage.Track(usageStore))
 	{
+		authGroup := api.Group("/auth")
+		authGroup.Use(middleware.RateLimitProfile("auth"), timeoutFor("auth"))
+		{
+			authGroup.POST("/register", audit.Record(auditLog, "user.register"), auth.HandleRegister(users, tokens))
+			authGroup.POST("/login", auth.HandleLogin(users, tokens))
+			authGroup.GET("/me", middleware.Identify(tokens), auth.HandleMe(users))
+		}
+
+		me := api.Group("/me")
+		me.Use(middleware.RateLimitProfile("api"), timeoutFor("me"))
+		{
+			me.GET("/usage", usage.HandleMyUsage(usageStore))
+		}
+
 		news := api.Group("/news")
-		news.Use(middleware.IPRateLimit())
+		news.Use(middleware.RateLimitProfile("news"), timeoutFor("news"))
 		{
-			news.GET("", scraper.HandleNews)
+			news.GET("", scraper.HandleNews(jobQueue, scrapePool))
+			news.GET("/archive", newsarchive.HandleSearch(newsArchive))
+			news.GET("/publishers", newsarchive.HandlePublishers(newsArchive))
+			news.GET("/authors", newsarchive.HandleAuthors(newsArchive))
+			news.GET("/digest", summarize.HandleDigest(newsArchive, summarizer, scraper.MarketMidnight))
+			news.GET("/:id/impact", scraper.HandleNewsImpact())
 		}
 
 		stocks := api.Group("/stock")
-		stocks.Use(middleware.IPRateLimit())
+		stocks.Use(middleware.RateLimitProfile("stock"), timeoutFor("stock"))
 		{
-			stocks.GET("", scraper.HandleStock)
+			stocks.GET("", scraper.HandleStock(scrapePool))
+			stocks.GET("/quotes", scraper.HandleQuotes(scrapePool))
+			stocks.GET("/most-shorted", scraper.HandleMostShorted(scrapePool))
+			stocks.GET("/:symbol/short-interest", scraper.HandleShortInterest(scrapePool))
+			stocks.GET("/:symbol/peers", scraper.HandlePeers(scrapePool))
+			stocks.GET("/:symbol/news", scraper.HandleSymbolNews(scrapePool))
+			stocks.GET("/:symbol/sparkline", scraper.HandleSparkline(scrapePool))
+			stocks.GET("/:symbol/eod", scraper.HandleEOD())
+			stocks.GET("/:symbol/volume-profile", scraper.HandleVolumeProfile())
 		}
-		// Reconsider other Rate Limiter
 		sectors := api.Group("/sector")
-		sectors.Use(middleware.SectorAPIRateLimit())
+		sectors.Use(middleware.RateLimitProfile("sector"), timeoutFor("sector"))
+		{
+			sectors.GET("", scraper.HandleSector(jobQueue, scrapePool))
+			sectors.GET("/:name/constituents", scraper.HandleSectorConstituents(scrapePool))
+			sectors.GET("/:name/movers", scraper.HandleSectorMovers(scrapePool))
+		}
+
+		chart := api.Group("/chart")
+		chart.Use(middleware.RateLimitProfile("chart"), timeoutFor("chart"))
+		{
+			chart.GET("/:file", scraper.HandleChart())
+		}
+
+		if discordBot != nil {
+			discordGroup := api.Group("/discord")
+			discordGroup.Use(timeoutFor("discord"))
+			{
+				discordGroup.POST("/interactions", discord.HandleInteraction(discordBot))
+			}
+		}
+
+		queryGroup := api.Group("/query")
+		queryGroup.Use(middleware.RateLimitProfile("query"), timeoutFor("query"))
+		{
+			queryGroup.POST("", query.HandleQuery(scrapePool))
+		}
+
+		tools := mcp.NewServer(mcp.MarketTools(scrapePool))
+		mcpGroup := api.Group("/mcp")
+		mcpGroup.Use(middleware.RateLimitProfile("mcp"), timeoutFor("mcp"))
+		{
+			mcpGroup.POST("", mcp.HandleRPC(tools))
+			mcpGroup.GET("/tools", mcp.HandleListTools(tools))
+			mcpGroup.POST("/tools/:name", mcp.HandleCallTool(tools))
+		}
+
+		analytics := api.Group("/analytics")
+		analytics.Use(middleware.RateLimitProfile("analytics"), timeoutFor("analytics"))
+		{
+			analytics.GET("/sector-rotation", scraper.HandleSectorRotation())
+			analytics.GET("/returns", scraper.HandleReturns())
+		}
+
+		calendar := api.Group("/economic-calendar")
+		calendar.Use(middleware.RateLimitProfile("calendar"), timeoutFor("calendar"))
+		{
+			calendar.GET("", scraper.HandleEconomicCalendar(scrapePool))
+		}
+
+		dashboard := api.Group("/dashboard")
+		dashboard.Use(middleware.RateLimitProfile("dashboard"), timeoutFor("dashboard"))
+		{
+			dashboard.GET("", scraper.HandleDashboard(scrapePool))
+		}
+
+		commodities := api.Group("/commodities")
+		commodities.Use(middleware.RateLimitProfile("commodities"), timeoutFor("commodities"))
+		{
+			commodities.GET("", scraper.HandleCommodities(scrapePool))
+		}
+
+		index := api.Group("/index")
+		index.Use(middleware.RateLimitProfile("index"), timeoutFor("index"))
+		{
+			index.GET("/:symbol/components", scraper.HandleIndexComponents(scrapePool))
+		}
+
+		breadth := api.Group("/breadth")
+		breadth.Use(middleware.RateLimitProfile("breadth"), timeoutFor("breadth"))
+		{
+			breadth.GET("", scraper.HandleBreadth(scrapePool))
+		}
+
+		dividends := api.Group("/dividends")
+		dividends.Use(middleware.RateLimitProfile("dividends"), timeoutFor("dividends"))
+		{
+			dividends.GET("/calendar", scraper.HandleDividendCalendar(scrapePool))
+		}
+
+		options := api.Group("/options")
+		options.Use(middleware.RateLimitProfile("options"), timeoutFor("options"))
+		{
+			options.GET("/most-active", scraper.HandleMostActiveOptions(scrapePool))
+		}
+
+		jobsGroup := api.Group("/jobs")
+		jobsGroup.Use(middleware.RateLimitProfile("jobs"), timeoutFor("jobs"))
+		{
+			jobsGroup.GET("/:id", queue.HandleGetJob(jobQueue))
+		}
+
+		screenerGroup := api.Group("/screener")
+		screenerGroup.Use(middleware.RateLimitProfile("screener"), timeoutFor("screener"))
+		{
+			screenerGroup.POST("", screener.HandleRunScreen(screener.MarketSource))
+		}
+
+		saved := api.Group("/screens")
+		saved.Use(middleware.RateLimitProfile("screens"), timeoutFor("screens"), middleware.Identify(tokens))
+		{
+			saved.POST("", audit.Record(auditLog, "screen.create"), screener.HandleCreateScreen(screens))
+			saved.GET("", screener.HandleListScreens(screens))
+			saved.DELETE("/:id", audit.Record(auditLog, "screen.delete"), screener.HandleDeleteScreen(screens))
+			saved.POST("/:id/run", screener.HandleRunSavedScreen(screens, screener.MarketSource))
+		}
+
+		watchlists := api.Group("/watchlists")
+		watchlists.Use(middleware.RateLimitProfile("watchlists"), timeoutFor("watchlists"), middleware.Identify(tokens))
+		{
+			watchlists.POST("", audit.Record(auditLog, "watchlist.create"), watchlist.HandleCreate(watchlistStore))
+			watchlists.GET("", watchlist.HandleList(watchlistStore))
+			watchlists.GET("/:id", watchlist.HandleGet(watchlistStore))
+			watchlists.PUT("/:id", audit.Record(auditLog, "watchlist.update"), watchlist.HandleUpdate(watchlistStore))
+			watchlists.DELETE("/:id", audit.Record(auditLog, "watchlist.delete"), watchlist.HandleDelete(watchlistStore))
+			watchlists.GET("/:id/dividends", scraper.HandleWatchlistDividends(watchlistStore, scrapePool))
+			watchlists.GET("/:id/returns", scraper.HandleWatchlistReturns(watchlistStore))
+		}
+
+		portfolios := api.Group("/portfolios")
+		portfolios.Use(middleware.RateLimitProfile("portfolios"), timeoutFor("portfolios"), middleware.Identify(tokens))
+		{
+			portfolios.POST("", audit.Record(auditLog, "portfolio.create"), portfolio.HandleCreate(portfolioStore))
+			portfolios.GET("", portfolio.HandleList(portfolioStore))
+			portfolios.GET("/:id", portfolio.HandleGet(portfolioStore))
+			portfolios.DELETE("/:id", audit.Record(auditLog, "portfolio.delete"), portfolio.HandleDelete(portfolioStore))
+			portfolios.POST("/:id/transactions", audit.Record(auditLog, "portfolio.transaction"), portfolio.HandleAddTransaction(portfolioStore))
+			portfolios.POST("/:id/import", audit.Record(auditLog, "portfolio.import"), portfolio.HandleImport(portfolioStore))
+			portfolios.GET("/:id/performance", scraper.HandlePortfolioPerformance(portfolioStore))
+		}
+
+		alertsGroup := api.Group("/alerts")
+		alertsGroup.Use(middleware.RateLimitProfile("alerts"), timeoutFor("alerts"), middleware.Identify(tokens))
+		{
+			alertsGroup.POST("", audit.Record(auditLog, "alert.create"), alerts.HandleCreateRule(alertRules, watchlistStore))
+			alertsGroup.GET("", alerts.HandleListRules(alertRules))
+			alertsGroup.DELETE("/:id", audit.Record(auditLog, "alert.delete"), alerts.HandleDeleteRule(alertRules))
+			alertsGroup.GET("/:id/events", alerts.HandleListEvents(alertRules))
+			alertsGroup.POST("/:id/events/:event/ack", audit.Record(auditLog, "alert.ack"), alerts.HandleAcknowledgeEvent(alertRules))
+			alertsGroup.POST("/:id/events/:event/snooze", audit.Record(auditLog, "alert.snooze"), alerts.HandleSnoozeEvent(alertRules))
+		}
+
+		notifications := api.Group("/notifications")
+		notifications.Use(middleware.RateLimitProfile("notifications"), timeoutFor("notifications"), middleware.Identify(tokens))
+		{
+			notifications.GET("/channel", notify.HandleGetChannel(notifier))
+			notifications.PUT("/channel", audit.Record(auditLog, "notification_channel.update"), notify.HandleSetChannel(notifier))
+		}
+
+		report := api.Group("/report")
+		report.Use(middleware.RateLimitProfile("reports"), timeoutFor("reports"))
+		{
+			report.GET("/:name", reports.HandleReport(reportBuilder))
+		}
+
+		reportsGroup := api.Group("/reports")
+		reportsGroup.Use(middleware.RateLimitProfile("reports"), timeoutFor("reports"), middleware.Identify(tokens))
+		{
+			reportsGroup.POST("/subscriptions", audit.Record(auditLog, "report_subscription.create"), reports.HandleCreate(reportSubscriptions, portfolioStore))
+			reportsGroup.GET("/subscriptions", reports.HandleList(reportSubscriptions))
+			reportsGroup.DELETE("/subscriptions/:id", audit.Record(auditLog, "report_subscription.delete"), reports.HandleDelete(reportSubscriptions))
+		}
+
+		exports := api.Group("/exports")
+		exports.Use(middleware.RateLimitProfile("exports"), timeoutFor("exports"))
 		{
-			sectors.GET("", scraper.HandleSector)
+			exports.GET("", file.HandleListExports(archiver))
+			exports.GET("/*path", file.HandleDownloadExport(archiver))
+		}
+
+		export := api.Group("/export")
+		export.Use(middleware.RateLimitProfile("exports"), timeoutFor("exports"))
+		{
+			export.GET("/bundle", file.HandleBundle(archiver))
+		}
+
+		sheets := api.Group("/sheets")
+		sheets.Use(middleware.RateLimitProfile("sheets"), timeoutFor("sheets"))
+		{
+			sheets.GET("/quotes", scraper.HandleSheetQuotes(scrapePool))
+		}
+
+		archive := api.Group("/archive")
+		archive.Use(middleware.RateLimitProfile("exports"), timeoutFor("archive"))
+		{
+			archive.GET("/:source", file.HandleStreamArchive(archiver))
+		}
+
+		subs := api.Group("/subscriptions")
+		subs.Use(middleware.RateLimitProfile("subscriptions"), timeoutFor("subscriptions"), middleware.Identify(tokens))
+		{
+			subs.POST("", audit.Record(auditLog, "subscription.create"), webhook.HandleCreateSubscription(subscriptions))
+			subs.GET("", webhook.HandleListSubscriptions(subscriptions))
+			subs.DELETE("/:id", audit.Record(auditLog, "subscription.delete"), webhook.HandleDeleteSubscription(subscriptions))
 		}
 	}
 
-	if err := r.Run(":8080"); err != nil {
+	r.GET("/metrics", gin.WrapH(metrics.Handler()))
+
+	adminFilter, err := middleware.IPFilter(cfg.Admin.AllowCIDRs, cfg.Admin.DenyCIDRs)
+	if err != nil {
+		log.Fatalf("Invalid admin CIDR config: %v", err)
+	}
+
+	admin := r.Group("/admin")
+	admin.Use(adminFilter, middleware.AdminAuth(cfg.Admin.Token))
+	{
+		ratelimit := admin.Group("/ratelimit")
+		ratelimit.GET("/clients", middleware.HandleListClients)
+		ratelimit.POST("/clients/reset", audit.Record(auditLog, "ratelimit.reset"), middleware.HandleResetClient)
+		ratelimit.GET("/bans", middleware.HandleListBans)
+		ratelimit.POST("/bans", audit.Record(auditLog, "ratelimit.ban"), middleware.HandleBanClient)
+		ratelimit.DELETE("/bans/:key", audit.Record(auditLog, "ratelimit.unban"), middleware.HandleUnbanClient)
+
+		admin.GET("/audit", audit.HandleListAudit(auditLog))
+		admin.GET("/analytics/usage", usage.HandleUsage(usageStore))
+		admin.GET("/mode", mode.HandleGetMode())
+		admin.PUT("/mode", audit.Record(auditLog, "mode.update"), mode.HandleSetMode())
+
+		jobs := admin.Group("/jobs")
+		{
+			jobs.GET("", scheduler.HandleListJobs(sched))
+			jobs.GET("/:name/runs", scheduler.HandleJobRuns(sched))
+			jobs.POST("/:name/trigger", audit.Record(auditLog, "scheduler.trigger"), scheduler.HandleTriggerJob(sched))
+			jobs.POST("/:name/pause", audit.Record(auditLog, "scheduler.pause"), scheduler.HandlePauseJob(sched))
+			jobs.POST("/:name/resume", audit.Record(auditLog, "scheduler.resume"), scheduler.HandleResumeJob(sched))
+			jobs.PUT("/:name/schedule", audit.Record(auditLog, "scheduler.reschedule"), scheduler.HandleRescheduleJob(sched))
+		}
+		admin.POST("/selftest", audit.Record(auditLog, "admin.selftest"), scraper.HandleSelfTest(scrapePool))
+		admin.GET("/quality", scraper.HandleQualityReport())
+		admin.GET("/upstream", scraper.HandleUpstreamStatus())
+
+		universeGroup := admin.Group("/universe")
+		{
+			universeGroup.GET("", universe.HandleListUniverse(universeStore))
+			universeGroup.GET("/:name", universe.HandleGetList(universeStore))
+			universeGroup.PUT("/:name", audit.Record(auditLog, "universe.import"), universe.HandleImportList(universeStore))
+			universeGroup.DELETE("/:name", audit.Record(auditLog, "universe.delete"), universe.HandleDeleteList(universeStore))
+		}
+	}
+
+	tlsCfg := cfg.Server.TLS
+	if err := server.Run(r, server.Option{
+		Addr:       cfg.Server.Addr,
+		SocketMode: os.FileMode(cfg.Server.SocketMode),
+		CertFile:   tlsCfg.CertFile,
+		KeyFile:    tlsCfg.KeyFile,
+		Autocert:   tlsCfg.Autocert,
+		Domains:    tlsCfg.Domains,
+		Email:      tlsCfg.Email,
+		CacheDir:   tlsCfg.CacheDir,
+		HTTPAddr:   tlsCfg.HTTPAddr,
+		Redirect:   tlsCfg.Redirect,
+	}); err != nil {
 		panic(err)
 	}
 }
+
+// newRouter builds the engine with the middleware every route shares,
+// after registering the configured rate limit profiles.
+func newRouter(cfg *config.Config) *gin.Engine {
+	rateLimits := make(map[string]middleware.Profile, len(cfg.RateLimits))
+	for name, rl := range cfg.RateLimits {
+		rateLimits[name] = middleware.Profile{
+			RPS:            rl.RPS,
+			Burst:          rl.Burst,
+			ExpirationTime: rl.Expiration,
+			Key:            rl.Key,
+		}
+	}
+	if err := middleware.RegisterProfiles(rateLimits); err != nil {
+		log.Fatalf("Invalid rate limit config: %v", err)
+	}
+
+	gin.SetMode(gin.DebugMode)
+
+	r := gin.Default()
+
+	if err := r.SetTrustedProxies(cfg.Proxy.TrustedProxies); err != nil {
+		log.Fatalf("Invalid trusted proxies: %v", err)
+	}
+	r.RemoteIPHeaders = cfg.Proxy.RealIPHeaders
+	switch cfg.Proxy.TrustedPlatform {
+	case "":
+		// Only RemoteIPHeaders from trusted proxies are consulted
+	case "cloudflare":
+		r.TrustedPlatform = gin.PlatformCloudflare
+	case "google_app_engine":
+		r.TrustedPlatform = gin.PlatformGoogleAppEngine
+	default:
+		// Any other value names the header carrying the client IP
+		r.TrustedPlatform = cfg.Proxy.TrustedPlatform
+	}
+
+	corsRoutes := make(map[string]middleware.CORSPolicy, len(cfg.CORS.Routes))
+	for prefix, policy := range cfg.CORS.Routes {
+		corsRoutes[prefix] = corsPolicy(policy)
+	}
+	corsHandler, err := middleware.CORS(corsPolicy(cfg.CORS.CORSPolicy), corsRoutes)
+	if err != nil {
+		log.Fatalf("Invalid CORS config: %v", err)
+	}
+	r.Use(corsHandler)
+
+	r.Use(gin.Recovery())
+	r.Use(middleware.AbuseGuard(middleware.AbuseConfig{
+		Window:         cfg.Abuse.Window,
+		MaxRateLimited: cfg.Abuse.MaxRateLimited,
+		MaxNotFound:    cfg.Abuse.MaxNotFound,
+		BanFor:         cfg.Abuse.BanFor,
+	}))
+	r.Use(mode.Guard())
+	r.Use(otelgin.Middleware(cfg.Tracing.ServiceName))
+	r.Use(middleware.BodyLimit(cfg.Server.MaxBodyBytes))
+	r.Use(middleware.SanitizeQuery(middleware.QueryLimits{
+		MaxLength:      cfg.Server.MaxQueryLength,
+		MaxParams:      cfg.Server.MaxQueryParams,
+		MaxValueLength: cfg.Server.MaxParamLength,
+	}))
+	r.Use(compat.Middleware())
+
+	return r
+}
+
+func corsPolicy(policy config.CORSPolicy) middleware.CORSPolicy {
+	return middleware.CORSPolicy{
+		AllowOrigins:     policy.AllowOrigins,
+		AllowMethods:     policy.AllowMethods,
+		AllowHeaders:     policy.AllowHeaders,
+		ExposeHeaders:    policy.ExposeHeaders,
+		AllowCredentials: policy.AllowCredentials,
+		MaxAge:           policy.MaxAge,
+	}
+}
+
+func randomSecret() string {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		log.Fatalf("Failed to generate JWT secret: %v", err)
+	}
+	return hex.EncodeToString(b)
+}
+
+// verifyParsers checks every parser against its stored fixtures, exiting
+// non-zero if any output no longer matches its golden file.
+func verifyParsers() {
+	results, err := scraper.VerifyParsers()
+	if err != nil {
+		log.Fatalf("Failed to verify parsers: %v", err)
+	}
+	failed := 0
+	for _, result := range results {
+		switch {
+		case result.Error != "":
+			failed++
+			log.Printf("FAIL %s: %s", result.Page, result.Error)
+		case !result.Passed:
+			failed++
+			log.Printf("FAIL %s: %s", result.Page, result.Diff)
+		default:
+			log.Printf("ok   %s", result.Page)
+		}
+	}
+	if failed > 0 {
+		log.Printf("%d of %d parsers changed their output", failed, len(results))
+		os.Exit(1)
+	}
+	log.Printf("All %d parsers match their golden outputs", len(results))
+}
+
+// runChecks verifies the dependencies configured in cfg before anything
+// relies on them, exiting with every failure and its remedy unless the
+// checks are ignored.
+func runChecks(cfg *config.Config, rdb *redis.Client) {
+	checks := []preflight.Check{
+		preflight.Redis(rdb),
+		preflight.WritableDir("output.dir", cfg.Output.Dir),
+		preflight.Reachable("https://finance.yahoo.com"),
+	}
+	if cfg.Storage.Driver == storage.DriverSQLite {
+		checks = append(checks, preflight.WritableFile("storage.path", cfg.Storage.Path))
+	}
+	if cfg.Archive.Enabled {
+		checks = append(checks, preflight.WritableDir("archive.dir", cfg.Archive.Dir))
+	}
+	if cfg.Server.TLS.Autocert {
+		checks = append(checks, preflight.WritableDir("server.tls.cache_dir", cfg.Server.TLS.CacheDir))
+	}
+
+	err := preflight.Run(context.Background(), cfg.Checks.Timeout, checks...)
+	if err == nil {
+		return
+	}
+	if cfg.Checks.Ignore {
+		log.Printf("Ignoring failed checks: %v", err)
+		return
+	}
+	log.Fatalf("%v\nFix the above, or start anyway with --ignore-checks", err)
+}
@@ -0,0 +1,262 @@
+// Package mcp exposes GoFinance to LLM agents as Model Context Protocol
+// tools over JSON-RPC, and as plain JSON tool calls for function-calling
+// clients that don't speak MCP.
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"go-webscraper/scraper"
+
+	"github.com/gin-gonic/gin"
+)
+
+// protocolVersions are the MCP revisions served, newest first. A client
+// asking for another is offered the newest.
+var protocolVersions = []string{"2025-06-18", "2025-03-26", "2024-11-05"}
+
+// JSON-RPC error codes.
+const (
+	codeParseError     = -32700
+	codeInvalidRequest = -32600
+	codeMethodNotFound = -32601
+	codeInvalidParams  = -32602
+)
+
+// Tool is one capability an agent can call. InputSchema is the JSON Schema
+// of the arguments Call accepts.
+type Tool struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	InputSchema map[string]interface{} `json:"inputSchema"`
+	Call        ToolFunc               `json:"-"`
+}
+
+type ToolFunc func(ctx context.Context, args json.RawMessage) (interface{}, error)
+
+type Server struct {
+	tools  []Tool
+	byName map[string]Tool
+}
+
+func NewServer(tools []Tool) *Server {
+	byName := make(map[string]Tool, len(tools))
+	for _, tool := range tools {
+		byName[tool.Name] = tool
+	}
+	return &Server{
+		tools:  tools,
+		byName: byName,
+	}
+}
+
+type request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+type response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type content struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+// callResult is a tools/call result. Tool failures are results with
+// IsError set, so the agent sees them, rather than JSON-RPC errors.
+type callResult struct {
+	Content []content `json:"content"`
+	IsError bool      `json:"isError,omitempty"`
+}
+
+func negotiate(requested string) string {
+	for _, version := range protocolVersions {
+		if version == requested {
+			return version
+		}
+	}
+	return protocolVersions[0]
+}
+
+// run calls the tool, treating missing arguments as an empty object.
+func (t Tool) run(ctx context.Context, args json.RawMessage) (interface{}, error) {
+	if len(args) == 0 || string(args) == "null" {
+		args = json.RawMessage("{}")
+	}
+	return t.Call(ctx, args)
+}
+
+// argumentError marks a tool failure caused by the caller's arguments.
+type argumentError struct {
+	error
+}
+
+// decodeArgs unmarshals a tool's arguments into v.
+func decodeArgs(args json.RawMessage, v interface{}) error {
+	if err := json.Unmarshal(args, v); err != nil {
+		return argumentError{fmt.Errorf("invalid arguments: %v", err)}
+	}
+	return nil
+}
+
+func invalidArgs(format string, a ...interface{}) error {
+	return argumentError{fmt.Errorf(format, a...)}
+}
+
+// dispatch answers one JSON-RPC request; it returns nil for notifications.
+func (s *Server) dispatch(ctx context.Context, req *request) *response {
+	if req.ID == nil {
+		return nil
+	}
+	resp := &response{JSONRPC: "2.0", ID: req.ID}
+	fail := func(code int, message string) *response {
+		resp.Error = &rpcError{Code: code, Message: message}
+		return resp
+	}
+	if req.JSONRPC != "2.0" {
+		return fail(codeInvalidRequest, "jsonrpc must be 2.0")
+	}
+
+	switch req.Method {
+	case "initialize":
+		var params struct {
+			ProtocolVersion string `json:"protocolVersion"`
+		}
+		if len(req.Params) > 0 {
+			if err := json.Unmarshal(req.Params, &params); err != nil {
+				return fail(codeInvalidParams, err.Error())
+			}
+		}
+		resp.Result = gin.H{
+			"protocolVersion": negotiate(params.ProtocolVersion),
+			"capabilities":    gin.H{"tools": gin.H{}},
+			"serverInfo":      gin.H{"name": "gofinance", "version": scraper.Version()},
+			"instructions":    "Market data scraped from Yahoo Finance: quotes, market movers, sector performance and news.",
+		}
+	case "ping":
+		resp.Result = gin.H{}
+	case "tools/list":
+		resp.Result = gin.H{"tools": s.tools}
+	case "tools/call":
+		var params struct {
+			Name      string          `json:"name"`
+			Arguments json.RawMessage `json:"arguments"`
+		}
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return fail(codeInvalidParams, err.Error())
+		}
+		tool, ok := s.byName[params.Name]
+		if !ok {
+			return fail(codeInvalidParams, fmt.Sprintf("unknown tool: %s", params.Name))
+		}
+		result, err := tool.run(ctx, params.Arguments)
+		if err != nil {
+			resp.Result = callResult{Content: []content{{Type: "text", Text: err.Error()}}, IsError: true}
+			break
+		}
+		text, err := json.Marshal(result)
+		if err != nil {
+			resp.Result = callResult{Content: []content{{Type: "text", Text: err.Error()}}, IsError: true}
+			break
+		}
+		resp.Result = callResult{Content: []content{{Type: "text", Text: string(text)}}}
+	default:
+		return fail(codeMethodNotFound, fmt.Sprintf("method not found: %s", req.Method))
+	}
+	return resp
+}
+
+// HandleRPC serves MCP's streamable HTTP transport without streaming:
+// every request is answered with a single JSON response.
+func HandleRPC(s *Server) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req request
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, response{
+				JSONRPC: "2.0",
+				ID:      json.RawMessage("null"),
+				Error:   &rpcError{Code: codeParseError, Message: err.Error()},
+			})
+			return
+		}
+
+		resp := s.dispatch(c.Request.Context(), &req)
+		if resp == nil {
+			c.Status(http.StatusAccepted)
+			return
+		}
+		c.JSON(http.StatusOK, resp)
+	}
+}
+
+// HandleListTools lists the tools with their schemas for plain JSON
+// tool calling.
+func HandleListTools(s *Server) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{
+			"status": "success",
+			"data":   s.tools,
+		})
+	}
+}
+
+// HandleCallTool calls the :name tool with the request body as its
+// arguments.
+func HandleCallTool(s *Server) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		args, err := c.GetRawData()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+		if len(args) > 0 && !json.Valid(args) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "arguments must be a JSON object",
+			})
+			return
+		}
+
+		tool, ok := s.byName[c.Param("name")]
+		if !ok {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "tool not found",
+			})
+			return
+		}
+		result, err := tool.run(c.Request.Context(), args)
+		if _, invalid := err.(argumentError); invalid {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"status": "success",
+			"data":   result,
+		})
+	}
+}
@@ -0,0 +1,166 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go-webscraper/scraper"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func testServer() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	echo := Tool{
+		Name:        "echo",
+		Description: "Echoes its text",
+		InputSchema: object(map[string]interface{}{"text": map[string]interface{}{"type": "string"}}, "text"),
+		Call: func(ctx context.Context, raw json.RawMessage) (interface{}, error) {
+			var args struct {
+				Text string `json:"text"`
+			}
+			if err := decodeArgs(raw, &args); err != nil {
+				return nil, err
+			}
+			if args.Text == "" {
+				return nil, invalidArgs("text is required")
+			}
+			if args.Text == "fail" {
+				return nil, fmt.Errorf("upstream unavailable")
+			}
+			return map[string]string{"text": args.Text}, nil
+		},
+	}
+	server := NewServer([]Tool{echo})
+
+	r := gin.New()
+	r.POST("/mcp", HandleRPC(server))
+	r.GET("/tools", HandleListTools(server))
+	r.POST("/tools/:name", HandleCallTool(server))
+	return r
+}
+
+func post(r *gin.Engine, path, body string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestHandleRPC(t *testing.T) {
+	r := testServer()
+
+	t.Run("Initialize", func(t *testing.T) {
+		w := post(r, "/mcp", `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2024-11-05"}}`)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var resp struct {
+			Result struct {
+				ProtocolVersion string `json:"protocolVersion"`
+				ServerInfo      struct {
+					Name string `json:"name"`
+				} `json:"serverInfo"`
+			} `json:"result"`
+		}
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, "2024-11-05", resp.Result.ProtocolVersion)
+		assert.Equal(t, "gofinance", resp.Result.ServerInfo.Name)
+
+		w = post(r, "/mcp", `{"jsonrpc":"2.0","id":2,"method":"initialize","params":{"protocolVersion":"2099-01-01"}}`)
+		assert.Contains(t, w.Body.String(), `"protocolVersion":"`+protocolVersions[0]+`"`)
+	})
+
+	t.Run("Notification", func(t *testing.T) {
+		w := post(r, "/mcp", `{"jsonrpc":"2.0","method":"notifications/initialized"}`)
+		assert.Equal(t, http.StatusAccepted, w.Code)
+		assert.Empty(t, w.Body.String())
+	})
+
+	t.Run("List tools", func(t *testing.T) {
+		w := post(r, "/mcp", `{"jsonrpc":"2.0","id":"a","method":"tools/list"}`)
+		assert.Contains(t, w.Body.String(), `"id":"a"`)
+		assert.Contains(t, w.Body.String(), `"name":"echo"`)
+		assert.Contains(t, w.Body.String(), `"inputSchema":{`)
+	})
+
+	t.Run("Call tool", func(t *testing.T) {
+		w := post(r, "/mcp", `{"jsonrpc":"2.0","id":3,"method":"tools/call","params":{"name":"echo","arguments":{"text":"hi"}}}`)
+		assert.JSONEq(t, `{"jsonrpc":"2.0","id":3,"result":{"content":[{"type":"text","text":"{\"text\":\"hi\"}"}]}}`, w.Body.String())
+
+		w = post(r, "/mcp", `{"jsonrpc":"2.0","id":4,"method":"tools/call","params":{"name":"echo","arguments":{}}}`)
+		assert.JSONEq(t, `{"jsonrpc":"2.0","id":4,"result":{"content":[{"type":"text","text":"text is required"}],"isError":true}}`, w.Body.String())
+
+		w = post(r, "/mcp", `{"jsonrpc":"2.0","id":5,"method":"tools/call","params":{"name":"missing"}}`)
+		assert.Contains(t, w.Body.String(), fmt.Sprintf(`"code":%d`, codeInvalidParams))
+	})
+
+	t.Run("Unknown method", func(t *testing.T) {
+		w := post(r, "/mcp", `{"jsonrpc":"2.0","id":6,"method":"resources/list"}`)
+		assert.Contains(t, w.Body.String(), fmt.Sprintf(`"code":%d`, codeMethodNotFound))
+	})
+
+	t.Run("Parse error", func(t *testing.T) {
+		w := post(r, "/mcp", `{"jsonrpc":`)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), fmt.Sprintf(`"code":%d`, codeParseError))
+	})
+}
+
+func TestHandleCallTool(t *testing.T) {
+	r := testServer()
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/tools", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"name":"echo"`)
+
+	w = post(r, "/tools/echo", `{"text":"hi"}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"status":"success","data":{"text":"hi"}}`, w.Body.String())
+
+	assert.Equal(t, http.StatusBadRequest, post(r, "/tools/echo", ``).Code)
+	assert.Equal(t, http.StatusBadRequest, post(r, "/tools/echo", `{"text":`).Code)
+	assert.Equal(t, http.StatusInternalServerError, post(r, "/tools/echo", `{"text":"fail"}`).Code)
+	assert.Equal(t, http.StatusNotFound, post(r, "/tools/missing", `{}`).Code)
+}
+
+func TestMarketTools(t *testing.T) {
+	tools := MarketTools(nil)
+	names := make([]string, len(tools))
+	for i, tool := range tools {
+		names[i] = tool.Name
+		assert.Equal(t, "object", tool.InputSchema["type"])
+	}
+	assert.Equal(t, []string{"get_quotes", "get_market_movers", "get_sectors", "get_news"}, names)
+
+	// Bad arguments are rejected before anything is scraped
+	_, err := tools[0].run(context.Background(), json.RawMessage(`{"symbols":["AAPL","BAD SYMBOL"]}`))
+	assert.IsType(t, argumentError{}, err)
+	_, err = tools[1].run(context.Background(), json.RawMessage(`{"category":"52_week_highs"}`))
+	assert.IsType(t, argumentError{}, err)
+	_, err = tools[2].run(context.Background(), json.RawMessage(`{"sector":"crypto"}`))
+	assert.IsType(t, argumentError{}, err)
+	_, err = tools[3].run(context.Background(), json.RawMessage(`{"limit":500}`))
+	assert.IsType(t, argumentError{}, err)
+}
+
+func TestFilterArticles(t *testing.T) {
+	articles := []scraper.Article{
+		{Title: "Fed holds rates steady"},
+		{Title: "Apple unveils new iPhone", Snippet: "Shares of AAPL rose"},
+		{Title: "Oil slides", Snippet: "Rates weigh on crude"},
+	}
+
+	assert.Len(t, filterArticles(articles, "", 2), 2)
+	matched := filterArticles(articles, "RATES", 10)
+	assert.Len(t, matched, 2)
+	assert.Equal(t, "Oil slides", matched[1].Title)
+}
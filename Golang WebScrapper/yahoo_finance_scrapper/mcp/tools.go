@@ -0,0 +1,279 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"strings"
+	"time"
+
+	"go-webscraper/queue"
+	"go-webscraper/scraper"
+)
+
+const (
+	maxToolSymbols  = 20
+	defaultArticles = 10
+	maxArticles     = 50
+	// newsWindow is how far back get_news reaches in the general feed.
+	newsWindow = 24 * time.Hour
+)
+
+func object(properties map[string]interface{}, required ...string) map[string]interface{} {
+	schema := map[string]interface{}{
+		"type":                 "object",
+		"properties":           properties,
+		"additionalProperties": false,
+	}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+func regionProperty() map[string]interface{} {
+	regions := make([]string, 0, len(scraper.Regions))
+	for code := range scraper.Regions {
+		regions = append(regions, code)
+	}
+	sort.Strings(regions)
+	return map[string]interface{}{
+		"type":        "string",
+		"enum":        regions,
+		"description": "Yahoo Finance region, default " + scraper.DefaultRegion,
+	}
+}
+
+// MarketTools are the quote, movers, sector and news tools, scraping
+// through pool like the matching API endpoints.
+func MarketTools(pool *queue.Pool) []Tool {
+	sectors := make([]string, 0, len(scraper.SectorURLs))
+	for name := range scraper.SectorURLs {
+		sectors = append(sectors, name)
+	}
+	sort.Strings(sectors)
+
+	return []Tool{
+		{
+			Name:        "get_quotes",
+			Description: "Latest price, change, volume and market cap for up to 20 ticker symbols.",
+			InputSchema: object(map[string]interface{}{
+				"symbols": map[string]interface{}{
+					"type":     "array",
+					"items":    map[string]interface{}{"type": "string"},
+					"minItems": 1,
+					"maxItems": maxToolSymbols,
+				},
+				"region": regionProperty(),
+			}, "symbols"),
+			Call: getQuotes(pool),
+		},
+		{
+			Name:        "get_market_movers",
+			Description: "Today's most active, top gaining, top losing or trending stocks.",
+			InputSchema: object(map[string]interface{}{
+				"category": map[string]interface{}{
+					"type": "string",
+					"enum": []string{"most_active", "gainers", "losers", "trending"},
+				},
+				"limit":  map[string]interface{}{"type": "integer", "minimum": 1, "maximum": 100},
+				"region": regionProperty(),
+			}, "category"),
+			Call: getMovers(pool),
+		},
+		{
+			Name:        "get_sectors",
+			Description: "Sector performance over the day, month, quarter and year with top stocks; one sector or all of them.",
+			InputSchema: object(map[string]interface{}{
+				"sector": map[string]interface{}{"type": "string", "enum": sectors},
+				"region": regionProperty(),
+			}),
+			Call: getSectors(pool),
+		},
+		{
+			Name:        "get_news",
+			Description: "Recent financial news headlines with links, for one symbol or from the general feed, optionally filtered by a search term.",
+			InputSchema: object(map[string]interface{}{
+				"symbol": map[string]interface{}{"type": "string"},
+				"query":  map[string]interface{}{"type": "string", "description": "Case-insensitive text the title or snippet must contain"},
+				"limit":  map[string]interface{}{"type": "integer", "minimum": 1, "maximum": maxArticles},
+				"region": regionProperty(),
+			}),
+			Call: getNews(pool),
+		},
+	}
+}
+
+func lookupRegion(code string) (scraper.Region, error) {
+	region, err := scraper.LookupRegion(code)
+	if err != nil {
+		return region, invalidArgs("%v", err)
+	}
+	return region, nil
+}
+
+func getQuotes(pool *queue.Pool) ToolFunc {
+	return func(ctx context.Context, raw json.RawMessage) (interface{}, error) {
+		var args struct {
+			Symbols []string `json:"symbols"`
+			Region  string   `json:"region"`
+		}
+		if err := decodeArgs(raw, &args); err != nil {
+			return nil, err
+		}
+		if len(args.Symbols) == 0 || len(args.Symbols) > maxToolSymbols {
+			return nil, invalidArgs("symbols must list 1 to %d tickers", maxToolSymbols)
+		}
+		symbols := make([]string, len(args.Symbols))
+		for i, symbol := range args.Symbols {
+			symbols[i] = strings.ToUpper(strings.TrimSpace(symbol))
+			if !scraper.ValidSymbol(symbols[i]) {
+				return nil, invalidArgs("invalid symbol: %s", symbol)
+			}
+		}
+		region, err := lookupRegion(args.Region)
+		if err != nil {
+			return nil, err
+		}
+
+		stockScraper := scraper.NewStockScraper(scraper.StockScraperOption{
+			Region:  region.Code,
+			Context: ctx,
+			Pool:    pool,
+		})
+		defer stockScraper.Close()
+
+		return stockScraper.CachedQuotes(symbols)
+	}
+}
+
+func getMovers(pool *queue.Pool) ToolFunc {
+	return func(ctx context.Context, raw json.RawMessage) (interface{}, error) {
+		var args struct {
+			Category string `json:"category"`
+			Limit    int    `json:"limit"`
+			Region   string `json:"region"`
+		}
+		if err := decodeArgs(raw, &args); err != nil {
+			return nil, err
+		}
+		if !scraper.IsMoverCategory(args.Category) {
+			return nil, invalidArgs("category must be one of: most_active, gainers, losers, trending")
+		}
+		region, err := lookupRegion(args.Region)
+		if err != nil {
+			return nil, err
+		}
+
+		stockScraper := scraper.NewStockScraper(scraper.StockScraperOption{
+			Region:  region.Code,
+			Context: ctx,
+			Pool:    pool,
+		})
+		defer stockScraper.Close()
+
+		stocks, err := stockScraper.ScrapeMovers(args.Category)
+		if err != nil {
+			return nil, err
+		}
+		if args.Limit > 0 && len(stocks) > args.Limit {
+			stocks = stocks[:args.Limit]
+		}
+		return stocks, nil
+	}
+}
+
+func getSectors(pool *queue.Pool) ToolFunc {
+	return func(ctx context.Context, raw json.RawMessage) (interface{}, error) {
+		var args struct {
+			Sector string `json:"sector"`
+			Region string `json:"region"`
+		}
+		if err := decodeArgs(raw, &args); err != nil {
+			return nil, err
+		}
+		sector := strings.ToLower(strings.TrimSpace(args.Sector))
+		if _, ok := scraper.SectorURLs[sector]; sector != "" && !ok {
+			return nil, invalidArgs("unknown sector: %s", args.Sector)
+		}
+		region, err := lookupRegion(args.Region)
+		if err != nil {
+			return nil, err
+		}
+
+		sectorScraper := scraper.NewSectorScraper(scraper.ScraperOption{
+			Region:  region.Code,
+			Context: ctx,
+			Pool:    pool,
+		})
+		defer sectorScraper.Close()
+
+		if sector != "" {
+			return sectorScraper.ScrapeSector(sector)
+		}
+		return sectorScraper.ScrapeAllSectors()
+	}
+}
+
+func getNews(pool *queue.Pool) ToolFunc {
+	return func(ctx context.Context, raw json.RawMessage) (interface{}, error) {
+		var args struct {
+			Symbol string `json:"symbol"`
+			Query  string `json:"query"`
+			Limit  int    `json:"limit"`
+			Region string `json:"region"`
+		}
+		if err := decodeArgs(raw, &args); err != nil {
+			return nil, err
+		}
+		symbol := strings.ToUpper(strings.TrimSpace(args.Symbol))
+		if symbol != "" && !scraper.ValidSymbol(symbol) {
+			return nil, invalidArgs("invalid symbol: %s", args.Symbol)
+		}
+		limit := args.Limit
+		if limit <= 0 {
+			limit = defaultArticles
+		}
+		if limit > maxArticles {
+			return nil, invalidArgs("limit must be at most %d", maxArticles)
+		}
+		region, err := lookupRegion(args.Region)
+		if err != nil {
+			return nil, err
+		}
+
+		newsScraper := scraper.NewScraper(scraper.ScraperOption{
+			Region:  region.Code,
+			Context: ctx,
+			Pool:    pool,
+		})
+		defer newsScraper.Close()
+
+		var articles []scraper.Article
+		if symbol != "" {
+			articles, err = newsScraper.ScrapeSymbolNews(symbol, maxArticles)
+		} else {
+			articles, err = newsScraper.ScrapeNews(time.Now().Add(-newsWindow))
+		}
+		if err != nil {
+			return nil, err
+		}
+		return filterArticles(articles, args.Query, limit), nil
+	}
+}
+
+// filterArticles keeps up to limit articles mentioning query, if set.
+func filterArticles(articles []scraper.Article, query string, limit int) []scraper.Article {
+	query = strings.ToLower(strings.TrimSpace(query))
+	matched := make([]scraper.Article, 0, limit)
+	for _, article := range articles {
+		if len(matched) == limit {
+			break
+		}
+		if query != "" && !strings.Contains(strings.ToLower(article.Title+" "+article.Snippet), query) {
+			continue
+		}
+		matched = append(matched, article)
+	}
+	return matched
+}
@@ -0,0 +1,117 @@
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+var (
+	RateLimitActiveClients = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "gofinance_ratelimit_active_clients",
+		Help: "Clients currently tracked by each rate limiter.",
+	}, []string{"limit_type"})
+
+	RateLimitRejections = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "gofinance_ratelimit_rejections_total",
+		Help: "Requests rejected by each rate limiter.",
+	}, []string{"limit_type"})
+
+	AutomaticBans = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "gofinance_automatic_bans_total",
+		Help: "Clients banned for repeated 429s or requests for unknown endpoints.",
+	})
+
+	ScrapePoolRunning = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "gofinance_scrape_pool_running",
+		Help: "Scrapes currently holding a worker slot.",
+	})
+
+	ScrapePoolQueued = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "gofinance_scrape_pool_queued",
+		Help: "Scrapes waiting for a worker slot, by priority class.",
+	}, []string{"class"})
+
+	ScrapePoolShed = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "gofinance_scrape_pool_shed_total",
+		Help: "Scrapes rejected because their class's queue was full.",
+	}, []string{"class"})
+
+	InflightScrapes = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "gofinance_inflight_scrape_requests",
+		Help: "API requests that have started a scrape and not yet been answered.",
+	})
+
+	InflightShed = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "gofinance_inflight_shed_total",
+		Help: "API requests refused a scrape, or served stale, because too many were already scraping.",
+	})
+
+	UpstreamSmoothingWait = promauto.NewHistogram(prometheus.HistogramOpts{
+		Name:    "gofinance_upstream_smoothing_wait_seconds",
+		Help:    "Time outbound Yahoo requests waited for a slot from the request smoother.",
+		Buckets: []float64{0, 0.1, 0.5, 1, 2.5, 5, 10, 30, 60},
+	})
+
+	CoalescedQuotes = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "gofinance_coalesced_quotes_total",
+		Help: "Quote requests answered by a scrape of the same symbol already made within the coalescing window.",
+	})
+
+	UpstreamAborts = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "gofinance_upstream_aborts_total",
+		Help: "Yahoo page fetches aborted by the upstream guards, by reason (size or deadline).",
+	}, []string{"reason"})
+
+	UpstreamResponses = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "gofinance_upstream_responses_total",
+		Help: "Responses from upstream domains by status code, or \"error\" when the request failed.",
+	}, []string{"domain", "status"})
+
+	UpstreamLatency = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "gofinance_upstream_latency_seconds",
+		Help:    "Time upstream domains took to answer, excluding smoothing waits.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"domain"})
+
+	UpstreamBlockStreak = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "gofinance_upstream_block_streak",
+		Help: "Consecutive 429 or 403 responses from each upstream domain.",
+	}, []string{"domain"})
+
+	ParseFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "gofinance_parse_failures_total",
+		Help: "Scraped values that couldn't be parsed as numbers, by field.",
+	}, []string{"field"})
+
+	SelectorMatches = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "gofinance_selector_matches_total",
+		Help: "Field extractions by the rank of the candidate selector that matched (0 is the primary, miss when none did).",
+	}, []string{"field", "rank"})
+
+	OutputDirBytes = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "gofinance_output_dir_bytes",
+		Help: "Bytes of files in each output or export directory as of the last janitor sweep.",
+	}, []string{"dir"})
+
+	OutputDirFiles = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "gofinance_output_dir_files",
+		Help: "Files in each output or export directory as of the last janitor sweep.",
+	}, []string{"dir"})
+
+	OutputFilesRemoved = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "gofinance_output_files_removed_total",
+		Help: "Files the janitor removed, by directory and reason (age or size).",
+	}, []string{"dir", "reason"})
+
+	AnomaliesQuarantined = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "gofinance_anomalies_quarantined_total",
+		Help: "Scraped rows flagged suspect, by source and the anomaly rule they broke.",
+	}, []string{"source", "rule"})
+)
+
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
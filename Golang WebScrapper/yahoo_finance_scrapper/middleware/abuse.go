@@ -0,0 +1,120 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"go-webscraper/metrics"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AbuseConfig sets when AbuseGuard bans a client: after MaxRateLimited
+// 429s, or MaxNotFound requests for routes that don't exist, within one
+// Window. A zero threshold disables that check.
+type AbuseConfig struct {
+	Window         time.Duration
+	MaxRateLimited int
+	MaxNotFound    int
+	BanFor         time.Duration
+}
+
+// strikes is one client's offences in the current window.
+type strikes struct {
+	since       time.Time
+	rateLimited int
+	notFound    int
+}
+
+type abuseDetector struct {
+	config  AbuseConfig
+	mu      sync.Mutex
+	clients map[string]*strikes
+}
+
+// strike records an offence by client and reports the reason to ban it,
+// if this one crossed a threshold.
+func (d *abuseDetector) strike(client string, status int, now time.Time) string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	s, exists := d.clients[client]
+	if !exists || now.Sub(s.since) > d.config.Window {
+		// Forget clients whose window has passed so the map stays small
+		for key, other := range d.clients {
+			if now.Sub(other.since) > d.config.Window {
+				delete(d.clients, key)
+			}
+		}
+		s = &strikes{since: now}
+		d.clients[client] = s
+	}
+
+	reason := ""
+	switch status {
+	case http.StatusTooManyRequests:
+		s.rateLimited++
+		if d.config.MaxRateLimited > 0 && s.rateLimited >= d.config.MaxRateLimited {
+			reason = fmt.Sprintf("rate limited %d times within %s", s.rateLimited, d.config.Window)
+		}
+	case http.StatusNotFound:
+		s.notFound++
+		if d.config.MaxNotFound > 0 && s.notFound >= d.config.MaxNotFound {
+			reason = fmt.Sprintf("requested %d unknown endpoints within %s", s.notFound, d.config.Window)
+		}
+	}
+	if reason != "" {
+		delete(d.clients, client)
+	}
+	return reason
+}
+
+// AbuseGuard turns banned clients away from every route, and temporarily
+// bans clients that keep hitting rate limits or probing for endpoints that
+// don't exist. Offences always count against the client's IP, and also
+// against its API key when it sends one: keys aren't validated here, so a
+// client rotating random keys still gets its IP banned.
+func AbuseGuard(config AbuseConfig) gin.HandlerFunc {
+	if config.Window == 0 {
+		config.Window = 10 * time.Minute
+	}
+	if config.BanFor == 0 {
+		config.BanFor = time.Hour
+	}
+	detector := &abuseDetector{config: config, clients: make(map[string]*strikes)}
+
+	return func(c *gin.Context) {
+		apiKey := APIKey(c)
+		if until, banned := isBanned(apiKey, c.ClientIP()); banned {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error":        "client banned",
+				"banned_until": until.Format(time.RFC3339),
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+
+		status := c.Writer.Status()
+		// Only 404s for unknown routes count; a handler's 404 for a missing
+		// symbol or list is an ordinary answer
+		if status != http.StatusTooManyRequests && (status != http.StatusNotFound || c.FullPath() != "") {
+			return
+		}
+
+		clients := []string{c.ClientIP()}
+		if apiKey != "" {
+			clients = append(clients, apiKey)
+		}
+		now := time.Now()
+		for _, client := range clients {
+			if reason := detector.strike(client, status, now); reason != "" {
+				ban(BanEntry{Key: client, BannedUntil: now.Add(config.BanFor), Reason: reason, Automatic: true})
+				metrics.AutomaticBans.Inc()
+			}
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAbuseGuard(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(AbuseGuard(AbuseConfig{Window: time.Minute, MaxRateLimited: 3, MaxNotFound: 2, BanFor: time.Hour}))
+	r.GET("/limited", func(c *gin.Context) { c.Status(http.StatusTooManyRequests) })
+	r.GET("/symbol", func(c *gin.Context) { c.Status(http.StatusNotFound) })
+	r.GET("/ok", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	serve := func(target, ip, apiKey string) int {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		req.RemoteAddr = ip + ":1234"
+		if apiKey != "" {
+			req.Header.Set("X-API-Key", apiKey)
+		}
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+	t.Cleanup(func() {
+		Unban("198.51.100.1")
+		Unban("203.0.113.9")
+		Unban("192.0.2.7")
+		Unban("abuser-key")
+	})
+
+	t.Run("Probing Unknown Endpoints", func(t *testing.T) {
+		// A handler's own 404 isn't probing
+		for i := 0; i < 3; i++ {
+			assert.Equal(t, http.StatusNotFound, serve("/symbol", "198.51.100.1", ""))
+		}
+		assert.Equal(t, http.StatusOK, serve("/ok", "198.51.100.1", ""))
+
+		assert.Equal(t, http.StatusNotFound, serve("/wp-admin", "198.51.100.1", ""))
+		assert.Equal(t, http.StatusNotFound, serve("/.env", "198.51.100.1", ""))
+		assert.Equal(t, http.StatusForbidden, serve("/ok", "198.51.100.1", ""))
+		assert.Equal(t, http.StatusOK, serve("/ok", "198.51.100.2", ""))
+	})
+
+	t.Run("Repeated Rate Limits", func(t *testing.T) {
+		for i := 0; i < 3; i++ {
+			assert.Equal(t, http.StatusTooManyRequests, serve("/limited", "203.0.113.9", "abuser-key"))
+		}
+		assert.Equal(t, http.StatusForbidden, serve("/ok", "203.0.113.10", "abuser-key"))
+		assert.Equal(t, http.StatusForbidden, serve("/ok", "203.0.113.9", ""))
+
+		var found bool
+		for _, entry := range Bans() {
+			if entry.Key == "abuser-key" {
+				found = true
+				assert.True(t, entry.Automatic)
+				assert.Contains(t, entry.Reason, "rate limited 3 times")
+			}
+		}
+		assert.True(t, found)
+	})
+
+	t.Run("Rotating API Keys", func(t *testing.T) {
+		for i := 0; i < 3; i++ {
+			assert.Equal(t, http.StatusTooManyRequests, serve("/limited", "192.0.2.7", fmt.Sprintf("random-%d", i)))
+		}
+		assert.Equal(t, http.StatusForbidden, serve("/ok", "192.0.2.7", "random-fresh"))
+		assert.Equal(t, http.StatusOK, serve("/ok", "192.0.2.8", "random-0"), "no single key crossed the threshold")
+	})
+}
+
+func TestAbuseWindow(t *testing.T) {
+	d := &abuseDetector{config: AbuseConfig{Window: time.Minute, MaxNotFound: 2}, clients: make(map[string]*strikes)}
+	start := time.Now()
+	assert.Empty(t, d.strike("client", http.StatusNotFound, start))
+	assert.Empty(t, d.strike("client", http.StatusNotFound, start.Add(2*time.Minute)), "strikes from a past window are forgotten")
+	assert.NotEmpty(t, d.strike("client", http.StatusNotFound, start.Add(150*time.Second)))
+}
+
+func TestPersistBans(t *testing.T) {
+	m := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: m.Addr()})
+	defer rdb.Close()
+
+	stop, err := PersistBans(rdb, time.Hour)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		bansMu.Lock()
+		bansRedis = nil
+		bansMu.Unlock()
+	})
+	defer stop()
+
+	Ban("persisted-key", time.Hour, "scraping aggressively")
+	assert.True(t, m.Exists(bansKey))
+
+	// Another instance, or a restart, sees the ban
+	bansMu.Lock()
+	bans = make(map[string]BanEntry)
+	bansMu.Unlock()
+	require.NoError(t, loadBans(context.Background(), rdb))
+	_, banned := isBanned("persisted-key")
+	assert.True(t, banned)
+
+	assert.True(t, Unban("persisted-key"))
+	require.NoError(t, loadBans(context.Background(), rdb))
+	_, banned = isBanned("persisted-key")
+	assert.False(t, banned)
+
+	ban(BanEntry{Key: "expired", BannedUntil: time.Now().Add(-time.Minute)})
+	require.NoError(t, loadBans(context.Background(), rdb))
+	assert.Empty(t, m.HGet(bansKey, "expired"))
+}
@@ -0,0 +1,180 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"go-webscraper/metrics"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ClientSnapshot struct {
+	LimitType     string    `json:"limit_type"`
+	Key           string    `json:"key"`
+	TotalRequests int64     `json:"total_requests"`
+	Tokens        float64   `json:"tokens"`
+	LastSeen      time.Time `json:"last_seen"`
+}
+
+func ClientSnapshots(limitType string) []ClientSnapshot {
+	limitersMu.RLock()
+	defer limitersMu.RUnlock()
+
+	snapshots := make([]ClientSnapshot, 0)
+	now := time.Now()
+	for _, rl := range limiters {
+		if limitType != "" && rl.config.LimitType != limitType {
+			continue
+		}
+
+		rl.mu.RLock()
+		for key, client := range rl.clients {
+			snapshots = append(snapshots, ClientSnapshot{
+				LimitType:     rl.config.LimitType,
+				Key:           key,
+				TotalRequests: atomic.LoadInt64(&client.totalRequest),
+				Tokens:        client.limiter.TokensAt(now),
+				LastSeen:      client.lastSeen,
+			})
+		}
+		rl.mu.RUnlock()
+	}
+
+	sort.Slice(snapshots, func(i, j int) bool {
+		return snapshots[i].TotalRequests > snapshots[j].TotalRequests
+	})
+	return snapshots
+}
+
+// ResetClient forgets key in every limiter (or only those of limitType),
+// giving the client a fresh bucket on its next request.
+func ResetClient(limitType, key string) int {
+	limitersMu.RLock()
+	defer limitersMu.RUnlock()
+
+	reset := 0
+	for _, rl := range limiters {
+		if limitType != "" && rl.config.LimitType != limitType {
+			continue
+		}
+
+		rl.mu.Lock()
+		if _, exists := rl.clients[key]; exists {
+			delete(rl.clients, key)
+			metrics.RateLimitActiveClients.WithLabelValues(rl.config.LimitType).Dec()
+			reset++
+		}
+		rl.mu.Unlock()
+	}
+	return reset
+}
+
+// AdminAuth guards admin routes with a shared token sent as X-Admin-Token.
+// With no token configured the admin API is disabled entirely.
+func AdminAuth(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if token == "" {
+			c.JSON(http.StatusServiceUnavailable, gin.H{
+				"error": "admin API is disabled",
+			})
+			c.Abort()
+			return
+		}
+
+		provided := c.GetHeader("X-Admin-Token")
+		if subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error": "invalid admin token",
+			})
+			c.Abort()
+			return
+		}
+
+		c.Set("actor", "admin")
+		c.Next()
+	}
+}
+
+func HandleListClients(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   ClientSnapshots(c.Query("limit_type")),
+	})
+}
+
+type ClientActionRequest struct {
+	Key       string `json:"key" binding:"required"`
+	LimitType string `json:"limit_type"`
+	Duration  string `json:"duration"`
+	Reason    string `json:"reason"`
+}
+
+func HandleResetClient(c *gin.Context) {
+	var req ClientActionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.Set("audit_target", req.Key)
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"reset":  ResetClient(req.LimitType, req.Key),
+	})
+}
+
+func HandleBanClient(c *gin.Context) {
+	var req ClientActionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	duration := 24 * time.Hour
+	if req.Duration != "" {
+		d, err := time.ParseDuration(req.Duration)
+		if err != nil || d <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "duration must be a positive Go duration, e.g. 30m",
+			})
+			return
+		}
+		duration = d
+	}
+
+	until := Ban(req.Key, duration, req.Reason)
+	c.Set("audit_target", req.Key)
+	c.JSON(http.StatusOK, gin.H{
+		"status":       "success",
+		"key":          req.Key,
+		"banned_until": until.Format(time.RFC3339),
+	})
+}
+
+func HandleListBans(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   Bans(),
+	})
+}
+
+func HandleUnbanClient(c *gin.Context) {
+	if !Unban(c.Param("key")) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "ban not found",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+	})
+}
@@ -0,0 +1,184 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"go-webscraper/metrics"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientSnapshots(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/quotes", RateLimit(RateLimiterConfig{RPS: 1, Burst: 5, LimitType: "admin-quotes"}), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	r.GET("/sectors", RateLimit(RateLimiterConfig{RPS: 1, Burst: 5, LimitType: "admin-sectors"}), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	serve := func(target, ip string) {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		req.RemoteAddr = ip + ":1234"
+		r.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	for i := 0; i < 3; i++ {
+		serve("/quotes", "198.51.100.20")
+	}
+	serve("/quotes", "198.51.100.21")
+	serve("/sectors", "198.51.100.20")
+
+	snapshots := ClientSnapshots("admin-quotes")
+	require.Len(t, snapshots, 2)
+	assert.Equal(t, "198.51.100.20", snapshots[0].Key, "busiest client first")
+	assert.Equal(t, int64(3), snapshots[0].TotalRequests)
+	assert.InDelta(t, 2, snapshots[0].Tokens, 0.1)
+	assert.WithinDuration(t, time.Now(), snapshots[0].LastSeen, time.Second)
+	assert.Equal(t, "198.51.100.21", snapshots[1].Key)
+	assert.Equal(t, int64(1), snapshots[1].TotalRequests)
+
+	keys := make(map[string]int)
+	for _, snapshot := range ClientSnapshots("") {
+		if strings.HasPrefix(snapshot.LimitType, "admin-") {
+			keys[snapshot.Key]++
+		}
+	}
+	assert.Equal(t, map[string]int{"198.51.100.20": 2, "198.51.100.21": 1}, keys)
+
+	activeClients := func(limitType string) float64 {
+		return testutil.ToFloat64(metrics.RateLimitActiveClients.WithLabelValues(limitType))
+	}
+
+	t.Run("Reset One Limit Type", func(t *testing.T) {
+		before := activeClients("admin-quotes")
+		assert.Equal(t, 1, ResetClient("admin-quotes", "198.51.100.20"))
+		require.Len(t, ClientSnapshots("admin-quotes"), 1)
+		assert.Len(t, ClientSnapshots("admin-sectors"), 1)
+		assert.Equal(t, before-1, activeClients("admin-quotes"))
+		assert.Zero(t, ResetClient("admin-quotes", "198.51.100.20"))
+		assert.Equal(t, before-1, activeClients("admin-quotes"), "nothing left to reset")
+	})
+
+	t.Run("Reset Every Limiter", func(t *testing.T) {
+		serve("/quotes", "198.51.100.20")
+		before := activeClients("admin-sectors")
+		assert.Equal(t, 2, ResetClient("", "198.51.100.20"))
+		assert.Empty(t, ClientSnapshots("admin-sectors"))
+		assert.Equal(t, before-1, activeClients("admin-sectors"))
+
+		// The next request starts from a full bucket
+		serve("/quotes", "198.51.100.21")
+		for _, snapshot := range ClientSnapshots("admin-quotes") {
+			assert.NotEqual(t, "198.51.100.20", snapshot.Key)
+		}
+	})
+}
+
+func TestAdminHandlers(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	admin := r.Group("/api/admin", AdminAuth("secret"))
+	admin.GET("/clients", HandleListClients)
+	admin.POST("/clients/reset", HandleResetClient)
+	admin.GET("/bans", HandleListBans)
+	admin.POST("/bans", HandleBanClient)
+	admin.DELETE("/bans/:key", HandleUnbanClient)
+	r.GET("/quotes", RateLimit(RateLimiterConfig{RPS: 1, Burst: 5, LimitType: "admin-handlers"}), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	serve := func(method, target, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req.RemoteAddr = "198.51.100.30:1234"
+		req.Header.Set("Content-Type", "application/json")
+		if strings.HasPrefix(target, "/api/admin") {
+			req.Header.Set("X-Admin-Token", "secret")
+		}
+		r.ServeHTTP(w, req)
+		return w
+	}
+	t.Cleanup(func() {
+		Unban("198.51.100.30")
+		Unban("banned-key")
+	})
+
+	t.Run("Requires Token", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/admin/bans", nil))
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("Reset", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, serve(http.MethodGet, "/quotes", "").Code)
+
+		w := serve(http.MethodPost, "/api/admin/clients/reset", `{"key":"198.51.100.30","limit_type":"admin-handlers"}`)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"status":"success","reset":1}`, w.Body.String())
+		assert.Empty(t, ClientSnapshots("admin-handlers"))
+
+		assert.Equal(t, http.StatusBadRequest, serve(http.MethodPost, "/api/admin/clients/reset", `{}`).Code)
+	})
+
+	t.Run("Ban", func(t *testing.T) {
+		w := serve(http.MethodPost, "/api/admin/bans", `{"key":"198.51.100.30","duration":"30m","reason":"scraping"}`)
+		require.Equal(t, http.StatusOK, w.Code)
+		var body struct {
+			Key         string    `json:"key"`
+			BannedUntil time.Time `json:"banned_until"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		assert.Equal(t, "198.51.100.30", body.Key)
+		assert.WithinDuration(t, time.Now().Add(30*time.Minute), body.BannedUntil, 2*time.Second)
+
+		assert.Equal(t, http.StatusForbidden, serve(http.MethodGet, "/quotes", "").Code)
+
+		w = serve(http.MethodGet, "/api/admin/bans", "")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"reason":"scraping"`)
+	})
+
+	t.Run("Default Duration", func(t *testing.T) {
+		w := serve(http.MethodPost, "/api/admin/bans", `{"key":"banned-key"}`)
+		require.Equal(t, http.StatusOK, w.Code)
+		until, banned := isBanned("banned-key")
+		assert.True(t, banned)
+		assert.WithinDuration(t, time.Now().Add(24*time.Hour), until, 2*time.Second)
+	})
+
+	t.Run("Invalid Duration", func(t *testing.T) {
+		for _, duration := range []string{"soon", "-5m", "0s"} {
+			w := serve(http.MethodPost, "/api/admin/bans", `{"key":"other-key","duration":"`+duration+`"}`)
+			assert.Equal(t, http.StatusBadRequest, w.Code, duration)
+		}
+		_, banned := isBanned("other-key")
+		assert.False(t, banned)
+	})
+
+	t.Run("Unban", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, serve(http.MethodDelete, "/api/admin/bans/198.51.100.30", "").Code)
+		assert.Equal(t, http.StatusOK, serve(http.MethodGet, "/quotes", "").Code)
+		assert.Equal(t, http.StatusNotFound, serve(http.MethodDelete, "/api/admin/bans/198.51.100.30", "").Code)
+	})
+}
+
+func TestAdminAuthDisabled(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/api/admin/bans", AdminAuth(""), HandleListBans)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/api/admin/bans", nil)
+	req.Header.Set("X-Admin-Token", "")
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+}
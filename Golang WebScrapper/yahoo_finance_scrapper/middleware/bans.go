@@ -0,0 +1,171 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// bansKey is a hash of banned client key to its JSON BanEntry.
+const bansKey = "ratelimit:bans"
+
+// BanEntry is one banned client: an IP, an API key or a limiter key.
+// Automatic bans were placed by AbuseGuard rather than an admin.
+type BanEntry struct {
+	Key         string    `json:"key"`
+	BannedUntil time.Time `json:"banned_until"`
+	Reason      string    `json:"reason,omitempty"`
+	Automatic   bool      `json:"automatic"`
+}
+
+var (
+	bansMu    sync.RWMutex
+	bans      = make(map[string]BanEntry)
+	bansRedis *redis.Client
+)
+
+// Ban bans key for duration, persisting the ban when PersistBans is in
+// effect.
+func Ban(key string, duration time.Duration, reason string) time.Time {
+	return ban(BanEntry{Key: key, BannedUntil: time.Now().Add(duration), Reason: reason})
+}
+
+func ban(entry BanEntry) time.Time {
+	bansMu.Lock()
+	bans[entry.Key] = entry
+	rdb := bansRedis
+	bansMu.Unlock()
+
+	if rdb != nil {
+		if data, err := json.Marshal(entry); err == nil {
+			if err := rdb.HSet(context.Background(), bansKey, entry.Key, data).Err(); err != nil {
+				log.Printf("Error persisting ban of %s: %v", entry.Key, err)
+			}
+		}
+	}
+	return entry.BannedUntil
+}
+
+func Unban(key string) bool {
+	bansMu.Lock()
+	_, exists := bans[key]
+	delete(bans, key)
+	rdb := bansRedis
+	bansMu.Unlock()
+
+	if rdb != nil {
+		removed, err := rdb.HDel(context.Background(), bansKey, key).Result()
+		if err != nil {
+			log.Printf("Error removing persisted ban of %s: %v", key, err)
+		}
+		exists = exists || removed > 0
+	}
+	return exists
+}
+
+// Bans lists the active bans, soonest to expire first.
+func Bans() []BanEntry {
+	bansMu.RLock()
+	defer bansMu.RUnlock()
+
+	active := make([]BanEntry, 0, len(bans))
+	for _, entry := range bans {
+		if time.Now().Before(entry.BannedUntil) {
+			active = append(active, entry)
+		}
+	}
+	sort.Slice(active, func(i, j int) bool {
+		return active[i].BannedUntil.Before(active[j].BannedUntil)
+	})
+	return active
+}
+
+// isBanned checks each of the given identities (limiter key, client IP)
+// against the ban list.
+func isBanned(keys ...string) (time.Time, bool) {
+	bansMu.RLock()
+	defer bansMu.RUnlock()
+
+	for _, key := range keys {
+		if key == "" {
+			continue
+		}
+		if entry, exists := bans[key]; exists && time.Now().Before(entry.BannedUntil) {
+			return entry.BannedUntil, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// loadBans replaces the in-memory ban list with the one in rdb, deleting
+// bans that have run out.
+func loadBans(ctx context.Context, rdb *redis.Client) error {
+	values, err := rdb.HGetAll(ctx, bansKey).Result()
+	if err != nil {
+		return err
+	}
+
+	loaded := make(map[string]BanEntry, len(values))
+	expired := make([]string, 0)
+	for key, value := range values {
+		var entry BanEntry
+		if err := json.Unmarshal([]byte(value), &entry); err != nil || !time.Now().Before(entry.BannedUntil) {
+			expired = append(expired, key)
+			continue
+		}
+		loaded[key] = entry
+	}
+	if len(expired) > 0 {
+		rdb.HDel(ctx, bansKey, expired...)
+	}
+
+	bansMu.Lock()
+	bans = loaded
+	bansMu.Unlock()
+	return nil
+}
+
+// PersistBans keeps the ban list in rdb so bans survive restarts: it loads
+// the stored list, writes every later ban and unban through, and reloads
+// every refresh so bans placed by other instances apply here too. The
+// returned func stops the reloads.
+func PersistBans(rdb *redis.Client, refresh time.Duration) (func(), error) {
+	if err := loadBans(context.Background(), rdb); err != nil {
+		return nil, err
+	}
+
+	bansMu.Lock()
+	bansRedis = rdb
+	bansMu.Unlock()
+
+	if refresh <= 0 {
+		refresh = time.Minute
+	}
+	ticker := time.NewTicker(refresh)
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				if err := loadBans(context.Background(), rdb); err != nil {
+					log.Printf("Error reloading bans: %v", err)
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			ticker.Stop()
+			close(done)
+		})
+	}, nil
+}
@@ -0,0 +1,117 @@
+package middleware
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-contrib/cors"
+	"github.com/gin-gonic/gin"
+)
+
+// CORSPolicy is which browser origins may call a set of routes, and how.
+// An origin of "*" allows any; one like "https://*.example.com" allows
+// any matching origin.
+type CORSPolicy struct {
+	AllowOrigins     []string
+	AllowMethods     []string
+	AllowHeaders     []string
+	ExposeHeaders    []string
+	AllowCredentials bool
+	// MaxAge is how long browsers may cache a preflight response.
+	MaxAge time.Duration
+}
+
+// inherit fills the fields p leaves empty from def. AllowCredentials is
+// never inherited, so a route policy must ask for credentials itself.
+func (p CORSPolicy) inherit(def CORSPolicy) CORSPolicy {
+	if len(p.AllowOrigins) == 0 {
+		p.AllowOrigins = def.AllowOrigins
+	}
+	if len(p.AllowMethods) == 0 {
+		p.AllowMethods = def.AllowMethods
+	}
+	if len(p.AllowHeaders) == 0 {
+		p.AllowHeaders = def.AllowHeaders
+	}
+	if len(p.ExposeHeaders) == 0 {
+		p.ExposeHeaders = def.ExposeHeaders
+	}
+	if p.MaxAge == 0 {
+		p.MaxAge = def.MaxAge
+	}
+	return p
+}
+
+func (p CORSPolicy) config() (cors.Config, error) {
+	config := cors.Config{
+		AllowMethods:     p.AllowMethods,
+		AllowHeaders:     p.AllowHeaders,
+		ExposeHeaders:    p.ExposeHeaders,
+		AllowCredentials: p.AllowCredentials,
+		MaxAge:           p.MaxAge,
+	}
+	for _, origin := range p.AllowOrigins {
+		origin = strings.TrimSpace(origin)
+		switch {
+		case origin == "":
+			continue
+		case origin == "*":
+			config.AllowAllOrigins = true
+		case strings.Count(origin, "*") > 1:
+			return config, fmt.Errorf("origin %s may hold only one *", origin)
+		default:
+			config.AllowWildcard = config.AllowWildcard || strings.Contains(origin, "*")
+			config.AllowOrigins = append(config.AllowOrigins, origin)
+		}
+	}
+
+	if config.AllowAllOrigins {
+		// Browsers refuse credentialed responses allowed for any origin
+		if config.AllowCredentials {
+			return config, fmt.Errorf("credentials can't be allowed for every origin; list the origins instead")
+		}
+		config.AllowOrigins = nil
+	}
+	if err := config.Validate(); err != nil {
+		return config, err
+	}
+	return config, nil
+}
+
+// CORS applies def to every request except those under a path prefix in
+// routes, which get that prefix's policy instead; the longest matching
+// prefix wins. Fields a route policy leaves empty come from def. It runs
+// for unmatched routes too, so preflight requests are answered.
+func CORS(def CORSPolicy, routes map[string]CORSPolicy) (gin.HandlerFunc, error) {
+	config, err := def.config()
+	if err != nil {
+		return nil, fmt.Errorf("cors: %v", err)
+	}
+	defaultHandler := cors.New(config)
+
+	prefixes := make([]string, 0, len(routes))
+	handlers := make(map[string]gin.HandlerFunc, len(routes))
+	for prefix, policy := range routes {
+		config, err := policy.inherit(def).config()
+		if err != nil {
+			return nil, fmt.Errorf("cors policy for %s: %v", prefix, err)
+		}
+		prefix = "/" + strings.Trim(prefix, "/")
+		prefixes = append(prefixes, prefix)
+		handlers[prefix] = cors.New(config)
+	}
+	sort.Slice(prefixes, func(i, j int) bool { return len(prefixes[i]) > len(prefixes[j]) })
+
+	return func(c *gin.Context) {
+		path := c.Request.URL.Path
+		for _, prefix := range prefixes {
+			if path == prefix || strings.HasPrefix(path, strings.TrimSuffix(prefix, "/")+"/") {
+				handlers[prefix](c)
+				return
+			}
+		}
+		defaultHandler(c)
+	}, nil
+}
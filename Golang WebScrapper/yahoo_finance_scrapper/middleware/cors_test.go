@@ -0,0 +1,88 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCORS(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	handler, err := CORS(CORSPolicy{
+		AllowOrigins: []string{"https://app.example.com", "https://*.partner.com"},
+		AllowMethods: []string{"GET", "POST"},
+		AllowHeaders: []string{"Content-Type", "X-API-Key"},
+		MaxAge:       time.Hour,
+	}, map[string]CORSPolicy{
+		"/api/sheets": {AllowOrigins: []string{"*"}},
+		"/api/me":     {AllowOrigins: []string{"https://app.example.com"}, AllowCredentials: true},
+	})
+	require.NoError(t, err)
+
+	r := gin.New()
+	r.Use(handler)
+	for _, path := range []string{"/api/stock", "/api/sheets/quotes", "/api/me/usage", "/api/meta"} {
+		r.GET(path, func(c *gin.Context) { c.Status(http.StatusOK) })
+	}
+
+	serve := func(method, path, origin string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set("Origin", origin)
+		if method == http.MethodOptions {
+			req.Header.Set("Access-Control-Request-Method", "GET")
+		}
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := serve(http.MethodGet, "/api/stock", "https://app.example.com")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "https://app.example.com", w.Header().Get("Access-Control-Allow-Origin"))
+	assert.Empty(t, w.Header().Get("Access-Control-Allow-Credentials"))
+
+	w = serve(http.MethodGet, "/api/stock", "https://eu.partner.com")
+	assert.Equal(t, "https://eu.partner.com", w.Header().Get("Access-Control-Allow-Origin"))
+
+	assert.Equal(t, http.StatusForbidden, serve(http.MethodGet, "/api/stock", "https://evil.com").Code)
+
+	// Preflights are answered even though no OPTIONS route exists
+	w = serve(http.MethodOptions, "/api/stock", "https://app.example.com")
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, "3600", w.Header().Get("Access-Control-Max-Age"))
+	assert.Contains(t, w.Header().Get("Access-Control-Allow-Methods"), "GET")
+
+	w = serve(http.MethodGet, "/api/sheets/quotes", "https://evil.com")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "*", w.Header().Get("Access-Control-Allow-Origin"))
+
+	w = serve(http.MethodGet, "/api/me/usage", "https://app.example.com")
+	assert.Equal(t, "true", w.Header().Get("Access-Control-Allow-Credentials"))
+	assert.Equal(t, http.StatusForbidden, serve(http.MethodGet, "/api/me/usage", "https://eu.partner.com").Code)
+
+	// /api/meta isn't under /api/me
+	w = serve(http.MethodGet, "/api/meta", "https://eu.partner.com")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Header().Get("Access-Control-Allow-Credentials"))
+}
+
+func TestCORSRejectsCredentialsForAnyOrigin(t *testing.T) {
+	_, err := CORS(CORSPolicy{AllowOrigins: []string{"*"}, AllowCredentials: true}, nil)
+	assert.Error(t, err)
+
+	_, err = CORS(CORSPolicy{AllowOrigins: []string{"https://app.example.com"}}, map[string]CORSPolicy{
+		"/api/public": {AllowOrigins: []string{"*"}, AllowCredentials: true},
+	})
+	assert.Error(t, err)
+
+	_, err = CORS(CORSPolicy{AllowOrigins: []string{"https://*.*.example.com"}}, nil)
+	assert.Error(t, err)
+
+	_, err = CORS(CORSPolicy{}, nil)
+	assert.Error(t, err, "a policy must allow some origin")
+}
@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"go-webscraper/auth"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Identify verifies the bearer token on the request and stores its subject
+// as "user_id" for per-user handlers, rejecting anonymous requests.
+func Identify(tokens *auth.Tokens) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		header := c.GetHeader("Authorization")
+		token, found := strings.CutPrefix(header, "Bearer ")
+		if !found || token == "" {
+			c.Header("WWW-Authenticate", `Bearer realm="gofinance"`)
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error": "missing bearer token",
+			})
+			c.Abort()
+			return
+		}
+
+		claims, err := tokens.Parse(token)
+		if err != nil {
+			c.Header("WWW-Authenticate", `Bearer realm="gofinance", error="invalid_token"`)
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error": "invalid or expired token",
+			})
+			c.Abort()
+			return
+		}
+
+		c.Set("user_id", claims.Subject)
+		c.Set("username", claims.Username)
+		c.Next()
+	}
+}
@@ -0,0 +1,79 @@
+package middleware
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// parseCIDRs accepts CIDR blocks as well as bare IPs, which are treated as
+// single-address blocks.
+func parseCIDRs(entries []string) ([]*net.IPNet, error) {
+	nets := make([]*net.IPNet, 0, len(entries))
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		if !strings.Contains(entry, "/") {
+			ip := net.ParseIP(entry)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid IP address: %s", entry)
+			}
+			bits := 32
+			if ip.To4() == nil {
+				bits = 128
+			}
+			entry = fmt.Sprintf("%s/%d", entry, bits)
+		}
+
+		_, ipNet, err := net.ParseCIDR(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CIDR %s: %v", entry, err)
+		}
+		nets = append(nets, ipNet)
+	}
+	return nets, nil
+}
+
+func containsIP(nets []*net.IPNet, ip net.IP) bool {
+	for _, n := range nets {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// IPFilter rejects clients outside allow (when non-empty) or inside deny.
+// Deny entries win over allow entries. The client address comes from
+// c.ClientIP, so it honours the engine's trusted proxy settings.
+func IPFilter(allow, deny []string) (gin.HandlerFunc, error) {
+	allowNets, err := parseCIDRs(allow)
+	if err != nil {
+		return nil, err
+	}
+	denyNets, err := parseCIDRs(deny)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(c *gin.Context) {
+		ip := net.ParseIP(c.ClientIP())
+		if ip == nil ||
+			containsIP(denyNets, ip) ||
+			(len(allowNets) > 0 && !containsIP(allowNets, ip)) {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error": "access denied for client address",
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}, nil
+}
@@ -0,0 +1,55 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIPFilter(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	newRouter := func(t *testing.T, allow, deny []string) *gin.Engine {
+		filter, err := IPFilter(allow, deny)
+		require.NoError(t, err)
+
+		r := gin.New()
+		r.Use(filter)
+		r.GET("/admin", func(c *gin.Context) {
+			c.String(http.StatusOK, "ok")
+		})
+		return r
+	}
+
+	request := func(r *gin.Engine, remoteAddr string) int {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/admin", nil)
+		req.RemoteAddr = remoteAddr
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	t.Run("Allowlist", func(t *testing.T) {
+		r := newRouter(t, []string{"10.0.0.0/8", "192.168.1.5"}, nil)
+
+		assert.Equal(t, http.StatusOK, request(r, "10.1.2.3:1234"))
+		assert.Equal(t, http.StatusOK, request(r, "192.168.1.5:1234"))
+		assert.Equal(t, http.StatusForbidden, request(r, "192.168.1.6:1234"))
+	})
+
+	t.Run("Denylist Wins", func(t *testing.T) {
+		r := newRouter(t, []string{"10.0.0.0/8"}, []string{"10.0.0.0/24"})
+
+		assert.Equal(t, http.StatusForbidden, request(r, "10.0.0.7:1234"))
+		assert.Equal(t, http.StatusOK, request(r, "10.0.1.7:1234"))
+	})
+
+	t.Run("Invalid CIDR", func(t *testing.T) {
+		_, err := IPFilter([]string{"10.0.0.0/33"}, nil)
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,80 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"unicode"
+
+	"github.com/gin-gonic/gin"
+)
+
+type QueryLimits struct {
+	MaxLength      int
+	MaxParams      int
+	MaxValueLength int
+}
+
+// BodyLimit rejects requests declaring a body larger than maxBytes and caps
+// the reader for those that don't declare one.
+func BodyLimit(maxBytes int64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if maxBytes <= 0 {
+			c.Next()
+			return
+		}
+
+		if c.Request.ContentLength > maxBytes {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{
+				"status": "error",
+				"error":  fmt.Sprintf("request body exceeds %d bytes", maxBytes),
+			})
+			c.Abort()
+			return
+		}
+
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxBytes)
+		c.Next()
+	}
+}
+
+func validateQuery(c *gin.Context, limits QueryLimits) error {
+	if limits.MaxLength > 0 && len(c.Request.URL.RawQuery) > limits.MaxLength {
+		return fmt.Errorf("query string exceeds %d characters", limits.MaxLength)
+	}
+
+	values := c.Request.URL.Query()
+	count := 0
+	for name, vs := range values {
+		count += len(vs)
+		for _, v := range vs {
+			if limits.MaxValueLength > 0 && len(v) > limits.MaxValueLength {
+				return fmt.Errorf("query parameter %s exceeds %d characters", name, limits.MaxValueLength)
+			}
+			if strings.IndexFunc(name+v, unicode.IsControl) >= 0 {
+				return fmt.Errorf("query parameter %s contains control characters", name)
+			}
+		}
+	}
+	if limits.MaxParams > 0 && count > limits.MaxParams {
+		return fmt.Errorf("too many query parameters (max %d)", limits.MaxParams)
+	}
+	return nil
+}
+
+// SanitizeQuery rejects oversized query strings and parameter values
+// containing control characters before they reach cache keys or scrape URLs.
+func SanitizeQuery(limits QueryLimits) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if err := validateQuery(c, limits); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"status": "error",
+				"error":  err.Error(),
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,82 @@
+package middleware
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Profile struct {
+	RPS            float64
+	Burst          int
+	ExpirationTime time.Duration
+	Key            string
+}
+
+var keyStrategies = map[string]func(*gin.Context) string{
+	"ip":        defaultKeyFunc,
+	"api_key":   apiKeyFunc,
+	"ip_sector": sectorKeyFunc,
+}
+
+var (
+	profilesMu sync.RWMutex
+	profiles   = map[string]Profile{
+		"default":    {RPS: 5, Burst: 10, ExpirationTime: 1 * time.Hour, Key: "ip"},
+		"ip":         {RPS: 5, Burst: 10, ExpirationTime: 1 * time.Hour, Key: "ip"},
+		"api":        {RPS: 10, Burst: 20, ExpirationTime: 1 * time.Hour, Key: "api_key"},
+		"sector_api": {RPS: 2, Burst: 5, ExpirationTime: 1 * time.Hour, Key: "ip_sector"},
+	}
+)
+
+// RegisterProfiles adds or overrides named rate limit profiles. It must be
+// called before routes are built, since RateLimitProfile resolves the
+// profile once when the middleware is created. Nothing is registered if any
+// profile is invalid.
+func RegisterProfiles(defs map[string]Profile) error {
+	valid := make(map[string]Profile, len(defs))
+	for name, profile := range defs {
+		if profile.Key == "" {
+			profile.Key = "ip"
+		}
+		if _, ok := keyStrategies[profile.Key]; !ok {
+			return fmt.Errorf("rate limit profile %s: unknown key strategy %q", name, profile.Key)
+		}
+		// Rate limit headers divide by RPS, so it must be a positive number
+		if !(profile.RPS > 0) || math.IsInf(profile.RPS, 0) || profile.Burst <= 0 {
+			return fmt.Errorf("rate limit profile %s: rps and burst must be greater than zero", name)
+		}
+		valid[name] = profile
+	}
+
+	profilesMu.Lock()
+	defer profilesMu.Unlock()
+	for name, profile := range valid {
+		profiles[name] = profile
+	}
+	return nil
+}
+
+// RateLimitProfile builds a limiter from the named profile, falling back to
+// the "default" profile when the name isn't configured.
+func RateLimitProfile(name string) gin.HandlerFunc {
+	profilesMu.RLock()
+	profile, ok := profiles[name]
+	if !ok {
+		log.Printf("Rate limit profile %s not configured, using default", name)
+		profile = profiles["default"]
+	}
+	profilesMu.RUnlock()
+
+	return RateLimit(RateLimiterConfig{
+		RPS:            profile.RPS,
+		Burst:          profile.Burst,
+		ExpirationTime: profile.ExpirationTime,
+		LimitType:      name,
+		KeyFunc:        keyStrategies[profile.Key],
+	})
+}
@@ -0,0 +1,113 @@
+package middleware
+
+import (
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func restoreProfiles(t *testing.T) {
+	profilesMu.RLock()
+	saved := make(map[string]Profile, len(profiles))
+	for name, profile := range profiles {
+		saved[name] = profile
+	}
+	profilesMu.RUnlock()
+
+	t.Cleanup(func() {
+		profilesMu.Lock()
+		profiles = saved
+		profilesMu.Unlock()
+	})
+}
+
+func TestRegisterProfiles(t *testing.T) {
+	restoreProfiles(t)
+
+	assert.NoError(t, RegisterProfiles(map[string]Profile{
+		"quotes": {RPS: 0.5, Burst: 3, ExpirationTime: time.Minute},
+	}))
+	profilesMu.RLock()
+	assert.Equal(t, Profile{RPS: 0.5, Burst: 3, ExpirationTime: time.Minute, Key: "ip"}, profiles["quotes"])
+	profilesMu.RUnlock()
+
+	for name, profile := range map[string]Profile{
+		"zero rps":      {RPS: 0, Burst: 5},
+		"zero burst":    {RPS: 1, Burst: 0},
+		"negative rps":  {RPS: -1, Burst: 5},
+		"nan rps":       {RPS: math.NaN(), Burst: 5},
+		"infinite rps":  {RPS: math.Inf(1), Burst: 5},
+		"unknown key":   {RPS: 1, Burst: 5, Key: "cookie"},
+		"negative both": {RPS: -1, Burst: -1},
+	} {
+		assert.Error(t, RegisterProfiles(map[string]Profile{name: profile}), name)
+	}
+
+	// One bad profile registers none of them
+	assert.Error(t, RegisterProfiles(map[string]Profile{
+		"good": {RPS: 1, Burst: 1},
+		"bad":  {RPS: 0, Burst: 1},
+	}))
+	profilesMu.RLock()
+	_, registered := profiles["good"]
+	profilesMu.RUnlock()
+	assert.False(t, registered)
+}
+
+func TestRateLimitProfile(t *testing.T) {
+	restoreProfiles(t)
+	gin.SetMode(gin.TestMode)
+	assert.NoError(t, RegisterProfiles(map[string]Profile{
+		"keyed": {RPS: 1, Burst: 1, Key: "api_key"},
+	}))
+
+	r := gin.New()
+	r.GET("/keyed", RateLimitProfile("keyed"), func(c *gin.Context) { c.Status(http.StatusOK) })
+	r.GET("/unknown", RateLimitProfile("no-such-profile"), func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	serve := func(target, apiKey string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		req.RemoteAddr = "198.51.100.20:1234"
+		if apiKey != "" {
+			req.Header.Set("X-API-Key", apiKey)
+		}
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	assert.Equal(t, http.StatusOK, serve("/keyed", "alpha").Code)
+	assert.Equal(t, http.StatusTooManyRequests, serve("/keyed", "alpha").Code)
+	assert.Equal(t, http.StatusOK, serve("/keyed", "beta").Code, "each key has its own bucket")
+
+	w := serve("/unknown", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "10", w.Header().Get("X-RateLimit-Limit"), "falls back to the default profile")
+}
+
+func TestKeyStrategies(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	request := func(target string, header http.Header) *gin.Context {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest(http.MethodGet, target, nil)
+		c.Request.RemoteAddr = "203.0.113.5:4321"
+		for name, values := range header {
+			c.Request.Header[name] = values
+		}
+		return c
+	}
+
+	assert.Equal(t, "203.0.113.5", keyStrategies["ip"](request("/", nil)))
+
+	assert.Equal(t, "from-header", keyStrategies["api_key"](request("/?api_key=from-query", http.Header{"X-Api-Key": {"from-header"}})))
+	assert.Equal(t, "from-query", keyStrategies["api_key"](request("/?api_key=from-query", nil)))
+	assert.Equal(t, "ip:203.0.113.5", keyStrategies["api_key"](request("/", nil)), "keyless callers don't share a bucket")
+
+	assert.Equal(t, "203.0.113.5:technology", keyStrategies["ip_sector"](request("/?sector=technology", nil)))
+	assert.Equal(t, "203.0.113.5:all", keyStrategies["ip_sector"](request("/", nil)))
+}
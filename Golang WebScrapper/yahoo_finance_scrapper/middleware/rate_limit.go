@@ -1,10 +1,15 @@
 package middleware
 
 import (
+	"math"
 	"net/http"
+	"strconv"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"go-webscraper/metrics"
+
 	"github.com/gin-gonic/gin"
 	"golang.org/x/time/rate"
 )
@@ -30,6 +35,13 @@ type RateLimiter struct {
 	cleanupTk *time.Ticker
 }
 
+// limiters tracks every limiter built by RateLimit so the admin API can
+// inspect and reset them.
+var (
+	limitersMu sync.RWMutex
+	limiters   []*RateLimiter
+)
+
 func NewRateLimiter(config RateLimiterConfig) *RateLimiter {
 	if config.RPS == 0 {
 		config.RPS = 10
@@ -52,6 +64,10 @@ func NewRateLimiter(config RateLimiterConfig) *RateLimiter {
 
 	go rl.cleanup()
 
+	limitersMu.Lock()
+	limiters = append(limiters, rl)
+	limitersMu.Unlock()
+
 	return rl
 }
 
@@ -59,12 +75,30 @@ func defaultKeyFunc(c *gin.Context) string {
 	return c.ClientIP()
 }
 
+// APIKey returns the caller's API key, sent as X-API-Key or ?api_key=.
+func APIKey(c *gin.Context) string {
+	if key := c.GetHeader("X-API-Key"); key != "" {
+		return key
+	}
+	return c.Query("api_key")
+}
+
+// apiKeyFunc keys callers by API key, and keyless callers by address so
+// one anonymous client can't drain a bucket every other one shares.
+func apiKeyFunc(c *gin.Context) string {
+	if key := APIKey(c); key != "" {
+		return key
+	}
+	return "ip:" + c.ClientIP()
+}
+
 func (rl *RateLimiter) cleanup() {
 	for range rl.cleanupTk.C {
 		rl.mu.Lock()
 		for key, client := range rl.clients {
 			if time.Since(client.lastSeen) > rl.config.ExpirationTime {
 				delete(rl.clients, key)
+				metrics.RateLimitActiveClients.WithLabelValues(rl.config.LimitType).Dec()
 			}
 		}
 		rl.mu.Unlock()
@@ -85,24 +119,50 @@ func (rl *RateLimiter) getClientLimiter(key string) *ClientTracker {
 		lastSeen: time.Now(),
 	}
 	rl.clients[key] = client
+	metrics.RateLimitActiveClients.WithLabelValues(rl.config.LimitType).Inc()
 	return client
 }
 
 func RateLimit(config RateLimiterConfig) gin.HandlerFunc {
 	rateLimiter := NewRateLimiter(config)
+	config = rateLimiter.config
 
 	return func(c *gin.Context) {
 		key := config.KeyFunc(c)
 		client := rateLimiter.getClientLimiter(key)
 
-		client.totalRequest++
-		if !client.limiter.Allow() {
+		if until, banned := isBanned(key, c.ClientIP()); banned {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error":        "client banned",
+				"banned_until": until.Format(time.RFC3339),
+			})
+			c.Abort()
+			return
+		}
+
+		totalRequests := atomic.AddInt64(&client.totalRequest, 1)
+
+		now := time.Now()
+		reservation := client.limiter.ReserveN(now, 1)
+		wait := reservation.DelayFrom(now)
+		if wait > 0 {
+			// Give the token back so rejected requests don't push out recovery
+			reservation.CancelAt(now)
+		}
+
+		setRateLimitHeaders(c, config, client.limiter.TokensAt(now), wait)
+
+		if wait > 0 {
+			metrics.RateLimitRejections.WithLabelValues(config.LimitType).Inc()
 			c.JSON(http.StatusTooManyRequests, gin.H{
-				"error": "rate limit exceeded",
+				"error":          "rate limit exceeded",
+				"limit_type":     config.LimitType,
+				"retry_after":    wait.Round(time.Millisecond).String(),
+				"retry_after_ms": wait.Milliseconds(),
 				"rate": gin.H{
 					"requests_per_second": config.RPS,
 					"burst":               config.Burst,
-					"total_requests":      client.totalRequest,
+					"total_requests":      totalRequests,
 				},
 			})
 			c.Abort()
@@ -113,46 +173,40 @@ func RateLimit(config RateLimiterConfig) gin.HandlerFunc {
 	}
 }
 
-func APIRateLimit() gin.HandlerFunc {
-	config := RateLimiterConfig{
-		RPS:            10,
-		Burst:          20,
-		ExpirationTime: 1 * time.Hour,
-		LimitType:      "api",
-		KeyFunc: func(c *gin.Context) string {
-			if key := c.GetHeader("X-API-Key"); key != "" {
-				return key
-			}
-			return c.Query("api_key")
-		},
+func setRateLimitHeaders(c *gin.Context, config RateLimiterConfig, tokens float64, wait time.Duration) {
+	remaining := int(math.Floor(tokens))
+	if remaining < 0 {
+		remaining = 0
 	}
-	return RateLimit(config)
+
+	// Reset is when the bucket will be full again
+	refill := time.Duration((float64(config.Burst) - tokens) / config.RPS * float64(time.Second))
+	if refill < 0 {
+		refill = 0
+	}
+
+	c.Header("X-RateLimit-Limit", strconv.Itoa(config.Burst))
+	c.Header("X-RateLimit-Remaining", strconv.Itoa(remaining))
+	c.Header("X-RateLimit-Reset", strconv.FormatInt(time.Now().Add(refill).Unix(), 10))
+	c.Header("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
 }
 
-func IPRateLimit() gin.HandlerFunc {
-	config := RateLimiterConfig{
-		RPS:            5,
-		Burst:          10,
-		ExpirationTime: 1 * time.Hour,
-		LimitType:      "ip",
-		KeyFunc:        defaultKeyFunc,
+func sectorKeyFunc(c *gin.Context) string {
+	sector := c.Query("sector")
+	if sector == "" {
+		sector = "all"
 	}
-	return RateLimit(config)
+	return c.ClientIP() + ":" + sector
+}
+
+func APIRateLimit() gin.HandlerFunc {
+	return RateLimitProfile("api")
+}
+
+func IPRateLimit() gin.HandlerFunc {
+	return RateLimitProfile("ip")
 }
 
 func SectorAPIRateLimit() gin.HandlerFunc {
-	config := RateLimiterConfig{
-		RPS:            2,
-		Burst:          5,
-		ExpirationTime: 1 * time.Hour,
-		LimitType:      "sector_api",
-		KeyFunc: func(c *gin.Context) string {
-			sector := c.Query("sector")
-			if sector == "" {
-				sector = "all"
-			}
-			return c.ClientIP() + ":" + sector
-		},
-	}
-	return RateLimit(config)
+	return RateLimitProfile("sector_api")
 }
@@ -99,4 +99,34 @@ func TestRateLimiter(t *testing.T) {
 		router.ServeHTTP(w, req)
 		assert.Equal(t, http.StatusOK, w.Code)
 	})
+
+	t.Run("Rate Limit Headers", func(t *testing.T) {
+		router := gin.New()
+		router.Use(SectorAPIRateLimit())
+		router.GET("/test", func(c *gin.Context) {
+			c.String(http.StatusOK, "success")
+		})
+
+		req, _ := http.NewRequest("GET", "/test", nil)
+		req.RemoteAddr = "1.2.3.4:1234"
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "5", w.Header().Get("X-RateLimit-Limit"))
+		assert.Equal(t, "4", w.Header().Get("X-RateLimit-Remaining"))
+		assert.Equal(t, "0", w.Header().Get("Retry-After"))
+		assert.NotEmpty(t, w.Header().Get("X-RateLimit-Reset"))
+
+		for i := 0; i < 4; i++ {
+			router.ServeHTTP(httptest.NewRecorder(), req)
+		}
+
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+		assert.Equal(t, "0", w.Header().Get("X-RateLimit-Remaining"))
+		assert.Equal(t, "1", w.Header().Get("Retry-After"))
+		assert.Contains(t, w.Body.String(), "retry_after_ms")
+	})
 }
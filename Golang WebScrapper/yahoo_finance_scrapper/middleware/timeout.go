@@ -0,0 +1,227 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// timeoutWriter buffers the handler's response so that, if the deadline
+// passes first, the 504 can be written without racing the handler. A
+// handler that flushes commits to the response and streams from then on.
+type timeoutWriter struct {
+	gin.ResponseWriter
+	mu        sync.Mutex
+	header    http.Header
+	body      bytes.Buffer
+	status    int
+	timedOut  bool
+	streaming bool
+}
+
+func (w *timeoutWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *timeoutWriter) WriteHeader(code int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.timedOut || w.status != 0 {
+		return
+	}
+	w.status = code
+}
+
+func (w *timeoutWriter) WriteHeaderNow() {
+	w.WriteHeader(http.StatusOK)
+}
+
+func (w *timeoutWriter) Write(data []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+	if w.streaming {
+		return w.ResponseWriter.Write(data)
+	}
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.body.Write(data)
+}
+
+func (w *timeoutWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+func (w *timeoutWriter) Status() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.status == 0 {
+		return http.StatusOK
+	}
+	return w.status
+}
+
+func (w *timeoutWriter) Size() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.streaming {
+		return w.ResponseWriter.Size()
+	}
+	if w.status == 0 {
+		return -1
+	}
+	return w.body.Len()
+}
+
+func (w *timeoutWriter) Written() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.status != 0
+}
+
+// Flush writes out the buffered headers and body and switches to
+// streaming. Once streaming, the deadline can no longer turn the response
+// into a 504; it only cancels the request context.
+func (w *timeoutWriter) Flush() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.timedOut {
+		return
+	}
+	if !w.streaming {
+		w.commit()
+		w.streaming = true
+	}
+	w.ResponseWriter.Flush()
+}
+
+// commit copies the buffered response to the underlying writer. Callers
+// hold mu.
+func (w *timeoutWriter) commit() {
+	dst := w.ResponseWriter.Header()
+	for k, vv := range w.header {
+		dst[k] = vv
+	}
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	w.ResponseWriter.WriteHeader(w.status)
+	w.ResponseWriter.Write(w.body.Bytes())
+	w.body.Reset()
+}
+
+// Timeout gives the rest of the chain d to respond. The request context
+// carries the deadline so Redis calls made with it are cut short; if the
+// handler still hasn't answered, the client gets a 504 straight away and
+// whatever the handler writes afterwards is discarded. Streaming handlers
+// that have already flushed are instead left to notice the cancelled
+// context and end the stream themselves. The middleware waits for the
+// handler to return before releasing the gin.Context, since gin reuses it
+// for the next request, but the 504 is complete once flushed: it carries
+// its length and closes the connection, so the client isn't kept waiting
+// for the end of the body or for its next request on the connection.
+func Timeout(d time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if d <= 0 {
+			c.Next()
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), d)
+		defer cancel()
+		c.Request = c.Request.WithContext(ctx)
+
+		w := c.Writer
+		tw := &timeoutWriter{ResponseWriter: w, header: make(http.Header)}
+		c.Writer = tw
+
+		done := make(chan struct{})
+		panicChan := make(chan interface{}, 1)
+		go func() {
+			defer func() {
+				if p := recover(); p != nil {
+					panicChan <- p
+				}
+				close(done)
+			}()
+			c.Next()
+		}()
+
+		select {
+		case <-done:
+			c.Writer = w
+			select {
+			case p := <-panicChan:
+				panic(p)
+			default:
+			}
+
+			tw.mu.Lock()
+			defer tw.mu.Unlock()
+			if tw.streaming {
+				return
+			}
+			dst := w.Header()
+			for k, vv := range tw.header {
+				dst[k] = vv
+			}
+			if tw.status != 0 {
+				w.WriteHeader(tw.status)
+			}
+			w.Write(tw.body.Bytes())
+
+		case <-ctx.Done():
+			tw.mu.Lock()
+			if tw.streaming {
+				tw.mu.Unlock()
+				<-done
+				c.Writer = w
+				select {
+				case p := <-panicChan:
+					panic(p)
+				default:
+				}
+				return
+			}
+			tw.timedOut = true
+			tw.mu.Unlock()
+
+			body, _ := json.Marshal(gin.H{
+				"status":  "error",
+				"error":   "request timed out",
+				"timeout": d.String(),
+			})
+			w.Header().Set("Content-Type", "application/json; charset=utf-8")
+			w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+			w.Header().Set("Connection", "close")
+			w.WriteHeader(http.StatusGatewayTimeout)
+			w.Write(body)
+			w.Flush()
+
+			<-done
+			c.Writer = w
+			select {
+			case p := <-panicChan:
+				log.Printf("Handler for %s panicked after timing out: %v", c.Request.URL.Path, p)
+			default:
+			}
+			c.Abort()
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimeout(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	r := gin.New()
+	r.Use(Timeout(50 * time.Millisecond))
+	r.GET("/fast", func(c *gin.Context) {
+		c.Header("X-Handler", "fast")
+		c.JSON(http.StatusCreated, gin.H{"status": "success"})
+	})
+	r.GET("/slow", func(c *gin.Context) {
+		<-c.Request.Context().Done()
+		time.Sleep(10 * time.Millisecond)
+		c.JSON(http.StatusOK, gin.H{"status": "success"})
+	})
+
+	t.Run("Fast Handler", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/fast", nil)
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.Equal(t, "fast", w.Header().Get("X-Handler"))
+		assert.Contains(t, w.Body.String(), "success")
+	})
+
+	t.Run("Slow Handler", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/slow", nil)
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusGatewayTimeout, w.Code)
+		assert.Contains(t, w.Body.String(), "request timed out")
+		assert.NotContains(t, w.Body.String(), "success")
+	})
+
+	t.Run("Streaming Handler", func(t *testing.T) {
+		r := gin.New()
+		r.Use(Timeout(50 * time.Millisecond))
+		r.GET("/stream", func(c *gin.Context) {
+			c.Status(http.StatusOK)
+			c.Writer.WriteString("first")
+			c.Writer.Flush()
+			<-c.Request.Context().Done()
+			c.Writer.WriteString(",last")
+		})
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/stream", nil)
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "first,last", w.Body.String())
+	})
+}
+
+// TestTimeoutResponseComplete checks a client gets the whole 504 while a
+// handler that ignores the deadline is still running.
+func TestTimeoutResponseComplete(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	release := make(chan struct{})
+	r := gin.New()
+	r.Use(Timeout(50 * time.Millisecond))
+	r.GET("/hung", func(c *gin.Context) {
+		<-release
+	})
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+	defer close(release)
+
+	client := &http.Client{Timeout: 2 * time.Second}
+	resp, err := client.Get(srv.URL + "/hung")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err, "body ends before the handler returns")
+	assert.Equal(t, http.StatusGatewayTimeout, resp.StatusCode)
+	assert.Equal(t, int64(len(body)), resp.ContentLength)
+	assert.True(t, resp.Close, "connection isn't reused")
+	assert.Contains(t, string(body), "request timed out")
+}
+
+func TestRequestLimits(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	r := gin.New()
+	r.Use(BodyLimit(16), SanitizeQuery(QueryLimits{MaxLength: 64, MaxParams: 3, MaxValueLength: 10}))
+	r.Any("/", func(c *gin.Context) {
+		c.String(http.StatusOK, "ok")
+	})
+
+	request := func(method, target, body string) int {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, target, strings.NewReader(body))
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusOK, request("GET", "/?sector=technology", ""))
+	assert.Equal(t, http.StatusBadRequest, request("GET", "/?sector=semiconductors", ""))
+	assert.Equal(t, http.StatusBadRequest, request("GET", "/?a=1&b=2&c=3&d=4", ""))
+	assert.Equal(t, http.StatusBadRequest, request("GET", "/?q=a%00b", ""))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, request("POST", "/", strings.Repeat("x", 32)))
+}
@@ -0,0 +1,135 @@
+// Package migrations versions the data kept in Redis. Each migration
+// upgrades what earlier builds wrote, such as reindexing or reshaping
+// stored records, and runs once per deployment in version order. The
+// applied version is recorded in Redis alongside the data.
+package migrations
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"go-webscraper/newsarchive"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	versionKey = "schema:version"
+	// historyKey records when each version was applied.
+	historyKey = "schema:history"
+	// lockKey keeps two instances starting together from running the
+	// same migration twice.
+	lockKey     = "schema:lock"
+	lockTimeout = 10 * time.Minute
+)
+
+// Migration upgrades stored data to Version. Up must be safe to run again
+// if it fails part way.
+type Migration struct {
+	Version int
+	Name    string
+	Up      func(ctx context.Context, rdb *redis.Client) error
+}
+
+// All are the migrations in version order. Append new ones with the next
+// version; never renumber or remove a released one.
+var All = []Migration{
+	{
+		Version: 1,
+		Name:    "baseline",
+		Up:      func(context.Context, *redis.Client) error { return nil },
+	},
+	{
+		Version: 2,
+		Name:    "index archived news by entity",
+		Up: func(_ context.Context, rdb *redis.Client) error {
+			indexed, err := newsarchive.NewStore(rdb).IndexEntities()
+			if err == nil {
+				log.Printf("Indexed entities of %d archived articles", indexed)
+			}
+			return err
+		},
+	},
+}
+
+// Version returns the version the data in rdb is at, 0 before any
+// migration ran.
+func Version(ctx context.Context, rdb *redis.Client) (int, error) {
+	value, err := rdb.Get(ctx, versionKey).Result()
+	if err == redis.Nil {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(value)
+}
+
+// Pending returns the migrations of all newer than version, in order.
+func Pending(all []Migration, version int) []Migration {
+	var pending []Migration
+	for _, m := range all {
+		if m.Version > version {
+			pending = append(pending, m)
+		}
+	}
+	return pending
+}
+
+// Validate checks all is numbered 1, 2, 3... without gaps.
+func Validate(all []Migration) error {
+	for i, m := range all {
+		if m.Version != i+1 {
+			return fmt.Errorf("migration %q has version %d, expected %d", m.Name, m.Version, i+1)
+		}
+		if m.Up == nil {
+			return fmt.Errorf("migration %d has no Up", m.Version)
+		}
+	}
+	return nil
+}
+
+// Run applies the pending migrations, returning those it applied. Each is
+// recorded as it completes, so a failed run resumes from the failed one.
+func Run(ctx context.Context, rdb *redis.Client) ([]Migration, error) {
+	if err := Validate(All); err != nil {
+		return nil, err
+	}
+
+	locked, err := rdb.SetNX(ctx, lockKey, time.Now().Format(time.RFC3339), lockTimeout).Result()
+	if err != nil {
+		return nil, err
+	}
+	if !locked {
+		return nil, fmt.Errorf("another instance is migrating; %s is held", lockKey)
+	}
+	defer rdb.Del(ctx, lockKey)
+
+	version, err := Version(ctx, rdb)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema version: %v", err)
+	}
+	if version > len(All) {
+		return nil, fmt.Errorf("data is at schema version %d, newer than this build's %d", version, len(All))
+	}
+
+	var applied []Migration
+	for _, m := range Pending(All, version) {
+		start := time.Now()
+		if err := m.Up(ctx, rdb); err != nil {
+			return applied, fmt.Errorf("migration %d (%s) failed: %v", m.Version, m.Name, err)
+		}
+		pipe := rdb.TxPipeline()
+		pipe.Set(ctx, versionKey, m.Version, 0)
+		pipe.HSet(ctx, historyKey, strconv.Itoa(m.Version), time.Now().Format(time.RFC3339))
+		if _, err := pipe.Exec(ctx); err != nil {
+			return applied, fmt.Errorf("failed to record migration %d: %v", m.Version, err)
+		}
+		log.Printf("Applied migration %d (%s) in %s", m.Version, m.Name, time.Since(start).Round(time.Millisecond))
+		applied = append(applied, m)
+	}
+	return applied, nil
+}
@@ -0,0 +1,54 @@
+package migrations
+
+import (
+	"context"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidate(t *testing.T) {
+	assert.NoError(t, Validate(All))
+
+	up := func(context.Context, *redis.Client) error { return nil }
+	assert.Error(t, Validate([]Migration{{Version: 1, Up: up}, {Version: 3, Up: up}}))
+	assert.Error(t, Validate([]Migration{{Version: 1}}))
+}
+
+func TestPending(t *testing.T) {
+	up := func(context.Context, *redis.Client) error { return nil }
+	all := []Migration{{Version: 1, Up: up}, {Version: 2, Up: up}, {Version: 3, Up: up}}
+
+	assert.Len(t, Pending(all, 0), 3)
+	pending := Pending(all, 2)
+	assert.Len(t, pending, 1)
+	assert.Equal(t, 3, pending[0].Version)
+	assert.Empty(t, Pending(all, 3))
+}
+
+func TestRun(t *testing.T) {
+	m := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: m.Addr()})
+	defer rdb.Close()
+	ctx := context.Background()
+
+	applied, err := Run(ctx, rdb)
+	assert.NoError(t, err)
+	assert.Len(t, applied, len(All))
+	version, err := Version(ctx, rdb)
+	assert.NoError(t, err)
+	assert.Equal(t, len(All), version)
+	assert.False(t, m.Exists(lockKey))
+
+	// Already current
+	applied, err = Run(ctx, rdb)
+	assert.NoError(t, err)
+	assert.Empty(t, applied)
+
+	// Data from a newer build is left alone
+	m.Set(versionKey, "99")
+	_, err = Run(ctx, rdb)
+	assert.Error(t, err)
+}
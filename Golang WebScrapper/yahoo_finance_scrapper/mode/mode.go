@@ -0,0 +1,256 @@
+// Package mode holds the server-wide operating mode operators switch
+// during Yahoo blocks or migrations. The mode is kept in Redis so every
+// instance follows it, and re-read at most every refreshEvery.
+package mode
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// Normal serves the API and scrapes as usual.
+	Normal = "normal"
+	// Maintenance answers every request but the admin API with 503 and
+	// skips scheduled jobs.
+	Maintenance = "maintenance"
+	// ReadOnly serves cached and stored data but never scrapes, so
+	// requests that need a scrape get 503 and scheduled jobs are skipped.
+	ReadOnly = "read_only"
+	// ScrapeOnly keeps the scheduler refreshing while the API is off.
+	ScrapeOnly = "scrape_only"
+)
+
+const (
+	stateKey          = "mode:state"
+	refreshEvery      = 5 * time.Second
+	defaultRetryAfter = 5 * time.Minute
+)
+
+var ErrReadOnly = errors.New("scraping is disabled while the server is read-only")
+
+// State is the current mode. RetryAfter, in seconds, is what 503s
+// advertise; Reason is shown to callers.
+type State struct {
+	Mode       string    `json:"mode"`
+	Reason     string    `json:"reason,omitempty"`
+	RetryAfter int       `json:"retry_after,omitempty"`
+	Since      time.Time `json:"since"`
+}
+
+func (s State) retryAfter() int {
+	if s.RetryAfter > 0 {
+		return s.RetryAfter
+	}
+	return int(defaultRetryAfter.Seconds())
+}
+
+var (
+	mu       sync.Mutex
+	rdb      *redis.Client
+	current  = State{Mode: Normal}
+	loadedAt time.Time
+)
+
+// Configure keeps the mode in rdb. Without it the mode lives in memory.
+func Configure(client *redis.Client) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	rdb = client
+	loadedAt = time.Time{}
+}
+
+// Current returns the mode, re-reading it from Redis when the copy in
+// memory is older than refreshEvery. A failed read keeps the last mode.
+func Current() State {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if rdb == nil || time.Since(loadedAt) < refreshEvery {
+		return current
+	}
+	loadedAt = time.Now()
+
+	data, err := rdb.Get(context.Background(), stateKey).Bytes()
+	if err == redis.Nil {
+		current = State{Mode: Normal}
+		return current
+	}
+	if err != nil {
+		log.Printf("Error reading server mode: %v", err)
+		return current
+	}
+	var state State
+	if err := json.Unmarshal(data, &state); err != nil {
+		log.Printf("Error decoding server mode: %v", err)
+		return current
+	}
+	current = state
+	return current
+}
+
+func (s State) Validate() error {
+	switch s.Mode {
+	case Normal, Maintenance, ReadOnly, ScrapeOnly:
+	default:
+		return fmt.Errorf("mode must be one of: %s, %s, %s, %s", Normal, Maintenance, ReadOnly, ScrapeOnly)
+	}
+	if s.RetryAfter < 0 {
+		return fmt.Errorf("retry_after must not be negative")
+	}
+	return nil
+}
+
+// Set switches every instance to state.
+func Set(state State) error {
+	if err := state.Validate(); err != nil {
+		return err
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if rdb != nil {
+		data, err := json.Marshal(state)
+		if err != nil {
+			return err
+		}
+		if err := rdb.Set(context.Background(), stateKey, data, 0).Err(); err != nil {
+			return err
+		}
+	}
+	current = state
+	loadedAt = time.Now()
+	return nil
+}
+
+// Scraping returns ErrReadOnly if scrapes may not run, which they may in
+// every mode but read-only.
+func Scraping() error {
+	if Current().Mode == ReadOnly {
+		return ErrReadOnly
+	}
+	return nil
+}
+
+// PausesJobs reports whether scheduled jobs should be skipped, which they
+// are in maintenance and read-only modes.
+func PausesJobs() bool {
+	m := Current().Mode
+	return m == Maintenance || m == ReadOnly
+}
+
+// Unavailable answers with 503, advertising the mode's Retry-After.
+func Unavailable(c *gin.Context, message string) {
+	state := Current()
+	c.Header("Retry-After", fmt.Sprintf("%d", state.retryAfter()))
+	response := gin.H{
+		"status":      "error",
+		"error":       message,
+		"mode":        state.Mode,
+		"retry_after": state.retryAfter(),
+	}
+	if state.Reason != "" {
+		response["reason"] = state.Reason
+	}
+	c.AbortWithStatusJSON(http.StatusServiceUnavailable, response)
+}
+
+// exempt paths stay reachable in every mode, so operators can switch the
+// mode back and scrape metrics.
+func exempt(path string) bool {
+	return path == "/metrics" || path == "/admin" || strings.HasPrefix(path, "/admin/")
+}
+
+// Guard enforces the mode on every request and labels responses with it
+// whenever it isn't normal.
+func Guard() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		state := Current()
+		if state.Mode == Normal {
+			c.Next()
+			return
+		}
+		c.Header("X-GoFinance-Mode", state.Mode)
+		if exempt(c.Request.URL.Path) {
+			c.Next()
+			return
+		}
+
+		switch state.Mode {
+		case Maintenance:
+			Unavailable(c, "down for maintenance")
+			return
+		case ScrapeOnly:
+			Unavailable(c, "the API is disabled while the server only scrapes")
+			return
+		}
+		c.Next()
+	}
+}
+
+func HandleGetMode() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{
+			"status": "success",
+			"data":   Current(),
+		})
+	}
+}
+
+type ModeRequest struct {
+	Mode       string `json:"mode" binding:"required"`
+	Reason     string `json:"reason"`
+	RetryAfter int    `json:"retry_after"`
+}
+
+func HandleSetMode() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req ModeRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		state := State{
+			Mode:       req.Mode,
+			Reason:     req.Reason,
+			RetryAfter: req.RetryAfter,
+			Since:      time.Now(),
+		}
+		if previous := Current(); previous.Mode == state.Mode {
+			state.Since = previous.Since
+		}
+		if err := state.Validate(); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+		if err := Set(state); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		c.Set("audit_target", state.Mode)
+		c.JSON(http.StatusOK, gin.H{
+			"status": "success",
+			"data":   state,
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package mode
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGuard(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	defer Set(State{Mode: Normal})
+
+	r := gin.New()
+	r.Use(Guard())
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	r.GET("/api/stock", ok)
+	r.GET("/admin/mode", ok)
+	r.GET("/metrics", ok)
+
+	get := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		return w
+	}
+
+	t.Run("Normal", func(t *testing.T) {
+		w := get("/api/stock")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Empty(t, w.Header().Get("X-GoFinance-Mode"))
+		assert.NoError(t, Scraping())
+	})
+
+	t.Run("Maintenance", func(t *testing.T) {
+		assert.NoError(t, Set(State{Mode: Maintenance, Reason: "migrating redis", RetryAfter: 120}))
+
+		w := get("/api/stock")
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+		assert.Equal(t, "120", w.Header().Get("Retry-After"))
+		assert.Contains(t, w.Body.String(), "migrating redis")
+
+		assert.Equal(t, http.StatusOK, get("/admin/mode").Code)
+		assert.Equal(t, http.StatusOK, get("/metrics").Code)
+		assert.True(t, PausesJobs())
+	})
+
+	t.Run("Scrape only", func(t *testing.T) {
+		assert.NoError(t, Set(State{Mode: ScrapeOnly}))
+
+		w := get("/api/stock")
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+		assert.Equal(t, "300", w.Header().Get("Retry-After"))
+		assert.False(t, PausesJobs())
+		assert.NoError(t, Scraping())
+	})
+
+	t.Run("Read only", func(t *testing.T) {
+		assert.NoError(t, Set(State{Mode: ReadOnly}))
+
+		w := get("/api/stock")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, ReadOnly, w.Header().Get("X-GoFinance-Mode"))
+		assert.ErrorIs(t, Scraping(), ErrReadOnly)
+		assert.True(t, PausesJobs())
+	})
+}
+
+func TestSetValidates(t *testing.T) {
+	defer Set(State{Mode: Normal})
+
+	assert.Error(t, Set(State{Mode: "paused"}))
+	assert.Error(t, Set(State{Mode: Maintenance, RetryAfter: -1}))
+	assert.Equal(t, Normal, Current().Mode)
+}
@@ -0,0 +1,64 @@
+// Package model holds the data types GoFinance serves, shared by the
+// scrapers and the client package so consumers don't depend on the
+// scraping stack.
+package model
+
+// StockData's yahoo tags name its fields under the yahoo response
+// profile, after Yahoo Finance's quote API.
+type StockData struct {
+	Symbol     string  `json:"symbol"`
+	Name       string  `json:"name" yahoo:"shortName"`
+	Price      float64 `json:"price" yahoo:"regularMarketPrice"`
+	Change     float64 `json:"change" yahoo:"regularMarketChange"`
+	ChangePerc float64 `json:"change_percentage" yahoo:"regularMarketChangePercent"`
+	Volume     int64   `json:"volume" yahoo:"regularMarketVolume"`
+	MarketCap  string  `json:"market_cap" yahoo:"marketCap"`
+	Currency   string  `json:"currency"`
+	Timestamp  string  `json:"timestamp" yahoo:"regularMarketTime"`
+	// Suspect rows failed an anomaly check against recent history.
+	Suspect       bool   `json:"suspect,omitempty"`
+	SuspectReason string `json:"suspect_reason,omitempty" yahoo:"suspectReason"`
+}
+
+type SectorData struct {
+	Name          string      `json:"name"`
+	Performance   float64     `json:"performance"`
+	Volume        int64       `json:"volume"`
+	MarketCap     string      `json:"market_cap" yahoo:"marketCap"`
+	AveragePE     float64     `json:"average_pe" yahoo:"averagePE"`
+	Volatility    float64     `json:"volatility"`
+	TopStocks     []StockData `json:"top_stocks" yahoo:"topStocks"`
+	Performance1M float64     `json:"performance_1m" yahoo:"performance1M"`
+	Performance3M float64     `json:"performance_3m" yahoo:"performance3M"`
+	Performance1Y float64     `json:"performance_1y" yahoo:"performance1Y"`
+	SubIndustries []SubSector `json:"sub_industries" yahoo:"industries"`
+	Timestamp     string      `json:"timestamp"`
+}
+
+type SubSector struct {
+	Name        string  `json:"name"`
+	Performance float64 `json:"performance"`
+	StockCount  int     `json:"stock_count" yahoo:"stockCount"`
+	MarketCap   string  `json:"market_cap" yahoo:"marketCap"`
+}
+
+type Article struct {
+	DatePublished string `json:"date" yahoo:"providerPublishTime"`
+	Title         string `json:"title"`
+	Link          string `json:"link"`
+	Snippet       string `json:"snippet"`
+	Type          string `json:"type"`
+	Author        string `json:"author,omitempty"`
+	Publisher     string `json:"publisher,omitempty"`
+	Summary       string `json:"summary,omitempty"`
+	// Entities are the companies, people and tickers the article
+	// mentions.
+	Entities []Entity `json:"entities,omitempty"`
+}
+
+// Entity is something an article mentions. Type is company, person or
+// ticker.
+type Entity struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
@@ -0,0 +1,541 @@
+// Package newsarchive keeps every scraped article past its cache TTL and
+// searches them by relevance. The index lives in Redis: one sorted set per
+// term mapping article links to weighted term counts, ranked with BM25 at
+// query time. Only titles and snippets are indexed, since the news scraper
+// doesn't fetch article bodies.
+package newsarchive
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"go-webscraper/entity"
+	"go-webscraper/model"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	articlesKey   = "news:archive:articles"
+	publishedKey  = "news:archive:published"
+	termKeyPrefix = "news:archive:term:"
+	publishersKey = "news:archive:publishers"
+	authorsKey    = "news:archive:authors"
+	// One sorted set per entity, of the links mentioning it scored by
+	// publication time.
+	entityKeyPrefix = "news:archive:entity:"
+)
+
+// titleWeight counts a term in the title as this many snippet mentions.
+const titleWeight = 3
+
+// BM25 parameters. With no document lengths stored, only term frequency
+// saturation applies.
+const k1 = 1.2
+
+const (
+	defaultLimit = 20
+	maxLimit     = 100
+)
+
+var stopwords = map[string]bool{
+	"a": true, "an": true, "and": true, "are": true, "as": true, "at": true,
+	"be": true, "by": true, "for": true, "from": true, "has": true, "in": true,
+	"is": true, "it": true, "its": true, "of": true, "on": true, "or": true,
+	"that": true, "the": true, "to": true, "was": true, "were": true, "will": true,
+	"with": true,
+}
+
+// Tokenize lowercases text and splits it into indexable terms, dropping
+// stopwords and single characters.
+func Tokenize(text string) []string {
+	fields := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	terms := make([]string, 0, len(fields))
+	for _, field := range fields {
+		if len(field) < 2 || stopwords[field] {
+			continue
+		}
+		terms = append(terms, field)
+	}
+	return terms
+}
+
+// termWeights counts the terms of an article, title terms weighted up.
+func termWeights(article model.Article) map[string]float64 {
+	weights := make(map[string]float64)
+	for _, term := range Tokenize(article.Title) {
+		weights[term] += titleWeight
+	}
+	for _, term := range Tokenize(article.Snippet) {
+		weights[term]++
+	}
+	return weights
+}
+
+// bm25 scores one term of a query: tf is its weight in the article, df the
+// number of archived articles containing it and n the archive size.
+func bm25(tf float64, df, n int64) float64 {
+	idf := math.Log(1 + (float64(n-df)+0.5)/(float64(df)+0.5))
+	return idf * tf * (k1 + 1) / (tf + k1)
+}
+
+type Result struct {
+	ID string `json:"id"`
+	model.Article
+	Score float64 `json:"score,omitempty"`
+}
+
+// Query is a search. An empty Text lists articles newest first. From and
+// To bound publication time, To exclusive; either may be zero. Entity
+// keeps only articles mentioning that company, person or ticker.
+type Query struct {
+	Text   string
+	Entity string
+	From   time.Time
+	To     time.Time
+	Limit  int
+	Offset int
+}
+
+// ID is the stable identifier of the article at link, used in routes
+// such as /api/news/:id/impact.
+func ID(link string) string {
+	sum := sha256.Sum256([]byte(link))
+	return hex.EncodeToString(sum[:8])
+}
+
+type Store struct {
+	redis *redis.Client
+	ctx   context.Context
+}
+
+func NewStore(rdb *redis.Client) *Store {
+	return &Store{
+		redis: rdb,
+		ctx:   context.Background(),
+	}
+}
+
+// Add archives and indexes the articles not archived yet, returning how
+// many were new. Articles are keyed by link.
+func (s *Store) Add(articles []model.Article) (int, error) {
+	added := 0
+	for _, article := range articles {
+		if article.Link == "" {
+			continue
+		}
+		if len(article.Entities) == 0 {
+			// Cached before articles were tagged
+			article.Entities = entity.Extract(article.Title, article.Snippet)
+		}
+		data, err := json.Marshal(article)
+		if err != nil {
+			return added, err
+		}
+
+		isNew, err := s.redis.HSetNX(s.ctx, articlesKey, article.Link, data).Result()
+		if err != nil {
+			return added, err
+		}
+		if !isNew {
+			continue
+		}
+
+		var published float64
+		if t, err := time.Parse(time.RFC3339, article.DatePublished); err == nil {
+			published = float64(t.Unix())
+		}
+
+		pipe := s.redis.Pipeline()
+		pipe.ZAdd(s.ctx, publishedKey, redis.Z{Score: published, Member: article.Link})
+		for term, weight := range termWeights(article) {
+			pipe.ZAdd(s.ctx, termKeyPrefix+term, redis.Z{Score: weight, Member: article.Link})
+		}
+		if article.Publisher != "" {
+			pipe.HIncrBy(s.ctx, publishersKey, article.Publisher, 1)
+		}
+		if article.Author != "" {
+			pipe.HIncrBy(s.ctx, authorsKey, article.Author, 1)
+		}
+		for _, e := range article.Entities {
+			pipe.ZAdd(s.ctx, entityKeyPrefix+entity.Key(e.Name), redis.Z{Score: published, Member: article.Link})
+		}
+		if _, err := pipe.Exec(s.ctx); err != nil {
+			return added, fmt.Errorf("failed to index %s: %v", article.Link, err)
+		}
+		added++
+	}
+	return added, nil
+}
+
+// IndexEntities indexes every archived article by the entities it
+// mentions, tagging those archived before articles were tagged. It is
+// safe to run again.
+func (s *Store) IndexEntities() (int, error) {
+	indexed := 0
+	iter := s.redis.HScan(s.ctx, articlesKey, 0, "", 100).Iterator()
+	for iter.Next(s.ctx) {
+		link := iter.Val()
+		if !iter.Next(s.ctx) {
+			break
+		}
+		var article model.Article
+		if err := json.Unmarshal([]byte(iter.Val()), &article); err != nil {
+			continue
+		}
+		entities := article.Entities
+		if len(entities) == 0 {
+			entities = entity.Extract(article.Title, article.Snippet)
+		}
+		if len(entities) == 0 {
+			continue
+		}
+		published, err := s.redis.ZScore(s.ctx, publishedKey, link).Result()
+		if err != nil && err != redis.Nil {
+			return indexed, err
+		}
+
+		pipe := s.redis.Pipeline()
+		for _, e := range entities {
+			pipe.ZAdd(s.ctx, entityKeyPrefix+entity.Key(e.Name), redis.Z{Score: published, Member: link})
+		}
+		if _, err := pipe.Exec(s.ctx); err != nil {
+			return indexed, fmt.Errorf("failed to index %s: %v", link, err)
+		}
+		indexed++
+	}
+	return indexed, iter.Err()
+}
+
+// Search returns one page of matching articles, best first, and the total
+// number of matches.
+func (s *Store) Search(q Query) ([]Result, int, error) {
+	terms := dedupe(Tokenize(q.Text))
+	if len(terms) == 0 {
+		return s.latest(q)
+	}
+
+	n, err := s.redis.HLen(s.ctx, articlesKey).Result()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var mentions map[string]bool
+	if q.Entity != "" {
+		links, err := s.redis.ZRange(s.ctx, entityKeyPrefix+entity.Key(q.Entity), 0, -1).Result()
+		if err != nil {
+			return nil, 0, err
+		}
+		mentions = make(map[string]bool, len(links))
+		for _, link := range links {
+			mentions[link] = true
+		}
+	}
+
+	scores := make(map[string]float64)
+	for _, term := range terms {
+		postings, err := s.redis.ZRangeWithScores(s.ctx, termKeyPrefix+term, 0, -1).Result()
+		if err != nil {
+			return nil, 0, err
+		}
+		for _, posting := range postings {
+			link := posting.Member.(string)
+			if mentions != nil && !mentions[link] {
+				continue
+			}
+			scores[link] += bm25(posting.Score, int64(len(postings)), n)
+		}
+	}
+	if len(scores) == 0 {
+		return []Result{}, 0, nil
+	}
+
+	links := make([]string, 0, len(scores))
+	for link := range scores {
+		links = append(links, link)
+	}
+	published, err := s.redis.ZMScore(s.ctx, publishedKey, links...).Result()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	matches := links[:0]
+	publishedAt := make(map[string]float64, len(links))
+	for i, link := range links {
+		if inRange(published[i], q.From, q.To) {
+			matches = append(matches, link)
+			publishedAt[link] = published[i]
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		if scores[matches[i]] != scores[matches[j]] {
+			return scores[matches[i]] > scores[matches[j]]
+		}
+		return publishedAt[matches[i]] > publishedAt[matches[j]]
+	})
+
+	page := paginate(matches, q.Offset, q.Limit)
+	results, err := s.load(page)
+	if err != nil {
+		return nil, 0, err
+	}
+	for i := range results {
+		results[i].Score = math.Round(scores[results[i].Link]*1000) / 1000
+	}
+	return results, len(matches), nil
+}
+
+// latest pages through the archive, or an entity's mentions, by
+// publication time, newest first.
+func (s *Store) latest(q Query) ([]Result, int, error) {
+	key := publishedKey
+	if q.Entity != "" {
+		key = entityKeyPrefix + entity.Key(q.Entity)
+	}
+
+	by := &redis.ZRangeBy{Min: "-inf", Max: "+inf"}
+	if !q.From.IsZero() {
+		by.Min = strconv.FormatInt(q.From.Unix(), 10)
+	}
+	if !q.To.IsZero() {
+		by.Max = "(" + strconv.FormatInt(q.To.Unix(), 10)
+	}
+
+	total, err := s.redis.ZCount(s.ctx, key, by.Min, by.Max).Result()
+	if err != nil {
+		return nil, 0, err
+	}
+	by.Offset, by.Count = int64(q.Offset), int64(q.Limit)
+	links, err := s.redis.ZRevRangeByScore(s.ctx, key, by).Result()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	results, err := s.load(links)
+	return results, int(total), err
+}
+
+func (s *Store) load(links []string) ([]Result, error) {
+	results := make([]Result, 0, len(links))
+	if len(links) == 0 {
+		return results, nil
+	}
+
+	values, err := s.redis.HMGet(s.ctx, articlesKey, links...).Result()
+	if err != nil {
+		return nil, err
+	}
+	for _, value := range values {
+		data, ok := value.(string)
+		if !ok {
+			continue
+		}
+		var result Result
+		if err := json.Unmarshal([]byte(data), &result.Article); err != nil {
+			continue
+		}
+		result.ID = ID(result.Link)
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// Count is how many archived articles a publisher or author has.
+type Count struct {
+	Name     string `json:"name"`
+	Articles int64  `json:"articles"`
+}
+
+// Publishers counts archived articles per publisher, most first. Articles
+// archived before bylines were scraped aren't counted.
+func (s *Store) Publishers() ([]Count, error) {
+	return s.counts(publishersKey)
+}
+
+// Authors counts archived articles per author, most first.
+func (s *Store) Authors() ([]Count, error) {
+	return s.counts(authorsKey)
+}
+
+func (s *Store) counts(key string) ([]Count, error) {
+	values, err := s.redis.HGetAll(s.ctx, key).Result()
+	if err != nil {
+		return nil, err
+	}
+	return rankCounts(values), nil
+}
+
+// rankCounts orders counts most first, then by name.
+func rankCounts(values map[string]string) []Count {
+	counts := make([]Count, 0, len(values))
+	for name, value := range values {
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || n <= 0 {
+			continue
+		}
+		counts = append(counts, Count{Name: name, Articles: n})
+	}
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].Articles != counts[j].Articles {
+			return counts[i].Articles > counts[j].Articles
+		}
+		return counts[i].Name < counts[j].Name
+	})
+	return counts
+}
+
+func inRange(published float64, from, to time.Time) bool {
+	if !from.IsZero() && published < float64(from.Unix()) {
+		return false
+	}
+	if !to.IsZero() && published >= float64(to.Unix()) {
+		return false
+	}
+	return true
+}
+
+func paginate(links []string, offset, limit int) []string {
+	if offset >= len(links) {
+		return nil
+	}
+	links = links[offset:]
+	if limit < len(links) {
+		links = links[:limit]
+	}
+	return links
+}
+
+func dedupe(terms []string) []string {
+	seen := make(map[string]bool, len(terms))
+	unique := terms[:0]
+	for _, term := range terms {
+		if !seen[term] {
+			seen[term] = true
+			unique = append(unique, term)
+		}
+	}
+	return unique
+}
+
+// HandleSearch serves GET /api/news/archive?q=&entity=&from=&to=. from
+// and to are YYYY-MM-DD dates, both inclusive; limit and offset page the
+// results.
+func HandleSearch(store *Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		q := Query{Text: c.Query("q"), Entity: strings.TrimSpace(c.Query("entity")), Limit: defaultLimit}
+
+		for _, name := range []string{"from", "to"} {
+			value := c.Query(name)
+			if value == "" {
+				continue
+			}
+			date, err := time.Parse("2006-01-02", value)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{
+					"error": fmt.Sprintf("invalid %s date: %s", name, value),
+				})
+				return
+			}
+			if name == "from" {
+				q.From = date
+			} else {
+				q.To = date.AddDate(0, 0, 1)
+			}
+		}
+
+		if value := c.Query("limit"); value != "" {
+			limit, err := strconv.Atoi(value)
+			if err != nil || limit < 1 || limit > maxLimit {
+				c.JSON(http.StatusBadRequest, gin.H{
+					"error": fmt.Sprintf("limit must be between 1 and %d", maxLimit),
+				})
+				return
+			}
+			q.Limit = limit
+		}
+		if value := c.Query("offset"); value != "" {
+			offset, err := strconv.Atoi(value)
+			if err != nil || offset < 0 {
+				c.JSON(http.StatusBadRequest, gin.H{
+					"error": "offset must be a non-negative integer",
+				})
+				return
+			}
+			q.Offset = offset
+		}
+
+		results, total, err := store.Search(q)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"status": "success",
+			"total":  total,
+			"data":   results,
+		})
+	}
+}
+
+// HandlePublishers serves GET /api/news/publishers, the archived article
+// count per publisher. Filter live news by one with ?publisher=.
+func HandlePublishers(store *Store) gin.HandlerFunc {
+	return handleCounts(store.Publishers)
+}
+
+// HandleAuthors serves GET /api/news/authors, the archived article count
+// per author.
+func HandleAuthors(store *Store) gin.HandlerFunc {
+	return handleCounts(store.Authors)
+}
+
+func handleCounts(counts func() ([]Count, error)) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		limit := maxLimit
+		if value := c.Query("limit"); value != "" {
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 || n > maxLimit {
+				c.JSON(http.StatusBadRequest, gin.H{
+					"error": fmt.Sprintf("limit must be between 1 and %d", maxLimit),
+				})
+				return
+			}
+			limit = n
+		}
+
+		data, err := counts()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+		total := len(data)
+		if len(data) > limit {
+			data = data[:limit]
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"status": "success",
+			"total":  total,
+			"data":   data,
+		})
+	}
+}
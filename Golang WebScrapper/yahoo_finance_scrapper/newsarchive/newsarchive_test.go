@@ -0,0 +1,70 @@
+package newsarchive
+
+import (
+	"testing"
+	"time"
+
+	"go-webscraper/model"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokenize(t *testing.T) {
+	assert.Equal(t, []string{"fed", "holds", "rates", "inflation", "cools", "2024"},
+		Tokenize("The Fed holds rates as inflation cools in 2024!"))
+	assert.Empty(t, Tokenize("a & I"))
+}
+
+func TestTermWeights(t *testing.T) {
+	weights := termWeights(model.Article{
+		Title:   "Inflation data surprises",
+		Snippet: "Inflation rose faster than expected.",
+	})
+	assert.Equal(t, float64(titleWeight+1), weights["inflation"])
+	assert.Equal(t, 1.0, weights["expected"])
+}
+
+func TestBM25(t *testing.T) {
+	// Rare terms outrank common ones, and more mentions score higher with
+	// diminishing returns
+	assert.Greater(t, bm25(1, 2, 100), bm25(1, 50, 100))
+	assert.Greater(t, bm25(4, 10, 100), bm25(1, 10, 100))
+	assert.Less(t, bm25(4, 10, 100), 4*bm25(1, 10, 100))
+}
+
+func TestPaginate(t *testing.T) {
+	links := []string{"a", "b", "c", "d", "e"}
+	assert.Equal(t, []string{"c", "d"}, paginate(links, 2, 2))
+	assert.Equal(t, []string{"e"}, paginate(links, 4, 20))
+	assert.Nil(t, paginate(links, 5, 20))
+}
+
+func TestInRange(t *testing.T) {
+	from := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 0, 1)
+	assert.True(t, inRange(float64(from.Unix()), from, to))
+	assert.False(t, inRange(float64(to.Unix()), from, to))
+	assert.True(t, inRange(0, time.Time{}, to))
+	assert.False(t, inRange(0, from, time.Time{}))
+}
+
+func TestRankCounts(t *testing.T) {
+	counts := rankCounts(map[string]string{
+		"Reuters":          "12",
+		"Bloomberg":        "30",
+		"Associated Press": "12",
+		"Broken":           "x",
+	})
+	assert.Equal(t, []Count{
+		{Name: "Bloomberg", Articles: 30},
+		{Name: "Associated Press", Articles: 12},
+		{Name: "Reuters", Articles: 12},
+	}, counts)
+}
+
+func TestID(t *testing.T) {
+	id := ID("https://finance.yahoo.com/news/fed-holds-rates.html")
+	assert.Len(t, id, 16)
+	assert.Equal(t, id, ID("https://finance.yahoo.com/news/fed-holds-rates.html"))
+	assert.NotEqual(t, id, ID("https://finance.yahoo.com/news/fed-cuts-rates.html"))
+}
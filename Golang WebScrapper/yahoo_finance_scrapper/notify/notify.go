@@ -0,0 +1,250 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	netmail "net/mail"
+	"time"
+
+	"go-webscraper/config"
+	errs "go-webscraper/error"
+	"go-webscraper/pkg/egress"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+	"gopkg.in/mail.v2"
+)
+
+const channelsKey = "notify:channels"
+
+type Channel struct {
+	Type   string `json:"type" binding:"required"`
+	Target string `json:"target" binding:"required"`
+}
+
+// Validate checks a channel a user asked for. Webhooks must be http(s)
+// URLs on public hosts, since deliveries come from inside our network, and
+// email goes to exactly one address, so the configured SMTP account can't
+// be used to mail arbitrary recipients.
+func (ch *Channel) Validate(ctx context.Context) error {
+	switch ch.Type {
+	case "email":
+		addr, err := netmail.ParseAddress(ch.Target)
+		if err != nil {
+			return fmt.Errorf("target must be a single email address")
+		}
+		ch.Target = addr.Address
+	case "webhook":
+		if err := egress.CheckURL(ctx, ch.Target); err != nil {
+			return fmt.Errorf("target %v", err)
+		}
+	default:
+		return fmt.Errorf("type must be one of: email, webhook")
+	}
+	return nil
+}
+
+type Message struct {
+	Subject string      `json:"subject"`
+	Body    string      `json:"body"`
+	Data    interface{} `json:"data,omitempty"`
+	// Attachments are only delivered by email.
+	Attachments []Attachment `json:"-"`
+}
+
+type Attachment struct {
+	Filename    string
+	ContentType string
+	Data        []byte
+}
+
+// Sender delivers msg to a channel target, for channel types other
+// packages provide.
+type Sender func(target string, msg Message) error
+
+type Notifier struct {
+	redis   *redis.Client
+	ctx     context.Context
+	email   *config.EmailConfig
+	client  *http.Client
+	trusted *http.Client
+	senders map[string]Sender
+}
+
+func NewNotifier(rdb *redis.Client) *Notifier {
+	email, err := config.LoadEmail()
+	if err != nil {
+		errs.NoEmailConfigFound()
+		email = nil
+	}
+
+	return &Notifier{
+		redis:   rdb,
+		ctx:     context.Background(),
+		email:   email,
+		client:  egress.Client(10 * time.Second),
+		trusted: &http.Client{Timeout: 10 * time.Second},
+		senders: make(map[string]Sender),
+	}
+}
+
+// Register delivers channelType channels through send. Register every
+// type before the first notification is sent.
+func (n *Notifier) Register(channelType string, send Sender) {
+	n.senders[channelType] = send
+}
+
+func (n *Notifier) SetChannel(userID string, channel Channel) error {
+	data, err := json.Marshal(channel)
+	if err != nil {
+		return err
+	}
+	return n.redis.HSet(n.ctx, channelsKey, userID, data).Err()
+}
+
+func (n *Notifier) GetChannel(userID string) (*Channel, error) {
+	data, err := n.redis.HGet(n.ctx, channelsKey, userID).Result()
+	if err != nil {
+		if err == redis.Nil {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var channel Channel
+	if err := json.Unmarshal([]byte(data), &channel); err != nil {
+		return nil, err
+	}
+	return &channel, nil
+}
+
+// NotifyUser delivers msg over the channel the user configured.
+func (n *Notifier) NotifyUser(userID string, msg Message) error {
+	channel, err := n.GetChannel(userID)
+	if err != nil {
+		return err
+	}
+	if channel == nil {
+		return fmt.Errorf("no notification channel configured for user %s", userID)
+	}
+	return n.Send(*channel, msg)
+}
+
+// Send delivers msg over a channel users chose, refusing to connect to
+// non-public webhook addresses.
+func (n *Notifier) Send(channel Channel, msg Message) error {
+	return n.send(n.client, channel, msg)
+}
+
+// SendOperator delivers msg over a channel from the server's config, which
+// may be an internal webhook.
+func (n *Notifier) SendOperator(channel Channel, msg Message) error {
+	return n.send(n.trusted, channel, msg)
+}
+
+func (n *Notifier) send(client *http.Client, channel Channel, msg Message) error {
+	switch channel.Type {
+	case "email":
+		return n.sendEmail(channel.Target, msg)
+	case "webhook":
+		return n.sendWebhook(client, channel.Target, msg)
+	}
+	if send, ok := n.senders[channel.Type]; ok {
+		return send(channel.Target, msg)
+	}
+	return fmt.Errorf("unsupported notification channel: %s", channel.Type)
+}
+
+func (n *Notifier) sendEmail(to string, msg Message) error {
+	if n.email == nil {
+		return fmt.Errorf("email notifications are not configured")
+	}
+
+	m := mail.NewMessage()
+	m.SetHeader("From", n.email.Email)
+	m.SetHeader("To", to)
+	m.SetHeader("Subject", msg.Subject)
+	m.SetBody("text/plain", msg.Body)
+	for _, attachment := range msg.Attachments {
+		m.AttachReader(attachment.Filename, bytes.NewReader(attachment.Data), mail.SetHeader(map[string][]string{
+			"Content-Type": {attachment.ContentType},
+		}))
+	}
+
+	d := mail.NewDialer(n.email.SMTPHost, n.email.SMTPPort, n.email.Email, n.email.Password)
+	return d.DialAndSend(m)
+}
+
+func (n *Notifier) sendWebhook(client *http.Client, url string, msg Message) error {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	resp, err := client.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func HandleGetChannel(n *Notifier) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		channel, err := n.GetChannel(c.GetString("user_id"))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+		if channel == nil {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "no notification channel configured",
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"status": "success",
+			"data":   channel,
+		})
+	}
+}
+
+func HandleSetChannel(n *Notifier) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var channel Channel
+		if err := c.ShouldBindJSON(&channel); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+		if err := channel.Validate(c.Request.Context()); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		if err := n.SetChannel(c.GetString("user_id"), channel); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"status": "success",
+			"data":   channel,
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package notify
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go-webscraper/pkg/egress"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChannelValidate(t *testing.T) {
+	ctx := context.Background()
+
+	email := Channel{Type: "email", Target: "Jane Doe <jane@example.com>"}
+	assert.NoError(t, email.Validate(ctx))
+	assert.Equal(t, "jane@example.com", email.Target)
+
+	assert.NoError(t, (&Channel{Type: "webhook", Target: "https://93.184.216.34/hooks/gofinance"}).Validate(ctx))
+
+	for _, ch := range []Channel{
+		{Type: "email", Target: "jane@example.com, victim@example.org"},
+		{Type: "email", Target: "jane@example.com; victim@example.org"},
+		{Type: "email", Target: "not an address"},
+		{Type: "webhook", Target: "ftp://93.184.216.34/"},
+		{Type: "webhook", Target: "http://127.0.0.1:6379/"},
+		{Type: "webhook", Target: "http://169.254.169.254/latest/meta-data/"},
+		{Type: "webhook", Target: "http://10.0.0.8/hook"},
+		{Type: "sms", Target: "+15555550100"},
+	} {
+		assert.Error(t, ch.Validate(ctx), ch.Target)
+	}
+}
+
+func TestSendRefusesPrivateWebhooks(t *testing.T) {
+	hits := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+	}))
+	defer srv.Close()
+
+	n := &Notifier{
+		client:  egress.Client(time.Second),
+		trusted: srv.Client(),
+		senders: make(map[string]Sender),
+	}
+	channel := Channel{Type: "webhook", Target: srv.URL}
+
+	assert.ErrorContains(t, n.Send(channel, Message{Subject: "hi"}), "non-public address")
+	assert.NoError(t, n.SendOperator(channel, Message{Subject: "hi"}))
+	assert.Equal(t, 1, hits)
+}
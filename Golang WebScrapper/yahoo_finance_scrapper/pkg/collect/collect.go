@@ -0,0 +1,81 @@
+// Package collect gathers the rows a scrape produces. Async collectors run
+// callbacks for different responses on different goroutines, so every
+// scrape accumulates into its own Rows, created inside the scrape, instead
+// of appending to a slice or field shared across callbacks.
+package collect
+
+import "sync"
+
+// Rows is safe for concurrent use. The zero value is empty and ready to
+// use.
+type Rows[T any] struct {
+	mu   sync.Mutex
+	rows []T
+	seen map[string]bool
+}
+
+// Add appends rows.
+func (r *Rows[T]) Add(rows ...T) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.rows = append(r.rows, rows...)
+}
+
+// AddUnique appends row unless a row was already added under key, and
+// reports whether it was added.
+func (r *Rows[T]) AddUnique(key string, row T) bool {
+	return r.AddUniqueUpTo(key, row, 0)
+}
+
+// AddUniqueUpTo is AddUnique that also stops adding once limit rows are
+// held. A zero limit doesn't stop.
+func (r *Rows[T]) AddUniqueUpTo(key string, row T, limit int) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.seen[key] || (limit > 0 && len(r.rows) >= limit) {
+		return false
+	}
+	if r.seen == nil {
+		r.seen = make(map[string]bool)
+	}
+	r.seen[key] = true
+	r.rows = append(r.rows, row)
+	return true
+}
+
+// Len is how many rows have been added.
+func (r *Rows[T]) Len() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.rows)
+}
+
+// Rows returns a copy of the rows in the order they were added. It is
+// never nil, so an empty result still encodes as a JSON array.
+func (r *Rows[T]) Rows() []T {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append(make([]T, 0, len(r.rows)), r.rows...)
+}
+
+// Seen records keys, such as the links a crawl has followed. It is safe
+// for concurrent use, and the zero value is empty and ready to use.
+type Seen struct {
+	mu   sync.Mutex
+	keys map[string]bool
+}
+
+// First records key and reports whether this is the first time it was
+// seen.
+func (s *Seen) First(key string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.keys[key] {
+		return false
+	}
+	if s.keys == nil {
+		s.keys = make(map[string]bool)
+	}
+	s.keys[key] = true
+	return true
+}
@@ -0,0 +1,73 @@
+package collect
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRowsConcurrentAdd(t *testing.T) {
+	var rows Rows[int]
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			rows.Add(i, i)
+			rows.Len()
+		}(i)
+	}
+	wg.Wait()
+
+	assert.Equal(t, 100, rows.Len())
+	assert.Len(t, rows.Rows(), 100)
+}
+
+func TestRowsAddUnique(t *testing.T) {
+	var rows Rows[string]
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			key := fmt.Sprint(i % 10)
+			rows.AddUnique(key, key)
+		}(i)
+	}
+	wg.Wait()
+	assert.ElementsMatch(t, []string{"0", "1", "2", "3", "4", "5", "6", "7", "8", "9"}, rows.Rows())
+
+	var limited Rows[string]
+	assert.True(t, limited.AddUniqueUpTo("a", "a", 2))
+	assert.False(t, limited.AddUniqueUpTo("a", "a", 2))
+	assert.True(t, limited.AddUniqueUpTo("b", "b", 2))
+	assert.False(t, limited.AddUniqueUpTo("c", "c", 2))
+	assert.Equal(t, []string{"a", "b"}, limited.Rows())
+}
+
+func TestRowsEmpty(t *testing.T) {
+	var rows Rows[int]
+	assert.Equal(t, []int{}, rows.Rows())
+	assert.Equal(t, 0, rows.Len())
+}
+
+func TestSeen(t *testing.T) {
+	var seen Seen
+	var first int32
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if seen.First("https://example.com/news/a") {
+				atomic.AddInt32(&first, 1)
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(1), first)
+	assert.True(t, seen.First("https://example.com/news/b"))
+}
@@ -0,0 +1,94 @@
+// Package egress guards outbound requests to user-supplied URLs. Webhook
+// and notification targets come from API callers, so they are checked to
+// resolve only to public addresses before they are stored, and dialed
+// through a client that checks the address again at connect time, which
+// also covers DNS that changes after validation and redirects.
+package egress
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"syscall"
+	"time"
+)
+
+// lookupIP resolves host names; tests replace it.
+var lookupIP = net.DefaultResolver.LookupIPAddr
+
+// IsPublic reports whether ip is a globally routable unicast address, not
+// loopback, link-local (including cloud metadata at 169.254.169.254),
+// private, carrier-grade NAT or unspecified.
+func IsPublic(ip net.IP) bool {
+	if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsMulticast() ||
+		ip.IsInterfaceLocalMulticast() {
+		return false
+	}
+	if ip4 := ip.To4(); ip4 != nil {
+		// 100.64.0.0/10 shared address space and 0.0.0.0/8
+		if ip4[0] == 100 && ip4[1]&0xc0 == 64 || ip4[0] == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// CheckURL verifies raw is an absolute http(s) URL whose host resolves
+// only to public addresses.
+func CheckURL(ctx context.Context, raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return fmt.Errorf("must be an absolute http(s) URL")
+	}
+	return CheckHost(ctx, u.Hostname())
+}
+
+// CheckHost verifies every address host resolves to is public.
+func CheckHost(ctx context.Context, host string) error {
+	if ip := net.ParseIP(host); ip != nil {
+		if !IsPublic(ip) {
+			return fmt.Errorf("host %s is not a public address", host)
+		}
+		return nil
+	}
+
+	addrs, err := lookupIP(ctx, host)
+	if err != nil {
+		return fmt.Errorf("cannot resolve host %s", host)
+	}
+	if len(addrs) == 0 {
+		return fmt.Errorf("cannot resolve host %s", host)
+	}
+	for _, addr := range addrs {
+		if !IsPublic(addr.IP) {
+			return fmt.Errorf("host %s resolves to non-public address %s", host, addr.IP)
+		}
+	}
+	return nil
+}
+
+// Client returns an HTTP client that refuses to connect to non-public
+// addresses.
+func Client(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: 10 * time.Second,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if !IsPublic(net.ParseIP(host)) {
+				return fmt.Errorf("refusing to connect to non-public address %s", host)
+			}
+			return nil
+		},
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{Timeout: timeout, Transport: transport}
+}
@@ -0,0 +1,69 @@
+package egress
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsPublic(t *testing.T) {
+	cases := map[string]bool{
+		"93.184.216.34":   true,
+		"2606:4700::1111": true,
+		"127.0.0.1":       false,
+		"10.1.2.3":        false,
+		"172.16.0.1":      false,
+		"192.168.1.1":     false,
+		"169.254.169.254": false,
+		"100.64.0.1":      false,
+		"0.0.0.0":         false,
+		"::1":             false,
+		"fe80::1":         false,
+		"fd00::1":         false,
+	}
+	for ip, want := range cases {
+		assert.Equal(t, want, IsPublic(net.ParseIP(ip)), ip)
+	}
+}
+
+func TestCheckURL(t *testing.T) {
+	lookupIP = func(ctx context.Context, host string) ([]net.IPAddr, error) {
+		switch host {
+		case "hooks.example.com":
+			return []net.IPAddr{{IP: net.ParseIP("93.184.216.34")}}, nil
+		case "internal.example.com":
+			return []net.IPAddr{{IP: net.ParseIP("93.184.216.34")}, {IP: net.ParseIP("10.0.0.5")}}, nil
+		}
+		return nil, &net.DNSError{Err: "no such host", Name: host}
+	}
+	defer func() { lookupIP = net.DefaultResolver.LookupIPAddr }()
+
+	ctx := context.Background()
+	assert.NoError(t, CheckURL(ctx, "https://hooks.example.com/in"))
+	assert.NoError(t, CheckURL(ctx, "http://93.184.216.34:8080/"))
+
+	for _, raw := range []string{
+		"ftp://hooks.example.com/",
+		"/relative",
+		"http://127.0.0.1/",
+		"http://169.254.169.254/latest/meta-data/",
+		"http://[::1]:9000/",
+		"https://internal.example.com/",
+		"https://missing.example.com/",
+	} {
+		assert.Error(t, CheckURL(ctx, raw), raw)
+	}
+}
+
+func TestClientRefusesPrivateAddresses(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	_, err := Client(time.Second).Get(srv.URL)
+	assert.ErrorContains(t, err, "non-public address")
+}
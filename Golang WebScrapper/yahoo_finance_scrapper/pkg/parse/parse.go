@@ -0,0 +1,145 @@
+// Package parse reads the numbers Yahoo renders as text: grouped digits,
+// signed or parenthesized negatives, percentages, K/M/B/T suffixes, and
+// the placeholders shown for missing values. Malformed values can be
+// counted per field, so a layout change shows up in the quality report
+// instead of as a column of silent zeros.
+package parse
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+
+	"go-webscraper/metrics"
+)
+
+// ErrMissing is returned for placeholders such as "N/A" or an em-dash,
+// which Yahoo shows when a value doesn't exist rather than failing to
+// render it.
+var ErrMissing = errors.New("value not available")
+
+// Format is how a region writes numbers.
+type Format struct {
+	DecimalSep   string
+	ThousandsSep string
+}
+
+// US is the format of finance.yahoo.com.
+var US = Format{DecimalSep: ".", ThousandsSep: ","}
+
+var placeholders = map[string]bool{
+	"":       true,
+	"N/A":    true,
+	"NA":     true,
+	"-":      true,
+	"--":     true,
+	"\u2014": true, // em dash
+	"\u2013": true, // en dash
+}
+
+var suffixes = map[string]float64{
+	"K": 1e3,
+	"M": 1e6,
+	"B": 1e9,
+	"T": 1e12,
+}
+
+// normalize trims s and rewrites it with no digit grouping and "." as the
+// decimal separator.
+func (f Format) normalize(s string) string {
+	s = strings.TrimSpace(s)
+	// Regular, no-break and narrow no-break spaces all group digits
+	s = strings.NewReplacer(" ", "", "\u00a0", "", "\u202f", "").Replace(s)
+	if f.ThousandsSep != "" && f.ThousandsSep != " " {
+		s = strings.ReplaceAll(s, f.ThousandsSep, "")
+	}
+	if f.DecimalSep != "" && f.DecimalSep != "." {
+		s = strings.ReplaceAll(s, f.DecimalSep, ".")
+	}
+	return s
+}
+
+// Float parses s, e.g. "1,234.5", "-0.8%", "(12.3)" or "118.6M". A
+// percentage is returned in percent, so "1.5%" is 1.5. Parentheses around
+// an unsigned number make it negative, as in accounts; Yahoo also wraps
+// signed change percentages like "(+1.2%)", which keep their sign.
+func (f Format) Float(s string) (float64, error) {
+	original := s
+	s = f.normalize(s)
+	if placeholders[strings.ToUpper(s)] {
+		return 0, ErrMissing
+	}
+
+	negative := false
+	if strings.HasPrefix(s, "(") && strings.HasSuffix(s, ")") {
+		s = s[1 : len(s)-1]
+		negative = s != "" && s[0] != '+' && s[0] != '-'
+	}
+	s = strings.TrimSuffix(s, "%")
+
+	multiplier := 1.0
+	if n := len(s); n > 0 {
+		if m, ok := suffixes[strings.ToUpper(s[n-1:])]; ok {
+			multiplier = m
+			s = s[:n-1]
+		}
+	}
+
+	value, err := strconv.ParseFloat(s, 64)
+	if err != nil || math.IsInf(value, 0) || math.IsNaN(value) {
+		return 0, fmt.Errorf("invalid number: %q", original)
+	}
+	value *= multiplier
+	if negative && value != 0 {
+		value = -value
+	}
+	return value, nil
+}
+
+// Int parses s like Float, rounding to the nearest integer, so "1.2M"
+// volume is 1200000.
+func (f Format) Int(s string) (int64, error) {
+	value, err := f.Float(s)
+	if err != nil {
+		return 0, err
+	}
+	if math.Abs(value) > math.MaxInt64 {
+		return 0, fmt.Errorf("invalid number: %q is out of range", s)
+	}
+	return int64(math.Round(value)), nil
+}
+
+var (
+	mutex    sync.Mutex
+	failures = make(map[string]int64)
+)
+
+// OK reports whether a parse succeeded, counting a malformed value against
+// field. Missing values aren't failures and aren't counted.
+func OK(field string, err error) bool {
+	if err == nil {
+		return true
+	}
+	if !errors.Is(err, ErrMissing) {
+		mutex.Lock()
+		failures[field]++
+		mutex.Unlock()
+		metrics.ParseFailures.WithLabelValues(field).Inc()
+	}
+	return false
+}
+
+// Failures returns the malformed values seen per field since startup.
+func Failures() map[string]int64 {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	counts := make(map[string]int64, len(failures))
+	for field, n := range failures {
+		counts[field] = n
+	}
+	return counts
+}
@@ -0,0 +1,83 @@
+package parse
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFloat(t *testing.T) {
+	tests := []struct {
+		in   string
+		want float64
+	}{
+		{"1,234.56", 1234.56},
+		{" 42 ", 42},
+		{"-0.83", -0.83},
+		{"+1.20%", 1.2},
+		{"(+1.20%)", 1.2},
+		{"(-1.20%)", -1.2},
+		{"(12.5)", -12.5},
+		{"(0.00%)", 0},
+		{"118.6M", 118.6e6},
+		{"2.35T", 2.35e12},
+		{"950k", 950e3},
+		{"1,234", 1234},
+	}
+	for _, tt := range tests {
+		got, err := US.Float(tt.in)
+		assert.NoError(t, err, tt.in)
+		assert.Equal(t, tt.want, got, tt.in)
+	}
+
+	for _, in := range []string{"", "N/A", "-", "--", "—", "–"} {
+		_, err := US.Float(in)
+		assert.ErrorIs(t, err, ErrMissing, in)
+	}
+
+	for _, in := range []string{"abc", "1.2.3", "12X", "()"} {
+		_, err := US.Float(in)
+		assert.Error(t, err, in)
+		assert.False(t, errors.Is(err, ErrMissing), in)
+	}
+}
+
+func TestRegionalFormats(t *testing.T) {
+	de := Format{DecimalSep: ",", ThousandsSep: "."}
+	v, err := de.Float("1.234,5")
+	assert.NoError(t, err)
+	assert.Equal(t, 1234.5, v)
+
+	fr := Format{DecimalSep: ",", ThousandsSep: " "}
+	v, err = fr.Float("1 234,5 %")
+	assert.NoError(t, err)
+	assert.Equal(t, 1234.5, v)
+}
+
+func TestInt(t *testing.T) {
+	v, err := US.Int("1.2M")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1200000), v)
+
+	v, err = US.Int("12,345,678")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(12345678), v)
+
+	_, err = US.Int("N/A")
+	assert.ErrorIs(t, err, ErrMissing)
+}
+
+func TestOK(t *testing.T) {
+	before := Failures()["test.price"]
+
+	_, err := US.Float("1.5")
+	assert.True(t, OK("test.price", err))
+	_, err = US.Float("N/A")
+	assert.False(t, OK("test.price", err))
+	assert.Equal(t, before, Failures()["test.price"])
+
+	_, err = US.Float("garbage")
+	assert.False(t, OK("test.price", err))
+	assert.Equal(t, before+1, Failures()["test.price"])
+}
@@ -0,0 +1,57 @@
+package yahoo
+
+import (
+	"context"
+	"fmt"
+
+	"go-webscraper/pkg/collect"
+
+	"github.com/gocolly/colly"
+)
+
+type EconomicEvent struct {
+	Event       string `json:"event"`
+	Country     string `json:"country"`
+	ReleaseTime string `json:"release_time"`
+	Period      string `json:"period"`
+	Actual      string `json:"actual"`
+	Forecast    string `json:"forecast"`
+	Previous    string `json:"previous"`
+	Revised     string `json:"revised"`
+	Date        string `json:"date"`
+}
+
+// EconomicCalendar scrapes the economic events released on date, given as
+// YYYY-MM-DD.
+func (c *Client) EconomicCalendar(ctx context.Context, date string) ([]EconomicEvent, error) {
+	var rows collect.Rows[EconomicEvent]
+
+	col := c.clone(ctx)
+	col.OnHTML("table tbody tr", func(e *colly.HTMLElement) {
+		event := EconomicEvent{
+			Event:       SelectText(e, "calendar.event"),
+			Country:     SelectText(e, "calendar.country"),
+			ReleaseTime: SelectText(e, "calendar.release_time"),
+			Period:      SelectText(e, "calendar.period"),
+			Actual:      SelectText(e, "calendar.actual"),
+			Forecast:    SelectText(e, "calendar.forecast"),
+			Previous:    SelectText(e, "calendar.previous"),
+			Revised:     SelectText(e, "calendar.revised"),
+			Date:        date,
+		}
+		if event.Event == "" {
+			return
+		}
+
+		rows.Add(event)
+	})
+
+	if err := col.Visit(c.region.URL("/calendar/economic?day=" + date)); err != nil {
+		return nil, fmt.Errorf("failed to scrape economic calendar: %v", err)
+	}
+	if err := col.wait(); err != nil {
+		return nil, err
+	}
+
+	return rows.Rows(), nil
+}
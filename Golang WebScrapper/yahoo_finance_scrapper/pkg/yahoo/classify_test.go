@@ -0,0 +1,46 @@
+package yahoo
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/gocolly/colly"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClassifyArticle(t *testing.T) {
+	tests := []struct {
+		name    string
+		link    string
+		markers articleMarkers
+		want    string
+	}{
+		{"Editorial", "https://finance.yahoo.com/news/fed-holds-rates-120000123.html", articleMarkers{Provider: "Reuters"}, ArticleEditorial},
+		{"Press Release URL", "https://finance.yahoo.com/news/acme-announces-dividend-prnewswire-130000456.html", articleMarkers{}, ArticlePressRelease},
+		{"Press Release Provider", "https://finance.yahoo.com/news/acme-q3-results-140000789.html", articleMarkers{Provider: "GlobeNewswire"}, ArticlePressRelease},
+		{"Video", "https://finance.yahoo.com/video/markets-close-150000000.html", articleMarkers{}, ArticleVideo},
+		{"Sponsored Label", "https://finance.yahoo.com/news/retire-early-160000000.html", articleMarkers{Labels: "Sponsored"}, ArticleSponsored},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, classifyArticle(tt.link, tt.markers))
+		})
+	}
+}
+
+func TestReadByline(t *testing.T) {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(`<article>
+<div class="caas-attr-provider">Reuters</div>
+<div class="caas-author-byline-collapse">Jane
+  Doe</div>
+<p>Body</p></article>`))
+	assert.NoError(t, err)
+	article := doc.Find("article")
+	e := colly.NewHTMLElementFromSelectionNode(&colly.Response{Request: &colly.Request{}}, article, article.Nodes[0], 0)
+
+	publisher, author := readByline(e)
+	assert.Equal(t, "Reuters", publisher)
+	assert.Equal(t, "Jane Doe", author)
+}
@@ -0,0 +1,155 @@
+package yahoo
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go-webscraper/pkg/parse"
+
+	"github.com/gocolly/colly"
+)
+
+// Commodities maps the front-month continuous futures Yahoo quotes to a
+// readable name.
+var Commodities = map[string]string{
+	"CL=F": "Crude Oil",
+	"GC=F": "Gold",
+	"SI=F": "Silver",
+	"NG=F": "Natural Gas",
+}
+
+// CommodityQuote is a futures quote. ContractMonth is the front month the
+// continuous symbol currently rolls to, as YYYY-MM.
+type CommodityQuote struct {
+	Symbol          string  `json:"symbol"`
+	Name            string  `json:"name"`
+	ContractMonth   string  `json:"contract_month,omitempty"`
+	Price           float64 `json:"price"`
+	Change          float64 `json:"change"`
+	ChangePerc      float64 `json:"change_percentage"`
+	PriorSettlement float64 `json:"prior_settlement,omitempty"`
+	SettlementDate  string  `json:"settlement_date,omitempty"`
+	OpenInterest    int64   `json:"open_interest,omitempty"`
+	Volume          int64   `json:"volume,omitempty"`
+	Timestamp       string  `json:"timestamp"`
+}
+
+// "Crude Oil Dec 24 (CL=F)" in the quote page heading
+var contractMonth = regexp.MustCompile(`\b([A-Z][a-z]{2}) (\d{2})\b`)
+
+func parseContractMonth(heading string) string {
+	m := contractMonth.FindStringSubmatch(heading)
+	if m == nil {
+		return ""
+	}
+	month, err := time.Parse("Jan 06", m[1]+" "+m[2])
+	if err != nil {
+		return ""
+	}
+	return month.Format("2006-01")
+}
+
+// parseCommoditySummary fills quote from one quote summary label/value pair.
+func parseCommoditySummary(region Region, quote *CommodityQuote, label, value string) {
+	switch label {
+	case "Previous Close", "Prior Settlement":
+		if v, err := region.ParseFloat(value); parse.OK("commodities.prior_settlement", err) {
+			quote.PriorSettlement = v
+		}
+	case "Settlement Date":
+		quote.SettlementDate = value
+	case "Open Interest":
+		if v, err := region.ParseInt(value); parse.OK("commodities.open_interest", err) {
+			quote.OpenInterest = v
+		}
+	case "Volume":
+		if v, err := region.ParseInt(value); parse.OK("commodities.volume", err) {
+			quote.Volume = v
+		}
+	}
+}
+
+// Commodities fetches every commodity's quote page in one batch, sorted by
+// name.
+func (c *Client) Commodities(ctx context.Context) ([]CommodityQuote, error) {
+	symbols := make([]string, 0, len(Commodities))
+	quotes := make(map[string]*CommodityQuote, len(Commodities))
+	for symbol, name := range Commodities {
+		symbols = append(symbols, symbol)
+		quotes[symbol] = &CommodityQuote{
+			Symbol:    symbol,
+			Name:      name,
+			Timestamp: time.Now().Format(time.RFC3339),
+		}
+	}
+	var mu sync.Mutex
+
+	col := c.clone(ctx)
+	col.OnHTML("h1", func(e *colly.HTMLElement) {
+		mu.Lock()
+		defer mu.Unlock()
+		if quote := quotes[e.Request.Ctx.Get("symbol")]; quote != nil && quote.ContractMonth == "" {
+			quote.ContractMonth = parseContractMonth(e.Text)
+		}
+	})
+	col.OnHTML("fin-streamer[data-field]", func(e *colly.HTMLElement) {
+		symbol := e.Request.Ctx.Get("symbol")
+		if !strings.EqualFold(e.Attr("data-symbol"), symbol) {
+			return
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		quote := quotes[symbol]
+		switch e.Attr("data-field") {
+		case "regularMarketPrice":
+			if v, err := c.region.ParseFloat(e.Text); parse.OK("commodities.price", err) {
+				quote.Price = v
+			}
+		case "regularMarketChange":
+			if v, err := c.region.ParseFloat(e.Text); parse.OK("commodities.change", err) {
+				quote.Change = v
+			}
+		case "regularMarketChangePercent":
+			if v, err := c.region.ParsePercentage(e.Text); parse.OK("commodities.change_percent", err) {
+				quote.ChangePerc = v
+			}
+		}
+	})
+	col.OnHTML(QuoteSummaryRow, func(e *colly.HTMLElement) {
+		label, value := QuoteSummaryPair(e)
+
+		mu.Lock()
+		defer mu.Unlock()
+		if quote := quotes[e.Request.Ctx.Get("symbol")]; quote != nil {
+			parseCommoditySummary(c.region, quote, label, value)
+		}
+	})
+
+	for _, symbol := range symbols {
+		reqCtx := colly.NewContext()
+		reqCtx.Put("symbol", symbol)
+		if err := col.Request(http.MethodGet, c.region.URL("/quote/"+symbol+"/"), nil, reqCtx, nil); err != nil {
+			return nil, fmt.Errorf("failed to scrape %s: %v", symbol, err)
+		}
+	}
+	if err := col.waitPartial(); err != nil {
+		return nil, err
+	}
+
+	result := make([]CommodityQuote, 0, len(symbols))
+	for _, quote := range quotes {
+		result = append(result, *quote)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+
+	return result, nil
+}
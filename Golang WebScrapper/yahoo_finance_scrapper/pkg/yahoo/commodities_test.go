@@ -0,0 +1,13 @@
+package yahoo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseContractMonth(t *testing.T) {
+	assert.Equal(t, "2024-12", parseContractMonth("Crude Oil Dec 24 (CL=F)"))
+	assert.Equal(t, "2025-02", parseContractMonth("Gold Feb 25"))
+	assert.Equal(t, "", parseContractMonth("Natural Gas (NG=F)"))
+}
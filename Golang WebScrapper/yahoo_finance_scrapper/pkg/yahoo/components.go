@@ -0,0 +1,104 @@
+package yahoo
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"go-webscraper/pkg/collect"
+	"go-webscraper/pkg/parse"
+
+	"github.com/gocolly/colly"
+)
+
+const (
+	componentsPageSize = 100
+	maxComponents      = 600
+)
+
+// IndexComponent is one constituent of an index. Weight is only set when
+// the components table publishes one.
+type IndexComponent struct {
+	Symbol     string  `json:"symbol"`
+	Name       string  `json:"name"`
+	Price      float64 `json:"price"`
+	Change     float64 `json:"change"`
+	ChangePerc float64 `json:"change_percentage"`
+	Volume     int64   `json:"volume"`
+	Weight     float64 `json:"weight,omitempty"`
+}
+
+// parseComponentsTable maps columns by header text, so the optional weight
+// column and reordered tables parse the same way.
+func parseComponentsTable(e *colly.HTMLElement, region Region) []IndexComponent {
+	cell := tableColumns(e)
+
+	components := make([]IndexComponent, 0)
+	e.ForEach("tbody tr", func(_ int, row *colly.HTMLElement) {
+		component := IndexComponent{
+			Symbol: strings.ToUpper(cell(row, "symbol")),
+			Name:   cell(row, "company name", "name"),
+		}
+		if component.Symbol == "" {
+			return
+		}
+		if price, err := region.ParseFloat(cell(row, "last price", "price")); parse.OK("components.price", err) {
+			component.Price = price
+		}
+		if change, err := region.ParseFloat(cell(row, "change")); parse.OK("components.change", err) {
+			component.Change = change
+		}
+		if changePerc, err := region.ParsePercentage(cell(row, "% change", "change %")); parse.OK("components.change_percent", err) {
+			component.ChangePerc = changePerc
+		}
+		if volume, err := region.ParseInt(cell(row, "volume")); parse.OK("components.volume", err) {
+			component.Volume = volume
+		}
+		if weight, err := region.ParsePercentage(cell(row, "weight", "% weight")); parse.OK("components.weight", err) {
+			component.Weight = weight
+		}
+		components = append(components, component)
+	})
+	return components
+}
+
+// IndexComponents pages through symbol's components table until a page
+// adds no new constituents.
+func (c *Client) IndexComponents(ctx context.Context, symbol string) ([]IndexComponent, error) {
+	if !ValidSymbol(symbol) {
+		return nil, fmt.Errorf("invalid symbol: %s", symbol)
+	}
+	symbol = strings.ToUpper(symbol)
+
+	var components collect.Rows[IndexComponent]
+
+	col := c.clone(ctx)
+	col.OnHTML("table", func(e *colly.HTMLElement) {
+		for _, component := range parseComponentsTable(e, c.region) {
+			components.AddUnique(component.Symbol, component)
+		}
+	})
+
+	for start := 0; start < maxComponents; start += componentsPageSize {
+		before := components.Len()
+		url := c.region.URL(fmt.Sprintf("/quote/%s/components/?start=%d&count=%d", symbol, start, componentsPageSize))
+		err := col.Visit(url)
+		if err == nil {
+			err = col.wait()
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if err != nil {
+			if start == 0 {
+				return nil, fmt.Errorf("failed to scrape components of %s: %v", symbol, err)
+			}
+			break
+		}
+
+		if components.Len()-before < componentsPageSize {
+			break
+		}
+	}
+	return components.Rows(), nil
+}
@@ -0,0 +1,32 @@
+package yahoo
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/gocolly/colly"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseComponentsTable(t *testing.T) {
+	html := `<table>
+		<thead><tr><th>Symbol</th><th>Company Name</th><th>Last Price</th><th>Change</th><th>% Change</th><th>Volume</th><th>Weight</th></tr></thead>
+		<tbody>
+			<tr><td>aapl</td><td>Apple Inc.</td><td>227.55</td><td>-1.32</td><td>-0.58%</td><td>42,104,567</td><td>7.12%</td></tr>
+			<tr><td></td><td>Footer</td></tr>
+			<tr><td>MSFT</td><td>Microsoft Corporation</td><td>416.06</td><td>+2.11</td><td>+0.51%</td><td>17,552,020</td><td></td></tr>
+		</tbody>
+	</table>`
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(html))
+	assert.NoError(t, err)
+	table := doc.Find("table").First()
+	e := colly.NewHTMLElementFromSelectionNode(&colly.Response{Request: &colly.Request{}}, table, table.Nodes[0], 0)
+
+	components := parseComponentsTable(e, Regions["us"])
+
+	assert.Equal(t, []IndexComponent{
+		{Symbol: "AAPL", Name: "Apple Inc.", Price: 227.55, Change: -1.32, ChangePerc: -0.58, Volume: 42104567, Weight: 7.12},
+		{Symbol: "MSFT", Name: "Microsoft Corporation", Price: 416.06, Change: 2.11, ChangePerc: 0.51, Volume: 17552020},
+	}, components)
+}
@@ -0,0 +1,92 @@
+package yahoo
+
+import (
+	"context"
+	"net/http"
+	"sync"
+
+	"github.com/gocolly/colly"
+)
+
+// Validators are the cache validators of a page's last full response.
+type Validators struct {
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"last_modified,omitempty"`
+}
+
+// Conditional tracks the conditional requests made under a context: it
+// sends the validators known for each URL and collects the ones returned.
+type Conditional struct {
+	mu          sync.Mutex
+	known       map[string]Validators
+	seen        map[string]Validators
+	requests    int
+	notModified int
+}
+
+type conditionalKey struct{}
+
+// WithConditional returns a context whose scrapes send If-None-Match and
+// If-Modified-Since for the URLs in known. A page that answers 304 Not
+// Modified isn't parsed, so callers must check NotModified and reuse what
+// they had.
+func WithConditional(ctx context.Context, known map[string]Validators) (context.Context, *Conditional) {
+	cond := &Conditional{known: known, seen: make(map[string]Validators)}
+	return context.WithValue(ctx, conditionalKey{}, cond), cond
+}
+
+func conditionalFrom(ctx context.Context) *Conditional {
+	cond, _ := ctx.Value(conditionalKey{}).(*Conditional)
+	return cond
+}
+
+// NotModified reports whether requests were made and every one of them
+// was answered 304.
+func (c *Conditional) NotModified() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.requests > 0 && c.notModified == c.requests
+}
+
+// Validators returns the validators of every page fetched in full.
+func (c *Conditional) Validators() map[string]Validators {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	validators := make(map[string]Validators, len(c.seen))
+	for url, v := range c.seen {
+		validators[url] = v
+	}
+	return validators
+}
+
+func (c *Conditional) request(r *colly.Request) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.requests++
+	v := c.known[r.URL.String()]
+	if v.ETag != "" {
+		r.Headers.Set("If-None-Match", v.ETag)
+	}
+	if v.LastModified != "" {
+		r.Headers.Set("If-Modified-Since", v.LastModified)
+	}
+}
+
+func (c *Conditional) response(r *colly.Response) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if r.StatusCode == http.StatusNotModified {
+		c.notModified++
+		return
+	}
+	if r.StatusCode != http.StatusOK || r.Headers == nil {
+		return
+	}
+	v := Validators{ETag: r.Headers.Get("ETag"), LastModified: r.Headers.Get("Last-Modified")}
+	if v != (Validators{}) {
+		c.seen[r.Request.URL.String()] = v
+	}
+}
@@ -0,0 +1,48 @@
+package yahoo
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gocolly/colly"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConditional(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Last-Modified", "Wed, 14 Oct 2026 12:00:00 GMT")
+		w.Write([]byte("<html><body><p>page</p></body></html>"))
+	}))
+	defer srv.Close()
+
+	scrape := func(known map[string]Validators) (*Conditional, bool) {
+		ctx, cond := WithConditional(context.Background(), known)
+		c := colly.NewCollector()
+		TraceCollector(ctx, c)
+		parsed := false
+		c.OnHTML("p", func(e *colly.HTMLElement) { parsed = true })
+		c.Visit(srv.URL + "/page")
+		return cond, parsed
+	}
+
+	cond, parsed := scrape(nil)
+	assert.True(t, parsed)
+	assert.False(t, cond.NotModified())
+	validators := cond.Validators()
+	assert.Equal(t, Validators{ETag: `"v1"`, LastModified: "Wed, 14 Oct 2026 12:00:00 GMT"}, validators[srv.URL+"/page"])
+
+	cond, parsed = scrape(validators)
+	assert.False(t, parsed)
+	assert.True(t, cond.NotModified())
+	assert.Empty(t, cond.Validators())
+
+	_, cond = WithConditional(context.Background(), nil)
+	assert.False(t, cond.NotModified())
+}
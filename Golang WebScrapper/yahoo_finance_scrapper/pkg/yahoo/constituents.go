@@ -0,0 +1,104 @@
+package yahoo
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"go-webscraper/pkg/collect"
+	"go-webscraper/pkg/parse"
+
+	"github.com/gocolly/colly"
+)
+
+// ConstituentsPageSize is how many companies a sector's company list
+// shows per page.
+const ConstituentsPageSize = 100
+
+// SectorConstituent is one company listed in a sector.
+type SectorConstituent struct {
+	Symbol     string  `json:"symbol"`
+	Name       string  `json:"name"`
+	Industry   string  `json:"industry,omitempty"`
+	Price      float64 `json:"price"`
+	Change     float64 `json:"change"`
+	ChangePerc float64 `json:"change_percentage"`
+	Volume     int64   `json:"volume"`
+	MarketCap  string  `json:"market_cap"`
+}
+
+// parseConstituentsTable maps columns by header text like
+// parseComponentsTable does.
+func parseConstituentsTable(e *colly.HTMLElement, region Region) []SectorConstituent {
+	cell := tableColumns(e)
+
+	constituents := make([]SectorConstituent, 0)
+	e.ForEach("tbody tr", func(_ int, row *colly.HTMLElement) {
+		constituent := SectorConstituent{
+			Symbol:    strings.ToUpper(cell(row, "symbol")),
+			Name:      cell(row, "company name", "name"),
+			Industry:  cell(row, "industry"),
+			MarketCap: cell(row, "market cap"),
+		}
+		if constituent.Symbol == "" {
+			return
+		}
+		if price, err := region.ParseFloat(cell(row, "last price", "price")); parse.OK("constituents.price", err) {
+			constituent.Price = price
+		}
+		if change, err := region.ParseFloat(cell(row, "change")); parse.OK("constituents.change", err) {
+			constituent.Change = change
+		}
+		if changePerc, err := region.ParsePercentage(cell(row, "% change", "change %")); parse.OK("constituents.change_percent", err) {
+			constituent.ChangePerc = changePerc
+		}
+		if volume, err := region.ParseInt(cell(row, "volume")); parse.OK("constituents.volume", err) {
+			constituent.Volume = volume
+		}
+		constituents = append(constituents, constituent)
+	})
+	return constituents
+}
+
+// SectorConstituents pages through sector's company list until it holds
+// limit companies or a page adds no new ones.
+func (c *Client) SectorConstituents(ctx context.Context, sector string, limit int) ([]SectorConstituent, error) {
+	url, exists := SectorURLs[sector]
+	if !exists {
+		return nil, fmt.Errorf("invalid sector: %s", sector)
+	}
+	url = strings.TrimSuffix(c.region.Rewrite(url), "/")
+	var constituents collect.Rows[SectorConstituent]
+
+	col := c.clone(ctx)
+	col.OnHTML("table[data-test='sector-companies']", func(e *colly.HTMLElement) {
+		for _, constituent := range parseConstituentsTable(e, c.region) {
+			constituents.AddUniqueUpTo(constituent.Symbol, constituent, limit)
+		}
+	})
+
+	for offset := 0; offset < limit; offset += ConstituentsPageSize {
+		before := constituents.Len()
+		page := fmt.Sprintf("%s/?offset=%d&count=%d", url, offset, ConstituentsPageSize)
+		err := col.Visit(page)
+		if err == nil {
+			err = col.wait()
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if err != nil {
+			if offset == 0 {
+				return nil, fmt.Errorf("failed to scrape constituents of %s: %v", sector, err)
+			}
+			log.Printf("Stopped paging %s constituents at offset %d: %v", sector, offset, err)
+			break
+		}
+
+		if constituents.Len()-before < ConstituentsPageSize {
+			break
+		}
+	}
+	return constituents.Rows(), nil
+}
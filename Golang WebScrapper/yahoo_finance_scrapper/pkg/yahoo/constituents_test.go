@@ -0,0 +1,32 @@
+package yahoo
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/gocolly/colly"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseConstituentsTable(t *testing.T) {
+	html := `<table data-test="sector-companies">
+		<thead><tr><th>Symbol</th><th>Name</th><th>Industry</th><th>Price</th><th>Change</th><th>% Change</th><th>Volume</th><th>Market Cap</th></tr></thead>
+		<tbody>
+			<tr><td>nvda</td><td>NVIDIA Corporation</td><td>Semiconductors</td><td>135.40</td><td>+2.10</td><td>+1.58%</td><td>250,104,567</td><td>3.32T</td></tr>
+			<tr><td></td><td>Footer</td></tr>
+			<tr><td>ADBE</td><td>Adobe Inc.</td><td>Software - Application</td><td>512.00</td><td>-4.00</td><td>-0.78%</td><td>2,552,020</td><td>226.1B</td></tr>
+		</tbody>
+	</table>`
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(html))
+	assert.NoError(t, err)
+	table := doc.Find("table").First()
+	e := colly.NewHTMLElementFromSelectionNode(&colly.Response{Request: &colly.Request{}}, table, table.Nodes[0], 0)
+
+	constituents := parseConstituentsTable(e, Regions["us"])
+
+	assert.Equal(t, []SectorConstituent{
+		{Symbol: "NVDA", Name: "NVIDIA Corporation", Industry: "Semiconductors", Price: 135.40, Change: 2.10, ChangePerc: 1.58, Volume: 250104567, MarketCap: "3.32T"},
+		{Symbol: "ADBE", Name: "Adobe Inc.", Industry: "Software - Application", Price: 512.00, Change: -4.00, ChangePerc: -0.78, Volume: 2552020, MarketCap: "226.1B"},
+	}, constituents)
+}
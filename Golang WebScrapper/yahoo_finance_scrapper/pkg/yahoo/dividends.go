@@ -0,0 +1,135 @@
+package yahoo
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"go-webscraper/pkg/collect"
+	"go-webscraper/pkg/parse"
+
+	"github.com/gocolly/colly"
+)
+
+// DividendEvent is one row of the market-wide ex-dividend calendar.
+type DividendEvent struct {
+	Symbol     string `json:"symbol"`
+	Company    string `json:"company"`
+	ExDate     string `json:"ex_date"`
+	PayoutDate string `json:"payout_date,omitempty"`
+	Amount     string `json:"amount,omitempty"`
+	Yield      string `json:"yield,omitempty"`
+}
+
+// DividendInfo is a single symbol's forward dividend as shown in its quote
+// summary. Upcoming is set when the ex-dividend date is today or later.
+type DividendInfo struct {
+	Symbol          string  `json:"symbol"`
+	ForwardDividend float64 `json:"forward_dividend"`
+	Yield           float64 `json:"yield"`
+	ExDividendDate  string  `json:"ex_dividend_date,omitempty"`
+	Upcoming        bool    `json:"upcoming"`
+}
+
+// DividendCalendar scrapes the stocks going ex-dividend on date, given as
+// YYYY-MM-DD.
+func (c *Client) DividendCalendar(ctx context.Context, date string) ([]DividendEvent, error) {
+	var rows collect.Rows[DividendEvent]
+
+	col := c.clone(ctx)
+	col.OnHTML("table tbody tr", func(e *colly.HTMLElement) {
+		event := DividendEvent{
+			Symbol:     strings.TrimSpace(e.ChildText("td[aria-label='Symbol']")),
+			Company:    strings.TrimSpace(e.ChildText("td[aria-label='Company']")),
+			ExDate:     date,
+			PayoutDate: strings.TrimSpace(e.ChildText("td[aria-label='Payout Date']")),
+			Amount:     strings.TrimSpace(e.ChildText("td[aria-label='Dividend']")),
+			Yield:      strings.TrimSpace(e.ChildText("td[aria-label='Yield']")),
+		}
+		if event.Symbol == "" {
+			return
+		}
+
+		rows.Add(event)
+	})
+
+	if err := col.Visit(c.region.URL("/calendar/dividends?day=" + date)); err != nil {
+		return nil, fmt.Errorf("failed to scrape dividend calendar: %v", err)
+	}
+	if err := col.wait(); err != nil {
+		return nil, err
+	}
+
+	return rows.Rows(), nil
+}
+
+// parseDividendSummary fills info from a quote summary label/value pair,
+// with numbers written in region's format.
+func parseDividendSummary(region Region, info *DividendInfo, label, value string, today time.Time) {
+	switch label {
+	case "Forward Dividend & Yield":
+		if m := ForwardDividend.FindStringSubmatch(value); m != nil {
+			if v, err := region.ParseFloat(m[1]); parse.OK("dividends.forward_dividend", err) {
+				info.ForwardDividend = v
+			}
+			if v, err := region.ParsePercentage(m[2]); parse.OK("dividends.yield", err) {
+				info.Yield = v
+			}
+		}
+	case "Ex-Dividend Date":
+		if exDate, err := time.Parse("Jan 2, 2006", value); err == nil {
+			info.ExDividendDate = exDate.Format("2006-01-02")
+			info.Upcoming = !exDate.Before(today)
+		}
+	}
+}
+
+// Dividends reads the forward dividend and ex-dividend date from each
+// symbol's quote summary, judging Upcoming against today. Symbols whose
+// page didn't load are left out, so callers can tell them apart from
+// symbols that pay no dividend.
+func (c *Client) Dividends(ctx context.Context, symbols []string, today time.Time) ([]DividendInfo, error) {
+	results := make(map[string]*DividendInfo, len(symbols))
+	loaded := make(map[string]bool, len(symbols))
+	var mu sync.Mutex
+
+	col := c.clone(ctx)
+	col.OnHTML(QuoteSummaryRow, func(e *colly.HTMLElement) {
+		label, value := QuoteSummaryPair(e)
+
+		mu.Lock()
+		defer mu.Unlock()
+		if info := results[e.Request.Ctx.Get("symbol")]; info != nil {
+			parseDividendSummary(c.region, info, label, value, today)
+		}
+	})
+	col.OnScraped(func(r *colly.Response) {
+		mu.Lock()
+		defer mu.Unlock()
+		loaded[r.Ctx.Get("symbol")] = true
+	})
+
+	for _, symbol := range symbols {
+		symbol = strings.ToUpper(symbol)
+		results[symbol] = &DividendInfo{Symbol: symbol}
+
+		reqCtx := colly.NewContext()
+		reqCtx.Put("symbol", symbol)
+		col.Request(http.MethodGet, c.region.URL("/quote/"+symbol+"/"), nil, reqCtx, nil)
+	}
+	if err := col.waitPartial(); err != nil {
+		return nil, err
+	}
+
+	dividends := make([]DividendInfo, 0, len(symbols))
+	for _, symbol := range symbols {
+		symbol = strings.ToUpper(symbol)
+		if loaded[symbol] {
+			dividends = append(dividends, *results[symbol])
+		}
+	}
+	return dividends, nil
+}
@@ -0,0 +1,36 @@
+package yahoo
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseDividendSummary(t *testing.T) {
+	today := time.Date(2024, 11, 1, 0, 0, 0, 0, time.UTC)
+	us := Regions["us"]
+
+	info := &DividendInfo{Symbol: "AAPL"}
+	parseDividendSummary(us, info, "Forward Dividend & Yield", "1,00 (0.43%)", today)
+	parseDividendSummary(us, info, "Ex-Dividend Date", "Nov 8, 2024", today)
+	parseDividendSummary(us, info, "Market Cap", "3.5T", today)
+
+	assert.Equal(t, 100.0, info.ForwardDividend)
+	assert.Equal(t, 0.43, info.Yield)
+	assert.Equal(t, "2024-11-08", info.ExDividendDate)
+	assert.True(t, info.Upcoming)
+
+	past := &DividendInfo{Symbol: "MSFT"}
+	parseDividendSummary(us, past, "Ex-Dividend Date", "Aug 15, 2024", today)
+	assert.False(t, past.Upcoming)
+
+	none := &DividendInfo{Symbol: "TSLA"}
+	parseDividendSummary(us, none, "Forward Dividend & Yield", "N/A (N/A)", today)
+	assert.Zero(t, none.ForwardDividend)
+
+	regional := &DividendInfo{Symbol: "SAP.DE"}
+	parseDividendSummary(Regions["de"], regional, "Forward Dividend & Yield", "2,20 (1,05 %)", today)
+	assert.Equal(t, 2.2, regional.ForwardDividend)
+	assert.Equal(t, 1.05, regional.Yield)
+}
@@ -0,0 +1,59 @@
+package yahoo
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/gocolly/colly"
+)
+
+// Fetch is one page request made while scraping.
+type Fetch struct {
+	URL      string
+	Status   int
+	Duration time.Duration
+	At       time.Time
+}
+
+// FetchLog collects the requests made under a context, so callers can tell
+// where a result came from.
+type FetchLog struct {
+	mu      sync.Mutex
+	fetches []Fetch
+}
+
+type fetchLogKey struct{}
+
+// WithFetchLog returns a context whose scrapes are recorded in a new log.
+func WithFetchLog(ctx context.Context) context.Context {
+	return context.WithValue(ctx, fetchLogKey{}, &FetchLog{})
+}
+
+// FetchLogFrom returns ctx's log, or nil when it has none.
+func FetchLogFrom(ctx context.Context) *FetchLog {
+	log, _ := ctx.Value(fetchLogKey{}).(*FetchLog)
+	return log
+}
+
+func (l *FetchLog) Fetches() []Fetch {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]Fetch(nil), l.fetches...)
+}
+
+func recordFetch(ctx context.Context, r *colly.Response) {
+	log := FetchLogFrom(ctx)
+	if log == nil {
+		return
+	}
+
+	fetch := Fetch{URL: r.Request.URL.String(), Status: r.StatusCode, At: time.Now()}
+	if start, ok := r.Ctx.GetAny(startCtxKey).(time.Time); ok {
+		fetch.Duration = fetch.At.Sub(start)
+	}
+
+	log.mu.Lock()
+	log.fetches = append(log.fetches, fetch)
+	log.mu.Unlock()
+}
@@ -0,0 +1,289 @@
+package yahoo
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"go-webscraper/entity"
+	"go-webscraper/model"
+	"go-webscraper/pkg/collect"
+
+	"github.com/gocolly/colly"
+)
+
+var validSymbol = regexp.MustCompile(`^[A-Za-z0-9.\-^=]{1,12}$`)
+
+// ValidSymbol reports whether symbol looks like a Yahoo ticker.
+func ValidSymbol(symbol string) bool {
+	return validSymbol.MatchString(symbol)
+}
+
+// ArticleStore lets news scrapes skip articles fetched before: Lookup
+// returns a stored copy of the article at url, and Store is handed every
+// article parsed.
+type ArticleStore interface {
+	Lookup(url string) (*model.Article, bool)
+	Store(url string, article model.Article)
+}
+
+// NewsCrawl seeds and bounds one news crawl. Zero limits leave the crawl
+// unbounded in that direction.
+type NewsCrawl struct {
+	// Since drops articles published before it; zero keeps everything.
+	Since time.Time
+	// Hub starts the crawl at the region's news hub and follows the news
+	// links on every page it reaches.
+	Hub bool
+	// Links are visited too, such as the ones a feed listed.
+	Links []string
+	// Skip holds links not to visit at all.
+	Skip map[string]bool
+
+	MaxPages    int
+	MaxDepth    int
+	MaxDuration time.Duration
+
+	// Store, when set, serves articles already fetched and keeps new ones.
+	Store ArticleStore
+}
+
+// NewsResult is what a news crawl found. Visited lists the article links
+// it fetched or found stored, and Unvisited the ones it discovered but
+// left when its budget ran out, so a later crawl can pick up from there.
+type NewsResult struct {
+	Articles  []model.Article
+	Visited   []string
+	Unvisited []string
+
+	// Fetched counts pages requested, Scraped articles parsed from them,
+	// Stored articles served from the store and Skipped links in Skip.
+	Fetched, Scraped, Stored, Skipped int
+}
+
+// News crawls the region's news pages as crawl says.
+func (c *Client) News(ctx context.Context, crawl NewsCrawl) (*NewsResult, error) {
+	result := &NewsResult{}
+	var articles collect.Rows[model.Article]
+	var mu sync.Mutex
+
+	startTime := time.Now()
+	hub := c.region.URL("/news/")
+
+	col := c.clone(ctx)
+	// Depth is bounded by crawl.MaxDepth below, so links past it can be
+	// left for a later crawl instead of dropped
+	col.MaxDepth = 0
+	var followed collect.Seen
+	visit := func(link string) error {
+		if !followed.First(link) {
+			return nil
+		}
+		return col.Visit(link)
+	}
+
+	col.OnRequest(func(r *colly.Request) {
+		url := r.URL.String()
+		mu.Lock()
+		defer mu.Unlock()
+
+		if crawl.Skip[url] {
+			result.Skipped++
+			r.Abort()
+			return
+		}
+
+		overBudget := (crawl.MaxPages > 0 && result.Fetched >= crawl.MaxPages) ||
+			(crawl.MaxDuration > 0 && time.Since(startTime) > crawl.MaxDuration)
+		tooDeep := crawl.MaxDepth > 0 && r.Depth > crawl.MaxDepth
+		if tooDeep || overBudget {
+			if overBudget && !tooDeep && url != hub {
+				result.Unvisited = append(result.Unvisited, url)
+			}
+			r.Abort()
+			return
+		}
+		if article, ok := storedArticle(crawl.Store, url); ok {
+			if publishedSince(article.DatePublished, crawl.Since) {
+				articles.Add(*article)
+			}
+			result.Stored++
+			result.Visited = append(result.Visited, url)
+			r.Abort()
+			return
+		}
+		result.Fetched++
+		if url != hub {
+			result.Visited = append(result.Visited, url)
+		}
+	})
+
+	// Responses are handled in parallel, so the title and link are read
+	// from the article's own page rather than the last one requested
+	col.OnHTML("article", func(e *colly.HTMLElement) {
+		articleDate := e.ChildAttr("time", "datetime")
+		if !publishedSince(articleDate, crawl.Since) {
+			return
+		}
+
+		link := e.Request.URL.String()
+		article := parseArticle(e, link, pageTitle(e))
+		articles.Add(article)
+		mu.Lock()
+		result.Scraped++
+		mu.Unlock()
+		if crawl.Store != nil {
+			crawl.Store.Store(link, article)
+		}
+	})
+
+	if crawl.Hub {
+		col.OnHTML("a[href]", func(e *colly.HTMLElement) {
+			link := e.Request.AbsoluteURL(e.Attr("href"))
+			if strings.Contains(link, "/news/") && followed.First(link) {
+				e.Request.Visit(link)
+			}
+		})
+
+		if err := visit(hub); err != nil {
+			return nil, fmt.Errorf("failed to start scraping: %v", err)
+		}
+	}
+	for _, link := range crawl.Links {
+		visit(link)
+	}
+	if err := col.waitPartial(); err != nil {
+		return nil, err
+	}
+
+	result.Articles = articles.Rows()
+	return result, nil
+}
+
+// SymbolNews crawls symbol's own news tab instead of the global feed,
+// following up to limit story links. store may be nil.
+func (c *Client) SymbolNews(ctx context.Context, symbol string, limit int, store ArticleStore) ([]model.Article, error) {
+	if !ValidSymbol(symbol) {
+		return nil, fmt.Errorf("invalid symbol: %s", symbol)
+	}
+	symbol = strings.ToUpper(symbol)
+	listURL := c.region.URL("/quote/" + symbol + "/news/")
+
+	var articles collect.Rows[model.Article]
+	var seen collect.Seen
+	followed := 0
+	var mu sync.Mutex
+
+	col := c.clone(ctx)
+	col.MaxDepth = 2
+
+	col.OnRequest(func(r *colly.Request) {
+		if r.Depth == 1 {
+			return
+		}
+		if article, ok := storedArticle(store, r.URL.String()); ok {
+			articles.Add(*article)
+			r.Abort()
+		}
+	})
+
+	// Only the listing page is mined for links; story pages are leaves
+	col.OnHTML("main a[href]", func(e *colly.HTMLElement) {
+		if e.Request.Depth != 1 {
+			return
+		}
+		link := e.Request.AbsoluteURL(e.Attr("href"))
+		if !strings.Contains(link, "/news/") || strings.HasPrefix(link, listURL) || !seen.First(link) {
+			return
+		}
+
+		mu.Lock()
+		if followed >= limit {
+			mu.Unlock()
+			return
+		}
+		followed++
+		mu.Unlock()
+
+		e.Request.Visit(link)
+	})
+
+	col.OnHTML("html", func(e *colly.HTMLElement) {
+		if e.Request.Depth == 1 {
+			return
+		}
+		url := e.Request.URL.String()
+		title := strings.TrimSpace(e.ChildText("head title"))
+
+		e.ForEach("article", func(_ int, el *colly.HTMLElement) {
+			article := parseArticle(el, url, title)
+			articles.Add(article)
+			if store != nil {
+				store.Store(url, article)
+			}
+		})
+	})
+
+	if err := col.Visit(listURL); err != nil {
+		return nil, fmt.Errorf("failed to scrape news for %s: %v", symbol, err)
+	}
+	if err := col.waitPartial(); err != nil {
+		return nil, err
+	}
+
+	return articles.Rows(), nil
+}
+
+// parseArticle reads the article in e, found on the page at link.
+func parseArticle(e *colly.HTMLElement, link, title string) model.Article {
+	publisher, author := readByline(e)
+	article := model.Article{
+		DatePublished: e.ChildAttr("time", "datetime"),
+		Title:         title,
+		Link:          link,
+		Snippet:       e.ChildText("p"),
+		Type:          classifyArticle(link, readArticleMarkers(e)),
+		Author:        author,
+		Publisher:     publisher,
+	}
+	article.Entities = entity.Extract(article.Title, article.Snippet)
+	return article
+}
+
+// storedArticle looks url up in store, which may be nil.
+func storedArticle(store ArticleStore, url string) (*model.Article, bool) {
+	if store == nil {
+		return nil, false
+	}
+	article, ok := store.Lookup(url)
+	if !ok {
+		return nil, false
+	}
+	if article.Type == "" {
+		// Stored before articles were classified
+		article.Type = classifyArticle(url, articleMarkers{})
+	}
+	return article, true
+}
+
+// pageTitle is the title of the page holding e.
+func pageTitle(e *colly.HTMLElement) string {
+	return strings.TrimSpace(e.DOM.Closest("html").Find("head title").First().Text())
+}
+
+// publishedSince reports whether an article's datetime attribute falls at
+// or after since. Articles without a parseable date only pass when there
+// is no cutoff.
+func publishedSince(date string, since time.Time) bool {
+	if since.IsZero() {
+		return true
+	}
+	published, err := time.Parse(time.RFC3339, date)
+	if err != nil {
+		return false
+	}
+	return !published.Before(since)
+}
@@ -0,0 +1,18 @@
+package yahoo
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPublishedSince(t *testing.T) {
+	since := time.Date(2024, 7, 10, 4, 0, 0, 0, time.UTC)
+
+	assert.True(t, publishedSince("2024-07-10T04:30:00.000Z", since))
+	assert.True(t, publishedSince("2024-07-10T04:00:00Z", since))
+	assert.False(t, publishedSince("2024-07-10T03:59:00Z", since))
+	assert.False(t, publishedSince("", since))
+	assert.True(t, publishedSince("", time.Time{}))
+}
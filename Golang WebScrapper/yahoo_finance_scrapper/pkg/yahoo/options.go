@@ -0,0 +1,137 @@
+package yahoo
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-webscraper/pkg/collect"
+	"go-webscraper/pkg/parse"
+
+	"github.com/gocolly/colly"
+)
+
+type OptionContract struct {
+	Contract          string  `json:"contract"`
+	Underlying        string  `json:"underlying"`
+	Type              string  `json:"type"`
+	Strike            float64 `json:"strike"`
+	Expiry            string  `json:"expiry"`
+	LastPrice         float64 `json:"last_price"`
+	Volume            int64   `json:"volume"`
+	OpenInterest      int64   `json:"open_interest"`
+	ImpliedVolatility float64 `json:"implied_volatility"`
+	Timestamp         string  `json:"timestamp"`
+}
+
+// OptionsLists are the paths of the most active options lists, by what
+// they rank on: open interest or implied volatility.
+var OptionsLists = map[string]string{
+	"oi": "/markets/options/highest-open-interest/",
+	"iv": "/markets/options/highest-implied-volatility/",
+}
+
+// OCC option symbols encode underlying, expiry (YYMMDD), call/put and the
+// strike in thousandths, e.g. AAPL250117C00200000.
+var occSymbol = regexp.MustCompile(`^([A-Z.]{1,6})(\d{6})([CP])(\d{8})$`)
+
+// tableColumns maps a table's columns by header text, so reordered tables
+// and optional columns parse the same way. The returned cell reads a row's
+// cell under the first of names the table has.
+func tableColumns(e *colly.HTMLElement) func(row *colly.HTMLElement, names ...string) string {
+	columns := make(map[string]int)
+	e.ForEach("thead th", func(i int, th *colly.HTMLElement) {
+		columns[strings.ToLower(strings.TrimSpace(th.Text))] = i + 1
+	})
+	return func(row *colly.HTMLElement, names ...string) string {
+		for _, name := range names {
+			if i, ok := columns[name]; ok {
+				return strings.TrimSpace(row.ChildText(fmt.Sprintf("td:nth-child(%d)", i)))
+			}
+		}
+		return ""
+	}
+}
+
+// MostActiveOptions scrapes one of OptionsLists.
+func (c *Client) MostActiveOptions(ctx context.Context, by string) ([]OptionContract, error) {
+	path, exists := OptionsLists[by]
+	if !exists {
+		return nil, fmt.Errorf("invalid options list: %s", by)
+	}
+
+	var rows collect.Rows[OptionContract]
+
+	col := c.clone(ctx)
+	col.OnHTML("table", func(e *colly.HTMLElement) {
+		cell := tableColumns(e)
+
+		e.ForEach("tbody tr", func(_ int, row *colly.HTMLElement) {
+			contract := OptionContract{
+				Contract:   cell(row, "symbol", "contract name"),
+				Underlying: cell(row, "underlying symbol", "underlying"),
+				Expiry:     cell(row, "expiration date", "expiration"),
+				Timestamp:  time.Now().Format(time.RFC3339),
+			}
+			if contract.Contract == "" {
+				return
+			}
+			parseOCCSymbol(&contract)
+
+			if strike, err := c.region.ParseFloat(cell(row, "strike")); parse.OK("options.strike", err) {
+				contract.Strike = strike
+			}
+			if price, err := c.region.ParseFloat(cell(row, "price", "last price")); parse.OK("options.price", err) {
+				contract.LastPrice = price
+			}
+			if volume, err := c.region.ParseInt(cell(row, "volume")); parse.OK("options.volume", err) {
+				contract.Volume = volume
+			}
+			if oi, err := c.region.ParseInt(cell(row, "open interest")); parse.OK("options.open_interest", err) {
+				contract.OpenInterest = oi
+			}
+			if iv, err := c.region.ParsePercentage(cell(row, "implied volatility")); parse.OK("options.implied_volatility", err) {
+				contract.ImpliedVolatility = iv
+			}
+
+			rows.Add(contract)
+		})
+	})
+
+	if err := col.Visit(c.region.URL(path)); err != nil {
+		return nil, fmt.Errorf("failed to scrape options list: %v", err)
+	}
+	if err := col.wait(); err != nil {
+		return nil, err
+	}
+
+	return rows.Rows(), nil
+}
+
+// parseOCCSymbol fills in fields the table left blank from the contract
+// symbol itself.
+func parseOCCSymbol(contract *OptionContract) {
+	m := occSymbol.FindStringSubmatch(contract.Contract)
+	if m == nil {
+		return
+	}
+
+	if contract.Underlying == "" {
+		contract.Underlying = m[1]
+	}
+	if contract.Expiry == "" {
+		if expiry, err := time.Parse("060102", m[2]); err == nil {
+			contract.Expiry = expiry.Format("2006-01-02")
+		}
+	}
+	contract.Type = "call"
+	if m[3] == "P" {
+		contract.Type = "put"
+	}
+	if strike, err := strconv.ParseInt(m[4], 10, 64); err == nil {
+		contract.Strike = float64(strike) / 1000
+	}
+}
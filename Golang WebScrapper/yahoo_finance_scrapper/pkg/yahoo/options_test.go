@@ -0,0 +1,59 @@
+package yahoo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseOCCSymbol(t *testing.T) {
+	tests := []struct {
+		name     string
+		contract OptionContract
+		want     OptionContract
+	}{
+		{
+			name:     "Call",
+			contract: OptionContract{Contract: "AAPL250117C00200000"},
+			want:     OptionContract{Contract: "AAPL250117C00200000", Underlying: "AAPL", Expiry: "2025-01-17", Type: "call", Strike: 200},
+		},
+		{
+			name:     "Put With Fractional Strike",
+			contract: OptionContract{Contract: "SPY261120P00552500"},
+			want:     OptionContract{Contract: "SPY261120P00552500", Underlying: "SPY", Expiry: "2026-11-20", Type: "put", Strike: 552.5},
+		},
+		{
+			name:     "Dotted Underlying",
+			contract: OptionContract{Contract: "BRK.B261218C00480000"},
+			want:     OptionContract{Contract: "BRK.B261218C00480000", Underlying: "BRK.B", Expiry: "2026-12-18", Type: "call", Strike: 480},
+		},
+		{
+			name:     "Table Values Kept",
+			contract: OptionContract{Contract: "SPXW261016C05800000", Underlying: "^SPX", Expiry: "2026-10-16"},
+			want:     OptionContract{Contract: "SPXW261016C05800000", Underlying: "^SPX", Expiry: "2026-10-16", Type: "call", Strike: 5800},
+		},
+		{
+			name:     "Invalid Expiry",
+			contract: OptionContract{Contract: "AAPL251399C00200000"},
+			want:     OptionContract{Contract: "AAPL251399C00200000", Underlying: "AAPL", Type: "call", Strike: 200},
+		},
+		{
+			name:     "Not An OCC Symbol",
+			contract: OptionContract{Contract: "AAPL Dec 18 250 Call", Strike: 250},
+			want:     OptionContract{Contract: "AAPL Dec 18 250 Call", Strike: 250},
+		},
+		{
+			name:     "Lowercase",
+			contract: OptionContract{Contract: "aapl250117c00200000"},
+			want:     OptionContract{Contract: "aapl250117c00200000"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			contract := tt.contract
+			parseOCCSymbol(&contract)
+			assert.Equal(t, tt.want, contract)
+		})
+	}
+}
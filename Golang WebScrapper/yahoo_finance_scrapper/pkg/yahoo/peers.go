@@ -0,0 +1,140 @@
+package yahoo
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gocolly/colly"
+)
+
+// PeerQuote is one row of a side-by-side peer comparison.
+type PeerQuote = Quote
+
+// PeerGroup is a symbol and the industry peers it was compared against.
+// The symbol itself is always the first quote.
+type PeerGroup struct {
+	Symbol    string      `json:"symbol"`
+	Sector    string      `json:"sector"`
+	Industry  string      `json:"industry"`
+	Quotes    []PeerQuote `json:"quotes"`
+	Timestamp string      `json:"timestamp"`
+}
+
+// /sectors/<sector>/ and /sectors/<sector>/<industry>/ on the profile page
+var sectorPath = regexp.MustCompile(`/sectors/([a-z-]+)(?:/([a-z-]+))?/?$`)
+
+var quotePath = regexp.MustCompile(`/quote/([A-Za-z0-9.\-^=]{1,12})/?$`)
+
+// discoverIndustry reads the sector and industry links from symbol's
+// profile page, returning the industry page URL.
+func (c *Client) discoverIndustry(ctx context.Context, symbol string, group *PeerGroup) (string, error) {
+	var industryURL string
+	var mu sync.Mutex
+
+	col := c.clone(ctx)
+	col.OnHTML("a[href*='/sectors/']", func(e *colly.HTMLElement) {
+		link := e.Request.AbsoluteURL(e.Attr("href"))
+		m := sectorPath.FindStringSubmatch(link)
+		if m == nil {
+			return
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		if m[2] == "" && group.Sector == "" {
+			group.Sector = strings.TrimSpace(e.Text)
+		}
+		if m[2] != "" && industryURL == "" {
+			group.Industry = strings.TrimSpace(e.Text)
+			industryURL = link
+		}
+	})
+
+	if err := col.Visit(c.region.URL("/quote/" + symbol + "/profile/")); err != nil {
+		return "", fmt.Errorf("failed to scrape profile for %s: %v", symbol, err)
+	}
+	if err := col.wait(); err != nil {
+		return "", err
+	}
+
+	return industryURL, nil
+}
+
+// industrySymbols lists the companies on an industry page in page order.
+func (c *Client) industrySymbols(ctx context.Context, industryURL string) ([]string, error) {
+	symbols := make([]string, 0)
+	seen := make(map[string]bool)
+	var mu sync.Mutex
+
+	col := c.clone(ctx)
+	col.OnHTML("table tbody tr a[href*='/quote/']", func(e *colly.HTMLElement) {
+		m := quotePath.FindStringSubmatch(e.Attr("href"))
+		if m == nil {
+			return
+		}
+		symbol := strings.ToUpper(m[1])
+
+		mu.Lock()
+		if !seen[symbol] {
+			seen[symbol] = true
+			symbols = append(symbols, symbol)
+		}
+		mu.Unlock()
+	})
+
+	if err := col.Visit(industryURL); err != nil {
+		return nil, fmt.Errorf("failed to scrape industry %s: %v", industryURL, err)
+	}
+	if err := col.wait(); err != nil {
+		return nil, err
+	}
+
+	return symbols, nil
+}
+
+// Peers discovers symbol's industry from its profile and compares it with
+// up to limit companies from the industry page, returning nil if the
+// profile names no industry.
+func (c *Client) Peers(ctx context.Context, symbol string, limit int) (*PeerGroup, error) {
+	if !ValidSymbol(symbol) {
+		return nil, fmt.Errorf("invalid symbol: %s", symbol)
+	}
+	symbol = strings.ToUpper(symbol)
+
+	group := &PeerGroup{
+		Symbol:    symbol,
+		Timestamp: time.Now().Format(time.RFC3339),
+	}
+	industryURL, err := c.discoverIndustry(ctx, symbol, group)
+	if err != nil {
+		return nil, err
+	}
+	if industryURL == "" {
+		return nil, nil
+	}
+
+	candidates, err := c.industrySymbols(ctx, industryURL)
+	if err != nil {
+		return nil, err
+	}
+
+	symbols := []string{symbol}
+	for _, candidate := range candidates {
+		if len(symbols) > limit {
+			break
+		}
+		if candidate != symbol {
+			symbols = append(symbols, candidate)
+		}
+	}
+
+	group.Quotes, err = c.Quotes(ctx, symbols)
+	if err != nil {
+		return nil, err
+	}
+	return group, nil
+}
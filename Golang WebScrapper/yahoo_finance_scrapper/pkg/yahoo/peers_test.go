@@ -0,0 +1,17 @@
+package yahoo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSectorPath(t *testing.T) {
+	m := sectorPath.FindStringSubmatch("https://finance.yahoo.com/sectors/technology/software-infrastructure/")
+	assert.Equal(t, []string{"technology", "software-infrastructure"}, m[1:])
+
+	m = sectorPath.FindStringSubmatch("https://finance.yahoo.com/sectors/technology")
+	assert.Equal(t, []string{"technology", ""}, m[1:])
+
+	assert.Equal(t, "ORCL", quotePath.FindStringSubmatch("/quote/ORCL/")[1])
+}
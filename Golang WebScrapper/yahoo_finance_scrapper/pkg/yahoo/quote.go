@@ -0,0 +1,161 @@
+package yahoo
+
+import (
+	"context"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+
+	"go-webscraper/pkg/parse"
+
+	"github.com/gocolly/colly"
+)
+
+// Quote is one symbol's price and headline statistics from its quote page.
+type Quote struct {
+	Symbol        string  `json:"symbol"`
+	Name          string  `json:"name"`
+	Price         float64 `json:"price"`
+	Change        float64 `json:"change"`
+	ChangePerc    float64 `json:"change_percentage"`
+	Currency      string  `json:"currency,omitempty"`
+	Volume        int64   `json:"volume,omitempty"`
+	MarketCap     string  `json:"market_cap"`
+	PERatio       float64 `json:"pe_ratio,omitempty"`
+	EPS           float64 `json:"eps,omitempty"`
+	Beta          float64 `json:"beta,omitempty"`
+	DividendYield float64 `json:"dividend_yield,omitempty"`
+}
+
+// QuoteCurrency matches the currency a quote page header names, as in
+// "Currency in USD" or "Nasdaq Real Time Price • USD".
+var QuoteCurrency = regexp.MustCompile(`(?:Currency in|•)\s*([A-Z]{3})\b`)
+
+// quoteHeader matches the spans of a quote page header.
+const quoteHeader = "div[data-testid='quote-hdr'] span, div#quote-header-info span"
+
+// QuoteSummaryRow matches a label/value pair in a quote page's summary,
+// both the older table layout and the newer statistics list.
+const QuoteSummaryRow = "div#quote-summary tr, div[data-testid='quote-statistics'] li"
+
+func QuoteSummaryPair(e *colly.HTMLElement) (string, string) {
+	return strings.TrimSpace(e.ChildText("td:first-child, span.label")),
+		strings.TrimSpace(e.ChildText("td:nth-child(2), span.value"))
+}
+
+// ForwardDividend matches "0.96 (0.52%)" from the Forward Dividend & Yield
+// row, and "0,96 (0,52 %)" from regional pages.
+var ForwardDividend = regexp.MustCompile(`^([\d.,]+)\s*\(([\d.,]+)[\s\x{a0}\x{202f}]*%\)`)
+
+// parseSummary fills quote from one quote summary label/value pair, with
+// numbers written in region's format.
+func parseSummary(region Region, quote *Quote, label, value string) {
+	switch {
+	case strings.HasPrefix(label, "Market Cap"):
+		quote.MarketCap = value
+	case strings.HasPrefix(label, "PE Ratio"):
+		if v, err := region.ParseFloat(value); parse.OK("quote.pe_ratio", err) {
+			quote.PERatio = v
+		}
+	case strings.HasPrefix(label, "EPS"):
+		if v, err := region.ParseFloat(value); parse.OK("quote.eps", err) {
+			quote.EPS = v
+		}
+	case strings.HasPrefix(label, "Beta"):
+		if v, err := region.ParseFloat(value); parse.OK("quote.beta", err) {
+			quote.Beta = v
+		}
+	case label == "Forward Dividend & Yield":
+		if m := ForwardDividend.FindStringSubmatch(value); m != nil {
+			if v, err := region.ParsePercentage(m[2]); parse.OK("quote.dividend_yield", err) {
+				quote.DividendYield = v
+			}
+		}
+	}
+}
+
+// Quotes fetches every symbol's quote page in one batch of parallel
+// requests, returning them in the order given. Symbols whose page didn't
+// load come back with only Symbol set, and Currency is empty when the
+// page header doesn't name one.
+func (c *Client) Quotes(ctx context.Context, symbols []string) ([]Quote, error) {
+	quotes := make(map[string]*Quote, len(symbols))
+	for _, symbol := range symbols {
+		quotes[symbol] = &Quote{Symbol: symbol}
+	}
+	var mu sync.Mutex
+
+	col := c.clone(ctx)
+	col.OnHTML("h1", func(e *colly.HTMLElement) {
+		mu.Lock()
+		defer mu.Unlock()
+		if quote := quotes[e.Request.Ctx.Get("symbol")]; quote != nil && quote.Name == "" {
+			quote.Name = strings.TrimSpace(e.Text)
+		}
+	})
+	col.OnHTML(quoteHeader, func(e *colly.HTMLElement) {
+		m := QuoteCurrency.FindStringSubmatch(e.Text)
+		if m == nil {
+			return
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		if quote := quotes[e.Request.Ctx.Get("symbol")]; quote != nil && quote.Currency == "" {
+			quote.Currency = m[1]
+		}
+	})
+	col.OnHTML("fin-streamer[data-field]", func(e *colly.HTMLElement) {
+		symbol := e.Request.Ctx.Get("symbol")
+		if !strings.EqualFold(e.Attr("data-symbol"), symbol) {
+			return
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		quote := quotes[symbol]
+		switch e.Attr("data-field") {
+		case "regularMarketPrice":
+			if v, err := c.region.ParseFloat(e.Text); parse.OK("quote.price", err) {
+				quote.Price = v
+			}
+		case "regularMarketChange":
+			if v, err := c.region.ParseFloat(e.Text); parse.OK("quote.change", err) {
+				quote.Change = v
+			}
+		case "regularMarketChangePercent":
+			if v, err := c.region.ParsePercentage(e.Text); parse.OK("quote.change_percent", err) {
+				quote.ChangePerc = v
+			}
+		case "regularMarketVolume":
+			if v, err := c.region.ParseInt(e.Text); parse.OK("quote.volume", err) {
+				quote.Volume = v
+			}
+		}
+	})
+	col.OnHTML(QuoteSummaryRow, func(e *colly.HTMLElement) {
+		label, value := QuoteSummaryPair(e)
+
+		mu.Lock()
+		defer mu.Unlock()
+		if quote := quotes[e.Request.Ctx.Get("symbol")]; quote != nil {
+			parseSummary(c.region, quote, label, value)
+		}
+	})
+
+	for _, symbol := range symbols {
+		reqCtx := colly.NewContext()
+		reqCtx.Put("symbol", symbol)
+		col.Request(http.MethodGet, c.region.URL("/quote/"+symbol+"/"), nil, reqCtx, nil)
+	}
+	if err := col.waitPartial(); err != nil {
+		return nil, err
+	}
+
+	ordered := make([]Quote, 0, len(symbols))
+	for _, symbol := range symbols {
+		ordered = append(ordered, *quotes[symbol])
+	}
+	return ordered, nil
+}
@@ -0,0 +1,45 @@
+package yahoo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSummary(t *testing.T) {
+	quote := &Quote{Symbol: "MSFT"}
+	parseSummary(Regions["us"], quote, "Market Cap (intraday)", "3.09T")
+	parseSummary(Regions["us"], quote, "PE Ratio (TTM)", "35.42")
+	parseSummary(Regions["us"], quote, "EPS (TTM)", "11.80")
+	parseSummary(Regions["us"], quote, "Beta (5Y Monthly)", "0.90")
+	parseSummary(Regions["us"], quote, "Forward Dividend & Yield", "3.32 (0.79%)")
+	parseSummary(Regions["us"], quote, "Volume", "18,224,315")
+
+	assert.Equal(t, Quote{
+		Symbol:        "MSFT",
+		MarketCap:     "3.09T",
+		PERatio:       35.42,
+		EPS:           11.80,
+		Beta:          0.90,
+		DividendYield: 0.79,
+	}, *quote)
+}
+
+func TestParseSummaryRegional(t *testing.T) {
+	quote := &Quote{Symbol: "SAP.DE"}
+	parseSummary(Regions["de"], quote, "PE Ratio (TTM)", "12,34")
+	parseSummary(Regions["de"], quote, "EPS (TTM)", "1.234,50")
+	parseSummary(Regions["de"], quote, "Beta (5Y Monthly)", "0,90")
+	parseSummary(Regions["de"], quote, "Forward Dividend & Yield", "2,20 (1,05 %)")
+
+	assert.Equal(t, 12.34, quote.PERatio)
+	assert.Equal(t, 1234.5, quote.EPS)
+	assert.Equal(t, 0.90, quote.Beta)
+	assert.Equal(t, 1.05, quote.DividendYield)
+}
+
+func TestQuoteCurrency(t *testing.T) {
+	assert.Equal(t, "EUR", QuoteCurrency.FindStringSubmatch("XETRA - XETRA Delayed Price. Currency in EUR")[1])
+	assert.Equal(t, "USD", QuoteCurrency.FindStringSubmatch("NasdaqGS - Nasdaq Real Time Price • USD")[1])
+	assert.Nil(t, QuoteCurrency.FindStringSubmatch("At close: 4:00 PM EDT"))
+}
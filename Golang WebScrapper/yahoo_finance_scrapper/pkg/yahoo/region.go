@@ -0,0 +1,88 @@
+package yahoo
+
+import (
+	"fmt"
+	"strings"
+
+	"go-webscraper/pkg/parse"
+)
+
+type Region struct {
+	Code         string `json:"code"`
+	Host         string `json:"host"`
+	Currency     string `json:"currency"`
+	DecimalSep   string `json:"decimal_separator"`
+	ThousandsSep string `json:"thousands_separator"`
+}
+
+const DefaultRegion = "us"
+
+// Regions are the Yahoo Finance sites that share finance.yahoo.com's
+// markup and paths, so Rewrite only has to swap the host. French pages
+// group digits with a narrow no-break space, or a no-break space in some
+// tables; parsing drops every kind of space either way.
+var Regions = map[string]Region{
+	"us": {Code: "us", Host: "finance.yahoo.com", Currency: "USD", DecimalSep: ".", ThousandsSep: ","},
+	"uk": {Code: "uk", Host: "uk.finance.yahoo.com", Currency: "GBP", DecimalSep: ".", ThousandsSep: ","},
+	"ca": {Code: "ca", Host: "ca.finance.yahoo.com", Currency: "CAD", DecimalSep: ".", ThousandsSep: ","},
+	"au": {Code: "au", Host: "au.finance.yahoo.com", Currency: "AUD", DecimalSep: ".", ThousandsSep: ","},
+	"sg": {Code: "sg", Host: "sg.finance.yahoo.com", Currency: "SGD", DecimalSep: ".", ThousandsSep: ","},
+	"in": {Code: "in", Host: "in.finance.yahoo.com", Currency: "INR", DecimalSep: ".", ThousandsSep: ","},
+	"de": {Code: "de", Host: "de.finance.yahoo.com", Currency: "EUR", DecimalSep: ",", ThousandsSep: "."},
+	"fr": {Code: "fr", Host: "fr.finance.yahoo.com", Currency: "EUR", DecimalSep: ",", ThousandsSep: "\u202f"},
+	"es": {Code: "es", Host: "es.finance.yahoo.com", Currency: "EUR", DecimalSep: ",", ThousandsSep: "."},
+	"it": {Code: "it", Host: "it.finance.yahoo.com", Currency: "EUR", DecimalSep: ",", ThousandsSep: "."},
+}
+
+func LookupRegion(code string) (Region, error) {
+	if code == "" {
+		code = DefaultRegion
+	}
+	region, exists := Regions[strings.ToLower(code)]
+	if !exists {
+		return Region{}, fmt.Errorf("invalid region: %s", code)
+	}
+	return region, nil
+}
+
+func (r Region) BaseURL() string {
+	return "https://" + r.Host
+}
+
+func (r Region) URL(path string) string {
+	return r.BaseURL() + path
+}
+
+// Rewrite moves an absolute finance.yahoo.com URL onto the region's host.
+func (r Region) Rewrite(url string) string {
+	return strings.Replace(url, "https://finance.yahoo.com", r.BaseURL(), 1)
+}
+
+// CacheKey leaves keys for the default region untouched so existing cache
+// entries stay valid, and suffixes the region code otherwise.
+func (r Region) CacheKey(key string) string {
+	if r.Code == "" || r.Code == DefaultRegion {
+		return key
+	}
+	return key + ":" + r.Code
+}
+
+func (r Region) format() parse.Format {
+	return parse.Format{DecimalSep: r.DecimalSep, ThousandsSep: r.ThousandsSep}
+}
+
+// ParseFloat reads a number written in the region's format, with the
+// suffixes and placeholders parse.Format.Float accepts.
+func (r Region) ParseFloat(s string) (float64, error) {
+	return r.format().Float(s)
+}
+
+func (r Region) ParseInt(s string) (int64, error) {
+	return r.format().Int(s)
+}
+
+// ParsePercentage reads a percentage such as "-0.8%" or "(+1.2%)", in
+// percent.
+func (r Region) ParsePercentage(s string) (float64, error) {
+	return r.format().Float(s)
+}
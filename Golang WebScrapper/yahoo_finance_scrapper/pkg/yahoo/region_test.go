@@ -0,0 +1,55 @@
+package yahoo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegionalNumbers(t *testing.T) {
+	cases := []struct {
+		region string
+		text   string
+		want   float64
+	}{
+		{"us", "1,234.56", 1234.56},
+		{"uk", "-12.5%", -12.5},
+		{"in", "2,345.10", 2345.1},
+		{"de", "1.234,56", 1234.56},
+		{"de", "-0,84%", -0.84},
+		{"es", "12.345.678", 12345678},
+		{"it", "3,2M", 3.2e6},
+		{"fr", "1\u202f234,56", 1234.56},
+		{"fr", "1\u00a0234,56", 1234.56},
+		{"fr", "12\u202f345\u202f678", 12345678},
+		{"fr", "+1,25\u00a0%", 1.25},
+	}
+	for _, tc := range cases {
+		region, err := LookupRegion(tc.region)
+		assert.NoError(t, err)
+		got, err := region.ParseFloat(tc.text)
+		assert.NoError(t, err, "%s %q", tc.region, tc.text)
+		assert.InDelta(t, tc.want, got, 1e-9, "%s %q", tc.region, tc.text)
+	}
+
+	de := Regions["de"]
+	volume, err := de.ParseInt("1.204.337")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1204337), volume)
+}
+
+func TestLookupRegion(t *testing.T) {
+	region, err := LookupRegion("")
+	assert.NoError(t, err)
+	assert.Equal(t, DefaultRegion, region.Code)
+
+	region, err = LookupRegion("DE")
+	assert.NoError(t, err)
+	assert.Equal(t, "https://de.finance.yahoo.com/quote/SAP.DE", region.Rewrite("https://finance.yahoo.com/quote/SAP.DE"))
+	assert.Equal(t, "sector:technology:de", region.CacheKey("sector:technology"))
+	assert.Equal(t, "sector:technology", Regions["us"].CacheKey("sector:technology"))
+
+	// finance.yahoo.co.jp is a different site, not a host swap
+	_, err = LookupRegion("jp")
+	assert.Error(t, err)
+}
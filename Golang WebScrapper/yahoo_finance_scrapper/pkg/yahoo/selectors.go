@@ -0,0 +1,114 @@
+package yahoo
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"go-webscraper/metrics"
+
+	"github.com/gocolly/colly"
+)
+
+// defaultSelectors lists the candidate CSS selectors for each parsed field,
+// keyed by "<table>.<field>". The first entry is the primary; the rest are
+// fallbacks for markup Yahoo has served before or is rolling out.
+var defaultSelectors = map[string][]string{
+	"quote_table.symbol":         {"td:nth-child(1)", "td[aria-label='Symbol']"},
+	"quote_table.name":           {"td:nth-child(2)", "td[aria-label='Name']"},
+	"quote_table.price":          {"td:nth-child(3) fin-streamer", "fin-streamer[data-field='regularMarketPrice']"},
+	"quote_table.change":         {"td:nth-child(4) fin-streamer", "fin-streamer[data-field='regularMarketChange']"},
+	"quote_table.change_percent": {"td:nth-child(5) fin-streamer", "fin-streamer[data-field='regularMarketChangePercent']"},
+	"quote_table.volume":         {"td:nth-child(6) fin-streamer", "fin-streamer[data-field='regularMarketVolume']"},
+	"quote_table.market_cap":     {"td:nth-child(7) fin-streamer", "fin-streamer[data-field='marketCap']"},
+	"quote_table.currency":       {"td[aria-label='Currency']", "fin-streamer[data-field='currency']"},
+
+	"calendar.event":        {"td[aria-label='Event']", "td:nth-child(1)"},
+	"calendar.country":      {"td[aria-label='Country']", "td:nth-child(2)"},
+	"calendar.release_time": {"td[aria-label='Event Time']"},
+	"calendar.period":       {"td[aria-label='For']"},
+	"calendar.actual":       {"td[aria-label='Actual']"},
+	"calendar.forecast":     {"td[aria-label='Market Expectation']"},
+	"calendar.previous":     {"td[aria-label='Prior to This']"},
+	"calendar.revised":      {"td[aria-label='Revised from']"},
+}
+
+var (
+	selectorMutex   sync.RWMutex
+	selectors       = defaultSelectors
+	selectorVersion = hashSelectors(defaultSelectors)
+)
+
+// hashSelectors fingerprints a selector set, so results can be traced to
+// the configuration that parsed them.
+func hashSelectors(set map[string][]string) string {
+	fields := make([]string, 0, len(set))
+	for field := range set {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	h := sha256.New()
+	for _, field := range fields {
+		fmt.Fprintf(h, "%s=%s\n", field, strings.Join(set[field], "|"))
+	}
+	return hex.EncodeToString(h.Sum(nil))[:12]
+}
+
+// SelectorVersion identifies the selector set currently in use.
+func SelectorVersion() string {
+	selectorMutex.RLock()
+	defer selectorMutex.RUnlock()
+	return selectorVersion
+}
+
+// ConfigureSelectors replaces the candidate chain for the given fields,
+// keyed by table then field as in the selectors config section. Fields not
+// mentioned keep their defaults.
+func ConfigureSelectors(overrides map[string]map[string][]string) error {
+	merged := make(map[string][]string, len(defaultSelectors))
+	for field, chain := range defaultSelectors {
+		merged[field] = chain
+	}
+
+	for table, fields := range overrides {
+		for name, chain := range fields {
+			field := table + "." + name
+			if _, exists := defaultSelectors[field]; !exists {
+				return fmt.Errorf("unknown selector field: %s", field)
+			}
+			if len(chain) == 0 {
+				return fmt.Errorf("selector field %s needs at least one selector", field)
+			}
+			merged[field] = chain
+		}
+	}
+
+	selectorMutex.Lock()
+	selectors = merged
+	selectorVersion = hashSelectors(merged)
+	selectorMutex.Unlock()
+	return nil
+}
+
+// SelectText returns the trimmed text of the first candidate selector for
+// field that matches inside e, counting which rank matched so a primary
+// selector that stops matching shows up in metrics before data goes dark.
+func SelectText(e *colly.HTMLElement, field string) string {
+	selectorMutex.RLock()
+	chain := selectors[field]
+	selectorMutex.RUnlock()
+
+	for rank, selector := range chain {
+		if text := strings.TrimSpace(e.ChildText(selector)); text != "" {
+			metrics.SelectorMatches.WithLabelValues(field, strconv.Itoa(rank)).Inc()
+			return text
+		}
+	}
+	metrics.SelectorMatches.WithLabelValues(field, "miss").Inc()
+	return ""
+}
@@ -0,0 +1,65 @@
+package yahoo
+
+import (
+	"strings"
+	"testing"
+
+	"go-webscraper/metrics"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/gocolly/colly"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func rowElement(t *testing.T, html string) *colly.HTMLElement {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader("<table><tbody>" + html + "</tbody></table>"))
+	assert.NoError(t, err)
+	row := doc.Find("tr").First()
+	return colly.NewHTMLElementFromSelectionNode(&colly.Response{Request: &colly.Request{}}, row, row.Nodes[0], 0)
+}
+
+func TestSelectText(t *testing.T) {
+	defer ConfigureSelectors(nil)
+
+	t.Run("Primary", func(t *testing.T) {
+		e := rowElement(t, "<tr><td>AAPL</td><td>Apple Inc.</td></tr>")
+		before := testutil.ToFloat64(metrics.SelectorMatches.WithLabelValues("quote_table.symbol", "0"))
+
+		assert.Equal(t, "AAPL", SelectText(e, "quote_table.symbol"))
+		assert.Equal(t, before+1, testutil.ToFloat64(metrics.SelectorMatches.WithLabelValues("quote_table.symbol", "0")))
+	})
+
+	t.Run("Fallback", func(t *testing.T) {
+		e := rowElement(t, `<tr><td><fin-streamer data-field="regularMarketPrice">187.5</fin-streamer></td></tr>`)
+		before := testutil.ToFloat64(metrics.SelectorMatches.WithLabelValues("quote_table.price", "1"))
+
+		assert.Equal(t, "187.5", SelectText(e, "quote_table.price"))
+		assert.Equal(t, before+1, testutil.ToFloat64(metrics.SelectorMatches.WithLabelValues("quote_table.price", "1")))
+	})
+
+	t.Run("Miss", func(t *testing.T) {
+		e := rowElement(t, "<tr><td></td></tr>")
+		before := testutil.ToFloat64(metrics.SelectorMatches.WithLabelValues("quote_table.market_cap", "miss"))
+
+		assert.Equal(t, "", SelectText(e, "quote_table.market_cap"))
+		assert.Equal(t, before+1, testutil.ToFloat64(metrics.SelectorMatches.WithLabelValues("quote_table.market_cap", "miss")))
+	})
+
+	t.Run("Configured Override", func(t *testing.T) {
+		before := SelectorVersion()
+		assert.NoError(t, ConfigureSelectors(map[string]map[string][]string{
+			"quote_table": {"symbol": {"td.ticker"}},
+		}))
+		assert.NotEqual(t, before, SelectorVersion())
+		e := rowElement(t, `<tr><td>ignored</td><td class="ticker">MSFT</td></tr>`)
+		assert.Equal(t, "MSFT", SelectText(e, "quote_table.symbol"))
+	})
+}
+
+func TestConfigureSelectorsRejectsUnknownFields(t *testing.T) {
+	defer ConfigureSelectors(nil)
+
+	assert.Error(t, ConfigureSelectors(map[string]map[string][]string{"quote_table": {"ticker": {"td"}}}))
+	assert.Error(t, ConfigureSelectors(map[string]map[string][]string{"calendar": {"event": {}}}))
+}
@@ -0,0 +1,150 @@
+package yahoo
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"go-webscraper/model"
+	"go-webscraper/pkg/collect"
+	"go-webscraper/pkg/parse"
+
+	"github.com/gocolly/colly"
+)
+
+// ShortInterest is the short position reported on a symbol's statistics
+// page. DaysToCover is Yahoo's "Short Ratio": shares short over average
+// daily volume.
+type ShortInterest struct {
+	Symbol                  string  `json:"symbol"`
+	SharesShort             int64   `json:"shares_short"`
+	SharesShortPriorMonth   int64   `json:"shares_short_prior_month"`
+	DaysToCover             float64 `json:"days_to_cover"`
+	ShortPercentFloat       float64 `json:"short_percent_float"`
+	ShortPercentOutstanding float64 `json:"short_percent_outstanding"`
+	AsOf                    string  `json:"as_of,omitempty"`
+	Timestamp               string  `json:"timestamp"`
+}
+
+// Statistics labels carry the settlement date and a footnote number,
+// e.g. "Shares Short (9/30/2024) 4".
+var statisticsLabel = regexp.MustCompile(`^(.*?)\s*(?:\(([^)]*)\))?\s*\d*$`)
+
+// parseShortInterestRow fills short from one statistics label/value row,
+// with numbers written in region's format.
+func parseShortInterestRow(region Region, short *ShortInterest, label, value string) {
+	m := statisticsLabel.FindStringSubmatch(strings.TrimSpace(label))
+	if m == nil {
+		return
+	}
+	name, date := m[1], m[2]
+
+	switch {
+	case name == "Shares Short" && strings.HasPrefix(date, "prior month"):
+		if v, err := region.ParseInt(value); parse.OK("short_interest.shares_short_prior_month", err) {
+			short.SharesShortPriorMonth = v
+		}
+	case name == "Shares Short":
+		if v, err := region.ParseInt(value); parse.OK("short_interest.shares_short", err) {
+			short.SharesShort = v
+		}
+		if date != "" {
+			short.AsOf = date
+		}
+	case name == "Short Ratio":
+		if v, err := region.ParseFloat(value); parse.OK("short_interest.days_to_cover", err) {
+			short.DaysToCover = v
+		}
+	case name == "Short % of Float":
+		if v, err := region.ParsePercentage(value); parse.OK("short_interest.short_percent_float", err) {
+			short.ShortPercentFloat = v
+		}
+	case name == "Short % of Shares Outstanding":
+		if v, err := region.ParsePercentage(value); parse.OK("short_interest.short_percent_outstanding", err) {
+			short.ShortPercentOutstanding = v
+		}
+	}
+}
+
+// ShortInterest reads the short interest block of symbol's key-statistics
+// page, returning nil if the page reports none.
+func (c *Client) ShortInterest(ctx context.Context, symbol string) (*ShortInterest, error) {
+	if !ValidSymbol(symbol) {
+		return nil, fmt.Errorf("invalid symbol: %s", symbol)
+	}
+	symbol = strings.ToUpper(symbol)
+
+	short := &ShortInterest{
+		Symbol:    symbol,
+		Timestamp: time.Now().Format(time.RFC3339),
+	}
+	found := false
+	var mu sync.Mutex
+
+	col := c.clone(ctx)
+	col.OnHTML("table tr", func(e *colly.HTMLElement) {
+		label := strings.TrimSpace(e.ChildText("td:first-child"))
+		if !strings.HasPrefix(label, "Short") && !strings.HasPrefix(label, "Shares Short") {
+			return
+		}
+
+		mu.Lock()
+		parseShortInterestRow(c.region, short, label, e.ChildText("td:nth-child(2)"))
+		found = true
+		mu.Unlock()
+	})
+
+	if err := col.Visit(c.region.URL("/quote/" + symbol + "/key-statistics/")); err != nil {
+		return nil, fmt.Errorf("failed to scrape short interest for %s: %v", symbol, err)
+	}
+	if err := col.wait(); err != nil {
+		return nil, err
+	}
+
+	if !found {
+		return nil, nil
+	}
+	return short, nil
+}
+
+// MostShorted scrapes Yahoo's predefined most-shorted screener.
+func (c *Client) MostShorted(ctx context.Context) ([]model.StockData, error) {
+	var rows collect.Rows[model.StockData]
+
+	col := c.clone(ctx)
+	col.OnHTML("table tbody tr", func(e *colly.HTMLElement) {
+		stock := model.StockData{
+			Symbol:    SelectText(e, "quote_table.symbol"),
+			Name:      SelectText(e, "quote_table.name"),
+			MarketCap: SelectText(e, "quote_table.market_cap"),
+			Currency:  RowCurrency(e),
+			Timestamp: time.Now().Format(time.RFC3339),
+		}
+		if stock.Symbol == "" {
+			return
+		}
+		if price, err := c.region.ParseFloat(SelectText(e, "quote_table.price")); parse.OK("quote_table.price", err) {
+			stock.Price = price
+		}
+		if change, err := c.region.ParseFloat(SelectText(e, "quote_table.change")); parse.OK("quote_table.change", err) {
+			stock.Change = change
+		}
+		if changePerc, err := c.region.ParsePercentage(SelectText(e, "quote_table.change_percent")); parse.OK("quote_table.change_percent", err) {
+			stock.ChangePerc = changePerc
+		}
+
+		rows.Add(stock)
+	})
+
+	if err := col.Visit(c.region.URL("/screener/predefined/most_shorted_stocks/")); err != nil {
+		return nil, fmt.Errorf("failed to scrape most shorted stocks: %v", err)
+	}
+	if err := col.wait(); err != nil {
+		return nil, err
+	}
+
+	return rows.Rows(), nil
+}
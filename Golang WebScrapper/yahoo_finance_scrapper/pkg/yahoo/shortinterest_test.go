@@ -0,0 +1,29 @@
+package yahoo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseShortInterestRow(t *testing.T) {
+	short := &ShortInterest{Symbol: "GME"}
+	rows := [][2]string{
+		{"Shares Short (9/30/2024) 4", "29.3M"},
+		{"Short Ratio (9/30/2024) 4", "4.12"},
+		{"Short % of Float (9/30/2024) 4", "8.07%"},
+		{"Short % of Shares Outstanding (9/30/2024) 4", "6.81%"},
+		{"Shares Short (prior month 8/30/2024) 4", "31.1M"},
+		{"Float 8", "363.2M"},
+	}
+	for _, row := range rows {
+		parseShortInterestRow(Regions["us"], short, row[0], row[1])
+	}
+
+	assert.Equal(t, int64(29300000), short.SharesShort)
+	assert.Equal(t, int64(31100000), short.SharesShortPriorMonth)
+	assert.Equal(t, 4.12, short.DaysToCover)
+	assert.Equal(t, 8.07, short.ShortPercentFloat)
+	assert.Equal(t, 6.81, short.ShortPercentOutstanding)
+	assert.Equal(t, "9/30/2024", short.AsOf)
+}
@@ -0,0 +1,69 @@
+package yahoo
+
+import (
+	"context"
+	"time"
+
+	"github.com/gocolly/colly"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	spanCtxKey  = "otel_span"
+	startCtxKey = "fetch_start"
+)
+
+var tracer = otel.Tracer("go-webscraper/pkg/yahoo")
+
+// TraceCollector records a span per page visit under ctx, and the visit
+// itself in ctx's FetchLog if it carries one. It also makes the visits
+// conditional when ctx carries a Conditional. Callbacks aren't carried
+// over by Clone, so call it on every cloned collector too.
+func TraceCollector(ctx context.Context, c *colly.Collector) {
+	cond := conditionalFrom(ctx)
+
+	c.OnRequest(func(r *colly.Request) {
+		if cond != nil {
+			cond.request(r)
+		}
+		_, span := tracer.Start(ctx, "colly.visit",
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(
+				attribute.String("http.request.method", r.Method),
+				attribute.String("url.full", r.URL.String()),
+			),
+		)
+		r.Ctx.Put(spanCtxKey, span)
+		r.Ctx.Put(startCtxKey, time.Now())
+	})
+
+	c.OnResponse(func(r *colly.Response) {
+		if span, ok := r.Ctx.GetAny(spanCtxKey).(trace.Span); ok {
+			span.SetAttributes(
+				attribute.Int("http.response.status_code", r.StatusCode),
+				attribute.Int("http.response.body.size", len(r.Body)),
+			)
+			span.End()
+		}
+		recordFetch(ctx, r)
+		if cond != nil {
+			cond.response(r)
+		}
+	})
+
+	c.OnError(func(r *colly.Response, err error) {
+		if span, ok := r.Ctx.GetAny(spanCtxKey).(trace.Span); ok {
+			span.SetAttributes(attribute.Int("http.response.status_code", r.StatusCode))
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+			span.End()
+		}
+		recordFetch(ctx, r)
+		if cond != nil {
+			cond.response(r)
+		}
+	})
+}
@@ -0,0 +1,334 @@
+// Package yahoo scrapes Yahoo Finance pages into typed results. It has no
+// HTTP server, cache or queue dependencies, so other binaries can use it
+// directly; the scraper package layers caching and pooling on top.
+package yahoo
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"go-webscraper/model"
+	"go-webscraper/pkg/collect"
+	"go-webscraper/pkg/parse"
+	"go-webscraper/upstream"
+
+	"github.com/gocolly/colly"
+)
+
+type Option struct {
+	Region      string
+	Parallelism int
+	// Delay and RandomDelay space out requests to the same host.
+	Delay       time.Duration
+	RandomDelay time.Duration
+}
+
+// Client scrapes one regional Yahoo Finance site. It is safe for
+// concurrent use; every call works on its own clone of the collector.
+type Client struct {
+	region    Region
+	collector *colly.Collector
+}
+
+func New(opts Option) (*Client, error) {
+	region, err := LookupRegion(opts.Region)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Parallelism == 0 {
+		opts.Parallelism = 20
+	}
+
+	c := upstream.NewCollector(
+		colly.AllowedDomains(region.Host),
+		colly.MaxDepth(1),
+		colly.Async(true),
+	)
+	c.Limit(&colly.LimitRule{
+		DomainGlob:  "*",
+		Parallelism: opts.Parallelism,
+		Delay:       opts.Delay,
+		RandomDelay: opts.RandomDelay,
+	})
+
+	return &Client{region: region, collector: c}, nil
+}
+
+func (c *Client) Region() Region {
+	return c.region
+}
+
+// Collector is the configured base collector, for scrapes the client has
+// no method for. Clone it before registering callbacks.
+func (c *Client) Collector() *colly.Collector {
+	return c.collector
+}
+
+// Clone returns a traced copy of base for one scrape, without base's
+// callbacks. colly clones share their parent's record of visited URLs, so
+// a long-lived scraper could otherwise never fetch a page twice; clones
+// allow revisits instead, and scrapes that follow links skip the ones
+// they've already followed themselves.
+func Clone(ctx context.Context, base *colly.Collector) *colly.Collector {
+	col := base.Clone()
+	col.AllowURLRevisit = true
+	TraceCollector(ctx, col)
+	return col
+}
+
+// scrape is a collector cloned for one call. Its requests run async, so
+// Visit can't report upstream failures such as a 5xx or a reset
+// connection; scrape keeps them for wait instead.
+type scrape struct {
+	*colly.Collector
+	ctx context.Context
+
+	mu     sync.Mutex
+	err    error
+	loaded int
+}
+
+// clone returns a collector for one scrape that stops issuing requests
+// once ctx is done.
+func (c *Client) clone(ctx context.Context) *scrape {
+	s := &scrape{Collector: Clone(ctx, c.collector), ctx: ctx}
+	s.OnRequest(func(r *colly.Request) {
+		if ctx.Err() != nil {
+			r.Abort()
+		}
+	})
+	s.OnError(func(r *colly.Response, err error) {
+		// A 304 answers a conditional request, which the caller checks,
+		// and a 404 is a page with nothing to scrape
+		if r.StatusCode == http.StatusNotModified || r.StatusCode == http.StatusNotFound {
+			return
+		}
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if s.err == nil {
+			s.err = fmt.Errorf("failed to fetch %s: %w", r.Request.URL, err)
+		}
+	})
+	s.OnScraped(func(*colly.Response) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.loaded++
+	})
+	return s
+}
+
+// wait waits for the requests made so far, returning ctx's error or else
+// the first upstream failure.
+func (s *scrape) wait() error {
+	s.Wait()
+	if err := s.ctx.Err(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+// waitPartial is wait for batches that return what loaded: an upstream
+// failure only counts when no page loaded at all.
+func (s *scrape) waitPartial() error {
+	err := s.wait()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err != nil && err != s.ctx.Err() && s.loaded > 0 {
+		return nil
+	}
+	return err
+}
+
+type moverPage struct {
+	path string
+	// table is the data-test of the page's quote table
+	table string
+}
+
+var moverPages = map[string]moverPage{
+	"most-active": {"/markets/stocks/most-active/", "most-actives"},
+	"most_active": {"/markets/stocks/most-active/", "most-actives"},
+	"gainers":     {"/markets/stocks/gainers/", "gainers"},
+	"losers":      {"/markets/stocks/losers/", "losers"},
+	"trending":    {"/markets/stocks/trending/", "trending-tickers"},
+}
+
+// MostActive scrapes the most active stocks table.
+func (c *Client) MostActive(ctx context.Context) ([]model.StockData, error) {
+	return c.Movers(ctx, "most-active")
+}
+
+// Movers scrapes one market movers page: most-active, most_active,
+// gainers, losers or trending.
+func (c *Client) Movers(ctx context.Context, page string) ([]model.StockData, error) {
+	movers, ok := moverPages[page]
+	if !ok {
+		return nil, fmt.Errorf("unknown movers page: %s", page)
+	}
+
+	var stocks collect.Rows[model.StockData]
+
+	col := c.clone(ctx)
+	col.OnHTML(fmt.Sprintf("table[data-test='%s'] tbody tr", movers.table), func(e *colly.HTMLElement) {
+		stocks.Add(c.quoteRow(e))
+	})
+
+	if err := col.Visit(c.region.URL(movers.path)); err != nil {
+		return nil, fmt.Errorf("failed to scrape %s: %v", page, err)
+	}
+	if err := col.wait(); err != nil {
+		return nil, err
+	}
+
+	return stocks.Rows(), nil
+}
+
+// quoteRow parses one row of a quote table through the configurable
+// selector chains.
+func (c *Client) quoteRow(e *colly.HTMLElement) model.StockData {
+	stock := model.StockData{
+		Symbol:    SelectText(e, "quote_table.symbol"),
+		Name:      SelectText(e, "quote_table.name"),
+		MarketCap: SelectText(e, "quote_table.market_cap"),
+		Currency:  RowCurrency(e),
+		Timestamp: time.Now().Format(time.RFC3339),
+	}
+	if price, err := c.region.ParseFloat(SelectText(e, "quote_table.price")); parse.OK("quote_table.price", err) {
+		stock.Price = price
+	}
+	if change, err := c.region.ParseFloat(SelectText(e, "quote_table.change")); parse.OK("quote_table.change", err) {
+		stock.Change = change
+	}
+	if changePerc, err := c.region.ParsePercentage(SelectText(e, "quote_table.change_percent")); parse.OK("quote_table.change_percent", err) {
+		stock.ChangePerc = changePerc
+	}
+	if volume, err := c.region.ParseInt(SelectText(e, "quote_table.volume")); parse.OK("quote_table.volume", err) {
+		stock.Volume = volume
+	}
+	return stock
+}
+
+var currencyCode = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// RowCurrency is the currency a quote table row is priced in, empty when
+// the row doesn't say. A regional site lists foreign listings in their
+// own currency, so the site's currency is no stand-in. Minor units such as
+// GBp, pence, aren't ISO codes and count as unknown.
+func RowCurrency(e *colly.HTMLElement) string {
+	code := SelectText(e, "quote_table.currency")
+	if !currencyCode.MatchString(code) {
+		return ""
+	}
+	return code
+}
+
+var SectorURLs = map[string]string{
+	"technology":    "https://finance.yahoo.com/sector/technology",
+	"healthcare":    "https://finance.yahoo.com/sector/healthcare",
+	"financial":     "https://finance.yahoo.com/sector/financial",
+	"energy":        "https://finance.yahoo.com/sector/energy",
+	"consumer":      "https://finance.yahoo.com/sector/consumer_cyclical",
+	"industrial":    "https://finance.yahoo.com/sector/industrial",
+	"materials":     "https://finance.yahoo.com/sector/basic_materials",
+	"utilities":     "https://finance.yahoo.com/sector/utilities",
+	"real_estate":   "https://finance.yahoo.com/sector/real_estate",
+	"communication": "https://finance.yahoo.com/sector/communication_services",
+}
+
+// Sector scrapes a sector page's performance summary, top stocks and
+// sub-industries.
+func (c *Client) Sector(ctx context.Context, name string) (*model.SectorData, error) {
+	url, exists := SectorURLs[strings.ToLower(name)]
+	if !exists {
+		return nil, fmt.Errorf("invalid sector: %s", name)
+	}
+	url = c.region.Rewrite(url)
+
+	sector := &model.SectorData{
+		Name:          name,
+		SubIndustries: make([]model.SubSector, 0),
+		TopStocks:     make([]model.StockData, 0),
+		Timestamp:     time.Now().Format(time.RFC3339),
+	}
+	var mu sync.Mutex
+	var topStocks collect.Rows[model.StockData]
+	var subIndustries collect.Rows[model.SubSector]
+
+	col := c.clone(ctx)
+	col.OnHTML("div#quote-summary", func(e *colly.HTMLElement) {
+		mu.Lock()
+		defer mu.Unlock()
+		e.ForEach("tr", func(_ int, row *colly.HTMLElement) {
+			perf, err := c.region.ParsePercentage(row.ChildText("td:nth-child(2)"))
+			if !parse.OK("sector.performance", err) {
+				return
+			}
+			switch row.ChildText("td:first-child") {
+			case "Performance":
+				sector.Performance = perf
+			case "1-Month Performance":
+				sector.Performance1M = perf
+			case "3-Month Performance":
+				sector.Performance3M = perf
+			case "1-Year Performance":
+				sector.Performance1Y = perf
+			}
+		})
+	})
+
+	col.OnHTML("table[data-test='top-stocks'] tbody tr", func(e *colly.HTMLElement) {
+		stock := model.StockData{
+			Symbol:    strings.TrimSpace(e.ChildText("td:nth-child(1)")),
+			Name:      strings.TrimSpace(e.ChildText("td:nth-child(2)")),
+			Currency:  RowCurrency(e),
+			Timestamp: time.Now().Format(time.RFC3339),
+		}
+		if price, err := c.region.ParseFloat(e.ChildText("td:nth-child(3)")); parse.OK("sector.top_stocks.price", err) {
+			stock.Price = price
+		}
+		if change, err := c.region.ParseFloat(e.ChildText("td:nth-child(4)")); parse.OK("sector.top_stocks.change", err) {
+			stock.Change = change
+		}
+		if changePerc, err := c.region.ParsePercentage(e.ChildText("td:nth-child(5)")); parse.OK("sector.top_stocks.change_percent", err) {
+			stock.ChangePerc = changePerc
+		}
+		if volume, err := c.region.ParseInt(e.ChildText("td:nth-child(6)")); parse.OK("sector.top_stocks.volume", err) {
+			stock.Volume = volume
+		}
+
+		topStocks.Add(stock)
+	})
+
+	col.OnHTML("table[data-test='sub-industries'] tbody tr", func(e *colly.HTMLElement) {
+		subSector := model.SubSector{
+			Name:      strings.TrimSpace(e.ChildText("td:nth-child(1)")),
+			MarketCap: strings.TrimSpace(e.ChildText("td:nth-child(4)")),
+		}
+		if perf, err := c.region.ParsePercentage(e.ChildText("td:nth-child(2)")); parse.OK("sector.sub_industries.performance", err) {
+			subSector.Performance = perf
+		}
+		if count, err := c.region.ParseInt(e.ChildText("td:nth-child(3)")); parse.OK("sector.sub_industries.stock_count", err) {
+			subSector.StockCount = int(count)
+		}
+
+		subIndustries.Add(subSector)
+	})
+
+	if err := col.Visit(url); err != nil {
+		return nil, fmt.Errorf("failed to scrape sector data: %v", err)
+	}
+	if err := col.wait(); err != nil {
+		return nil, err
+	}
+
+	sector.TopStocks = append(sector.TopStocks, topStocks.Rows()...)
+	sector.SubIndustries = append(sector.SubIndustries, subIndustries.Rows()...)
+	return sector, nil
+}
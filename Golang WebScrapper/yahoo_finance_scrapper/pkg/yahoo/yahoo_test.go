@@ -0,0 +1,122 @@
+package yahoo
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	_, err := New(Option{Region: "zz"})
+	assert.Error(t, err)
+
+	c, err := New(Option{Region: "DE"})
+	assert.NoError(t, err)
+	assert.Equal(t, "de.finance.yahoo.com", c.Region().Host)
+
+	_, err = c.Movers(context.Background(), "52-week-highs")
+	assert.EqualError(t, err, "unknown movers page: 52-week-highs")
+
+	_, err = c.Sector(context.Background(), "shipping")
+	assert.EqualError(t, err, "invalid sector: shipping")
+}
+
+// TestConcurrentSectorScrapes runs overlapping scrapes on one client so
+// the race detector sees their callbacks interleave.
+func TestConcurrentSectorScrapes(t *testing.T) {
+	var rows strings.Builder
+	for i := 0; i < 30; i++ {
+		fmt.Fprintf(&rows, "<tr><td>S%d</td><td>Stock %d</td><td>%d.00</td><td>1.00</td><td>1.00%%</td><td>1,000</td></tr>", i, i, i)
+	}
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `<html><body>
+			<table data-test="top-stocks"><tbody>%s</tbody></table>
+			<table data-test="sub-industries"><tbody><tr><td>Software</td><td>1.00%%</td><td>30</td><td>1T</td></tr></tbody></table>
+		</body></html>`, rows.String())
+	}))
+	defer srv.Close()
+
+	client, err := New(Option{Parallelism: 8})
+	require.NoError(t, err)
+	client.region.Host = strings.TrimPrefix(srv.URL, "https://")
+	client.collector.AllowedDomains = nil
+	client.collector.WithTransport(srv.Client().Transport)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sector, err := client.Sector(context.Background(), "technology")
+			if assert.NoError(t, err) {
+				assert.Len(t, sector.TopStocks, 30)
+				assert.Len(t, sector.SubIndustries, 1)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestMoversRowCurrency(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<html><body><table data-test="gainers"><tbody>
+			<tr><td>VOD.L</td><td>Vodafone</td><td>70.10</td><td>1.00</td><td>1.45%</td><td>1,000</td><td>18B</td><td aria-label="Currency">GBp</td></tr>
+			<tr><td>SAP.DE</td><td>SAP</td><td>180.00</td><td>2.00</td><td>1.12%</td><td>1,000</td><td>210B</td><td aria-label="Currency">EUR</td></tr>
+			<tr><td>BP.L</td><td>BP</td><td>4.10</td><td>0.02</td><td>0.49%</td><td>1,000</td><td>70B</td></tr>
+		</tbody></table></body></html>`)
+	}))
+	defer srv.Close()
+
+	client, err := New(Option{Region: "uk"})
+	require.NoError(t, err)
+	client.region.Host = strings.TrimPrefix(srv.URL, "https://")
+	client.collector.AllowedDomains = nil
+	client.collector.WithTransport(srv.Client().Transport)
+
+	stocks, err := client.Movers(context.Background(), "gainers")
+	require.NoError(t, err)
+	require.Len(t, stocks, 3)
+	assert.Empty(t, stocks[0].Currency, "pence aren't GBP")
+	assert.Equal(t, "EUR", stocks[1].Currency)
+	assert.Empty(t, stocks[2].Currency, "no currency rather than the site's")
+}
+
+func TestUpstreamFailures(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.Contains(r.URL.Path, "/quote/AAPL/") {
+			fmt.Fprint(w, `<html><body><h1>Apple Inc. (AAPL)</h1></body></html>`)
+			return
+		}
+		http.Error(w, "upstream down", http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	client, err := New(Option{})
+	require.NoError(t, err)
+	client.region.Host = strings.TrimPrefix(srv.URL, "https://")
+	client.collector.AllowedDomains = nil
+	client.collector.WithTransport(srv.Client().Transport)
+	ctx := context.Background()
+
+	_, err = client.Movers(ctx, "gainers")
+	assert.ErrorContains(t, err, "Internal Server Error")
+	_, err = client.Sector(ctx, "technology")
+	assert.Error(t, err)
+	_, err = client.SectorConstituents(ctx, "technology", 100)
+	assert.Error(t, err)
+	_, err = client.EconomicCalendar(ctx, "2024-11-04")
+	assert.Error(t, err)
+
+	quotes, err := client.Quotes(ctx, []string{"AAPL", "MSFT"})
+	require.NoError(t, err, "a batch returns the pages that loaded")
+	assert.Equal(t, "Apple Inc. (AAPL)", quotes[0].Name)
+	_, err = client.Quotes(ctx, []string{"MSFT"})
+	assert.Error(t, err, "no page loaded")
+}
@@ -0,0 +1,286 @@
+package portfolio
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"go-webscraper/pkg/parse"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ColumnMapping names the CSV header of each transaction field. Type is
+// optional: without it, a negative quantity is a sell. Type values are
+// matched loosely, so "Buy", "YOU BOUGHT" and "Sell Short" all work, and
+// rows whose type is neither, like dividends or transfers, are skipped.
+type ColumnMapping struct {
+	Symbol     string `json:"symbol"`
+	Type       string `json:"type,omitempty"`
+	Quantity   string `json:"quantity"`
+	Price      string `json:"price"`
+	Fees       string `json:"fees,omitempty"`
+	Date       string `json:"date"`
+	DateFormat string `json:"date_format"`
+}
+
+// BrokerMappings are the column layouts of common broker exports, chosen
+// with ?broker=. Any field can be overridden with ?columns[field]=.
+var BrokerMappings = map[string]ColumnMapping{
+	"generic": {
+		Symbol: "symbol", Type: "type", Quantity: "quantity", Price: "price",
+		Fees: "fees", Date: "date", DateFormat: "2006-01-02",
+	},
+	"fidelity": {
+		Symbol: "Symbol", Type: "Action", Quantity: "Quantity", Price: "Price ($)",
+		Fees: "Commission ($)", Date: "Run Date", DateFormat: "01/02/2006",
+	},
+	"schwab": {
+		Symbol: "Symbol", Type: "Action", Quantity: "Quantity", Price: "Price",
+		Fees: "Fees & Comm", Date: "Date", DateFormat: "01/02/2006",
+	},
+	"interactive_brokers": {
+		Symbol: "Symbol", Quantity: "Quantity", Price: "TradePrice",
+		Fees: "IBCommission", Date: "TradeDate", DateFormat: "20060102",
+	},
+}
+
+// RowError is why one CSV row couldn't be imported. Row counts from 1 at
+// the header.
+type RowError struct {
+	Row   int    `json:"row"`
+	Error string `json:"error"`
+}
+
+// Statement is a parsed CSV export.
+type Statement struct {
+	Transactions []Transaction `json:"transactions"`
+	Skipped      int           `json:"skipped"`
+	Errors       []RowError    `json:"errors,omitempty"`
+}
+
+// mappingFor resolves ?broker= and any ?columns[field]= overrides.
+func mappingFor(broker string, overrides map[string]string) (ColumnMapping, error) {
+	if broker == "" {
+		broker = "generic"
+	}
+	mapping, ok := BrokerMappings[broker]
+	if !ok {
+		names := make([]string, 0, len(BrokerMappings))
+		for name := range BrokerMappings {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return mapping, fmt.Errorf("broker must be one of: %s", strings.Join(names, ", "))
+	}
+
+	fields := map[string]*string{
+		"symbol":      &mapping.Symbol,
+		"type":        &mapping.Type,
+		"quantity":    &mapping.Quantity,
+		"price":       &mapping.Price,
+		"fees":        &mapping.Fees,
+		"date":        &mapping.Date,
+		"date_format": &mapping.DateFormat,
+	}
+	for field, column := range overrides {
+		target, ok := fields[field]
+		if !ok {
+			return mapping, fmt.Errorf("unknown column mapping field: %s", field)
+		}
+		*target = column
+	}
+	return mapping, nil
+}
+
+// tradeType reads a broker's action column as buy or sell, or "" for
+// anything else.
+func tradeType(action string) string {
+	action = strings.ToLower(action)
+	switch {
+	case strings.Contains(action, "buy") || strings.Contains(action, "bought"):
+		return Buy
+	case strings.Contains(action, "sell") || strings.Contains(action, "sold"):
+		return Sell
+	}
+	return ""
+}
+
+// parseAmount reads a number as brokers print it, e.g. "$1,234.50" or
+// "(12.00)".
+func parseAmount(value string) (float64, error) {
+	v, err := parse.US.Float(strings.ReplaceAll(value, "$", ""))
+	if err == parse.ErrMissing {
+		return 0, nil
+	}
+	return v, err
+}
+
+// ParseStatement reads transactions from a broker CSV export using
+// mapping. Every row is checked, so all errors come back together.
+func ParseStatement(r io.Reader, mapping ColumnMapping, today time.Time) (*Statement, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("invalid CSV: %v", err)
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("CSV is empty")
+	}
+
+	columns := make(map[string]int, len(records[0]))
+	for i, name := range records[0] {
+		// Exports often start with a byte order mark
+		name = strings.TrimPrefix(strings.TrimSpace(name), "\ufeff")
+		columns[strings.ToLower(name)] = i
+	}
+	index := func(name string) int {
+		if name == "" {
+			return -1
+		}
+		if i, ok := columns[strings.ToLower(name)]; ok {
+			return i
+		}
+		return -1
+	}
+	for field, name := range map[string]string{
+		"symbol": mapping.Symbol, "quantity": mapping.Quantity,
+		"price": mapping.Price, "date": mapping.Date,
+	} {
+		if index(name) < 0 {
+			return nil, fmt.Errorf("%s column %q not found in header", field, name)
+		}
+	}
+
+	statement := &Statement{Transactions: make([]Transaction, 0, len(records)-1)}
+	for i, record := range records[1:] {
+		row := i + 2
+		value := func(name string) string {
+			if j := index(name); j >= 0 && j < len(record) {
+				return strings.TrimSpace(record[j])
+			}
+			return ""
+		}
+		if strings.Join(record, "") == "" {
+			continue
+		}
+
+		txType := ""
+		if mapping.Type != "" {
+			if txType = tradeType(value(mapping.Type)); txType == "" {
+				statement.Skipped++
+				continue
+			}
+		}
+
+		quantity, err := parseAmount(value(mapping.Quantity))
+		if err != nil {
+			statement.Errors = append(statement.Errors, RowError{Row: row, Error: fmt.Sprintf("invalid quantity: %v", err)})
+			continue
+		}
+		if txType == "" {
+			txType = Buy
+			if quantity < 0 {
+				txType = Sell
+			}
+		}
+		price, err := parseAmount(value(mapping.Price))
+		if err != nil {
+			statement.Errors = append(statement.Errors, RowError{Row: row, Error: fmt.Sprintf("invalid price: %v", err)})
+			continue
+		}
+		fees, err := parseAmount(value(mapping.Fees))
+		if err != nil {
+			statement.Errors = append(statement.Errors, RowError{Row: row, Error: fmt.Sprintf("invalid fees: %v", err)})
+			continue
+		}
+		date, err := time.Parse(mapping.DateFormat, value(mapping.Date))
+		if err != nil {
+			statement.Errors = append(statement.Errors, RowError{Row: row, Error: fmt.Sprintf("date %q doesn't match %s", value(mapping.Date), mapping.DateFormat)})
+			continue
+		}
+
+		tx, err := NewTransaction(TransactionRequest{
+			Symbol:   value(mapping.Symbol),
+			Type:     txType,
+			Quantity: math.Abs(quantity),
+			Price:    price,
+			Fees:     math.Abs(fees),
+			Date:     date.Format("2006-01-02"),
+		}, today)
+		if err != nil {
+			statement.Errors = append(statement.Errors, RowError{Row: row, Error: err.Error()})
+			continue
+		}
+		statement.Transactions = append(statement.Transactions, tx)
+	}
+	return statement, nil
+}
+
+// HandleImport adds the transactions in a broker CSV export to the
+// caller's portfolio. The import is all or nothing: if any row is invalid,
+// or a sell would exceed the holding, nothing is added and every row error
+// is reported.
+func HandleImport(store *Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		p := Owned(c, store)
+		if p == nil {
+			return
+		}
+
+		mapping, err := mappingFor(c.Query("broker"), c.QueryMap("columns"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		statement, err := ParseStatement(http.MaxBytesReader(c.Writer, c.Request.Body, 5<<20), mapping, time.Now())
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+		if len(statement.Errors) > 0 {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{
+				"error":  fmt.Sprintf("%d rows could not be imported", len(statement.Errors)),
+				"errors": statement.Errors,
+			})
+			return
+		}
+		if err := p.Add(statement.Transactions...); err != nil {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		p.UpdatedAt = time.Now()
+		if err := store.Save(p); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		c.Set("audit_target", p.ID)
+		c.JSON(http.StatusCreated, gin.H{
+			"status": "success",
+			"data": gin.H{
+				"imported":     len(statement.Transactions),
+				"skipped":      statement.Skipped,
+				"transactions": statement.Transactions,
+			},
+		})
+	}
+}
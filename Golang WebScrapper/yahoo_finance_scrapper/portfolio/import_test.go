@@ -0,0 +1,89 @@
+package portfolio
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseStatement(t *testing.T) {
+	today := time.Date(2024, 7, 10, 0, 0, 0, 0, time.UTC)
+
+	t.Run("Schwab", func(t *testing.T) {
+		mapping, err := mappingFor("schwab", nil)
+		assert.NoError(t, err)
+
+		csv := "\ufeffDate,Action,Symbol,Quantity,Price,Fees & Comm\n" +
+			"07/01/2024,Buy,AAPL,10,$190.50,$1.00\n" +
+			"07/02/2024,Qualified Dividend,AAPL,,,\n" +
+			"07/03/2024,Sell,AAPL,4,\"$1,200.00\",\n"
+		statement, err := ParseStatement(strings.NewReader(csv), mapping, today)
+		assert.NoError(t, err)
+		assert.Empty(t, statement.Errors)
+		assert.Equal(t, 1, statement.Skipped)
+		assert.Len(t, statement.Transactions, 2)
+		assert.Equal(t, Transaction{ID: statement.Transactions[0].ID, Symbol: "AAPL", Type: Buy, Quantity: 10, Price: 190.5, Fees: 1, Date: "2024-07-01"}, statement.Transactions[0])
+		assert.Equal(t, Sell, statement.Transactions[1].Type)
+		assert.Equal(t, 1200.0, statement.Transactions[1].Price)
+	})
+
+	t.Run("Signed quantities without a type column", func(t *testing.T) {
+		mapping, err := mappingFor("interactive_brokers", nil)
+		assert.NoError(t, err)
+
+		csv := "Symbol,TradeDate,Quantity,TradePrice,IBCommission\n" +
+			"MSFT,20240701,5,450,-1\n" +
+			"MSFT,20240702,-2,455,-1\n"
+		statement, err := ParseStatement(strings.NewReader(csv), mapping, today)
+		assert.NoError(t, err)
+		assert.Len(t, statement.Transactions, 2)
+		assert.Equal(t, Buy, statement.Transactions[0].Type)
+		assert.Equal(t, Sell, statement.Transactions[1].Type)
+		assert.Equal(t, 2.0, statement.Transactions[1].Quantity)
+		assert.Equal(t, 1.0, statement.Transactions[1].Fees)
+	})
+
+	t.Run("Column overrides", func(t *testing.T) {
+		mapping, err := mappingFor("", map[string]string{"symbol": "Ticker", "date_format": "02.01.2006"})
+		assert.NoError(t, err)
+
+		csv := "Ticker,type,quantity,price,date\nSAP,buy,3,180,01.07.2024\n"
+		statement, err := ParseStatement(strings.NewReader(csv), mapping, today)
+		assert.NoError(t, err)
+		assert.Len(t, statement.Transactions, 1)
+		assert.Equal(t, "2024-07-01", statement.Transactions[0].Date)
+
+		_, err = mappingFor("", map[string]string{"isin": "ISIN"})
+		assert.Error(t, err)
+		_, err = mappingFor("robinhood", nil)
+		assert.Error(t, err)
+	})
+
+	t.Run("Errors are reported per row", func(t *testing.T) {
+		mapping, _ := mappingFor("generic", nil)
+
+		csv := "symbol,type,quantity,price,date\n" +
+			"AAPL,buy,10,190,2024-07-01\n" +
+			"AAPL,buy,ten,190,2024-07-01\n" +
+			"AAPL,buy,10,190,07/01/2024\n" +
+			"AAPL,buy,10,190,2024-08-01\n"
+		statement, err := ParseStatement(strings.NewReader(csv), mapping, today)
+		assert.NoError(t, err)
+		assert.Len(t, statement.Transactions, 1)
+		if assert.Len(t, statement.Errors, 3) {
+			assert.Equal(t, 3, statement.Errors[0].Row)
+			assert.Contains(t, statement.Errors[0].Error, "quantity")
+			assert.Equal(t, 4, statement.Errors[1].Row)
+			assert.Equal(t, 5, statement.Errors[2].Row)
+			assert.Contains(t, statement.Errors[2].Error, "future")
+		}
+	})
+
+	t.Run("Missing column", func(t *testing.T) {
+		mapping, _ := mappingFor("generic", nil)
+		_, err := ParseStatement(strings.NewReader("symbol,quantity,price\nAAPL,1,1\n"), mapping, today)
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,407 @@
+package portfolio
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+const portfoliosKey = "portfolios"
+
+const MaxTransactions = 5000
+
+const (
+	Buy  = "buy"
+	Sell = "sell"
+)
+
+var validSymbol = regexp.MustCompile(`^[A-Z0-9.\-^=]{1,12}$`)
+
+// Transaction is one trade. Positions and performance are derived from a
+// portfolio's transactions, never stored on their own.
+type Transaction struct {
+	ID       string  `json:"id"`
+	Symbol   string  `json:"symbol"`
+	Type     string  `json:"type"`
+	Quantity float64 `json:"quantity"`
+	Price    float64 `json:"price"`
+	Fees     float64 `json:"fees,omitempty"`
+	Date     string  `json:"date"`
+}
+
+type Portfolio struct {
+	ID           string        `json:"id"`
+	UserID       string        `json:"-"`
+	Name         string        `json:"name"`
+	Transactions []Transaction `json:"transactions"`
+	CreatedAt    time.Time     `json:"created_at"`
+	UpdatedAt    time.Time     `json:"updated_at"`
+}
+
+// storedPortfolio keeps UserID in Redis while the API never exposes it.
+type storedPortfolio struct {
+	Portfolio
+	UserID string `json:"user_id"`
+}
+
+// Symbols returns every symbol the portfolio has traded, sorted.
+func (p *Portfolio) Symbols() []string {
+	seen := make(map[string]bool)
+	symbols := make([]string, 0)
+	for _, tx := range p.Transactions {
+		if !seen[tx.Symbol] {
+			seen[tx.Symbol] = true
+			symbols = append(symbols, tx.Symbol)
+		}
+	}
+	sort.Strings(symbols)
+	return symbols
+}
+
+// Positions returns the quantity held of each symbol after every
+// transaction dated on or before date. An empty date counts them all.
+func (p *Portfolio) Positions(date string) map[string]float64 {
+	positions := make(map[string]float64)
+	for _, tx := range p.Transactions {
+		if date != "" && tx.Date > date {
+			continue
+		}
+		if tx.Type == Sell {
+			positions[tx.Symbol] -= tx.Quantity
+		} else {
+			positions[tx.Symbol] += tx.Quantity
+		}
+	}
+	return positions
+}
+
+// Add validates transactions and appends them, keeping transactions in
+// date order. A sell may not exceed the quantity held on its date.
+func (p *Portfolio) Add(transactions ...Transaction) error {
+	if len(p.Transactions)+len(transactions) > MaxTransactions {
+		return fmt.Errorf("a portfolio holds at most %d transactions", MaxTransactions)
+	}
+
+	merged := append(append([]Transaction{}, p.Transactions...), transactions...)
+	sort.SliceStable(merged, func(i, j int) bool {
+		return merged[i].Date < merged[j].Date
+	})
+
+	held := make(map[string]float64)
+	for _, tx := range merged {
+		if tx.Type == Sell {
+			if tx.Quantity > held[tx.Symbol]+1e-9 {
+				return fmt.Errorf("selling %g %s on %s exceeds the %g held", tx.Quantity, tx.Symbol, tx.Date, held[tx.Symbol])
+			}
+			held[tx.Symbol] -= tx.Quantity
+		} else {
+			held[tx.Symbol] += tx.Quantity
+		}
+	}
+
+	p.Transactions = merged
+	return nil
+}
+
+type Store struct {
+	redis *redis.Client
+	ctx   context.Context
+}
+
+func NewStore(rdb *redis.Client) *Store {
+	return &Store{
+		redis: rdb,
+		ctx:   context.Background(),
+	}
+}
+
+func (s *Store) Save(p *Portfolio) error {
+	data, err := json.Marshal(storedPortfolio{Portfolio: *p, UserID: p.UserID})
+	if err != nil {
+		return err
+	}
+	return s.redis.HSet(s.ctx, portfoliosKey, p.ID, data).Err()
+}
+
+func (s *Store) Get(id string) (*Portfolio, error) {
+	data, err := s.redis.HGet(s.ctx, portfoliosKey, id).Result()
+	if err != nil {
+		if err == redis.Nil {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var stored storedPortfolio
+	if err := json.Unmarshal([]byte(data), &stored); err != nil {
+		return nil, err
+	}
+	stored.Portfolio.UserID = stored.UserID
+	return &stored.Portfolio, nil
+}
+
+func (s *Store) Delete(id string) error {
+	return s.redis.HDel(s.ctx, portfoliosKey, id).Err()
+}
+
+func (s *Store) All() ([]*Portfolio, error) {
+	values, err := s.redis.HGetAll(s.ctx, portfoliosKey).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	portfolios := make([]*Portfolio, 0, len(values))
+	for _, value := range values {
+		var stored storedPortfolio
+		if err := json.Unmarshal([]byte(value), &stored); err != nil {
+			continue
+		}
+		stored.Portfolio.UserID = stored.UserID
+		portfolios = append(portfolios, &stored.Portfolio)
+	}
+	return portfolios, nil
+}
+
+func (s *Store) ListByUser(userID string) ([]*Portfolio, error) {
+	portfolios, err := s.All()
+	if err != nil {
+		return nil, err
+	}
+
+	owned := make([]*Portfolio, 0)
+	for _, p := range portfolios {
+		if p.UserID == userID {
+			owned = append(owned, p)
+		}
+	}
+	return owned, nil
+}
+
+// Symbols returns every symbol held in any portfolio, so scheduled jobs
+// can record the closes performance is computed from.
+func (s *Store) Symbols() ([]string, error) {
+	portfolios, err := s.All()
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	symbols := make([]string, 0)
+	for _, p := range portfolios {
+		for _, symbol := range p.Symbols() {
+			if !seen[symbol] {
+				seen[symbol] = true
+				symbols = append(symbols, symbol)
+			}
+		}
+	}
+	sort.Strings(symbols)
+	return symbols, nil
+}
+
+// Owned loads the portfolio named by the :id route param, writing a 404
+// when it is missing or belongs to someone else. It returns nil if it has
+// already responded.
+func Owned(c *gin.Context, store *Store) *Portfolio {
+	p, err := store.Get(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return nil
+	}
+	if p == nil || p.UserID != c.GetString("user_id") {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "portfolio not found",
+		})
+		return nil
+	}
+	return p
+}
+
+func randomID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+type TransactionRequest struct {
+	Symbol   string  `json:"symbol" binding:"required"`
+	Type     string  `json:"type" binding:"required"`
+	Quantity float64 `json:"quantity" binding:"required"`
+	Price    float64 `json:"price" binding:"required"`
+	Fees     float64 `json:"fees"`
+	Date     string  `json:"date" binding:"required"`
+}
+
+// NewTransaction validates a transaction request against today's date.
+func NewTransaction(req TransactionRequest, today time.Time) (Transaction, error) {
+	tx := Transaction{
+		ID:       randomID(),
+		Symbol:   strings.ToUpper(strings.TrimSpace(req.Symbol)),
+		Type:     strings.ToLower(strings.TrimSpace(req.Type)),
+		Quantity: req.Quantity,
+		Price:    req.Price,
+		Fees:     req.Fees,
+		Date:     strings.TrimSpace(req.Date),
+	}
+	if !validSymbol.MatchString(tx.Symbol) {
+		return tx, fmt.Errorf("invalid symbol: %s", req.Symbol)
+	}
+	if tx.Type != Buy && tx.Type != Sell {
+		return tx, fmt.Errorf("type must be buy or sell")
+	}
+	if tx.Quantity <= 0 || tx.Price <= 0 || tx.Fees < 0 {
+		return tx, fmt.Errorf("quantity and price must be positive and fees not negative")
+	}
+	date, err := time.Parse("2006-01-02", tx.Date)
+	if err != nil {
+		return tx, fmt.Errorf("date must be a YYYY-MM-DD date")
+	}
+	if date.After(today) {
+		return tx, fmt.Errorf("date %s is in the future", tx.Date)
+	}
+	return tx, nil
+}
+
+type PortfolioRequest struct {
+	Name string `json:"name" binding:"required"`
+}
+
+func HandleCreate(store *Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req PortfolioRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		now := time.Now()
+		p := &Portfolio{
+			ID:           randomID(),
+			UserID:       c.GetString("user_id"),
+			Name:         strings.TrimSpace(req.Name),
+			Transactions: []Transaction{},
+			CreatedAt:    now,
+			UpdatedAt:    now,
+		}
+		if err := store.Save(p); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		c.Set("audit_target", p.ID)
+		c.JSON(http.StatusCreated, gin.H{
+			"status": "success",
+			"data":   p,
+		})
+	}
+}
+
+func HandleList(store *Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		portfolios, err := store.ListByUser(c.GetString("user_id"))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"status": "success",
+			"data":   portfolios,
+		})
+	}
+}
+
+func HandleGet(store *Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		p := Owned(c, store)
+		if p == nil {
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"status": "success",
+			"data":   p,
+		})
+	}
+}
+
+func HandleDelete(store *Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		p := Owned(c, store)
+		if p == nil {
+			return
+		}
+
+		if err := store.Delete(p.ID); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"status": "success",
+		})
+	}
+}
+
+// HandleAddTransaction records one trade in the caller's portfolio.
+func HandleAddTransaction(store *Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		p := Owned(c, store)
+		if p == nil {
+			return
+		}
+
+		var req TransactionRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		tx, err := NewTransaction(req, time.Now())
+		if err == nil {
+			err = p.Add(tx)
+		}
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		p.UpdatedAt = time.Now()
+		if err := store.Save(p); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		c.Set("audit_target", p.ID)
+		c.JSON(http.StatusCreated, gin.H{
+			"status": "success",
+			"data":   tx,
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package portfolio
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewTransaction(t *testing.T) {
+	today := time.Date(2024, 7, 10, 0, 0, 0, 0, time.UTC)
+
+	tx, err := NewTransaction(TransactionRequest{Symbol: " aapl", Type: "BUY", Quantity: 10, Price: 190, Date: "2024-07-01"}, today)
+	assert.NoError(t, err)
+	assert.Equal(t, "AAPL", tx.Symbol)
+	assert.Equal(t, Buy, tx.Type)
+	assert.NotEmpty(t, tx.ID)
+
+	for _, req := range []TransactionRequest{
+		{Symbol: "AAPL; DROP", Type: Buy, Quantity: 1, Price: 1, Date: "2024-07-01"},
+		{Symbol: "AAPL", Type: "short", Quantity: 1, Price: 1, Date: "2024-07-01"},
+		{Symbol: "AAPL", Type: Buy, Quantity: -1, Price: 1, Date: "2024-07-01"},
+		{Symbol: "AAPL", Type: Buy, Quantity: 1, Price: 1, Date: "07/01/2024"},
+		{Symbol: "AAPL", Type: Buy, Quantity: 1, Price: 1, Date: "2024-07-11"},
+	} {
+		_, err := NewTransaction(req, today)
+		assert.Error(t, err, req)
+	}
+}
+
+func TestAdd(t *testing.T) {
+	p := &Portfolio{}
+	assert.NoError(t, p.Add(
+		Transaction{Symbol: "AAPL", Type: Buy, Quantity: 10, Price: 100, Date: "2024-07-02"},
+		Transaction{Symbol: "MSFT", Type: Buy, Quantity: 5, Price: 400, Date: "2024-07-01"},
+	))
+	assert.Equal(t, "MSFT", p.Transactions[0].Symbol)
+	assert.Equal(t, []string{"AAPL", "MSFT"}, p.Symbols())
+
+	// Selling before the shares were bought is rejected
+	assert.Error(t, p.Add(Transaction{Symbol: "AAPL", Type: Sell, Quantity: 5, Price: 110, Date: "2024-07-01"}))
+	assert.Error(t, p.Add(Transaction{Symbol: "AAPL", Type: Sell, Quantity: 11, Price: 110, Date: "2024-07-03"}))
+	assert.Len(t, p.Transactions, 2)
+
+	assert.NoError(t, p.Add(Transaction{Symbol: "AAPL", Type: Sell, Quantity: 4, Price: 110, Date: "2024-07-03"}))
+	assert.Equal(t, map[string]float64{"AAPL": 6, "MSFT": 5}, p.Positions(""))
+	assert.Equal(t, map[string]float64{"MSFT": 5}, p.Positions("2024-07-01"))
+}
@@ -0,0 +1,158 @@
+// Package preflight checks at startup that the services and directories
+// GoFinance depends on are usable, so a misconfigured deployment fails at
+// boot with every problem listed rather than at its first request.
+package preflight
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"go-webscraper/upstream"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Check is one dependency to verify. Hint tells the operator how to fix
+// it when Run fails.
+type Check struct {
+	Name string
+	Run  func(ctx context.Context) error
+	Hint string
+}
+
+// Failure is a check that failed and why.
+type Failure struct {
+	Check Check
+	Err   error
+}
+
+// Error lists every failure with its hint, one per line.
+type Error []Failure
+
+func (e Error) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d startup check(s) failed:", len(e))
+	for _, failure := range e {
+		fmt.Fprintf(&b, "\n  %s: %v", failure.Check.Name, failure.Err)
+		if failure.Check.Hint != "" {
+			fmt.Fprintf(&b, "\n    hint: %s", failure.Check.Hint)
+		}
+	}
+	return b.String()
+}
+
+// Run runs checks concurrently, each bounded by timeout, and returns an
+// Error holding the failures in the order the checks were given, or nil.
+func Run(ctx context.Context, timeout time.Duration, checks ...Check) error {
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	errs := make([]error, len(checks))
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		wg.Add(1)
+		go func(i int, check Check) {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+			errs[i] = check.Run(ctx)
+		}(i, check)
+	}
+	wg.Wait()
+
+	var failures Error
+	for i, err := range errs {
+		if err != nil {
+			failures = append(failures, Failure{Check: checks[i], Err: err})
+		}
+	}
+	if len(failures) == 0 {
+		return nil
+	}
+	return failures
+}
+
+// Redis checks rdb answers a PING, which also selects its database.
+func Redis(rdb *redis.Client) Check {
+	addr := rdb.Options().Addr
+	return Check{
+		Name: "redis",
+		Run: func(ctx context.Context) error {
+			return rdb.Ping(ctx).Err()
+		},
+		Hint: fmt.Sprintf("start Redis on %s or set redis.addr, redis.password and redis.db "+
+			"(GOFINANCE_REDIS_ADDR); without Redis, run with --storage=sqlite --cache=memory", addr),
+	}
+}
+
+// WritableFile checks the file at path, configured as key, can be opened
+// for writing, or created when it doesn't exist yet. Nothing is written
+// to an existing file.
+func WritableFile(key, path string) Check {
+	return Check{
+		Name: key,
+		Run: func(context.Context) error {
+			f, err := os.OpenFile(path, os.O_WRONLY, 0)
+			if os.IsNotExist(err) {
+				return writableDir(filepath.Dir(path))
+			}
+			if err != nil {
+				return err
+			}
+			return f.Close()
+		},
+		Hint: fmt.Sprintf("make %s writable by the user GoFinance runs as, or set %s", path, key),
+	}
+}
+
+// WritableDir checks GoFinance can create files in dir, configured as
+// key, creating the directory if needed.
+func WritableDir(key, dir string) Check {
+	return Check{
+		Name: key,
+		Run: func(context.Context) error {
+			if err := os.MkdirAll(dir, 0o755); err != nil {
+				return err
+			}
+			return writableDir(dir)
+		},
+		Hint: fmt.Sprintf("create %s writable by the user GoFinance runs as, or set %s", dir, key),
+	}
+}
+
+func writableDir(dir string) error {
+	f, err := os.CreateTemp(dir, ".preflight-*")
+	if err != nil {
+		return err
+	}
+	f.Close()
+	return os.Remove(f.Name())
+}
+
+// Reachable checks url answers over the shared upstream transport, so the
+// configured proxy is used. Any HTTP response counts: the check is for
+// DNS, routing, proxies and TLS, not for what the site says.
+func Reachable(url string) Check {
+	return Check{
+		Name: "upstream " + url,
+		Run: func(ctx context.Context) error {
+			req, err := http.NewRequestWithContext(ctx, http.MethodHead, url, nil)
+			if err != nil {
+				return err
+			}
+			resp, err := (&http.Client{Transport: upstream.Transport()}).Do(req)
+			if err != nil {
+				return err
+			}
+			return resp.Body.Close()
+		},
+		Hint: fmt.Sprintf("allow outbound HTTPS to %s through the firewall, or set upstream.proxy "+
+			"if traffic must go through a proxy", url),
+	}
+}
@@ -0,0 +1,88 @@
+package preflight
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go-webscraper/upstream"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRun(t *testing.T) {
+	ok := Check{Name: "ok", Run: func(context.Context) error { return nil }}
+	broken := Check{Name: "broken", Run: func(context.Context) error { return errors.New("refused") }, Hint: "start it"}
+	slow := Check{Name: "slow", Run: func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}}
+
+	assert.NoError(t, Run(context.Background(), time.Second, ok))
+
+	err := Run(context.Background(), 50*time.Millisecond, slow, ok, broken)
+	var failures Error
+	require.ErrorAs(t, err, &failures)
+	require.Len(t, failures, 2)
+	assert.Equal(t, "slow", failures[0].Check.Name)
+	assert.ErrorIs(t, failures[0].Err, context.DeadlineExceeded)
+	assert.Equal(t, "broken", failures[1].Check.Name)
+	assert.Contains(t, err.Error(), "2 startup check(s) failed")
+	assert.Contains(t, err.Error(), "broken: refused\n    hint: start it")
+}
+
+func TestRedis(t *testing.T) {
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	defer mr.Close()
+
+	addr := mr.Addr()
+	rdb := redis.NewClient(&redis.Options{Addr: addr})
+	defer rdb.Close()
+	assert.NoError(t, Run(context.Background(), time.Second, Redis(rdb)))
+
+	mr.Close()
+	err = Run(context.Background(), time.Second, Redis(rdb))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "hint: start Redis on "+addr)
+}
+
+func TestWritable(t *testing.T) {
+	dir := t.TempDir()
+	check := WritableDir("output.dir", filepath.Join(dir, "stock_data"))
+	require.NoError(t, check.Run(context.Background()))
+	entries, err := os.ReadDir(filepath.Join(dir, "stock_data"))
+	require.NoError(t, err)
+	assert.Empty(t, entries, "the probe file is removed")
+
+	// A file where the directory should be can't be written into
+	blocked := filepath.Join(dir, "blocked")
+	require.NoError(t, os.WriteFile(blocked, nil, 0o644))
+	assert.Error(t, WritableDir("archive.dir", blocked).Run(context.Background()))
+
+	assert.NoError(t, WritableFile("storage.path", filepath.Join(dir, "gofinance.db")).Run(context.Background()))
+	assert.NoError(t, WritableFile("storage.path", blocked).Run(context.Background()))
+	assert.Error(t, WritableFile("storage.path", filepath.Join(blocked, "gofinance.db")).Run(context.Background()))
+}
+
+func TestReachable(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer server.Close()
+	defer upstream.UseTransport(server.Client().Transport)()
+
+	// An answer of any kind means the network path works
+	assert.NoError(t, Reachable(server.URL).Run(context.Background()))
+
+	server.Close()
+	assert.Error(t, Reachable(server.URL).Run(context.Background()))
+}
@@ -0,0 +1,235 @@
+// Package query answers simple natural-language market questions by
+// matching them to an intent with keyword rules and running the service
+// call that intent maps to.
+package query
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"go-webscraper/scraper"
+)
+
+const (
+	IntentQuote   = "quote"
+	IntentReturns = "returns"
+	IntentMovers  = "movers"
+	IntentSector  = "sector"
+	IntentNews    = "news"
+)
+
+const (
+	defaultLimit = 10
+	maxLimit     = 100
+	maxSymbols   = 10
+)
+
+// Query is how a question was interpreted: the intent and the parameters
+// of the service call it maps to.
+type Query struct {
+	Intent   string   `json:"intent"`
+	Symbols  []string `json:"symbols,omitempty"`
+	Category string   `json:"category,omitempty"`
+	Sector   string   `json:"sector,omitempty"`
+	Period   string   `json:"period,omitempty"`
+	Limit    int      `json:"limit,omitempty"`
+}
+
+var (
+	wordPattern   = regexp.MustCompile(`\$?[A-Za-z][A-Za-z0-9.\-]*|\d+`)
+	symbolPattern = regexp.MustCompile(`^[A-Z][A-Z0-9]{0,4}([.\-][A-Z]{1,2})?$`)
+)
+
+// sectorAliases maps the words people use for a sector to its name in
+// scraper.SectorURLs.
+var sectorAliases = map[string]string{
+	"tech":           "technology",
+	"technology":     "technology",
+	"health":         "healthcare",
+	"healthcare":     "healthcare",
+	"financial":      "financial",
+	"financials":     "financial",
+	"finance":        "financial",
+	"banks":          "financial",
+	"energy":         "energy",
+	"oil":            "energy",
+	"consumer":       "consumer",
+	"industrial":     "industrial",
+	"industrials":    "industrial",
+	"materials":      "materials",
+	"utilities":      "utilities",
+	"utility":        "utilities",
+	"reits":          "real_estate",
+	"realestate":     "real_estate",
+	"communication":  "communication",
+	"communications": "communication",
+	"telecom":        "communication",
+	"media":          "communication",
+}
+
+var categoryWords = map[string]string{
+	"gainers":    "gainers",
+	"gainer":     "gainers",
+	"winners":    "gainers",
+	"best":       "gainers",
+	"losers":     "losers",
+	"loser":      "losers",
+	"decliners":  "losers",
+	"worst":      "losers",
+	"active":     "most_active",
+	"trending":   "trending",
+	"popular":    "trending",
+	"mostactive": "most_active",
+}
+
+// periodPhrases map phrases to return periods, longest first so "past 3
+// months" wins over "month".
+var periodPhrases = []struct {
+	phrase string
+	period string
+}{
+	{"year to date", "ytd"},
+	{"this year", "ytd"},
+	{"ytd", "ytd"},
+	{"5 years", "5y"},
+	{"five years", "5y"},
+	{"3 years", "3y"},
+	{"three years", "3y"},
+	{"6 months", "6m"},
+	{"six months", "6m"},
+	{"3 months", "3m"},
+	{"three months", "3m"},
+	{"quarter", "3m"},
+	{"all time", "max"},
+	{"year", "1y"},
+	{"month", "1m"},
+	{"week", "1w"},
+}
+
+// stopwords are uppercase words that aren't tickers, for questions typed
+// in capitals.
+var stopwords = map[string]bool{
+	"A": true, "I": true, "AND": true, "OR": true, "VS": true, "THE": true, "OF": true,
+	"FOR": true, "IN": true, "ON": true, "TO": true, "IS": true, "HOW": true, "WHAT": true,
+	"US": true, "USD": true, "ETF": true, "YTD": true, "EPS": true, "PE": true, "IPO": true,
+	"CEO": true, "TOP": true, "NEWS": true, "PRICE": true, "TODAY": true, "STOCK": true,
+	"STOCKS": true, "SHOW": true, "ME": true, "MOST": true, "ALL": true, "THIS": true,
+}
+
+// Parse interprets question. News questions take precedence, then market
+// movers, then symbols (a quote, or returns when a period is named), then
+// sectors.
+func Parse(question string) (*Query, error) {
+	lower := " " + strings.Join(strings.Fields(strings.ToLower(question)), " ") + " "
+	words := wordPattern.FindAllString(question, -1)
+
+	q := &Query{}
+	var symbols []string
+	seen := make(map[string]bool)
+	wantsNews, wantsSectors := false, false
+	for i, word := range words {
+		plain := strings.ToLower(strings.TrimPrefix(word, "$"))
+		switch {
+		case strings.HasPrefix(word, "$") || (symbolPattern.MatchString(word) && !stopwords[word] && sectorAliases[plain] == "" && categoryWords[plain] == ""):
+			symbol := strings.ToUpper(strings.TrimPrefix(word, "$"))
+			if !seen[symbol] && scraper.ValidSymbol(symbol) {
+				seen[symbol] = true
+				symbols = append(symbols, symbol)
+			}
+		case plain == "news" || plain == "headlines":
+			wantsNews = true
+		case plain == "sectors":
+			wantsSectors = true
+		case sectorAliases[plain] != "":
+			q.Sector = sectorAliases[plain]
+		case categoryWords[plain] != "":
+			q.Category = categoryWords[plain]
+		case plain == "top" && i+1 < len(words):
+			if n, err := strconv.Atoi(words[i+1]); err == nil {
+				q.Limit = n
+			}
+		}
+	}
+	if strings.Contains(lower, " real estate ") {
+		q.Sector = "real_estate"
+	}
+	if strings.Contains(lower, " health care ") {
+		q.Sector = "healthcare"
+	}
+	for _, p := range periodPhrases {
+		if strings.Contains(lower, " "+p.phrase+" ") || strings.Contains(lower, " "+p.phrase+"s ") {
+			q.Period = p.period
+			break
+		}
+	}
+	if len(symbols) > maxSymbols {
+		return nil, fmt.Errorf("at most %d symbols per question", maxSymbols)
+	}
+	if q.Limit < 0 || q.Limit > maxLimit {
+		return nil, fmt.Errorf("at most %d results per question", maxLimit)
+	}
+
+	switch {
+	case wantsNews:
+		q.Intent = IntentNews
+		q.Symbols = symbols
+		if len(q.Symbols) > 1 {
+			q.Symbols = q.Symbols[:1]
+		}
+		q.Category, q.Sector, q.Period = "", "", ""
+	case q.Category != "":
+		q.Intent = IntentMovers
+		q.Period = ""
+		if q.Category == "trending" && q.Sector != "" {
+			return nil, fmt.Errorf("trending stocks can't be filtered by sector")
+		}
+	case len(symbols) > 0:
+		q.Intent = IntentQuote
+		if q.Period != "" {
+			q.Intent = IntentReturns
+		}
+		q.Symbols = symbols
+		q.Sector, q.Limit = "", 0
+	case q.Sector != "" || wantsSectors:
+		q.Intent = IntentSector
+		q.Period, q.Limit = "", 0
+	default:
+		return nil, fmt.Errorf("couldn't interpret the question; try e.g. \"top 5 energy gainers today\", \"AAPL price vs MSFT this month\" or \"news about TSLA\"")
+	}
+	if q.Intent == IntentMovers || q.Intent == IntentNews {
+		if q.Limit == 0 {
+			q.Limit = defaultLimit
+		}
+	}
+	return q, nil
+}
+
+// Describe restates the query in words, so callers can check it was
+// understood.
+func (q *Query) Describe() string {
+	switch q.Intent {
+	case IntentQuote:
+		return fmt.Sprintf("Latest quote for %s", strings.Join(q.Symbols, ", "))
+	case IntentReturns:
+		return fmt.Sprintf("%s return for %s", q.Period, strings.Join(q.Symbols, " vs "))
+	case IntentMovers:
+		category := strings.ReplaceAll(q.Category, "_", " ")
+		if q.Sector != "" {
+			return fmt.Sprintf("Top %d %s %s today", q.Limit, strings.ReplaceAll(q.Sector, "_", " "), category)
+		}
+		return fmt.Sprintf("Top %d %s today", q.Limit, category)
+	case IntentSector:
+		if q.Sector == "" {
+			return "Performance of all sectors"
+		}
+		return fmt.Sprintf("Performance of the %s sector", strings.ReplaceAll(q.Sector, "_", " "))
+	case IntentNews:
+		if len(q.Symbols) > 0 {
+			return fmt.Sprintf("Latest %d news articles about %s", q.Limit, q.Symbols[0])
+		}
+		return fmt.Sprintf("Latest %d news articles", q.Limit)
+	}
+	return ""
+}
@@ -0,0 +1,199 @@
+package query
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"go-webscraper/queue"
+	"go-webscraper/scraper"
+
+	"github.com/gin-gonic/gin"
+)
+
+const maxQuestionLength = 200
+
+type Request struct {
+	Question string `json:"question" binding:"required"`
+	Region   string `json:"region"`
+}
+
+// Interpretation is returned alongside the answer.
+type Interpretation struct {
+	*Query
+	Question    string `json:"question"`
+	Description string `json:"description"`
+}
+
+// sectorMovers ranks a sector's top stocks for category, since the movers
+// lists carry no sector.
+func sectorMovers(stocks []scraper.StockData, category string, limit int) []scraper.StockData {
+	ranked := append([]scraper.StockData(nil), stocks...)
+	sort.SliceStable(ranked, func(i, j int) bool {
+		switch category {
+		case "losers":
+			return ranked[i].ChangePerc < ranked[j].ChangePerc
+		case "most_active":
+			return ranked[i].Volume > ranked[j].Volume
+		}
+		return ranked[i].ChangePerc > ranked[j].ChangePerc
+	})
+	filtered := make([]scraper.StockData, 0, limit)
+	for _, stock := range ranked {
+		if len(filtered) == limit {
+			break
+		}
+		if (category == "gainers" && stock.ChangePerc <= 0) || (category == "losers" && stock.ChangePerc >= 0) {
+			continue
+		}
+		filtered = append(filtered, stock)
+	}
+	return filtered
+}
+
+// Run makes the service call q maps to.
+func Run(ctx context.Context, q *Query, region scraper.Region, pool *queue.Pool) (interface{}, error) {
+	switch q.Intent {
+	case IntentQuote, IntentReturns:
+		stockScraper := scraper.NewStockScraper(scraper.StockScraperOption{
+			Region:  region.Code,
+			Context: ctx,
+			Pool:    pool,
+		})
+		defer stockScraper.Close()
+
+		if q.Intent == IntentReturns {
+			return stockScraper.Returns(q.Symbols, []string{q.Period}, false)
+		}
+		return stockScraper.CachedQuotes(q.Symbols)
+
+	case IntentMovers:
+		if q.Sector != "" {
+			sector, err := scrapeSector(ctx, q.Sector, region, pool)
+			if err != nil {
+				return nil, err
+			}
+			return sectorMovers(sector.TopStocks, q.Category, q.Limit), nil
+		}
+
+		stockScraper := scraper.NewStockScraper(scraper.StockScraperOption{
+			Region:  region.Code,
+			Context: ctx,
+			Pool:    pool,
+		})
+		defer stockScraper.Close()
+
+		stocks, err := stockScraper.ScrapeMovers(q.Category)
+		if err != nil {
+			return nil, err
+		}
+		if len(stocks) > q.Limit {
+			stocks = stocks[:q.Limit]
+		}
+		return stocks, nil
+
+	case IntentSector:
+		if q.Sector != "" {
+			return scrapeSector(ctx, q.Sector, region, pool)
+		}
+
+		sectorScraper := scraper.NewSectorScraper(scraper.ScraperOption{
+			Region:  region.Code,
+			Context: ctx,
+			Pool:    pool,
+		})
+		defer sectorScraper.Close()
+
+		return sectorScraper.ScrapeAllSectors()
+
+	case IntentNews:
+		newsScraper := scraper.NewScraper(scraper.ScraperOption{
+			Region:  region.Code,
+			Context: ctx,
+			Pool:    pool,
+		})
+		defer newsScraper.Close()
+
+		var articles []scraper.Article
+		var err error
+		if len(q.Symbols) > 0 {
+			articles, err = newsScraper.ScrapeSymbolNews(q.Symbols[0], q.Limit)
+		} else {
+			articles, err = newsScraper.ScrapeNews(scraper.MarketMidnight(time.Now()))
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(articles) > q.Limit {
+			articles = articles[:q.Limit]
+		}
+		return articles, nil
+	}
+	return nil, fmt.Errorf("unknown intent: %s", q.Intent)
+}
+
+func scrapeSector(ctx context.Context, name string, region scraper.Region, pool *queue.Pool) (*scraper.SectorData, error) {
+	sectorScraper := scraper.NewSectorScraper(scraper.ScraperOption{
+		Region:  region.Code,
+		Context: ctx,
+		Pool:    pool,
+	})
+	defer sectorScraper.Close()
+
+	return sectorScraper.ScrapeSector(name)
+}
+
+// HandleQuery answers POST /query {"question": "..."} with the data and
+// how the question was interpreted.
+func HandleQuery(pool *queue.Pool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req Request
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+		if len(req.Question) > maxQuestionLength {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": fmt.Sprintf("question must be at most %d characters", maxQuestionLength),
+			})
+			return
+		}
+
+		region, err := scraper.LookupRegion(req.Region)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		q, err := Parse(req.Question)
+		if err != nil {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{
+				"error":    err.Error(),
+				"question": req.Question,
+			})
+			return
+		}
+		interpretation := Interpretation{Query: q, Question: req.Question, Description: q.Describe()}
+
+		data, err := Run(c.Request.Context(), q, region, pool)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":       err.Error(),
+				"interpreted": interpretation,
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"status":      "success",
+			"interpreted": interpretation,
+			"data":        data,
+		})
+	}
+}
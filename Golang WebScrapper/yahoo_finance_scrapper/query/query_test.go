@@ -0,0 +1,76 @@
+package query
+
+import (
+	"testing"
+
+	"go-webscraper/scraper"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		question string
+		expected Query
+	}{
+		{"top 5 energy gainers today", Query{Intent: IntentMovers, Category: "gainers", Sector: "energy", Limit: 5}},
+		{"biggest losers", Query{Intent: IntentMovers, Category: "losers", Limit: defaultLimit}},
+		{"most active real estate stocks", Query{Intent: IntentMovers, Category: "most_active", Sector: "real_estate", Limit: defaultLimit}},
+		{"AAPL price vs MSFT this month", Query{Intent: IntentReturns, Symbols: []string{"AAPL", "MSFT"}, Period: "1m"}},
+		{"how has $nvda done over the past 3 months", Query{Intent: IntentReturns, Symbols: []string{"NVDA"}, Period: "3m"}},
+		{"BRK-B and AAPL year to date", Query{Intent: IntentReturns, Symbols: []string{"BRK-B", "AAPL"}, Period: "ytd"}},
+		{"what is the price of TSLA", Query{Intent: IntentQuote, Symbols: []string{"TSLA"}}},
+		{"WHAT IS THE PRICE OF TSLA", Query{Intent: IntentQuote, Symbols: []string{"TSLA"}}},
+		{"how is tech doing", Query{Intent: IntentSector, Sector: "technology"}},
+		{"how are sectors doing this week", Query{Intent: IntentSector}},
+		{"latest news about AMZN", Query{Intent: IntentNews, Symbols: []string{"AMZN"}, Limit: defaultLimit}},
+		{"top 3 headlines", Query{Intent: IntentNews, Limit: 3}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.question, func(t *testing.T) {
+			q, err := Parse(tt.question)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, *q)
+		})
+	}
+
+	for _, question := range []string{
+		"what should I have for lunch",
+		"top 500 gainers",
+		"trending tech stocks",
+	} {
+		_, err := Parse(question)
+		assert.Error(t, err, question)
+	}
+}
+
+func TestDescribe(t *testing.T) {
+	q, err := Parse("top 5 energy gainers today")
+	assert.NoError(t, err)
+	assert.Equal(t, "Top 5 energy gainers today", q.Describe())
+
+	q, err = Parse("AAPL price vs MSFT this month")
+	assert.NoError(t, err)
+	assert.Equal(t, "1m return for AAPL vs MSFT", q.Describe())
+}
+
+func TestSectorMovers(t *testing.T) {
+	stocks := []scraper.StockData{
+		{Symbol: "XOM", ChangePerc: 1.2, Volume: 900},
+		{Symbol: "CVX", ChangePerc: -0.8, Volume: 500},
+		{Symbol: "OXY", ChangePerc: 3.4, Volume: 300},
+		{Symbol: "SLB", ChangePerc: -2.1, Volume: 700},
+	}
+
+	symbols := func(stocks []scraper.StockData) []string {
+		out := make([]string, len(stocks))
+		for i, stock := range stocks {
+			out[i] = stock.Symbol
+		}
+		return out
+	}
+	assert.Equal(t, []string{"OXY", "XOM"}, symbols(sectorMovers(stocks, "gainers", 5)))
+	assert.Equal(t, []string{"SLB"}, symbols(sectorMovers(stocks, "losers", 1)))
+	assert.Equal(t, []string{"XOM", "SLB", "CVX"}, symbols(sectorMovers(stocks, "most_active", 3)))
+}
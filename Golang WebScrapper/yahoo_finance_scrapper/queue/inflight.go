@@ -0,0 +1,102 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"go-webscraper/metrics"
+
+	"github.com/gin-gonic/gin"
+)
+
+var ErrOverloaded = errors.New("too many requests are scraping")
+
+// Inflight counts the API requests that are scraping right now. Past its
+// limit a request that would start a scrape is turned away before it
+// touches Yahoo, while requests answered from the cache carry on, so
+// cache hits stay fast however busy the scrapers are.
+type Inflight struct {
+	mu    sync.Mutex
+	limit int
+	count int
+}
+
+// NewInflight sheds past limit requests scraping at once; 0 never sheds.
+func NewInflight(limit int) *Inflight {
+	return &Inflight{limit: limit}
+}
+
+// inflightRequest is one request's place in Inflight.
+type inflightRequest struct {
+	f       *Inflight
+	c       *gin.Context
+	mu      sync.Mutex
+	counted bool
+	stale   bool
+}
+
+type inflightKey struct{}
+
+// Middleware lets the scrapers behind it count their request towards f.
+// A request is counted from its first scrape until it has been answered.
+func (f *Inflight) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		req := &inflightRequest{f: f, c: c}
+		c.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), inflightKey{}, req))
+		c.Next()
+
+		req.mu.Lock()
+		defer req.mu.Unlock()
+		if req.counted {
+			f.mu.Lock()
+			f.count--
+			metrics.InflightScrapes.Set(float64(f.count))
+			f.mu.Unlock()
+		}
+	}
+}
+
+// Enter counts ctx's request as scraping, returning ErrOverloaded instead
+// when that would take it past the limit. Scrapes outside a request, such
+// as scheduled refreshes, are never counted or refused.
+func Enter(ctx context.Context) error {
+	req, ok := ctx.Value(inflightKey{}).(*inflightRequest)
+	if !ok || req.f.limit <= 0 {
+		return nil
+	}
+
+	req.mu.Lock()
+	defer req.mu.Unlock()
+	if req.counted {
+		return nil
+	}
+
+	f := req.f
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.count >= f.limit {
+		metrics.InflightShed.Inc()
+		return ErrOverloaded
+	}
+	f.count++
+	metrics.InflightScrapes.Set(float64(f.count))
+	req.counted = true
+	return nil
+}
+
+// ServedStale marks ctx's response as built from a copy past its TTL,
+// kept for when scraping is shed, with a Warning header.
+func ServedStale(ctx context.Context) {
+	req, ok := ctx.Value(inflightKey{}).(*inflightRequest)
+	if !ok {
+		return
+	}
+
+	req.mu.Lock()
+	defer req.mu.Unlock()
+	if !req.stale {
+		req.stale = true
+		req.c.Header("Warning", `110 - "Response is Stale"`)
+	}
+}
@@ -0,0 +1,80 @@
+package queue
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInflight(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(NewInflight(1).Middleware())
+
+	entered := make(chan struct{})
+	finish := make(chan struct{})
+	r.GET("/slow", func(c *gin.Context) {
+		if err := Enter(c.Request.Context()); err != nil {
+			c.Status(http.StatusServiceUnavailable)
+			return
+		}
+		// A request entering twice is still counted once
+		Enter(c.Request.Context())
+		close(entered)
+		<-finish
+		c.Status(http.StatusOK)
+	})
+	r.GET("/scrape", func(c *gin.Context) {
+		if err := Enter(c.Request.Context()); err != nil {
+			ServedStale(c.Request.Context())
+			c.Status(http.StatusServiceUnavailable)
+			return
+		}
+		c.Status(http.StatusOK)
+	})
+	r.GET("/cached", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	serve := func(target string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+		return w
+	}
+
+	slow := make(chan int)
+	go func() { slow <- serve("/slow").Code }()
+	<-entered
+
+	w := serve("/scrape")
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Equal(t, `110 - "Response is Stale"`, w.Header().Get("Warning"))
+	assert.Equal(t, http.StatusOK, serve("/cached").Code)
+	assert.NoError(t, Enter(context.Background()), "scrapes outside a request are never shed")
+
+	close(finish)
+	assert.Equal(t, http.StatusOK, <-slow)
+	assert.Equal(t, http.StatusOK, serve("/scrape").Code)
+	assert.Empty(t, serve("/scrape").Header().Get("Warning"))
+}
+
+func TestInflightUnlimited(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(NewInflight(0).Middleware())
+	r.GET("/scrape", func(c *gin.Context) {
+		if err := Enter(c.Request.Context()); err != nil {
+			c.Status(http.StatusServiceUnavailable)
+			return
+		}
+		c.Status(http.StatusOK)
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/scrape", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+}
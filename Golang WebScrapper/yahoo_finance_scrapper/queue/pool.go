@@ -0,0 +1,162 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"sync"
+	"time"
+
+	"go-webscraper/metrics"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Priority int
+
+const (
+	// Interactive scrapes were triggered by an API caller who is waiting.
+	Interactive Priority = iota
+	// Background scrapes come from scheduled refreshes.
+	Background
+)
+
+func (p Priority) String() string {
+	if p == Interactive {
+		return "interactive"
+	}
+	return "background"
+}
+
+var ErrSaturated = errors.New("scrape pool is saturated")
+
+// Pool bounds how many scrapes run at once. When every slot is busy,
+// callers wait in a queue per priority class; interactive waiters are
+// always served first, and a class whose queue is full is shed.
+type Pool struct {
+	mu         sync.Mutex
+	size       int
+	running    int
+	waiting    [2][]chan struct{}
+	maxWaiting [2]int
+	retryAfter time.Duration
+}
+
+type PoolOption struct {
+	Size                 int
+	MaxQueuedInteractive int
+	MaxQueuedBackground  int
+	RetryAfter           time.Duration
+}
+
+func NewPool(opts PoolOption) *Pool {
+	if opts.Size == 0 {
+		opts.Size = 8
+	}
+	if opts.MaxQueuedInteractive == 0 {
+		opts.MaxQueuedInteractive = 32
+	}
+	if opts.MaxQueuedBackground == 0 {
+		opts.MaxQueuedBackground = 8
+	}
+	if opts.RetryAfter == 0 {
+		opts.RetryAfter = 5 * time.Second
+	}
+
+	return &Pool{
+		size:       opts.Size,
+		maxWaiting: [2]int{opts.MaxQueuedInteractive, opts.MaxQueuedBackground},
+		retryAfter: opts.RetryAfter,
+	}
+}
+
+// Acquire takes a worker slot, waiting behind higher-priority callers. It
+// returns ErrSaturated straight away if p's queue is already full.
+func (p *Pool) Acquire(ctx context.Context, priority Priority) error {
+	p.mu.Lock()
+	if p.running < p.size && len(p.waiting[Interactive]) == 0 &&
+		(priority == Interactive || len(p.waiting[Background]) == 0) {
+		p.running++
+		metrics.ScrapePoolRunning.Set(float64(p.running))
+		p.mu.Unlock()
+		return nil
+	}
+
+	if len(p.waiting[priority]) >= p.maxWaiting[priority] {
+		p.mu.Unlock()
+		metrics.ScrapePoolShed.WithLabelValues(priority.String()).Inc()
+		return ErrSaturated
+	}
+
+	ready := make(chan struct{})
+	p.waiting[priority] = append(p.waiting[priority], ready)
+	metrics.ScrapePoolQueued.WithLabelValues(priority.String()).Set(float64(len(p.waiting[priority])))
+	p.mu.Unlock()
+
+	select {
+	case <-ready:
+		return nil
+	case <-ctx.Done():
+		p.mu.Lock()
+		for i, ch := range p.waiting[priority] {
+			if ch == ready {
+				p.waiting[priority] = append(p.waiting[priority][:i], p.waiting[priority][i+1:]...)
+				metrics.ScrapePoolQueued.WithLabelValues(priority.String()).Set(float64(len(p.waiting[priority])))
+				p.mu.Unlock()
+				return ctx.Err()
+			}
+		}
+		p.mu.Unlock()
+
+		// The slot was handed over just as ctx ended; give it back
+		p.Release()
+		return ctx.Err()
+	}
+}
+
+// Release hands the slot straight to the next waiter, if any.
+func (p *Pool) Release() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, priority := range []Priority{Interactive, Background} {
+		if len(p.waiting[priority]) == 0 {
+			continue
+		}
+
+		next := p.waiting[priority][0]
+		p.waiting[priority] = p.waiting[priority][1:]
+		metrics.ScrapePoolQueued.WithLabelValues(priority.String()).Set(float64(len(p.waiting[priority])))
+		close(next)
+		return
+	}
+
+	p.running--
+	metrics.ScrapePoolRunning.Set(float64(p.running))
+}
+
+func (p *Pool) Do(ctx context.Context, priority Priority, fn func() error) error {
+	if err := p.Acquire(ctx, priority); err != nil {
+		return err
+	}
+	defer p.Release()
+
+	return fn()
+}
+
+func (p *Pool) RetryAfter() time.Duration {
+	return p.retryAfter
+}
+
+// Unavailable writes the 503 load-shedding response for a saturated pool.
+func Unavailable(c *gin.Context, retryAfter time.Duration) {
+	seconds := int(math.Ceil(retryAfter.Seconds()))
+	c.Header("Retry-After", fmt.Sprintf("%d", seconds))
+	c.JSON(http.StatusServiceUnavailable, gin.H{
+		"status":      "error",
+		"error":       "server is busy, try again later",
+		"retry_after": seconds,
+	})
+}
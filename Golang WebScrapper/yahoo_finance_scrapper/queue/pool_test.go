@@ -0,0 +1,64 @@
+package queue
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPool(t *testing.T) {
+	t.Run("Interactive Before Background", func(t *testing.T) {
+		pool := NewPool(PoolOption{Size: 1})
+		require.NoError(t, pool.Acquire(context.Background(), Background))
+
+		var mu sync.Mutex
+		order := make([]Priority, 0)
+		var wg sync.WaitGroup
+		for _, priority := range []Priority{Background, Interactive} {
+			wg.Add(1)
+			go func(priority Priority) {
+				defer wg.Done()
+				pool.Do(context.Background(), priority, func() error {
+					mu.Lock()
+					order = append(order, priority)
+					mu.Unlock()
+					return nil
+				})
+			}(priority)
+			// Make sure the background waiter queues up first
+			time.Sleep(20 * time.Millisecond)
+		}
+
+		pool.Release()
+		wg.Wait()
+		assert.Equal(t, []Priority{Interactive, Background}, order)
+	})
+
+	t.Run("Sheds Full Class", func(t *testing.T) {
+		pool := NewPool(PoolOption{Size: 1, MaxQueuedBackground: 1})
+		require.NoError(t, pool.Acquire(context.Background(), Interactive))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go pool.Acquire(ctx, Background)
+		time.Sleep(20 * time.Millisecond)
+
+		assert.ErrorIs(t, pool.Acquire(context.Background(), Background), ErrSaturated)
+	})
+
+	t.Run("Cancelled Waiter Leaves Queue", func(t *testing.T) {
+		pool := NewPool(PoolOption{Size: 1})
+		require.NoError(t, pool.Acquire(context.Background(), Interactive))
+
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		assert.ErrorIs(t, pool.Acquire(ctx, Interactive), context.DeadlineExceeded)
+
+		pool.Release()
+		require.NoError(t, pool.Acquire(context.Background(), Interactive))
+	})
+}
@@ -0,0 +1,286 @@
+package queue
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	pendingKey = "jobs:pending"
+	jobPrefix  = "jobs:"
+)
+
+const (
+	StatusQueued    = "queued"
+	StatusRunning   = "running"
+	StatusSucceeded = "succeeded"
+	StatusFailed    = "failed"
+)
+
+type Job struct {
+	ID          string            `json:"id"`
+	Kind        string            `json:"kind"`
+	Params      map[string]string `json:"params,omitempty"`
+	Status      string            `json:"status"`
+	Error       string            `json:"error,omitempty"`
+	Result      json.RawMessage   `json:"result,omitempty"`
+	CreatedAt   time.Time         `json:"created_at"`
+	StartedAt   *time.Time        `json:"started_at,omitempty"`
+	CompletedAt *time.Time        `json:"completed_at,omitempty"`
+}
+
+// HandlerFunc performs one kind of job and returns its JSON-able result.
+type HandlerFunc func(ctx context.Context, params map[string]string) (interface{}, error)
+
+type Queue struct {
+	redis      *redis.Client
+	ctx        context.Context
+	workers    int
+	resultTTL  time.Duration
+	maxPending int64
+
+	mu       sync.RWMutex
+	handlers map[string]HandlerFunc
+	wg       sync.WaitGroup
+	cancel   context.CancelFunc
+}
+
+type QueueOption struct {
+	Workers    int
+	ResultTTL  time.Duration
+	MaxPending int64
+}
+
+func New(rdb *redis.Client, opts QueueOption) *Queue {
+	if opts.Workers == 0 {
+		opts.Workers = 4
+	}
+	if opts.ResultTTL == 0 {
+		opts.ResultTTL = 1 * time.Hour
+	}
+	if opts.MaxPending == 0 {
+		opts.MaxPending = 100
+	}
+
+	return &Queue{
+		redis:      rdb,
+		ctx:        context.Background(),
+		workers:    opts.Workers,
+		resultTTL:  opts.ResultTTL,
+		maxPending: opts.MaxPending,
+		handlers:   make(map[string]HandlerFunc),
+	}
+}
+
+func (q *Queue) Register(kind string, handler HandlerFunc) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.handlers[kind] = handler
+}
+
+func (q *Queue) handler(kind string) (HandlerFunc, bool) {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	handler, exists := q.handlers[kind]
+	return handler, exists
+}
+
+func (q *Queue) save(job *Job) error {
+	data, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+	return q.redis.Set(q.ctx, jobPrefix+job.ID, data, q.resultTTL).Err()
+}
+
+// Enqueue records a queued job and pushes it for the next free worker,
+// returning ErrSaturated once MaxPending jobs are already waiting.
+func (q *Queue) Enqueue(kind string, params map[string]string) (*Job, error) {
+	if _, exists := q.handler(kind); !exists {
+		return nil, fmt.Errorf("unknown job kind: %s", kind)
+	}
+
+	pending, err := q.redis.LLen(q.ctx, pendingKey).Result()
+	if err != nil {
+		return nil, err
+	}
+	if pending >= q.maxPending {
+		return nil, ErrSaturated
+	}
+
+	job := &Job{
+		ID:        randomID(),
+		Kind:      kind,
+		Params:    params,
+		Status:    StatusQueued,
+		CreatedAt: time.Now(),
+	}
+	if err := q.save(job); err != nil {
+		return nil, err
+	}
+	if err := q.redis.LPush(q.ctx, pendingKey, job.ID).Err(); err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+func (q *Queue) Get(id string) (*Job, error) {
+	data, err := q.redis.Get(q.ctx, jobPrefix+id).Result()
+	if err != nil {
+		if err == redis.Nil {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var job Job
+	if err := json.Unmarshal([]byte(data), &job); err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+func (q *Queue) Start() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	q.cancel = cancel
+
+	for i := 0; i < q.workers; i++ {
+		q.wg.Add(1)
+		go q.work(ctx)
+	}
+}
+
+func (q *Queue) work(ctx context.Context) {
+	defer q.wg.Done()
+
+	for {
+		if ctx.Err() != nil {
+			return
+		}
+
+		// Block briefly so Stop is noticed without busy polling
+		result, err := q.redis.BRPop(ctx, 1*time.Second, pendingKey).Result()
+		if err != nil {
+			if err != redis.Nil && ctx.Err() == nil {
+				log.Printf("Error reading job queue: %v", err)
+				time.Sleep(1 * time.Second)
+			}
+			continue
+		}
+
+		q.run(ctx, result[1])
+	}
+}
+
+func (q *Queue) run(ctx context.Context, id string) {
+	job, err := q.Get(id)
+	if err != nil || job == nil {
+		log.Printf("Skipping job %s: record missing (%v)", id, err)
+		return
+	}
+
+	handler, exists := q.handler(job.Kind)
+	if !exists {
+		q.finish(job, nil, fmt.Errorf("unknown job kind: %s", job.Kind))
+		return
+	}
+
+	startedAt := time.Now()
+	job.Status = StatusRunning
+	job.StartedAt = &startedAt
+	if err := q.save(job); err != nil {
+		log.Printf("Error updating job %s: %v", job.ID, err)
+	}
+
+	result, err := handler(ctx, job.Params)
+	q.finish(job, result, err)
+	log.Printf("Job %s (%s) %s in %v", job.ID, job.Kind, job.Status, time.Since(startedAt).Round(time.Millisecond))
+}
+
+func (q *Queue) finish(job *Job, result interface{}, err error) {
+	completedAt := time.Now()
+	job.CompletedAt = &completedAt
+
+	if err == nil {
+		job.Result, err = json.Marshal(result)
+	}
+	if err != nil {
+		job.Status = StatusFailed
+		job.Error = err.Error()
+		job.Result = nil
+	} else {
+		job.Status = StatusSucceeded
+	}
+
+	if err := q.save(job); err != nil {
+		log.Printf("Error saving job %s: %v", job.ID, err)
+	}
+}
+
+func (q *Queue) Stop() {
+	q.mu.Lock()
+	cancel := q.cancel
+	q.mu.Unlock()
+
+	if cancel != nil {
+		cancel()
+	}
+	q.wg.Wait()
+}
+
+func randomID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// Accepted answers an ?async=true request with the queued job and where to
+// poll for it.
+func Accepted(c *gin.Context, job *Job) {
+	location := "/api/jobs/" + job.ID
+	c.Header("Location", location)
+	c.JSON(http.StatusAccepted, gin.H{
+		"status":     "accepted",
+		"job_id":     job.ID,
+		"status_url": location,
+		"data":       job,
+	})
+}
+
+func HandleGetJob(q *Queue) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		job, err := q.Get(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+		if job == nil {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "job not found",
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"status": "success",
+			"data":   job,
+		})
+	}
+}
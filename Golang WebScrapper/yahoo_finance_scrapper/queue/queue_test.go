@@ -0,0 +1,200 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestQueue(t *testing.T, opts QueueOption) (*Queue, *miniredis.Miniredis) {
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { rdb.Close() })
+	return New(rdb, opts), mr
+}
+
+func TestEnqueue(t *testing.T) {
+	q, mr := newTestQueue(t, QueueOption{MaxPending: 2, ResultTTL: time.Minute})
+	q.Register("quote", func(ctx context.Context, params map[string]string) (interface{}, error) {
+		return nil, nil
+	})
+
+	t.Run("Queued", func(t *testing.T) {
+		job, err := q.Enqueue("quote", map[string]string{"symbol": "AAPL"})
+		require.NoError(t, err)
+		assert.Equal(t, StatusQueued, job.Status)
+		assert.Len(t, job.ID, 16)
+
+		stored, err := q.Get(job.ID)
+		require.NoError(t, err)
+		assert.Equal(t, StatusQueued, stored.Status)
+		assert.Equal(t, map[string]string{"symbol": "AAPL"}, stored.Params)
+		assert.Equal(t, time.Minute, mr.TTL(jobPrefix+job.ID))
+
+		pending, _ := mr.List(pendingKey)
+		assert.Equal(t, []string{job.ID}, pending)
+	})
+
+	t.Run("Unknown Kind", func(t *testing.T) {
+		_, err := q.Enqueue("unknown", nil)
+		assert.EqualError(t, err, "unknown job kind: unknown")
+	})
+
+	t.Run("Saturated", func(t *testing.T) {
+		_, err := q.Enqueue("quote", nil)
+		require.NoError(t, err)
+		_, err = q.Enqueue("quote", nil)
+		assert.ErrorIs(t, err, ErrSaturated)
+	})
+
+	t.Run("Missing", func(t *testing.T) {
+		job, err := q.Get("missing")
+		assert.NoError(t, err)
+		assert.Nil(t, job)
+	})
+}
+
+func TestJobStatus(t *testing.T) {
+	q, _ := newTestQueue(t, QueueOption{})
+
+	started := make(chan struct{})
+	finish := make(chan error)
+	q.Register("quote", func(ctx context.Context, params map[string]string) (interface{}, error) {
+		close(started)
+		if err := <-finish; err != nil {
+			return nil, err
+		}
+		return map[string]string{"symbol": params["symbol"], "price": "231.40"}, nil
+	})
+	q.Register("fail", func(ctx context.Context, params map[string]string) (interface{}, error) {
+		return nil, errors.New("upstream unavailable")
+	})
+
+	t.Run("Succeeded", func(t *testing.T) {
+		job, err := q.Enqueue("quote", map[string]string{"symbol": "AAPL"})
+		require.NoError(t, err)
+
+		done := make(chan struct{})
+		go func() {
+			q.run(context.Background(), job.ID)
+			close(done)
+		}()
+
+		<-started
+		running, err := q.Get(job.ID)
+		require.NoError(t, err)
+		assert.Equal(t, StatusRunning, running.Status)
+		assert.NotNil(t, running.StartedAt)
+		assert.Nil(t, running.CompletedAt)
+
+		finish <- nil
+		<-done
+		succeeded, err := q.Get(job.ID)
+		require.NoError(t, err)
+		assert.Equal(t, StatusSucceeded, succeeded.Status)
+		assert.NotNil(t, succeeded.CompletedAt)
+		assert.Empty(t, succeeded.Error)
+		assert.JSONEq(t, `{"symbol":"AAPL","price":"231.40"}`, string(succeeded.Result))
+	})
+
+	t.Run("Failed", func(t *testing.T) {
+		job, err := q.Enqueue("fail", nil)
+		require.NoError(t, err)
+		q.run(context.Background(), job.ID)
+
+		failed, err := q.Get(job.ID)
+		require.NoError(t, err)
+		assert.Equal(t, StatusFailed, failed.Status)
+		assert.Equal(t, "upstream unavailable", failed.Error)
+		assert.Nil(t, failed.Result)
+	})
+
+	t.Run("Unserializable Result", func(t *testing.T) {
+		q.Register("channel", func(ctx context.Context, params map[string]string) (interface{}, error) {
+			return make(chan int), nil
+		})
+		job, err := q.Enqueue("channel", nil)
+		require.NoError(t, err)
+		q.run(context.Background(), job.ID)
+
+		failed, err := q.Get(job.ID)
+		require.NoError(t, err)
+		assert.Equal(t, StatusFailed, failed.Status)
+		assert.Contains(t, failed.Error, "unsupported type")
+	})
+}
+
+func TestWorkers(t *testing.T) {
+	q, _ := newTestQueue(t, QueueOption{Workers: 2})
+	q.Register("quote", func(ctx context.Context, params map[string]string) (interface{}, error) {
+		return params["symbol"], nil
+	})
+	q.Start()
+	defer q.Stop()
+
+	job, err := q.Enqueue("quote", map[string]string{"symbol": "MSFT"})
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		done, err := q.Get(job.ID)
+		return err == nil && done.Status == StatusSucceeded
+	}, 5*time.Second, 20*time.Millisecond)
+
+	done, _ := q.Get(job.ID)
+	assert.JSONEq(t, `"MSFT"`, string(done.Result))
+}
+
+func TestHandleGetJob(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	q, _ := newTestQueue(t, QueueOption{})
+	q.Register("quote", func(ctx context.Context, params map[string]string) (interface{}, error) {
+		return []string{"AAPL", "MSFT"}, nil
+	})
+
+	r := gin.New()
+	r.GET("/api/jobs/:id", HandleGetJob(q))
+	serve := func(target string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+		return w
+	}
+
+	job, err := q.Enqueue("quote", nil)
+	require.NoError(t, err)
+	q.run(context.Background(), job.ID)
+
+	w := serve("/api/jobs/" + job.ID)
+	require.Equal(t, http.StatusOK, w.Code)
+	var body struct {
+		Status string `json:"status"`
+		Data   Job    `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, "success", body.Status)
+	assert.Equal(t, job.ID, body.Data.ID)
+	assert.Equal(t, StatusSucceeded, body.Data.Status)
+	assert.JSONEq(t, `["AAPL","MSFT"]`, string(body.Data.Result))
+
+	assert.Equal(t, http.StatusNotFound, serve("/api/jobs/missing").Code)
+}
+
+func TestAccepted(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+
+	Accepted(c, &Job{ID: "abc123", Kind: "quote", Status: StatusQueued})
+	assert.Equal(t, http.StatusAccepted, w.Code)
+	assert.Equal(t, "/api/jobs/abc123", w.Header().Get("Location"))
+	assert.Contains(t, w.Body.String(), `"status_url":"/api/jobs/abc123"`)
+}
@@ -0,0 +1,257 @@
+package reports
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"go-webscraper/notify"
+	"go-webscraper/portfolio"
+	"go-webscraper/queue"
+	"go-webscraper/scraper"
+)
+
+const summaryMovers = 5
+
+// Report is a built report: titled tables that render as a plain-text
+// message body or a PDF. Data is the structured result it came from.
+type Report struct {
+	Report   string      `json:"report"`
+	Subject  string      `json:"subject"`
+	Title    string      `json:"title"`
+	Date     string      `json:"date"`
+	Sections []Section   `json:"sections"`
+	Data     interface{} `json:"data"`
+}
+
+// Section is one table. Links, when set, holds a URL per row that the
+// row's first cell links to.
+type Section struct {
+	Heading string     `json:"heading"`
+	Columns []string   `json:"columns"`
+	Rows    [][]string `json:"rows"`
+	Links   []string   `json:"links,omitempty"`
+}
+
+// Text lays the report out as aligned plain-text tables.
+func (r *Report) Text() string {
+	var body strings.Builder
+	fmt.Fprintf(&body, "%s\n", r.Title)
+	for _, section := range r.Sections {
+		fmt.Fprintf(&body, "\n%s:\n", section.Heading)
+		if len(section.Rows) == 0 {
+			body.WriteString("none\n")
+			continue
+		}
+
+		widths := make([]int, len(section.Columns))
+		for _, row := range append([][]string{section.Columns}, section.Rows...) {
+			for i, cell := range row {
+				if i < len(widths) && len(cell) > widths[i] {
+					widths[i] = len(cell)
+				}
+			}
+		}
+		for r, row := range append([][]string{section.Columns}, section.Rows...) {
+			cells := make([]string, len(row))
+			for i, cell := range row {
+				if i < len(row)-1 {
+					cell = fmt.Sprintf("%-*s", widths[i], cell)
+				}
+				cells[i] = cell
+			}
+			fmt.Fprintf(&body, "%s\n", strings.Join(cells, "  "))
+			if r > 0 && r <= len(section.Links) && section.Links[r-1] != "" {
+				fmt.Fprintf(&body, "  %s\n", section.Links[r-1])
+			}
+		}
+	}
+	return body.String()
+}
+
+// Message is the notification carrying the report, with it attached as a
+// PDF when withPDF is set.
+func (r *Report) Message(withPDF bool) (notify.Message, error) {
+	msg := notify.Message{
+		Subject: r.Subject,
+		Body:    r.Text(),
+		Data:    r.Data,
+	}
+	if withPDF {
+		pdf, err := RenderPDF(r)
+		if err != nil {
+			return msg, err
+		}
+		msg.Attachments = []notify.Attachment{{Filename: r.Filename(), ContentType: "application/pdf", Data: pdf}}
+	}
+	return msg, nil
+}
+
+// Filename names the report's PDF, e.g. gofinance-daily-summary-2024-07-12.pdf.
+func (r *Report) Filename() string {
+	return fmt.Sprintf("gofinance-%s-%s.pdf", strings.ReplaceAll(r.Report, "_", "-"), r.Date)
+}
+
+func percent(v float64) string {
+	return fmt.Sprintf("%+.2f%%", v)
+}
+
+func money(v float64) string {
+	return fmt.Sprintf("%.2f", v)
+}
+
+// MarketBuilder builds reports from the dashboard, stored sector history
+// and stored closes.
+func MarketBuilder(portfolios *portfolio.Store, pool *queue.Pool) Builder {
+	return func(ctx context.Context, sub *Subscription) (*Report, error) {
+		now := time.Now()
+		switch sub.Report {
+		case DailySummary:
+			region, err := scraper.LookupRegion("")
+			if err != nil {
+				return nil, err
+			}
+			dashboard := scraper.BuildDashboard(ctx, region, pool)
+			if len(dashboard.Indices) == 0 && len(dashboard.Sectors) == 0 && len(dashboard.Overview) == 0 {
+				return nil, fmt.Errorf("market data unavailable")
+			}
+			return dailySummary(dashboard, now), nil
+
+		case WeeklySectorReview:
+			sectorScraper := scraper.NewSectorScraper(scraper.ScraperOption{
+				Context:  ctx,
+				Pool:     pool,
+				Priority: queue.Background,
+			})
+			defer sectorScraper.Close()
+
+			rotation, days, err := sectorScraper.Rotation(now, scraper.DefaultRotationWindow)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read sector history: %v", err)
+			}
+			if days == 0 {
+				return nil, fmt.Errorf("no stored sector history yet")
+			}
+			return sectorReview(rotation, now), nil
+
+		case PortfolioPnL:
+			p, err := portfolios.Get(sub.PortfolioID)
+			if err != nil {
+				return nil, err
+			}
+			if p == nil || p.UserID != sub.UserID {
+				return nil, fmt.Errorf("portfolio %s no longer exists", sub.PortfolioID)
+			}
+
+			stockScraper := scraper.NewStockScraper(scraper.StockScraperOption{
+				Context:  ctx,
+				Pool:     pool,
+				Priority: queue.Background,
+			})
+			defer stockScraper.Close()
+
+			perf, err := stockScraper.PortfolioPerformance(p, scraper.DefaultBenchmark)
+			if err != nil {
+				return nil, err
+			}
+			return portfolioPnL(p, perf), nil
+		}
+		return nil, fmt.Errorf("unknown report: %s", sub.Report)
+	}
+}
+
+func dailySummary(d *scraper.Dashboard, now time.Time) *Report {
+	report := &Report{
+		Report:  DailySummary,
+		Subject: fmt.Sprintf("GoFinance daily summary: %s", now.Format("Jan 2")),
+		Title:   fmt.Sprintf("Market summary for %s", now.Format("Mon Jan 2, 2006")),
+		Date:    now.Format("2006-01-02"),
+		Data:    d,
+	}
+
+	if len(d.Indices) > 0 {
+		section := Section{Heading: "Indices", Columns: []string{"Symbol", "Name", "Price", "Change"}}
+		for _, index := range d.Indices {
+			section.Rows = append(section.Rows, []string{index.Symbol, index.Name, money(index.Price), percent(index.ChangePerc)})
+		}
+		report.Sections = append(report.Sections, section)
+	}
+	if len(d.Sectors) > 0 {
+		section := Section{Heading: "Sectors", Columns: []string{"Sector", "Day", "1M"}}
+		for _, sector := range d.Sectors {
+			section.Rows = append(section.Rows, []string{sector.Name, percent(sector.Performance), percent(sector.Performance1M)})
+		}
+		report.Sections = append(report.Sections, section)
+	}
+	for _, category := range []string{"gainers", "losers"} {
+		stocks := d.Overview[category]
+		if len(stocks) == 0 {
+			continue
+		}
+		if len(stocks) > summaryMovers {
+			stocks = stocks[:summaryMovers]
+		}
+		section := Section{Heading: "Top " + category, Columns: []string{"Symbol", "Price", "Change"}}
+		for _, stock := range stocks {
+			section.Rows = append(section.Rows, []string{stock.Symbol, money(stock.Price), percent(stock.ChangePerc)})
+		}
+		report.Sections = append(report.Sections, section)
+	}
+	if len(d.Headlines) > 0 {
+		section := Section{Heading: "Headlines", Columns: []string{"Headline", "Published"}}
+		for _, article := range d.Headlines {
+			published := article.DatePublished
+			if t, err := time.Parse(time.RFC3339, published); err == nil {
+				published = t.Format("Jan 2 15:04")
+			}
+			section.Rows = append(section.Rows, []string{article.Title, published})
+			section.Links = append(section.Links, article.Link)
+		}
+		report.Sections = append(report.Sections, section)
+	}
+	return report
+}
+
+func sectorReview(rotation []scraper.SectorRotation, now time.Time) *Report {
+	report := &Report{
+		Report:  WeeklySectorReview,
+		Subject: fmt.Sprintf("GoFinance weekly sector review: %s", now.Format("Jan 2")),
+		Title:   fmt.Sprintf("Sector rotation for the week ending %s, over %d days of history", now.Format("Jan 2, 2006"), scraper.DefaultRotationWindow),
+		Date:    now.Format("2006-01-02"),
+		Data:    rotation,
+	}
+
+	for _, q := range []string{scraper.QuadrantLeading, scraper.QuadrantWeakening, scraper.QuadrantLagging, scraper.QuadrantImproving} {
+		section := Section{Heading: strings.ToUpper(q[:1]) + q[1:], Columns: []string{"Sector", "RS-Ratio", "RS-Momentum"}}
+		for _, sector := range rotation {
+			if sector.Quadrant == q {
+				section.Rows = append(section.Rows, []string{sector.Sector, money(sector.RSRatio), money(sector.RSMomentum)})
+			}
+		}
+		report.Sections = append(report.Sections, section)
+	}
+	return report
+}
+
+func portfolioPnL(p *portfolio.Portfolio, perf *scraper.Performance) *Report {
+	report := &Report{
+		Report:  PortfolioPnL,
+		Subject: fmt.Sprintf("GoFinance portfolio P&L: %s", p.Name),
+		Title:   fmt.Sprintf("Portfolio %q as of %s", p.Name, perf.To),
+		Date:    perf.To,
+		Data:    perf,
+	}
+
+	report.Sections = append(report.Sections, Section{
+		Heading: "Performance since " + perf.From,
+		Columns: []string{"Value", "Return", perf.Benchmark, "Excess"},
+		Rows:    [][]string{{money(perf.Value), percent(perf.TimeWeightedReturn), percent(perf.BenchmarkReturn), percent(perf.ExcessReturn)}},
+	})
+	positions := Section{Heading: "Positions", Columns: []string{"Symbol", "Quantity", "Value", "P&L"}}
+	for _, position := range perf.Positions {
+		positions.Rows = append(positions.Rows, []string{position.Symbol, fmt.Sprintf("%g", position.Quantity), money(position.MarketValue), money(position.PnL)})
+	}
+	report.Sections = append(report.Sections, positions)
+	return report
+}
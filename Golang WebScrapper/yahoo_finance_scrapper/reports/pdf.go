@@ -0,0 +1,106 @@
+package reports
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+
+	"github.com/go-pdf/fpdf"
+)
+
+const (
+	pdfMargin    = 15.0
+	pdfRowHeight = 6.0
+)
+
+// RenderPDF lays the report out as an A4 document, one table per section.
+// Cells too wide for their column are cut short with an ellipsis.
+func RenderPDF(r *Report) ([]byte, error) {
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.SetMargins(pdfMargin, pdfMargin, pdfMargin)
+	pdf.SetAutoPageBreak(true, pdfMargin)
+	pdf.SetTitle(r.Title, true)
+	pdf.SetCreator("GoFinance", true)
+	pdf.SetFooterFunc(func() {
+		pdf.SetY(-pdfMargin)
+		pdf.SetFont("Helvetica", "", 8)
+		pdf.SetTextColor(110, 119, 128)
+		pdf.CellFormat(0, pdfRowHeight, fmt.Sprintf("GoFinance - page %d", pdf.PageNo()), "", 0, "C", false, 0, "")
+	})
+	// The core fonts are cp1252, so UTF-8 text needs translating
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+	width, _ := pdf.GetPageSize()
+	width -= 2 * pdfMargin
+
+	pdf.AddPage()
+	pdf.SetFont("Helvetica", "B", 16)
+	pdf.MultiCell(0, 8, tr(r.Title), "", "L", false)
+
+	for _, section := range r.Sections {
+		pdf.Ln(4)
+		pdf.SetFont("Helvetica", "B", 12)
+		pdf.SetTextColor(29, 34, 40)
+		pdf.CellFormat(0, 8, tr(section.Heading), "", 1, "L", false, 0, "")
+		if len(section.Rows) == 0 {
+			pdf.SetFont("Helvetica", "I", 9)
+			pdf.CellFormat(0, pdfRowHeight, "none", "", 1, "L", false, 0, "")
+			continue
+		}
+
+		// The first column holds names and headlines, so it gets the room
+		// the numeric columns don't need
+		widths := make([]float64, len(section.Columns))
+		rest := width
+		for i := 1; i < len(widths); i++ {
+			widths[i] = math.Min(width*0.5/float64(len(widths)-1), 30)
+			rest -= widths[i]
+		}
+		widths[0] = rest
+
+		pdf.SetFont("Helvetica", "B", 9)
+		pdf.SetFillColor(236, 238, 241)
+		for i, column := range section.Columns {
+			pdf.CellFormat(widths[i], pdfRowHeight, tr(column), "B", 0, align(i), true, 0, "")
+		}
+		pdf.Ln(-1)
+
+		pdf.SetFont("Helvetica", "", 9)
+		for r, row := range section.Rows {
+			for i := range section.Columns {
+				cell, link := "", ""
+				if i < len(row) {
+					cell = fit(pdf, tr(row[i]), widths[i]-2)
+				}
+				if i == 0 && r < len(section.Links) {
+					link = section.Links[r]
+				}
+				pdf.CellFormat(widths[i], pdfRowHeight, cell, "", 0, align(i), false, 0, link)
+			}
+			pdf.Ln(-1)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, fmt.Errorf("failed to render PDF: %v", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// align left-aligns the first column and right-aligns the figures.
+func align(column int) string {
+	if column == 0 {
+		return "L"
+	}
+	return "R"
+}
+
+func fit(pdf *fpdf.Fpdf, s string, width float64) string {
+	if pdf.GetStringWidth(s) <= width {
+		return s
+	}
+	for len(s) > 0 && pdf.GetStringWidth(s+"...") > width {
+		s = s[:len(s)-1]
+	}
+	return s + "..."
+}
@@ -0,0 +1,424 @@
+package reports
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"go-webscraper/notify"
+	"go-webscraper/portfolio"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+const subscriptionsKey = "reports:subscriptions"
+
+const (
+	DailySummary       = "daily_summary"
+	WeeklySectorReview = "weekly_sector_review"
+	PortfolioPnL       = "portfolio_pnl"
+)
+
+var weekdays = map[string]time.Weekday{
+	"monday":    time.Monday,
+	"tuesday":   time.Tuesday,
+	"wednesday": time.Wednesday,
+	"thursday":  time.Thursday,
+	"friday":    time.Friday,
+	"saturday":  time.Saturday,
+	"sunday":    time.Sunday,
+}
+
+// Schedule is when a report is delivered. Without a weekday it goes out
+// every market weekday, Monday to Friday.
+type Schedule struct {
+	Time     string `json:"time"`
+	Timezone string `json:"timezone,omitempty"`
+	Weekday  string `json:"weekday,omitempty"`
+}
+
+// Subscription delivers one report to its owner on a schedule. Without a
+// channel the report goes to the owner's configured notification channel.
+// With PDF set, emailed reports also come as an attached PDF.
+type Subscription struct {
+	ID          string          `json:"id"`
+	UserID      string          `json:"-"`
+	Report      string          `json:"report"`
+	PortfolioID string          `json:"portfolio_id,omitempty"`
+	Schedule    Schedule        `json:"schedule"`
+	Channel     *notify.Channel `json:"channel,omitempty"`
+	PDF         bool            `json:"pdf,omitempty"`
+	LastRun     string          `json:"last_run,omitempty"`
+	LastError   string          `json:"last_error,omitempty"`
+	CreatedAt   time.Time       `json:"created_at"`
+}
+
+// storedSubscription keeps UserID in Redis while the API never exposes it.
+type storedSubscription struct {
+	Subscription
+	UserID string `json:"user_id"`
+}
+
+func (s Schedule) location() (*time.Location, error) {
+	if s.Timezone == "" {
+		return time.LoadLocation("America/New_York")
+	}
+	return time.LoadLocation(s.Timezone)
+}
+
+func (s Schedule) Validate() error {
+	if _, err := time.Parse("15:04", s.Time); err != nil {
+		return fmt.Errorf("schedule time must be formatted as HH:MM")
+	}
+	if _, err := s.location(); err != nil {
+		return fmt.Errorf("invalid timezone: %s", s.Timezone)
+	}
+	if _, ok := weekdays[s.Weekday]; s.Weekday != "" && !ok {
+		return fmt.Errorf("invalid weekday: %s", s.Weekday)
+	}
+	return nil
+}
+
+// Due reports whether the report should be delivered at now, returning
+// the local date to record as its last run. A report goes out at most once
+// per day, at or after its scheduled time.
+func (s Schedule) Due(now time.Time, lastRun string) (bool, string) {
+	loc, err := s.location()
+	if err != nil {
+		return false, ""
+	}
+
+	local := now.In(loc)
+	today := local.Format("2006-01-02")
+	if s.Weekday != "" {
+		if local.Weekday() != weekdays[s.Weekday] {
+			return false, today
+		}
+	} else if local.Weekday() == time.Saturday || local.Weekday() == time.Sunday {
+		return false, today
+	}
+	return lastRun != today && local.Format("15:04") >= s.Time, today
+}
+
+type Store struct {
+	redis *redis.Client
+	ctx   context.Context
+}
+
+func NewStore(rdb *redis.Client) *Store {
+	return &Store{
+		redis: rdb,
+		ctx:   context.Background(),
+	}
+}
+
+func (s *Store) Save(sub *Subscription) error {
+	data, err := json.Marshal(storedSubscription{Subscription: *sub, UserID: sub.UserID})
+	if err != nil {
+		return err
+	}
+	return s.redis.HSet(s.ctx, subscriptionsKey, sub.ID, data).Err()
+}
+
+func (s *Store) Get(id string) (*Subscription, error) {
+	data, err := s.redis.HGet(s.ctx, subscriptionsKey, id).Result()
+	if err != nil {
+		if err == redis.Nil {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var stored storedSubscription
+	if err := json.Unmarshal([]byte(data), &stored); err != nil {
+		return nil, err
+	}
+	stored.Subscription.UserID = stored.UserID
+	return &stored.Subscription, nil
+}
+
+func (s *Store) Delete(id string) error {
+	return s.redis.HDel(s.ctx, subscriptionsKey, id).Err()
+}
+
+func (s *Store) All() ([]*Subscription, error) {
+	values, err := s.redis.HGetAll(s.ctx, subscriptionsKey).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	subs := make([]*Subscription, 0, len(values))
+	for _, value := range values {
+		var stored storedSubscription
+		if err := json.Unmarshal([]byte(value), &stored); err != nil {
+			continue
+		}
+		stored.Subscription.UserID = stored.UserID
+		subs = append(subs, &stored.Subscription)
+	}
+	return subs, nil
+}
+
+func (s *Store) ListByUser(userID string) ([]*Subscription, error) {
+	subs, err := s.All()
+	if err != nil {
+		return nil, err
+	}
+
+	owned := make([]*Subscription, 0)
+	for _, sub := range subs {
+		if sub.UserID == userID {
+			owned = append(owned, sub)
+		}
+	}
+	return owned, nil
+}
+
+// Builder builds the report a subscription asks for.
+type Builder func(ctx context.Context, sub *Subscription) (*Report, error)
+
+// RunDue is a scheduler job that builds every report whose schedule has
+// come due and delivers it. A report that fails is recorded on its
+// subscription and retried at its next scheduled time, not every run.
+func RunDue(store *Store, build Builder, notifier *notify.Notifier) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		subs, err := store.All()
+		if err != nil {
+			return err
+		}
+
+		for _, sub := range subs {
+			due, today := sub.Schedule.Due(time.Now(), sub.LastRun)
+			if !due {
+				continue
+			}
+
+			var msg notify.Message
+			report, err := build(ctx, sub)
+			if err == nil {
+				msg, err = report.Message(sub.PDF)
+			}
+			if err == nil {
+				if sub.Channel != nil {
+					err = notifier.Send(*sub.Channel, msg)
+				} else {
+					err = notifier.NotifyUser(sub.UserID, msg)
+				}
+			}
+			sub.LastError = ""
+			if err != nil {
+				log.Printf("Error delivering %s report %s to %s: %v", sub.Report, sub.ID, sub.UserID, err)
+				sub.LastError = err.Error()
+			}
+
+			sub.LastRun = today
+			if err := store.Save(sub); err != nil {
+				log.Printf("Error saving report subscription %s: %v", sub.ID, err)
+			}
+		}
+		return nil
+	}
+}
+
+func randomID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+type SubscriptionRequest struct {
+	Report      string          `json:"report" binding:"required"`
+	PortfolioID string          `json:"portfolio_id"`
+	Schedule    Schedule        `json:"schedule" binding:"required"`
+	Channel     *notify.Channel `json:"channel"`
+	PDF         bool            `json:"pdf"`
+}
+
+func HandleCreate(store *Store, portfolios *portfolio.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req SubscriptionRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		sub := &Subscription{
+			ID:        randomID(),
+			UserID:    c.GetString("user_id"),
+			Report:    strings.ToLower(strings.TrimSpace(req.Report)),
+			Schedule:  req.Schedule,
+			Channel:   req.Channel,
+			PDF:       req.PDF,
+			CreatedAt: time.Now(),
+		}
+		sub.Schedule.Weekday = strings.ToLower(strings.TrimSpace(sub.Schedule.Weekday))
+
+		switch sub.Report {
+		case DailySummary:
+		case WeeklySectorReview:
+			if sub.Schedule.Weekday == "" {
+				sub.Schedule.Weekday = "friday"
+			}
+		case PortfolioPnL:
+			p, err := portfolios.Get(req.PortfolioID)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{
+					"error": err.Error(),
+				})
+				return
+			}
+			if p == nil || p.UserID != sub.UserID {
+				c.JSON(http.StatusBadRequest, gin.H{
+					"error": "portfolio not found",
+				})
+				return
+			}
+			sub.PortfolioID = p.ID
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": fmt.Sprintf("report must be one of: %s, %s, %s", DailySummary, WeeklySectorReview, PortfolioPnL),
+			})
+			return
+		}
+
+		if err := sub.Schedule.Validate(); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+		if sub.Channel != nil {
+			if err := sub.Channel.Validate(c.Request.Context()); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{
+					"error": "channel " + err.Error(),
+				})
+				return
+			}
+		}
+		// Don't deliver immediately for a time that already passed today
+		if due, today := sub.Schedule.Due(time.Now(), ""); due {
+			sub.LastRun = today
+		}
+
+		if err := store.Save(sub); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		c.Set("audit_target", sub.ID)
+		c.JSON(http.StatusCreated, gin.H{
+			"status": "success",
+			"data":   sub,
+		})
+	}
+}
+
+func HandleList(store *Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		subs, err := store.ListByUser(c.GetString("user_id"))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"status": "success",
+			"data":   subs,
+		})
+	}
+}
+
+func HandleDelete(store *Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		sub, err := store.Get(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+		if sub == nil || sub.UserID != c.GetString("user_id") {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "report subscription not found",
+			})
+			return
+		}
+
+		if err := store.Delete(sub.ID); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"status": "success",
+		})
+	}
+}
+
+// publicReports are the reports anyone can download, by URL name.
+var publicReports = map[string]string{
+	"daily":  DailySummary,
+	"weekly": WeeklySectorReview,
+}
+
+// HandleReport builds the report named by :name now, as JSON or, with
+// ?format=pdf, as a PDF download.
+func HandleReport(build Builder) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		name, ok := publicReports[c.Param("name")]
+		if !ok {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "report must be one of: daily, weekly",
+			})
+			return
+		}
+		format := c.DefaultQuery("format", "json")
+		if format != "json" && format != "pdf" {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "format must be one of: json, pdf",
+			})
+			return
+		}
+
+		report, err := build(c.Request.Context(), &Subscription{Report: name})
+		if err != nil {
+			c.JSON(http.StatusServiceUnavailable, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		if format == "pdf" {
+			pdf, err := RenderPDF(report)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{
+					"error": err.Error(),
+				})
+				return
+			}
+			c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", report.Filename()))
+			c.Data(http.StatusOK, "application/pdf", pdf)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"status": "success",
+			"data":   report,
+		})
+	}
+}
@@ -0,0 +1,104 @@
+package reports
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"go-webscraper/portfolio"
+	"go-webscraper/scraper"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScheduleDue(t *testing.T) {
+	ny, _ := time.LoadLocation("America/New_York")
+	// Friday
+	now := time.Date(2024, 7, 12, 17, 0, 0, 0, ny)
+
+	daily := Schedule{Time: "16:30"}
+	due, today := daily.Due(now, "")
+	assert.True(t, due)
+	assert.Equal(t, "2024-07-12", today)
+
+	due, _ = daily.Due(now, "2024-07-12")
+	assert.False(t, due, "already delivered today")
+
+	due, _ = Schedule{Time: "17:30"}.Due(now, "")
+	assert.False(t, due, "not yet time")
+
+	due, _ = daily.Due(now.AddDate(0, 0, 1), "")
+	assert.False(t, due, "daily reports skip weekends")
+
+	due, _ = Schedule{Time: "16:30", Weekday: "friday"}.Due(now, "")
+	assert.True(t, due)
+	due, _ = Schedule{Time: "16:30", Weekday: "monday"}.Due(now, "")
+	assert.False(t, due)
+
+	due, today = Schedule{Time: "05:00", Timezone: "Asia/Tokyo", Weekday: "saturday"}.Due(now, "")
+	assert.True(t, due, "already Saturday morning in Tokyo")
+	assert.Equal(t, "2024-07-13", today)
+}
+
+func TestScheduleValidate(t *testing.T) {
+	assert.NoError(t, Schedule{Time: "07:45"}.Validate())
+	assert.NoError(t, Schedule{Time: "07:45", Timezone: "Asia/Tokyo", Weekday: "monday"}.Validate())
+	assert.Error(t, Schedule{Time: "7pm"}.Validate())
+	assert.Error(t, Schedule{Time: "07:45", Timezone: "Mars/Olympus"}.Validate())
+	assert.Error(t, Schedule{Time: "07:45", Weekday: "someday"}.Validate())
+}
+
+func TestReportText(t *testing.T) {
+	now := time.Date(2024, 7, 12, 17, 0, 0, 0, time.UTC)
+
+	report := sectorReview([]scraper.SectorRotation{
+		{Sector: "technology", RotationPoint: scraper.RotationPoint{RSRatio: 104.2, RSMomentum: 101.1, Quadrant: scraper.QuadrantLeading}},
+		{Sector: "energy", RotationPoint: scraper.RotationPoint{RSRatio: 96.5, RSMomentum: 98.7, Quadrant: scraper.QuadrantLagging}},
+	}, now)
+	assert.Equal(t, "GoFinance weekly sector review: Jul 12", report.Subject)
+	assert.Equal(t, "gofinance-weekly-sector-review-2024-07-12.pdf", report.Filename())
+	text := report.Text()
+	assert.Contains(t, text, "Leading:\nSector      RS-Ratio  RS-Momentum\ntechnology  104.20    101.10\n")
+	assert.Contains(t, text, "Improving:\nnone")
+
+	p := &portfolio.Portfolio{Name: "Core"}
+	report = portfolioPnL(p, &scraper.Performance{
+		From: "2024-07-01", To: "2024-07-12", Value: 2100, TimeWeightedReturn: 5, Benchmark: "^GSPC", BenchmarkReturn: 2, ExcessReturn: 3,
+		Positions: []scraper.PositionContribution{{Symbol: "AAPL", Quantity: 10, MarketValue: 2100, PnL: 100}},
+	})
+	assert.Equal(t, "GoFinance portfolio P&L: Core", report.Subject)
+	assert.Contains(t, report.Text(), "2100.00  +5.00%  +2.00%  +3.00%")
+
+	report = dailySummary(&scraper.Dashboard{
+		Headlines: []scraper.Article{{Title: "Stocks rally", Link: "https://example.com/a", DatePublished: "2024-07-12T14:30:00Z"}},
+	}, now)
+	assert.Contains(t, report.Text(), "Stocks rally  Jul 12 14:30\n  https://example.com/a\n")
+}
+
+func TestReportMessage(t *testing.T) {
+	report := &Report{
+		Report:  DailySummary,
+		Subject: "GoFinance daily summary: Jul 12",
+		Title:   "Market summary for Fri Jul 12, 2024 – ünïcode",
+		Date:    "2024-07-12",
+		Sections: []Section{
+			{Heading: "Indices", Columns: []string{"Symbol", "Price"}, Rows: [][]string{{"^GSPC", "5615.35"}}},
+			{Heading: "Headlines", Columns: []string{"Headline", "Published"}, Rows: [][]string{{strings.Repeat("Very long headline ", 20), "Jul 12 14:30"}}, Links: []string{"https://example.com/a"}},
+			{Heading: "Empty", Columns: []string{"Sector"}},
+		},
+	}
+
+	msg, err := report.Message(false)
+	assert.NoError(t, err)
+	assert.Equal(t, report.Subject, msg.Subject)
+	assert.Empty(t, msg.Attachments)
+
+	msg, err = report.Message(true)
+	assert.NoError(t, err)
+	if assert.Len(t, msg.Attachments, 1) {
+		attachment := msg.Attachments[0]
+		assert.Equal(t, "gofinance-daily-summary-2024-07-12.pdf", attachment.Filename)
+		assert.Equal(t, "application/pdf", attachment.ContentType)
+		assert.True(t, strings.HasPrefix(string(attachment.Data), "%PDF-"))
+	}
+}
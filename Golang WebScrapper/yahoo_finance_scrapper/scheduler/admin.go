@@ -0,0 +1,119 @@
+package scheduler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// jobError maps a scheduler error to its status code.
+func jobError(c *gin.Context, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, ErrUnknownJob):
+		status = http.StatusNotFound
+	case errors.Is(err, ErrRunning):
+		status = http.StatusConflict
+	}
+	c.JSON(status, gin.H{
+		"error": err.Error(),
+	})
+}
+
+func HandleListJobs(s *Scheduler) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{
+			"status": "success",
+			"data":   s.Status(),
+		})
+	}
+}
+
+// HandleJobRuns lists a job's recorded runs, newest first; ?limit= caps
+// how many (default 50).
+func HandleJobRuns(s *Scheduler) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		limit := 50
+		if value := c.Query("limit"); value != "" {
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 || n > maxRuns {
+				c.JSON(http.StatusBadRequest, gin.H{
+					"error": "limit must be between 1 and " + strconv.Itoa(maxRuns),
+				})
+				return
+			}
+			limit = n
+		}
+
+		runs, err := s.Runs(c.Param("name"), limit)
+		if err != nil {
+			jobError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"status": "success",
+			"data":   runs,
+		})
+	}
+}
+
+// handleAction runs one of the scheduler's job actions on :name and
+// responds with the job's updated status.
+func handleAction(s *Scheduler, action func(name string) error) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		name := c.Param("name")
+		if err := action(name); err != nil {
+			jobError(c, err)
+			return
+		}
+		status, err := s.Get(name)
+		if err != nil {
+			jobError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"status": "success",
+			"data":   status,
+		})
+	}
+}
+
+func HandleTriggerJob(s *Scheduler) gin.HandlerFunc {
+	return handleAction(s, s.Trigger)
+}
+
+func HandlePauseJob(s *Scheduler) gin.HandlerFunc {
+	return handleAction(s, s.Pause)
+}
+
+func HandleResumeJob(s *Scheduler) gin.HandlerFunc {
+	return handleAction(s, s.Resume)
+}
+
+// HandleRescheduleJob sets a job's schedule from {"schedule": "..."}, a
+// cron expression or an interval such as "15m".
+func HandleRescheduleJob(s *Scheduler) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req struct {
+			Schedule string `json:"schedule" binding:"required"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+		if _, err := Parse(req.Schedule); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		handleAction(s, func(name string) error {
+			return s.Reschedule(name, req.Schedule)
+		})(c)
+	}
+}
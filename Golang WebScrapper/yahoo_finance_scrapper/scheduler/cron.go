@@ -0,0 +1,179 @@
+package scheduler
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule decides when a job next runs.
+type Schedule interface {
+	Next(after time.Time) time.Time
+	String() string
+}
+
+type every time.Duration
+
+// Every runs a job at a fixed interval.
+func Every(interval time.Duration) Schedule {
+	return every(interval)
+}
+
+func (e every) Next(after time.Time) time.Time {
+	return after.Add(time.Duration(e))
+}
+
+func (e every) String() string {
+	return "@every " + time.Duration(e).String()
+}
+
+// Parse reads a schedule: "@every 15m", a bare duration such as "15m", or
+// a five-field cron expression.
+func Parse(spec string) (Schedule, error) {
+	spec = strings.TrimSpace(spec)
+	if interval, ok := strings.CutPrefix(spec, "@every "); ok {
+		spec = strings.TrimSpace(interval)
+	}
+	if interval, err := time.ParseDuration(spec); err == nil {
+		if interval < time.Second {
+			return nil, fmt.Errorf("interval must be at least 1s")
+		}
+		return Every(interval), nil
+	}
+	return ParseCron(spec)
+}
+
+type cronField struct {
+	name     string
+	min, max int
+}
+
+var cronFields = []cronField{
+	{"minute", 0, 59},
+	{"hour", 0, 23},
+	{"day of month", 1, 31},
+	{"month", 1, 12},
+	{"day of week", 0, 7},
+}
+
+// cron is a parsed expression, one bitset per field.
+type cron struct {
+	expr                          string
+	minute, hour, dom, month, dow uint64
+	domRestricted, dowRestricted  bool
+}
+
+// ParseCron parses a standard five-field expression (minute hour
+// day-of-month month day-of-week) with *, lists, ranges and steps. Times
+// are matched in the server's local timezone; 7 is Sunday, like 0.
+func ParseCron(expr string) (Schedule, error) {
+	fields := strings.Fields(expr)
+	if len(fields) != len(cronFields) {
+		return nil, fmt.Errorf("cron expression must have %d fields, got %d", len(cronFields), len(fields))
+	}
+
+	var sets [5]uint64
+	for i, field := range fields {
+		set, err := parseCronField(field, cronFields[i])
+		if err != nil {
+			return nil, err
+		}
+		sets[i] = set
+	}
+	// Fold Sunday-as-7 onto 0
+	if sets[4]&(1<<7) != 0 {
+		sets[4] |= 1
+	}
+
+	c := &cron{
+		expr:          strings.Join(fields, " "),
+		minute:        sets[0],
+		hour:          sets[1],
+		dom:           sets[2],
+		month:         sets[3],
+		dow:           sets[4],
+		domRestricted: fields[2] != "*",
+		dowRestricted: fields[4] != "*",
+	}
+	if c.Next(time.Now()).IsZero() {
+		return nil, fmt.Errorf("cron expression never matches: %s", expr)
+	}
+	return c, nil
+}
+
+func parseCronField(field string, spec cronField) (uint64, error) {
+	var set uint64
+	for _, part := range strings.Split(field, ",") {
+		rangePart, step := part, 1
+		if before, after, ok := strings.Cut(part, "/"); ok {
+			n, err := strconv.Atoi(after)
+			if err != nil || n < 1 {
+				return 0, fmt.Errorf("invalid %s step: %s", spec.name, part)
+			}
+			rangePart, step = before, n
+		}
+
+		lo, hi := spec.min, spec.max
+		if rangePart != "*" {
+			var err error
+			if before, after, ok := strings.Cut(rangePart, "-"); ok {
+				lo, err = strconv.Atoi(before)
+				if err == nil {
+					hi, err = strconv.Atoi(after)
+				}
+			} else {
+				lo, err = strconv.Atoi(rangePart)
+				hi = lo
+				if strings.Contains(part, "/") {
+					hi = spec.max
+				}
+			}
+			if err != nil || lo < spec.min || hi > spec.max || lo > hi {
+				return 0, fmt.Errorf("invalid %s: %s", spec.name, part)
+			}
+		}
+
+		for v := lo; v <= hi; v += step {
+			set |= 1 << v
+		}
+	}
+	return set, nil
+}
+
+func (c *cron) dayMatches(t time.Time) bool {
+	dom := c.dom&(1<<t.Day()) != 0
+	dow := c.dow&(1<<int(t.Weekday())) != 0
+	// Like cron, a restricted day of month and day of week match either
+	if c.domRestricted && c.dowRestricted {
+		return dom || dow
+	}
+	return dom && dow
+}
+
+func (c *cron) Next(after time.Time) time.Time {
+	t := after.Truncate(time.Minute).Add(time.Minute)
+	// Feb 29 can be up to eight years away
+	limit := t.AddDate(8, 0, 0)
+	for t.Before(limit) {
+		switch {
+		case c.month&(1<<int(t.Month())) == 0:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+		case !c.dayMatches(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+		case c.hour&(1<<t.Hour()) == 0:
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+		case c.minute&(1<<t.Minute()) == 0:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	// Only impossible dates such as Feb 30 get here, and ParseCron rejects
+	// those
+	return time.Time{}
+}
+
+func (c *cron) String() string {
+	return c.expr
+}
@@ -0,0 +1,44 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	schedule, err := Parse("15m")
+	assert.NoError(t, err)
+	assert.Equal(t, "@every 15m0s", schedule.String())
+
+	schedule, err = Parse("@every 1h")
+	assert.NoError(t, err)
+	assert.Equal(t, "@every 1h0m0s", schedule.String())
+
+	for _, spec := range []string{"1ms", "* * * *", "60 * * * *", "*/0 * * * *", "0 0 30 2 *", "5-1 * * * *"} {
+		_, err := Parse(spec)
+		assert.Error(t, err, spec)
+	}
+}
+
+func TestCronNext(t *testing.T) {
+	// Tuesday
+	at := time.Date(2024, 3, 5, 10, 7, 30, 0, time.UTC)
+
+	for expr, want := range map[string]time.Time{
+		"*/15 * * * *":   time.Date(2024, 3, 5, 10, 15, 0, 0, time.UTC),
+		"0 9 * * *":      time.Date(2024, 3, 6, 9, 0, 0, 0, time.UTC),
+		"30 9 * * 1-5":   time.Date(2024, 3, 6, 9, 30, 0, 0, time.UTC),
+		"0 0 * * 7":      time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC),
+		"0 12 1 * *":     time.Date(2024, 4, 1, 12, 0, 0, 0, time.UTC),
+		"0 0 29 2 *":     time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC),
+		"0 0 15 * 1":     time.Date(2024, 3, 11, 0, 0, 0, 0, time.UTC),
+		"7,8 10 * * *":   time.Date(2024, 3, 5, 10, 8, 0, 0, time.UTC),
+		"0 8-18/2 * * *": time.Date(2024, 3, 5, 12, 0, 0, 0, time.UTC),
+	} {
+		schedule, err := ParseCron(expr)
+		assert.NoError(t, err, expr)
+		assert.Equal(t, want, schedule.Next(at), expr)
+	}
+}
@@ -0,0 +1,76 @@
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"sync/atomic"
+	"time"
+)
+
+// maxRuns is how many runs of each job the store keeps.
+const maxRuns = 200
+
+const runsKeyPrefix = "scheduler:runs:"
+
+// Run is the record of one execution of a job.
+type Run struct {
+	StartedAt  time.Time `json:"started_at"`
+	DurationMs int64     `json:"duration_ms"`
+	Rows       int64     `json:"rows"`
+	Trigger    string    `json:"trigger"`
+	Outcome    string    `json:"outcome"`
+	Error      string    `json:"error,omitempty"`
+}
+
+type rowsKey struct{}
+
+// AddRows counts rows a job parsed towards the current run's record. It
+// is a no-op outside a scheduled run.
+func AddRows(ctx context.Context, n int) {
+	if rows, ok := ctx.Value(rowsKey{}).(*atomic.Int64); ok {
+		rows.Add(int64(n))
+	}
+}
+
+func (s *Store) RecordRun(name string, run Run) error {
+	data, err := json.Marshal(run)
+	if err != nil {
+		return err
+	}
+
+	pipe := s.redis.TxPipeline()
+	pipe.LPush(s.ctx, runsKeyPrefix+name, data)
+	pipe.LTrim(s.ctx, runsKeyPrefix+name, 0, maxRuns-1)
+	_, err = pipe.Exec(s.ctx)
+	return err
+}
+
+// Runs returns up to limit of a job's most recent runs, newest first.
+func (s *Store) Runs(name string, limit int) ([]Run, error) {
+	values, err := s.redis.LRange(s.ctx, runsKeyPrefix+name, 0, int64(limit)-1).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	runs := make([]Run, 0, len(values))
+	for _, value := range values {
+		var run Run
+		if err := json.Unmarshal([]byte(value), &run); err != nil {
+			continue
+		}
+		runs = append(runs, run)
+	}
+	return runs, nil
+}
+
+// consecutiveFailures counts the failed runs at the head of runs.
+func consecutiveFailures(runs []Run) int {
+	failures := 0
+	for _, run := range runs {
+		if run.Outcome != "failure" {
+			break
+		}
+		failures++
+	}
+	return failures
+}
@@ -0,0 +1,409 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	ErrUnknownJob = errors.New("unknown job")
+	ErrRunning    = errors.New("job is already running")
+)
+
+type JobFunc func(ctx context.Context) error
+
+type Job struct {
+	Name     string
+	Schedule Schedule
+	Run      JobFunc
+
+	paused       bool
+	running      bool
+	nextRun      time.Time
+	lastRun      time.Time
+	lastDuration time.Duration
+	lastRows     int64
+	lastErr      error
+	failures     int
+	// wake interrupts the job's wait so it picks up schedule changes;
+	// trigger asks for an immediate run
+	wake    chan struct{}
+	trigger chan struct{}
+}
+
+// JobStatus is a job's schedule and the outcome of its last run.
+type JobStatus struct {
+	Name           string     `json:"name"`
+	Schedule       string     `json:"schedule"`
+	Paused         bool       `json:"paused"`
+	Running        bool       `json:"running"`
+	NextRun        *time.Time `json:"next_run,omitempty"`
+	LastRun        *time.Time `json:"last_run,omitempty"`
+	LastDurationMs int64      `json:"last_duration_ms,omitempty"`
+	LastRows       int64      `json:"last_rows,omitempty"`
+	LastOutcome    string     `json:"last_outcome,omitempty"`
+	LastError      string     `json:"last_error,omitempty"`
+	// ConsecutiveFailures counts failed runs since the last success.
+	ConsecutiveFailures int `json:"consecutive_failures"`
+}
+
+// Alert reports a job that has failed Failures times in a row, or with
+// Recovered set, its first success after such an alert.
+type Alert struct {
+	Job       string
+	Failures  int
+	Recovered bool
+	Run       Run
+}
+
+type Scheduler struct {
+	jobs           []*Job
+	store          *Store
+	alertThreshold int
+	alert          func(Alert)
+	skip           func() bool
+	mu             sync.Mutex
+	wg             sync.WaitGroup
+	cancel         context.CancelFunc
+}
+
+func New() *Scheduler {
+	return &Scheduler{}
+}
+
+func (s *Scheduler) Add(name string, interval time.Duration, run JobFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.jobs = append(s.jobs, &Job{
+		Name:     name,
+		Schedule: Every(interval),
+		Run:      run,
+		wake:     make(chan struct{}, 1),
+		trigger:  make(chan struct{}, 1),
+	})
+}
+
+// AlertAfter calls fn when a job fails threshold runs in a row, and again
+// when it next succeeds. Call it before Start.
+func (s *Scheduler) AlertAfter(threshold int, fn func(Alert)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.alertThreshold = threshold
+	s.alert = fn
+}
+
+// SkipWhen skips scheduled runs while fn returns true; manual triggers
+// still run. Call it before Start.
+func (s *Scheduler) SkipWhen(fn func() bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.skip = fn
+}
+
+// Restore applies the pauses and schedule changes saved in store, picks up
+// each job's failure streak from its run history, and saves later changes
+// and runs there. Call it after adding jobs and before Start.
+func (s *Scheduler) Restore(store *Store) error {
+	overrides, err := store.Load()
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.store = store
+	for _, job := range s.jobs {
+		if runs, err := store.Runs(job.Name, maxRuns); err == nil {
+			job.failures = consecutiveFailures(runs)
+		}
+
+		override, ok := overrides[job.Name]
+		if !ok {
+			continue
+		}
+		job.paused = override.Paused
+		if override.Schedule == "" {
+			continue
+		}
+		schedule, err := Parse(override.Schedule)
+		if err != nil {
+			log.Printf("Ignoring saved schedule of job %s: %v", job.Name, err)
+			continue
+		}
+		job.Schedule = schedule
+	}
+	return nil
+}
+
+func (s *Scheduler) Start() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+
+	for _, job := range s.jobs {
+		s.wg.Add(1)
+		go s.loop(ctx, job)
+	}
+}
+
+func (s *Scheduler) loop(ctx context.Context, job *Job) {
+	defer s.wg.Done()
+
+	for {
+		s.mu.Lock()
+		job.nextRun = time.Time{}
+		var timer *time.Timer
+		var due <-chan time.Time
+		if !job.paused {
+			job.nextRun = job.Schedule.Next(time.Now())
+			timer = time.NewTimer(time.Until(job.nextRun))
+			due = timer.C
+		}
+		s.mu.Unlock()
+
+		trigger := ""
+		select {
+		case <-ctx.Done():
+			return
+		case <-job.wake:
+		case <-job.trigger:
+			trigger = "manual"
+		case <-due:
+			trigger = "scheduled"
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+		if trigger == "scheduled" && s.skip != nil && s.skip() {
+			continue
+		}
+		if trigger != "" {
+			s.runJob(ctx, job, trigger)
+		}
+	}
+}
+
+func (s *Scheduler) runJob(ctx context.Context, job *Job, trigger string) {
+	s.mu.Lock()
+	job.running = true
+	s.mu.Unlock()
+
+	var rows atomic.Int64
+	startTime := time.Now()
+	err := job.Run(context.WithValue(ctx, rowsKey{}, &rows))
+	duration := time.Since(startTime)
+
+	run := Run{
+		StartedAt:  startTime,
+		DurationMs: duration.Milliseconds(),
+		Rows:       rows.Load(),
+		Trigger:    trigger,
+		Outcome:    "success",
+	}
+	if err != nil {
+		run.Outcome = "failure"
+		run.Error = err.Error()
+	}
+
+	s.mu.Lock()
+	job.running = false
+	job.lastRun = startTime
+	job.lastDuration = duration
+	job.lastRows = run.Rows
+	job.lastErr = err
+	previousFailures := job.failures
+	if err != nil {
+		job.failures++
+	} else {
+		job.failures = 0
+	}
+	failures, store, threshold, alert := job.failures, s.store, s.alertThreshold, s.alert
+	s.mu.Unlock()
+
+	if store != nil {
+		if err := store.RecordRun(job.Name, run); err != nil {
+			log.Printf("Error recording run of job %s: %v", job.Name, err)
+		}
+	}
+	if alert != nil && threshold > 0 {
+		if failures == threshold {
+			alert(Alert{Job: job.Name, Failures: failures, Run: run})
+		} else if failures == 0 && previousFailures >= threshold {
+			alert(Alert{Job: job.Name, Failures: previousFailures, Recovered: true, Run: run})
+		}
+	}
+
+	if err != nil {
+		log.Printf("Job %s failed after %v: %v", job.Name, duration.Round(time.Millisecond), err)
+		return
+	}
+	log.Printf("Job %s completed in %v", job.Name, duration.Round(time.Millisecond))
+}
+
+func (s *Scheduler) Stop() {
+	s.mu.Lock()
+	cancel := s.cancel
+	s.mu.Unlock()
+
+	if cancel != nil {
+		cancel()
+	}
+	s.wg.Wait()
+}
+
+// Status lists every job by name.
+func (s *Scheduler) Status() []JobStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	statuses := make([]JobStatus, 0, len(s.jobs))
+	for _, job := range s.jobs {
+		statuses = append(statuses, job.status())
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Name < statuses[j].Name
+	})
+	return statuses
+}
+
+// Get returns one job's status.
+func (s *Scheduler) Get(name string) (JobStatus, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job := s.find(name)
+	if job == nil {
+		return JobStatus{}, ErrUnknownJob
+	}
+	return job.status(), nil
+}
+
+// Runs returns up to limit of a job's recorded runs, newest first. Without
+// a store nothing is recorded.
+func (s *Scheduler) Runs(name string, limit int) ([]Run, error) {
+	s.mu.Lock()
+	job, store := s.find(name), s.store
+	s.mu.Unlock()
+
+	if job == nil {
+		return nil, ErrUnknownJob
+	}
+	if store == nil {
+		return []Run{}, nil
+	}
+	return store.Runs(name, limit)
+}
+
+// Trigger runs a job now, paused or not. Like any run, it pushes back the
+// next run of interval jobs, which counts from when a run ends.
+func (s *Scheduler) Trigger(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job := s.find(name)
+	if job == nil {
+		return ErrUnknownJob
+	}
+	if job.running {
+		return ErrRunning
+	}
+	select {
+	case job.trigger <- struct{}{}:
+		return nil
+	default:
+		return ErrRunning
+	}
+}
+
+// Pause stops scheduled runs of a job until Resume. A run in progress
+// finishes.
+func (s *Scheduler) Pause(name string) error {
+	return s.update(name, func(job *Job) { job.paused = true })
+}
+
+func (s *Scheduler) Resume(name string) error {
+	return s.update(name, func(job *Job) { job.paused = false })
+}
+
+// Reschedule replaces a job's schedule with spec, in any form Parse takes.
+func (s *Scheduler) Reschedule(name, spec string) error {
+	schedule, err := Parse(spec)
+	if err != nil {
+		return err
+	}
+	return s.update(name, func(job *Job) { job.Schedule = schedule })
+}
+
+// update changes a job, saves the change and wakes the job to apply it.
+func (s *Scheduler) update(name string, change func(job *Job)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job := s.find(name)
+	if job == nil {
+		return ErrUnknownJob
+	}
+	change(job)
+
+	if s.store != nil {
+		override := Override{Paused: job.paused, Schedule: job.Schedule.String()}
+		if err := s.store.Save(job.Name, override); err != nil {
+			return fmt.Errorf("failed to save job %s: %v", job.Name, err)
+		}
+	}
+
+	select {
+	case job.wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+func (s *Scheduler) find(name string) *Job {
+	for _, job := range s.jobs {
+		if job.Name == name {
+			return job
+		}
+	}
+	return nil
+}
+
+// status snapshots the job. Callers hold the scheduler's lock.
+func (job *Job) status() JobStatus {
+	status := JobStatus{
+		Name:     job.Name,
+		Schedule: job.Schedule.String(),
+		Paused:   job.paused,
+		Running:  job.running,
+
+		ConsecutiveFailures: job.failures,
+	}
+	if !job.nextRun.IsZero() {
+		next := job.nextRun
+		status.NextRun = &next
+	}
+	if !job.lastRun.IsZero() {
+		last := job.lastRun
+		status.LastRun = &last
+		status.LastDurationMs = job.lastDuration.Milliseconds()
+		status.LastRows = job.lastRows
+		status.LastOutcome = "success"
+		if job.lastErr != nil {
+			status.LastOutcome = "failure"
+			status.LastError = job.lastErr.Error()
+		}
+	}
+	return status
+}
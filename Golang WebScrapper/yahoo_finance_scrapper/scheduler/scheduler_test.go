@@ -0,0 +1,149 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSchedulerActions(t *testing.T) {
+	runs := make(chan struct{}, 10)
+	s := New()
+	s.Add("refresh", time.Hour, func(ctx context.Context) error {
+		runs <- struct{}{}
+		return errors.New("upstream down")
+	})
+	s.Start()
+	defer s.Stop()
+
+	t.Run("Trigger", func(t *testing.T) {
+		assert.NoError(t, s.Trigger("refresh"))
+		select {
+		case <-runs:
+		case <-time.After(time.Second):
+			t.Fatal("triggered job didn't run")
+		}
+
+		assert.Eventually(t, func() bool {
+			status, _ := s.Get("refresh")
+			return status.LastOutcome == "failure"
+		}, time.Second, 10*time.Millisecond)
+		status, _ := s.Get("refresh")
+		assert.Equal(t, "upstream down", status.LastError)
+	})
+
+	t.Run("Pause", func(t *testing.T) {
+		assert.NoError(t, s.Pause("refresh"))
+		assert.Eventually(t, func() bool {
+			status, _ := s.Get("refresh")
+			return status.Paused && status.NextRun == nil
+		}, time.Second, 10*time.Millisecond)
+
+		assert.NoError(t, s.Resume("refresh"))
+		assert.Eventually(t, func() bool {
+			status, _ := s.Get("refresh")
+			return !status.Paused && status.NextRun != nil
+		}, time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("Reschedule", func(t *testing.T) {
+		assert.NoError(t, s.Reschedule("refresh", "0 6 * * *"))
+		assert.Eventually(t, func() bool {
+			status, _ := s.Get("refresh")
+			return status.Schedule == "0 6 * * *" && status.NextRun != nil && status.NextRun.Minute() == 0
+		}, time.Second, 10*time.Millisecond)
+
+		assert.Error(t, s.Reschedule("refresh", "whenever"))
+	})
+
+	t.Run("Unknown Job", func(t *testing.T) {
+		assert.ErrorIs(t, s.Trigger("nope"), ErrUnknownJob)
+		assert.ErrorIs(t, s.Pause("nope"), ErrUnknownJob)
+	})
+}
+
+func TestAlertAfter(t *testing.T) {
+	fail := true
+	ran := make(chan struct{}, 1)
+	s := New()
+	s.Add("calendar", time.Hour, func(ctx context.Context) error {
+		defer func() { ran <- struct{}{} }()
+		AddRows(ctx, 12)
+		if fail {
+			return errors.New("selector drift")
+		}
+		return nil
+	})
+	alerts := make(chan Alert, 10)
+	s.AlertAfter(2, func(alert Alert) { alerts <- alert })
+	s.Start()
+	defer s.Stop()
+
+	runOnce := func() {
+		assert.NoError(t, s.Trigger("calendar"))
+		<-ran
+		assert.Eventually(t, func() bool {
+			status, _ := s.Get("calendar")
+			return !status.Running
+		}, time.Second, 5*time.Millisecond)
+	}
+
+	runOnce()
+	runOnce()
+	runOnce()
+	alert := <-alerts
+	assert.Equal(t, Alert{Job: "calendar", Failures: 2, Run: alert.Run}, alert)
+	assert.Equal(t, int64(12), alert.Run.Rows)
+	assert.Equal(t, "manual", alert.Run.Trigger)
+	assert.Len(t, alerts, 0, "alerts once per streak")
+
+	fail = false
+	runOnce()
+	alert = <-alerts
+	assert.True(t, alert.Recovered)
+	assert.Equal(t, 3, alert.Failures)
+}
+
+func TestConsecutiveFailures(t *testing.T) {
+	assert.Equal(t, 2, consecutiveFailures([]Run{{Outcome: "failure"}, {Outcome: "failure"}, {Outcome: "success"}, {Outcome: "failure"}}))
+	assert.Equal(t, 0, consecutiveFailures(nil))
+}
+
+func TestSkipWhen(t *testing.T) {
+	var skipping atomic.Bool
+	skipping.Store(true)
+	runs := make(chan string, 10)
+	s := New()
+	s.Add("refresh", 20*time.Millisecond, func(ctx context.Context) error {
+		runs <- "ran"
+		return nil
+	})
+	s.SkipWhen(skipping.Load)
+	s.Start()
+	defer s.Stop()
+
+	select {
+	case <-runs:
+		t.Fatal("scheduled run wasn't skipped")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	// Manual triggers run regardless
+	assert.NoError(t, s.Trigger("refresh"))
+	select {
+	case <-runs:
+	case <-time.After(time.Second):
+		t.Fatal("triggered job didn't run")
+	}
+
+	skipping.Store(false)
+	select {
+	case <-runs:
+	case <-time.After(time.Second):
+		t.Fatal("scheduled runs didn't resume")
+	}
+}
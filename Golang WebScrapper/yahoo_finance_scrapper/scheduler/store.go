@@ -0,0 +1,56 @@
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const overridesKey = "scheduler:overrides"
+
+// Override is an admin's change to a job, kept across restarts.
+type Override struct {
+	Paused   bool   `json:"paused"`
+	Schedule string `json:"schedule,omitempty"`
+}
+
+type Store struct {
+	redis *redis.Client
+	ctx   context.Context
+}
+
+func NewStore(rdb *redis.Client) *Store {
+	return &Store{
+		redis: rdb,
+		ctx:   context.Background(),
+	}
+}
+
+func (s *Store) Save(name string, override Override) error {
+	data, err := json.Marshal(override)
+	if err != nil {
+		return err
+	}
+	return s.redis.HSet(s.ctx, overridesKey, name, data).Err()
+}
+
+// Load returns the saved overrides by job name.
+func (s *Store) Load() (map[string]Override, error) {
+	values, err := s.redis.HGetAll(s.ctx, overridesKey).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	overrides := make(map[string]Override, len(values))
+	for name, value := range values {
+		var override Override
+		if err := json.Unmarshal([]byte(value), &override); err != nil {
+			log.Printf("Ignoring corrupt override of job %s: %v", name, err)
+			continue
+		}
+		overrides[name] = override
+	}
+	return overrides, nil
+}
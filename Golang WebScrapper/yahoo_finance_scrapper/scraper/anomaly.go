@@ -0,0 +1,129 @@
+package scraper
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"time"
+
+	"go-webscraper/metrics"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// A row moving more than maxPriceRatio times (or to less than
+	// 1/maxPriceRatio of) its last accepted price is far more likely a
+	// misparsed cell than a real move.
+	maxPriceRatio = 10.0
+	maxChangePerc = 1000.0
+
+	// Accepted values older than this are too stale to judge a jump by.
+	anomalyHistory = 7 * 24 * time.Hour
+
+	quarantineKey       = "anomaly:quarantine"
+	quarantineRetention = 24 * time.Hour
+)
+
+// Anomaly rules, also the rule label on the quarantine metric.
+const (
+	rulePriceNotPositive  = "price_not_positive"
+	ruleChangeImplausible = "change_implausible"
+	rulePriceJump         = "price_jump"
+	ruleVolumeZero        = "volume_zero"
+)
+
+// acceptedValue is the last non-suspect reading of a symbol from a source.
+type acceptedValue struct {
+	Price  float64 `json:"price"`
+	Volume int64   `json:"volume"`
+	At     int64   `json:"at"`
+}
+
+// Quarantine records one row flagged as suspect.
+type Quarantine struct {
+	Source string `json:"source"`
+	Symbol string `json:"symbol"`
+	Rule   string `json:"rule"`
+	Reason string `json:"reason"`
+	At     int64  `json:"at"`
+}
+
+func lastAcceptedKey(source string) string {
+	return fmt.Sprintf("anomaly:last:%s", source)
+}
+
+// anomaly checks stock against the last accepted value from the same
+// source, returning the rule it breaks and why, or "" when it looks sane.
+func anomaly(stock StockData, last *acceptedValue, now time.Time) (string, string) {
+	if stock.Price <= 0 {
+		return rulePriceNotPositive, fmt.Sprintf("price %v is not positive", stock.Price)
+	}
+	if math.Abs(stock.ChangePerc) > maxChangePerc {
+		return ruleChangeImplausible, fmt.Sprintf("change of %.2f%% in one session", stock.ChangePerc)
+	}
+	if last == nil || now.Sub(time.Unix(last.At, 0)) > anomalyHistory || last.Price <= 0 {
+		return "", ""
+	}
+	if ratio := stock.Price / last.Price; ratio > maxPriceRatio || ratio < 1/maxPriceRatio {
+		return rulePriceJump, fmt.Sprintf("price moved from %.2f to %.2f", last.Price, stock.Price)
+	}
+	if last.Volume > 0 && stock.Volume == 0 {
+		return ruleVolumeZero, fmt.Sprintf("volume dropped from %d to zero", last.Volume)
+	}
+	return "", ""
+}
+
+// screenAnomalies flags rows that break an anomaly rule as suspect, in
+// place, and records them in the quarantine log. Only sane rows become the
+// history later rows are judged against, so one glitch can't mask the
+// next.
+func (s *StockScraper) screenAnomalies(source string, stocks []StockData) {
+	if len(stocks) == 0 {
+		return
+	}
+	now := time.Now()
+	historyKey := s.region.CacheKey(lastAcceptedKey(source))
+
+	symbols := make([]string, len(stocks))
+	for i, stock := range stocks {
+		symbols[i] = stock.Symbol
+	}
+	previous, err := s.redis.HMGet(s.ctx, historyKey, symbols...).Result()
+	if err != nil {
+		previous = make([]interface{}, len(stocks))
+	}
+
+	accepted := make(map[string]interface{})
+	pipe := s.redis.Pipeline()
+	for i := range stocks {
+		stock := &stocks[i]
+
+		var last *acceptedValue
+		if raw, ok := previous[i].(string); ok {
+			var value acceptedValue
+			if json.Unmarshal([]byte(raw), &value) == nil {
+				last = &value
+			}
+		}
+
+		rule, reason := anomaly(*stock, last, now)
+		if rule == "" {
+			data, _ := json.Marshal(acceptedValue{Price: stock.Price, Volume: stock.Volume, At: now.Unix()})
+			accepted[stock.Symbol] = data
+			continue
+		}
+
+		stock.Suspect = true
+		stock.SuspectReason = reason
+		metrics.AnomaliesQuarantined.WithLabelValues(source, rule).Inc()
+
+		entry, _ := json.Marshal(Quarantine{Source: source, Symbol: stock.Symbol, Rule: rule, Reason: reason, At: now.Unix()})
+		pipe.ZAdd(s.ctx, quarantineKey, redis.Z{Score: float64(now.Unix()), Member: entry})
+	}
+	if len(accepted) > 0 {
+		pipe.HSet(s.ctx, historyKey, accepted)
+	}
+	pipe.ZRemRangeByScore(s.ctx, quarantineKey, "-inf", fmt.Sprintf("(%d", now.Add(-quarantineRetention).Unix()))
+	pipe.Exec(s.ctx)
+}
@@ -0,0 +1,37 @@
+package scraper
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAnomaly(t *testing.T) {
+	now := time.Now()
+	last := &acceptedValue{Price: 200, Volume: 1_000_000, At: now.Add(-10 * time.Minute).Unix()}
+
+	tests := []struct {
+		name  string
+		stock StockData
+		last  *acceptedValue
+		rule  string
+	}{
+		{"Sane", StockData{Price: 204, ChangePerc: 2, Volume: 900_000}, last, ""},
+		{"NoHistory", StockData{Price: 20_000, ChangePerc: 2}, nil, ""},
+		{"ZeroPrice", StockData{Price: 0}, last, rulePriceNotPositive},
+		{"ImplausibleChange", StockData{Price: 204, ChangePerc: 10_000}, last, ruleChangeImplausible},
+		{"PriceJump", StockData{Price: 20_400, ChangePerc: 2, Volume: 900_000}, last, rulePriceJump},
+		{"PriceCollapse", StockData{Price: 2.04, ChangePerc: -1, Volume: 900_000}, last, rulePriceJump},
+		{"VolumeZero", StockData{Price: 204, ChangePerc: 2}, last, ruleVolumeZero},
+		{"StaleHistory", StockData{Price: 20_400, ChangePerc: 2}, &acceptedValue{Price: 200, At: now.Add(-30 * 24 * time.Hour).Unix()}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, reason := anomaly(tt.stock, tt.last, now)
+			assert.Equal(t, tt.rule, rule)
+			assert.Equal(t, tt.rule == "", reason == "")
+		})
+	}
+}
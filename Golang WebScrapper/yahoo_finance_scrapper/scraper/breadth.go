@@ -0,0 +1,273 @@
+package scraper
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go-webscraper/cachekey"
+	"go-webscraper/queue"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+var breadthCacheKey = cachekey.Breadth.Key()
+
+// breadthLookback is how far back stored closes are searched for new
+// highs and lows, matching the usual 52-week definition.
+const breadthLookback = 52 * 7 * 24 * time.Hour
+
+// BreadthIndices are the indices whose constituents make up the breadth
+// universe. Most active stocks are used when none of them can be scraped.
+var BreadthIndices = []string{"^GSPC", "^DJI", "^IXIC"}
+
+// Breadth summarises how broadly the market is moving. NewHighs and
+// NewLows compare prices against the closes stored by previous refreshes,
+// so they only count once HistoryDays is non-zero.
+type Breadth struct {
+	Universe            int     `json:"universe"`
+	Advancers           int     `json:"advancers"`
+	Decliners           int     `json:"decliners"`
+	Unchanged           int     `json:"unchanged"`
+	AdvanceDeclineRatio float64 `json:"advance_decline_ratio"`
+	NewHighs            int     `json:"new_highs"`
+	NewLows             int     `json:"new_lows"`
+	UpVolume            int64   `json:"up_volume"`
+	DownVolume          int64   `json:"down_volume"`
+	UpDownVolumeRatio   float64 `json:"up_down_volume_ratio"`
+	HistoryDays         int     `json:"history_days"`
+	Timestamp           string  `json:"timestamp"`
+}
+
+type breadthQuote struct {
+	Symbol     string
+	Price      float64
+	ChangePerc float64
+	Volume     int64
+}
+
+func breadthClosesKey(day time.Time) string {
+	return fmt.Sprintf("breadth:closes:%s", day.Format("2006-01-02"))
+}
+
+func ratio(a, b float64) float64 {
+	if b == 0 {
+		return 0
+	}
+	return a / b
+}
+
+// computeBreadth counts quotes against the prior highs and lows of each
+// symbol. Symbols without history never count as a new high or low.
+func computeBreadth(quotes []breadthQuote, highs, lows map[string]float64) Breadth {
+	breadth := Breadth{Universe: len(quotes)}
+	for _, quote := range quotes {
+		switch {
+		case quote.ChangePerc > 0:
+			breadth.Advancers++
+			breadth.UpVolume += quote.Volume
+		case quote.ChangePerc < 0:
+			breadth.Decliners++
+			breadth.DownVolume += quote.Volume
+		default:
+			breadth.Unchanged++
+		}
+
+		if high, ok := highs[quote.Symbol]; ok && quote.Price > high {
+			breadth.NewHighs++
+		}
+		if low, ok := lows[quote.Symbol]; ok && quote.Price < low {
+			breadth.NewLows++
+		}
+	}
+	breadth.AdvanceDeclineRatio = ratio(float64(breadth.Advancers), float64(breadth.Decliners))
+	breadth.UpDownVolumeRatio = ratio(float64(breadth.UpVolume), float64(breadth.DownVolume))
+	return breadth
+}
+
+// breadthUniverse merges the constituents of BreadthIndices, falling back
+// to the most active list.
+func (s *StockScraper) breadthUniverse() ([]breadthQuote, error) {
+	quotes := make([]breadthQuote, 0)
+	seen := make(map[string]bool)
+
+	for _, symbol := range BreadthIndices {
+		index, err := s.ScrapeIndexComponents(symbol)
+		if err != nil {
+			if errors.Is(err, queue.ErrSaturated) {
+				return nil, err
+			}
+			continue
+		}
+		if index == nil {
+			continue
+		}
+		for _, component := range index.Components {
+			if seen[component.Symbol] || component.Price == 0 {
+				continue
+			}
+			seen[component.Symbol] = true
+			quotes = append(quotes, breadthQuote{
+				Symbol:     component.Symbol,
+				Price:      component.Price,
+				ChangePerc: component.ChangePerc,
+				Volume:     component.Volume,
+			})
+		}
+	}
+	if len(quotes) > 0 {
+		return quotes, nil
+	}
+
+	stocks, err := s.ScrapeMostActive()
+	if err != nil {
+		return nil, err
+	}
+	for _, stock := range stocks {
+		if stock.Symbol == "" || stock.Price == 0 {
+			continue
+		}
+		quotes = append(quotes, breadthQuote{
+			Symbol:     stock.Symbol,
+			Price:      stock.Price,
+			ChangePerc: stock.ChangePerc,
+			Volume:     stock.Volume,
+		})
+	}
+	return quotes, nil
+}
+
+// priorExtremes reads the stored daily closes before today and returns
+// each symbol's highest and lowest close, plus how many days had data.
+func (s *StockScraper) priorExtremes(now time.Time) (map[string]float64, map[string]float64, int, error) {
+	today := MarketMidnight(now)
+	pipe := s.redis.Pipeline()
+	days := make([]*redis.MapStringStringCmd, 0)
+	for day := today.AddDate(0, 0, -1); today.Sub(day) <= breadthLookback; day = day.AddDate(0, 0, -1) {
+		days = append(days, pipe.HGetAll(s.ctx, s.region.CacheKey(breadthClosesKey(day))))
+	}
+	if _, err := pipe.Exec(s.ctx); err != nil && err != redis.Nil {
+		return nil, nil, 0, err
+	}
+
+	highs := make(map[string]float64)
+	lows := make(map[string]float64)
+	history := 0
+	for _, day := range days {
+		closes := day.Val()
+		if len(closes) > 0 {
+			history++
+		}
+		for symbol, value := range closes {
+			price, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			if high, ok := highs[symbol]; !ok || price > high {
+				highs[symbol] = price
+			}
+			if low, ok := lows[symbol]; !ok || price < low {
+				lows[symbol] = price
+			}
+		}
+	}
+	return highs, lows, history, nil
+}
+
+// recordCloses stores today's prices. Each refresh overwrites the last,
+// so the hash ends the day holding the closing prices.
+func (s *StockScraper) recordCloses(quotes []breadthQuote, now time.Time) error {
+	if len(quotes) == 0 {
+		return nil
+	}
+	key := s.region.CacheKey(breadthClosesKey(MarketMidnight(now)))
+	values := make(map[string]interface{}, len(quotes))
+	for _, quote := range quotes {
+		values[quote.Symbol] = strconv.FormatFloat(quote.Price, 'f', -1, 64)
+	}
+
+	pipe := s.redis.Pipeline()
+	pipe.HSet(s.ctx, key, values)
+	pipe.Expire(s.ctx, key, breadthLookback+7*24*time.Hour)
+	_, err := pipe.Exec(s.ctx)
+	return err
+}
+
+// ScrapeBreadth computes market breadth over the BreadthIndices universe
+// and stores today's closes for future high/low comparisons.
+func (s *StockScraper) ScrapeBreadth() (*Breadth, error) {
+	cacheKey := s.region.CacheKey(breadthCacheKey)
+	if cached, err := s.cache.Get(s.ctx, cacheKey); err == nil && !s.fresh {
+		var breadth Breadth
+		if err := json.Unmarshal(cached, &breadth); err == nil {
+			return &breadth, nil
+		}
+	}
+
+	quotes, err := s.breadthUniverse()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	highs, lows, history, err := s.priorExtremes(now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read stored closes: %v", err)
+	}
+
+	breadth := computeBreadth(quotes, highs, lows)
+	breadth.HistoryDays = history
+	breadth.Timestamp = now.Format(time.RFC3339)
+
+	if err := s.recordCloses(quotes, now); err != nil {
+		return nil, fmt.Errorf("failed to store closes: %v", err)
+	}
+
+	cacheIfChanged(s.ctx, s.cache, s.tracker, cacheKey, breadth, s.ttl)
+
+	return &breadth, nil
+}
+
+func HandleBreadth(pool *queue.Pool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		region, err := LookupRegion(c.Query("region"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		scraper := NewStockScraper(StockScraperOption{
+			Region:  region.Code,
+			Context: c.Request.Context(),
+			Pool:    pool,
+		})
+		defer scraper.Close()
+
+		if notModifiedSince(c, scraper.ctx, scraper.cache, scraper.tracker, region.CacheKey(breadthCacheKey)) {
+			return
+		}
+
+		breadth, err := scraper.ScrapeBreadth()
+		if shed(c, pool, err) {
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		setCacheControl(c, scraper.cache, region.CacheKey(breadthCacheKey))
+		c.JSON(http.StatusOK, gin.H{
+			"status": "success",
+			"data":   breadth,
+		})
+	}
+}
@@ -0,0 +1,38 @@
+package scraper
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestComputeBreadth(t *testing.T) {
+	quotes := []breadthQuote{
+		{Symbol: "AAPL", Price: 240, ChangePerc: 1.2, Volume: 400},
+		{Symbol: "MSFT", Price: 410, ChangePerc: 0.4, Volume: 200},
+		{Symbol: "INTC", Price: 19, ChangePerc: -2.5, Volume: 300},
+		{Symbol: "KO", Price: 62, ChangePerc: 0, Volume: 50},
+		{Symbol: "NEW", Price: 10, ChangePerc: 3},
+	}
+	highs := map[string]float64{"AAPL": 237, "MSFT": 468, "INTC": 51}
+	lows := map[string]float64{"AAPL": 164, "MSFT": 385, "INTC": 20}
+
+	breadth := computeBreadth(quotes, highs, lows)
+
+	assert.Equal(t, 5, breadth.Universe)
+	assert.Equal(t, 3, breadth.Advancers)
+	assert.Equal(t, 1, breadth.Decliners)
+	assert.Equal(t, 1, breadth.Unchanged)
+	assert.Equal(t, 3.0, breadth.AdvanceDeclineRatio)
+	assert.Equal(t, 1, breadth.NewHighs, "only AAPL cleared its prior high")
+	assert.Equal(t, 1, breadth.NewLows, "only INTC broke its prior low")
+	assert.Equal(t, int64(600), breadth.UpVolume)
+	assert.Equal(t, int64(300), breadth.DownVolume)
+	assert.Equal(t, 2.0, breadth.UpDownVolumeRatio)
+}
+
+func TestComputeBreadthNoDecliners(t *testing.T) {
+	breadth := computeBreadth([]breadthQuote{{Symbol: "AAPL", Price: 1, ChangePerc: 1}}, nil, nil)
+	assert.Equal(t, 0.0, breadth.AdvanceDeclineRatio)
+	assert.Equal(t, 0, breadth.NewHighs)
+}
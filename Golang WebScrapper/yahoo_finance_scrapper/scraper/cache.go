@@ -0,0 +1,107 @@
+package scraper
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"go-webscraper/cache"
+	"go-webscraper/cachekey"
+	"go-webscraper/changes"
+
+	"github.com/gin-gonic/gin"
+)
+
+// staleTTL is how long past its last scrape a blob's stale copy can still
+// answer a shed request.
+const staleTTL = 24 * time.Hour
+
+// ttlFor is override when a scraper was built with an explicit CacheTTL,
+// otherwise the TTL policy of key's class.
+func ttlFor(key string, override time.Duration) time.Duration {
+	if override > 0 {
+		return override
+	}
+	return cachekey.TTLFor(key, MarketOpen(time.Now()))
+}
+
+// cacheIfChanged rewrites key only when the scraped content differs from
+// what was recorded last time; unchanged data just has its TTL extended.
+// A zero ttl selects the TTL by key class. Either way the provenance
+// logged in ctx is stored alongside, and a stale copy is kept for
+// staleTTL.
+func cacheIfChanged(ctx context.Context, store cache.Cache, tracker *changes.Tracker, key string, data interface{}, ttl time.Duration) {
+	ttl = ttlFor(key, ttl)
+	saveProvenance(ctx, store, key, ttl)
+	// Keep the change record for as long as either copy can be served
+	changed, _, err := tracker.Record(key, data, ttl+staleTTL)
+	if err == nil && !changed {
+		if ok, err := store.Expire(ctx, key, ttl); err == nil && ok {
+			if ok, err := store.Expire(ctx, cachekey.StaleCopy(key), staleTTL); err == nil && ok {
+				return
+			}
+		}
+	}
+
+	if jsonData, err := json.Marshal(data); err == nil {
+		store.Set(ctx, key, jsonData, ttl)
+		store.Set(ctx, cachekey.StaleCopy(key), jsonData, staleTTL)
+	}
+}
+
+// setCacheControl lets browsers and CDNs cache a response for as long as
+// the blobs behind it stay cached here: the shortest remaining TTL of
+// keys. A response built from anything not cached is marked no-cache.
+func setCacheControl(c *gin.Context, store cache.Cache, keys ...string) {
+	if len(keys) == 0 {
+		c.Header("Cache-Control", "no-cache")
+		return
+	}
+	var maxAge time.Duration
+	for i, key := range keys {
+		ttl, err := store.TTL(c.Request.Context(), key)
+		if err != nil || ttl <= 0 {
+			c.Header("Cache-Control", "no-cache")
+			return
+		}
+		if i == 0 || ttl < maxAge {
+			maxAge = ttl
+		}
+	}
+	c.Header("Cache-Control", fmt.Sprintf("public, max-age=%d", int(maxAge.Round(time.Second).Seconds())))
+}
+
+// notModifiedSince answers a ?changed_since= poll without scraping when the
+// cached entry for key is still live and hasn't changed since the given
+// time. It returns true when the response has already been written.
+func notModifiedSince(c *gin.Context, ctx context.Context, store cache.Cache, tracker *changes.Tracker, key string) bool {
+	changedAt, known := tracker.ChangedAt(key)
+	if known {
+		c.Header("X-Changed-At", changedAt.Format(time.RFC3339))
+	}
+
+	param := c.Query("changed_since")
+	if param == "" {
+		return false
+	}
+
+	since, err := time.Parse(time.RFC3339, param)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "changed_since must be an RFC3339 timestamp",
+		})
+		return true
+	}
+
+	if !known || changedAt.After(since) {
+		return false
+	}
+	if _, err := store.TTL(ctx, key); err != nil {
+		return false
+	}
+
+	c.Status(http.StatusNotModified)
+	return true
+}
@@ -0,0 +1,70 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"go-webscraper/cache"
+	"go-webscraper/cachekey"
+	"go-webscraper/queue"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetCacheControl(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	store := cache.NewMemory(10)
+	ctx := context.Background()
+	store.Set(ctx, "quote:AAPL", []byte("{}"), 5*time.Minute)
+	store.Set(ctx, "quote:MSFT", []byte("{}"), 90*time.Second)
+	store.Set(ctx, "stocks:most_active", []byte("{}"), 0)
+
+	for keys, want := range map[string]string{
+		"quote:AAPL":            "public, max-age=300",
+		"quote:AAPL,quote:MSFT": "public, max-age=90",
+		"quote:AAPL,quote:TSLA": "no-cache",
+		"stocks:most_active":    "no-cache",
+	} {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest("GET", "/api/quotes", nil)
+		setCacheControl(c, store, strings.Split(keys, ",")...)
+		assert.Equal(t, want, w.Header().Get("Cache-Control"), keys)
+	}
+}
+
+func TestServeStale(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	store := cache.NewMemory(10)
+	store.Set(context.Background(), cachekey.StaleCopy("sector:technology"), []byte(`{"name":"technology"}`), time.Hour)
+
+	r := gin.New()
+	r.Use(queue.NewInflight(1).Middleware())
+	r.GET("/:key", func(c *gin.Context) {
+		var sector SectorData
+		if serveStale(c.Request.Context(), store, c.Param("key"), &sector, queue.ErrOverloaded) {
+			c.JSON(http.StatusOK, sector)
+			return
+		}
+		c.Status(http.StatusServiceUnavailable)
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/sector:technology", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"name":"technology"`)
+	assert.NotEmpty(t, w.Header().Get("Warning"))
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/sector:energy", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+
+	var sector SectorData
+	assert.False(t, serveStale(context.Background(), store, "sector:technology", &sector, errors.New("scrape failed")))
+}
@@ -0,0 +1,142 @@
+package scraper
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"go-webscraper/cache"
+	"go-webscraper/cachekey"
+	"go-webscraper/changes"
+	"go-webscraper/keyspace"
+	"go-webscraper/pkg/yahoo"
+	"go-webscraper/queue"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+type EconomicEvent = yahoo.EconomicEvent
+
+type CalendarScraper struct {
+	redis    *redis.Client
+	cache    cache.Cache
+	ctx      context.Context
+	ttl      time.Duration
+	yahoo    *yahoo.Client
+	region   Region
+	tracker  *changes.Tracker
+	pool     *queue.Pool
+	priority queue.Priority
+	fresh    bool
+}
+
+func calendarCacheKey(date string) string {
+	return cachekey.EconomicCalendar.Key(date)
+}
+
+func NewCalendarScraper(opts ScraperOption) *CalendarScraper {
+	region, err := LookupRegion(opts.Region)
+	if err != nil {
+		log.Printf("%v, falling back to %s", err, DefaultRegion)
+		region = Regions[DefaultRegion]
+	}
+
+	rdb := newRedis(opts.RedisAddr, opts.RedisPassword, opts.RedisDB)
+	traceRedis(rdb)
+	keyspace.Instrument(rdb)
+
+	client, err := yahoo.New(yahoo.Option{Region: region.Code})
+	if err != nil {
+		log.Fatalf("Failed to create Yahoo client: %v", err)
+	}
+
+	return &CalendarScraper{
+		redis:    rdb,
+		cache:    cache.New(rdb),
+		tracker:  changes.NewTracker(rdb),
+		ctx:      optionContext(opts.Context),
+		ttl:      opts.CacheTTL,
+		yahoo:    client,
+		region:   region,
+		pool:     opts.Pool,
+		priority: opts.Priority,
+	}
+}
+
+func (s *CalendarScraper) ScrapeEconomicCalendar(date string) ([]EconomicEvent, error) {
+	cacheKey := s.region.CacheKey(calendarCacheKey(date))
+	if cached, err := s.cache.Get(s.ctx, cacheKey); err == nil && !s.fresh {
+		var events []EconomicEvent
+		if err := json.Unmarshal(cached, &events); err == nil {
+			return events, nil
+		}
+	}
+
+	release, err := acquire(s.ctx, s.pool, s.priority)
+	if err != nil {
+		var stale []EconomicEvent
+		if serveStale(s.ctx, s.cache, cacheKey, &stale, err) {
+			return stale, nil
+		}
+		return nil, err
+	}
+	defer release()
+
+	ctx := yahoo.WithFetchLog(s.ctx)
+	events, err := s.yahoo.EconomicCalendar(ctx, date)
+	if err != nil {
+		return nil, err
+	}
+
+	cacheIfChanged(ctx, s.cache, s.tracker, cacheKey, events, s.ttl)
+
+	return events, nil
+}
+
+func (s *CalendarScraper) Close() {
+	s.redis.Close()
+}
+
+func HandleEconomicCalendar(pool *queue.Pool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		date := c.DefaultQuery("date", time.Now().Format("2006-01-02"))
+		if _, err := time.Parse("2006-01-02", date); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "date must be formatted as YYYY-MM-DD",
+			})
+			return
+		}
+
+		scraper := NewCalendarScraper(ScraperOption{
+			Context: c.Request.Context(),
+			Pool:    pool,
+		})
+		defer scraper.Close()
+
+		if notModifiedSince(c, c.Request.Context(), scraper.cache, scraper.tracker, scraper.region.CacheKey(calendarCacheKey(date))) {
+			return
+		}
+
+		events, err := scraper.ScrapeEconomicCalendar(date)
+		if shed(c, pool, err) {
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		cacheKey := scraper.region.CacheKey(calendarCacheKey(date))
+		setCacheControl(c, scraper.cache, cacheKey)
+		c.JSON(http.StatusOK, withProvenance(c, scraper.cache, cacheKey, gin.H{
+			"status": "success",
+			"date":   date,
+			"data":   events,
+		}))
+	}
+}
@@ -0,0 +1,67 @@
+package scraper
+
+import (
+	"testing"
+
+	"go-webscraper/upstream"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func scrapeCalendarFixture(t *testing.T, redisAddr, page string) ([]EconomicEvent, error) {
+	t.Helper()
+	restore := upstream.UseTransport(fixtureTransport{"/calendar/economic": page})
+	defer restore()
+
+	s := NewCalendarScraper(ScraperOption{RedisAddr: redisAddr})
+	defer s.Close()
+	return s.ScrapeEconomicCalendar(fixtureDate)
+}
+
+func TestEconomicCalendarRows(t *testing.T) {
+	mr := miniredis.RunT(t)
+
+	events, err := scrapeCalendarFixture(t, mr.Addr(), "economic_calendar.html")
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	assert.Equal(t, EconomicEvent{
+		Event:       "Initial Jobless Claims",
+		Country:     "US",
+		ReleaseTime: "8:30 AM EDT",
+		Period:      "Oct 12",
+		Actual:      "241K",
+		Forecast:    "260K",
+		Previous:    "258K",
+		Revised:     "260K",
+		Date:        fixtureDate,
+	}, events[1])
+
+	// The second scrape is answered from the cache, not the page
+	cached, err := scrapeCalendarFixture(t, mr.Addr(), "missing.html")
+	require.NoError(t, err)
+	assert.Equal(t, events, cached)
+}
+
+func TestEconomicCalendarFallbackSelectors(t *testing.T) {
+	mr := miniredis.RunT(t)
+
+	events, err := scrapeCalendarFixture(t, mr.Addr(), "economic_calendar_fallback.html")
+	require.NoError(t, err)
+
+	// Event and country fall back to column position when the cells lose
+	// their labels; rows without an event, like ad slots, are skipped
+	require.Len(t, events, 2)
+	assert.Equal(t, EconomicEvent{
+		Event:       "CPI YY",
+		Country:     "GB",
+		ReleaseTime: "2:00 AM EDT",
+		Period:      "Sep",
+		Actual:      "1.7",
+		Forecast:    "1.9",
+		Previous:    "2.2",
+		Date:        fixtureDate,
+	}, events[0])
+	assert.Equal(t, EconomicEvent{Event: "ZEW Economic Sentiment", Country: "DE", Date: fixtureDate}, events[1])
+}
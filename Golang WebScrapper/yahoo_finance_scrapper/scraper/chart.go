@@ -0,0 +1,331 @@
+package scraper
+
+import (
+	"bytes"
+	"fmt"
+	"image/color"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gonum.org/v1/plot"
+	"gonum.org/v1/plot/plotter"
+	"gonum.org/v1/plot/vg"
+	"gonum.org/v1/plot/vg/draw"
+)
+
+const (
+	DefaultChartRange  = "1mo"
+	defaultChartWidth  = 800
+	defaultChartHeight = 400
+	minChartSize       = 200
+	maxChartSize       = 2000
+	// intradayCandle is how much of the day each 1d candle covers.
+	intradayCandle = 30 * time.Minute
+)
+
+// chartRanges are how far back each ?range= reaches, in years, months and
+// days, matching Yahoo's range names. 1d is today's intraday series, ytd
+// and max are handled apart.
+var chartRanges = map[string][3]int{
+	"5d":  {0, 0, 7},
+	"1mo": {0, 1, 0},
+	"3mo": {0, 3, 0},
+	"6mo": {0, 6, 0},
+	"1y":  {1, 0, 0},
+	"2y":  {2, 0, 0},
+	"5y":  {5, 0, 0},
+}
+
+var (
+	chartUp   = color.RGBA{R: 10, G: 138, B: 58, A: 255}
+	chartDown = color.RGBA{R: 201, G: 37, B: 44, A: 255}
+	chartLine = color.RGBA{R: 29, G: 98, B: 199, A: 255}
+)
+
+type candle struct {
+	At                     time.Time
+	Open, High, Low, Close float64
+}
+
+func validChartRange(rng string) bool {
+	_, ok := chartRanges[rng]
+	return ok || rng == "1d" || rng == "ytd" || rng == "max"
+}
+
+// chartPrices returns the stored prices a chart of rng plots: today's
+// intraday series for 1d, otherwise the stored end-of-day closes.
+func (s *StockScraper) chartPrices(symbol, rng string, now time.Time) ([]pricePoint, error) {
+	if rng == "1d" {
+		return s.intradayPoints(symbol)
+	}
+
+	from := ""
+	switch rng {
+	case "max":
+	case "ytd":
+		from = fmt.Sprintf("%d-01-01", now.In(marketLocation).Year())
+	default:
+		back := chartRanges[rng]
+		from = now.In(marketLocation).AddDate(-back[0], -back[1], -back[2]).Format("2006-01-02")
+	}
+	history, err := s.EODHistory(symbol, from, "")
+	if err != nil {
+		return nil, err
+	}
+
+	points := make([]pricePoint, 0, len(history))
+	for _, record := range history {
+		date, err := time.ParseInLocation("2006-01-02", record.Date, marketLocation)
+		if err != nil || record.Close <= 0 {
+			continue
+		}
+		points = append(points, pricePoint{At: date.Add(marketClose), Price: record.Close})
+	}
+	return points, nil
+}
+
+// intradayCandles groups points into candles of width each.
+func intradayCandles(points []pricePoint, width time.Duration) []candle {
+	candles := make([]candle, 0)
+	for _, point := range points {
+		start := point.At.Truncate(width)
+		if n := len(candles); n > 0 && candles[n-1].At.Equal(start) {
+			last := &candles[n-1]
+			last.High = math.Max(last.High, point.Price)
+			last.Low = math.Min(last.Low, point.Price)
+			last.Close = point.Price
+			continue
+		}
+		candles = append(candles, candle{At: start, Open: point.Price, High: point.Price, Low: point.Price, Close: point.Price})
+	}
+	return candles
+}
+
+// dailyCandles builds candles from closes alone: only closes are stored,
+// so each day opens at the previous close and its range spans the two.
+func dailyCandles(points []pricePoint) []candle {
+	candles := make([]candle, 0, len(points))
+	for i, point := range points {
+		open := point.Price
+		if i > 0 {
+			open = points[i-1].Price
+		}
+		candles = append(candles, candle{
+			At:    point.At,
+			Open:  open,
+			High:  math.Max(open, point.Price),
+			Low:   math.Min(open, point.Price),
+			Close: point.Price,
+		})
+	}
+	return candles
+}
+
+// candlesticks plots candles as bodies from open to close with wicks to
+// the high and low, green when the price rose.
+type candlesticks []candle
+
+func (cs candlesticks) DataRange() (xmin, xmax, ymin, ymax float64) {
+	xmin, ymin = math.Inf(1), math.Inf(1)
+	xmax, ymax = math.Inf(-1), math.Inf(-1)
+	for _, c := range cs {
+		x := float64(c.At.Unix())
+		xmin, xmax = math.Min(xmin, x), math.Max(xmax, x)
+		ymin, ymax = math.Min(ymin, c.Low), math.Max(ymax, c.High)
+	}
+	return xmin, xmax, ymin, ymax
+}
+
+func (cs candlesticks) Plot(c draw.Canvas, plt *plot.Plot) {
+	trX, trY := plt.Transforms(&c)
+
+	// Bodies take most of the narrowest gap between candles
+	width := vg.Length(math.Inf(1))
+	for i := 1; i < len(cs); i++ {
+		if gap := trX(float64(cs[i].At.Unix())) - trX(float64(cs[i-1].At.Unix())); gap < width {
+			width = gap
+		}
+	}
+	if math.IsInf(float64(width), 1) || width > 20 {
+		width = 20
+	}
+	half := width * 0.35
+
+	for _, candle := range cs {
+		clr := chartUp
+		if candle.Close < candle.Open {
+			clr = chartDown
+		}
+		x := trX(float64(candle.At.Unix()))
+		c.StrokeLine2(draw.LineStyle{Color: clr, Width: vg.Points(1)}, x, trY(candle.Low), x, trY(candle.High))
+
+		top, bottom := trY(math.Max(candle.Open, candle.Close)), trY(math.Min(candle.Open, candle.Close))
+		if top-bottom < 1 {
+			top = bottom + 1
+		}
+		c.FillPolygon(clr, []vg.Point{{X: x - half, Y: bottom}, {X: x + half, Y: bottom}, {X: x + half, Y: top}, {X: x - half, Y: top}})
+	}
+}
+
+// renderChart draws points as a line or candle chart, returning a PNG of
+// width by height pixels.
+func renderChart(symbol, rng, kind string, points []pricePoint, width, height int) ([]byte, error) {
+	p := plot.New()
+	p.Title.Text = fmt.Sprintf("%s (%s)", symbol, rng)
+	p.Add(plotter.NewGrid())
+
+	format := "Jan 2"
+	if rng == "1d" {
+		format = "15:04"
+	} else if rng == "2y" || rng == "5y" || rng == "max" {
+		format = "Jan 2006"
+	}
+	p.X.Tick.Marker = plot.TimeTicks{
+		Format: format,
+		Time: func(t float64) time.Time {
+			return time.Unix(int64(t), 0).In(marketLocation)
+		},
+	}
+
+	if kind == "candle" {
+		candles := dailyCandles(points)
+		if rng == "1d" {
+			candles = intradayCandles(points, intradayCandle)
+		}
+		p.Add(candlesticks(candles))
+	} else {
+		xys := make(plotter.XYs, len(points))
+		for i, point := range points {
+			xys[i] = plotter.XY{X: float64(point.At.Unix()), Y: point.Price}
+		}
+		line, err := plotter.NewLine(xys)
+		if err != nil {
+			return nil, err
+		}
+		line.Color = chartLine
+		line.Width = vg.Points(1.5)
+		p.Add(line)
+	}
+
+	// PNGs are drawn at 96 dots per inch, so this many points is width pixels
+	writer, err := p.WriterTo(vg.Length(width)*vg.Inch/96, vg.Length(height)*vg.Inch/96, "png")
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if _, err := writer.WriteTo(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func parseChartSize(c *gin.Context, name string, fallback int) (int, error) {
+	value := c.Query(name)
+	if value == "" {
+		return fallback, nil
+	}
+	size, err := strconv.Atoi(value)
+	if err != nil || size < minChartSize || size > maxChartSize {
+		return 0, fmt.Errorf("%s must be between %d and %d", name, minChartSize, maxChartSize)
+	}
+	return size, nil
+}
+
+// HandleChart renders /chart/:symbol.png from stored prices, for embedding
+// in emails, chat messages and the dashboard.
+func HandleChart() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		file := c.Param("file")
+		if !strings.HasSuffix(file, ".png") {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "charts are served as SYMBOL.png",
+			})
+			return
+		}
+		symbol := strings.ToUpper(strings.TrimSuffix(file, ".png"))
+		if !ValidSymbol(symbol) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "invalid symbol",
+			})
+			return
+		}
+
+		rng := c.DefaultQuery("range", DefaultChartRange)
+		if !validChartRange(rng) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "range must be one of: 1d, 5d, 1mo, 3mo, 6mo, ytd, 1y, 2y, 5y, max",
+			})
+			return
+		}
+		kind := c.DefaultQuery("type", "line")
+		if kind != "line" && kind != "candle" {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "type must be one of: line, candle",
+			})
+			return
+		}
+		width, err := parseChartSize(c, "width", defaultChartWidth)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+		height, err := parseChartSize(c, "height", defaultChartHeight)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		region, err := LookupRegion(c.Query("region"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		scraper := NewStockScraper(StockScraperOption{
+			Region:  region.Code,
+			Context: c.Request.Context(),
+		})
+		defer scraper.Close()
+
+		points, err := scraper.chartPrices(symbol, rng, time.Now())
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": fmt.Sprintf("failed to read stored prices: %v", err),
+			})
+			return
+		}
+		if len(points) < 2 {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": fmt.Sprintf("not enough stored prices to chart %s over %s", symbol, rng),
+			})
+			return
+		}
+
+		png, err := renderChart(symbol, rng, kind, points, width, height)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": fmt.Sprintf("failed to render chart: %v", err),
+			})
+			return
+		}
+
+		// Intraday charts change with every recorded quote, daily ones once
+		// a day
+		maxAge := 3600
+		if rng == "1d" {
+			maxAge = 60
+		}
+		c.Header("Cache-Control", fmt.Sprintf("public, max-age=%d", maxAge))
+		c.Data(http.StatusOK, "image/png", png)
+	}
+}
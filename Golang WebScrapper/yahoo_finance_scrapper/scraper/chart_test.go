@@ -0,0 +1,53 @@
+package scraper
+
+import (
+	"bytes"
+	"image/png"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChartCandles(t *testing.T) {
+	day := time.Date(2024, 7, 12, 9, 30, 0, 0, marketLocation)
+	points := []pricePoint{
+		{At: day, Price: 100},
+		{At: day.Add(10 * time.Minute), Price: 103},
+		{At: day.Add(20 * time.Minute), Price: 99},
+		{At: day.Add(35 * time.Minute), Price: 101},
+	}
+
+	candles := intradayCandles(points, 30*time.Minute)
+	assert.Equal(t, []candle{
+		{At: day, Open: 100, High: 103, Low: 99, Close: 99},
+		{At: day.Add(30 * time.Minute), Open: 101, High: 101, Low: 101, Close: 101},
+	}, candles)
+
+	candles = dailyCandles(points[:3])
+	assert.Equal(t, candle{At: day, Open: 100, High: 100, Low: 100, Close: 100}, candles[0])
+	assert.Equal(t, candle{At: points[2].At, Open: 103, High: 103, Low: 99, Close: 99}, candles[2])
+}
+
+func TestRenderChart(t *testing.T) {
+	start := time.Date(2024, 6, 3, 16, 0, 0, 0, marketLocation)
+	points := make([]pricePoint, 0, 20)
+	for i := 0; i < 20; i++ {
+		points = append(points, pricePoint{At: start.AddDate(0, 0, i), Price: 100 + float64(i%5)})
+	}
+
+	for _, kind := range []string{"line", "candle"} {
+		data, err := renderChart("AAPL", "1mo", kind, points, 640, 320)
+		assert.NoError(t, err)
+
+		img, err := png.Decode(bytes.NewReader(data))
+		if assert.NoError(t, err, kind) {
+			assert.Equal(t, 640, img.Bounds().Dx())
+			assert.Equal(t, 320, img.Bounds().Dy())
+		}
+	}
+
+	assert.True(t, validChartRange("1d"))
+	assert.True(t, validChartRange("ytd"))
+	assert.False(t, validChartRange("10y"))
+}
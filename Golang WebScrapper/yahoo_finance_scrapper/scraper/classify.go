@@ -0,0 +1,97 @@
+package scraper
+
+import (
+	"strings"
+
+	"go-webscraper/pkg/yahoo"
+)
+
+const (
+	ArticlePressRelease = yahoo.ArticlePressRelease
+	ArticleEditorial    = yahoo.ArticleEditorial
+	ArticleVideo        = yahoo.ArticleVideo
+	ArticleSponsored    = yahoo.ArticleSponsored
+)
+
+var ArticleTypes = yahoo.ArticleTypes
+
+func filterArticlesByType(articles []Article, types []string) []Article {
+	if len(types) == 0 {
+		return articles
+	}
+
+	filtered := make([]Article, 0, len(articles))
+	for _, article := range articles {
+		for _, t := range types {
+			if article.Type == t {
+				filtered = append(filtered, article)
+				break
+			}
+		}
+	}
+	return filtered
+}
+
+// filterArticlesByByline keeps articles from any of publishers and by any
+// of authors, matched case-insensitively. An empty list doesn't filter.
+func filterArticlesByByline(articles []Article, publishers, authors []string) []Article {
+	if len(publishers) == 0 && len(authors) == 0 {
+		return articles
+	}
+
+	matches := func(value string, wanted []string) bool {
+		if len(wanted) == 0 {
+			return true
+		}
+		for _, w := range wanted {
+			if strings.EqualFold(value, w) {
+				return true
+			}
+		}
+		return false
+	}
+
+	filtered := make([]Article, 0, len(articles))
+	for _, article := range articles {
+		if matches(article.Publisher, publishers) && matches(article.Author, authors) {
+			filtered = append(filtered, article)
+		}
+	}
+	return filtered
+}
+
+// splitList splits a comma-separated query value, dropping empty items.
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// parseArticleTypes splits a comma-separated ?type= value, rejecting
+// unknown types.
+func parseArticleTypes(value string) ([]string, bool) {
+	if value == "" {
+		return nil, true
+	}
+
+	types := make([]string, 0)
+	for _, t := range strings.Split(value, ",") {
+		t = strings.ToLower(strings.TrimSpace(t))
+		valid := false
+		for _, known := range ArticleTypes {
+			if t == known {
+				valid = true
+				break
+			}
+		}
+		if !valid {
+			return nil, false
+		}
+		types = append(types, t)
+	}
+	return types, true
+}
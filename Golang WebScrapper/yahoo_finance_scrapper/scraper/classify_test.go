@@ -0,0 +1,32 @@
+package scraper
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseArticleTypes(t *testing.T) {
+	types, ok := parseArticleTypes("editorial, Video")
+	assert.True(t, ok)
+	assert.Equal(t, []string{"editorial", "video"}, types)
+
+	_, ok = parseArticleTypes("opinion")
+	assert.False(t, ok)
+}
+
+func TestFilterArticlesByByline(t *testing.T) {
+	articles := []Article{
+		{Title: "a", Publisher: "Reuters", Author: "Jane Doe"},
+		{Title: "b", Publisher: "Bloomberg"},
+		{Title: "c", Publisher: "reuters", Author: "John Roe"},
+	}
+
+	assert.Len(t, filterArticlesByByline(articles, nil, nil), 3)
+	assert.Len(t, filterArticlesByByline(articles, []string{"REUTERS"}, nil), 2)
+	assert.Len(t, filterArticlesByByline(articles, []string{"Reuters", "Bloomberg"}, nil), 3)
+	filtered := filterArticlesByByline(articles, []string{"Reuters"}, []string{"john roe"})
+	assert.Len(t, filtered, 1)
+	assert.Equal(t, "c", filtered[0].Title)
+	assert.Equal(t, []string{"Reuters", "AP"}, splitList(" Reuters, ,AP"))
+}
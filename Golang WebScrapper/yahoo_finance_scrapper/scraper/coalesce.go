@@ -0,0 +1,110 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"go-webscraper/metrics"
+)
+
+// quoteFlight is one scrape of a symbol's quote. Everyone asking for the
+// symbol within the coalescing window shares it.
+type quoteFlight struct {
+	started time.Time
+	done    chan struct{}
+	quote   *StockData
+	err     error
+}
+
+// coalescer hands out quote flights by cache key, so however many
+// watchlists, alerts and requests want a symbol, it is scraped at most
+// once per window.
+type coalescer struct {
+	mu      sync.Mutex
+	window  time.Duration
+	flights map[string]*quoteFlight
+}
+
+var quoteFlights = &coalescer{
+	window:  5 * time.Second,
+	flights: make(map[string]*quoteFlight),
+}
+
+// ConfigureCoalescing sets how long a quote scrape answers for its symbol.
+// Zero scrapes every request.
+func ConfigureCoalescing(window time.Duration) error {
+	if window < 0 {
+		return fmt.Errorf("coalesce window must not be negative")
+	}
+	quoteFlights.mu.Lock()
+	defer quoteFlights.mu.Unlock()
+	quoteFlights.window = window
+	return nil
+}
+
+// claim returns a flight for each key, and which of them are new and must
+// be flown by the caller. Flights that failed or are older than the window
+// are replaced.
+func (c *coalescer) claim(keys []string, now time.Time) (map[string]*quoteFlight, map[string]bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for key, f := range c.flights {
+		if now.Sub(f.started) >= c.window && f.finished() {
+			delete(c.flights, key)
+		}
+	}
+
+	flights := make(map[string]*quoteFlight, len(keys))
+	owned := make(map[string]bool)
+	for _, key := range keys {
+		if _, ok := flights[key]; ok {
+			continue
+		}
+		if f, ok := c.flights[key]; ok && now.Sub(f.started) < c.window {
+			flights[key] = f
+			metrics.CoalescedQuotes.Inc()
+			continue
+		}
+		f := &quoteFlight{started: now, done: make(chan struct{})}
+		if c.window > 0 {
+			c.flights[key] = f
+		}
+		flights[key] = f
+		owned[key] = true
+	}
+	return flights, owned
+}
+
+// land completes a flight. A failed flight is dropped at once so the next
+// caller retries instead of sharing the error for the rest of the window.
+func (c *coalescer) land(key string, f *quoteFlight, quote *StockData, err error) {
+	c.mu.Lock()
+	f.quote, f.err = quote, err
+	if err != nil && c.flights[key] == f {
+		delete(c.flights, key)
+	}
+	c.mu.Unlock()
+	close(f.done)
+}
+
+func (f *quoteFlight) finished() bool {
+	select {
+	case <-f.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// wait blocks until the flight lands or ctx ends.
+func (f *quoteFlight) wait(ctx context.Context) (*StockData, error) {
+	select {
+	case <-f.done:
+		return f.quote, f.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
@@ -0,0 +1,49 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCoalescer(t *testing.T) {
+	c := &coalescer{window: 5 * time.Second, flights: make(map[string]*quoteFlight)}
+	now := time.Date(2026, 10, 16, 14, 0, 0, 0, time.UTC)
+
+	flights, owned := c.claim([]string{"AAPL", "MSFT", "AAPL"}, now)
+	assert.Len(t, flights, 2)
+	assert.Equal(t, map[string]bool{"AAPL": true, "MSFT": true}, owned)
+
+	// A second caller in the window shares the flights in progress
+	shared, owned := c.claim([]string{"AAPL", "NVDA"}, now.Add(time.Second))
+	assert.Equal(t, map[string]bool{"NVDA": true}, owned)
+	assert.Same(t, flights["AAPL"], shared["AAPL"])
+
+	c.land("AAPL", flights["AAPL"], &StockData{Symbol: "AAPL", Price: 230}, nil)
+	quote, err := shared["AAPL"].wait(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 230.0, quote.Price)
+
+	// Failures aren't shared past the callers already waiting
+	c.land("MSFT", flights["MSFT"], nil, errors.New("boom"))
+	_, err = flights["MSFT"].wait(context.Background())
+	assert.EqualError(t, err, "boom")
+	_, owned = c.claim([]string{"MSFT"}, now.Add(2*time.Second))
+	assert.True(t, owned["MSFT"])
+
+	_, owned = c.claim([]string{"AAPL"}, now.Add(5*time.Second))
+	assert.True(t, owned["AAPL"])
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = shared["NVDA"].wait(ctx)
+	assert.ErrorIs(t, err, context.Canceled)
+
+	c = &coalescer{flights: make(map[string]*quoteFlight)}
+	c.claim([]string{"AAPL"}, now)
+	_, owned = c.claim([]string{"AAPL"}, now)
+	assert.True(t, owned["AAPL"])
+}
@@ -0,0 +1,109 @@
+package scraper
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"go-webscraper/cachekey"
+	"go-webscraper/pkg/yahoo"
+	"go-webscraper/queue"
+
+	"github.com/gin-gonic/gin"
+)
+
+var commoditiesCacheKey = cachekey.Commodities.Key()
+
+// Commodities maps the front-month continuous futures Yahoo quotes to a
+// readable name.
+var Commodities = yahoo.Commodities
+
+type CommodityQuote = yahoo.CommodityQuote
+
+// commodityTTL keeps quotes fresh while CME Globex trades (Sunday 18:00 to
+// Friday 17:00 ET with a daily hour break) and caches them for longer
+// once settlement prices stop moving.
+func commodityTTL(now time.Time) time.Duration {
+	et := now.In(marketLocation)
+	hour := et.Hour()
+
+	switch et.Weekday() {
+	case time.Saturday:
+		return 30 * time.Minute
+	case time.Sunday:
+		if hour < 18 {
+			return 30 * time.Minute
+		}
+	case time.Friday:
+		if hour >= 17 {
+			return 30 * time.Minute
+		}
+	}
+	if hour == 17 {
+		return 15 * time.Minute
+	}
+	return 1 * time.Minute
+}
+
+// ScrapeCommodities fetches every commodity's quote page in one batch.
+func (s *StockScraper) ScrapeCommodities() ([]CommodityQuote, error) {
+	cacheKey := s.region.CacheKey(commoditiesCacheKey)
+	if cached, err := s.cache.Get(s.ctx, cacheKey); err == nil && !s.fresh {
+		var quotes []CommodityQuote
+		if err := json.Unmarshal(cached, &quotes); err == nil {
+			return quotes, nil
+		}
+	}
+
+	release, err := acquire(s.ctx, s.pool, s.priority)
+	if err != nil {
+		var stale []CommodityQuote
+		if serveStale(s.ctx, s.cache, cacheKey, &stale, err) {
+			return stale, nil
+		}
+		return nil, err
+	}
+	defer release()
+
+	ctx := yahoo.WithFetchLog(s.ctx)
+	result, err := s.yahoo.Commodities(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	cacheIfChanged(ctx, s.cache, s.tracker, cacheKey, result, commodityTTL(time.Now()))
+
+	return result, nil
+}
+
+func HandleCommodities(pool *queue.Pool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		scraper := NewStockScraper(StockScraperOption{
+			Context: c.Request.Context(),
+			Pool:    pool,
+		})
+		defer scraper.Close()
+
+		if notModifiedSince(c, c.Request.Context(), scraper.cache, scraper.tracker, scraper.region.CacheKey(commoditiesCacheKey)) {
+			return
+		}
+
+		quotes, err := scraper.ScrapeCommodities()
+		if shed(c, pool, err) {
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		cacheKey := scraper.region.CacheKey(commoditiesCacheKey)
+		setCacheControl(c, scraper.cache, cacheKey)
+		c.JSON(http.StatusOK, withProvenance(c, scraper.cache, cacheKey, gin.H{
+			"status": "success",
+			"data":   quotes,
+		}))
+	}
+}
@@ -0,0 +1,22 @@
+package scraper
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCommodityTTL(t *testing.T) {
+	et := func(day, hour int) time.Time {
+		// November 2024: the 3rd is a Sunday
+		return time.Date(2024, 11, day, hour, 0, 0, 0, marketLocation)
+	}
+
+	assert.Equal(t, 1*time.Minute, commodityTTL(et(5, 10)), "Tuesday session")
+	assert.Equal(t, 15*time.Minute, commodityTTL(et(5, 17)), "daily maintenance break")
+	assert.Equal(t, 30*time.Minute, commodityTTL(et(8, 18)), "Friday after close")
+	assert.Equal(t, 30*time.Minute, commodityTTL(et(9, 12)), "Saturday")
+	assert.Equal(t, 30*time.Minute, commodityTTL(et(3, 12)), "Sunday before open")
+	assert.Equal(t, 1*time.Minute, commodityTTL(et(3, 19)), "Sunday evening open")
+}
@@ -0,0 +1,56 @@
+package scraper
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"go-webscraper/cache"
+	"go-webscraper/cachekey"
+	"go-webscraper/pkg/yahoo"
+)
+
+// validatorsTTL is how long the last full copy of a conditionally scraped
+// blob is kept for 304s to restore. Pages unchanged for longer are just
+// scraped again.
+const validatorsTTL = 7 * 24 * time.Hour
+
+// conditionalRecord is what a conditional scrape needs next time: the
+// validators of every page it fetched and the blob parsed from them.
+type conditionalRecord struct {
+	Validators map[string]yahoo.Validators `json:"validators"`
+	Data       json.RawMessage             `json:"data"`
+}
+
+// scrapeConditional runs scrape, which stores its result through out, with
+// conditional requests for the pages behind key. When every page answers
+// 304 nothing was parsed, so the last full copy is decoded into out and
+// put back at key; otherwise the caller caches out as usual. It reports
+// whether the copy was reused.
+func scrapeConditional(ctx context.Context, store cache.Cache, key string, ttl time.Duration, out interface{}, scrape func(ctx context.Context) error) (bool, error) {
+	var record conditionalRecord
+	if data, err := store.Get(ctx, cachekey.Validators(key)); err == nil {
+		json.Unmarshal(data, &record)
+	}
+
+	condCtx, cond := yahoo.WithConditional(ctx, record.Validators)
+	if err := scrape(condCtx); err != nil {
+		return false, err
+	}
+
+	if cond.NotModified() && len(record.Data) > 0 {
+		if err := json.Unmarshal(record.Data, out); err == nil {
+			store.Set(ctx, key, []byte(record.Data), ttlFor(key, ttl))
+			store.Expire(ctx, cachekey.Validators(key), validatorsTTL)
+			return true, nil
+		}
+	}
+
+	if validators := cond.Validators(); len(validators) > 0 {
+		if data, err := json.Marshal(out); err == nil {
+			encoded, _ := json.Marshal(conditionalRecord{Validators: validators, Data: data})
+			store.Set(ctx, cachekey.Validators(key), encoded, validatorsTTL)
+		}
+	}
+	return false, nil
+}
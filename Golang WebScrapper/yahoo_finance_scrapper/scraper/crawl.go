@@ -0,0 +1,75 @@
+package scraper
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// CrawlLimits bound how far a single news crawl may spider. Zero fields
+// take the defaults; anything above the caps is clamped.
+type CrawlLimits struct {
+	MaxPages    int           `json:"max_pages"`
+	MaxDepth    int           `json:"max_depth"`
+	MaxDuration time.Duration `json:"max_duration"`
+}
+
+var (
+	DefaultCrawlLimits = CrawlLimits{MaxPages: 50, MaxDepth: 2, MaxDuration: 30 * time.Second}
+	MaxCrawlLimits     = CrawlLimits{MaxPages: 200, MaxDepth: 3, MaxDuration: 90 * time.Second}
+)
+
+func (l CrawlLimits) normalize() CrawlLimits {
+	if l.MaxPages <= 0 {
+		l.MaxPages = DefaultCrawlLimits.MaxPages
+	}
+	if l.MaxDepth <= 0 {
+		l.MaxDepth = DefaultCrawlLimits.MaxDepth
+	}
+	if l.MaxDuration <= 0 {
+		l.MaxDuration = DefaultCrawlLimits.MaxDuration
+	}
+
+	if l.MaxPages > MaxCrawlLimits.MaxPages {
+		l.MaxPages = MaxCrawlLimits.MaxPages
+	}
+	if l.MaxDepth > MaxCrawlLimits.MaxDepth {
+		l.MaxDepth = MaxCrawlLimits.MaxDepth
+	}
+	if l.MaxDuration > MaxCrawlLimits.MaxDuration {
+		l.MaxDuration = MaxCrawlLimits.MaxDuration
+	}
+	return l
+}
+
+// parseCrawlLimits reads max_pages, max_depth and max_duration (a Go
+// duration such as 20s) as sent in a query string or job params.
+func parseCrawlLimits(pages, depth, duration string) (CrawlLimits, error) {
+	var limits CrawlLimits
+	var err error
+
+	if pages != "" {
+		if limits.MaxPages, err = strconv.Atoi(pages); err != nil || limits.MaxPages < 0 {
+			return limits, fmt.Errorf("max_pages must be a positive integer")
+		}
+	}
+	if depth != "" {
+		if limits.MaxDepth, err = strconv.Atoi(depth); err != nil || limits.MaxDepth < 0 {
+			return limits, fmt.Errorf("max_depth must be a positive integer")
+		}
+	}
+	if duration != "" {
+		if limits.MaxDuration, err = time.ParseDuration(duration); err != nil || limits.MaxDuration < 0 {
+			return limits, fmt.Errorf("max_duration must be a positive duration, e.g. 20s")
+		}
+	}
+	return limits.normalize(), nil
+}
+
+func (l CrawlLimits) params() map[string]string {
+	return map[string]string{
+		"max_pages":    strconv.Itoa(l.MaxPages),
+		"max_depth":    strconv.Itoa(l.MaxDepth),
+		"max_duration": l.MaxDuration.String(),
+	}
+}
@@ -0,0 +1,35 @@
+package scraper
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseCrawlLimits(t *testing.T) {
+	t.Run("Defaults", func(t *testing.T) {
+		limits, err := parseCrawlLimits("", "", "")
+		assert.NoError(t, err)
+		assert.Equal(t, DefaultCrawlLimits, limits)
+	})
+
+	t.Run("Clamped To Caps", func(t *testing.T) {
+		limits, err := parseCrawlLimits("1000", "10", "10m")
+		assert.NoError(t, err)
+		assert.Equal(t, MaxCrawlLimits, limits)
+	})
+
+	t.Run("Within Caps", func(t *testing.T) {
+		limits, err := parseCrawlLimits("10", "1", "5s")
+		assert.NoError(t, err)
+		assert.Equal(t, CrawlLimits{MaxPages: 10, MaxDepth: 1, MaxDuration: 5 * time.Second}, limits)
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		_, err := parseCrawlLimits("lots", "", "")
+		assert.Error(t, err)
+		_, err = parseCrawlLimits("", "", "-5s")
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,188 @@
+package scraper
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"go-webscraper/fx"
+
+	"github.com/gin-gonic/gin"
+)
+
+// convertStock converts stock out of the currency it is quoted in. Rows
+// in the target currency already, or whose currency the page didn't give,
+// are left as they are.
+func convertStock(stock StockData, rates map[string]*fx.Rate) StockData {
+	rate := rates[stock.Currency]
+	if rate == nil {
+		return stock
+	}
+	stock.Price *= rate.Rate
+	stock.Change *= rate.Rate
+	stock.MarketCap = fx.ConvertMarketCap(stock.MarketCap, rate.Rate)
+	stock.Currency = rate.To
+	return stock
+}
+
+func convertStocks(stocks []StockData, rates map[string]*fx.Rate) []StockData {
+	converted := make([]StockData, 0, len(stocks))
+	for _, stock := range stocks {
+		converted = append(converted, convertStock(stock, rates))
+	}
+	return converted
+}
+
+// convertSector converts the sector's own totals, which the page gives in
+// the site's currency base, and each top stock from its own currency.
+func convertSector(sector *SectorData, base string, rates map[string]*fx.Rate) *SectorData {
+	converted := *sector
+	converted.TopStocks = convertStocks(sector.TopStocks, rates)
+	rate := rates[base]
+	if rate == nil {
+		return &converted
+	}
+	converted.MarketCap = fx.ConvertMarketCap(sector.MarketCap, rate.Rate)
+	converted.SubIndustries = make([]SubSector, 0, len(sector.SubIndustries))
+	for _, sub := range sector.SubIndustries {
+		sub.MarketCap = fx.ConvertMarketCap(sub.MarketCap, rate.Rate)
+		converted.SubIndustries = append(converted.SubIndustries, sub)
+	}
+	return &converted
+}
+
+func convertData(data interface{}, base string, rates map[string]*fx.Rate) (interface{}, error) {
+	switch v := data.(type) {
+	case []StockData:
+		return convertStocks(v, rates), nil
+	case map[string][]StockData:
+		converted := make(map[string][]StockData, len(v))
+		for category, stocks := range v {
+			converted[category] = convertStocks(stocks, rates)
+		}
+		return converted, nil
+	case *SectorData:
+		return convertSector(v, base, rates), nil
+	case map[string]*SectorData:
+		converted := make(map[string]*SectorData, len(v))
+		for name, sector := range v {
+			converted[name] = convertSector(sector, base, rates)
+		}
+		return converted, nil
+	}
+	return nil, fmt.Errorf("unsupported data type for currency conversion")
+}
+
+// currencies lists the currencies data is quoted in: each row's own, and
+// base for sector totals.
+func currencies(data interface{}, base string) []string {
+	seen := make(map[string]bool)
+	addStocks := func(stocks []StockData) {
+		for _, stock := range stocks {
+			if stock.Currency != "" {
+				seen[stock.Currency] = true
+			}
+		}
+	}
+	switch v := data.(type) {
+	case []StockData:
+		addStocks(v)
+	case map[string][]StockData:
+		for _, stocks := range v {
+			addStocks(stocks)
+		}
+	case *SectorData:
+		seen[base] = true
+		addStocks(v.TopStocks)
+	case map[string]*SectorData:
+		seen[base] = true
+		for _, sector := range v {
+			addStocks(sector.TopStocks)
+		}
+	}
+	codes := make([]string, 0, len(seen))
+	for code := range seen {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	return codes
+}
+
+var currency = struct {
+	mu        sync.Mutex
+	converter *fx.Converter
+}{}
+
+// ConfigureCurrency sets the converter every ?currency= conversion shares.
+// Without one, the first conversion makes one on the configured Redis.
+func ConfigureCurrency(converter *fx.Converter) {
+	currency.mu.Lock()
+	defer currency.mu.Unlock()
+	currency.converter = converter
+}
+
+func currencyConverter() *fx.Converter {
+	currency.mu.Lock()
+	defer currency.mu.Unlock()
+	if currency.converter == nil {
+		redisServer.mu.RLock()
+		currency.converter = fx.NewConverter(fx.ConverterOption{
+			RedisAddr:     redisServer.addr,
+			RedisPassword: redisServer.password,
+			RedisDB:       redisServer.db,
+		})
+		redisServer.mu.RUnlock()
+	}
+	return currency.converter
+}
+
+// applyCurrency converts data into the currency requested by the
+// ?currency= query parameter, each row from the currency it is quoted in
+// and sector totals from base, the site's currency. The returned rates,
+// one per source currency, are nil when no conversion was requested. A
+// malformed code fails with fx.ErrInvalidCurrency; anything else is a
+// failure fetching a rate.
+func applyCurrency(c *gin.Context, base string, data interface{}) (interface{}, []*fx.Rate, error) {
+	target := strings.ToUpper(c.Query("currency"))
+	if target == "" {
+		return data, nil, nil
+	}
+	if !fx.ValidCode(target) {
+		return nil, nil, fmt.Errorf("%w: %s", fx.ErrInvalidCurrency, target)
+	}
+
+	rates := make(map[string]*fx.Rate)
+	used := make([]*fx.Rate, 0)
+	for _, from := range currencies(data, base) {
+		if from == target {
+			continue
+		}
+		rate, err := currencyConverter().Rate(from, target)
+		if err != nil {
+			return nil, nil, err
+		}
+		rates[from] = rate
+		used = append(used, rate)
+	}
+
+	converted, err := convertData(data, base, rates)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(used) == 0 {
+		return data, nil, nil
+	}
+	return converted, used, nil
+}
+
+// currencyStatus is the status for an applyCurrency error: the caller's
+// fault only when the code itself was bad.
+func currencyStatus(err error) int {
+	if errors.Is(err, fx.ErrInvalidCurrency) {
+		return http.StatusBadRequest
+	}
+	return http.StatusBadGateway
+}
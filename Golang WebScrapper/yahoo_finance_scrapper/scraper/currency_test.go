@@ -0,0 +1,104 @@
+package scraper
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go-webscraper/fx"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConvertData(t *testing.T) {
+	rates := map[string]*fx.Rate{"USD": {From: "USD", To: "EUR", Rate: 0.5}}
+	apple := StockData{Symbol: "AAPL", Price: 200, Change: -4, MarketCap: "3.2T", Currency: "USD"}
+
+	stocks, err := convertData([]StockData{apple}, "USD", rates)
+	require.NoError(t, err)
+	converted := stocks.([]StockData)[0]
+	assert.Equal(t, 100.0, converted.Price)
+	assert.Equal(t, -2.0, converted.Change)
+	assert.Equal(t, "1.600T", converted.MarketCap)
+	assert.Equal(t, "EUR", converted.Currency)
+	assert.Equal(t, 200.0, apple.Price, "the source row is left alone")
+
+	overview, err := convertData(map[string][]StockData{"gainers": {apple}}, "USD", rates)
+	require.NoError(t, err)
+	assert.Equal(t, 100.0, overview.(map[string][]StockData)["gainers"][0].Price)
+
+	tech := &SectorData{
+		Name:          "Technology",
+		MarketCap:     "15.4T",
+		TopStocks:     []StockData{apple},
+		SubIndustries: []SubSector{{Name: "Semiconductors", MarketCap: "900B"}},
+	}
+	sector, err := convertData(tech, "USD", rates)
+	require.NoError(t, err)
+	assert.Equal(t, "7.700T", sector.(*SectorData).MarketCap)
+	assert.Equal(t, "450.000B", sector.(*SectorData).SubIndustries[0].MarketCap)
+	assert.Equal(t, "EUR", sector.(*SectorData).TopStocks[0].Currency)
+	assert.Equal(t, "15.4T", tech.MarketCap, "the cached sector is left alone")
+	assert.Equal(t, "900B", tech.SubIndustries[0].MarketCap)
+
+	sectors, err := convertData(map[string]*SectorData{"technology": tech}, "USD", rates)
+	require.NoError(t, err)
+	assert.Equal(t, "7.700T", sectors.(map[string]*SectorData)["technology"].MarketCap)
+
+	_, err = convertData([]Article{}, "USD", rates)
+	assert.Error(t, err)
+}
+
+func TestConvertDataByRowCurrency(t *testing.T) {
+	rates := map[string]*fx.Rate{
+		"GBP": {From: "GBP", To: "USD", Rate: 1.25},
+		"EUR": {From: "EUR", To: "USD", Rate: 1.1},
+	}
+	stocks := []StockData{
+		{Symbol: "VOD.L", Price: 100, Currency: "GBP"},
+		{Symbol: "SAP.DE", Price: 100, Currency: "EUR"},
+		{Symbol: "AAPL", Price: 100, Currency: "USD"},
+		{Symbol: "UNKNOWN", Price: 100},
+	}
+	assert.Equal(t, []string{"EUR", "GBP", "USD"}, currencies(stocks, "GBP"))
+
+	converted, err := convertData(stocks, "GBP", rates)
+	require.NoError(t, err)
+	rows := converted.([]StockData)
+	assert.Equal(t, 125.0, rows[0].Price)
+	assert.InDelta(t, 110.0, rows[1].Price, 1e-9)
+	assert.Equal(t, "USD", rows[1].Currency)
+	assert.Equal(t, 100.0, rows[2].Price)
+	assert.Equal(t, StockData{Symbol: "UNKNOWN", Price: 100}, rows[3], "rows without a currency are left alone")
+}
+
+func TestApplyCurrencyErrors(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	request := func(query string) *gin.Context {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest(http.MethodGet, "/api/stocks"+query, nil)
+		return c
+	}
+	data := []StockData{{Symbol: "AAPL", Price: 1}}
+
+	same, rates, err := applyCurrency(request("?currency=usd"), "USD", []StockData{{Symbol: "AAPL", Price: 1, Currency: "USD"}})
+	assert.NoError(t, err)
+	assert.Nil(t, rates)
+	assert.Equal(t, []StockData{{Symbol: "AAPL", Price: 1, Currency: "USD"}}, same)
+
+	same, rates, err = applyCurrency(request("?currency=eur"), "USD", data)
+	assert.NoError(t, err, "nothing to convert without a row currency")
+	assert.Nil(t, rates)
+	assert.Equal(t, data, same)
+
+	for _, code := range []string{"EURO", "E1R", "$"} {
+		_, _, err := applyCurrency(request("?currency="+code), "USD", data)
+		assert.ErrorIs(t, err, fx.ErrInvalidCurrency, code)
+		assert.Equal(t, http.StatusBadRequest, currencyStatus(err))
+	}
+
+	assert.Equal(t, http.StatusBadGateway, currencyStatus(errors.New("failed to scrape exchange rate USD/EUR")))
+}
@@ -0,0 +1,246 @@
+package scraper
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"go-webscraper/cachekey"
+	"go-webscraper/pkg/parse"
+	"go-webscraper/pkg/yahoo"
+	"go-webscraper/queue"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gocolly/colly"
+)
+
+var majorIndicesCacheKey = cachekey.MajorIndices.Key()
+
+// dashboardTimeout bounds each section; a slow one is reported in Errors
+// instead of holding up the rest of the page.
+const dashboardTimeout = 20 * time.Second
+
+const dashboardHeadlines = 10
+
+// MajorIndices are the headline US benchmarks shown on the dashboard.
+var MajorIndices = []string{"^GSPC", "^DJI", "^IXIC", "^RUT", "^VIX"}
+
+// SectorSummary is the slice of SectorData a heatmap needs.
+type SectorSummary struct {
+	Name          string  `json:"name"`
+	Performance   float64 `json:"performance"`
+	Performance1M float64 `json:"performance_1m"`
+	MarketCap     string  `json:"market_cap"`
+}
+
+// Dashboard is everything a home page renders, fetched in one request.
+// Sections that failed or timed out are omitted and explained in Errors.
+type Dashboard struct {
+	Overview  map[string][]StockData `json:"overview,omitempty"`
+	Sectors   []SectorSummary        `json:"sectors,omitempty"`
+	Headlines []Article              `json:"headlines,omitempty"`
+	Indices   []StockData            `json:"indices,omitempty"`
+	Errors    map[string]string      `json:"errors,omitempty"`
+	Timestamp string                 `json:"timestamp"`
+}
+
+// ScrapeMajorIndices reads the world indices table and keeps MajorIndices
+// in their listed order.
+func (s *StockScraper) ScrapeMajorIndices() ([]StockData, error) {
+	cacheKey := s.region.CacheKey(majorIndicesCacheKey)
+	if cached, err := s.cache.Get(s.ctx, cacheKey); err == nil && !s.fresh {
+		var indices []StockData
+		if err := json.Unmarshal(cached, &indices); err == nil {
+			return indices, nil
+		}
+	}
+
+	found := make(map[string]StockData)
+	var mu sync.Mutex
+
+	ctx := yahoo.WithFetchLog(s.ctx)
+	c := yahoo.Clone(ctx, s.collector)
+	c.OnHTML("table tbody tr", func(e *colly.HTMLElement) {
+		index := StockData{
+			Symbol:    yahoo.SelectText(e, "quote_table.symbol"),
+			Name:      yahoo.SelectText(e, "quote_table.name"),
+			Currency:  yahoo.RowCurrency(e),
+			Timestamp: time.Now().Format(time.RFC3339),
+		}
+		if price, err := s.region.ParseFloat(yahoo.SelectText(e, "quote_table.price")); parse.OK("quote_table.price", err) {
+			index.Price = price
+		}
+		if change, err := s.region.ParseFloat(yahoo.SelectText(e, "quote_table.change")); parse.OK("quote_table.change", err) {
+			index.Change = change
+		}
+		if changePerc, err := s.region.ParsePercentage(yahoo.SelectText(e, "quote_table.change_percent")); parse.OK("quote_table.change_percent", err) {
+			index.ChangePerc = changePerc
+		}
+
+		mu.Lock()
+		found[index.Symbol] = index
+		mu.Unlock()
+	})
+
+	release, err := acquire(s.ctx, s.pool, s.priority)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	err = c.Visit(s.region.URL("/markets/world-indices/"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to scrape world indices: %v", err)
+	}
+
+	c.Wait()
+
+	indices := make([]StockData, 0, len(MajorIndices))
+	for _, symbol := range MajorIndices {
+		if index, exists := found[symbol]; exists {
+			indices = append(indices, index)
+		}
+	}
+
+	s.screenAnomalies("indices", indices)
+	s.recordScrape("indices", indices)
+	cacheIfChanged(ctx, s.cache, s.tracker, cacheKey, indices, s.ttl)
+
+	return indices, nil
+}
+
+func summarizeSectors(sectors map[string]*SectorData) []SectorSummary {
+	summaries := make([]SectorSummary, 0, len(sectors))
+	for _, sector := range sectors {
+		summaries = append(summaries, SectorSummary{
+			Name:          sector.Name,
+			Performance:   sector.Performance,
+			Performance1M: sector.Performance1M,
+			MarketCap:     sector.MarketCap,
+		})
+	}
+	sort.Slice(summaries, func(i, j int) bool {
+		return summaries[i].Performance > summaries[j].Performance
+	})
+	return summaries
+}
+
+// BuildDashboard fans out to every section in parallel. Each section goes
+// through its usual scraper, so cached results are served without touching
+// Yahoo and only expired sections are scraped.
+func BuildDashboard(ctx context.Context, region Region, pool *queue.Pool) *Dashboard {
+	dashboard := &Dashboard{
+		Errors:    make(map[string]string),
+		Timestamp: time.Now().Format(time.RFC3339),
+	}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	section := func(name string, fetch func(ctx context.Context) error) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			ctx, cancel := context.WithTimeout(ctx, dashboardTimeout)
+			defer cancel()
+
+			if err := fetch(ctx); err != nil {
+				mu.Lock()
+				dashboard.Errors[name] = err.Error()
+				mu.Unlock()
+			}
+		}()
+	}
+
+	section("overview", func(ctx context.Context) error {
+		s := NewStockScraper(StockScraperOption{Region: region.Code, Context: ctx, Pool: pool})
+		defer s.Close()
+
+		overview, err := s.ScrapeMarketOverview()
+		if err != nil {
+			return err
+		}
+		mu.Lock()
+		dashboard.Overview = overview
+		mu.Unlock()
+		return nil
+	})
+
+	section("sectors", func(ctx context.Context) error {
+		s := NewSectorScraper(ScraperOption{Region: region.Code, Context: ctx, Pool: pool})
+		defer s.Close()
+
+		sectors, err := s.ScrapeAllSectors()
+		if err != nil {
+			return err
+		}
+		mu.Lock()
+		dashboard.Sectors = summarizeSectors(sectors)
+		mu.Unlock()
+		return nil
+	})
+
+	section("headlines", func(ctx context.Context) error {
+		s := NewScraper(ScraperOption{
+			Region:  region.Code,
+			Context: ctx,
+			Pool:    pool,
+			Crawl:   CrawlLimits{MaxPages: 15, MaxDepth: 1, MaxDuration: dashboardTimeout / 2},
+		})
+		defer s.Close()
+
+		articles, err := s.ScrapeNews(MarketMidnight(time.Now()))
+		if err != nil {
+			return err
+		}
+		if len(articles) > dashboardHeadlines {
+			articles = articles[:dashboardHeadlines]
+		}
+		mu.Lock()
+		dashboard.Headlines = articles
+		mu.Unlock()
+		return nil
+	})
+
+	section("indices", func(ctx context.Context) error {
+		s := NewStockScraper(StockScraperOption{Region: region.Code, Context: ctx, Pool: pool})
+		defer s.Close()
+
+		indices, err := s.ScrapeMajorIndices()
+		if err != nil {
+			return err
+		}
+		mu.Lock()
+		dashboard.Indices = indices
+		mu.Unlock()
+		return nil
+	})
+
+	wg.Wait()
+
+	if len(dashboard.Errors) == 0 {
+		dashboard.Errors = nil
+	}
+	return dashboard
+}
+
+func HandleDashboard(pool *queue.Pool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		region, err := LookupRegion(c.Query("region"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"status": "success",
+			"data":   BuildDashboard(c.Request.Context(), region, pool),
+		})
+	}
+}
@@ -0,0 +1,21 @@
+package scraper
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSummarizeSectors(t *testing.T) {
+	summaries := summarizeSectors(map[string]*SectorData{
+		"energy":     {Name: "energy", Performance: -1.2, MarketCap: "3.1T"},
+		"technology": {Name: "technology", Performance: 2.4, Performance1M: 5.1, MarketCap: "19T"},
+		"utilities":  {Name: "utilities", Performance: 0.3},
+	})
+
+	assert.Equal(t, []SectorSummary{
+		{Name: "technology", Performance: 2.4, Performance1M: 5.1, MarketCap: "19T"},
+		{Name: "utilities", Performance: 0.3},
+		{Name: "energy", Performance: -1.2, MarketCap: "3.1T"},
+	}, summaries)
+}
@@ -0,0 +1,259 @@
+package scraper
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"go-webscraper/cache"
+	"go-webscraper/cachekey"
+	"go-webscraper/changes"
+	"go-webscraper/keyspace"
+	"go-webscraper/pkg/yahoo"
+	"go-webscraper/queue"
+	"go-webscraper/watchlist"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+type (
+	DividendEvent = yahoo.DividendEvent
+	DividendInfo  = yahoo.DividendInfo
+)
+
+type DividendScraper struct {
+	redis    *redis.Client
+	cache    cache.Cache
+	ctx      context.Context
+	ttl      time.Duration
+	yahoo    *yahoo.Client
+	region   Region
+	tracker  *changes.Tracker
+	pool     *queue.Pool
+	priority queue.Priority
+}
+
+func dividendCalendarCacheKey(date string) string {
+	return cachekey.DividendCalendar.Key(date)
+}
+
+func dividendCacheKey(symbol string) string {
+	return cachekey.Dividend.Key(symbol)
+}
+
+func NewDividendScraper(opts ScraperOption) *DividendScraper {
+	region, err := LookupRegion(opts.Region)
+	if err != nil {
+		log.Printf("%v, falling back to %s", err, DefaultRegion)
+		region = Regions[DefaultRegion]
+	}
+
+	rdb := newRedis(opts.RedisAddr, opts.RedisPassword, opts.RedisDB)
+	traceRedis(rdb)
+	keyspace.Instrument(rdb)
+
+	client, err := yahoo.New(yahoo.Option{
+		Region:      region.Code,
+		Parallelism: 4,
+		Delay:       200 * time.Millisecond,
+	})
+	if err != nil {
+		log.Fatalf("Failed to create Yahoo client: %v", err)
+	}
+
+	return &DividendScraper{
+		redis:    rdb,
+		cache:    cache.New(rdb),
+		tracker:  changes.NewTracker(rdb),
+		ctx:      optionContext(opts.Context),
+		ttl:      opts.CacheTTL,
+		yahoo:    client,
+		region:   region,
+		pool:     opts.Pool,
+		priority: opts.Priority,
+	}
+}
+
+// ScrapeDividendCalendar returns the stocks going ex-dividend on date.
+func (s *DividendScraper) ScrapeDividendCalendar(date string) ([]DividendEvent, error) {
+	cacheKey := s.region.CacheKey(dividendCalendarCacheKey(date))
+	if cached, err := s.cache.Get(s.ctx, cacheKey); err == nil {
+		var events []DividendEvent
+		if err := json.Unmarshal(cached, &events); err == nil {
+			return events, nil
+		}
+	}
+
+	release, err := acquire(s.ctx, s.pool, s.priority)
+	if err != nil {
+		var stale []DividendEvent
+		if serveStale(s.ctx, s.cache, cacheKey, &stale, err) {
+			return stale, nil
+		}
+		return nil, err
+	}
+	defer release()
+
+	ctx := yahoo.WithFetchLog(s.ctx)
+	events, err := s.yahoo.DividendCalendar(ctx, date)
+	if err != nil {
+		return nil, err
+	}
+
+	cacheIfChanged(ctx, s.cache, s.tracker, cacheKey, events, s.ttl)
+
+	return events, nil
+}
+
+// ScrapeDividends reads the forward dividend and ex-dividend date from each
+// symbol's quote summary, scraping only the symbols that aren't cached.
+func (s *DividendScraper) ScrapeDividends(symbols []string) ([]DividendInfo, error) {
+	today := MarketMidnight(time.Now())
+	today = time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, time.UTC)
+
+	release, err := acquire(s.ctx, s.pool, s.priority)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	results := make(map[string]*DividendInfo, len(symbols))
+	var uncached []string
+	for _, symbol := range symbols {
+		symbol = strings.ToUpper(symbol)
+		info := &DividendInfo{Symbol: symbol}
+		results[symbol] = info
+
+		if cached, err := s.cache.Get(s.ctx, s.region.CacheKey(dividendCacheKey(symbol))); err == nil {
+			if err := json.Unmarshal(cached, info); err == nil {
+				if exDate, err := time.Parse("2006-01-02", info.ExDividendDate); err == nil {
+					info.Upcoming = !exDate.Before(today)
+				}
+				continue
+			}
+		}
+		uncached = append(uncached, symbol)
+	}
+
+	if len(uncached) > 0 {
+		scraped, err := s.yahoo.Dividends(s.ctx, uncached, today)
+		if err != nil {
+			return nil, err
+		}
+		// Only pages that loaded come back, so a failed fetch is retried
+		for i := range scraped {
+			info := &scraped[i]
+			results[info.Symbol] = info
+			if data, err := json.Marshal(info); err == nil {
+				key := s.region.CacheKey(dividendCacheKey(info.Symbol))
+				s.cache.Set(s.ctx, key, data, ttlFor(key, s.ttl))
+			}
+		}
+	}
+
+	dividends := make([]DividendInfo, 0, len(symbols))
+	for _, symbol := range symbols {
+		dividends = append(dividends, *results[strings.ToUpper(symbol)])
+	}
+	sortDividends(dividends)
+	return dividends, nil
+}
+
+// sortDividends puts upcoming payouts first, soonest ex-date first, then
+// the rest by symbol.
+func sortDividends(dividends []DividendInfo) {
+	sort.SliceStable(dividends, func(i, j int) bool {
+		a, b := dividends[i], dividends[j]
+		if a.Upcoming != b.Upcoming {
+			return a.Upcoming
+		}
+		if a.Upcoming && a.ExDividendDate != b.ExDividendDate {
+			return a.ExDividendDate < b.ExDividendDate
+		}
+		return a.Symbol < b.Symbol
+	})
+}
+
+func (s *DividendScraper) Close() {
+	s.redis.Close()
+}
+
+func HandleDividendCalendar(pool *queue.Pool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		date := c.DefaultQuery("date", time.Now().In(marketLocation).Format("2006-01-02"))
+		if _, err := time.Parse("2006-01-02", date); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "date must be formatted as YYYY-MM-DD",
+			})
+			return
+		}
+
+		scraper := NewDividendScraper(ScraperOption{
+			Context: c.Request.Context(),
+			Pool:    pool,
+		})
+		defer scraper.Close()
+
+		if notModifiedSince(c, c.Request.Context(), scraper.cache, scraper.tracker, scraper.region.CacheKey(dividendCalendarCacheKey(date))) {
+			return
+		}
+
+		events, err := scraper.ScrapeDividendCalendar(date)
+		if shed(c, pool, err) {
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		cacheKey := scraper.region.CacheKey(dividendCalendarCacheKey(date))
+		setCacheControl(c, scraper.cache, cacheKey)
+		c.JSON(http.StatusOK, withProvenance(c, scraper.cache, cacheKey, gin.H{
+			"status": "success",
+			"date":   date,
+			"data":   events,
+		}))
+	}
+}
+
+// HandleWatchlistDividends lists the dividend details of every symbol in
+// the caller's watchlist, upcoming ex-dates first.
+func HandleWatchlistDividends(store *watchlist.Store, pool *queue.Pool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		list := watchlist.Owned(c, store)
+		if list == nil {
+			return
+		}
+
+		scraper := NewDividendScraper(ScraperOption{
+			Context: c.Request.Context(),
+			Pool:    pool,
+		})
+		defer scraper.Close()
+
+		dividends, err := scraper.ScrapeDividends(list.Symbols)
+		if shed(c, pool, err) {
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"status":    "success",
+			"watchlist": list.ID,
+			"data":      dividends,
+		})
+	}
+}
@@ -0,0 +1,23 @@
+package scraper
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSortDividends(t *testing.T) {
+	dividends := []DividendInfo{
+		{Symbol: "TSLA"},
+		{Symbol: "MSFT", ExDividendDate: "2024-11-20", Upcoming: true},
+		{Symbol: "KO", ExDividendDate: "2024-09-01"},
+		{Symbol: "AAPL", ExDividendDate: "2024-11-08", Upcoming: true},
+	}
+	sortDividends(dividends)
+
+	symbols := make([]string, len(dividends))
+	for i, d := range dividends {
+		symbols[i] = d.Symbol
+	}
+	assert.Equal(t, []string{"AAPL", "MSFT", "KO", "TSLA"}, symbols)
+}
@@ -0,0 +1,283 @@
+package scraper
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-webscraper/pkg/parse"
+
+	"github.com/gin-gonic/gin"
+)
+
+// marketClose is when the regular session ends, after midnight Eastern.
+const marketClose = 16 * time.Hour
+
+// EOD is one market day's finalized close of a symbol or sector. Sectors
+// have no price, so only their day change is recorded.
+type EOD struct {
+	Date       string  `json:"date"`
+	Close      float64 `json:"close,omitempty"`
+	Volume     int64   `json:"volume,omitempty"`
+	Change     float64 `json:"change,omitempty"`
+	ChangePerc float64 `json:"change_percentage"`
+	RecordedAt string  `json:"recorded_at"`
+}
+
+// eodKey holds a symbol's end-of-day records, one hash field per market
+// day. Unlike intraday series and breadth closes these never expire.
+func eodKey(symbol string) string {
+	return fmt.Sprintf("eod:%s", symbol)
+}
+
+func sectorEODKey(sector string) string {
+	return fmt.Sprintf("eod:sector:%s", sector)
+}
+
+// eodDividendsKey holds the cash dividend a symbol paid, by ex-date.
+func eodDividendsKey(symbol string) string {
+	return fmt.Sprintf("eod:dividends:%s", symbol)
+}
+
+func eodFinalizedKey(day time.Time) string {
+	return fmt.Sprintf("eod:finalized:%s", day.Format("2006-01-02"))
+}
+
+// EODDue reports whether the market day containing t closed at least delay
+// ago. Like MarketOpen, exchange holidays are not excluded.
+func EODDue(t time.Time, delay time.Duration) bool {
+	t = t.In(marketLocation)
+	if t.Weekday() == time.Saturday || t.Weekday() == time.Sunday {
+		return false
+	}
+	return !t.Before(MarketMidnight(t).Add(marketClose + delay))
+}
+
+// EODFinalized reports whether day's end-of-day snapshot has been taken.
+func (s *StockScraper) EODFinalized(day time.Time) (bool, error) {
+	n, err := s.redis.Exists(s.ctx, s.region.CacheKey(eodFinalizedKey(day))).Result()
+	return n > 0, err
+}
+
+// MarkEODFinalized records that day's snapshot is complete, so later runs
+// that day skip it.
+func (s *StockScraper) MarkEODFinalized(day time.Time) error {
+	return s.redis.Set(s.ctx, s.region.CacheKey(eodFinalizedKey(day)), time.Now().Format(time.RFC3339), 7*24*time.Hour).Err()
+}
+
+// FinalizeEOD scrapes symbols' closing quotes and records them for the
+// market day of now, returning how many were recorded. Records are
+// immutable: a day already recorded for a symbol is never overwritten.
+func (s *StockScraper) FinalizeEOD(symbols []string, now time.Time) (int, error) {
+	if len(symbols) == 0 {
+		return 0, nil
+	}
+	stocks, err := s.ScrapeQuotes(symbols)
+	if err != nil {
+		return 0, err
+	}
+
+	date := MarketMidnight(now).Format("2006-01-02")
+	recorded := 0
+	for _, stock := range stocks {
+		if stock.Suspect {
+			continue
+		}
+		data, err := json.Marshal(EOD{
+			Date:       date,
+			Close:      stock.Price,
+			Volume:     stock.Volume,
+			Change:     stock.Change,
+			ChangePerc: stock.ChangePerc,
+			RecordedAt: now.Format(time.RFC3339),
+		})
+		if err != nil {
+			return recorded, err
+		}
+		added, err := s.redis.HSetNX(s.ctx, s.region.CacheKey(eodKey(stock.Symbol)), date, data).Result()
+		if err != nil {
+			return recorded, fmt.Errorf("failed to record %s close: %v", stock.Symbol, err)
+		}
+		if added {
+			recorded++
+		}
+	}
+	return recorded, nil
+}
+
+// FinalizeEOD records every sector's day change for the market day of now,
+// returning how many were recorded. Like symbol closes, they're immutable.
+func (s *SectorScraper) FinalizeEOD(now time.Time) (int, error) {
+	s.fresh = true
+	sectors, err := s.ScrapeAllSectors()
+	if err != nil {
+		return 0, err
+	}
+
+	date := MarketMidnight(now).Format("2006-01-02")
+	recorded := 0
+	for name, sector := range sectors {
+		data, err := json.Marshal(EOD{
+			Date:       date,
+			Volume:     sector.Volume,
+			ChangePerc: sector.Performance,
+			RecordedAt: now.Format(time.RFC3339),
+		})
+		if err != nil {
+			return recorded, err
+		}
+		added, err := s.redis.HSetNX(s.ctx, s.region.CacheKey(sectorEODKey(name)), date, data).Result()
+		if err != nil {
+			return recorded, fmt.Errorf("failed to record %s close: %v", name, err)
+		}
+		if added {
+			recorded++
+		}
+	}
+	return recorded, nil
+}
+
+// RecordExDividends stores the cash dividend of every stock the dividend
+// calendar lists as going ex on date, so returns over stored closes can
+// be adjusted for them. Like closes, a recorded dividend is immutable.
+func (s *DividendScraper) RecordExDividends(date string) (int, error) {
+	events, err := s.ScrapeDividendCalendar(date)
+	if err != nil {
+		return 0, err
+	}
+
+	recorded := 0
+	for _, event := range events {
+		amount, err := parse.US.Float(strings.TrimPrefix(event.Amount, "$"))
+		if !parse.OK("dividends.amount", err) || amount <= 0 {
+			continue
+		}
+		key := s.region.CacheKey(eodDividendsKey(strings.ToUpper(event.Symbol)))
+		added, err := s.redis.HSetNX(s.ctx, key, date, strconv.FormatFloat(amount, 'f', -1, 64)).Result()
+		if err != nil {
+			return recorded, fmt.Errorf("failed to record %s dividend: %v", event.Symbol, err)
+		}
+		if added {
+			recorded++
+		}
+	}
+	return recorded, nil
+}
+
+// exDividends returns a symbol's recorded dividends by ex-date.
+func (s *StockScraper) exDividends(symbol string) (map[string]float64, error) {
+	values, err := s.redis.HGetAll(s.ctx, s.region.CacheKey(eodDividendsKey(symbol))).Result()
+	if err != nil {
+		return nil, err
+	}
+	dividends := make(map[string]float64, len(values))
+	for date, value := range values {
+		if amount, err := strconv.ParseFloat(value, 64); err == nil {
+			dividends[date] = amount
+		}
+	}
+	return dividends, nil
+}
+
+// EODHistory returns a symbol's end-of-day records between from and to
+// inclusive, oldest first. Empty bounds are open.
+func (s *StockScraper) EODHistory(symbol, from, to string) ([]EOD, error) {
+	values, err := s.redis.HGetAll(s.ctx, s.region.CacheKey(eodKey(symbol))).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	records := make([]EOD, 0, len(values))
+	for date, value := range values {
+		// Dates are YYYY-MM-DD, so they compare as strings
+		if (from != "" && date < from) || (to != "" && date > to) {
+			continue
+		}
+		var record EOD
+		if err := json.Unmarshal([]byte(value), &record); err != nil {
+			continue
+		}
+		records = append(records, record)
+	}
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].Date < records[j].Date
+	})
+	return records, nil
+}
+
+func parseDateParam(c *gin.Context, name string) (string, error) {
+	value := c.Query(name)
+	if value == "" {
+		return "", nil
+	}
+	if _, err := time.Parse("2006-01-02", value); err != nil {
+		return "", fmt.Errorf("%s must be a YYYY-MM-DD date", name)
+	}
+	return value, nil
+}
+
+func HandleEOD() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		symbol := strings.ToUpper(c.Param("symbol"))
+		if !ValidSymbol(symbol) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "invalid symbol",
+			})
+			return
+		}
+
+		region, err := LookupRegion(c.Query("region"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		from, err := parseDateParam(c, "from")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+		to, err := parseDateParam(c, "to")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+		if from != "" && to != "" && from > to {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "from must not be after to",
+			})
+			return
+		}
+
+		scraper := NewStockScraper(StockScraperOption{
+			Region:  region.Code,
+			Context: c.Request.Context(),
+		})
+		defer scraper.Close()
+
+		records, err := scraper.EODHistory(symbol, from, to)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"status": "success",
+			"symbol": symbol,
+			"region": region.Code,
+			"data":   records,
+		})
+	}
+}
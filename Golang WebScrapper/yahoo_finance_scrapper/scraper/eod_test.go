@@ -0,0 +1,25 @@
+package scraper
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEODDue(t *testing.T) {
+	delay := 15 * time.Minute
+	for at, want := range map[string]bool{
+		"2024-07-10T16:10:00-04:00": false,
+		"2024-07-10T16:15:00-04:00": true,
+		"2024-07-10T23:30:00-04:00": true,
+		// 02:00 UTC Thursday is still Wednesday evening in New York
+		"2024-07-11T02:00:00Z":      true,
+		"2024-07-11T09:00:00-04:00": false,
+		"2024-07-13T17:00:00-04:00": false,
+	} {
+		now, err := time.Parse(time.RFC3339, at)
+		assert.NoError(t, err)
+		assert.Equal(t, want, EODDue(now, delay), at)
+	}
+}
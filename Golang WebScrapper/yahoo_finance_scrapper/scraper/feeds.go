@@ -0,0 +1,224 @@
+package scraper
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	neturl "net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"go-webscraper/upstream"
+)
+
+// News discovery sources: how ScrapeNews finds the article links it
+// visits. Crawl spiders anchors from the news hub; feeds reads RSS feeds
+// and sitemaps, which list articles with their dates and so skip pages
+// that were never going to match.
+const (
+	NewsSourceCrawl = "crawl"
+	NewsSourceFeeds = "feeds"
+)
+
+var NewsSources = []string{NewsSourceCrawl, NewsSourceFeeds}
+
+// DefaultNewsFeeds are read by the feeds source unless configured
+// otherwise. Paths are resolved against the scraped region's site.
+var DefaultNewsFeeds = []string{"/news/rssindex", "/rss/topstories"}
+
+const (
+	feedTimeout = 15 * time.Second
+	// maxFeedBytes bounds one feed or sitemap, which Yahoo keeps far
+	// smaller.
+	maxFeedBytes = 10 << 20
+)
+
+var newsDiscovery = struct {
+	mu     sync.RWMutex
+	source string
+	feeds  []string
+}{source: NewsSourceCrawl, feeds: DefaultNewsFeeds}
+
+// ConfigureNewsDiscovery sets the source news scrapes use when their
+// option doesn't name one, and the feeds the feeds source reads. Feeds
+// may be RSS 2.0 feeds, sitemaps or sitemap indexes, given as absolute
+// URLs or paths on the region's site; none keeps DefaultNewsFeeds.
+func ConfigureNewsDiscovery(source string, feeds []string) error {
+	if !validNewsSource(source) {
+		return fmt.Errorf("news source must be one of: %s", strings.Join(NewsSources, ", "))
+	}
+	for _, feed := range feeds {
+		if !strings.HasPrefix(feed, "/") {
+			if u, err := neturl.Parse(feed); err != nil || u.Host == "" {
+				return fmt.Errorf("invalid news feed %q: must be an absolute URL or a path", feed)
+			}
+		}
+	}
+	if len(feeds) == 0 {
+		feeds = DefaultNewsFeeds
+	}
+
+	newsDiscovery.mu.Lock()
+	defer newsDiscovery.mu.Unlock()
+	newsDiscovery.source = source
+	newsDiscovery.feeds = feeds
+	return nil
+}
+
+func validNewsSource(source string) bool {
+	for _, s := range NewsSources {
+		if s == source {
+			return true
+		}
+	}
+	return false
+}
+
+func newsDiscoverySettings() (string, []string) {
+	newsDiscovery.mu.RLock()
+	defer newsDiscovery.mu.RUnlock()
+	return newsDiscovery.source, newsDiscovery.feeds
+}
+
+// feedDocument decodes the three shapes a feed comes in: an RSS channel,
+// a sitemap listing pages, or a sitemap index listing sitemaps. Google
+// News sitemaps carry each article's date in news:publication_date.
+type feedDocument struct {
+	Items []struct {
+		Link    string `xml:"link"`
+		PubDate string `xml:"pubDate"`
+	} `xml:"channel>item"`
+	URLs []struct {
+		Loc     string `xml:"loc"`
+		LastMod string `xml:"lastmod"`
+		News    struct {
+			PublicationDate string `xml:"publication_date"`
+		} `xml:"news"`
+	} `xml:"url"`
+	Sitemaps []struct {
+		Loc string `xml:"loc"`
+	} `xml:"sitemap"`
+}
+
+type feedEntry struct {
+	Link      string
+	Published time.Time
+}
+
+// parseFeed returns the article links a feed lists and, for a sitemap
+// index, the sitemaps to read next.
+func parseFeed(r io.Reader) ([]feedEntry, []string, error) {
+	var doc feedDocument
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, nil, err
+	}
+
+	var entries []feedEntry
+	for _, item := range doc.Items {
+		entries = append(entries, feedEntry{Link: strings.TrimSpace(item.Link), Published: parseFeedTime(item.PubDate)})
+	}
+	for _, u := range doc.URLs {
+		date := u.News.PublicationDate
+		if date == "" {
+			date = u.LastMod
+		}
+		entries = append(entries, feedEntry{Link: strings.TrimSpace(u.Loc), Published: parseFeedTime(date)})
+	}
+	sitemaps := make([]string, 0, len(doc.Sitemaps))
+	for _, sitemap := range doc.Sitemaps {
+		sitemaps = append(sitemaps, strings.TrimSpace(sitemap.Loc))
+	}
+	return entries, sitemaps, nil
+}
+
+// parseFeedTime reads RSS (RFC 1123) and sitemap (W3C) dates, returning
+// the zero time for anything else.
+func parseFeedTime(value string) time.Time {
+	value = strings.TrimSpace(value)
+	for _, layout := range []string{time.RFC1123Z, time.RFC1123, time.RFC3339, "2006-01-02T15:04Z07:00", "2006-01-02"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// feedLinks picks the entries worth visiting: on the region's site, not
+// already picked, and not known to predate since. Undated entries are
+// kept, since only the article page can rule them out.
+func feedLinks(entries []feedEntry, host string, since time.Time, seen map[string]bool) []string {
+	var links []string
+	for _, entry := range entries {
+		u, err := neturl.Parse(entry.Link)
+		if err != nil || u.Host != host || seen[entry.Link] {
+			continue
+		}
+		if !since.IsZero() && !entry.Published.IsZero() && entry.Published.Before(since) {
+			continue
+		}
+		seen[entry.Link] = true
+		links = append(links, entry.Link)
+	}
+	return links
+}
+
+// discoverFromFeeds reads the configured feeds, following sitemap indexes
+// one level down, and returns the article links to visit. A feed that
+// fails is logged and skipped; it's an error only if every feed fails.
+func (s *Scraper) discoverFromFeeds(feeds []string, since time.Time) ([]string, error) {
+	client := &http.Client{Transport: upstream.Transport(), Timeout: feedTimeout}
+	fetch := func(feed string) ([]feedEntry, []string, error) {
+		if strings.HasPrefix(feed, "/") {
+			feed = s.region.URL(feed)
+		}
+		req, err := http.NewRequestWithContext(s.ctx, http.MethodGet, feed, nil)
+		if err != nil {
+			return nil, nil, err
+		}
+		req.Header.Set("User-Agent", upstream.DefaultUserAgent)
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to fetch feed %s: %v", feed, err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return nil, nil, fmt.Errorf("failed to fetch feed %s: status %d", feed, resp.StatusCode)
+		}
+		entries, sitemaps, err := parseFeed(io.LimitReader(resp.Body, maxFeedBytes))
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to parse feed %s: %v", feed, err)
+		}
+		return entries, sitemaps, nil
+	}
+
+	seen := make(map[string]bool)
+	var links []string
+	var failed int
+	var lastErr error
+	for _, feed := range feeds {
+		entries, sitemaps, err := fetch(feed)
+		if err != nil {
+			log.Printf("%v", err)
+			failed++
+			lastErr = err
+			continue
+		}
+		for _, sitemap := range sitemaps {
+			nested, _, err := fetch(sitemap)
+			if err != nil {
+				log.Printf("%v", err)
+				continue
+			}
+			entries = append(entries, nested...)
+		}
+		links = append(links, feedLinks(entries, s.region.Host, since, seen)...)
+	}
+	if failed > 0 && failed == len(feeds) {
+		return nil, lastErr
+	}
+	return links, nil
+}
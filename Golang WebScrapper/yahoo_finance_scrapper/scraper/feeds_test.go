@@ -0,0 +1,81 @@
+package scraper
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseFeed(t *testing.T) {
+	t.Run("RSS", func(t *testing.T) {
+		entries, sitemaps, err := parseFeed(strings.NewReader(`<?xml version="1.0"?>
+<rss version="2.0"><channel><title>Yahoo Finance</title>
+<item><title>Fed holds</title><link>https://finance.yahoo.com/news/fed-holds-120000.html</link><pubDate>Tue, 13 Oct 2026 14:05:00 +0000</pubDate></item>
+<item><title>Undated</title><link> https://finance.yahoo.com/news/undated.html </link></item>
+</channel></rss>`))
+		assert.NoError(t, err)
+		assert.Empty(t, sitemaps)
+		assert.Len(t, entries, 2)
+		assert.Equal(t, "https://finance.yahoo.com/news/fed-holds-120000.html", entries[0].Link)
+		assert.True(t, entries[0].Published.Equal(time.Date(2026, 10, 13, 14, 5, 0, 0, time.UTC)))
+		assert.Equal(t, feedEntry{Link: "https://finance.yahoo.com/news/undated.html"}, entries[1])
+	})
+
+	t.Run("News Sitemap", func(t *testing.T) {
+		entries, _, err := parseFeed(strings.NewReader(`<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9" xmlns:news="http://www.google.com/schemas/sitemap-news/0.9">
+<url><loc>https://finance.yahoo.com/news/oil-slides.html</loc><news:news><news:publication_date>2026-10-13T09:30:00Z</news:publication_date></news:news></url>
+<url><loc>https://finance.yahoo.com/news/rates.html</loc><lastmod>2026-10-12</lastmod></url>
+</urlset>`))
+		assert.NoError(t, err)
+		assert.Len(t, entries, 2)
+		assert.True(t, entries[0].Published.Equal(time.Date(2026, 10, 13, 9, 30, 0, 0, time.UTC)))
+		assert.True(t, entries[1].Published.Equal(time.Date(2026, 10, 12, 0, 0, 0, 0, time.UTC)))
+	})
+
+	t.Run("Sitemap Index", func(t *testing.T) {
+		entries, sitemaps, err := parseFeed(strings.NewReader(`<sitemapindex><sitemap><loc>https://finance.yahoo.com/sitemap-news-1.xml</loc></sitemap></sitemapindex>`))
+		assert.NoError(t, err)
+		assert.Empty(t, entries)
+		assert.Equal(t, []string{"https://finance.yahoo.com/sitemap-news-1.xml"}, sitemaps)
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		_, _, err := parseFeed(strings.NewReader(`<html><body>blocked`))
+		assert.Error(t, err)
+	})
+}
+
+func TestFeedLinks(t *testing.T) {
+	since := time.Date(2026, 10, 13, 4, 0, 0, 0, time.UTC)
+	entries := []feedEntry{
+		{Link: "https://finance.yahoo.com/news/today.html", Published: since.Add(time.Hour)},
+		{Link: "https://finance.yahoo.com/news/yesterday.html", Published: since.Add(-time.Hour)},
+		{Link: "https://finance.yahoo.com/news/undated.html"},
+		{Link: "https://uk.finance.yahoo.com/news/other-region.html", Published: since.Add(time.Hour)},
+		{Link: "https://finance.yahoo.com/news/today.html", Published: since.Add(time.Hour)},
+	}
+
+	links := feedLinks(entries, "finance.yahoo.com", since, make(map[string]bool))
+	assert.Equal(t, []string{"https://finance.yahoo.com/news/today.html", "https://finance.yahoo.com/news/undated.html"}, links)
+
+	// Without a since, only host and duplicates filter
+	assert.Len(t, feedLinks(entries, "finance.yahoo.com", time.Time{}, make(map[string]bool)), 3)
+}
+
+func TestConfigureNewsDiscovery(t *testing.T) {
+	defer ConfigureNewsDiscovery(NewsSourceCrawl, nil)
+
+	assert.Error(t, ConfigureNewsDiscovery("scrape", nil))
+	assert.Error(t, ConfigureNewsDiscovery(NewsSourceFeeds, []string{"news/rssindex"}))
+
+	assert.NoError(t, ConfigureNewsDiscovery(NewsSourceFeeds, nil))
+	source, feeds := newsDiscoverySettings()
+	assert.Equal(t, NewsSourceFeeds, source)
+	assert.Equal(t, DefaultNewsFeeds, feeds)
+
+	assert.NoError(t, ConfigureNewsDiscovery(NewsSourceFeeds, []string{"/sitemap-news.xml", "https://finance.yahoo.com/rss/topstories"}))
+	_, feeds = newsDiscoverySettings()
+	assert.Len(t, feeds, 2)
+}
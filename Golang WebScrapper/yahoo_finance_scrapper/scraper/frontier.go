@@ -0,0 +1,91 @@
+package scraper
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// Links visited longer ago than this are forgotten, and so crawled
+	// again if the hub still links to them.
+	seenRetention = 7 * 24 * time.Hour
+	// maxFrontier caps the links carried over to later runs, keeping the
+	// most recently discovered.
+	maxFrontier = 500
+)
+
+// frontier is what an incremental news crawl carries between runs: every
+// link already visited, and links discovered but left unvisited when the
+// crawl budget ran out. Both are sorted sets scored by Unix time.
+type frontier struct {
+	seen    map[string]bool
+	pending []string
+}
+
+func (s *Scraper) frontierKeys() (string, string) {
+	return s.region.CacheKey("news:crawl:seen"), s.region.CacheKey("news:crawl:frontier")
+}
+
+// loadFrontier reads the frontier, dropping links seen before the
+// retention window.
+func (s *Scraper) loadFrontier(now time.Time) (*frontier, error) {
+	seenKey, frontierKey := s.frontierKeys()
+	cutoff := strconv.FormatInt(now.Add(-seenRetention).Unix(), 10)
+
+	pipe := s.redis.Pipeline()
+	pipe.ZRemRangeByScore(s.ctx, seenKey, "-inf", "("+cutoff)
+	seen := pipe.ZRange(s.ctx, seenKey, 0, -1)
+	pending := pipe.ZRevRange(s.ctx, frontierKey, 0, maxFrontier-1)
+	if _, err := pipe.Exec(s.ctx); err != nil {
+		return nil, fmt.Errorf("failed to load crawl frontier: %v", err)
+	}
+
+	f := &frontier{seen: make(map[string]bool, len(seen.Val()))}
+	for _, link := range seen.Val() {
+		f.seen[link] = true
+	}
+	for _, link := range pending.Val() {
+		if !f.seen[link] {
+			f.pending = append(f.pending, link)
+		}
+	}
+	return f, nil
+}
+
+// saveFrontier marks visited as seen and replaces them in the frontier
+// with the links the crawl discovered but didn't reach.
+func (s *Scraper) saveFrontier(visited, unvisited []string, now time.Time) error {
+	seenKey, frontierKey := s.frontierKeys()
+	score := float64(now.Unix())
+
+	pipe := s.redis.Pipeline()
+	if len(visited) > 0 {
+		members := make([]redis.Z, len(visited))
+		removed := make([]interface{}, len(visited))
+		for i, link := range visited {
+			members[i] = redis.Z{Score: score, Member: link}
+			removed[i] = link
+		}
+		pipe.ZAdd(s.ctx, seenKey, members...)
+		pipe.Expire(s.ctx, seenKey, seenRetention)
+		pipe.ZRem(s.ctx, frontierKey, removed...)
+	}
+	if len(unvisited) > 0 {
+		members := make([]redis.Z, len(unvisited))
+		for i, link := range unvisited {
+			members[i] = redis.Z{Score: score, Member: link}
+		}
+		// NX keeps when a link was first discovered, so the cap drops
+		// the links that have waited longest
+		pipe.ZAddNX(s.ctx, frontierKey, members...)
+		pipe.Expire(s.ctx, frontierKey, seenRetention)
+	}
+	pipe.ZRemRangeByRank(s.ctx, frontierKey, 0, -maxFrontier-1)
+	if _, err := pipe.Exec(s.ctx); err != nil {
+		return fmt.Errorf("failed to save crawl frontier: %v", err)
+	}
+	return nil
+}
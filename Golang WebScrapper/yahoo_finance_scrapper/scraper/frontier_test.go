@@ -0,0 +1,78 @@
+package scraper
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFrontier(t *testing.T) {
+	mr := miniredis.RunT(t)
+	s := NewScraper(ScraperOption{RedisAddr: mr.Addr()})
+	defer s.Close()
+	seenKey, frontierKey := s.frontierKeys()
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+
+	t.Run("Empty", func(t *testing.T) {
+		f, err := s.loadFrontier(now)
+		require.NoError(t, err)
+		assert.Empty(t, f.seen)
+		assert.Empty(t, f.pending)
+	})
+
+	t.Run("Visited Leave The Frontier", func(t *testing.T) {
+		require.NoError(t, s.saveFrontier(nil, []string{"/news/a.html", "/news/b.html"}, now))
+		require.NoError(t, s.saveFrontier([]string{"/news/a.html"}, []string{"/news/c.html"}, now.Add(time.Minute)))
+
+		f, err := s.loadFrontier(now.Add(time.Minute))
+		require.NoError(t, err)
+		assert.Equal(t, map[string]bool{"/news/a.html": true}, f.seen)
+		assert.ElementsMatch(t, []string{"/news/b.html", "/news/c.html"}, f.pending)
+	})
+
+	t.Run("Discovery Time Kept", func(t *testing.T) {
+		require.NoError(t, s.saveFrontier(nil, []string{"/news/b.html"}, now.Add(time.Hour)))
+
+		score, err := s.redis.ZScore(s.ctx, frontierKey, "/news/b.html").Result()
+		require.NoError(t, err)
+		assert.Equal(t, float64(now.Unix()), score)
+	})
+
+	t.Run("Retention", func(t *testing.T) {
+		require.NoError(t, s.saveFrontier([]string{"/news/d.html"}, nil, now.Add(time.Hour)))
+
+		f, err := s.loadFrontier(now.Add(seenRetention + 2*time.Minute))
+		require.NoError(t, err)
+		assert.Equal(t, map[string]bool{"/news/d.html": true}, f.seen)
+
+		seen, err := s.redis.ZRange(s.ctx, seenKey, 0, -1).Result()
+		require.NoError(t, err)
+		assert.Equal(t, []string{"/news/d.html"}, seen)
+	})
+
+	t.Run("Capped", func(t *testing.T) {
+		s.redis.Del(s.ctx, seenKey, frontierKey)
+
+		// Each batch is discovered a second later than the one before, so
+		// the cap keeps the newest maxFrontier links
+		for i := 0; i < maxFrontier+10; i++ {
+			link := fmt.Sprintf("/news/%d.html", i)
+			require.NoError(t, s.saveFrontier(nil, []string{link}, now.Add(time.Duration(i)*time.Second)))
+		}
+
+		count, err := s.redis.ZCard(s.ctx, frontierKey).Result()
+		require.NoError(t, err)
+		assert.Equal(t, int64(maxFrontier), count)
+
+		f, err := s.loadFrontier(now.Add(time.Hour))
+		require.NoError(t, err)
+		require.Len(t, f.pending, maxFrontier)
+		assert.Equal(t, fmt.Sprintf("/news/%d.html", maxFrontier+9), f.pending[0])
+		assert.NotContains(t, f.pending, "/news/9.html")
+		assert.Contains(t, f.pending, "/news/10.html")
+	})
+}
@@ -0,0 +1,373 @@
+package scraper
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-webscraper/entity"
+	"go-webscraper/model"
+	"go-webscraper/newsarchive"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// impactKey holds one Impact per article ID; impactPendingKey the IDs
+	// still waiting on a price, scored by publication time.
+	impactKey        = "news:impact"
+	impactPendingKey = "news:impact:pending"
+
+	// A horizon is only resolved once snapshots around it have had time
+	// to land, and a snapshot this close to it counts as its price.
+	impactTolerance = 15 * time.Minute
+	// Without a snapshot near the horizon, the last earlier price stands
+	// in unless it is older than this, which spans a weekend.
+	impactMaxStale = 4 * 24 * time.Hour
+	// Articles stop being tracked this long after publication, priced or
+	// not.
+	impactWindow = 2 * 24 * time.Hour
+
+	maxImpactSymbols = 5
+)
+
+// ImpactPrice is a symbol's price at an impact horizon. At is when the
+// snapshot was taken, which may precede the horizon outside market hours.
+type ImpactPrice struct {
+	Price      float64  `json:"price"`
+	At         string   `json:"at"`
+	ChangePerc *float64 `json:"change_percentage,omitempty"`
+}
+
+// SymbolImpact is a symbol's price when an article was published and an
+// hour and a day later. Horizons not yet priced, or with no snapshot, are
+// null.
+type SymbolImpact struct {
+	Symbol  string       `json:"symbol"`
+	Publish *ImpactPrice `json:"publish"`
+	Hour    *ImpactPrice `json:"after_1h"`
+	Day     *ImpactPrice `json:"after_1d"`
+}
+
+// Impact links an article to the prices of the tickers it mentions.
+type Impact struct {
+	ID        string         `json:"id"`
+	Link      string         `json:"link"`
+	Title     string         `json:"title"`
+	Published string         `json:"published"`
+	Symbols   []SymbolImpact `json:"symbols"`
+	Settled   bool           `json:"settled"`
+}
+
+// impactSymbols are the tickers tagged on article, in Yahoo's notation.
+func impactSymbols(article model.Article) []string {
+	var symbols []string
+	for _, e := range article.Entities {
+		if e.Type != entity.TypeTicker {
+			continue
+		}
+		symbols = append(symbols, strings.ReplaceAll(e.Name, ".", "-"))
+		if len(symbols) == maxImpactSymbols {
+			break
+		}
+	}
+	return symbols
+}
+
+// TrackImpacts starts tracking the price impact of the articles tagged
+// with tickers, returning how many weren't tracked yet.
+func (s *StockScraper) TrackImpacts(articles []model.Article) (int, error) {
+	tracked := 0
+	for _, article := range articles {
+		symbols := impactSymbols(article)
+		published, err := time.Parse(time.RFC3339, article.DatePublished)
+		if len(symbols) == 0 || article.Link == "" || err != nil {
+			continue
+		}
+
+		impact := Impact{
+			ID:        newsarchive.ID(article.Link),
+			Link:      article.Link,
+			Title:     article.Title,
+			Published: published.Format(time.RFC3339),
+			Symbols:   make([]SymbolImpact, len(symbols)),
+		}
+		for i, symbol := range symbols {
+			impact.Symbols[i].Symbol = symbol
+		}
+		data, err := json.Marshal(impact)
+		if err != nil {
+			return tracked, err
+		}
+
+		isNew, err := s.redis.HSetNX(s.ctx, impactKey, impact.ID, data).Result()
+		if err != nil {
+			return tracked, err
+		}
+		if !isNew {
+			continue
+		}
+		if err := s.redis.ZAdd(s.ctx, impactPendingKey, redis.Z{Score: float64(published.Unix()), Member: impact.ID}).Err(); err != nil {
+			return tracked, err
+		}
+		tracked++
+	}
+	return tracked, nil
+}
+
+// Impact returns the tracked impact of the article with id, or nil if it
+// isn't tracked.
+func (s *StockScraper) Impact(id string) (*Impact, error) {
+	data, err := s.redis.HGet(s.ctx, impactKey, id).Result()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var impact Impact
+	if err := json.Unmarshal([]byte(data), &impact); err != nil {
+		return nil, err
+	}
+	return &impact, nil
+}
+
+// RecordImpacts prices the pending impact horizons settled by now,
+// returning how many prices were recorded. Symbols with a horizon due
+// right now are quoted first so a fresh snapshot backs it.
+func (s *StockScraper) RecordImpacts(now time.Time) (int, error) {
+	ids, err := s.redis.ZRange(s.ctx, impactPendingKey, 0, -1).Result()
+	if err != nil || len(ids) == 0 {
+		return 0, err
+	}
+
+	impacts := make([]*Impact, 0, len(ids))
+	due := make(map[string]bool)
+	for _, id := range ids {
+		impact, err := s.Impact(id)
+		if err != nil {
+			return 0, err
+		}
+		if impact == nil {
+			s.redis.ZRem(s.ctx, impactPendingKey, id)
+			continue
+		}
+		impacts = append(impacts, impact)
+		for _, symbol := range impactDue(impact, now) {
+			due[symbol] = true
+		}
+	}
+	if len(due) > 0 {
+		symbols := make([]string, 0, len(due))
+		for symbol := range due {
+			symbols = append(symbols, symbol)
+		}
+		sort.Strings(symbols)
+		// Stored snapshots may still price them, so a failed quote
+		// doesn't stop the run
+		if _, err := s.ScrapeQuotes(symbols); err != nil {
+			log.Printf("Error quoting %s for news impact: %v", strings.Join(symbols, ", "), err)
+		}
+	}
+
+	points := make(map[string][]pricePoint)
+	recorded := 0
+	for _, impact := range impacts {
+		for _, sym := range impact.Symbols {
+			if _, ok := points[sym.Symbol]; ok {
+				continue
+			}
+			if points[sym.Symbol], err = s.impactPoints(sym.Symbol, now); err != nil {
+				return recorded, err
+			}
+		}
+
+		n := fillImpact(impact, points, now)
+		if n == 0 && !impact.Settled {
+			continue
+		}
+		data, err := json.Marshal(impact)
+		if err != nil {
+			return recorded, err
+		}
+		pipe := s.redis.Pipeline()
+		pipe.HSet(s.ctx, impactKey, impact.ID, data)
+		if impact.Settled {
+			pipe.ZRem(s.ctx, impactPendingKey, impact.ID)
+		}
+		if _, err := pipe.Exec(s.ctx); err != nil {
+			return recorded, err
+		}
+		recorded += n
+	}
+	return recorded, nil
+}
+
+// impactPoints are the stored prices of symbol over the last impact
+// window: today's intraday series and the end-of-day closes before it.
+func (s *StockScraper) impactPoints(symbol string, now time.Time) ([]pricePoint, error) {
+	from := now.Add(-impactWindow - impactMaxStale).In(marketLocation).Format("2006-01-02")
+	history, err := s.EODHistory(symbol, from, "")
+	if err != nil {
+		return nil, err
+	}
+	intraday, err := s.intradayPoints(symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	points := make([]pricePoint, 0, len(history)+len(intraday))
+	for _, record := range history {
+		date, err := time.ParseInLocation("2006-01-02", record.Date, marketLocation)
+		if err != nil || record.Close <= 0 {
+			continue
+		}
+		points = append(points, pricePoint{At: date.Add(marketClose), Price: record.Close})
+	}
+	points = append(points, intraday...)
+	sort.SliceStable(points, func(i, j int) bool { return points[i].At.Before(points[j].At) })
+	return points, nil
+}
+
+// impactHorizons are when an impact's publish, +1h and +1d prices are
+// taken.
+func impactHorizons(published time.Time) [3]time.Time {
+	return [3]time.Time{published, published.Add(time.Hour), published.Add(24 * time.Hour)}
+}
+
+// impactDue returns the symbols with an unpriced horizon within the
+// tolerance before now.
+func impactDue(impact *Impact, now time.Time) []string {
+	published, err := time.Parse(time.RFC3339, impact.Published)
+	if err != nil {
+		return nil
+	}
+	var symbols []string
+	for _, sym := range impact.Symbols {
+		prices := [3]*ImpactPrice{sym.Publish, sym.Hour, sym.Day}
+		for i, at := range impactHorizons(published) {
+			if prices[i] == nil && !now.Before(at) && now.Sub(at) < impactTolerance {
+				symbols = append(symbols, sym.Symbol)
+				break
+			}
+		}
+	}
+	return symbols
+}
+
+// fillImpact prices impact's horizons that are settled by now from each
+// symbol's points, returning how many it priced. The impact is settled
+// once every horizon is priced or the tracking window has passed.
+func fillImpact(impact *Impact, points map[string][]pricePoint, now time.Time) int {
+	published, err := time.Parse(time.RFC3339, impact.Published)
+	if err != nil {
+		impact.Settled = true
+		return 0
+	}
+
+	filled := 0
+	complete := true
+	for i := range impact.Symbols {
+		sym := &impact.Symbols[i]
+		prices := [3]**ImpactPrice{&sym.Publish, &sym.Hour, &sym.Day}
+		for h, at := range impactHorizons(published) {
+			if *prices[h] != nil {
+				continue
+			}
+			if now.Before(at.Add(impactTolerance)) {
+				complete = false
+				continue
+			}
+			point, ok := priceAt(points[sym.Symbol], at)
+			if !ok {
+				complete = false
+				continue
+			}
+			*prices[h] = &ImpactPrice{Price: point.Price, At: point.At.Format(time.RFC3339)}
+			filled++
+		}
+		if sym.Publish != nil && sym.Publish.Price > 0 {
+			for _, price := range []*ImpactPrice{sym.Hour, sym.Day} {
+				if price != nil {
+					change := (price.Price - sym.Publish.Price) / sym.Publish.Price * 100
+					price.ChangePerc = &change
+				}
+			}
+		}
+	}
+	impact.Settled = complete || !now.Before(published.Add(impactWindow))
+	return filled
+}
+
+// priceAt returns the price at t: the snapshot nearest t within the
+// tolerance, otherwise the last one before t if it isn't stale. points
+// run oldest first.
+func priceAt(points []pricePoint, t time.Time) (pricePoint, bool) {
+	var best pricePoint
+	bestGap := impactTolerance + 1
+	last := -1
+	for i, point := range points {
+		gap := point.At.Sub(t)
+		if gap < 0 {
+			gap = -gap
+			last = i
+		}
+		if gap <= impactTolerance && gap < bestGap {
+			best, bestGap = point, gap
+		}
+	}
+	if bestGap <= impactTolerance {
+		return best, true
+	}
+	if last >= 0 && t.Sub(points[last].At) <= impactMaxStale {
+		return points[last], true
+	}
+	return pricePoint{}, false
+}
+
+// HandleNewsImpact serves GET /api/news/:id/impact, the prices of the
+// tickers an archived article mentions at publication, an hour and a day
+// later. Article IDs are listed by /api/news/archive.
+func HandleNewsImpact() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := strings.ToLower(c.Param("id"))
+		if _, err := strconv.ParseUint(id, 16, 64); err != nil || len(id) != 16 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"status": "error",
+				"error":  "invalid article id",
+			})
+			return
+		}
+
+		s := NewStockScraper(StockScraperOption{
+			Context: c.Request.Context(),
+		})
+		defer s.Close()
+
+		impact, err := s.Impact(id)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"status": "error",
+				"error":  err.Error(),
+			})
+			return
+		}
+		if impact == nil {
+			c.JSON(http.StatusNotFound, gin.H{
+				"status": "error",
+				"error":  "no impact tracked for article " + id,
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"status": "success",
+			"data":   impact,
+		})
+	}
+}
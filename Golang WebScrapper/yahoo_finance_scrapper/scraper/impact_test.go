@@ -0,0 +1,81 @@
+package scraper
+
+import (
+	"testing"
+	"time"
+
+	"go-webscraper/model"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestImpactSymbols(t *testing.T) {
+	article := model.Article{Entities: []model.Entity{
+		{Name: "Berkshire Hathaway", Type: "company"},
+		{Name: "BRK.B", Type: "ticker"},
+		{Name: "AAPL", Type: "ticker"},
+	}}
+	assert.Equal(t, []string{"BRK-B", "AAPL"}, impactSymbols(article))
+	assert.Empty(t, impactSymbols(model.Article{}))
+}
+
+func TestPriceAt(t *testing.T) {
+	at := time.Date(2024, 3, 5, 10, 0, 0, 0, marketLocation)
+	points := []pricePoint{
+		{At: at.Add(-24 * time.Hour), Price: 99},
+		{At: at.Add(-10 * time.Minute), Price: 100},
+		{At: at.Add(4 * time.Minute), Price: 101},
+	}
+
+	// The nearest snapshot wins, even just after the horizon
+	point, ok := priceAt(points, at)
+	assert.True(t, ok)
+	assert.Equal(t, 101.0, point.Price)
+
+	// Without one nearby, the last earlier price stands in
+	point, ok = priceAt(points[:1], at)
+	assert.True(t, ok)
+	assert.Equal(t, 99.0, point.Price)
+
+	_, ok = priceAt(points[:1], at.Add(5*24*time.Hour))
+	assert.False(t, ok)
+	_, ok = priceAt(points[2:], at.Add(-time.Hour))
+	assert.False(t, ok)
+}
+
+func TestFillImpact(t *testing.T) {
+	published := time.Date(2024, 3, 5, 10, 0, 0, 0, marketLocation)
+	impact := &Impact{
+		Published: published.Format(time.RFC3339),
+		Symbols:   []SymbolImpact{{Symbol: "AAPL"}},
+	}
+	points := map[string][]pricePoint{"AAPL": {
+		{At: published.Add(2 * time.Minute), Price: 100},
+		{At: published.Add(time.Hour), Price: 102},
+	}}
+
+	// Horizons are only priced once their tolerance has passed
+	assert.Equal(t, 0, fillImpact(impact, points, published.Add(5*time.Minute)))
+	assert.Nil(t, impact.Symbols[0].Publish)
+	assert.Equal(t, []string{"AAPL"}, impactDue(impact, published.Add(5*time.Minute)))
+
+	assert.Equal(t, 2, fillImpact(impact, points, published.Add(90*time.Minute)))
+	sym := impact.Symbols[0]
+	assert.Equal(t, 100.0, sym.Publish.Price)
+	assert.Equal(t, 102.0, sym.Hour.Price)
+	assert.InDelta(t, 2.0, *sym.Hour.ChangePerc, 1e-9)
+	assert.Nil(t, sym.Day)
+	assert.False(t, impact.Settled)
+
+	points["AAPL"] = append(points["AAPL"], pricePoint{At: published.Add(24 * time.Hour), Price: 95})
+	assert.Equal(t, 1, fillImpact(impact, points, published.Add(25*time.Hour)))
+	assert.InDelta(t, -5.0, *impact.Symbols[0].Day.ChangePerc, 1e-9)
+	assert.True(t, impact.Settled)
+
+	// Horizons with no snapshot stop being tracked after the window
+	missing := &Impact{Published: published.Format(time.RFC3339), Symbols: []SymbolImpact{{Symbol: "MSFT"}}}
+	assert.Equal(t, 0, fillImpact(missing, points, published.Add(25*time.Hour)))
+	assert.False(t, missing.Settled)
+	fillImpact(missing, points, published.Add(impactWindow))
+	assert.True(t, missing.Settled)
+}
@@ -0,0 +1,185 @@
+package scraper
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"go-webscraper/cachekey"
+	"go-webscraper/pkg/parse"
+	"go-webscraper/pkg/yahoo"
+	"go-webscraper/queue"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gocolly/colly"
+)
+
+type IndexComponent = yahoo.IndexComponent
+
+type IndexComponents struct {
+	Index      string           `json:"index"`
+	Components []IndexComponent `json:"components"`
+	Timestamp  string           `json:"timestamp"`
+}
+
+func indexComponentsCacheKey(symbol string) string {
+	return cachekey.IndexComponents.Key(symbol)
+}
+
+// parseComponentsTable maps columns by header text, so the optional weight
+// column and reordered tables parse the same way.
+func parseComponentsTable(e *colly.HTMLElement, region Region) []IndexComponent {
+	columns := make(map[string]int)
+	e.ForEach("thead th", func(i int, th *colly.HTMLElement) {
+		columns[strings.ToLower(strings.TrimSpace(th.Text))] = i + 1
+	})
+	cell := func(row *colly.HTMLElement, names ...string) string {
+		for _, name := range names {
+			if i, ok := columns[name]; ok {
+				return strings.TrimSpace(row.ChildText(fmt.Sprintf("td:nth-child(%d)", i)))
+			}
+		}
+		return ""
+	}
+
+	components := make([]IndexComponent, 0)
+	e.ForEach("tbody tr", func(_ int, row *colly.HTMLElement) {
+		component := IndexComponent{
+			Symbol: strings.ToUpper(cell(row, "symbol")),
+			Name:   cell(row, "company name", "name"),
+		}
+		if component.Symbol == "" {
+			return
+		}
+		if price, err := region.ParseFloat(cell(row, "last price", "price")); parse.OK("components.price", err) {
+			component.Price = price
+		}
+		if change, err := region.ParseFloat(cell(row, "change")); parse.OK("components.change", err) {
+			component.Change = change
+		}
+		if changePerc, err := region.ParsePercentage(cell(row, "% change", "change %")); parse.OK("components.change_percent", err) {
+			component.ChangePerc = changePerc
+		}
+		if volume, err := region.ParseInt(cell(row, "volume")); parse.OK("components.volume", err) {
+			component.Volume = volume
+		}
+		if weight, err := region.ParsePercentage(cell(row, "weight", "% weight")); parse.OK("components.weight", err) {
+			component.Weight = weight
+		}
+		components = append(components, component)
+	})
+	return components
+}
+
+// ScrapeIndexComponents scrapes an index's constituents, reusing the last
+// copy when Yahoo reports the components pages unchanged.
+func (s *StockScraper) ScrapeIndexComponents(symbol string) (*IndexComponents, error) {
+	if !ValidSymbol(symbol) {
+		return nil, fmt.Errorf("invalid symbol: %s", symbol)
+	}
+	symbol = strings.ToUpper(symbol)
+
+	cacheKey := s.region.CacheKey(indexComponentsCacheKey(symbol))
+	if cached, err := s.cache.Get(s.ctx, cacheKey); err == nil && !s.fresh {
+		var index IndexComponents
+		if err := json.Unmarshal(cached, &index); err == nil {
+			return &index, nil
+		}
+	}
+
+	release, err := acquire(s.ctx, s.pool, s.priority)
+	if err != nil {
+		var stale IndexComponents
+		if serveStale(s.ctx, s.cache, cacheKey, &stale, err) {
+			return &stale, nil
+		}
+		return nil, err
+	}
+	defer release()
+
+	index := &IndexComponents{
+		Index:      symbol,
+		Components: make([]IndexComponent, 0),
+		Timestamp:  time.Now().Format(time.RFC3339),
+	}
+	ctx := yahoo.WithFetchLog(s.ctx)
+	notModified, err := scrapeConditional(ctx, s.cache, cacheKey, s.ttl, index, func(ctx context.Context) error {
+		components, err := s.yahoo.IndexComponents(ctx, symbol)
+		if err != nil {
+			return err
+		}
+		index.Components = append(index.Components, components...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(index.Components) == 0 {
+		return nil, nil
+	}
+
+	if !notModified {
+		cacheIfChanged(ctx, s.cache, s.tracker, cacheKey, index, s.ttl)
+	}
+
+	return index, nil
+}
+
+func HandleIndexComponents(pool *queue.Pool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		symbol := c.Param("symbol")
+		if !ValidSymbol(symbol) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "invalid symbol",
+			})
+			return
+		}
+
+		region, err := LookupRegion(c.Query("region"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		scraper := NewStockScraper(StockScraperOption{
+			Region:  region.Code,
+			Context: c.Request.Context(),
+			Pool:    pool,
+		})
+		defer scraper.Close()
+
+		if notModifiedSince(c, c.Request.Context(), scraper.cache, scraper.tracker, region.CacheKey(indexComponentsCacheKey(strings.ToUpper(symbol)))) {
+			return
+		}
+
+		index, err := scraper.ScrapeIndexComponents(symbol)
+		if shed(c, pool, err) {
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+		if index == nil {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "no components listed for " + strings.ToUpper(symbol),
+			})
+			return
+		}
+
+		cacheKey := region.CacheKey(indexComponentsCacheKey(strings.ToUpper(symbol)))
+		setCacheControl(c, scraper.cache, cacheKey)
+		c.JSON(http.StatusOK, withProvenance(c, scraper.cache, cacheKey, gin.H{
+			"status": "success",
+			"data":   index,
+		}))
+	}
+}
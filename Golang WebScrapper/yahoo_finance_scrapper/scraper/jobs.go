@@ -0,0 +1,78 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-webscraper/queue"
+)
+
+// RegisterJobs adds the long-running scrapes that handlers can hand off to
+// the job queue with ?async=true. They run at interactive priority since a
+// caller is polling for them. Results are the same unconverted data the
+// synchronous endpoints return before ?currency= is applied.
+func RegisterJobs(q *queue.Queue, pool *queue.Pool) {
+	q.Register("sectors", func(ctx context.Context, params map[string]string) (interface{}, error) {
+		scraper := NewSectorScraper(ScraperOption{
+			Region:   params["region"],
+			Context:  ctx,
+			Pool:     pool,
+			Priority: queue.Interactive,
+		})
+		defer scraper.Close()
+
+		return scraper.ScrapeAllSectors()
+	})
+
+	q.Register("sector", func(ctx context.Context, params map[string]string) (interface{}, error) {
+		if params["sector"] == "" {
+			return nil, fmt.Errorf("sector is required")
+		}
+
+		scraper := NewSectorScraper(ScraperOption{
+			Region:   params["region"],
+			Context:  ctx,
+			Pool:     pool,
+			Priority: queue.Interactive,
+		})
+		defer scraper.Close()
+
+		return scraper.ScrapeSector(params["sector"])
+	})
+
+	q.Register("news", func(ctx context.Context, params map[string]string) (interface{}, error) {
+		recent, _ := strconv.ParseBool(params["recent"])
+		since, err := parseSince(params["since"], recent, time.Now())
+		if err != nil {
+			return nil, err
+		}
+		crawl, err := parseCrawlLimits(params["max_pages"], params["max_depth"], params["max_duration"])
+		if err != nil {
+			return nil, err
+		}
+		if params["source"] != "" && !validNewsSource(params["source"]) {
+			return nil, fmt.Errorf("source must be one of: %s", strings.Join(NewsSources, ", "))
+		}
+
+		s := NewScraper(ScraperOption{
+			Region:   params["region"],
+			Context:  ctx,
+			Pool:     pool,
+			Priority: queue.Interactive,
+			Crawl:    crawl,
+
+			NewsSource: params["source"],
+		})
+		defer s.Close()
+
+		articles, err := s.ScrapeNews(since)
+		if err != nil {
+			return nil, err
+		}
+		types, _ := parseArticleTypes(params["type"])
+		return filterArticlesByByline(filterArticlesByType(articles, types), splitList(params["publisher"]), splitList(params["author"])), nil
+	})
+}
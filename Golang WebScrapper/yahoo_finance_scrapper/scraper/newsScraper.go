@@ -3,157 +3,186 @@ package scraper
 import (
 	"context"
 	"encoding/json"
-	"fmt"
 	"log"
 	"net/http"
 	"strings"
-	"sync"
 	"time"
 
+	"go-webscraper/cache"
+	"go-webscraper/cachekey"
+	"go-webscraper/keyspace"
+	"go-webscraper/model"
+	"go-webscraper/pkg/yahoo"
+	"go-webscraper/queue"
+
 	"github.com/gin-gonic/gin"
-	"github.com/gocolly/colly"
 	"github.com/redis/go-redis/v9"
 )
 
-type Article struct {
-	DatePublished string `json:"date"`
-	Title         string `json:"title"`
-	Link          string `json:"link"`
-	Snippet       string `json:"snippet"`
-}
+type Article = model.Article
 
 type Scraper struct {
-	redis     *redis.Client
-	ctx       context.Context
-	ttl       time.Duration
-	mutex     sync.Mutex
-	collector *colly.Collector
+	redis    *redis.Client
+	cache    cache.Cache
+	ctx      context.Context
+	ttl      time.Duration
+	yahoo    *yahoo.Client
+	region   Region
+	pool     *queue.Pool
+	priority queue.Priority
+	crawl    CrawlLimits
+	fresh    bool
+	// incremental crawls skip links visited by earlier incremental
+	// crawls and resume from the links they left unvisited.
+	incremental bool
+	newsSource  string
 }
 
 type ScraperOption struct {
+	// CacheTTL overrides the per-source TTL policy in cachekey when set.
 	CacheTTL      time.Duration
 	RedisAddr     string
 	RedisPassword string
 	RedisDB       int
 	NumThread     int
+	Region        string
+	Context       context.Context
+	Pool          *queue.Pool
+	Priority      queue.Priority
+	Crawl         CrawlLimits
+	// Incremental makes ScrapeNews a feed: each crawl visits and returns
+	// only links earlier incremental crawls haven't, tracked in Redis.
+	// There is one frontier per region and the scheduled news refresh
+	// owns it, so request handlers must never set this: a crawl that
+	// marks articles seen hides them from the refresh, which archives,
+	// publishes and alerts on them.
+	Incremental bool
+	// NewsSource picks how ScrapeNews discovers articles, one of
+	// NewsSources; empty uses the configured source.
+	NewsSource string
 }
 
 func NewScraper(opts ScraperOption) *Scraper {
-	if opts.CacheTTL == 0 {
-		opts.CacheTTL = 24 * time.Hour
-	}
-	if opts.RedisAddr == "" {
-		opts.RedisAddr = "localhost:6379"
-	}
 	if opts.NumThread == 0 {
 		opts.NumThread = 20
 	}
 
-	rdb := redis.NewClient(&redis.Options{
-		Addr:     opts.RedisAddr,
-		Password: opts.RedisPassword,
-		DB:       opts.RedisDB,
-	})
-
-	c := colly.NewCollector(
-		colly.UserAgent("Mozilla/5.0 (Macintosh; Intel Mac OS X 11_2_1) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/88.0.4324.182 Safari/537.36"),
-		colly.AllowedDomains("finance.yahoo.com"),
-		colly.MaxDepth(0),
-		colly.Async(true),
-	)
+	region, err := LookupRegion(opts.Region)
+	if err != nil {
+		log.Printf("%v, falling back to %s", err, DefaultRegion)
+		region = Regions[DefaultRegion]
+	}
 
-	c.AllowURLRevisit = false
+	rdb := newRedis(opts.RedisAddr, opts.RedisPassword, opts.RedisDB)
+	traceRedis(rdb)
+	keyspace.Instrument(rdb)
 
-	c.Limit(&colly.LimitRule{
-		DomainGlob:  "*",
+	client, err := yahoo.New(yahoo.Option{
+		Region:      region.Code,
 		Parallelism: opts.NumThread,
 		Delay:       100 * time.Millisecond,
 	})
+	if err != nil {
+		log.Fatalf("Failed to create Yahoo client: %v", err)
+	}
 
 	return &Scraper{
-		redis:     rdb,
-		ctx:       context.Background(),
-		ttl:       24 * time.Hour,
-		mutex:     sync.Mutex{},
-		collector: c,
+		redis:    rdb,
+		cache:    cache.New(rdb),
+		ctx:      optionContext(opts.Context),
+		ttl:      opts.CacheTTL,
+		yahoo:    client,
+		region:   region,
+		pool:     opts.Pool,
+		priority: opts.Priority,
+		crawl:    opts.Crawl.normalize(),
+
+		incremental: opts.Incremental,
+		newsSource:  opts.NewsSource,
 	}
 }
 
-func (s *Scraper) ScrapeNews(recentOnly bool) ([]Article, error) {
-	var newsData []Article
-	var currentTitle string
-	var currentLink string
-	today := time.Now().Format("2006-01-02")
-
+// ScrapeNews crawls the region's news pages and returns articles published
+// at or after since. The zero time returns everything found.
+func (s *Scraper) ScrapeNews(since time.Time) ([]Article, error) {
 	startTime := time.Now()
-	var visitedLinks, scrapedArticles, cachedArticles int
-	s.collector.OnRequest(func(r *colly.Request) {
-		url := r.URL.String()
-		s.mutex.Lock()
-		currentLink = url
-		defer s.mutex.Unlock()
-
-		if article, err := s.getFromCache(url); err == nil && article != nil {
-			if article.DatePublished == today {
-				newsData = append(newsData, *article)
-			}
-			cachedArticles++
-			r.Abort()
-			return
-		} else {
-			visitedLinks++
-			log.Printf("Visiting: %s", url)
-		}
-	})
-
-	s.collector.OnHTML("head title", func(e *colly.HTMLElement) {
-		s.mutex.Lock()
-		currentTitle = e.Text
-		s.mutex.Unlock()
-	})
+	crawl := yahoo.NewsCrawl{
+		Since:       since,
+		MaxPages:    s.crawl.MaxPages,
+		MaxDepth:    s.crawl.MaxDepth,
+		MaxDuration: s.crawl.MaxDuration,
+		Store:       articleCache{s},
+	}
 
-	s.collector.OnHTML("article", func(e *colly.HTMLElement) {
-		articleDate := e.ChildAttr("time", "datetime")
-		if recentOnly && (articleDate == "" || strings.Split(articleDate, "T")[0] != today) {
-			return
+	var crawled *frontier
+	if s.incremental {
+		var err error
+		if crawled, err = s.loadFrontier(startTime); err != nil {
+			log.Printf("%v, crawling everything", err)
 		}
+	}
+	if crawled != nil {
+		crawl.Skip = crawled.seen
+	}
 
-		article := Article{
-			DatePublished: articleDate,
-			Title:         currentTitle,
-			Link:          currentLink,
-			Snippet:       e.ChildText("p"),
-		}
+	source, feeds := newsDiscoverySettings()
+	if s.newsSource != "" {
+		source = s.newsSource
+	}
 
-		s.mutex.Lock()
-		newsData = append(newsData, article)
-		scrapedArticles++
-		s.cacheArticle(currentLink, article, ExcludeFromCache)
-		s.mutex.Unlock()
-	})
+	release, err := acquire(s.ctx, s.pool, s.priority)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
 
-	s.collector.OnHTML("a[href]", func(e *colly.HTMLElement) {
-		link := e.Request.AbsoluteURL(e.Attr("href"))
-		if strings.Contains(link, "/news/") {
-			e.Request.Visit(link)
+	if source == NewsSourceFeeds {
+		if crawl.Links, err = s.discoverFromFeeds(feeds, since); err != nil {
+			log.Printf("%v, crawling the news hub instead", err)
+			source = NewsSourceCrawl
 		}
-	})
+	}
+	crawl.Hub = source == NewsSourceCrawl
+	if crawled != nil {
+		crawl.Links = append(crawl.Links, crawled.pending...)
+	}
 
-	err := s.collector.Visit("https://finance.yahoo.com/news/")
+	result, err := s.yahoo.News(s.ctx, crawl)
 	if err != nil {
-		return nil, fmt.Errorf("failed to start scraping: %v", err)
+		return nil, err
 	}
 
-	s.collector.Wait()
+	if crawled != nil {
+		if err := s.saveFrontier(result.Visited, result.Unvisited, startTime); err != nil {
+			log.Printf("%v", err)
+		}
+	}
 
-	log.Printf("Scraping completed - Time: %v, Visited: %d, Scraped: %d, Cached: %d, Total: %d",
+	log.Printf("Scraping completed - Source: %s, Time: %v, Visited: %d, Scraped: %d, Cached: %d, Skipped: %d, Total: %d",
+		source,
 		time.Since(startTime).Round(time.Millisecond),
-		visitedLinks,
-		scrapedArticles,
-		cachedArticles,
-		len(newsData))
+		result.Fetched,
+		result.Scraped,
+		result.Stored,
+		result.Skipped,
+		len(result.Articles))
+
+	return result.Articles, nil
+}
 
-	return newsData, nil
+// articleCache serves news crawls the articles in the scraper's URL-keyed
+// cache and caches the ones they parse.
+type articleCache struct {
+	s *Scraper
+}
+
+func (a articleCache) Lookup(url string) (*Article, bool) {
+	article, err := a.s.getFromCache(url)
+	return article, err == nil && article != nil
+}
+
+func (a articleCache) Store(url string, article Article) {
+	a.s.cacheArticle(url, article, ExcludeFromCache)
 }
 
 func (s *Scraper) cacheArticle(url string, article Article, excludePatterns []string) {
@@ -169,23 +198,26 @@ func (s *Scraper) cacheArticle(url string, article Article, excludePatterns []st
 		return
 	}
 
-	if err := s.redis.Set(s.ctx, url, data, s.ttl).Err(); err != nil {
+	if err := s.cache.Set(s.ctx, cachekey.Article.Key(url), data, ttlFor(cachekey.Article.Key(url), s.ttl)); err != nil {
 		log.Printf("Error caching article for URL %s: %v", url, err)
 		return
 	}
 }
 
 func (s *Scraper) getFromCache(url string) (*Article, error) {
-	data, err := s.redis.Get(s.ctx, url).Result()
+	if s.fresh {
+		return nil, nil
+	}
+	data, err := s.cache.Get(s.ctx, cachekey.Article.Key(url))
 	if err != nil {
-		if err == redis.Nil {
+		if err == cache.ErrMiss {
 			return nil, nil
 		}
 		return nil, err
 	}
 
 	var article Article
-	if err := json.Unmarshal([]byte(data), &article); err != nil {
+	if err := json.Unmarshal(data, &article); err != nil {
 		return nil, err
 	}
 	return &article, nil
@@ -195,41 +227,149 @@ func (s *Scraper) Close() {
 	s.redis.Close()
 }
 
+var ArticleCSVHeaders = []string{"Date", "Title", "Link", "Snippet", "Type", "Publisher", "Author"}
+
+func ArticleRecord(article Article) []string {
+	return []string{
+		article.DatePublished,
+		article.Title,
+		article.Link,
+		article.Snippet,
+		article.Type,
+		article.Publisher,
+		article.Author,
+	}
+}
+
 type NewsResponse struct {
-	Status string    `json:"status"`
-	Data   []Article `json:"data"`
+	Status string       `json:"status"`
+	Crawl  *CrawlLimits `json:"crawl,omitempty"`
+	Data   []Article    `json:"data"`
 }
 
 type NewsRequest struct {
-	RecentOnly bool `form:"recent" default:"false"`
+	RecentOnly bool   `form:"recent" default:"false"`
+	Since      string `form:"since"`
+	Region     string `form:"region"`
+	Async      bool   `form:"async"`
+	Type       string `form:"type"`
+	Source     string `form:"source"`
+	Publisher  string `form:"publisher"`
+	Author     string `form:"author"`
+
+	MaxPages    string `form:"max_pages"`
+	MaxDepth    string `form:"max_depth"`
+	MaxDuration string `form:"max_duration"`
 }
 
-func HandleNews(c *gin.Context) {
-	var req NewsRequest
-	if err := c.ShouldBindQuery(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"status": "error",
-			"error":  err.Error(),
+func HandleNews(jobs *queue.Queue, pool *queue.Pool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req NewsRequest
+		if err := c.ShouldBindQuery(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"status": "error",
+				"error":  err.Error(),
+			})
+			return
+		}
+
+		region, err := LookupRegion(req.Region)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"status": "error",
+				"error":  err.Error(),
+			})
+			return
+		}
+
+		types, ok := parseArticleTypes(req.Type)
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"status": "error",
+				"error":  "type must be a comma-separated list of: " + strings.Join(ArticleTypes, ", "),
+			})
+			return
+		}
+
+		since, err := parseSince(req.Since, req.RecentOnly, time.Now())
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"status": "error",
+				"error":  err.Error(),
+			})
+			return
+		}
+
+		crawl, err := parseCrawlLimits(req.MaxPages, req.MaxDepth, req.MaxDuration)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"status": "error",
+				"error":  err.Error(),
+			})
+			return
+		}
+
+		if req.Source != "" && !validNewsSource(req.Source) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"status": "error",
+				"error":  "source must be one of: " + strings.Join(NewsSources, ", "),
+			})
+			return
+		}
+
+		if req.Async {
+			params := crawl.params()
+			params["region"] = region.Code
+			if !since.IsZero() {
+				params["since"] = since.Format(time.RFC3339)
+			}
+			params["type"] = strings.Join(types, ",")
+			params["source"] = req.Source
+			params["publisher"] = req.Publisher
+			params["author"] = req.Author
+
+			job, err := jobs.Enqueue("news", params)
+			if shed(c, pool, err) {
+				return
+			}
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{
+					"status": "error",
+					"error":  err.Error(),
+				})
+				return
+			}
+			queue.Accepted(c, job)
+			return
+		}
+
+		s := NewScraper(ScraperOption{
+			NumThread: 0,
+			Region:    region.Code,
+			Context:   c.Request.Context(),
+			Pool:      pool,
+			Crawl:     crawl,
+
+			NewsSource: req.Source,
 		})
-		return
-	}
+		defer s.Close()
 
-	s := NewScraper(ScraperOption{
-		NumThread: 0,
-	})
-	defer s.Close()
+		articles, err := s.ScrapeNews(since)
+		if shed(c, pool, err) {
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"status": "error",
+				"error":  "Failed to fetch news",
+			})
+			return
+		}
 
-	articles, err := s.ScrapeNews(req.RecentOnly)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"status": "error",
-			"error":  "Failed to fetch news",
+		c.JSON(http.StatusOK, NewsResponse{
+			Status: "success",
+			Crawl:  &crawl,
+			Data:   filterArticlesByByline(filterArticlesByType(articles, types), splitList(req.Publisher), splitList(req.Author)),
 		})
-		return
 	}
-
-	c.JSON(http.StatusOK, NewsResponse{
-		Status: "success",
-		Data:   articles,
-	})
 }
@@ -0,0 +1,134 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"go-webscraper/upstream"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// serverTransport sends every request to srv, whatever its host, so
+// scrapes keep their region's URLs.
+func serverTransport(srv *httptest.Server) http.RoundTripper {
+	return forwardTransport{host: strings.TrimPrefix(srv.URL, "https://"), next: srv.Client().Transport}
+}
+
+type forwardTransport struct {
+	host string
+	next http.RoundTripper
+}
+
+func (t forwardTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	forwarded := req.Clone(req.Context())
+	forwarded.URL.Host = t.host
+	resp, err := t.next.RoundTrip(forwarded)
+	if resp != nil {
+		resp.Request = req
+	}
+	return resp, err
+}
+
+// TestScrapeNewsParallelPages crawls pages fetched in parallel, twice on
+// one scraper, so the race detector sees callbacks interleave and each
+// article must keep its own page's title.
+func TestScrapeNewsParallelPages(t *testing.T) {
+	const stories = 12
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/news/" {
+			for i := 0; i < stories; i++ {
+				// Every story is linked twice but must be fetched once
+				fmt.Fprintf(w, `<a href="/news/story-%d.html">%d</a><a href="/news/story-%d.html">again</a>`, i, i, i)
+			}
+			return
+		}
+		var i int
+		if _, err := fmt.Sscanf(r.URL.Path, "/news/story-%d.html", &i); err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprintf(w, `<html><head><title>Story %d</title></head><body>
+			<article><time datetime="2026-10-16T10:00:00Z"></time><p>Body %d</p></article>
+			<a href="/news/">News</a>
+		</body></html>`, i, i)
+	}))
+	defer srv.Close()
+
+	restore := upstream.UseTransport(serverTransport(srv))
+	defer restore()
+
+	mr := miniredis.RunT(t)
+	s := NewScraper(ScraperOption{RedisAddr: mr.Addr(), NumThread: 6, NewsSource: NewsSourceCrawl})
+	defer s.redis.Close()
+
+	for run := 0; run < 2; run++ {
+		articles, err := s.ScrapeNews(time.Time{})
+		require.NoError(t, err)
+		assert.Len(t, articles, stories, "run %d", run)
+		for _, article := range articles {
+			var i int
+			_, err := fmt.Sscanf(article.Link[strings.LastIndex(article.Link, "/"):], "/story-%d.html", &i)
+			require.NoError(t, err, article.Link)
+			assert.Equal(t, fmt.Sprintf("Story %d", i), article.Title)
+		}
+	}
+}
+
+type countingSummarizer struct {
+	calls int
+}
+
+func (cs *countingSummarizer) Summarize(ctx context.Context, title, text string, sentences int) (string, error) {
+	cs.calls++
+	return "Summary of " + title, nil
+}
+
+// TestSummarizeOffRequestPath checks request scrapes never call the
+// summarizer, and serve the summary the refresh cached once it has run.
+func TestSummarizeOffRequestPath(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/news/" {
+			fmt.Fprint(w, `<a href="/news/story.html">Story</a>`)
+			return
+		}
+		fmt.Fprint(w, `<html><head><title>Story</title></head><body>
+			<article><time datetime="2026-10-16T10:00:00Z"></time><p>Body</p></article>
+		</body></html>`)
+	}))
+	defer srv.Close()
+
+	summarizer := &countingSummarizer{}
+	require.NoError(t, ConfigureSummarizer(summarizer, 3))
+	t.Cleanup(func() { ConfigureSummarizer(nil, 3) })
+
+	restore := upstream.UseTransport(serverTransport(srv))
+	defer restore()
+
+	mr := miniredis.RunT(t)
+	s := NewScraper(ScraperOption{RedisAddr: mr.Addr(), NumThread: 2, NewsSource: NewsSourceCrawl})
+	defer s.redis.Close()
+
+	articles, err := s.ScrapeNews(time.Time{})
+	require.NoError(t, err)
+	require.Len(t, articles, 1)
+	assert.Empty(t, articles[0].Summary)
+	assert.Zero(t, summarizer.calls)
+
+	s.Summarize(articles)
+	assert.Equal(t, "Summary of Story", articles[0].Summary)
+	assert.Equal(t, 1, summarizer.calls)
+
+	articles, err = s.ScrapeNews(time.Time{})
+	require.NoError(t, err)
+	require.Len(t, articles, 1)
+	assert.Equal(t, "Summary of Story", articles[0].Summary)
+	assert.Equal(t, 1, summarizer.calls)
+}
@@ -0,0 +1,143 @@
+package scraper
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"go-webscraper/cache"
+	"go-webscraper/cachekey"
+	"go-webscraper/changes"
+	"go-webscraper/keyspace"
+	"go-webscraper/pkg/yahoo"
+	"go-webscraper/queue"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+type OptionContract = yahoo.OptionContract
+
+type OptionsScraper struct {
+	redis    *redis.Client
+	cache    cache.Cache
+	ctx      context.Context
+	ttl      time.Duration
+	yahoo    *yahoo.Client
+	region   Region
+	tracker  *changes.Tracker
+	pool     *queue.Pool
+	priority queue.Priority
+	fresh    bool
+}
+
+var OptionsLists = yahoo.OptionsLists
+
+func optionsCacheKey(by string) string {
+	return cachekey.OptionsMostActive.Key(by)
+}
+
+func NewOptionsScraper(opts ScraperOption) *OptionsScraper {
+	rdb := newRedis(opts.RedisAddr, opts.RedisPassword, opts.RedisDB)
+	traceRedis(rdb)
+	keyspace.Instrument(rdb)
+
+	client, err := yahoo.New(yahoo.Option{Region: DefaultRegion})
+	if err != nil {
+		log.Fatalf("Failed to create Yahoo client: %v", err)
+	}
+
+	return &OptionsScraper{
+		redis:    rdb,
+		cache:    cache.New(rdb),
+		tracker:  changes.NewTracker(rdb),
+		ctx:      optionContext(opts.Context),
+		ttl:      opts.CacheTTL,
+		yahoo:    client,
+		region:   Regions[DefaultRegion],
+		pool:     opts.Pool,
+		priority: opts.Priority,
+	}
+}
+
+func (s *OptionsScraper) ScrapeMostActiveOptions(by string) ([]OptionContract, error) {
+	if _, exists := OptionsLists[by]; !exists {
+		return nil, fmt.Errorf("invalid options list: %s", by)
+	}
+
+	cacheKey := optionsCacheKey(by)
+	if cached, err := s.cache.Get(s.ctx, cacheKey); err == nil && !s.fresh {
+		var contracts []OptionContract
+		if err := json.Unmarshal(cached, &contracts); err == nil {
+			return contracts, nil
+		}
+	}
+
+	release, err := acquire(s.ctx, s.pool, s.priority)
+	if err != nil {
+		var stale []OptionContract
+		if serveStale(s.ctx, s.cache, cacheKey, &stale, err) {
+			return stale, nil
+		}
+		return nil, err
+	}
+	defer release()
+
+	ctx := yahoo.WithFetchLog(s.ctx)
+	contracts, err := s.yahoo.MostActiveOptions(ctx, by)
+	if err != nil {
+		return nil, err
+	}
+
+	cacheIfChanged(ctx, s.cache, s.tracker, cacheKey, contracts, s.ttl)
+
+	return contracts, nil
+}
+
+func (s *OptionsScraper) Close() {
+	s.redis.Close()
+}
+
+func HandleMostActiveOptions(pool *queue.Pool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		by := c.DefaultQuery("by", "oi")
+		if _, exists := OptionsLists[by]; !exists {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "by must be one of: oi, iv",
+			})
+			return
+		}
+
+		scraper := NewOptionsScraper(ScraperOption{
+			Context: c.Request.Context(),
+			Pool:    pool,
+		})
+		defer scraper.Close()
+
+		if notModifiedSince(c, c.Request.Context(), scraper.cache, scraper.tracker, optionsCacheKey(by)) {
+			return
+		}
+
+		contracts, err := scraper.ScrapeMostActiveOptions(by)
+		if shed(c, pool, err) {
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		cacheKey := optionsCacheKey(by)
+		setCacheControl(c, scraper.cache, cacheKey)
+		c.JSON(http.StatusOK, withProvenance(c, scraper.cache, cacheKey, gin.H{
+			"status": "success",
+			"by":     by,
+			"data":   contracts,
+		}))
+	}
+}
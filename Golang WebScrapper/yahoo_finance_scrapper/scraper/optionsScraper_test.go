@@ -0,0 +1,79 @@
+package scraper
+
+import (
+	"testing"
+
+	"go-webscraper/upstream"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOptionsColumnMapping(t *testing.T) {
+	tests := []struct {
+		name string
+		by   string
+		path string
+		page string
+		rows int
+		want OptionContract
+	}{
+		{
+			name: "Open Interest",
+			by:   "oi",
+			path: "/markets/options/highest-open-interest/",
+			page: "options.html",
+			rows: 2,
+			want: OptionContract{
+				Contract:          "AAPL261218C00250000",
+				Underlying:        "AAPL",
+				Type:              "call",
+				Strike:            250,
+				Expiry:            "2026-12-18",
+				LastPrice:         4.35,
+				Volume:            12031,
+				OpenInterest:      98114,
+				ImpliedVolatility: 24.51,
+			},
+		},
+		{
+			// Reordered columns with the alternate header names
+			name: "Implied Volatility",
+			by:   "iv",
+			path: "/markets/options/highest-implied-volatility/",
+			// The row without a contract name is skipped
+			page: "options_iv.html",
+			rows: 1,
+			want: OptionContract{
+				Contract:          "GME261120C00040000",
+				Underlying:        "GME",
+				Type:              "call",
+				Strike:            40,
+				Expiry:            "2026-11-20",
+				LastPrice:         0.92,
+				Volume:            8044,
+				OpenInterest:      3512,
+				ImpliedVolatility: 182.4,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mr := miniredis.RunT(t)
+			restore := upstream.UseTransport(fixtureTransport{tt.path: tt.page})
+			defer restore()
+
+			s := NewOptionsScraper(ScraperOption{RedisAddr: mr.Addr()})
+			defer s.Close()
+			contracts, err := s.ScrapeMostActiveOptions(tt.by)
+			require.NoError(t, err)
+			require.Len(t, contracts, tt.rows)
+
+			got := contracts[0]
+			got.Timestamp = ""
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
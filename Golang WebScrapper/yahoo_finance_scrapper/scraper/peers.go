@@ -0,0 +1,125 @@
+package scraper
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"go-webscraper/cachekey"
+	"go-webscraper/pkg/yahoo"
+	"go-webscraper/queue"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	DefaultPeerLimit = 5
+	MaxPeerLimit     = 10
+)
+
+type (
+	PeerQuote = yahoo.PeerQuote
+	PeerGroup = yahoo.PeerGroup
+)
+
+func peersCacheKey(symbol string, limit int) string {
+	return cachekey.Peers.Key(symbol, strconv.Itoa(limit))
+}
+
+// ScrapePeers discovers symbol's industry from its profile and compares it
+// with up to limit companies from the industry page.
+func (s *StockScraper) ScrapePeers(symbol string, limit int) (*PeerGroup, error) {
+	if !ValidSymbol(symbol) {
+		return nil, fmt.Errorf("invalid symbol: %s", symbol)
+	}
+	symbol = strings.ToUpper(symbol)
+
+	cacheKey := s.region.CacheKey(peersCacheKey(symbol, limit))
+	if cached, err := s.cache.Get(s.ctx, cacheKey); err == nil && !s.fresh {
+		var group PeerGroup
+		if err := json.Unmarshal(cached, &group); err == nil {
+			return &group, nil
+		}
+	}
+
+	release, err := acquire(s.ctx, s.pool, s.priority)
+	if err != nil {
+		var stale PeerGroup
+		if serveStale(s.ctx, s.cache, cacheKey, &stale, err) {
+			return &stale, nil
+		}
+		return nil, err
+	}
+	defer release()
+
+	ctx := yahoo.WithFetchLog(s.ctx)
+	group, err := s.yahoo.Peers(ctx, symbol, limit)
+	if err != nil || group == nil {
+		return nil, err
+	}
+
+	cacheIfChanged(ctx, s.cache, s.tracker, cacheKey, group, s.ttl)
+
+	return group, nil
+}
+
+func HandlePeers(pool *queue.Pool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		symbol := c.Param("symbol")
+		if !ValidSymbol(symbol) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "invalid symbol",
+			})
+			return
+		}
+
+		limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(DefaultPeerLimit)))
+		if err != nil || limit <= 0 || limit > MaxPeerLimit {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": fmt.Sprintf("limit must be between 1 and %d", MaxPeerLimit),
+			})
+			return
+		}
+
+		region, err := LookupRegion(c.Query("region"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		scraper := NewStockScraper(StockScraperOption{
+			Region:  region.Code,
+			Context: c.Request.Context(),
+			Pool:    pool,
+		})
+		defer scraper.Close()
+
+		group, err := scraper.ScrapePeers(symbol, limit)
+		if shed(c, pool, err) {
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+		if group == nil {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "no industry found for " + strings.ToUpper(symbol),
+			})
+			return
+		}
+
+		cacheKey := region.CacheKey(peersCacheKey(strings.ToUpper(symbol), limit))
+		setCacheControl(c, scraper.cache, cacheKey)
+		c.JSON(http.StatusOK, withProvenance(c, scraper.cache, cacheKey, gin.H{
+			"status": "success",
+			"data":   group,
+		}))
+	}
+}
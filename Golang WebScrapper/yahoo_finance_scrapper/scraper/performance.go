@@ -0,0 +1,283 @@
+package scraper
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"go-webscraper/portfolio"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DefaultBenchmark is what portfolio performance is compared against when
+// ?benchmark= isn't given. The end-of-day job always records its close.
+const DefaultBenchmark = "^GSPC"
+
+// PerformancePoint is a portfolio's value and cumulative returns, in
+// percent, at one stored close.
+type PerformancePoint struct {
+	Date      string  `json:"date"`
+	Value     float64 `json:"value"`
+	Return    float64 `json:"return"`
+	Benchmark float64 `json:"benchmark"`
+}
+
+// PositionContribution is how much one position added to the portfolio's
+// return. Contribution sums the position's share of each day's return, in
+// percentage points, so contributions add up to the daily returns rather
+// than to the compounded time-weighted return.
+type PositionContribution struct {
+	Symbol       string  `json:"symbol"`
+	Quantity     float64 `json:"quantity"`
+	MarketValue  float64 `json:"market_value"`
+	PnL          float64 `json:"pnl"`
+	Contribution float64 `json:"contribution"`
+}
+
+// Performance measures a portfolio from its first transaction to the
+// latest stored close. TimeWeightedReturn chains daily returns net of
+// each day's buys and sells, so deposits don't count as gains.
+type Performance struct {
+	From               string                 `json:"from"`
+	To                 string                 `json:"to"`
+	Value              float64                `json:"value"`
+	TimeWeightedReturn float64                `json:"time_weighted_return"`
+	Benchmark          string                 `json:"benchmark"`
+	BenchmarkReturn    float64                `json:"benchmark_return"`
+	ExcessReturn       float64                `json:"excess_return"`
+	Positions          []PositionContribution `json:"positions"`
+	Series             []PerformancePoint     `json:"series"`
+}
+
+// closeOn returns the last close in history on or before date, or 0.
+func closeOn(history []EOD, date string) float64 {
+	price := 0.0
+	for _, record := range history {
+		if record.Date > date {
+			break
+		}
+		if record.Close > 0 {
+			price = record.Close
+		}
+	}
+	return price
+}
+
+// computePerformance values p at every date any of its symbols has a
+// stored close, from its first transaction on. A trade on a day without a
+// close is settled at the next one. Symbols without a close yet are
+// valued at their first trade price.
+func computePerformance(p *portfolio.Portfolio, closes map[string][]EOD, benchmark string, benchmarkCloses []EOD) (*Performance, error) {
+	if len(p.Transactions) == 0 {
+		return nil, fmt.Errorf("portfolio has no transactions")
+	}
+	first := p.Transactions[0].Date
+
+	dateSet := make(map[string]bool)
+	for _, history := range closes {
+		for _, record := range history {
+			if record.Date >= first && record.Close > 0 {
+				dateSet[record.Date] = true
+			}
+		}
+	}
+	if len(dateSet) == 0 {
+		return nil, fmt.Errorf("no stored closes since the first transaction on %s", first)
+	}
+	dates := make([]string, 0, len(dateSet))
+	for date := range dateSet {
+		dates = append(dates, date)
+	}
+	sort.Strings(dates)
+
+	held := make(map[string]float64)
+	prices := make(map[string]float64)
+	cost := make(map[string]float64)
+	contribution := make(map[string]float64)
+	benchmarkStart := closeOn(benchmarkCloses, dates[0])
+
+	perf := &Performance{
+		From:      dates[0],
+		To:        dates[len(dates)-1],
+		Benchmark: benchmark,
+		Series:    make([]PerformancePoint, 0, len(dates)),
+	}
+	growth, previous, next := 1.0, 0.0, 0
+	for _, date := range dates {
+		opening := make(map[string]float64, len(held))
+		for symbol, quantity := range held {
+			opening[symbol] = quantity * prices[symbol]
+		}
+
+		flows := make(map[string]float64)
+		for ; next < len(p.Transactions) && p.Transactions[next].Date <= date; next++ {
+			tx := p.Transactions[next]
+			amount := tx.Quantity * tx.Price
+			if tx.Type == portfolio.Sell {
+				held[tx.Symbol] -= tx.Quantity
+				flows[tx.Symbol] -= amount - tx.Fees
+			} else {
+				held[tx.Symbol] += tx.Quantity
+				flows[tx.Symbol] += amount + tx.Fees
+			}
+			if prices[tx.Symbol] == 0 {
+				prices[tx.Symbol] = tx.Price
+			}
+		}
+		for symbol := range held {
+			if price := closeOn(closes[symbol], date); price > 0 {
+				prices[symbol] = price
+			}
+		}
+
+		value, flow := 0.0, 0.0
+		for symbol, quantity := range held {
+			value += quantity * prices[symbol]
+		}
+		for symbol, amount := range flows {
+			flow += amount
+			cost[symbol] += amount
+		}
+
+		// A day's trades don't earn on the prior value, but anything bought
+		// on the first day earns from its trade price to the close
+		base := previous
+		if base <= 0 {
+			base = flow
+		}
+		if base > 0 {
+			growth *= 1 + (value-previous-flow)/base
+			for symbol, quantity := range held {
+				gain := quantity*prices[symbol] - opening[symbol] - flows[symbol]
+				contribution[symbol] += gain / base
+			}
+		}
+		previous = value
+
+		point := PerformancePoint{Date: date, Value: round2(value), Return: round2((growth - 1) * 100)}
+		if benchmarkStart > 0 {
+			point.Benchmark = round2((closeOn(benchmarkCloses, date)/benchmarkStart - 1) * 100)
+		}
+		perf.Series = append(perf.Series, point)
+	}
+
+	last := perf.Series[len(perf.Series)-1]
+	perf.Value = last.Value
+	perf.TimeWeightedReturn = last.Return
+	perf.BenchmarkReturn = last.Benchmark
+	perf.ExcessReturn = round2(last.Return - last.Benchmark)
+
+	for _, symbol := range p.Symbols() {
+		marketValue := held[symbol] * prices[symbol]
+		perf.Positions = append(perf.Positions, PositionContribution{
+			Symbol:       symbol,
+			Quantity:     held[symbol],
+			MarketValue:  round2(marketValue),
+			PnL:          round2(marketValue - cost[symbol]),
+			Contribution: round2(contribution[symbol] * 100),
+		})
+	}
+	sort.SliceStable(perf.Positions, func(i, j int) bool {
+		return perf.Positions[i].Contribution > perf.Positions[j].Contribution
+	})
+	return perf, nil
+}
+
+// portfolioCloses reads the stored closes of every symbol p has traded.
+func (s *StockScraper) portfolioCloses(p *portfolio.Portfolio) (map[string][]EOD, error) {
+	closes := make(map[string][]EOD)
+	for _, symbol := range p.Symbols() {
+		history, err := s.EODHistory(symbol, "", "")
+		if err != nil {
+			return nil, err
+		}
+		closes[symbol] = history
+	}
+	return closes, nil
+}
+
+// PortfolioPerformance measures p against benchmark from stored closes.
+func (s *StockScraper) PortfolioPerformance(p *portfolio.Portfolio, benchmark string) (*Performance, error) {
+	closes, err := s.portfolioCloses(p)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read stored closes: %v", err)
+	}
+	benchmarkCloses, err := s.EODHistory(benchmark, "", "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read stored closes: %v", err)
+	}
+	if len(benchmarkCloses) == 0 {
+		return nil, fmt.Errorf("no stored closes for benchmark %s", benchmark)
+	}
+	return computePerformance(p, closes, benchmark, benchmarkCloses)
+}
+
+// HandlePortfolioPerformance serves the time-weighted return of the
+// caller's portfolio against ?benchmark=, from stored end-of-day closes.
+func HandlePortfolioPerformance(store *portfolio.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		p := portfolio.Owned(c, store)
+		if p == nil {
+			return
+		}
+
+		benchmark := strings.ToUpper(c.DefaultQuery("benchmark", DefaultBenchmark))
+		if !ValidSymbol(benchmark) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "invalid benchmark",
+			})
+			return
+		}
+
+		region, err := LookupRegion(c.Query("region"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		scraper := NewStockScraper(StockScraperOption{
+			Region:  region.Code,
+			Context: c.Request.Context(),
+		})
+		defer scraper.Close()
+
+		closes, err := scraper.portfolioCloses(p)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": fmt.Sprintf("failed to read stored closes: %v", err),
+			})
+			return
+		}
+		benchmarkCloses, err := scraper.EODHistory(benchmark, "", "")
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": fmt.Sprintf("failed to read stored closes: %v", err),
+			})
+			return
+		}
+		if len(benchmarkCloses) == 0 {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": fmt.Sprintf("no stored closes for benchmark %s", benchmark),
+			})
+			return
+		}
+
+		perf, err := computePerformance(p, closes, benchmark, benchmarkCloses)
+		if err != nil {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"status":    "success",
+			"portfolio": p.ID,
+			"data":      perf,
+		})
+	}
+}
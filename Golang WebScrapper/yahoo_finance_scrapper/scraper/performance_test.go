@@ -0,0 +1,47 @@
+package scraper
+
+import (
+	"testing"
+
+	"go-webscraper/portfolio"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestComputePerformance(t *testing.T) {
+	p := &portfolio.Portfolio{}
+	assert.NoError(t, p.Add(
+		portfolio.Transaction{Symbol: "AAPL", Type: portfolio.Buy, Quantity: 10, Price: 100, Date: "2024-07-01"},
+		// Doubling the position mid-way is a deposit, not a gain
+		portfolio.Transaction{Symbol: "AAPL", Type: portfolio.Buy, Quantity: 10, Price: 110, Date: "2024-07-02"},
+		portfolio.Transaction{Symbol: "MSFT", Type: portfolio.Buy, Quantity: 5, Price: 400, Date: "2024-07-02"},
+	))
+	closes := map[string][]EOD{
+		"AAPL": {{Date: "2024-07-01", Close: 100}, {Date: "2024-07-02", Close: 110}, {Date: "2024-07-03", Close: 121}},
+		"MSFT": {{Date: "2024-07-02", Close: 400}, {Date: "2024-07-03", Close: 380}},
+	}
+	benchmark := []EOD{{Date: "2024-06-28", Close: 5000}, {Date: "2024-07-03", Close: 5100}}
+
+	perf, err := computePerformance(p, closes, "^GSPC", benchmark)
+	assert.NoError(t, err)
+	assert.Equal(t, "2024-07-01", perf.From)
+	assert.Equal(t, "2024-07-03", perf.To)
+	assert.Len(t, perf.Series, 3)
+	assert.Equal(t, 4320.0, perf.Value)
+
+	// Day 2: +10% on 1000 before the deposit. Day 3: 4320 / 4200 = +2.857%
+	assert.Equal(t, 13.14, perf.TimeWeightedReturn)
+	assert.Equal(t, 2.0, perf.BenchmarkReturn)
+	assert.Equal(t, 11.14, perf.ExcessReturn)
+
+	assert.Equal(t, "AAPL", perf.Positions[0].Symbol)
+	assert.Equal(t, 20.0, perf.Positions[0].Quantity)
+	assert.Equal(t, 320.0, perf.Positions[0].PnL)
+	assert.Equal(t, 15.24, perf.Positions[0].Contribution)
+	assert.Equal(t, "MSFT", perf.Positions[1].Symbol)
+	assert.Equal(t, -100.0, perf.Positions[1].PnL)
+	assert.Equal(t, -2.38, perf.Positions[1].Contribution)
+
+	_, err = computePerformance(&portfolio.Portfolio{}, closes, "^GSPC", benchmark)
+	assert.Error(t, err)
+}
@@ -0,0 +1,77 @@
+package scraper
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"go-webscraper/cache"
+	"go-webscraper/cachekey"
+	"go-webscraper/mode"
+	"go-webscraper/queue"
+
+	"github.com/gin-gonic/gin"
+)
+
+// acquire takes a slot in the shared scrape pool before visiting Yahoo.
+// Scrapers built without a pool run unbounded. No scrape starts while the
+// server is read-only, or for a request shed by queue.Inflight.
+func acquire(ctx context.Context, pool *queue.Pool, priority queue.Priority) (func(), error) {
+	if err := mode.Scraping(); err != nil {
+		return nil, err
+	}
+	if err := queue.Enter(ctx); err != nil {
+		return nil, err
+	}
+	if pool == nil {
+		return func() {}, nil
+	}
+	if err := pool.Acquire(ctx, priority); err != nil {
+		return nil, err
+	}
+	return pool.Release, nil
+}
+
+// shed answers with 503 and Retry-After when err means the scrape pool,
+// job queue or in-flight limit turned the request away. It returns true if
+// it responded.
+func shed(c *gin.Context, pool *queue.Pool, err error) bool {
+	if errors.Is(err, mode.ErrReadOnly) {
+		mode.Unavailable(c, err.Error())
+		return true
+	}
+	if !errors.Is(err, queue.ErrSaturated) && !errors.Is(err, queue.ErrOverloaded) {
+		return false
+	}
+
+	retryAfter := 5 * time.Second
+	if pool != nil {
+		retryAfter = pool.RetryAfter()
+	}
+	queue.Unavailable(c, retryAfter)
+	return true
+}
+
+// serveStale decodes the last copy of key kept past its TTL into out when
+// err means the request was shed for having too many scrapes in flight, so
+// it is answered late rather than not at all. It reports whether it did.
+func serveStale(ctx context.Context, store cache.Cache, key string, out interface{}, err error) bool {
+	if !errors.Is(err, queue.ErrOverloaded) {
+		return false
+	}
+
+	data, getErr := store.Get(ctx, cachekey.StaleCopy(key))
+	if getErr != nil {
+		var record conditionalRecord
+		if data, getErr := store.Get(ctx, cachekey.Validators(key)); getErr != nil || json.Unmarshal(data, &record) != nil || len(record.Data) == 0 {
+			return false
+		}
+		data = record.Data
+	}
+	if json.Unmarshal(data, out) != nil {
+		return false
+	}
+	queue.ServedStale(ctx)
+	return true
+}
@@ -0,0 +1,133 @@
+package scraper
+
+import (
+	"context"
+	"encoding/json"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"time"
+
+	"go-webscraper/cache"
+	"go-webscraper/cachekey"
+	"go-webscraper/pkg/yahoo"
+
+	"github.com/gin-gonic/gin"
+)
+
+// BuildVersion is stamped at build time with
+// -ldflags "-X go-webscraper/scraper.BuildVersion=<version>". Unstamped
+// builds report the VCS revision Go embedded, or "dev".
+var BuildVersion string
+
+var resolveBuildVersion = sync.OnceValue(func() string {
+	if BuildVersion != "" {
+		return BuildVersion
+	}
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range info.Settings {
+			if setting.Key == "vcs.revision" && len(setting.Value) >= 12 {
+				return setting.Value[:12]
+			}
+		}
+	}
+	return "dev"
+})
+
+// Version is the running build's version, as BuildVersion describes.
+func Version() string {
+	return resolveBuildVersion()
+}
+
+// ProvenanceSource is one page a cached blob was parsed from.
+type ProvenanceSource struct {
+	URL        string `json:"url"`
+	Status     int    `json:"status"`
+	DurationMs int64  `json:"duration_ms"`
+}
+
+// Provenance describes how a cached blob was produced, so bad data can be
+// traced to the pages, selectors and build behind it.
+type Provenance struct {
+	Sources         []ProvenanceSource `json:"sources"`
+	SelectorVersion string             `json:"selector_version"`
+	BuildVersion    string             `json:"build_version"`
+	FetchDurationMs int64              `json:"fetch_duration_ms"`
+	FetchedAt       time.Time          `json:"fetched_at"`
+}
+
+// newProvenance summarizes fetches. The fetch duration is wall time from
+// the first request starting to the last one finishing, since pages are
+// fetched in parallel.
+func newProvenance(fetches []yahoo.Fetch) Provenance {
+	provenance := Provenance{
+		Sources:         make([]ProvenanceSource, 0, len(fetches)),
+		SelectorVersion: yahoo.SelectorVersion(),
+		BuildVersion:    resolveBuildVersion(),
+	}
+
+	var start time.Time
+	for _, fetch := range fetches {
+		provenance.Sources = append(provenance.Sources, ProvenanceSource{
+			URL:        fetch.URL,
+			Status:     fetch.Status,
+			DurationMs: fetch.Duration.Milliseconds(),
+		})
+		if began := fetch.At.Add(-fetch.Duration); start.IsZero() || began.Before(start) {
+			start = began
+		}
+		if fetch.At.After(provenance.FetchedAt) {
+			provenance.FetchedAt = fetch.At
+		}
+	}
+	provenance.FetchDurationMs = provenance.FetchedAt.Sub(start).Milliseconds()
+	return provenance
+}
+
+// saveProvenance stores the fetches logged in ctx next to key, expiring
+// with it. Writes made outside a logged scrape leave the last record alone.
+func saveProvenance(ctx context.Context, store cache.Cache, key string, ttl time.Duration) {
+	log := yahoo.FetchLogFrom(ctx)
+	if log == nil {
+		return
+	}
+	fetches := log.Fetches()
+	if len(fetches) == 0 {
+		return
+	}
+
+	if data, err := json.Marshal(newProvenance(fetches)); err == nil {
+		store.Set(ctx, cachekey.Provenance(key), data, ttl)
+	}
+}
+
+func loadProvenance(ctx context.Context, store cache.Cache, key string) *Provenance {
+	data, err := store.Get(ctx, cachekey.Provenance(key))
+	if err != nil {
+		return nil
+	}
+	var provenance Provenance
+	if err := json.Unmarshal(data, &provenance); err != nil {
+		return nil
+	}
+	return &provenance
+}
+
+// wantsProvenance reports whether ?include= lists provenance.
+func wantsProvenance(c *gin.Context) bool {
+	for _, include := range strings.Split(c.Query("include"), ",") {
+		if strings.TrimSpace(include) == "provenance" {
+			return true
+		}
+	}
+	return false
+}
+
+// withProvenance adds the provenance of key to response when the request
+// asked for it.
+func withProvenance(c *gin.Context, store cache.Cache, key string, response gin.H) gin.H {
+	if wantsProvenance(c) {
+		response["provenance"] = loadProvenance(c.Request.Context(), store, key)
+	}
+	return response
+}
@@ -0,0 +1,42 @@
+package scraper
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go-webscraper/pkg/yahoo"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewProvenance(t *testing.T) {
+	at := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	provenance := newProvenance([]yahoo.Fetch{
+		{URL: "https://finance.yahoo.com/quote/AAPL/", Status: 200, Duration: 300 * time.Millisecond, At: at},
+		{URL: "https://finance.yahoo.com/quote/MSFT/", Status: 404, Duration: 200 * time.Millisecond, At: at.Add(400 * time.Millisecond)},
+	})
+
+	assert.Len(t, provenance.Sources, 2)
+	assert.Equal(t, 404, provenance.Sources[1].Status)
+	assert.Equal(t, int64(300), provenance.Sources[0].DurationMs)
+	assert.Equal(t, int64(700), provenance.FetchDurationMs)
+	assert.Equal(t, at.Add(400*time.Millisecond), provenance.FetchedAt)
+	assert.Equal(t, yahoo.SelectorVersion(), provenance.SelectorVersion)
+	assert.NotEmpty(t, provenance.BuildVersion)
+}
+
+func TestWantsProvenance(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	for query, want := range map[string]bool{
+		"":                             false,
+		"?include=provenance":          true,
+		"?include=history,+provenance": true,
+		"?include=provenances":         false,
+	} {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest("GET", "/api/stock"+query, nil)
+		assert.Equal(t, want, wantsProvenance(c), query)
+	}
+}
@@ -0,0 +1,201 @@
+package scraper
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"go-webscraper/pkg/parse"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	sourcesKey     = "quality:sources"
+	lastSuccessKey = "quality:last_success"
+	qualityWindow  = 24 * time.Hour
+)
+
+// qualityFields are the StockData fields fill rates are reported for.
+var qualityFields = []string{"symbol", "name", "price", "change", "change_percentage", "volume", "market_cap"}
+
+// scrapeRecord is the metadata persisted for one scrape of a source.
+// ParseErrors counts rows missing a symbol or price, which are unusable.
+type scrapeRecord struct {
+	At          int64          `json:"at"`
+	Rows        int            `json:"rows"`
+	Filled      map[string]int `json:"filled"`
+	ParseErrors int            `json:"parse_errors"`
+}
+
+// SourceQuality summarises a source's scrapes over the report window.
+type SourceQuality struct {
+	Source      string             `json:"source"`
+	Scrapes     int                `json:"scrapes"`
+	Rows        int                `json:"rows"`
+	FillRates   map[string]float64 `json:"fill_rates"`
+	ParseErrors int                `json:"parse_errors"`
+	Quarantined int                `json:"quarantined"`
+	LastSuccess string             `json:"last_success,omitempty"`
+}
+
+func scrapesKey(source string) string {
+	return fmt.Sprintf("quality:scrapes:%s", source)
+}
+
+func newScrapeRecord(stocks []StockData, now time.Time) scrapeRecord {
+	record := scrapeRecord{At: now.Unix(), Rows: len(stocks), Filled: make(map[string]int)}
+	for _, stock := range stocks {
+		filled := map[string]bool{
+			"symbol":            stock.Symbol != "",
+			"name":              stock.Name != "",
+			"price":             stock.Price != 0,
+			"change":            stock.Change != 0,
+			"change_percentage": stock.ChangePerc != 0,
+			"volume":            stock.Volume != 0,
+			"market_cap":        stock.MarketCap != "",
+		}
+		for field, ok := range filled {
+			if ok {
+				record.Filled[field]++
+			}
+		}
+		if !filled["symbol"] || !filled["price"] {
+			record.ParseErrors++
+		}
+	}
+	return record
+}
+
+// recordScrape persists the metadata of a completed scrape for the quality
+// report. A scrape that returned rows counts as a success.
+func (s *StockScraper) recordScrape(source string, stocks []StockData) {
+	now := time.Now()
+	data, err := json.Marshal(newScrapeRecord(stocks, now))
+	if err != nil {
+		return
+	}
+
+	key := scrapesKey(source)
+	pipe := s.redis.Pipeline()
+	pipe.ZAdd(s.ctx, key, redis.Z{Score: float64(now.Unix()), Member: data})
+	pipe.ZRemRangeByScore(s.ctx, key, "-inf", fmt.Sprintf("(%d", now.Add(-qualityWindow).Unix()))
+	pipe.Expire(s.ctx, key, 2*qualityWindow)
+	pipe.SAdd(s.ctx, sourcesKey, source)
+	if len(stocks) > 0 {
+		pipe.HSet(s.ctx, lastSuccessKey, source, now.Unix())
+	}
+	pipe.Exec(s.ctx)
+}
+
+// summarize folds a source's scrape records and quarantine count into its
+// report entry.
+func summarize(source string, records []scrapeRecord, quarantined int, lastSuccess int64) SourceQuality {
+	quality := SourceQuality{
+		Source:      source,
+		Scrapes:     len(records),
+		FillRates:   make(map[string]float64, len(qualityFields)),
+		Quarantined: quarantined,
+	}
+	filled := make(map[string]int)
+	for _, record := range records {
+		quality.Rows += record.Rows
+		quality.ParseErrors += record.ParseErrors
+		for field, n := range record.Filled {
+			filled[field] += n
+		}
+	}
+	for _, field := range qualityFields {
+		if quality.Rows > 0 {
+			quality.FillRates[field] = float64(filled[field]) / float64(quality.Rows)
+		}
+	}
+	if lastSuccess > 0 {
+		quality.LastSuccess = time.Unix(lastSuccess, 0).Format(time.RFC3339)
+	}
+	return quality
+}
+
+// QualityReport summarises every source seen in the last 24 hours.
+func (s *StockScraper) QualityReport() ([]SourceQuality, error) {
+	since := strconv.FormatInt(time.Now().Add(-qualityWindow).Unix(), 10)
+
+	lastSuccess, err := s.redis.HGetAll(s.ctx, lastSuccessKey).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	quarantined := make(map[string]int)
+	entries, err := s.redis.ZRangeByScore(s.ctx, quarantineKey, &redis.ZRangeBy{Min: since, Max: "+inf"}).Result()
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		var q Quarantine
+		if json.Unmarshal([]byte(entry), &q) == nil {
+			quarantined[q.Source]++
+		}
+	}
+
+	known, err := s.redis.SMembers(s.ctx, sourcesKey).Result()
+	if err != nil {
+		return nil, err
+	}
+	sources := make(map[string]bool)
+	for _, source := range known {
+		sources[source] = true
+	}
+	for source := range quarantined {
+		sources[source] = true
+	}
+
+	report := make([]SourceQuality, 0, len(sources))
+	for source := range sources {
+		members, err := s.redis.ZRangeByScore(s.ctx, scrapesKey(source), &redis.ZRangeBy{Min: since, Max: "+inf"}).Result()
+		if err != nil {
+			return nil, err
+		}
+		records := make([]scrapeRecord, 0, len(members))
+		for _, member := range members {
+			var record scrapeRecord
+			if json.Unmarshal([]byte(member), &record) == nil {
+				records = append(records, record)
+			}
+		}
+		last, _ := strconv.ParseInt(lastSuccess[source], 10, 64)
+		report = append(report, summarize(source, records, quarantined[source], last))
+	}
+	sort.Slice(report, func(i, j int) bool {
+		return report[i].Source < report[j].Source
+	})
+	return report, nil
+}
+
+func HandleQualityReport() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		scraper := NewStockScraper(StockScraperOption{
+			Context: c.Request.Context(),
+		})
+		defer scraper.Close()
+
+		report, err := scraper.QualityReport()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		// Parse failures are counted in process, so they cover this
+		// instance since it started rather than the report window
+		c.JSON(http.StatusOK, gin.H{
+			"status":         "success",
+			"data":           report,
+			"parse_failures": parse.Failures(),
+		})
+	}
+}
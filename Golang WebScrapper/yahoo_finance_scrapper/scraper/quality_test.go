@@ -0,0 +1,37 @@
+package scraper
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScrapeRecordAndSummarize(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	first := newScrapeRecord([]StockData{
+		{Symbol: "AAPL", Name: "Apple Inc.", Price: 190, Change: 1, ChangePerc: 0.5, Volume: 100, MarketCap: "2.9T"},
+		{Symbol: "MSFT", Name: "Microsoft", Price: 410},
+		{Name: "Unparsed"},
+	}, now)
+	assert.Equal(t, 3, first.Rows)
+	assert.Equal(t, 1, first.ParseErrors)
+	assert.Equal(t, 2, first.Filled["price"])
+	assert.Equal(t, 3, first.Filled["name"])
+
+	second := newScrapeRecord([]StockData{{Symbol: "AAPL", Price: 191, Volume: 50}}, now)
+
+	quality := summarize("most_active", []scrapeRecord{first, second}, 2, now.Unix())
+	assert.Equal(t, 2, quality.Scrapes)
+	assert.Equal(t, 4, quality.Rows)
+	assert.Equal(t, 1, quality.ParseErrors)
+	assert.Equal(t, 2, quality.Quarantined)
+	assert.Equal(t, 0.75, quality.FillRates["price"])
+	assert.Equal(t, 0.5, quality.FillRates["volume"])
+	assert.Equal(t, 0.25, quality.FillRates["market_cap"])
+	assert.Equal(t, now.Format(time.RFC3339), quality.LastSuccess)
+
+	empty := summarize("indices", nil, 0, 0)
+	assert.Equal(t, 0.0, empty.FillRates["price"])
+	assert.Empty(t, empty.LastSuccess)
+}
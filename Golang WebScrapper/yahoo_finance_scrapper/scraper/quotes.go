@@ -0,0 +1,240 @@
+package scraper
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"go-webscraper/cachekey"
+	"go-webscraper/pkg/yahoo"
+	"go-webscraper/queue"
+
+	"github.com/gin-gonic/gin"
+)
+
+// quoteBatchSize bounds how many quote pages share one pool slot, so a
+// large universe refresh doesn't starve interactive requests.
+const quoteBatchSize = 50
+
+const maxQuoteSymbols = 100
+
+// ScrapeQuotes returns symbols' freshly scraped quotes in order. A symbol
+// another caller scraped, or started scraping, within the coalescing
+// window shares that scrape instead of fetching its page again.
+func (s *StockScraper) ScrapeQuotes(symbols []string) ([]StockData, error) {
+	keys := make([]string, len(symbols))
+	for i, symbol := range symbols {
+		keys[i] = s.region.CacheKey(cachekey.Quote.Key(symbol))
+	}
+	flights, owned := quoteFlights.claim(keys, time.Now())
+
+	mine := make([]string, 0, len(owned))
+	for i, symbol := range symbols {
+		if owned[keys[i]] {
+			mine = append(mine, symbol)
+		}
+	}
+	var fetched []StockData
+	var fetchErr error
+	if len(mine) > 0 {
+		fetched, fetchErr = s.fetchQuotes(mine)
+	}
+	bySymbol := make(map[string]StockData, len(fetched))
+	for _, stock := range fetched {
+		bySymbol[stock.Symbol] = stock
+	}
+	// Land our flights before waiting on anyone else's, so two callers
+	// sharing each other's symbols can't wait on each other
+	for i, symbol := range symbols {
+		if !owned[keys[i]] {
+			continue
+		}
+		delete(owned, keys[i])
+		if stock, ok := bySymbol[symbol]; ok {
+			quoteFlights.land(keys[i], flights[keys[i]], &stock, nil)
+		} else {
+			quoteFlights.land(keys[i], flights[keys[i]], nil, fetchErr)
+		}
+	}
+
+	stocks := make([]StockData, 0, len(symbols))
+	for i := range symbols {
+		quote, err := flights[keys[i]].wait(s.ctx)
+		if err != nil {
+			return stocks, err
+		}
+		if quote != nil {
+			stocks = append(stocks, *quote)
+		}
+	}
+	return stocks, nil
+}
+
+// fetchQuotes fetches symbols' quote pages in batches, caching each quote
+// and appending it to the symbol's intraday series.
+func (s *StockScraper) fetchQuotes(symbols []string) ([]StockData, error) {
+	stocks := make([]StockData, 0, len(symbols))
+	for start := 0; start < len(symbols); start += quoteBatchSize {
+		batch := symbols[start:min(start+quoteBatchSize, len(symbols))]
+
+		release, err := acquire(s.ctx, s.pool, s.priority)
+		if err != nil {
+			return stocks, err
+		}
+		ctx := yahoo.WithFetchLog(s.ctx)
+		quotes, err := s.yahoo.Quotes(ctx, batch)
+		release()
+		if err != nil {
+			return stocks, err
+		}
+
+		fetched := make([]StockData, 0, len(quotes))
+		for _, quote := range quotes {
+			if quote.Price == 0 {
+				continue
+			}
+			stock := StockData{
+				Symbol:     quote.Symbol,
+				Name:       quote.Name,
+				Price:      quote.Price,
+				Change:     quote.Change,
+				ChangePerc: quote.ChangePerc,
+				Volume:     quote.Volume,
+				MarketCap:  quote.MarketCap,
+				Currency:   quote.Currency,
+				Timestamp:  time.Now().Format(time.RFC3339),
+			}
+			fetched = append(fetched, stock)
+		}
+		s.screenAnomalies("quote", fetched)
+		s.recordScrape("quote", fetched)
+		for _, stock := range fetched {
+			cacheIfChanged(ctx, s.cache, s.tracker, s.region.CacheKey(cachekey.Quote.Key(stock.Symbol)), stock, s.ttl)
+		}
+		recordIntraday(s.ctx, s.redis, fetched)
+		stocks = append(stocks, fetched...)
+	}
+	return stocks, nil
+}
+
+// parseSymbolList reads a comma-separated ?symbols= list, keeping the
+// caller's order.
+func parseSymbolList(raw string) ([]string, error) {
+	symbols := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, symbol := range strings.Split(raw, ",") {
+		symbol = strings.ToUpper(strings.TrimSpace(symbol))
+		if symbol == "" || seen[symbol] {
+			continue
+		}
+		if !ValidSymbol(symbol) {
+			return nil, fmt.Errorf("invalid symbol: %s", symbol)
+		}
+		seen[symbol] = true
+		symbols = append(symbols, symbol)
+	}
+	if len(symbols) == 0 {
+		return nil, fmt.Errorf("symbols is required")
+	}
+	if len(symbols) > maxQuoteSymbols {
+		return nil, fmt.Errorf("at most %d symbols per request", maxQuoteSymbols)
+	}
+	return symbols, nil
+}
+
+// CachedQuotes returns the symbols' quotes in the order asked for, taking
+// cached ones as they are and scraping only the rest. Symbols Yahoo has no
+// quote for are left out.
+func (s *StockScraper) CachedQuotes(symbols []string) ([]StockData, error) {
+	found := make(map[string]StockData, len(symbols))
+	missing := make([]string, 0)
+	for _, symbol := range symbols {
+		cached, err := s.cache.Get(s.ctx, s.region.CacheKey(cachekey.Quote.Key(symbol)))
+		if err == nil && !s.fresh {
+			var quote StockData
+			if err := json.Unmarshal(cached, &quote); err == nil {
+				found[symbol] = quote
+				continue
+			}
+		}
+		missing = append(missing, symbol)
+	}
+
+	if len(missing) > 0 {
+		scraped, err := s.ScrapeQuotes(missing)
+		if err != nil {
+			return nil, err
+		}
+		for _, quote := range scraped {
+			found[quote.Symbol] = quote
+		}
+	}
+
+	quotes := make([]StockData, 0, len(found))
+	for _, symbol := range symbols {
+		if quote, ok := found[symbol]; ok {
+			quotes = append(quotes, quote)
+		}
+	}
+	return quotes, nil
+}
+
+func HandleQuotes(pool *queue.Pool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		symbols, err := parseSymbolList(c.Query("symbols"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		region, err := LookupRegion(c.Query("region"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		scraper := NewStockScraper(StockScraperOption{
+			Region:  region.Code,
+			Context: c.Request.Context(),
+			Pool:    pool,
+		})
+		defer scraper.Close()
+
+		quotes, err := scraper.CachedQuotes(symbols)
+		if shed(c, pool, err) {
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		keys := make([]string, len(quotes))
+		for i, quote := range quotes {
+			keys[i] = region.CacheKey(cachekey.Quote.Key(quote.Symbol))
+		}
+		setCacheControl(c, scraper.cache, keys...)
+
+		response := gin.H{
+			"status": "success",
+			"region": region.Code,
+			"data":   quotes,
+		}
+		if wantsProvenance(c) {
+			provenance := make(map[string]*Provenance, len(quotes))
+			for i, quote := range quotes {
+				provenance[quote.Symbol] = loadProvenance(c.Request.Context(), scraper.cache, keys[i])
+			}
+			response["provenance"] = provenance
+		}
+		c.JSON(http.StatusOK, response)
+	}
+}
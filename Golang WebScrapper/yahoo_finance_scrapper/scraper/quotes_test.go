@@ -0,0 +1,18 @@
+package scraper
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSymbolList(t *testing.T) {
+	symbols, err := parseSymbolList(" aapl,MSFT,,aapl, brk-b ")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"AAPL", "MSFT", "BRK-B"}, symbols)
+
+	_, err = parseSymbolList("")
+	assert.Error(t, err)
+	_, err = parseSymbolList("AAPL,<script>")
+	assert.Error(t, err)
+}
@@ -0,0 +1,42 @@
+package scraper
+
+import (
+	"sync"
+
+	"github.com/redis/go-redis/v9"
+)
+
+var redisServer = struct {
+	mu       sync.RWMutex
+	addr     string
+	password string
+	db       int
+}{addr: "localhost:6379"}
+
+// ConfigureRedis sets the Redis server scrapers connect to when their
+// option leaves RedisAddr empty, so scrapers made by handlers and
+// scheduled jobs share the configured server and credentials.
+func ConfigureRedis(addr, password string, db int) {
+	redisServer.mu.Lock()
+	defer redisServer.mu.Unlock()
+	if addr != "" {
+		redisServer.addr = addr
+	}
+	redisServer.password = password
+	redisServer.db = db
+}
+
+// newRedis connects to addr, or to the configured server when addr is
+// empty.
+func newRedis(addr, password string, db int) *redis.Client {
+	if addr == "" {
+		redisServer.mu.RLock()
+		addr, password, db = redisServer.addr, redisServer.password, redisServer.db
+		redisServer.mu.RUnlock()
+	}
+	return redis.NewClient(&redis.Options{
+		Addr:     addr,
+		Password: password,
+		DB:       db,
+	})
+}
@@ -0,0 +1,13 @@
+package scraper
+
+import "go-webscraper/pkg/yahoo"
+
+type Region = yahoo.Region
+
+const DefaultRegion = yahoo.DefaultRegion
+
+var Regions = yahoo.Regions
+
+func LookupRegion(code string) (Region, error) {
+	return yahoo.LookupRegion(code)
+}
@@ -0,0 +1,263 @@
+package scraper
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+
+	"go-webscraper/watchlist"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DefaultReturnPeriods are computed when ?period= isn't given.
+const DefaultReturnPeriods = "1m,3m,ytd,1y"
+
+// returnPeriods are how far back from the latest close each period
+// starts, in years, months and days. ytd and max are handled apart.
+var returnPeriods = map[string][3]int{
+	"1w": {0, 0, 7},
+	"1m": {0, 1, 0},
+	"3m": {0, 3, 0},
+	"6m": {0, 6, 0},
+	"1y": {1, 0, 0},
+	"3y": {3, 0, 0},
+	"5y": {5, 0, 0},
+}
+
+// Return is a symbol's performance over one period, measured between the
+// stored closes at its ends. Returns are in percent; AnnualizedReturn
+// compounds TotalReturn to a 365-day year.
+type Return struct {
+	Period           string  `json:"period"`
+	From             string  `json:"from"`
+	To               string  `json:"to"`
+	StartClose       float64 `json:"start_close"`
+	EndClose         float64 `json:"end_close"`
+	Dividends        float64 `json:"dividends,omitempty"`
+	TotalReturn      float64 `json:"total_return"`
+	AnnualizedReturn float64 `json:"annualized_return"`
+}
+
+// SymbolReturns holds a symbol's returns for every period its stored
+// closes reach back far enough for, listing the rest as insufficient.
+type SymbolReturns struct {
+	Symbol              string   `json:"symbol"`
+	AsOf                string   `json:"as_of,omitempty"`
+	Returns             []Return `json:"returns"`
+	InsufficientHistory []string `json:"insufficient_history,omitempty"`
+}
+
+// parseReturnPeriods reads a comma-separated ?period= list.
+func parseReturnPeriods(param string) ([]string, error) {
+	if param == "" {
+		param = DefaultReturnPeriods
+	}
+	periods := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, period := range strings.Split(param, ",") {
+		period = strings.ToLower(strings.TrimSpace(period))
+		if period == "" || seen[period] {
+			continue
+		}
+		if _, ok := returnPeriods[period]; !ok && period != "ytd" && period != "max" {
+			return nil, fmt.Errorf("unknown period: %s (use 1w, 1m, 3m, 6m, ytd, 1y, 3y, 5y or max)", period)
+		}
+		seen[period] = true
+		periods = append(periods, period)
+	}
+	return periods, nil
+}
+
+// baseIndex finds the close a period is measured from: the last one on or
+// before its start. It returns -1 when history doesn't reach that far.
+func baseIndex(history []EOD, period string, end time.Time) int {
+	if period == "max" {
+		return 0
+	}
+
+	var start time.Time
+	if period == "ytd" {
+		// The base is the previous year's final close
+		start = time.Date(end.Year(), 1, 1, 0, 0, 0, 0, time.UTC).AddDate(0, 0, -1)
+	} else {
+		back := returnPeriods[period]
+		start = end.AddDate(-back[0], -back[1], -back[2])
+	}
+
+	date := start.Format("2006-01-02")
+	base := -1
+	for i, record := range history {
+		if record.Date > date {
+			break
+		}
+		base = i
+	}
+	return base
+}
+
+// computeReturns measures each period over history, which must be sorted
+// oldest first. When adjusted, each dividend is reinvested at the close of
+// its ex-date, or the next stored close if that day is missing.
+func computeReturns(symbol string, history []EOD, dividends map[string]float64, periods []string, adjusted bool) SymbolReturns {
+	result := SymbolReturns{Symbol: symbol, Returns: make([]Return, 0, len(periods))}
+
+	closes := make([]EOD, 0, len(history))
+	for _, record := range history {
+		if record.Close > 0 {
+			closes = append(closes, record)
+		}
+	}
+	if len(closes) == 0 {
+		result.InsufficientHistory = periods
+		return result
+	}
+
+	last := len(closes) - 1
+	result.AsOf = closes[last].Date
+	end, _ := time.Parse("2006-01-02", closes[last].Date)
+
+	for _, period := range periods {
+		base := baseIndex(closes, period, end)
+		if base < 0 || base == last {
+			result.InsufficientHistory = append(result.InsufficientHistory, period)
+			continue
+		}
+
+		growth := closes[last].Close / closes[base].Close
+		paid := 0.0
+		if adjusted {
+			growth = 1
+			for i := base + 1; i <= last; i++ {
+				dividend := 0.0
+				for date, amount := range dividends {
+					if date > closes[i-1].Date && date <= closes[i].Date {
+						dividend += amount
+					}
+				}
+				paid += dividend
+				growth *= (closes[i].Close + dividend) / closes[i-1].Close
+			}
+		}
+
+		start, _ := time.Parse("2006-01-02", closes[base].Date)
+		days := end.Sub(start).Hours() / 24
+		result.Returns = append(result.Returns, Return{
+			Period:           period,
+			From:             closes[base].Date,
+			To:               closes[last].Date,
+			StartClose:       closes[base].Close,
+			EndClose:         closes[last].Close,
+			Dividends:        round2(paid),
+			TotalReturn:      round2((growth - 1) * 100),
+			AnnualizedReturn: round2((math.Pow(growth, 365/days) - 1) * 100),
+		})
+	}
+	return result
+}
+
+// Returns computes each symbol's returns from its stored end-of-day
+// closes, in the order given.
+func (s *StockScraper) Returns(symbols, periods []string, adjusted bool) ([]SymbolReturns, error) {
+	results := make([]SymbolReturns, 0, len(symbols))
+	for _, symbol := range symbols {
+		history, err := s.EODHistory(symbol, "", "")
+		if err != nil {
+			return nil, err
+		}
+		var dividends map[string]float64
+		if adjusted {
+			if dividends, err = s.exDividends(symbol); err != nil {
+				return nil, err
+			}
+		}
+		results = append(results, computeReturns(symbol, history, dividends, periods, adjusted))
+	}
+	return results, nil
+}
+
+// respondReturns computes and writes the returns of symbols for the
+// ?period=, ?adjusted= and ?region= parameters.
+func respondReturns(c *gin.Context, symbols []string, extra gin.H) {
+	periods, err := parseReturnPeriods(c.Query("period"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	region, err := LookupRegion(c.Query("region"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	scraper := NewStockScraper(StockScraperOption{
+		Region:  region.Code,
+		Context: c.Request.Context(),
+	})
+	defer scraper.Close()
+
+	adjusted := c.Query("adjusted") == "true"
+	returns, err := scraper.Returns(symbols, periods, adjusted)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": fmt.Sprintf("failed to read stored closes: %v", err),
+		})
+		return
+	}
+
+	response := gin.H{
+		"status":   "success",
+		"region":   region.Code,
+		"adjusted": adjusted,
+		"data":     returns,
+	}
+	for key, value := range extra {
+		response[key] = value
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// HandleReturns serves returns for ?symbol=, or a batch in ?symbols=.
+func HandleReturns() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		raw := c.Query("symbols")
+		if raw == "" {
+			raw = c.Query("symbol")
+		}
+		symbols, err := parseSymbolList(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+		if len(symbols) == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "symbol or symbols is required",
+			})
+			return
+		}
+
+		respondReturns(c, symbols, nil)
+	}
+}
+
+// HandleWatchlistReturns serves the returns of every symbol in the
+// caller's watchlist.
+func HandleWatchlistReturns(store *watchlist.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		list := watchlist.Owned(c, store)
+		if list == nil {
+			return
+		}
+
+		respondReturns(c, list.Symbols, gin.H{"watchlist": list.ID})
+	}
+}
@@ -0,0 +1,54 @@
+package scraper
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestComputeReturns(t *testing.T) {
+	history := []EOD{
+		{Date: "2023-12-29", Close: 100},
+		{Date: "2024-03-28", Close: 110},
+		{Date: "2024-06-28", Close: 121},
+		{Date: "2024-09-30", Close: 0},
+		{Date: "2024-12-31", Close: 132},
+	}
+	dividends := map[string]float64{"2024-06-28": 11}
+
+	result := computeReturns("AAPL", history, nil, []string{"ytd", "3m", "6m", "3y"}, false)
+	assert.Equal(t, "2024-12-31", result.AsOf)
+	assert.Equal(t, []string{"3y"}, result.InsufficientHistory)
+	assert.Len(t, result.Returns, 3)
+
+	ytd := result.Returns[0]
+	assert.Equal(t, "2023-12-29", ytd.From)
+	assert.Equal(t, 32.0, ytd.TotalReturn)
+	assert.Equal(t, 31.7, ytd.AnnualizedReturn)
+
+	// The zero close on 2024-09-30 is skipped, so 3m reaches back to June
+	assert.Equal(t, "2024-06-28", result.Returns[1].From)
+	assert.Equal(t, "2024-06-28", result.Returns[2].From)
+
+	adjusted := computeReturns("AAPL", history, dividends, []string{"ytd"}, true)
+	assert.Equal(t, 11.0, adjusted.Returns[0].Dividends)
+	// 110 -> 121 + 11 is a 20% quarter instead of 10%
+	assert.Equal(t, 44.0, adjusted.Returns[0].TotalReturn)
+
+	empty := computeReturns("MSFT", nil, nil, []string{"1m"}, false)
+	assert.Empty(t, empty.Returns)
+	assert.Equal(t, []string{"1m"}, empty.InsufficientHistory)
+}
+
+func TestParseReturnPeriods(t *testing.T) {
+	periods, err := parseReturnPeriods("")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"1m", "3m", "ytd", "1y"}, periods)
+
+	periods, err = parseReturnPeriods("YTD, max,ytd")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"ytd", "max"}, periods)
+
+	_, err = parseReturnPeriods("2w")
+	assert.Error(t, err)
+}
@@ -0,0 +1,274 @@
+package scraper
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// DefaultRotationWindow is how many days of sector history a rotation
+	// analysis covers when ?window= isn't given.
+	DefaultRotationWindow = 90
+	// maxRotationWindow bounds ?window= and how long daily sector
+	// performance is kept.
+	maxRotationWindow = 365
+	// rotationMomentumDays is the lookback RS-Momentum measures the change
+	// in RS-Ratio over.
+	rotationMomentumDays = 10
+)
+
+const (
+	QuadrantLeading   = "leading"
+	QuadrantWeakening = "weakening"
+	QuadrantLagging   = "lagging"
+	QuadrantImproving = "improving"
+)
+
+// RotationPoint places a sector on a relative rotation graph for one day.
+// Both axes are centred on 100: RSRatio above 100 means the sector has
+// outperformed the average sector over the window, RSMomentum above 100
+// means that relative strength is rising.
+type RotationPoint struct {
+	Date       string  `json:"date"`
+	RSRatio    float64 `json:"rs_ratio"`
+	RSMomentum float64 `json:"rs_momentum"`
+	Rank       int     `json:"rank"`
+	Quadrant   string  `json:"quadrant"`
+}
+
+// SectorRotation is a sector's latest position plus the daily trail that
+// led to it, oldest first.
+type SectorRotation struct {
+	Sector string `json:"sector"`
+	RotationPoint
+	Trail []RotationPoint `json:"trail"`
+}
+
+type sectorDay struct {
+	Date        string
+	Performance map[string]float64
+}
+
+func sectorPerformanceKey(day time.Time) string {
+	return fmt.Sprintf("sector:performance:%s", day.Format("2006-01-02"))
+}
+
+// recordPerformance stores a sector's daily performance. Like breadth
+// closes, each refresh overwrites the last, so the day ends holding the
+// closing move.
+func (s *SectorScraper) recordPerformance(sectorName string, sector *SectorData, now time.Time) error {
+	key := s.region.CacheKey(sectorPerformanceKey(MarketMidnight(now)))
+	pipe := s.redis.Pipeline()
+	pipe.HSet(s.ctx, key, strings.ToLower(sectorName), strconv.FormatFloat(sector.Performance, 'f', -1, 64))
+	pipe.Expire(s.ctx, key, (maxRotationWindow+7)*24*time.Hour)
+	_, err := pipe.Exec(s.ctx)
+	return err
+}
+
+// performanceHistory reads the stored daily performance of every sector
+// over the last days days, oldest first, skipping days with no data.
+func (s *SectorScraper) performanceHistory(now time.Time, days int) ([]sectorDay, error) {
+	today := MarketMidnight(now)
+	dates := make([]time.Time, 0, days)
+	pipe := s.redis.Pipeline()
+	cmds := make([]*redis.MapStringStringCmd, 0, days)
+	for i := days - 1; i >= 0; i-- {
+		day := today.AddDate(0, 0, -i)
+		dates = append(dates, day)
+		cmds = append(cmds, pipe.HGetAll(s.ctx, s.region.CacheKey(sectorPerformanceKey(day))))
+	}
+	if _, err := pipe.Exec(s.ctx); err != nil && err != redis.Nil {
+		return nil, err
+	}
+
+	history := make([]sectorDay, 0, days)
+	for i, cmd := range cmds {
+		performance := make(map[string]float64)
+		for sector, value := range cmd.Val() {
+			if v, err := strconv.ParseFloat(value, 64); err == nil {
+				performance[sector] = v
+			}
+		}
+		if len(performance) == 0 {
+			continue
+		}
+		history = append(history, sectorDay{
+			Date:        dates[i].Format("2006-01-02"),
+			Performance: performance,
+		})
+	}
+	return history, nil
+}
+
+func quadrant(ratio, momentum float64) string {
+	switch {
+	case ratio >= 100 && momentum >= 100:
+		return QuadrantLeading
+	case ratio >= 100:
+		return QuadrantWeakening
+	case momentum < 100:
+		return QuadrantLagging
+	default:
+		return QuadrantImproving
+	}
+}
+
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+// computeRotation builds relative rotation data from daily sector
+// performance. Each sector's relative strength compounds its daily move
+// against the equal-weighted average of all sectors that day. RS-Ratio
+// normalises that line by its own mean to date, and RS-Momentum is the
+// change in RS-Ratio over rotationMomentumDays. Sectors are returned by
+// their latest rank.
+func computeRotation(history []sectorDay) []SectorRotation {
+	seen := make(map[string]bool)
+	names := make([]string, 0)
+	for _, day := range history {
+		for sector := range day.Performance {
+			if !seen[sector] {
+				seen[sector] = true
+				names = append(names, sector)
+			}
+		}
+	}
+	// Sorted so that ties rank alphabetically
+	sort.Strings(names)
+
+	strength := make(map[string]float64, len(names))
+	strengthSum := make(map[string]float64, len(names))
+	ratios := make(map[string][]float64, len(names))
+	trails := make(map[string][]RotationPoint, len(names))
+	for _, sector := range names {
+		strength[sector] = 100
+	}
+
+	for t, day := range history {
+		benchmark := 0.0
+		for _, performance := range day.Performance {
+			benchmark += performance
+		}
+		benchmark /= float64(len(day.Performance))
+
+		points := make([]*RotationPoint, 0, len(names))
+		for _, sector := range names {
+			// A sector missing for a day carries its strength forward
+			if performance, ok := day.Performance[sector]; ok {
+				strength[sector] *= (1 + performance/100) / (1 + benchmark/100)
+			}
+			strengthSum[sector] += strength[sector]
+			ratio := 100 * strength[sector] / (strengthSum[sector] / float64(t+1))
+			ratios[sector] = append(ratios[sector], ratio)
+
+			momentum := 100.0
+			if lookback := min(rotationMomentumDays, t); lookback > 0 {
+				momentum = 100 * ratio / ratios[sector][t-lookback]
+			}
+
+			trails[sector] = append(trails[sector], RotationPoint{
+				Date:       day.Date,
+				RSRatio:    round2(ratio),
+				RSMomentum: round2(momentum),
+				Quadrant:   quadrant(ratio, momentum),
+			})
+			points = append(points, &trails[sector][len(trails[sector])-1])
+		}
+
+		sort.SliceStable(points, func(i, j int) bool {
+			return points[i].RSRatio > points[j].RSRatio
+		})
+		for i, point := range points {
+			point.Rank = i + 1
+		}
+	}
+
+	rotation := make([]SectorRotation, 0, len(names))
+	for _, sector := range names {
+		trail := trails[sector]
+		rotation = append(rotation, SectorRotation{
+			Sector:        sector,
+			RotationPoint: trail[len(trail)-1],
+			Trail:         trail,
+		})
+	}
+	sort.SliceStable(rotation, func(i, j int) bool {
+		return rotation[i].Rank < rotation[j].Rank
+	})
+	return rotation
+}
+
+// parseRotationWindow reads ?window= as a number of days, e.g. "90d".
+// Rotation places every sector on the rotation graph from the last days of
+// stored performance, also returning how many of those days had data.
+func (s *SectorScraper) Rotation(now time.Time, days int) ([]SectorRotation, int, error) {
+	history, err := s.performanceHistory(now, days)
+	if err != nil {
+		return nil, 0, err
+	}
+	return computeRotation(history), len(history), nil
+}
+
+func parseRotationWindow(param string) (int, error) {
+	if param == "" {
+		return DefaultRotationWindow, nil
+	}
+	days, err := strconv.Atoi(strings.TrimSuffix(param, "d"))
+	if err != nil || days < 2 || days > maxRotationWindow {
+		return 0, fmt.Errorf("window must be between 2d and %dd", maxRotationWindow)
+	}
+	return days, nil
+}
+
+func HandleSectorRotation() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		region, err := LookupRegion(c.Query("region"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		window, err := parseRotationWindow(c.Query("window"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		scraper := NewSectorScraper(ScraperOption{
+			Region:  region.Code,
+			Context: c.Request.Context(),
+		})
+		defer scraper.Close()
+
+		rotation, days, err := scraper.Rotation(time.Now(), window)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": fmt.Sprintf("failed to read sector history: %v", err),
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"status": "success",
+			"region": region.Code,
+			"data": gin.H{
+				"window_days":  window,
+				"history_days": days,
+				"sectors":      rotation,
+			},
+		})
+	}
+}
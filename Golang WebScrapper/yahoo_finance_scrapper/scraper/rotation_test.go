@@ -0,0 +1,69 @@
+package scraper
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestComputeRotation(t *testing.T) {
+	history := make([]sectorDay, 0)
+	for i := 0; i < 30; i++ {
+		// Technology outperforms throughout; energy lags until the last
+		// five days, when it starts to recover.
+		energy := -1.0
+		if i >= 25 {
+			energy = 2
+		}
+		history = append(history, sectorDay{
+			Date: fmt.Sprintf("2024-06-%02d", i+1),
+			Performance: map[string]float64{
+				"technology": 1,
+				"energy":     energy,
+				"utilities":  0,
+			},
+		})
+	}
+
+	rotation := computeRotation(history)
+	assert.Len(t, rotation, 3)
+
+	tech := rotation[0]
+	assert.Equal(t, "technology", tech.Sector)
+	assert.Equal(t, 1, tech.Rank)
+	assert.Greater(t, tech.RSRatio, 100.0)
+	assert.Len(t, tech.Trail, 30)
+	assert.Equal(t, "2024-06-30", tech.Date)
+
+	energy := rotation[2]
+	assert.Equal(t, "energy", energy.Sector)
+	assert.Less(t, energy.RSRatio, 100.0)
+	assert.Greater(t, energy.RSMomentum, 100.0)
+	assert.Equal(t, QuadrantImproving, energy.Quadrant)
+	assert.Equal(t, QuadrantLagging, energy.Trail[20].Quadrant)
+
+	assert.Empty(t, computeRotation(nil))
+}
+
+func TestQuadrant(t *testing.T) {
+	assert.Equal(t, QuadrantLeading, quadrant(101, 101))
+	assert.Equal(t, QuadrantWeakening, quadrant(101, 99))
+	assert.Equal(t, QuadrantLagging, quadrant(99, 99))
+	assert.Equal(t, QuadrantImproving, quadrant(99, 101))
+}
+
+func TestParseRotationWindow(t *testing.T) {
+	days, err := parseRotationWindow("")
+	assert.NoError(t, err)
+	assert.Equal(t, DefaultRotationWindow, days)
+
+	days, err = parseRotationWindow("30d")
+	assert.NoError(t, err)
+	assert.Equal(t, 30, days)
+
+	for _, param := range []string{"1d", "400d", "3m", "abc"} {
+		_, err := parseRotationWindow(param)
+		assert.Error(t, err, param)
+	}
+}
@@ -0,0 +1,269 @@
+package scraper
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-webscraper/cachekey"
+	"go-webscraper/pkg/parse"
+	"go-webscraper/pkg/yahoo"
+	"go-webscraper/queue"
+	"go-webscraper/stream"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gocolly/colly"
+)
+
+const (
+	constituentsPageSize = yahoo.ConstituentsPageSize
+	maxConstituents      = 2000
+)
+
+type SectorConstituent = yahoo.SectorConstituent
+
+// SectorConstituents is a sector's company list, as far as Limit
+// companies.
+type SectorConstituents struct {
+	Sector       string              `json:"sector"`
+	Limit        int                 `json:"limit"`
+	Constituents []SectorConstituent `json:"constituents"`
+	Timestamp    string              `json:"timestamp"`
+}
+
+func sectorConstituentsCacheKey(sectorName string, limit int) string {
+	return cachekey.SectorMembers.Key(sectorName, strconv.Itoa(limit))
+}
+
+// parseConstituentsTable maps columns by header text like
+// parseComponentsTable does.
+func parseConstituentsTable(e *colly.HTMLElement, region Region) []SectorConstituent {
+	columns := make(map[string]int)
+	e.ForEach("thead th", func(i int, th *colly.HTMLElement) {
+		columns[strings.ToLower(strings.TrimSpace(th.Text))] = i + 1
+	})
+	cell := func(row *colly.HTMLElement, names ...string) string {
+		for _, name := range names {
+			if i, ok := columns[name]; ok {
+				return strings.TrimSpace(row.ChildText(fmt.Sprintf("td:nth-child(%d)", i)))
+			}
+		}
+		return ""
+	}
+
+	constituents := make([]SectorConstituent, 0)
+	e.ForEach("tbody tr", func(_ int, row *colly.HTMLElement) {
+		constituent := SectorConstituent{
+			Symbol:    strings.ToUpper(cell(row, "symbol")),
+			Name:      cell(row, "company name", "name"),
+			Industry:  cell(row, "industry"),
+			MarketCap: cell(row, "market cap"),
+		}
+		if constituent.Symbol == "" {
+			return
+		}
+		if price, err := region.ParseFloat(cell(row, "last price", "price")); parse.OK("constituents.price", err) {
+			constituent.Price = price
+		}
+		if change, err := region.ParseFloat(cell(row, "change")); parse.OK("constituents.change", err) {
+			constituent.Change = change
+		}
+		if changePerc, err := region.ParsePercentage(cell(row, "% change", "change %")); parse.OK("constituents.change_percent", err) {
+			constituent.ChangePerc = changePerc
+		}
+		if volume, err := region.ParseInt(cell(row, "volume")); parse.OK("constituents.volume", err) {
+			constituent.Volume = volume
+		}
+		constituents = append(constituents, constituent)
+	})
+	return constituents
+}
+
+// ScrapeSectorConstituents scrapes up to limit companies from a sector's
+// company list, which unlike the top stocks table runs over many pages.
+func (s *SectorScraper) ScrapeSectorConstituents(sectorName string, limit int) (*SectorConstituents, error) {
+	sectorName = strings.ToLower(sectorName)
+	if _, exists := SectorURLs[sectorName]; !exists {
+		return nil, fmt.Errorf("invalid sector: %s", sectorName)
+	}
+	if limit <= 0 || limit > maxConstituents {
+		limit = maxConstituents
+	}
+
+	cacheKey := s.region.CacheKey(sectorConstituentsCacheKey(sectorName, limit))
+	if cached, err := s.cache.Get(s.ctx, cacheKey); err == nil && !s.fresh {
+		var sector SectorConstituents
+		if err := json.Unmarshal(cached, &sector); err == nil {
+			return &sector, nil
+		}
+	}
+
+	release, err := acquire(s.ctx, s.pool, s.priority)
+	if err != nil {
+		var stale SectorConstituents
+		if serveStale(s.ctx, s.cache, cacheKey, &stale, err) {
+			return &stale, nil
+		}
+		return nil, err
+	}
+	defer release()
+
+	sector := &SectorConstituents{
+		Sector:       sectorName,
+		Limit:        limit,
+		Constituents: make([]SectorConstituent, 0),
+		Timestamp:    time.Now().Format(time.RFC3339),
+	}
+	ctx := yahoo.WithFetchLog(s.ctx)
+	notModified, err := scrapeConditional(ctx, s.cache, cacheKey, s.ttl, sector, func(ctx context.Context) error {
+		constituents, err := s.yahoo.SectorConstituents(ctx, sectorName, limit)
+		if err != nil {
+			return err
+		}
+		sector.Constituents = append(sector.Constituents, constituents...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(sector.Constituents) == 0 {
+		return nil, nil
+	}
+
+	if !notModified {
+		cacheIfChanged(ctx, s.cache, s.tracker, cacheKey, sector, s.ttl)
+	}
+
+	return sector, nil
+}
+
+var SectorConstituentCSVHeaders = []string{
+	"Symbol", "Name", "Industry", "Price", "Change", "Change %", "Volume", "Market Cap",
+}
+
+func SectorConstituentRecord(constituent SectorConstituent) []string {
+	return []string{
+		constituent.Symbol,
+		constituent.Name,
+		constituent.Industry,
+		strconv.FormatFloat(constituent.Price, 'f', 2, 64),
+		strconv.FormatFloat(constituent.Change, 'f', 2, 64),
+		strconv.FormatFloat(constituent.ChangePerc, 'f', 2, 64),
+		strconv.FormatInt(constituent.Volume, 10),
+		constituent.MarketCap,
+	}
+}
+
+// parseConstituentsLimit reads ?limit=, which is all or a number of
+// companies, defaulting to the first page.
+func parseConstituentsLimit(value string) (int, error) {
+	switch value {
+	case "":
+		return constituentsPageSize, nil
+	case "all":
+		return maxConstituents, nil
+	}
+	limit, err := strconv.Atoi(value)
+	if err != nil || limit <= 0 || limit > maxConstituents {
+		return 0, fmt.Errorf("limit must be all or between 1 and %d", maxConstituents)
+	}
+	return limit, nil
+}
+
+// HandleSectorConstituents serves GET /api/sector/:name/constituents, as
+// JSON or, with format=csv, as a CSV attachment.
+func HandleSectorConstituents(pool *queue.Pool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		region, err := LookupRegion(c.Query("region"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		name := strings.ToLower(c.Param("name"))
+		if _, exists := SectorURLs[name]; !exists {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "invalid sector: " + name,
+			})
+			return
+		}
+
+		limit, err := parseConstituentsLimit(c.Query("limit"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		format := c.DefaultQuery("format", "json")
+		if format != "json" && format != "csv" {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "format must be json or csv",
+			})
+			return
+		}
+
+		scraper := NewSectorScraper(ScraperOption{
+			Region:  region.Code,
+			Context: c.Request.Context(),
+			Pool:    pool,
+		})
+		defer scraper.Close()
+
+		cacheKey := region.CacheKey(sectorConstituentsCacheKey(name, limit))
+		if notModifiedSince(c, c.Request.Context(), scraper.cache, scraper.tracker, cacheKey) {
+			return
+		}
+
+		sector, err := scraper.ScrapeSectorConstituents(name, limit)
+		if shed(c, pool, err) {
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+		if sector == nil {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "no constituents listed for " + name,
+			})
+			return
+		}
+
+		if format == "csv" {
+			writer := stream.NewCSV(c, fmt.Sprintf("%s_constituents_%s.csv", name, time.Now().Format("20060102")))
+			if err := writer.Write(SectorConstituentCSVHeaders); err != nil {
+				log.Printf("%s constituents CSV ended early: %v", name, err)
+				return
+			}
+			for _, constituent := range sector.Constituents {
+				if err := writer.Write(SectorConstituentRecord(constituent)); err != nil {
+					log.Printf("%s constituents CSV ended early: %v", name, err)
+					return
+				}
+			}
+			if err := writer.Close(); err != nil {
+				log.Printf("%s constituents CSV ended early: %v", name, err)
+			}
+			return
+		}
+
+		setCacheControl(c, scraper.cache, cacheKey)
+		c.JSON(http.StatusOK, withProvenance(c, scraper.cache, cacheKey, gin.H{
+			"status": "success",
+			"region": region.Code,
+			"data":   sector,
+		}))
+	}
+}
@@ -0,0 +1,26 @@
+package scraper
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseConstituentsLimit(t *testing.T) {
+	limit, err := parseConstituentsLimit("")
+	assert.NoError(t, err)
+	assert.Equal(t, constituentsPageSize, limit)
+
+	limit, err = parseConstituentsLimit("all")
+	assert.NoError(t, err)
+	assert.Equal(t, maxConstituents, limit)
+
+	limit, err = parseConstituentsLimit("250")
+	assert.NoError(t, err)
+	assert.Equal(t, 250, limit)
+
+	for _, value := range []string{"0", "-1", "lots", "100000"} {
+		_, err := parseConstituentsLimit(value)
+		assert.Error(t, err, value)
+	}
+}
@@ -0,0 +1,143 @@
+package scraper
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"go-webscraper/queue"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	DefaultSectorMovers = 5
+	MaxSectorMovers     = 25
+)
+
+// SectorMovers is a sector's biggest gainers and losers of the day, ranked
+// by percentage change among the first Scanned companies of its list.
+type SectorMovers struct {
+	Sector    string              `json:"sector"`
+	Scanned   int                 `json:"scanned"`
+	Gainers   []SectorConstituent `json:"gainers"`
+	Losers    []SectorConstituent `json:"losers"`
+	Timestamp string              `json:"timestamp"`
+}
+
+// rankSectorMovers picks up to count risers and count fallers from
+// constituents, biggest move first. Unchanged companies are neither.
+func rankSectorMovers(constituents []SectorConstituent, count int) (gainers, losers []SectorConstituent) {
+	ranked := append([]SectorConstituent(nil), constituents...)
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return ranked[i].ChangePerc > ranked[j].ChangePerc
+	})
+
+	gainers = make([]SectorConstituent, 0, count)
+	for _, constituent := range ranked {
+		if len(gainers) == count || constituent.ChangePerc <= 0 {
+			break
+		}
+		gainers = append(gainers, constituent)
+	}
+	losers = make([]SectorConstituent, 0, count)
+	for i := len(ranked) - 1; i >= 0; i-- {
+		if len(losers) == count || ranked[i].ChangePerc >= 0 {
+			break
+		}
+		losers = append(losers, ranked[i])
+	}
+	return gainers, losers
+}
+
+// ScrapeSectorMovers ranks the day's movers among the first limit
+// companies of a sector's list. The list is the one
+// ScrapeSectorConstituents caches, so movers and constituents agree.
+func (s *SectorScraper) ScrapeSectorMovers(sectorName string, limit, count int) (*SectorMovers, error) {
+	sector, err := s.ScrapeSectorConstituents(sectorName, limit)
+	if err != nil || sector == nil {
+		return nil, err
+	}
+
+	gainers, losers := rankSectorMovers(sector.Constituents, count)
+	return &SectorMovers{
+		Sector:    sector.Sector,
+		Scanned:   len(sector.Constituents),
+		Gainers:   gainers,
+		Losers:    losers,
+		Timestamp: sector.Timestamp,
+	}, nil
+}
+
+// HandleSectorMovers serves GET /api/sector/:name/movers. ?count= is how
+// many gainers and losers to return and ?limit= how many of the sector's
+// companies to rank, as for constituents.
+func HandleSectorMovers(pool *queue.Pool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		region, err := LookupRegion(c.Query("region"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		name := strings.ToLower(c.Param("name"))
+		if _, exists := SectorURLs[name]; !exists {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "invalid sector: " + name,
+			})
+			return
+		}
+
+		count, err := strconv.Atoi(c.DefaultQuery("count", strconv.Itoa(DefaultSectorMovers)))
+		if err != nil || count <= 0 || count > MaxSectorMovers {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": fmt.Sprintf("count must be between 1 and %d", MaxSectorMovers),
+			})
+			return
+		}
+
+		limit, err := parseConstituentsLimit(c.Query("limit"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		scraper := NewSectorScraper(ScraperOption{
+			Region:  region.Code,
+			Context: c.Request.Context(),
+			Pool:    pool,
+		})
+		defer scraper.Close()
+
+		movers, err := scraper.ScrapeSectorMovers(name, limit, count)
+		if shed(c, pool, err) {
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+		if movers == nil {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "no constituents listed for " + name,
+			})
+			return
+		}
+
+		cacheKey := region.CacheKey(sectorConstituentsCacheKey(name, limit))
+		setCacheControl(c, scraper.cache, cacheKey)
+		c.JSON(http.StatusOK, withProvenance(c, scraper.cache, cacheKey, gin.H{
+			"status": "success",
+			"region": region.Code,
+			"data":   movers,
+		}))
+	}
+}
@@ -0,0 +1,40 @@
+package scraper
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRankSectorMovers(t *testing.T) {
+	constituents := []SectorConstituent{
+		{Symbol: "AAPL", ChangePerc: 0.4},
+		{Symbol: "NVDA", ChangePerc: 3.1},
+		{Symbol: "ADBE", ChangePerc: -2.5},
+		{Symbol: "ORCL", ChangePerc: 0},
+		{Symbol: "INTC", ChangePerc: -0.3},
+		{Symbol: "AMD", ChangePerc: 1.2},
+	}
+
+	gainers, losers := rankSectorMovers(constituents, 2)
+	assert.Equal(t, []string{"NVDA", "AMD"}, moverSymbols(gainers))
+	assert.Equal(t, []string{"ADBE", "INTC"}, moverSymbols(losers))
+
+	// Unchanged companies fill neither list
+	gainers, losers = rankSectorMovers(constituents, 10)
+	assert.Equal(t, []string{"NVDA", "AMD", "AAPL"}, moverSymbols(gainers))
+	assert.Equal(t, []string{"ADBE", "INTC"}, moverSymbols(losers))
+	assert.Equal(t, "AAPL", constituents[0].Symbol, "the input order is kept")
+
+	gainers, losers = rankSectorMovers(nil, 5)
+	assert.Empty(t, gainers)
+	assert.Empty(t, losers)
+}
+
+func moverSymbols(constituents []SectorConstituent) []string {
+	symbols := make([]string, 0, len(constituents))
+	for _, constituent := range constituents {
+		symbols = append(symbols, constituent.Symbol)
+	}
+	return symbols
+}
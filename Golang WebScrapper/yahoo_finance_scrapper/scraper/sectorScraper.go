@@ -3,197 +3,125 @@ package scraper
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log"
 	"net/http"
 	"strconv"
 	"strings"
 	"sync"
 	"time"
 
+	"go-webscraper/cache"
+	"go-webscraper/cachekey"
+	"go-webscraper/changes"
+	"go-webscraper/keyspace"
+	"go-webscraper/model"
+	"go-webscraper/pkg/yahoo"
+	"go-webscraper/queue"
+
 	"github.com/gin-gonic/gin"
-	"github.com/gocolly/colly"
 	"github.com/redis/go-redis/v9"
 )
 
-type SectorData struct {
-	Name          string      `json:"name"`
-	Performance   float64     `json:"performance"`
-	Volume        int64       `json:"volume"`
-	MarketCap     string      `json:"market_cap"`
-	AveragePE     float64     `json:"average_pe"`
-	Volatility    float64     `json:"volatility"`
-	TopStocks     []StockData `json:"top_stocks"`
-	Performance1M float64     `json:"performance_1m"`
-	Performance3M float64     `json:"performance_3m"`
-	Performance1Y float64     `json:"performance_1y"`
-	SubIndustries []SubSector `json:"sub_industries"`
-	Timestamp     string      `json:"timestamp"`
-}
-
-type SubSector struct {
-	Name        string  `json:"name"`
-	Performance float64 `json:"performance"`
-	StockCount  int     `json:"stock_count"`
-	MarketCap   string  `json:"market_cap"`
-}
+type (
+	SectorData = model.SectorData
+	SubSector  = model.SubSector
+)
 
 type SectorScraper struct {
-	redis     *redis.Client
-	ctx       context.Context
-	ttl       time.Duration
-	collector *colly.Collector
-	mutex     sync.Mutex
+	redis    *redis.Client
+	cache    cache.Cache
+	ctx      context.Context
+	ttl      time.Duration
+	yahoo    *yahoo.Client
+	mutex    sync.Mutex
+	region   Region
+	tracker  *changes.Tracker
+	pool     *queue.Pool
+	priority queue.Priority
+	fresh    bool
 }
 
-var SectorURLs = map[string]string{
-	"technology":    "https://finance.yahoo.com/sector/technology",
-	"healthcare":    "https://finance.yahoo.com/sector/healthcare",
-	"financial":     "https://finance.yahoo.com/sector/financial",
-	"energy":        "https://finance.yahoo.com/sector/energy",
-	"consumer":      "https://finance.yahoo.com/sector/consumer_cyclical",
-	"industrial":    "https://finance.yahoo.com/sector/industrial",
-	"materials":     "https://finance.yahoo.com/sector/basic_materials",
-	"utilities":     "https://finance.yahoo.com/sector/utilities",
-	"real_estate":   "https://finance.yahoo.com/sector/real_estate",
-	"communication": "https://finance.yahoo.com/sector/communication_services",
+var SectorURLs = yahoo.SectorURLs
+
+func sectorCacheKey(sectorName string) string {
+	return cachekey.Sector.Key(sectorName)
 }
 
 func NewSectorScraper(opts ScraperOption) *SectorScraper {
-	if opts.CacheTTL == 0 {
-		opts.CacheTTL = 1 * time.Hour
+	region, err := LookupRegion(opts.Region)
+	if err != nil {
+		log.Printf("%v, falling back to %s", err, DefaultRegion)
+		region = Regions[DefaultRegion]
 	}
 
-	rdb := redis.NewClient(&redis.Options{
-		Addr:     opts.RedisAddr,
-		Password: opts.RedisPassword,
-		DB:       opts.RedisDB,
-	})
+	rdb := newRedis(opts.RedisAddr, opts.RedisPassword, opts.RedisDB)
+	traceRedis(rdb)
+	keyspace.Instrument(rdb)
 
-	c := colly.NewCollector(
-		colly.UserAgent("Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/91.0.4472.124 Safari/537.36"),
-		colly.AllowedDomains("finance.yahoo.com"),
-		colly.MaxDepth(1),
-		colly.Async(true),
-	)
-
-	c.Limit(&colly.LimitRule{
-		DomainGlob:  "*",
+	client, err := yahoo.New(yahoo.Option{
+		Region:      region.Code,
 		Parallelism: opts.NumThread,
 		RandomDelay: 2 * time.Second,
 	})
+	if err != nil {
+		log.Fatalf("Failed to create Yahoo client: %v", err)
+	}
 
 	return &SectorScraper{
-		redis:     rdb,
-		ctx:       context.Background(),
-		ttl:       opts.CacheTTL,
-		collector: c,
-		mutex:     sync.Mutex{},
+		redis:    rdb,
+		cache:    cache.New(rdb),
+		tracker:  changes.NewTracker(rdb),
+		ctx:      optionContext(opts.Context),
+		ttl:      opts.CacheTTL,
+		yahoo:    client,
+		mutex:    sync.Mutex{},
+		region:   region,
+		pool:     opts.Pool,
+		priority: opts.Priority,
 	}
 }
 
 func (s *SectorScraper) ScrapeSector(sectorName string) (*SectorData, error) {
-	cacheKey := fmt.Sprintf("sector:%s", sectorName)
-	cachedData, err := s.redis.Get(s.ctx, cacheKey).Result()
-	if err == nil {
+	cacheKey := s.region.CacheKey(sectorCacheKey(sectorName))
+	cachedData, err := s.cache.Get(s.ctx, cacheKey)
+	if err == nil && !s.fresh {
 		var sectorData SectorData
-		if err := json.Unmarshal([]byte(cachedData), &sectorData); err == nil {
+		if err := json.Unmarshal(cachedData, &sectorData); err == nil {
 			return &sectorData, nil
 		}
 	}
 
-	url, exists := SectorURLs[strings.ToLower(sectorName)]
-	if !exists {
+	if _, exists := SectorURLs[strings.ToLower(sectorName)]; !exists {
 		return nil, fmt.Errorf("invalid sector: %s", sectorName)
 	}
 
-	sectorData := &SectorData{
-		Name:          sectorName,
-		SubIndustries: make([]SubSector, 0),
-		TopStocks:     make([]StockData, 0),
-		Timestamp:     time.Now().Format(time.RFC3339),
-	}
-
-	c := s.collector.Clone()
-
-	c.OnHTML("div#quote-summary", func(e *colly.HTMLElement) {
-		e.ForEach("tr", func(_ int, row *colly.HTMLElement) {
-			label := row.ChildText("td:first-child")
-			value := row.ChildText("td:nth-child(2)")
-
-			switch label {
-			case "Performance":
-				if perf, err := parsePercentage(value); err == nil {
-					sectorData.Performance = perf
-				}
-			case "1-Month Performance":
-				if perf, err := parsePercentage(value); err == nil {
-					sectorData.Performance1M = perf
-				}
-			case "3-Month Performance":
-				if perf, err := parsePercentage(value); err == nil {
-					sectorData.Performance3M = perf
-				}
-			case "1-Year Performance":
-				if perf, err := parsePercentage(value); err == nil {
-					sectorData.Performance1Y = perf
-				}
-			}
-		})
-	})
-
-	c.OnHTML("table[data-test='top-stocks'] tbody tr", func(e *colly.HTMLElement) {
-		stock := StockData{
-			Symbol:    strings.TrimSpace(e.ChildText("td:nth-child(1)")),
-			Name:      strings.TrimSpace(e.ChildText("td:nth-child(2)")),
-			Timestamp: time.Now().Format(time.RFC3339),
-		}
-
-		if price, err := strconv.ParseFloat(strings.ReplaceAll(e.ChildText("td:nth-child(3)"), ",", ""), 64); err == nil {
-			stock.Price = price
-		}
-
-		if change, err := strconv.ParseFloat(strings.ReplaceAll(e.ChildText("td:nth-child(4)"), ",", ""), 64); err == nil {
-			stock.Change = change
-		}
-
-		if changePerc, err := parsePercentage(e.ChildText("td:nth-child(5)")); err == nil {
-			stock.ChangePerc = changePerc
-		}
-
-		if volume, err := strconv.ParseInt(strings.ReplaceAll(e.ChildText("td:nth-child(6)"), ",", ""), 10, 64); err == nil {
-			stock.Volume = volume
-		}
-
-		sectorData.TopStocks = append(sectorData.TopStocks, stock)
-	})
-
-	c.OnHTML("table[data-test='sub-industries'] tbody tr", func(e *colly.HTMLElement) {
-		subSector := SubSector{
-			Name: strings.TrimSpace(e.ChildText("td:nth-child(1)")),
-		}
-
-		if perf, err := parsePercentage(e.ChildText("td:nth-child(2)")); err == nil {
-			subSector.Performance = perf
-		}
-
-		if count, err := strconv.Atoi(strings.TrimSpace(e.ChildText("td:nth-child(3)"))); err == nil {
-			subSector.StockCount = count
+	release, err := acquire(s.ctx, s.pool, s.priority)
+	if err != nil {
+		var stale SectorData
+		if serveStale(s.ctx, s.cache, cacheKey, &stale, err) {
+			return &stale, nil
 		}
+		return nil, err
+	}
+	defer release()
 
-		subSector.MarketCap = strings.TrimSpace(e.ChildText("td:nth-child(4)"))
-
-		sectorData.SubIndustries = append(sectorData.SubIndustries, subSector)
+	ctx := yahoo.WithFetchLog(s.ctx)
+	var sectorData *SectorData
+	notModified, err := scrapeConditional(ctx, s.cache, cacheKey, s.ttl, &sectorData, func(ctx context.Context) error {
+		sectorData, err = s.yahoo.Sector(ctx, sectorName)
+		return err
 	})
-
-	err = c.Visit(url)
 	if err != nil {
-		return nil, fmt.Errorf("failed to scrape sector data: %v", err)
+		return nil, err
 	}
 
-	c.Wait()
-
-	if jsonData, err := json.Marshal(sectorData); err == nil {
-		s.redis.Set(s.ctx, cacheKey, jsonData, s.ttl)
+	if !notModified {
+		cacheIfChanged(ctx, s.cache, s.tracker, cacheKey, sectorData, s.ttl)
+	}
+	if err := s.recordPerformance(sectorName, sectorData, time.Now()); err != nil {
+		log.Printf("failed to record %s performance: %v", sectorName, err)
 	}
 
 	return sectorData, nil
@@ -211,6 +139,10 @@ func (s *SectorScraper) ScrapeAllSectors() (map[string]*SectorData, error) {
 			defer wg.Done()
 
 			sectorData, err := s.ScrapeSector(sector)
+			if errors.Is(err, queue.ErrSaturated) {
+				errChan <- err
+				return
+			}
 			if err != nil {
 				errChan <- fmt.Errorf("error scraping %s: %v", sector, err)
 				return
@@ -234,45 +166,130 @@ func (s *SectorScraper) ScrapeAllSectors() (map[string]*SectorData, error) {
 	return results, nil
 }
 
-func parsePercentage(s string) (float64, error) {
-	s = strings.TrimSpace(strings.TrimSuffix(s, "%"))
-	return strconv.ParseFloat(s, 64)
+func (s *SectorScraper) Close() {
+	s.redis.Close()
 }
 
-func HandleSector(c *gin.Context) {
-	scraper := NewSectorScraper(ScraperOption{
-		CacheTTL:  1 * time.Hour,
-		RedisAddr: "localhost:6379",
-	})
+var SectorCSVHeaders = []string{
+	"Sector", "Performance", "1M", "3M", "1Y",
+	"Volume", "Market Cap", "Average PE", "Volatility", "Timestamp",
+}
 
-	sector := c.Query("sector")
-	all := c.Query("all") == "true"
-
-	var (
-		data interface{}
-		err  error
-	)
-
-	if all {
-		data, err = scraper.ScrapeAllSectors()
-	} else if sector != "" {
-		data, err = scraper.ScrapeSector(sector)
-	} else {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "please specify sector parameter or use all=true",
-		})
-		return
+func SectorRecord(sector *SectorData) []string {
+	return []string{
+		sector.Name,
+		strconv.FormatFloat(sector.Performance, 'f', 2, 64),
+		strconv.FormatFloat(sector.Performance1M, 'f', 2, 64),
+		strconv.FormatFloat(sector.Performance3M, 'f', 2, 64),
+		strconv.FormatFloat(sector.Performance1Y, 'f', 2, 64),
+		strconv.FormatInt(sector.Volume, 10),
+		sector.MarketCap,
+		strconv.FormatFloat(sector.AveragePE, 'f', 2, 64),
+		strconv.FormatFloat(sector.Volatility, 'f', 2, 64),
+		sector.Timestamp,
 	}
+}
 
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": err.Error(),
+func HandleSector(jobs *queue.Queue, pool *queue.Pool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		region, err := LookupRegion(c.Query("region"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		sector := c.Query("sector")
+		all := c.Query("all") == "true"
+
+		if c.Query("async") == "true" && (all || sector != "") {
+			kind, params := "sector", map[string]string{"region": region.Code, "sector": sector}
+			if all {
+				kind, params = "sectors", map[string]string{"region": region.Code}
+			}
+			job, err := jobs.Enqueue(kind, params)
+			if shed(c, pool, err) {
+				return
+			}
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{
+					"error": err.Error(),
+				})
+				return
+			}
+			queue.Accepted(c, job)
+			return
+		}
+
+		scraper := NewSectorScraper(ScraperOption{
+			Region:  region.Code,
+			Context: c.Request.Context(),
+			Pool:    pool,
 		})
-		return
-	}
+		defer scraper.Close()
 
-	c.JSON(http.StatusOK, gin.H{
-		"status": "success",
-		"data":   data,
-	})
+		var data interface{}
+
+		if all {
+			data, err = scraper.ScrapeAllSectors()
+		} else if sector != "" {
+			if notModifiedSince(c, scraper.ctx, scraper.cache, scraper.tracker, region.CacheKey(sectorCacheKey(sector))) {
+				return
+			}
+			data, err = scraper.ScrapeSector(sector)
+		} else {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "please specify sector parameter or use all=true",
+			})
+			return
+		}
+
+		if shed(c, pool, err) {
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		data, rates, err := applyCurrency(c, region.Currency, data)
+		if err != nil {
+			c.JSON(currencyStatus(err), gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		if all {
+			keys := make([]string, 0, len(SectorURLs))
+			for name := range SectorURLs {
+				keys = append(keys, region.CacheKey(sectorCacheKey(name)))
+			}
+			setCacheControl(c, scraper.cache, keys...)
+		} else {
+			setCacheControl(c, scraper.cache, region.CacheKey(sectorCacheKey(sector)))
+		}
+
+		response := gin.H{
+			"status": "success",
+			"region": region.Code,
+			"data":   data,
+		}
+		if rates != nil {
+			response["fx"] = rates
+		}
+		if all && wantsProvenance(c) {
+			provenance := make(map[string]*Provenance, len(SectorURLs))
+			for name := range SectorURLs {
+				provenance[name] = loadProvenance(c.Request.Context(), scraper.cache, region.CacheKey(sectorCacheKey(name)))
+			}
+			response["provenance"] = provenance
+		} else if !all {
+			response = withProvenance(c, scraper.cache, region.CacheKey(sectorCacheKey(sector)), response)
+		}
+		c.JSON(http.StatusOK, response)
+	}
 }
@@ -0,0 +1,283 @@
+package scraper
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go-webscraper/queue"
+
+	"github.com/gin-gonic/gin"
+)
+
+// selfTestTimeout bounds the whole run; every source scrapes in parallel.
+const selfTestTimeout = 90 * time.Second
+
+const selfTestSampleRows = 3
+
+// SelfTestResult is the outcome of one source's canary scrape. Missing
+// lists the fields that came back empty in the sample rows, which usually
+// means Yahoo changed the page layout under a selector.
+type SelfTestResult struct {
+	Source   string      `json:"source"`
+	Passed   bool        `json:"passed"`
+	Rows     int         `json:"rows"`
+	Missing  []string    `json:"missing,omitempty"`
+	Error    string      `json:"error,omitempty"`
+	Duration string      `json:"duration"`
+	Sample   interface{} `json:"sample,omitempty"`
+}
+
+// selfTest scrapes one source bypassing the cache and returns sample rows
+// plus the names of required fields that were empty in any of them.
+type selfTest func(ctx context.Context, pool *queue.Pool) (sample interface{}, rows int, missing []string, err error)
+
+var selfTests = map[string]selfTest{
+	"stock":    selfTestStock,
+	"sector":   selfTestSector,
+	"news":     selfTestNews,
+	"calendar": selfTestCalendar,
+	"options":  selfTestOptions,
+}
+
+// emptyFields marks every field whose value is empty as missing.
+func emptyFields(missing map[string]bool, fields map[string]string) {
+	for name, value := range fields {
+		if value == "" {
+			missing[name] = true
+		}
+	}
+}
+
+func missingList(missing map[string]bool) []string {
+	if len(missing) == 0 {
+		return nil
+	}
+	names := make([]string, 0, len(missing))
+	for name := range missing {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func nonZero(f float64) string {
+	if f == 0 {
+		return ""
+	}
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+func selfTestStock(ctx context.Context, pool *queue.Pool) (interface{}, int, []string, error) {
+	s := NewStockScraper(StockScraperOption{Context: ctx, Pool: pool})
+	defer s.Close()
+	s.fresh = true
+
+	stocks, err := s.ScrapeMostActive()
+	if err != nil {
+		return nil, 0, nil, err
+	}
+	if len(stocks) > selfTestSampleRows {
+		stocks = stocks[:selfTestSampleRows]
+	}
+
+	missing := make(map[string]bool)
+	for _, stock := range stocks {
+		emptyFields(missing, map[string]string{
+			"symbol": stock.Symbol,
+			"name":   stock.Name,
+			"price":  nonZero(stock.Price),
+		})
+	}
+	return stocks, len(stocks), missingList(missing), nil
+}
+
+func selfTestSector(ctx context.Context, pool *queue.Pool) (interface{}, int, []string, error) {
+	s := NewSectorScraper(ScraperOption{Context: ctx, Pool: pool})
+	defer s.Close()
+	s.fresh = true
+
+	sector, err := s.ScrapeSector("technology")
+	if err != nil {
+		return nil, 0, nil, err
+	}
+
+	missing := make(map[string]bool)
+	if len(sector.TopStocks) == 0 {
+		missing["top_stocks"] = true
+	}
+	if len(sector.TopStocks) > selfTestSampleRows {
+		sector.TopStocks = sector.TopStocks[:selfTestSampleRows]
+	}
+	for _, stock := range sector.TopStocks {
+		emptyFields(missing, map[string]string{
+			"top_stocks.symbol": stock.Symbol,
+			"top_stocks.price":  nonZero(stock.Price),
+		})
+	}
+	return sector, len(sector.TopStocks), missingList(missing), nil
+}
+
+func selfTestNews(ctx context.Context, pool *queue.Pool) (interface{}, int, []string, error) {
+	s := NewScraper(ScraperOption{
+		Context: ctx,
+		Pool:    pool,
+		Crawl:   CrawlLimits{MaxPages: 5, MaxDepth: 1, MaxDuration: 30 * time.Second},
+	})
+	defer s.Close()
+	s.fresh = true
+
+	articles, err := s.ScrapeNews(time.Time{})
+	if err != nil {
+		return nil, 0, nil, err
+	}
+	if len(articles) > selfTestSampleRows {
+		articles = articles[:selfTestSampleRows]
+	}
+
+	missing := make(map[string]bool)
+	for _, article := range articles {
+		emptyFields(missing, map[string]string{
+			"title": article.Title,
+			"link":  article.Link,
+			"date":  article.DatePublished,
+		})
+	}
+	return articles, len(articles), missingList(missing), nil
+}
+
+func selfTestCalendar(ctx context.Context, pool *queue.Pool) (interface{}, int, []string, error) {
+	s := NewCalendarScraper(ScraperOption{Context: ctx, Pool: pool})
+	defer s.Close()
+	s.fresh = true
+
+	events, err := s.ScrapeEconomicCalendar(time.Now().In(marketLocation).Format("2006-01-02"))
+	if err != nil {
+		return nil, 0, nil, err
+	}
+	if len(events) > selfTestSampleRows {
+		events = events[:selfTestSampleRows]
+	}
+
+	missing := make(map[string]bool)
+	for _, event := range events {
+		emptyFields(missing, map[string]string{
+			"event":   event.Event,
+			"country": event.Country,
+		})
+	}
+	return events, len(events), missingList(missing), nil
+}
+
+func selfTestOptions(ctx context.Context, pool *queue.Pool) (interface{}, int, []string, error) {
+	s := NewOptionsScraper(ScraperOption{Context: ctx, Pool: pool})
+	defer s.Close()
+	s.fresh = true
+
+	contracts, err := s.ScrapeMostActiveOptions("oi")
+	if err != nil {
+		return nil, 0, nil, err
+	}
+	if len(contracts) > selfTestSampleRows {
+		contracts = contracts[:selfTestSampleRows]
+	}
+
+	missing := make(map[string]bool)
+	for _, contract := range contracts {
+		emptyFields(missing, map[string]string{
+			"contract":   contract.Contract,
+			"underlying": contract.Underlying,
+			"strike":     nonZero(contract.Strike),
+		})
+	}
+	return contracts, len(contracts), missingList(missing), nil
+}
+
+// runSelfTests runs every test concurrently and returns results sorted by
+// source name.
+func runSelfTests(ctx context.Context, pool *queue.Pool, tests map[string]selfTest) []SelfTestResult {
+	results := make([]SelfTestResult, 0, len(tests))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	for source, test := range tests {
+		wg.Add(1)
+		go func(source string, test selfTest) {
+			defer wg.Done()
+
+			start := time.Now()
+			sample, rows, missing, err := test(ctx, pool)
+			result := SelfTestResult{
+				Source:   source,
+				Rows:     rows,
+				Missing:  missing,
+				Duration: time.Since(start).Round(time.Millisecond).String(),
+				Sample:   sample,
+			}
+			switch {
+			case err != nil:
+				result.Error = err.Error()
+			case rows == 0:
+				result.Error = "no rows parsed"
+			default:
+				result.Passed = len(missing) == 0
+			}
+
+			mu.Lock()
+			results = append(results, result)
+			mu.Unlock()
+		}(source, test)
+	}
+	wg.Wait()
+
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Source < results[j].Source
+	})
+	return results
+}
+
+// HandleSelfTest runs a minimal uncached scrape of every source and reports
+// per-source pass/fail with parsed sample rows. ?source= limits the run to
+// a comma-separated subset.
+func HandleSelfTest(pool *queue.Pool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tests := selfTests
+		if param := c.Query("source"); param != "" {
+			tests = make(map[string]selfTest)
+			for _, source := range strings.Split(param, ",") {
+				source = strings.TrimSpace(source)
+				test, exists := selfTests[source]
+				if !exists {
+					c.JSON(http.StatusBadRequest, gin.H{
+						"status": "error",
+						"error":  "unknown source: " + source,
+					})
+					return
+				}
+				tests[source] = test
+			}
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), selfTestTimeout)
+		defer cancel()
+
+		results := runSelfTests(ctx, pool, tests)
+
+		passed := true
+		for _, result := range results {
+			passed = passed && result.Passed
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"status": "success",
+			"data": gin.H{
+				"passed":  passed,
+				"results": results,
+			},
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go-webscraper/queue"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunSelfTests(t *testing.T) {
+	tests := map[string]selfTest{
+		"ok": func(context.Context, *queue.Pool) (interface{}, int, []string, error) {
+			return []string{"row"}, 1, nil, nil
+		},
+		"layout": func(context.Context, *queue.Pool) (interface{}, int, []string, error) {
+			return []string{"row"}, 1, []string{"price"}, nil
+		},
+		"empty": func(context.Context, *queue.Pool) (interface{}, int, []string, error) {
+			return nil, 0, nil, nil
+		},
+		"down": func(context.Context, *queue.Pool) (interface{}, int, []string, error) {
+			return nil, 0, nil, errors.New("connection refused")
+		},
+	}
+
+	results := runSelfTests(context.Background(), nil, tests)
+	assert.Len(t, results, 4)
+
+	bySource := make(map[string]SelfTestResult)
+	for _, result := range results {
+		bySource[result.Source] = result
+	}
+	assert.Equal(t, "down", results[0].Source)
+
+	assert.True(t, bySource["ok"].Passed)
+	assert.False(t, bySource["layout"].Passed)
+	assert.Equal(t, []string{"price"}, bySource["layout"].Missing)
+	assert.False(t, bySource["empty"].Passed)
+	assert.Equal(t, "no rows parsed", bySource["empty"].Error)
+	assert.False(t, bySource["down"].Passed)
+	assert.Equal(t, "connection refused", bySource["down"].Error)
+}
+
+func TestEmptyFields(t *testing.T) {
+	missing := make(map[string]bool)
+	emptyFields(missing, map[string]string{"symbol": "AAPL", "name": "", "price": nonZero(0)})
+	emptyFields(missing, map[string]string{"symbol": "", "name": "Apple", "price": nonZero(1.5)})
+
+	assert.Equal(t, []string{"name", "price", "symbol"}, missingList(missing))
+	assert.Nil(t, missingList(map[string]bool{}))
+}
@@ -0,0 +1,157 @@
+package scraper
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-webscraper/cachekey"
+	"go-webscraper/queue"
+	"go-webscraper/stream"
+
+	"github.com/gin-gonic/gin"
+)
+
+// sheetQuotesMaxAge is how long spreadsheets may reuse a regular (not
+// long-cached) response.
+const sheetQuotesMaxAge = 1 * time.Minute
+
+var sheetHeaders = []interface{}{
+	"Symbol", "Name", "Price", "Change", "Change%", "Market Cap", "Currency", "Timestamp",
+}
+
+// sheetRows lays quotes out as a header row plus one row per requested
+// symbol. Symbols without a quote keep their row with blank cells so
+// formulas indexing into the range don't shift.
+func sheetRows(symbols []string, quotes []StockData) [][]interface{} {
+	bySymbol := make(map[string]StockData, len(quotes))
+	for _, quote := range quotes {
+		bySymbol[quote.Symbol] = quote
+	}
+
+	rows := [][]interface{}{sheetHeaders}
+	for _, symbol := range symbols {
+		quote, ok := bySymbol[symbol]
+		if !ok {
+			rows = append(rows, []interface{}{symbol, "", "", "", "", "", "", ""})
+			continue
+		}
+		rows = append(rows, []interface{}{
+			quote.Symbol, quote.Name, quote.Price, quote.Change, quote.ChangePerc,
+			quote.MarketCap, quote.Currency, quote.Timestamp,
+		})
+	}
+	return rows
+}
+
+// sheetCell formats floats in plain decimal notation, which Sheets parses
+// as a number where fmt's exponent form would be read as text.
+func sheetCell(cell interface{}) string {
+	if f, ok := cell.(float64); ok {
+		return strconv.FormatFloat(f, 'f', -1, 64)
+	}
+	return fmt.Sprint(cell)
+}
+
+// sheetTable builds the table, or with long set reuses one cached under
+// the sheets policy so recalculating spreadsheets don't trigger scrapes.
+func (s *StockScraper) sheetTable(symbols []string, long bool) ([][]interface{}, time.Duration, error) {
+	if !long {
+		quotes, err := s.CachedQuotes(symbols)
+		if err != nil {
+			return nil, 0, err
+		}
+		return sheetRows(symbols, quotes), sheetQuotesMaxAge, nil
+	}
+
+	cacheKey := s.region.CacheKey(cachekey.SheetQuotes.Key(strings.Join(symbols, ",")))
+	ttl := ttlFor(cacheKey, s.ttl)
+	if cached, err := s.cache.Get(s.ctx, cacheKey); err == nil && !s.fresh {
+		var rows [][]interface{}
+		if err := json.Unmarshal(cached, &rows); err == nil {
+			if remaining, err := s.cache.TTL(s.ctx, cacheKey); err == nil && remaining > 0 {
+				ttl = remaining
+			}
+			return rows, ttl, nil
+		}
+	}
+
+	quotes, err := s.CachedQuotes(symbols)
+	if err != nil {
+		return nil, 0, err
+	}
+	rows := sheetRows(symbols, quotes)
+	cacheIfChanged(s.ctx, s.cache, s.tracker, cacheKey, rows, s.ttl)
+	return rows, ttl, nil
+}
+
+// HandleSheetQuotes serves quotes as a bare table for IMPORTDATA and Apps
+// Script: CSV by default or a JSON array of rows with ?format=json, header
+// row first and no envelope. ?cache=long serves a table that is only
+// rebuilt once per sheets TTL.
+func HandleSheetQuotes(pool *queue.Pool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		symbols, err := parseSymbolList(c.Query("symbols"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		region, err := LookupRegion(c.Query("region"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		format := c.DefaultQuery("format", "csv")
+		if format != "csv" && format != "json" {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "format must be csv or json",
+			})
+			return
+		}
+
+		scraper := NewStockScraper(StockScraperOption{
+			Region:  region.Code,
+			Context: c.Request.Context(),
+			Pool:    pool,
+		})
+		defer scraper.Close()
+
+		rows, maxAge, err := scraper.sheetTable(symbols, c.Query("cache") == "long")
+		if shed(c, pool, err) {
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		c.Header("Cache-Control", fmt.Sprintf("public, max-age=%d", int(maxAge.Seconds())))
+		if format == "json" {
+			c.JSON(http.StatusOK, rows)
+			return
+		}
+
+		writer := stream.NewCSV(c, "quotes.csv")
+		for _, row := range rows {
+			record := make([]string, len(row))
+			for i, cell := range row {
+				record[i] = sheetCell(cell)
+			}
+			if err := writer.Write(record); err != nil {
+				return
+			}
+		}
+		writer.Close()
+	}
+}
@@ -0,0 +1,20 @@
+package scraper
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSheetRows(t *testing.T) {
+	rows := sheetRows([]string{"MSFT", "NOPE", "AAPL"}, []StockData{
+		{Symbol: "AAPL", Name: "Apple Inc.", Price: 1234567.5, Currency: "USD"},
+		{Symbol: "MSFT", Name: "Microsoft", Price: 410.25, Currency: "USD"},
+	})
+
+	assert.Len(t, rows, 4)
+	assert.Equal(t, "Symbol", rows[0][0])
+	assert.Equal(t, "MSFT", rows[1][0])
+	assert.Equal(t, []interface{}{"NOPE", "", "", "", "", "", "", ""}, rows[2])
+	assert.Equal(t, "1234567.5", sheetCell(rows[3][2]))
+}
@@ -0,0 +1,184 @@
+package scraper
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"go-webscraper/cachekey"
+	"go-webscraper/pkg/yahoo"
+	"go-webscraper/queue"
+
+	"github.com/gin-gonic/gin"
+)
+
+var mostShortedCacheKey = cachekey.MostShorted.Key()
+
+type ShortInterest = yahoo.ShortInterest
+
+func shortInterestCacheKey(symbol string) string {
+	return cachekey.ShortInterest.Key(symbol)
+}
+
+// ScrapeShortInterest reads the short interest block of symbol's
+// key-statistics page.
+func (s *StockScraper) ScrapeShortInterest(symbol string) (*ShortInterest, error) {
+	if !ValidSymbol(symbol) {
+		return nil, fmt.Errorf("invalid symbol: %s", symbol)
+	}
+	symbol = strings.ToUpper(symbol)
+
+	cacheKey := s.region.CacheKey(shortInterestCacheKey(symbol))
+	if cached, err := s.cache.Get(s.ctx, cacheKey); err == nil && !s.fresh {
+		var short ShortInterest
+		if err := json.Unmarshal(cached, &short); err == nil {
+			return &short, nil
+		}
+	}
+
+	release, err := acquire(s.ctx, s.pool, s.priority)
+	if err != nil {
+		var stale ShortInterest
+		if serveStale(s.ctx, s.cache, cacheKey, &stale, err) {
+			return &stale, nil
+		}
+		return nil, err
+	}
+	defer release()
+
+	ctx := yahoo.WithFetchLog(s.ctx)
+	short, err := s.yahoo.ShortInterest(ctx, symbol)
+	if err != nil || short == nil {
+		return nil, err
+	}
+
+	cacheIfChanged(ctx, s.cache, s.tracker, cacheKey, short, s.ttl)
+
+	return short, nil
+}
+
+// ScrapeMostShorted scrapes Yahoo's predefined most-shorted screener.
+func (s *StockScraper) ScrapeMostShorted() ([]StockData, error) {
+	cacheKey := s.region.CacheKey(mostShortedCacheKey)
+	if cached, err := s.cache.Get(s.ctx, cacheKey); err == nil && !s.fresh {
+		var stocks []StockData
+		if err := json.Unmarshal(cached, &stocks); err == nil {
+			return stocks, nil
+		}
+	}
+
+	release, err := acquire(s.ctx, s.pool, s.priority)
+	if err != nil {
+		var stale []StockData
+		if serveStale(s.ctx, s.cache, cacheKey, &stale, err) {
+			return stale, nil
+		}
+		return nil, err
+	}
+	defer release()
+
+	ctx := yahoo.WithFetchLog(s.ctx)
+	stocks, err := s.yahoo.MostShorted(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	s.screenAnomalies("most_shorted", stocks)
+	s.recordScrape("most_shorted", stocks)
+	cacheIfChanged(ctx, s.cache, s.tracker, cacheKey, stocks, s.ttl)
+
+	return stocks, nil
+}
+
+func HandleShortInterest(pool *queue.Pool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		symbol := c.Param("symbol")
+		if !ValidSymbol(symbol) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "invalid symbol",
+			})
+			return
+		}
+
+		region, err := LookupRegion(c.Query("region"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		scraper := NewStockScraper(StockScraperOption{
+			Region:  region.Code,
+			Context: c.Request.Context(),
+			Pool:    pool,
+		})
+		defer scraper.Close()
+
+		short, err := scraper.ScrapeShortInterest(symbol)
+		if shed(c, pool, err) {
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+		if short == nil {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "no short interest reported for " + strings.ToUpper(symbol),
+			})
+			return
+		}
+
+		cacheKey := region.CacheKey(shortInterestCacheKey(strings.ToUpper(symbol)))
+		setCacheControl(c, scraper.cache, cacheKey)
+		c.JSON(http.StatusOK, withProvenance(c, scraper.cache, cacheKey, gin.H{
+			"status": "success",
+			"data":   short,
+		}))
+	}
+}
+
+func HandleMostShorted(pool *queue.Pool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		region, err := LookupRegion(c.Query("region"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		scraper := NewStockScraper(StockScraperOption{
+			Region:  region.Code,
+			Context: c.Request.Context(),
+			Pool:    pool,
+		})
+		defer scraper.Close()
+
+		if notModifiedSince(c, c.Request.Context(), scraper.cache, scraper.tracker, region.CacheKey(mostShortedCacheKey)) {
+			return
+		}
+
+		stocks, err := scraper.ScrapeMostShorted()
+		if shed(c, pool, err) {
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		cacheKey := region.CacheKey(mostShortedCacheKey)
+		setCacheControl(c, scraper.cache, cacheKey)
+		c.JSON(http.StatusOK, withProvenance(c, scraper.cache, cacheKey, gin.H{
+			"status": "success",
+			"data":   stocks,
+		}))
+	}
+}
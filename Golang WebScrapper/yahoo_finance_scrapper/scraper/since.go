@@ -0,0 +1,55 @@
+package scraper
+
+import (
+	"fmt"
+	"time"
+	_ "time/tzdata" // America/New_York must resolve in minimal containers
+)
+
+// marketLocation is the exchange timezone "today" is measured in. Yahoo
+// stamps articles in UTC, so comparing calendar dates in server local time
+// drops or keeps early-morning articles depending on where we run.
+var marketLocation = mustLoadLocation("America/New_York")
+
+func mustLoadLocation(name string) *time.Location {
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		panic(fmt.Sprintf("failed to load timezone %s: %v", name, err))
+	}
+	return loc
+}
+
+// MarketMidnight returns the start of the current US market day (midnight
+// Eastern) for t.
+func MarketMidnight(t time.Time) time.Time {
+	t = t.In(marketLocation)
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, marketLocation)
+}
+
+// parseSince resolves the ?since= and ?recent= news parameters into a
+// cutoff. An explicit since wins; recent is sugar for since=<midnight ET>.
+// The zero time means no filtering.
+func parseSince(since string, recent bool, now time.Time) (time.Time, error) {
+	if since != "" {
+		t, err := time.Parse(time.RFC3339, since)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("since must be an RFC3339 timestamp")
+		}
+		return t, nil
+	}
+	if recent {
+		return MarketMidnight(now), nil
+	}
+	return time.Time{}, nil
+}
+
+// MarketOpen reports whether t falls in the regular US equity session,
+// 9:30 to 16:00 Eastern on weekdays. Exchange holidays are not excluded.
+func MarketOpen(t time.Time) bool {
+	t = t.In(marketLocation)
+	if t.Weekday() == time.Saturday || t.Weekday() == time.Sunday {
+		return false
+	}
+	minutes := t.Hour()*60 + t.Minute()
+	return minutes >= 9*60+30 && minutes < 16*60
+}
@@ -0,0 +1,48 @@
+package scraper
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMarketMidnight(t *testing.T) {
+	// 03:30 UTC on the 5th is still the evening of the 4th in New York
+	now := time.Date(2024, 3, 5, 3, 30, 0, 0, time.UTC)
+	midnight := MarketMidnight(now)
+
+	assert.Equal(t, time.Date(2024, 3, 4, 5, 0, 0, 0, time.UTC), midnight.UTC())
+}
+
+func TestParseSince(t *testing.T) {
+	now := time.Date(2024, 7, 10, 14, 0, 0, 0, time.UTC)
+
+	since, err := parseSince("", false, now)
+	assert.NoError(t, err)
+	assert.True(t, since.IsZero())
+
+	since, err = parseSince("", true, now)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 7, 10, 4, 0, 0, 0, time.UTC), since.UTC())
+
+	since, err = parseSince("2024-07-09T12:00:00Z", true, now)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 7, 9, 12, 0, 0, 0, time.UTC), since)
+
+	_, err = parseSince("yesterday", false, now)
+	assert.Error(t, err)
+}
+
+func TestMarketOpen(t *testing.T) {
+	et := func(day, hour, minute int) time.Time {
+		// November 2024: the 4th is a Monday
+		return time.Date(2024, 11, day, hour, minute, 0, 0, marketLocation)
+	}
+
+	assert.False(t, MarketOpen(et(4, 9, 29)))
+	assert.True(t, MarketOpen(et(4, 9, 30)))
+	assert.True(t, MarketOpen(et(4, 15, 59)))
+	assert.False(t, MarketOpen(et(4, 16, 0)))
+	assert.False(t, MarketOpen(et(9, 12, 0)), "Saturday")
+}
@@ -0,0 +1,258 @@
+package scraper
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-webscraper/queue"
+	"go-webscraper/upstream"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	DefaultSparklinePoints = 30
+	MaxSparklinePoints     = 200
+)
+
+var chartURL = "https://query1.finance.yahoo.com/v8/finance/chart/"
+
+// Sparkline is a compact series of recent prices for list views. Prices
+// run oldest to newest between From and To.
+type Sparkline struct {
+	Symbol string    `json:"symbol"`
+	Source string    `json:"source"`
+	From   string    `json:"from"`
+	To     string    `json:"to"`
+	Prices []float64 `json:"prices"`
+}
+
+type pricePoint struct {
+	At    time.Time
+	Price float64
+}
+
+func intradayKey(symbol string) string {
+	return fmt.Sprintf("intraday:%s", strings.ToUpper(symbol))
+}
+
+// recordIntraday appends each stock's price to its intraday series so
+// sparklines can be served without another scrape, and its volume to the
+// day's volume snapshots. Points from before the current market day are
+// dropped.
+func recordIntraday(ctx context.Context, rdb *redis.Client, stocks []StockData) {
+	now := time.Now()
+	cutoff := strconv.FormatInt(MarketMidnight(now).Unix(), 10)
+
+	pipe := rdb.Pipeline()
+	for _, stock := range stocks {
+		if stock.Symbol == "" || stock.Price == 0 || stock.Suspect {
+			continue
+		}
+		key := intradayKey(stock.Symbol)
+		pipe.ZAdd(ctx, key, redis.Z{
+			Score:  float64(now.Unix()),
+			Member: fmt.Sprintf("%d:%s", now.Unix(), strconv.FormatFloat(stock.Price, 'f', -1, 64)),
+		})
+		pipe.ZRemRangeByScore(ctx, key, "-inf", "("+cutoff)
+		pipe.Expire(ctx, key, 24*time.Hour)
+		recordVolume(ctx, pipe, stock, now)
+	}
+	pipe.Exec(ctx)
+}
+
+func (s *StockScraper) intradayPoints(symbol string) ([]pricePoint, error) {
+	members, err := s.redis.ZRangeByScore(s.ctx, intradayKey(symbol), &redis.ZRangeBy{
+		Min: strconv.FormatInt(MarketMidnight(time.Now()).Unix(), 10),
+		Max: "+inf",
+	}).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	points := make([]pricePoint, 0, len(members))
+	for _, member := range members {
+		ts, price, found := strings.Cut(member, ":")
+		if !found {
+			continue
+		}
+		unix, err := strconv.ParseInt(ts, 10, 64)
+		if err != nil {
+			continue
+		}
+		value, err := strconv.ParseFloat(price, 64)
+		if err != nil {
+			continue
+		}
+		points = append(points, pricePoint{At: time.Unix(unix, 0), Price: value})
+	}
+	return points, nil
+}
+
+type chartResponse struct {
+	Chart struct {
+		Result []struct {
+			Timestamp  []int64 `json:"timestamp"`
+			Indicators struct {
+				Quote []struct {
+					Close []*float64 `json:"close"`
+				} `json:"quote"`
+			} `json:"indicators"`
+		} `json:"result"`
+		Error *struct {
+			Description string `json:"description"`
+		} `json:"error"`
+	} `json:"chart"`
+}
+
+// chartPoints fetches today's 5-minute closes from Yahoo's chart endpoint,
+// used when no intraday snapshots have been recorded for the symbol.
+func (s *StockScraper) chartPoints(symbol string) ([]pricePoint, error) {
+	release, err := acquire(s.ctx, s.pool, s.priority)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	req, err := http.NewRequestWithContext(s.ctx, http.MethodGet,
+		chartURL+url.PathEscape(strings.ToUpper(symbol))+"?range=1d&interval=5m", nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", upstream.DefaultUserAgent)
+
+	resp, err := (&http.Client{Transport: upstream.Transport(), Timeout: 15 * time.Second}).Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch chart for %s: %v", symbol, err)
+	}
+	defer resp.Body.Close()
+
+	var chart chartResponse
+	if err := json.NewDecoder(resp.Body).Decode(&chart); err != nil {
+		return nil, fmt.Errorf("failed to decode chart for %s: %v", symbol, err)
+	}
+	if chart.Chart.Error != nil {
+		return nil, fmt.Errorf("chart for %s: %s", symbol, chart.Chart.Error.Description)
+	}
+	if len(chart.Chart.Result) == 0 || len(chart.Chart.Result[0].Indicators.Quote) == 0 {
+		return nil, nil
+	}
+
+	result := chart.Chart.Result[0]
+	closes := result.Indicators.Quote[0].Close
+	points := make([]pricePoint, 0, len(result.Timestamp))
+	for i, ts := range result.Timestamp {
+		// Yahoo leaves gaps as null closes
+		if i >= len(closes) || closes[i] == nil {
+			continue
+		}
+		points = append(points, pricePoint{At: time.Unix(ts, 0), Price: *closes[i]})
+	}
+	return points, nil
+}
+
+// downsample picks n evenly spaced points, always keeping the first and
+// the latest so the line ends on the current price.
+func downsample(points []pricePoint, n int) []pricePoint {
+	if len(points) <= n || n < 2 {
+		return points
+	}
+	sampled := make([]pricePoint, n)
+	step := float64(len(points)-1) / float64(n-1)
+	for i := range sampled {
+		sampled[i] = points[int(float64(i)*step+0.5)]
+	}
+	return sampled
+}
+
+// Sparkline returns up to points recent prices for symbol, preferring the
+// intraday snapshots recorded by stock scrapes and falling back to Yahoo's
+// chart endpoint when fewer than two are available.
+func (s *StockScraper) Sparkline(symbol string, points int) (*Sparkline, error) {
+	if !ValidSymbol(symbol) {
+		return nil, fmt.Errorf("invalid symbol: %s", symbol)
+	}
+
+	source := "snapshots"
+	series, err := s.intradayPoints(symbol)
+	if err != nil || len(series) < 2 {
+		source = "chart"
+		if series, err = s.chartPoints(symbol); err != nil {
+			return nil, err
+		}
+	}
+
+	spark := &Sparkline{
+		Symbol: strings.ToUpper(symbol),
+		Source: source,
+		Prices: make([]float64, 0, points),
+	}
+	series = downsample(series, points)
+	for _, point := range series {
+		spark.Prices = append(spark.Prices, point.Price)
+	}
+	if len(series) > 0 {
+		spark.From = series[0].At.Format(time.RFC3339)
+		spark.To = series[len(series)-1].At.Format(time.RFC3339)
+	}
+	return spark, nil
+}
+
+func HandleSparkline(pool *queue.Pool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		symbol := c.Param("symbol")
+		if !ValidSymbol(symbol) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"status": "error",
+				"error":  "invalid symbol",
+			})
+			return
+		}
+
+		points, err := strconv.Atoi(c.DefaultQuery("points", strconv.Itoa(DefaultSparklinePoints)))
+		if err != nil || points < 2 || points > MaxSparklinePoints {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"status": "error",
+				"error":  fmt.Sprintf("points must be between 2 and %d", MaxSparklinePoints),
+			})
+			return
+		}
+
+		s := NewStockScraper(StockScraperOption{
+			Context: c.Request.Context(),
+			Pool:    pool,
+		})
+		defer s.Close()
+
+		spark, err := s.Sparkline(symbol, points)
+		if shed(c, pool, err) {
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"status": "error",
+				"error":  err.Error(),
+			})
+			return
+		}
+		if len(spark.Prices) == 0 {
+			c.JSON(http.StatusNotFound, gin.H{
+				"status": "error",
+				"error":  "no intraday prices for " + spark.Symbol,
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"status": "success",
+			"data":   spark,
+		})
+	}
+}
@@ -0,0 +1,46 @@
+package scraper
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDownsample(t *testing.T) {
+	points := make([]pricePoint, 100)
+	for i := range points {
+		points[i] = pricePoint{At: time.Unix(int64(i), 0), Price: float64(i)}
+	}
+
+	sampled := downsample(points, 5)
+	assert.Len(t, sampled, 5)
+	assert.Equal(t, 0.0, sampled[0].Price)
+	assert.Equal(t, 99.0, sampled[4].Price)
+
+	assert.Len(t, downsample(points[:3], 30), 3)
+}
+
+func TestChartPoints(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/AAPL", r.URL.Path)
+		w.Write([]byte(`{"chart":{"result":[{"timestamp":[1700000000,1700000300,1700000600],
+			"indicators":{"quote":[{"close":[189.5,null,190.25]}]}}],"error":null}}`))
+	}))
+	defer server.Close()
+
+	original := chartURL
+	chartURL = server.URL + "/"
+	defer func() { chartURL = original }()
+
+	s := &StockScraper{ctx: context.Background()}
+	points, err := s.chartPoints("aapl")
+	assert.NoError(t, err)
+	assert.Equal(t, []pricePoint{
+		{At: time.Unix(1700000000, 0), Price: 189.5},
+		{At: time.Unix(1700000600, 0), Price: 190.25},
+	}, points)
+}
@@ -2,7 +2,6 @@ package scraper
 
 import (
 	"context"
-	"encoding/csv"
 	"encoding/json"
 	"fmt"
 	"log"
@@ -13,52 +12,104 @@ import (
 	"sync"
 	"time"
 
+	"go-webscraper/cache"
+	"go-webscraper/cachekey"
+	"go-webscraper/changes"
+	"go-webscraper/keyspace"
+	"go-webscraper/model"
+	"go-webscraper/pkg/yahoo"
+	"go-webscraper/queue"
+	"go-webscraper/stream"
+
 	"github.com/gin-gonic/gin"
 	"github.com/gocolly/colly"
 	"github.com/redis/go-redis/v9"
 )
 
-type StockData struct {
-	Symbol     string  `json:"symbol"`
-	Name       string  `json:"name"`
-	Price      float64 `json:"price"`
-	Change     float64 `json:"change"`
-	ChangePerc float64 `json:"change_percentage"`
-	Volume     int64   `json:"volume"`
-	MarketCap  string  `json:"market_cap"`
-	Timestamp  string  `json:"timestamp"`
-}
+type StockData = model.StockData
 
 type StockScraper struct {
 	redis     *redis.Client
+	cache     cache.Cache
 	ctx       context.Context
 	ttl       time.Duration
 	mutex     sync.Mutex
 	collector *colly.Collector
+	yahoo     *yahoo.Client
 	outputDir string
+	region    Region
+	tracker   *changes.Tracker
+	pool      *queue.Pool
+	priority  queue.Priority
+	fresh     bool
+}
+
+var (
+	mostActiveCacheKey     = cachekey.MostActive.Key()
+	marketOverviewCacheKey = cachekey.MarketOverview.Key()
+)
+
+// moverCacheKeys are the /api/stock categories that list one Yahoo market
+// movers page, each cached on its own.
+var moverCacheKeys = map[string]string{
+	"most_active": mostActiveCacheKey,
+	"gainers":     cachekey.Gainers.Key(),
+	"losers":      cachekey.Losers.Key(),
+	"trending":    cachekey.Trending.Key(),
+}
+
+// IsMoverCategory reports whether ScrapeMovers accepts category.
+func IsMoverCategory(category string) bool {
+	_, ok := moverCacheKeys[category]
+	return ok
 }
 
 type StockScraperOption struct {
+	// CacheTTL overrides the per-source TTL policy in cachekey when set.
 	CacheTTL      time.Duration
 	RedisAddr     string
 	RedisPassword string
 	RedisDB       int
 	NumThread     int
 	OutputDir     string
+	Region        string
+	Context       context.Context
+	Pool          *queue.Pool
+	Priority      queue.Priority
 }
 
-func NewStockScraper(opts StockScraperOption) *StockScraper {
-	if opts.CacheTTL == 0 {
-		opts.CacheTTL = 1 * time.Hour
-	}
-	if opts.RedisAddr == "" {
-		opts.RedisAddr = "localhost:6379"
+var outputDir = struct {
+	mu  sync.RWMutex
+	dir string
+}{dir: "stock_data"}
+
+// ConfigureOutputDir sets the directory stock scrapers write to when
+// their option leaves OutputDir empty.
+func ConfigureOutputDir(dir string) error {
+	if strings.TrimSpace(dir) == "" {
+		return fmt.Errorf("output directory must not be empty")
 	}
+
+	outputDir.mu.Lock()
+	defer outputDir.mu.Unlock()
+	outputDir.dir = dir
+	return nil
+}
+
+func NewStockScraper(opts StockScraperOption) *StockScraper {
 	if opts.NumThread == 0 {
 		opts.NumThread = 20
 	}
 	if opts.OutputDir == "" {
-		opts.OutputDir = "stock_data"
+		outputDir.mu.RLock()
+		opts.OutputDir = outputDir.dir
+		outputDir.mu.RUnlock()
+	}
+
+	region, err := LookupRegion(opts.Region)
+	if err != nil {
+		log.Printf("%v, falling back to %s", err, DefaultRegion)
+		region = Regions[DefaultRegion]
 	}
 
 	// Ensure output directory exists
@@ -66,176 +117,112 @@ func NewStockScraper(opts StockScraperOption) *StockScraper {
 		log.Fatalf("Failed to create output directory: %v", err)
 	}
 
-	rdb := redis.NewClient(&redis.Options{
-		Addr:     opts.RedisAddr,
-		Password: opts.RedisPassword,
-		DB:       opts.RedisDB,
-	})
-
-	c := colly.NewCollector(
-		colly.UserAgent("Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/91.0.4472.124 Safari/537.36"),
-		colly.AllowedDomains("finance.yahoo.com"),
-		colly.MaxDepth(1),
-		colly.Async(true),
-	)
+	rdb := newRedis(opts.RedisAddr, opts.RedisPassword, opts.RedisDB)
+	traceRedis(rdb)
+	keyspace.Instrument(rdb)
 
-	c.Limit(&colly.LimitRule{
-		DomainGlob:  "*",
+	client, err := yahoo.New(yahoo.Option{
+		Region:      region.Code,
 		Parallelism: opts.NumThread,
 		Delay:       200 * time.Millisecond,
 	})
+	if err != nil {
+		log.Fatalf("Failed to create Yahoo client: %v", err)
+	}
 
 	return &StockScraper{
 		redis:     rdb,
-		ctx:       context.Background(),
+		cache:     cache.New(rdb),
+		tracker:   changes.NewTracker(rdb),
+		ctx:       optionContext(opts.Context),
 		ttl:       opts.CacheTTL,
 		mutex:     sync.Mutex{},
-		collector: c,
+		collector: client.Collector(),
+		yahoo:     client,
 		outputDir: opts.OutputDir,
+		region:    region,
+		pool:      opts.Pool,
+		priority:  opts.Priority,
 	}
 }
 
 func (s *StockScraper) ScrapeMostActive() ([]StockData, error) {
-	var stocks []StockData
-	var mu sync.Mutex
+	return s.ScrapeMovers("most_active")
+}
 
-	cacheKey := "most_active_stocks"
-	if cached, err := s.redis.Get(s.ctx, cacheKey).Result(); err == nil {
+// ScrapeMovers scrapes one market movers category: most_active, gainers,
+// losers or trending.
+func (s *StockScraper) ScrapeMovers(category string) ([]StockData, error) {
+	key, ok := moverCacheKeys[category]
+	if !ok {
+		return nil, fmt.Errorf("unknown movers category: %s", category)
+	}
+	cacheKey := s.region.CacheKey(key)
+	if cached, err := s.cache.Get(s.ctx, cacheKey); err == nil && !s.fresh {
 		var cachedStocks []StockData
-		if err := json.Unmarshal([]byte(cached), &cachedStocks); err == nil {
+		if err := json.Unmarshal(cached, &cachedStocks); err == nil {
 			return cachedStocks, nil
 		}
 	}
 
-	c := s.collector.Clone()
-
-	c.OnHTML("table[data-test='most-actives'] tbody tr", func(e *colly.HTMLElement) {
-		stock := StockData{
-			Symbol:    strings.TrimSpace(e.ChildText("td:nth-child(1)")),
-			Name:      strings.TrimSpace(e.ChildText("td:nth-child(2)")),
-			Timestamp: time.Now().Format(time.RFC3339),
-		}
-
-		priceStr := strings.TrimSpace(e.ChildText("td:nth-child(3) fin-streamer"))
-		price, err := strconv.ParseFloat(strings.ReplaceAll(priceStr, ",", ""), 64)
-		if err == nil {
-			stock.Price = price
-		}
-
-		changeStr := strings.TrimSpace(e.ChildText("td:nth-child(4) fin-streamer"))
-		change, err := strconv.ParseFloat(strings.ReplaceAll(changeStr, ",", ""), 64)
-		if err == nil {
-			stock.Change = change
-		}
-
-		changePercStr := strings.TrimSpace(e.ChildText("td:nth-child(5) fin-streamer"))
-		changePercStr = strings.Trim(changePercStr, "()%")
-		changePerc, err := strconv.ParseFloat(changePercStr, 64)
-		if err == nil {
-			stock.ChangePerc = changePerc
-		}
-
-		volumeStr := strings.TrimSpace(e.ChildText("td:nth-child(6) fin-streamer"))
-		volumeStr = strings.ReplaceAll(volumeStr, ",", "")
-		volume, err := strconv.ParseInt(volumeStr, 10, 64)
-		if err == nil {
-			stock.Volume = volume
-		}
-
-		marketCapStr := strings.TrimSpace(e.ChildText("td:nth-child(7) fin-streamer"))
-		if marketCapStr != "" {
-			stock.MarketCap = marketCapStr
+	release, err := acquire(s.ctx, s.pool, s.priority)
+	if err != nil {
+		var stale []StockData
+		if serveStale(s.ctx, s.cache, cacheKey, &stale, err) {
+			return stale, nil
 		}
+		return nil, err
+	}
+	defer release()
 
-		mu.Lock()
-		stocks = append(stocks, stock)
-		mu.Unlock()
-	})
-
-	err := c.Visit("https://finance.yahoo.com/most-active")
+	ctx := yahoo.WithFetchLog(s.ctx)
+	stocks, err := s.yahoo.Movers(ctx, category)
 	if err != nil {
-		return nil, fmt.Errorf("failed to scrape most active stocks: %v", err)
+		return nil, fmt.Errorf("failed to scrape %s stocks: %v", strings.ReplaceAll(category, "_", " "), err)
 	}
 
-	c.Wait()
-
-	if jsonData, err := json.Marshal(stocks); err == nil {
-		s.redis.Set(s.ctx, cacheKey, jsonData, s.ttl)
-	}
+	s.screenAnomalies(category, stocks)
+	s.recordScrape(category, stocks)
+	cacheIfChanged(ctx, s.cache, s.tracker, cacheKey, stocks, s.ttl)
+	recordIntraday(s.ctx, s.redis, stocks)
 
 	return stocks, nil
 }
 
+// ScrapeMarketOverview gathers the most active, gainers and losers lists,
+// reusing whichever are cached on their own.
 func (s *StockScraper) ScrapeMarketOverview() (map[string][]StockData, error) {
 	result := make(map[string][]StockData)
 	var mu sync.Mutex
 
-	cacheKey := "market_overview"
-	if cached, err := s.redis.Get(s.ctx, cacheKey).Result(); err == nil {
+	cacheKey := s.region.CacheKey(marketOverviewCacheKey)
+	if cached, err := s.cache.Get(s.ctx, cacheKey); err == nil {
 		var cachedResult map[string][]StockData
-		if err := json.Unmarshal([]byte(cached), &cachedResult); err == nil {
+		if err := json.Unmarshal(cached, &cachedResult); err == nil {
 			return cachedResult, nil
 		}
 	}
 
-	categories := map[string]string{
-		"most_active": "most-actives",
-		"gainers":     "gainers",
-		"losers":      "losers",
-	}
+	categories := []string{"most_active", "gainers", "losers"}
 
 	var wg sync.WaitGroup
 	errChan := make(chan error, len(categories))
 
-	for category, selector := range categories {
+	for _, category := range categories {
 		wg.Add(1)
-		go func(cat, sel string) {
+		go func(cat string) {
 			defer wg.Done()
 
-			c := s.collector.Clone()
-			var stocks []StockData
-
-			c.OnHTML(fmt.Sprintf("table[data-test='%s'] tbody tr", sel), func(e *colly.HTMLElement) {
-				stock := StockData{
-					Symbol:    strings.TrimSpace(e.ChildText("td:nth-child(1)")),
-					Name:      strings.TrimSpace(e.ChildText("td:nth-child(2)")),
-					Timestamp: time.Now().Format(time.RFC3339),
-				}
-
-				priceStr := strings.TrimSpace(e.ChildText("td:nth-child(3) fin-streamer"))
-				price, err := strconv.ParseFloat(strings.ReplaceAll(priceStr, ",", ""), 64)
-				if err == nil {
-					stock.Price = price
-				}
-
-				changeStr := strings.TrimSpace(e.ChildText("td:nth-child(4) fin-streamer"))
-				change, err := strconv.ParseFloat(strings.ReplaceAll(changeStr, ",", ""), 64)
-				if err == nil {
-					stock.Change = change
-				}
-
-				changePercStr := strings.TrimSpace(e.ChildText("td:nth-child(5) fin-streamer"))
-				changePercStr = strings.Trim(changePercStr, "()%")
-				changePerc, err := strconv.ParseFloat(changePercStr, 64)
-				if err == nil {
-					stock.ChangePerc = changePerc
-				}
-
-				mu.Lock()
-				stocks = append(stocks, stock)
-				mu.Unlock()
-			})
-
-			url := fmt.Sprintf("https://finance.yahoo.com/%s", cat)
-			if err := c.Visit(url); err != nil {
-				errChan <- fmt.Errorf("failed to scrape %s: %v", cat, err)
+			stocks, err := s.ScrapeMovers(cat)
+			if err != nil {
+				errChan <- err
 				return
 			}
 
 			mu.Lock()
 			result[cat] = stocks
 			mu.Unlock()
-		}(category, selector)
+		}(category)
 	}
 
 	wg.Wait()
@@ -246,9 +233,7 @@ func (s *StockScraper) ScrapeMarketOverview() (map[string][]StockData, error) {
 		}
 	}
 
-	if jsonData, err := json.Marshal(result); err == nil {
-		s.redis.Set(s.ctx, cacheKey, jsonData, s.ttl)
-	}
+	cacheIfChanged(s.ctx, s.cache, s.tracker, cacheKey, result, s.ttl)
 
 	return result, nil
 }
@@ -257,8 +242,13 @@ func (s *StockScraper) Close() {
 	s.redis.Close()
 }
 
-func writeStockRecord(writer *csv.Writer, stock StockData, category string) error {
-	record := []string{
+var StockCSVHeaders = []string{
+	"Symbol", "Name", "Price", "Change", "Change%",
+	"Volume", "Market Cap", "Timestamp", "Category", "Currency",
+}
+
+func StockRecord(stock StockData, category string) []string {
+	return []string{
 		stock.Symbol,
 		stock.Name,
 		strconv.FormatFloat(stock.Price, 'f', 2, 64),
@@ -268,43 +258,29 @@ func writeStockRecord(writer *csv.Writer, stock StockData, category string) erro
 		stock.MarketCap,
 		stock.Timestamp,
 		category,
+		stock.Currency,
 	}
-
-	if err := writer.Write(record); err != nil {
-		return fmt.Errorf("failed to write stock record: %v", err)
-	}
-
-	return nil
 }
 
 func (s *StockScraper) writeToCSV(c *gin.Context, data interface{}) error {
 	timestamp := time.Now().Format("20060102_150405")
-	filename := fmt.Sprintf("stock_data_%s.csv", timestamp)
-	c.Header("Content-Type", "text/csv")
-	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
-
-	writer := csv.NewWriter(c.Writer)
-	defer writer.Flush()
+	writer := stream.NewCSV(c, fmt.Sprintf("stock_data_%s.csv", timestamp))
 
-	headers := []string{
-		"Symbol", "Name", "Price", "Change", "Change%",
-		"Volume", "Market Cap", "Timestamp", "Category",
-	}
-	if err := writer.Write(headers); err != nil {
-		return fmt.Errorf("failed to write CSV headers: %v", err)
+	if err := writer.Write(StockCSVHeaders); err != nil {
+		return err
 	}
 
 	switch v := data.(type) {
 	case []StockData:
 		for _, stock := range v {
-			if err := writeStockRecord(writer, stock, ""); err != nil {
+			if err := writer.Write(StockRecord(stock, "")); err != nil {
 				return err
 			}
 		}
 	case map[string][]StockData:
 		for category, stocks := range v {
 			for _, stock := range stocks {
-				if err := writeStockRecord(writer, stock, category); err != nil {
+				if err := writer.Write(StockRecord(stock, category)); err != nil {
 					return err
 				}
 			}
@@ -313,52 +289,88 @@ func (s *StockScraper) writeToCSV(c *gin.Context, data interface{}) error {
 		return fmt.Errorf("unsupported data type for CSV conversion")
 	}
 
-	return nil
+	return writer.Close()
 }
 
-func HandleStock(c *gin.Context) {
-	scraper := NewStockScraper(StockScraperOption{
-		CacheTTL:  1 * time.Hour,
-		RedisAddr: "localhost:6379",
-	})
-	defer scraper.Close()
+func HandleStock(pool *queue.Pool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		region, err := LookupRegion(c.Query("region"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
 
-	category := c.DefaultQuery("category", "most_active")
-	format := c.DefaultQuery("format", "json")
+		scraper := NewStockScraper(StockScraperOption{
+			Region:  region.Code,
+			Context: c.Request.Context(),
+			Pool:    pool,
+		})
+		defer scraper.Close()
 
-	var data interface{}
-	var err error
+		category := c.DefaultQuery("category", "most_active")
+		format := c.DefaultQuery("format", "json")
 
-	switch category {
-	case "most_active":
-		data, err = scraper.ScrapeMostActive()
-	case "overview":
-		data, err = scraper.ScrapeMarketOverview()
-	default:
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "invalid category",
-		})
-		return
-	}
+		var data interface{}
+		var cacheKey string
 
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": err.Error(),
-		})
-		return
-	}
+		switch category {
+		case "most_active", "gainers", "losers", "trending":
+			cacheKey = region.CacheKey(moverCacheKeys[category])
+			if notModifiedSince(c, scraper.ctx, scraper.cache, scraper.tracker, cacheKey) {
+				return
+			}
+			data, err = scraper.ScrapeMovers(category)
+		case "overview":
+			cacheKey = region.CacheKey(marketOverviewCacheKey)
+			if notModifiedSince(c, scraper.ctx, scraper.cache, scraper.tracker, cacheKey) {
+				return
+			}
+			data, err = scraper.ScrapeMarketOverview()
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "invalid category",
+			})
+			return
+		}
 
-	if format == "csv" {
-		if err := scraper.writeToCSV(c, data); err != nil {
+		if shed(c, pool, err) {
+			return
+		}
+		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{
-				"error": fmt.Sprintf("failed to generate CSV: %v", err),
+				"error": err.Error(),
 			})
+			return
 		}
-		return
-	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"status": "success",
-		"data":   data,
-	})
+		data, rates, err := applyCurrency(c, region.Currency, data)
+		if err != nil {
+			c.JSON(currencyStatus(err), gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		if format == "csv" {
+			if err := scraper.writeToCSV(c, data); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{
+					"error": fmt.Sprintf("failed to generate CSV: %v", err),
+				})
+			}
+			return
+		}
+
+		response := gin.H{
+			"status": "success",
+			"region": region.Code,
+			"data":   data,
+		}
+		if rates != nil {
+			response["fx"] = rates
+		}
+		setCacheControl(c, scraper.cache, cacheKey)
+		c.JSON(http.StatusOK, withProvenance(c, scraper.cache, cacheKey, response))
+	}
 }
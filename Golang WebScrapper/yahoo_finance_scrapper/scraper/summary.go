@@ -0,0 +1,66 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+)
+
+// Summarizer condenses an article's text, as the summarize package's
+// summarizers do.
+type Summarizer interface {
+	Summarize(ctx context.Context, title, text string, sentences int) (string, error)
+}
+
+var summaries = struct {
+	mu         sync.RWMutex
+	summarizer Summarizer
+	sentences  int
+}{sentences: 3}
+
+// ConfigureSummarizer has articles summarized in up to sentences
+// sentences by the scheduled news refresh. A nil summarizer leaves them
+// without a summary.
+func ConfigureSummarizer(summarizer Summarizer, sentences int) error {
+	if sentences < 1 || sentences > 5 {
+		return fmt.Errorf("summary sentences must be between 1 and 5")
+	}
+
+	summaries.mu.Lock()
+	defer summaries.mu.Unlock()
+	summaries.summarizer = summarizer
+	summaries.sentences = sentences
+	return nil
+}
+
+// Summarize fills in the summaries articles lack and caches the articles
+// again with them, so requests that come across the articles later serve
+// the summary from the cache. Summarizers can be slow and paid for, so
+// only the scheduled news refresh calls this; request scrapes never
+// summarize.
+func (s *Scraper) Summarize(articles []Article) {
+	summaries.mu.RLock()
+	summarizer, sentences := summaries.summarizer, summaries.sentences
+	summaries.mu.RUnlock()
+	if summarizer == nil {
+		return
+	}
+
+	for i := range articles {
+		article := &articles[i]
+		if article.Summary != "" || article.Snippet == "" {
+			continue
+		}
+		if s.ctx.Err() != nil {
+			return
+		}
+		summary, err := summarizer.Summarize(s.ctx, article.Title, article.Snippet, sentences)
+		if err != nil {
+			log.Printf("Error summarizing %q: %v", article.Title, err)
+			continue
+		}
+		article.Summary = summary
+		s.cacheArticle(article.Link, *article, ExcludeFromCache)
+	}
+}
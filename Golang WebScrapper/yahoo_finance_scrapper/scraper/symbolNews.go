@@ -0,0 +1,100 @@
+package scraper
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"go-webscraper/pkg/yahoo"
+	"go-webscraper/queue"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ValidSymbol reports whether symbol looks like a Yahoo ticker.
+func ValidSymbol(symbol string) bool {
+	return yahoo.ValidSymbol(symbol)
+}
+
+// ScrapeSymbolNews crawls the symbol's own news tab instead of the global
+// feed, following up to limit story links. Articles share the URL-keyed
+// cache used by ScrapeNews, so a story seen by either is only fetched once.
+func (s *Scraper) ScrapeSymbolNews(symbol string, limit int) ([]Article, error) {
+	if !ValidSymbol(symbol) {
+		return nil, fmt.Errorf("invalid symbol: %s", symbol)
+	}
+
+	release, err := acquire(s.ctx, s.pool, s.priority)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	return s.yahoo.SymbolNews(s.ctx, symbol, limit, articleCache{s})
+}
+
+func HandleSymbolNews(pool *queue.Pool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		symbol := c.Param("symbol")
+		if !ValidSymbol(symbol) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"status": "error",
+				"error":  "invalid symbol",
+			})
+			return
+		}
+
+		region, err := LookupRegion(c.Query("region"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"status": "error",
+				"error":  err.Error(),
+			})
+			return
+		}
+
+		types, ok := parseArticleTypes(c.Query("type"))
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"status": "error",
+				"error":  "type must be a comma-separated list of: " + strings.Join(ArticleTypes, ", "),
+			})
+			return
+		}
+
+		limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
+		if err != nil || limit <= 0 || limit > 50 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"status": "error",
+				"error":  "limit must be between 1 and 50",
+			})
+			return
+		}
+
+		s := NewScraper(ScraperOption{
+			Region:  region.Code,
+			Context: c.Request.Context(),
+			Pool:    pool,
+		})
+		defer s.Close()
+
+		articles, err := s.ScrapeSymbolNews(symbol, limit)
+		if shed(c, pool, err) {
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"status": "error",
+				"error":  err.Error(),
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"status": "success",
+			"symbol": strings.ToUpper(symbol),
+			"data":   filterArticlesByByline(filterArticlesByType(articles, types), splitList(c.Query("publisher")), splitList(c.Query("author"))),
+		})
+	}
+}
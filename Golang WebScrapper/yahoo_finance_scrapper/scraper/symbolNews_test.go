@@ -0,0 +1,139 @@
+package scraper
+
+import (
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"go-webscraper/upstream"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingTransport serves fixture pages and remembers every URL asked for.
+type recordingTransport struct {
+	pages fixtureTransport
+	mu    sync.Mutex
+	urls  []string
+}
+
+func (t *recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.mu.Lock()
+	t.urls = append(t.urls, req.URL.String())
+	t.mu.Unlock()
+	return t.pages.RoundTrip(req)
+}
+
+func (t *recordingTransport) requested() []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]string(nil), t.urls...)
+}
+
+func scrapeSymbolNewsFixture(t *testing.T, redisAddr, region, symbol string, limit int) ([]Article, []string) {
+	t.Helper()
+	transport := &recordingTransport{pages: fixtureTransport{
+		"/quote/AAPL/news/":                "symbol_news.html",
+		"/news/fed-holds-rates.html":       "news_article.html",
+		"/news/apple-supplier-orders.html": "news_article.html",
+	}}
+	restore := upstream.UseTransport(transport)
+	defer restore()
+
+	s := NewScraper(ScraperOption{RedisAddr: redisAddr, Region: region})
+	defer s.Close()
+	articles, err := s.ScrapeSymbolNews(symbol, limit)
+	require.NoError(t, err)
+	return articles, transport.requested()
+}
+
+func TestScrapeSymbolNewsURLs(t *testing.T) {
+	t.Run("Listing Page", func(t *testing.T) {
+		mr := miniredis.RunT(t)
+		articles, urls := scrapeSymbolNewsFixture(t, mr.Addr(), "", "aapl", 10)
+
+		// Story links are followed once; the listing's own pages and
+		// non-news links are not
+		assert.Equal(t, "https://finance.yahoo.com/quote/AAPL/news/", urls[0])
+		assert.ElementsMatch(t, []string{
+			"https://finance.yahoo.com/quote/AAPL/news/",
+			"https://finance.yahoo.com/news/fed-holds-rates.html",
+			"https://finance.yahoo.com/news/apple-supplier-orders.html",
+		}, urls)
+		require.Len(t, articles, 2)
+	})
+
+	t.Run("Region Host", func(t *testing.T) {
+		mr := miniredis.RunT(t)
+		_, urls := scrapeSymbolNewsFixture(t, mr.Addr(), "uk", "AAPL", 10)
+		assert.Equal(t, "https://uk.finance.yahoo.com/quote/AAPL/news/", urls[0])
+		assert.Contains(t, urls, "https://uk.finance.yahoo.com/news/fed-holds-rates.html")
+	})
+
+	t.Run("Limit", func(t *testing.T) {
+		mr := miniredis.RunT(t)
+		articles, urls := scrapeSymbolNewsFixture(t, mr.Addr(), "", "AAPL", 1)
+		assert.Len(t, urls, 2)
+		assert.Len(t, articles, 1)
+	})
+
+	t.Run("Invalid Symbol", func(t *testing.T) {
+		s := NewScraper(ScraperOption{RedisAddr: miniredis.RunT(t).Addr()})
+		defer s.Close()
+		for _, symbol := range []string{"", "AAPL/../../admin", "TOOLONGSYMBOL1", "AA PL"} {
+			_, err := s.ScrapeSymbolNews(symbol, 10)
+			assert.EqualError(t, err, "invalid symbol: "+symbol)
+		}
+	})
+}
+
+func TestScrapeSymbolNewsSharesArticleCache(t *testing.T) {
+	const story = "https://finance.yahoo.com/news/fed-holds-rates.html"
+
+	t.Run("Reads Articles Cached By The News Feed", func(t *testing.T) {
+		mr := miniredis.RunT(t)
+		restore := upstream.UseTransport(fixtureTransport{
+			"/news/":                     "news_hub.html",
+			"/news/fed-holds-rates.html": "news_article.html",
+		})
+		feed := NewScraper(ScraperOption{RedisAddr: mr.Addr(), NewsSource: NewsSourceCrawl})
+		_, err := feed.ScrapeNews(time.Time{})
+		feed.Close()
+		restore()
+		require.NoError(t, err)
+
+		articles, urls := scrapeSymbolNewsFixture(t, mr.Addr(), "", "AAPL", 10)
+		assert.NotContains(t, urls, story, "a cached story is not fetched again")
+		require.Len(t, articles, 2)
+
+		var cached *Article
+		for i := range articles {
+			if articles[i].Link == story {
+				cached = &articles[i]
+			}
+		}
+		require.NotNil(t, cached)
+		assert.Equal(t, "Fed holds rates steady as inflation cools", cached.Title)
+		assert.Equal(t, "Reuters", cached.Publisher)
+	})
+
+	t.Run("Caches Articles For The News Feed", func(t *testing.T) {
+		mr := miniredis.RunT(t)
+		scrapeSymbolNewsFixture(t, mr.Addr(), "", "AAPL", 10)
+
+		s := NewScraper(ScraperOption{RedisAddr: mr.Addr()})
+		defer s.Close()
+		article, err := s.getFromCache(story)
+		require.NoError(t, err)
+		require.NotNil(t, article)
+		assert.Equal(t, story, article.Link)
+		assert.Equal(t, "2026-10-16T13:05:00.000Z", article.DatePublished)
+
+		listing, err := s.getFromCache("https://finance.yahoo.com/quote/AAPL/news/")
+		assert.NoError(t, err)
+		assert.Nil(t, listing, "the listing page itself is not an article")
+	})
+}
@@ -0,0 +1,24 @@
+package scraper
+
+import (
+	"context"
+	"log"
+
+	"github.com/redis/go-redis/extra/redisotel/v9"
+	"github.com/redis/go-redis/v9"
+)
+
+// optionContext is the parent for a scraper's Redis calls and visits,
+// normally the incoming request's context.
+func optionContext(ctx context.Context) context.Context {
+	if ctx != nil {
+		return ctx
+	}
+	return context.Background()
+}
+
+func traceRedis(rdb *redis.Client) {
+	if err := redisotel.InstrumentTracing(rdb); err != nil {
+		log.Printf("Error instrumenting redis client: %v", err)
+	}
+}
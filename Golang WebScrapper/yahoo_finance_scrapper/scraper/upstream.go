@@ -0,0 +1,20 @@
+package scraper
+
+import (
+	"net/http"
+
+	"go-webscraper/upstream"
+
+	"github.com/gin-gonic/gin"
+)
+
+// HandleUpstreamStatus serves the upstream summary for GET /admin/upstream.
+// Counts cover this instance since it started.
+func HandleUpstreamStatus() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{
+			"status": "success",
+			"data":   upstream.Status(),
+		})
+	}
+}
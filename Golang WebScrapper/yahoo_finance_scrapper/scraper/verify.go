@@ -0,0 +1,322 @@
+package scraper
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"go-webscraper/upstream"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+// fixtures holds a stored copy of every page type the scrapers parse, in
+// pages, and what the parsers made of each, in golden.
+//
+//go:embed fixtures
+var fixtures embed.FS
+
+const fixtureDate = "2026-10-16"
+
+// volatileFields differ on every scrape, so they are blanked before
+// outputs are compared.
+var volatileFields = map[string]bool{"timestamp": true}
+
+// parserCase scrapes one page type from fixture pages, keyed by URL path.
+type parserCase struct {
+	name  string
+	pages map[string]string
+	run   func(redisAddr string) (interface{}, error)
+}
+
+var parserCases = []parserCase{
+	{
+		name:  "most_active",
+		pages: map[string]string{"/markets/stocks/most-active/": "most_active.html"},
+		run: func(redisAddr string) (interface{}, error) {
+			s := verifyStockScraper(redisAddr)
+			defer s.Close()
+			return s.ScrapeMostActive()
+		},
+	},
+	{
+		name:  "world_indices",
+		pages: map[string]string{"/markets/world-indices/": "world_indices.html"},
+		run: func(redisAddr string) (interface{}, error) {
+			s := verifyStockScraper(redisAddr)
+			defer s.Close()
+			return s.ScrapeMajorIndices()
+		},
+	},
+	{
+		name:  "quote",
+		pages: map[string]string{"/quote/AAPL/": "quote.html"},
+		run: func(redisAddr string) (interface{}, error) {
+			s := verifyStockScraper(redisAddr)
+			defer s.Close()
+			return s.ScrapeQuotes([]string{"AAPL"})
+		},
+	},
+	{
+		name:  "index_components",
+		pages: map[string]string{"/quote/^GSPC/components/": "index_components.html"},
+		run: func(redisAddr string) (interface{}, error) {
+			s := verifyStockScraper(redisAddr)
+			defer s.Close()
+			return s.ScrapeIndexComponents("^GSPC")
+		},
+	},
+	{
+		name:  "short_interest",
+		pages: map[string]string{"/quote/AAPL/key-statistics/": "short_interest.html"},
+		run: func(redisAddr string) (interface{}, error) {
+			s := verifyStockScraper(redisAddr)
+			defer s.Close()
+			return s.ScrapeShortInterest("AAPL")
+		},
+	},
+	{
+		name:  "most_shorted",
+		pages: map[string]string{"/screener/predefined/most_shorted_stocks/": "most_shorted.html"},
+		run: func(redisAddr string) (interface{}, error) {
+			s := verifyStockScraper(redisAddr)
+			defer s.Close()
+			return s.ScrapeMostShorted()
+		},
+	},
+	{
+		name:  "sector",
+		pages: map[string]string{"/sector/technology": "sector.html"},
+		run: func(redisAddr string) (interface{}, error) {
+			s := NewSectorScraper(ScraperOption{RedisAddr: redisAddr})
+			defer s.Close()
+			s.fresh = true
+			return s.ScrapeSector("technology")
+		},
+	},
+	{
+		name:  "sector_constituents",
+		pages: map[string]string{"/sector/technology/": "sector_constituents.html"},
+		run: func(redisAddr string) (interface{}, error) {
+			s := NewSectorScraper(ScraperOption{RedisAddr: redisAddr})
+			defer s.Close()
+			s.fresh = true
+			return s.ScrapeSectorConstituents("technology", constituentsPageSize)
+		},
+	},
+	{
+		name:  "economic_calendar",
+		pages: map[string]string{"/calendar/economic": "economic_calendar.html"},
+		run: func(redisAddr string) (interface{}, error) {
+			s := NewCalendarScraper(ScraperOption{RedisAddr: redisAddr})
+			defer s.Close()
+			return s.ScrapeEconomicCalendar(fixtureDate)
+		},
+	},
+	{
+		name:  "dividend_calendar",
+		pages: map[string]string{"/calendar/dividends": "dividend_calendar.html"},
+		run: func(redisAddr string) (interface{}, error) {
+			s := NewDividendScraper(ScraperOption{RedisAddr: redisAddr})
+			defer s.Close()
+			return s.ScrapeDividendCalendar(fixtureDate)
+		},
+	},
+	{
+		name:  "options",
+		pages: map[string]string{"/markets/options/highest-open-interest/": "options.html"},
+		run: func(redisAddr string) (interface{}, error) {
+			s := NewOptionsScraper(ScraperOption{RedisAddr: redisAddr})
+			defer s.Close()
+			s.fresh = true
+			return s.ScrapeMostActiveOptions("oi")
+		},
+	},
+	{
+		name: "news",
+		pages: map[string]string{
+			"/news/":                     "news_hub.html",
+			"/news/fed-holds-rates.html": "news_article.html",
+		},
+		run: func(redisAddr string) (interface{}, error) {
+			s := NewScraper(ScraperOption{RedisAddr: redisAddr, NewsSource: NewsSourceCrawl})
+			defer s.Close()
+			s.fresh = true
+			return s.ScrapeNews(time.Time{})
+		},
+	},
+}
+
+func verifyStockScraper(redisAddr string) *StockScraper {
+	s := NewStockScraper(StockScraperOption{RedisAddr: redisAddr, OutputDir: os.TempDir()})
+	s.fresh = true
+	return s
+}
+
+// fixtureTransport answers every request with the fixture page stored for
+// its URL path, whatever the host, and 404s the rest.
+type fixtureTransport map[string]string
+
+func (t fixtureTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	status, body := http.StatusNotFound, []byte("no fixture for "+req.URL.Path)
+	if name, ok := t[req.URL.Path]; ok {
+		data, err := fixtures.ReadFile(path.Join("fixtures/pages", name))
+		if err != nil {
+			return nil, err
+		}
+		status, body = http.StatusOK, data
+	}
+	return &http.Response{
+		StatusCode:    status,
+		Status:        http.StatusText(status),
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{"Content-Type": {"text/html; charset=utf-8"}},
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
+
+// ParserResult is how one page type's parser output compared with its
+// golden file. Diff shows the first line that differs.
+type ParserResult struct {
+	Page   string `json:"page"`
+	Passed bool   `json:"passed"`
+	Diff   string `json:"diff,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// parserOutputs runs every parser case against its fixtures, serving
+// pages from the fixtures and keys from an in-process Redis, and returns
+// each case's output as normalized, indented JSON.
+func parserOutputs() (map[string][]byte, map[string]error, error) {
+	redis, err := miniredis.Run()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to start fixture redis: %v", err)
+	}
+	defer redis.Close()
+
+	pages := make(fixtureTransport)
+	for _, pc := range parserCases {
+		for urlPath, name := range pc.pages {
+			pages[urlPath] = name
+		}
+	}
+	restore := upstream.UseTransport(pages)
+	defer restore()
+
+	outputs := make(map[string][]byte, len(parserCases))
+	errs := make(map[string]error)
+	for _, pc := range parserCases {
+		result, err := pc.run(redis.Addr())
+		if err != nil {
+			errs[pc.name] = err
+			continue
+		}
+		output, err := normalizeOutput(result)
+		if err != nil {
+			errs[pc.name] = err
+			continue
+		}
+		outputs[pc.name] = output
+	}
+	return outputs, errs, nil
+}
+
+// normalizeOutput encodes v as indented JSON with volatileFields blanked.
+func normalizeOutput(v interface{}) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var doc interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	blankVolatile(doc)
+	output, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(output, '\n'), nil
+}
+
+func blankVolatile(v interface{}) {
+	switch v := v.(type) {
+	case []interface{}:
+		for _, item := range v {
+			blankVolatile(item)
+		}
+	case map[string]interface{}:
+		for key, item := range v {
+			if volatileFields[key] {
+				v[key] = ""
+				continue
+			}
+			blankVolatile(item)
+		}
+	}
+}
+
+func goldenPath(name string) string {
+	return path.Join("fixtures/golden", name+".json")
+}
+
+// VerifyParsers runs every parser against its stored fixture pages and
+// compares the output with the golden file recorded for it, so a parser
+// change that alters an output's shape or values shows up as a failure.
+// No request leaves the process.
+func VerifyParsers() ([]ParserResult, error) {
+	outputs, errs, err := parserOutputs()
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]ParserResult, 0, len(parserCases))
+	for _, pc := range parserCases {
+		result := ParserResult{Page: pc.name}
+		golden, err := fixtures.ReadFile(goldenPath(pc.name))
+		switch {
+		case errs[pc.name] != nil:
+			result.Error = errs[pc.name].Error()
+		case err != nil:
+			result.Error = fmt.Sprintf("no golden output: %v", err)
+		default:
+			result.Diff = firstDiff(golden, outputs[pc.name])
+			result.Passed = result.Diff == ""
+		}
+		results = append(results, result)
+	}
+	sort.Slice(results, func(i, k int) bool { return results[i].Page < results[k].Page })
+	return results, nil
+}
+
+// firstDiff describes the first line where got departs from want, or
+// returns "" if they match.
+func firstDiff(want, got []byte) string {
+	wantLines := strings.Split(string(want), "\n")
+	gotLines := strings.Split(string(got), "\n")
+	for i := 0; i < len(wantLines) || i < len(gotLines); i++ {
+		var w, g string
+		if i < len(wantLines) {
+			w = wantLines[i]
+		}
+		if i < len(gotLines) {
+			g = gotLines[i]
+		}
+		if w != g {
+			return fmt.Sprintf("line %d: want %q, got %q", i+1, strings.TrimSpace(w), strings.TrimSpace(g))
+		}
+	}
+	return ""
+}
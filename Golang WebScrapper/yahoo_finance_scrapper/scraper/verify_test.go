@@ -0,0 +1,51 @@
+package scraper
+
+import (
+	"flag"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var updateGolden = flag.Bool("update", false, "rewrite the parsers' golden outputs from the fixtures")
+
+// TestParserGoldens fails when a parser's output for its fixture pages
+// drifts from the golden file. Run with -update after an intended change
+// and review the golden diff.
+func TestParserGoldens(t *testing.T) {
+	outputs, errs, err := parserOutputs()
+	require.NoError(t, err)
+
+	for _, pc := range parserCases {
+		t.Run(pc.name, func(t *testing.T) {
+			require.NoError(t, errs[pc.name])
+			if *updateGolden {
+				require.NoError(t, os.WriteFile(goldenPath(pc.name), outputs[pc.name], 0644))
+				return
+			}
+			golden, err := os.ReadFile(goldenPath(pc.name))
+			require.NoError(t, err, "no golden output, run with -update")
+			assert.Equal(t, string(golden), string(outputs[pc.name]))
+		})
+	}
+}
+
+func TestVerifyParsers(t *testing.T) {
+	if *updateGolden {
+		t.Skip("goldens are being rewritten")
+	}
+	results, err := VerifyParsers()
+	require.NoError(t, err)
+	require.Len(t, results, len(parserCases))
+	for _, result := range results {
+		assert.True(t, result.Passed, "%s: %s%s", result.Page, result.Diff, result.Error)
+	}
+}
+
+func TestFirstDiff(t *testing.T) {
+	assert.Equal(t, "", firstDiff([]byte("a\nb\n"), []byte("a\nb\n")))
+	assert.Equal(t, `line 2: want "b", got "c"`, firstDiff([]byte("a\nb\n"), []byte("a\nc\n")))
+	assert.Equal(t, `line 3: want "", got "d"`, firstDiff([]byte("a\nb\n"), []byte("a\nb\nd")))
+}
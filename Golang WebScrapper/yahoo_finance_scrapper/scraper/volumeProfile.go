@@ -0,0 +1,336 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// volumeAverageDays is how many earlier market days today's volume is
+	// compared against.
+	volumeAverageDays = 20
+	// volumeRetention keeps enough calendar days of snapshots to cover
+	// volumeAverageDays market days across weekends and holidays.
+	volumeRetention = 35 * 24 * time.Hour
+
+	DefaultVolumeInterval = "30m"
+)
+
+// VolumeIntervals are the bucket widths ?interval= accepts.
+var VolumeIntervals = map[string]time.Duration{
+	"5m":  5 * time.Minute,
+	"15m": 15 * time.Minute,
+	"30m": 30 * time.Minute,
+	"60m": time.Hour,
+}
+
+// VolumeBucket is the shares traded in one slice of the session, against
+// the average for the same slice over earlier days.
+type VolumeBucket struct {
+	Start          string  `json:"start"`
+	End            string  `json:"end"`
+	Volume         int64   `json:"volume"`
+	AverageVolume  int64   `json:"average_volume"`
+	RelativeVolume float64 `json:"relative_volume"`
+}
+
+// VolumeProfile is a symbol's volume by time of day on Date, as far as
+// AsOf. Volume and AverageVolume are cumulative to AsOf's time of day, so
+// RelativeVolume compares like with like during the session.
+type VolumeProfile struct {
+	Symbol         string         `json:"symbol"`
+	Date           string         `json:"date"`
+	Interval       string         `json:"interval"`
+	AsOf           string         `json:"as_of"`
+	Volume         int64          `json:"volume"`
+	AverageVolume  int64          `json:"average_volume"`
+	RelativeVolume float64        `json:"relative_volume"`
+	AverageDays    int            `json:"average_days"`
+	Buckets        []VolumeBucket `json:"buckets"`
+}
+
+// volumePoint is the day's cumulative volume as of At.
+type volumePoint struct {
+	At     time.Time
+	Volume int64
+}
+
+// volumeKey holds a symbol's cumulative volume snapshots for one market
+// day, kept volumeRetention so profiles can be averaged over past days.
+func volumeKey(symbol, date string) string {
+	return fmt.Sprintf("volume:%s:%s", strings.ToUpper(symbol), date)
+}
+
+// recordVolume queues stock's cumulative volume as of now on pipe,
+// alongside the price recordIntraday keeps.
+func recordVolume(ctx context.Context, pipe redis.Pipeliner, stock StockData, now time.Time) {
+	if stock.Volume <= 0 {
+		return
+	}
+	key := volumeKey(stock.Symbol, now.In(marketLocation).Format("2006-01-02"))
+	pipe.ZAdd(ctx, key, redis.Z{
+		Score:  float64(now.Unix()),
+		Member: fmt.Sprintf("%d:%d", now.Unix(), stock.Volume),
+	})
+	pipe.Expire(ctx, key, volumeRetention)
+}
+
+func parseVolumePoints(members []string) []volumePoint {
+	points := make([]volumePoint, 0, len(members))
+	for _, member := range members {
+		ts, volume, found := strings.Cut(member, ":")
+		if !found {
+			continue
+		}
+		unix, err := strconv.ParseInt(ts, 10, 64)
+		if err != nil {
+			continue
+		}
+		value, err := strconv.ParseInt(volume, 10, 64)
+		if err != nil {
+			continue
+		}
+		points = append(points, volumePoint{At: time.Unix(unix, 0), Volume: value})
+	}
+	return points
+}
+
+// sessionBounds returns the regular session's open and close on day, a
+// market date.
+func sessionBounds(day time.Time) (time.Time, time.Time) {
+	day = day.In(marketLocation)
+	open := time.Date(day.Year(), day.Month(), day.Day(), 9, 30, 0, 0, marketLocation)
+	return open, time.Date(day.Year(), day.Month(), day.Day(), 16, 0, 0, 0, marketLocation)
+}
+
+// cumulativeVolume is the most volume traded by t that points show.
+// Snapshots before the open are ignored, since Yahoo can still report the
+// previous day's volume then, and those after the close count at the
+// close.
+func cumulativeVolume(points []volumePoint, open, close, t time.Time) int64 {
+	var volume int64
+	for _, point := range points {
+		at := point.At
+		if at.Before(open) {
+			continue
+		}
+		if at.After(close) {
+			at = close
+		}
+		if !at.After(t) && point.Volume > volume {
+			volume = point.Volume
+		}
+	}
+	return volume
+}
+
+// lastSessionPoint is when the last snapshot within the session (or
+// after it, counted at the close) was taken, or the zero time.
+func lastSessionPoint(points []volumePoint, open, close time.Time) time.Time {
+	var last time.Time
+	for _, point := range points {
+		at := point.At
+		if at.Before(open) {
+			continue
+		}
+		if at.After(close) {
+			at = close
+		}
+		if at.After(last) {
+			last = at
+		}
+	}
+	return last
+}
+
+// buildVolumeProfile buckets day's snapshots into interval slices from the
+// open to the last snapshot, averaging each slice over history, the
+// snapshots of earlier days keyed by date. It returns nil when day has no
+// snapshots in the session.
+func buildVolumeProfile(symbol string, day time.Time, interval string, points []volumePoint, history map[string][]volumePoint) *VolumeProfile {
+	width := VolumeIntervals[interval]
+	open, close := sessionBounds(day)
+	asOf := lastSessionPoint(points, open, close)
+	if asOf.IsZero() {
+		return nil
+	}
+
+	type pastDay struct {
+		open, close time.Time
+		points      []volumePoint
+	}
+	days := make([]pastDay, 0, len(history))
+	for date, past := range history {
+		pastDate, err := time.ParseInLocation("2006-01-02", date, marketLocation)
+		if err != nil {
+			continue
+		}
+		pastOpen, pastClose := sessionBounds(pastDate)
+		if lastSessionPoint(past, pastOpen, pastClose).IsZero() {
+			continue
+		}
+		days = append(days, pastDay{open: pastOpen, close: pastClose, points: past})
+	}
+	// average is the mean over days of the volume traded between the
+	// given offsets from each day's open
+	average := func(from, to time.Duration) int64 {
+		if len(days) == 0 {
+			return 0
+		}
+		var total int64
+		for _, d := range days {
+			total += cumulativeVolume(d.points, d.open, d.close, d.open.Add(to)) -
+				cumulativeVolume(d.points, d.open, d.close, d.open.Add(from))
+		}
+		return total / int64(len(days))
+	}
+
+	profile := &VolumeProfile{
+		Symbol:      strings.ToUpper(symbol),
+		Date:        open.Format("2006-01-02"),
+		Interval:    interval,
+		AsOf:        asOf.Format(time.RFC3339),
+		Volume:      cumulativeVolume(points, open, close, asOf),
+		AverageDays: len(days),
+		Buckets:     make([]VolumeBucket, 0),
+	}
+	profile.AverageVolume = average(0, asOf.Sub(open))
+	profile.RelativeVolume = relativeVolume(profile.Volume, profile.AverageVolume)
+
+	for start := open; start.Before(asOf); start = start.Add(width) {
+		end := start.Add(width)
+		if end.After(close) {
+			end = close
+		}
+		bucket := VolumeBucket{
+			Start: start.Format("15:04"),
+			End:   end.Format("15:04"),
+			Volume: cumulativeVolume(points, open, close, end) -
+				cumulativeVolume(points, open, close, start),
+			AverageVolume: average(start.Sub(open), end.Sub(open)),
+		}
+		bucket.RelativeVolume = relativeVolume(bucket.Volume, bucket.AverageVolume)
+		profile.Buckets = append(profile.Buckets, bucket)
+		if !end.Before(close) {
+			break
+		}
+	}
+	return profile
+}
+
+func relativeVolume(volume, average int64) float64 {
+	if average <= 0 {
+		return 0
+	}
+	return round2(float64(volume) / float64(average))
+}
+
+// previousMarketDays returns the count weekdays before day, newest
+// first. Like MarketOpen, exchange holidays are not excluded; a holiday
+// simply has no snapshots.
+func previousMarketDays(day time.Time, count int) []string {
+	dates := make([]string, 0, count)
+	for len(dates) < count {
+		day = day.AddDate(0, 0, -1)
+		if day.Weekday() == time.Saturday || day.Weekday() == time.Sunday {
+			continue
+		}
+		dates = append(dates, day.Format("2006-01-02"))
+	}
+	return dates
+}
+
+// VolumeProfile builds symbol's volume profile for date, a YYYY-MM-DD
+// market day, from the volume snapshots recorded by quote scrapes. It
+// returns nil when none were recorded that day.
+func (s *StockScraper) VolumeProfile(symbol, date, interval string) (*VolumeProfile, error) {
+	if _, ok := VolumeIntervals[interval]; !ok {
+		return nil, fmt.Errorf("invalid interval: %s", interval)
+	}
+	day, err := time.ParseInLocation("2006-01-02", date, marketLocation)
+	if err != nil {
+		return nil, fmt.Errorf("invalid date: %s", date)
+	}
+
+	dates := append([]string{date}, previousMarketDays(day, volumeAverageDays)...)
+	pipe := s.redis.Pipeline()
+	results := make([]*redis.StringSliceCmd, len(dates))
+	for i, d := range dates {
+		results[i] = pipe.ZRange(s.ctx, volumeKey(symbol, d), 0, -1)
+	}
+	if _, err := pipe.Exec(s.ctx); err != nil {
+		return nil, err
+	}
+
+	history := make(map[string][]volumePoint, len(dates)-1)
+	for i, d := range dates[1:] {
+		if members := results[i+1].Val(); len(members) > 0 {
+			history[d] = parseVolumePoints(members)
+		}
+	}
+	return buildVolumeProfile(symbol, day, interval, parseVolumePoints(results[0].Val()), history), nil
+}
+
+// HandleVolumeProfile serves GET /api/stock/:symbol/volume-profile. ?date=
+// defaults to today's market day and ?interval= to 30m.
+func HandleVolumeProfile() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		symbol := strings.ToUpper(c.Param("symbol"))
+		if !ValidSymbol(symbol) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "invalid symbol",
+			})
+			return
+		}
+
+		date, err := parseDateParam(c, "date")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+		if date == "" {
+			date = MarketMidnight(time.Now()).Format("2006-01-02")
+		}
+
+		interval := c.DefaultQuery("interval", DefaultVolumeInterval)
+		if _, ok := VolumeIntervals[interval]; !ok {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "interval must be 5m, 15m, 30m or 60m",
+			})
+			return
+		}
+
+		scraper := NewStockScraper(StockScraperOption{
+			Context: c.Request.Context(),
+		})
+		defer scraper.Close()
+
+		profile, err := scraper.VolumeProfile(symbol, date, interval)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+		if profile == nil {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": fmt.Sprintf("no volume recorded for %s on %s", symbol, date),
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"status": "success",
+			"data":   profile,
+		})
+	}
+}
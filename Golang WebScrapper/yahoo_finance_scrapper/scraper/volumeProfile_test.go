@@ -0,0 +1,104 @@
+package scraper
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func marketTime(t *testing.T, value string) time.Time {
+	at, err := time.ParseInLocation("2006-01-02 15:04", value, marketLocation)
+	require.NoError(t, err)
+	return at
+}
+
+func TestBuildVolumeProfile(t *testing.T) {
+	points := []volumePoint{
+		// Before the open Yahoo may still show yesterday's volume
+		{At: marketTime(t, "2024-07-10 08:00"), Volume: 99999},
+		{At: marketTime(t, "2024-07-10 09:45"), Volume: 1000},
+		{At: marketTime(t, "2024-07-10 10:10"), Volume: 3000},
+		{At: marketTime(t, "2024-07-10 10:40"), Volume: 4000},
+	}
+	history := map[string][]volumePoint{
+		"2024-07-09": {
+			{At: marketTime(t, "2024-07-09 10:00"), Volume: 500},
+			{At: marketTime(t, "2024-07-09 11:00"), Volume: 2000},
+			{At: marketTime(t, "2024-07-09 17:00"), Volume: 10000},
+		},
+		"2024-07-08": {
+			{At: marketTime(t, "2024-07-08 10:00"), Volume: 1500},
+			{At: marketTime(t, "2024-07-08 10:30"), Volume: 2500},
+			{At: marketTime(t, "2024-07-08 11:00"), Volume: 3000},
+		},
+		// Only pre-market snapshots, so not a day to average over
+		"2024-07-05": {{At: marketTime(t, "2024-07-05 07:00"), Volume: 800}},
+	}
+
+	profile := buildVolumeProfile("aapl", marketTime(t, "2024-07-10 00:00"), "30m", points, history)
+	require.NotNil(t, profile)
+	assert.Equal(t, "AAPL", profile.Symbol)
+	assert.Equal(t, "2024-07-10", profile.Date)
+	assert.Equal(t, marketTime(t, "2024-07-10 10:40").Format(time.RFC3339), profile.AsOf)
+	assert.Equal(t, int64(4000), profile.Volume)
+	assert.Equal(t, int64(1500), profile.AverageVolume)
+	assert.Equal(t, 2.67, profile.RelativeVolume)
+	assert.Equal(t, 2, profile.AverageDays)
+	assert.Equal(t, []VolumeBucket{
+		{Start: "09:30", End: "10:00", Volume: 1000, AverageVolume: 1000, RelativeVolume: 1},
+		{Start: "10:00", End: "10:30", Volume: 2000, AverageVolume: 500, RelativeVolume: 4},
+		{Start: "10:30", End: "11:00", Volume: 1000, AverageVolume: 1000, RelativeVolume: 1},
+	}, profile.Buckets)
+
+	// A finished day runs to the close, counting after-hours snapshots there
+	profile = buildVolumeProfile("AAPL", marketTime(t, "2024-07-09 00:00"), "60m", history["2024-07-09"], nil)
+	require.NotNil(t, profile)
+	assert.Equal(t, int64(10000), profile.Volume)
+	assert.Equal(t, 0.0, profile.RelativeVolume)
+	require.Len(t, profile.Buckets, 7)
+	last := profile.Buckets[6]
+	assert.Equal(t, "15:30", last.Start)
+	assert.Equal(t, "16:00", last.End)
+	assert.Equal(t, int64(8000), last.Volume)
+
+	assert.Nil(t, buildVolumeProfile("AAPL", marketTime(t, "2024-07-05 00:00"), "30m", history["2024-07-05"], nil))
+}
+
+func TestPreviousMarketDays(t *testing.T) {
+	// Monday's previous market days skip the weekend
+	assert.Equal(t, []string{"2024-07-05", "2024-07-04", "2024-07-03"},
+		previousMarketDays(marketTime(t, "2024-07-08 00:00"), 3))
+}
+
+func TestVolumeProfile(t *testing.T) {
+	mr := miniredis.RunT(t)
+	s := NewStockScraper(StockScraperOption{RedisAddr: mr.Addr()})
+	defer s.Close()
+
+	ctx := context.Background()
+	pipe := s.redis.Pipeline()
+	recordVolume(ctx, pipe, StockData{Symbol: "AAPL", Volume: 1000}, marketTime(t, "2024-07-09 10:00"))
+	recordVolume(ctx, pipe, StockData{Symbol: "AAPL", Volume: 2000}, marketTime(t, "2024-07-10 10:00"))
+	recordVolume(ctx, pipe, StockData{Symbol: "AAPL", Volume: 0}, marketTime(t, "2024-07-10 10:20"))
+	_, err := pipe.Exec(ctx)
+	require.NoError(t, err)
+
+	profile, err := s.VolumeProfile("AAPL", "2024-07-10", "30m")
+	require.NoError(t, err)
+	require.NotNil(t, profile)
+	assert.Equal(t, int64(2000), profile.Volume)
+	assert.Equal(t, int64(1000), profile.AverageVolume)
+	assert.Equal(t, 2.0, profile.RelativeVolume)
+	assert.Equal(t, 1, profile.AverageDays)
+
+	profile, err = s.VolumeProfile("MSFT", "2024-07-10", "30m")
+	assert.NoError(t, err)
+	assert.Nil(t, profile)
+
+	_, err = s.VolumeProfile("AAPL", "2024-07-10", "7m")
+	assert.Error(t, err)
+}
@@ -0,0 +1,135 @@
+package screener
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+
+	"go-webscraper/scraper"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Candidate struct {
+	scraper.StockData
+	Category string `json:"category"`
+}
+
+type Filter struct {
+	Categories    []string `json:"categories,omitempty"`
+	Symbols       []string `json:"symbols,omitempty"`
+	MinPrice      *float64 `json:"min_price,omitempty"`
+	MaxPrice      *float64 `json:"max_price,omitempty"`
+	MinChangePerc *float64 `json:"min_change_percentage,omitempty"`
+	MaxChangePerc *float64 `json:"max_change_percentage,omitempty"`
+	MinVolume     *int64   `json:"min_volume,omitempty"`
+	SortBy        string   `json:"sort_by,omitempty"`
+	Limit         int      `json:"limit,omitempty"`
+}
+
+// Source supplies the universe a screen runs against.
+type Source func() ([]Candidate, error)
+
+func MarketSource() ([]Candidate, error) {
+	stockScraper := scraper.NewStockScraper(scraper.StockScraperOption{})
+	defer stockScraper.Close()
+
+	overview, err := stockScraper.ScrapeMarketOverview()
+	if err != nil {
+		return nil, err
+	}
+
+	candidates := make([]Candidate, 0)
+	for category, stocks := range overview {
+		for _, stock := range stocks {
+			candidates = append(candidates, Candidate{StockData: stock, Category: category})
+		}
+	}
+	return candidates, nil
+}
+
+func (f Filter) Match(c Candidate) bool {
+	if len(f.Categories) > 0 && !containsFold(f.Categories, c.Category) {
+		return false
+	}
+	if len(f.Symbols) > 0 && !containsFold(f.Symbols, c.Symbol) {
+		return false
+	}
+	if f.MinPrice != nil && c.Price < *f.MinPrice {
+		return false
+	}
+	if f.MaxPrice != nil && c.Price > *f.MaxPrice {
+		return false
+	}
+	if f.MinChangePerc != nil && c.ChangePerc < *f.MinChangePerc {
+		return false
+	}
+	if f.MaxChangePerc != nil && c.ChangePerc > *f.MaxChangePerc {
+		return false
+	}
+	if f.MinVolume != nil && c.Volume < *f.MinVolume {
+		return false
+	}
+	return true
+}
+
+func Run(candidates []Candidate, f Filter) []Candidate {
+	results := make([]Candidate, 0)
+	seen := make(map[string]bool)
+	for _, c := range candidates {
+		// Suspect rows are likely parse glitches; never notify on them
+		if seen[c.Symbol] || c.Suspect || !f.Match(c) {
+			continue
+		}
+		seen[c.Symbol] = true
+		results = append(results, c)
+	}
+
+	switch f.SortBy {
+	case "price":
+		sort.Slice(results, func(i, j int) bool { return results[i].Price > results[j].Price })
+	case "change":
+		sort.Slice(results, func(i, j int) bool { return results[i].ChangePerc > results[j].ChangePerc })
+	case "volume":
+		sort.Slice(results, func(i, j int) bool { return results[i].Volume > results[j].Volume })
+	}
+
+	if f.Limit > 0 && len(results) > f.Limit {
+		results = results[:f.Limit]
+	}
+	return results
+}
+
+func containsFold(values []string, s string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
+
+func HandleRunScreen(source Source) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var filter Filter
+		if err := c.ShouldBindJSON(&filter); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		candidates, err := source()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"status": "success",
+			"data":   Run(candidates, filter),
+		})
+	}
+}
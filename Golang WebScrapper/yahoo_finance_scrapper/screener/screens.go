@@ -0,0 +1,336 @@
+package screener
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"go-webscraper/notify"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+const screensKey = "screener:screens"
+
+type Schedule struct {
+	Time     string `json:"time"`
+	Timezone string `json:"timezone"`
+}
+
+type SavedScreen struct {
+	ID        string    `json:"id"`
+	UserID    string    `json:"-"`
+	Name      string    `json:"name"`
+	Filter    Filter    `json:"filter"`
+	Schedule  *Schedule `json:"schedule,omitempty"`
+	LastRun   string    `json:"last_run,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// storedScreen keeps UserID in Redis while the API never exposes it.
+type storedScreen struct {
+	SavedScreen
+	UserID string `json:"user_id"`
+}
+
+func (s Schedule) location() (*time.Location, error) {
+	if s.Timezone == "" {
+		return time.LoadLocation("America/New_York")
+	}
+	return time.LoadLocation(s.Timezone)
+}
+
+func (s Schedule) Validate() error {
+	if _, err := time.Parse("15:04", s.Time); err != nil {
+		return fmt.Errorf("schedule time must be formatted as HH:MM")
+	}
+	if _, err := s.location(); err != nil {
+		return fmt.Errorf("invalid timezone: %s", s.Timezone)
+	}
+	return nil
+}
+
+// Due reports whether the screen should run at now, returning the local
+// date to record as its last run. A screen runs at most once per day, at
+// or after its scheduled time.
+func (s Schedule) Due(now time.Time, lastRun string) (bool, string) {
+	loc, err := s.location()
+	if err != nil {
+		return false, ""
+	}
+
+	local := now.In(loc)
+	today := local.Format("2006-01-02")
+	return lastRun != today && local.Format("15:04") >= s.Time, today
+}
+
+type Store struct {
+	redis *redis.Client
+	ctx   context.Context
+}
+
+func NewStore(rdb *redis.Client) *Store {
+	return &Store{
+		redis: rdb,
+		ctx:   context.Background(),
+	}
+}
+
+func (s *Store) Save(screen *SavedScreen) error {
+	data, err := json.Marshal(storedScreen{SavedScreen: *screen, UserID: screen.UserID})
+	if err != nil {
+		return err
+	}
+	return s.redis.HSet(s.ctx, screensKey, screen.ID, data).Err()
+}
+
+func (s *Store) Get(id string) (*SavedScreen, error) {
+	data, err := s.redis.HGet(s.ctx, screensKey, id).Result()
+	if err != nil {
+		if err == redis.Nil {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var stored storedScreen
+	if err := json.Unmarshal([]byte(data), &stored); err != nil {
+		return nil, err
+	}
+	stored.SavedScreen.UserID = stored.UserID
+	return &stored.SavedScreen, nil
+}
+
+func (s *Store) Delete(id string) error {
+	return s.redis.HDel(s.ctx, screensKey, id).Err()
+}
+
+func (s *Store) All() ([]*SavedScreen, error) {
+	values, err := s.redis.HGetAll(s.ctx, screensKey).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	screens := make([]*SavedScreen, 0, len(values))
+	for _, value := range values {
+		var stored storedScreen
+		if err := json.Unmarshal([]byte(value), &stored); err != nil {
+			continue
+		}
+		stored.SavedScreen.UserID = stored.UserID
+		screens = append(screens, &stored.SavedScreen)
+	}
+	return screens, nil
+}
+
+func (s *Store) ListByUser(userID string) ([]*SavedScreen, error) {
+	screens, err := s.All()
+	if err != nil {
+		return nil, err
+	}
+
+	owned := make([]*SavedScreen, 0)
+	for _, screen := range screens {
+		if screen.UserID == userID {
+			owned = append(owned, screen)
+		}
+	}
+	return owned, nil
+}
+
+func formatResults(screen *SavedScreen, results []Candidate) notify.Message {
+	var body strings.Builder
+	fmt.Fprintf(&body, "Screen %q matched %d stocks:\n\n", screen.Name, len(results))
+	for _, r := range results {
+		fmt.Fprintf(&body, "%-8s %10.2f %8.2f%% %12d\n", r.Symbol, r.Price, r.ChangePerc, r.Volume)
+	}
+
+	return notify.Message{
+		Subject: fmt.Sprintf("GoFinance screen: %s", screen.Name),
+		Body:    body.String(),
+		Data:    results,
+	}
+}
+
+// RunDueScreens is a scheduler job that runs every saved screen whose daily
+// schedule has come due and sends the results to its owner.
+func RunDueScreens(store *Store, source Source, notifier *notify.Notifier) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		screens, err := store.All()
+		if err != nil {
+			return err
+		}
+
+		var candidates []Candidate
+		for _, screen := range screens {
+			if screen.Schedule == nil {
+				continue
+			}
+			due, today := screen.Schedule.Due(time.Now(), screen.LastRun)
+			if !due {
+				continue
+			}
+
+			if candidates == nil {
+				if candidates, err = source(); err != nil {
+					return fmt.Errorf("failed to load screener universe: %v", err)
+				}
+			}
+
+			results := Run(candidates, screen.Filter)
+			if err := notifier.NotifyUser(screen.UserID, formatResults(screen, results)); err != nil {
+				log.Printf("Error delivering screen %s to %s: %v", screen.ID, screen.UserID, err)
+			}
+
+			screen.LastRun = today
+			if err := store.Save(screen); err != nil {
+				log.Printf("Error saving screen %s: %v", screen.ID, err)
+			}
+		}
+		return nil
+	}
+}
+
+func randomID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+type ScreenRequest struct {
+	Name     string    `json:"name" binding:"required"`
+	Filter   Filter    `json:"filter"`
+	Schedule *Schedule `json:"schedule"`
+}
+
+func HandleCreateScreen(store *Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req ScreenRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		screen := &SavedScreen{
+			ID:        randomID(),
+			UserID:    c.GetString("user_id"),
+			Name:      req.Name,
+			Filter:    req.Filter,
+			Schedule:  req.Schedule,
+			CreatedAt: time.Now(),
+		}
+
+		if screen.Schedule != nil {
+			if err := screen.Schedule.Validate(); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{
+					"error": err.Error(),
+				})
+				return
+			}
+			// Don't fire immediately for a time that already passed today
+			if due, today := screen.Schedule.Due(time.Now(), ""); due {
+				screen.LastRun = today
+			}
+		}
+
+		if err := store.Save(screen); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		c.Set("audit_target", screen.ID)
+		c.JSON(http.StatusCreated, gin.H{
+			"status": "success",
+			"data":   screen,
+		})
+	}
+}
+
+func HandleListScreens(store *Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		screens, err := store.ListByUser(c.GetString("user_id"))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"status": "success",
+			"data":   screens,
+		})
+	}
+}
+
+// ownedScreen loads the screen named in the URL, writing a 404 unless it
+// belongs to the caller.
+func ownedScreen(c *gin.Context, store *Store) *SavedScreen {
+	screen, err := store.Get(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return nil
+	}
+	if screen == nil || screen.UserID != c.GetString("user_id") {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "screen not found",
+		})
+		return nil
+	}
+	return screen
+}
+
+func HandleDeleteScreen(store *Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		screen := ownedScreen(c, store)
+		if screen == nil {
+			return
+		}
+
+		if err := store.Delete(screen.ID); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"status": "success",
+		})
+	}
+}
+
+func HandleRunSavedScreen(store *Store, source Source) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		screen := ownedScreen(c, store)
+		if screen == nil {
+			return
+		}
+
+		candidates, err := source()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"status": "success",
+			"data":   Run(candidates, screen.Filter),
+		})
+	}
+}
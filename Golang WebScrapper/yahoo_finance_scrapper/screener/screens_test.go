@@ -0,0 +1,51 @@
+package screener
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScheduleDueInEastern(t *testing.T) {
+	at := func(value string) time.Time {
+		now, err := time.Parse(time.RFC3339, value)
+		require.NoError(t, err)
+		return now
+	}
+	open := Schedule{Time: "09:45"}
+
+	// 13:44 UTC is 09:44 EDT in July, 08:44 EST in January
+	due, today := open.Due(at("2024-07-10T13:44:00Z"), "")
+	assert.False(t, due)
+	assert.Equal(t, "2024-07-10", today)
+	due, _ = open.Due(at("2024-07-10T13:45:00Z"), "")
+	assert.True(t, due)
+	due, _ = open.Due(at("2024-01-10T13:45:00Z"), "")
+	assert.False(t, due, "still 08:45 in New York during standard time")
+	due, _ = open.Due(at("2024-01-10T14:45:00Z"), "")
+	assert.True(t, due)
+
+	// Once per New York day, even after midnight UTC
+	due, today = open.Due(at("2024-07-11T02:00:00Z"), "2024-07-10")
+	assert.False(t, due)
+	assert.Equal(t, "2024-07-10", today)
+	due, today = open.Due(at("2024-07-11T14:00:00Z"), "2024-07-10")
+	assert.True(t, due)
+	assert.Equal(t, "2024-07-11", today)
+
+	tokyo := Schedule{Time: "09:00", Timezone: "Asia/Tokyo"}
+	due, today = tokyo.Due(at("2024-07-09T23:59:00Z"), "")
+	assert.False(t, due)
+	assert.Equal(t, "2024-07-10", today)
+	due, _ = tokyo.Due(at("2024-07-10T00:00:00Z"), "")
+	assert.True(t, due)
+}
+
+func TestScheduleValidate(t *testing.T) {
+	assert.NoError(t, Schedule{Time: "16:05"}.Validate())
+	assert.NoError(t, Schedule{Time: "08:00", Timezone: "Europe/London"}.Validate())
+	assert.Error(t, Schedule{Time: "4pm"}.Validate())
+	assert.Error(t, Schedule{Time: "16:05", Timezone: "Mars/Olympus"}.Validate())
+}
@@ -0,0 +1,101 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const (
+	unixPrefix    = "unix:"
+	systemdPrefix = "systemd"
+	// listenFDsStart is the first file descriptor systemd passes, after
+	// stdin, stdout and stderr.
+	listenFDsStart = 3
+)
+
+// listen opens addr, which is one of:
+//
+//	host:port      a TCP address, e.g. ":8080"
+//	unix:/path     a Unix domain socket, created with mode
+//	systemd        the first socket systemd activated the process with
+//	systemd:name   the activated socket named name (FileDescriptorName=)
+func listen(addr string, mode os.FileMode) (net.Listener, error) {
+	switch {
+	case strings.HasPrefix(addr, unixPrefix):
+		return listenUnix(strings.TrimPrefix(addr, unixPrefix), mode)
+	case addr == systemdPrefix || strings.HasPrefix(addr, systemdPrefix+":"):
+		return listenSystemd(strings.TrimPrefix(strings.TrimPrefix(addr, systemdPrefix), ":"))
+	default:
+		return net.Listen("tcp", addr)
+	}
+}
+
+func listenUnix(path string, mode os.FileMode) (net.Listener, error) {
+	if path == "" {
+		return nil, errors.New("unix socket path is empty")
+	}
+	// A socket left by an unclean exit would make the bind fail; anything
+	// else at path is left alone
+	if info, err := os.Lstat(path); err == nil {
+		if info.Mode()&os.ModeSocket == 0 {
+			return nil, fmt.Errorf("%s exists and is not a socket", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, fmt.Errorf("failed to remove stale socket %s: %v", path, err)
+		}
+	}
+
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	if mode != 0 {
+		if err := os.Chmod(path, mode); err != nil {
+			ln.Close()
+			return nil, fmt.Errorf("failed to set mode of %s: %v", path, err)
+		}
+	}
+	return ln, nil
+}
+
+// listenSystemd takes over a socket passed by systemd socket activation,
+// following sd_listen_fds(3): LISTEN_PID names this process, LISTEN_FDS
+// counts the sockets and LISTEN_FDNAMES names them.
+func listenSystemd(name string) (net.Listener, error) {
+	if pid, err := strconv.Atoi(os.Getenv("LISTEN_PID")); err != nil || pid != os.Getpid() {
+		return nil, errors.New("no sockets were passed by systemd")
+	}
+	count, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if err != nil || count < 1 {
+		return nil, errors.New("no sockets were passed by systemd")
+	}
+
+	index := 0
+	if name != "" {
+		index = -1
+		for i, fdName := range strings.Split(os.Getenv("LISTEN_FDNAMES"), ":") {
+			if fdName == name && i < count {
+				index = i
+				break
+			}
+		}
+		if index < 0 {
+			return nil, fmt.Errorf("systemd passed no socket named %s", name)
+		}
+	}
+
+	f := os.NewFile(uintptr(listenFDsStart+index), "systemd:"+name)
+	if f == nil {
+		return nil, fmt.Errorf("systemd socket %d is not open", listenFDsStart+index)
+	}
+	defer f.Close()
+	ln, err := net.FileListener(f)
+	if err != nil {
+		return nil, fmt.Errorf("systemd socket %d: %v", listenFDsStart+index, err)
+	}
+	return ln, nil
+}
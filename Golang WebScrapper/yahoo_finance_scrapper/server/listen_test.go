@@ -0,0 +1,85 @@
+package server
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListenUnix(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "api.sock")
+	ln, err := listen("unix:"+path, 0o600)
+	require.NoError(t, err)
+	go http.Serve(ln, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "ok")
+	}))
+	defer ln.Close()
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+	client := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "unix", path)
+		},
+	}}
+	resp, err := client.Get("http://unix/")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	assert.Equal(t, "ok", string(body))
+}
+
+func TestListenUnixStaleSocket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "api.sock")
+	stale, err := net.Listen("unix", path)
+	require.NoError(t, err)
+	// Leave the socket file behind, as a crashed process would
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	stale.Close()
+
+	ln, err := listen("unix:"+path, 0)
+	require.NoError(t, err)
+	ln.Close()
+}
+
+func TestListenUnixRefusesOtherFiles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "api.sock")
+	require.NoError(t, os.WriteFile(path, []byte("data"), 0o644))
+
+	_, err := listen("unix:"+path, 0)
+	assert.Error(t, err)
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "data", string(data))
+
+	_, err = listen("unix:", 0)
+	assert.Error(t, err)
+}
+
+func TestListenSystemd(t *testing.T) {
+	// Sockets passed to another process aren't ours to take
+	t.Setenv("LISTEN_PID", strconv.Itoa(os.Getpid()+1))
+	t.Setenv("LISTEN_FDS", "1")
+	_, err := listen("systemd", 0)
+	assert.Error(t, err)
+
+	t.Setenv("LISTEN_PID", strconv.Itoa(os.Getpid()))
+	t.Setenv("LISTEN_FDS", "0")
+	_, err = listen("systemd", 0)
+	assert.Error(t, err)
+
+	t.Setenv("LISTEN_FDS", "1")
+	t.Setenv("LISTEN_FDNAMES", "http")
+	_, err = listen("systemd:https", 0)
+	assert.Error(t, err)
+}
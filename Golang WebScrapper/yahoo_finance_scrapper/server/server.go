@@ -0,0 +1,133 @@
+// Package server runs the HTTP API, over TLS when configured, so small
+// deployments can serve HTTPS without a reverse proxy in front.
+package server
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// Option selects how the API is served. With neither CertFile and KeyFile
+// nor Autocert, Addr serves plain HTTP. Addr and HTTPAddr may be TCP
+// addresses, Unix sockets or systemd-activated sockets; see listen.
+type Option struct {
+	Addr string
+	// SocketMode is the permissions given to Unix sockets created for
+	// Addr or HTTPAddr.
+	SocketMode os.FileMode
+	CertFile   string
+	KeyFile    string
+	// Autocert obtains and renews certificates for Domains from Let's
+	// Encrypt, keeping them in CacheDir.
+	Autocert bool
+	Domains  []string
+	Email    string
+	CacheDir string
+	// HTTPAddr serves plain HTTP alongside HTTPS: ACME challenges, then
+	// a redirect to HTTPS when Redirect is set, otherwise the API itself.
+	HTTPAddr string
+	Redirect bool
+}
+
+func (o Option) tls() bool {
+	return o.Autocert || o.CertFile != "" || o.KeyFile != ""
+}
+
+func (o Option) validate() error {
+	if (o.CertFile == "") != (o.KeyFile == "") {
+		return errors.New("tls needs both cert_file and key_file")
+	}
+	if o.Autocert && o.CertFile != "" {
+		return errors.New("tls can use autocert or cert_file and key_file, not both")
+	}
+	if o.Autocert && len(o.Domains) == 0 {
+		return errors.New("autocert needs at least one domain")
+	}
+	if o.tls() && o.HTTPAddr == o.Addr {
+		return fmt.Errorf("tls http_addr must differ from addr %s", o.Addr)
+	}
+	return nil
+}
+
+// redirect sends plain HTTP requests to the same URL over HTTPS on the
+// port httpsAddr listens on.
+func redirect(httpsAddr string) http.Handler {
+	_, port, _ := net.SplitHostPort(httpsAddr)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		if port != "" && port != "443" {
+			host = net.JoinHostPort(host, port)
+		}
+		target := "https://" + host + r.URL.RequestURI()
+		http.Redirect(w, r, target, http.StatusMovedPermanently)
+	})
+}
+
+// Run serves handler as opts says until a listener fails.
+func Run(handler http.Handler, opts Option) error {
+	if err := opts.validate(); err != nil {
+		return err
+	}
+	ln, err := listen(opts.Addr, opts.SocketMode)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %v", opts.Addr, err)
+	}
+	if !opts.tls() {
+		log.Printf("Serving HTTP on %s", opts.Addr)
+		return http.Serve(ln, handler)
+	}
+
+	httpsServer := &http.Server{
+		Addr:      opts.Addr,
+		Handler:   handler,
+		TLSConfig: &tls.Config{MinVersion: tls.VersionTLS12},
+	}
+	var plain http.Handler = handler
+	if opts.Redirect {
+		plain = redirect(opts.Addr)
+	}
+
+	if opts.Autocert {
+		manager := &autocert.Manager{
+			Prompt:     autocert.AcceptTOS,
+			HostPolicy: autocert.HostWhitelist(opts.Domains...),
+			Cache:      autocert.DirCache(opts.CacheDir),
+			Email:      opts.Email,
+		}
+		httpsServer.TLSConfig = manager.TLSConfig()
+		httpsServer.TLSConfig.MinVersion = tls.VersionTLS12
+		// The HTTP-01 challenge is answered on the plain listener
+		plain = manager.HTTPHandler(plain)
+		log.Printf("Serving HTTPS on %s with certificates for %s", opts.Addr, strings.Join(opts.Domains, ", "))
+	} else {
+		log.Printf("Serving HTTPS on %s with %s", opts.Addr, opts.CertFile)
+	}
+
+	errs := make(chan error, 2)
+	if opts.HTTPAddr != "" {
+		plainLn, err := listen(opts.HTTPAddr, opts.SocketMode)
+		if err != nil {
+			ln.Close()
+			return fmt.Errorf("failed to listen on %s: %v", opts.HTTPAddr, err)
+		}
+		go func() {
+			log.Printf("Serving HTTP on %s", opts.HTTPAddr)
+			errs <- http.Serve(plainLn, plain)
+		}()
+	}
+	go func() {
+		errs <- httpsServer.ServeTLS(ln, opts.CertFile, opts.KeyFile)
+	}()
+	return <-errs
+}
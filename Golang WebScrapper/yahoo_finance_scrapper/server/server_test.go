@@ -0,0 +1,108 @@
+package server
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidate(t *testing.T) {
+	assert.NoError(t, Option{Addr: ":8080"}.validate())
+	assert.NoError(t, Option{Addr: ":443", CertFile: "cert.pem", KeyFile: "key.pem", HTTPAddr: ":80"}.validate())
+	assert.NoError(t, Option{Addr: ":443", Autocert: true, Domains: []string{"api.example.com"}, HTTPAddr: ":80"}.validate())
+
+	assert.Error(t, Option{Addr: ":443", CertFile: "cert.pem"}.validate())
+	assert.Error(t, Option{Addr: ":443", Autocert: true}.validate())
+	assert.Error(t, Option{Addr: ":443", Autocert: true, Domains: []string{"a.com"}, CertFile: "c", KeyFile: "k"}.validate())
+	assert.Error(t, Option{Addr: ":443", CertFile: "cert.pem", KeyFile: "key.pem", HTTPAddr: ":443"}.validate())
+}
+
+func TestRedirect(t *testing.T) {
+	for addr, want := range map[string]string{
+		":443":  "https://api.example.com/api/stock?category=gainers",
+		":8443": "https://api.example.com:8443/api/stock?category=gainers",
+	} {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "http://api.example.com:8080/api/stock?category=gainers", nil)
+		redirect(addr).ServeHTTP(w, r)
+		assert.Equal(t, http.StatusMovedPermanently, w.Code)
+		assert.Equal(t, want, w.Header().Get("Location"))
+	}
+}
+
+func writeSelfSigned(t *testing.T, dir string) (string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		DNSNames:     []string{"localhost"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	certFile, keyFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600))
+	return certFile, keyFile
+}
+
+func freeAddr(t *testing.T) string {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer ln.Close()
+	return ln.Addr().String()
+}
+
+func TestRunTLS(t *testing.T) {
+	certFile, keyFile := writeSelfSigned(t, t.TempDir())
+	httpsAddr, httpAddr := freeAddr(t), freeAddr(t)
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	})
+	go Run(handler, Option{Addr: httpsAddr, CertFile: certFile, KeyFile: keyFile, HTTPAddr: httpAddr, Redirect: true})
+
+	client := &http.Client{
+		Transport: &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	var resp *http.Response
+	require.Eventually(t, func() bool {
+		var err error
+		resp, err = client.Get("https://" + httpsAddr + "/")
+		return err == nil
+	}, 2*time.Second, 20*time.Millisecond)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, uint16(tls.VersionTLS13), resp.TLS.Version)
+
+	resp, err := client.Get("http://" + httpAddr + "/api/stock")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusMovedPermanently, resp.StatusCode)
+	_, port, _ := net.SplitHostPort(httpsAddr)
+	assert.Equal(t, "https://127.0.0.1:"+port+"/api/stock", resp.Header.Get("Location"))
+}
@@ -0,0 +1,171 @@
+package snapshot
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"go-webscraper/cachekey"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// DefaultPatterns match the scraper caches worth carrying across a restart.
+var DefaultPatterns = []string{
+	cachekey.MostActive.Pattern(),
+	cachekey.MarketOverview.Pattern(),
+	cachekey.Sector.Pattern(),
+	cachekey.EconomicCalendar.Pattern(),
+	cachekey.OptionsMostActive.Pattern(),
+	cachekey.FXRate.Pattern(),
+	cachekey.ProvenancePattern,
+}
+
+type Entry struct {
+	Value string        `json:"value"`
+	TTL   time.Duration `json:"ttl"`
+}
+
+type Snapshot struct {
+	TakenAt time.Time        `json:"taken_at"`
+	Entries map[string]Entry `json:"entries"`
+}
+
+type Snapshotter struct {
+	redis    *redis.Client
+	ctx      context.Context
+	path     string
+	patterns []string
+	maxAge   time.Duration
+	minTTL   time.Duration
+}
+
+type SnapshotOption struct {
+	Path     string
+	Patterns []string
+	MaxAge   time.Duration
+	MinTTL   time.Duration
+}
+
+func New(rdb *redis.Client, opts SnapshotOption) *Snapshotter {
+	if opts.Path == "" {
+		opts.Path = "snapshots/boot.json"
+	}
+	if len(opts.Patterns) == 0 {
+		opts.Patterns = DefaultPatterns
+	}
+	if opts.MaxAge == 0 {
+		opts.MaxAge = 24 * time.Hour
+	}
+	if opts.MinTTL == 0 {
+		opts.MinTTL = 5 * time.Minute
+	}
+
+	return &Snapshotter{
+		redis:    rdb,
+		ctx:      context.Background(),
+		path:     opts.Path,
+		patterns: opts.Patterns,
+		maxAge:   opts.MaxAge,
+		minTTL:   opts.MinTTL,
+	}
+}
+
+// Save writes every cached string value matching the configured patterns,
+// with its remaining TTL, to the snapshot file. The file is replaced
+// atomically so a crash mid-write never leaves a truncated snapshot.
+func (s *Snapshotter) Save() (int, error) {
+	snap := Snapshot{
+		TakenAt: time.Now().UTC(),
+		Entries: make(map[string]Entry),
+	}
+
+	for _, pattern := range s.patterns {
+		iter := s.redis.Scan(s.ctx, 0, pattern, 100).Iterator()
+		for iter.Next(s.ctx) {
+			key := iter.Val()
+			value, err := s.redis.Get(s.ctx, key).Result()
+			if err != nil {
+				// Expired since the scan, or not a string value
+				continue
+			}
+			ttl, err := s.redis.PTTL(s.ctx, key).Result()
+			if err != nil || ttl < 0 {
+				ttl = 0
+			}
+			snap.Entries[key] = Entry{Value: value, TTL: ttl}
+		}
+		if err := iter.Err(); err != nil {
+			return 0, fmt.Errorf("failed to scan %s: %v", pattern, err)
+		}
+	}
+
+	data, err := json.Marshal(snap)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return 0, err
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return 0, err
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return 0, err
+	}
+	return len(snap.Entries), nil
+}
+
+// Load restores snapshot entries that Redis doesn't already hold. Entries
+// keep whatever TTL they had left, but never less than MinTTL so the next
+// scheduled refresh replaces them rather than a burst of user requests.
+// A missing or too-old snapshot restores nothing.
+func (s *Snapshotter) Load() (int, error) {
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+		return 0, err
+	}
+
+	var snap Snapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return 0, fmt.Errorf("invalid snapshot %s: %v", s.path, err)
+	}
+
+	age := time.Since(snap.TakenAt)
+	if age > s.maxAge {
+		return 0, fmt.Errorf("snapshot %s is %v old, older than %v", s.path, age.Round(time.Second), s.maxAge)
+	}
+
+	restored := 0
+	for key, entry := range snap.Entries {
+		ttl := entry.TTL - age
+		if ttl < s.minTTL {
+			ttl = s.minTTL
+		}
+
+		ok, err := s.redis.SetNX(s.ctx, key, entry.Value, ttl).Result()
+		if err != nil {
+			return restored, err
+		}
+		if ok {
+			restored++
+		}
+	}
+	return restored, nil
+}
+
+// Job is a scheduler job that keeps the snapshot file current.
+func (s *Snapshotter) Job() func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		_, err := s.Save()
+		return err
+	}
+}
@@ -0,0 +1,114 @@
+package snapshot
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go-webscraper/cachekey"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestSnapshotter(t *testing.T, path string) (*Snapshotter, *miniredis.Miniredis) {
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { rdb.Close() })
+	return New(rdb, SnapshotOption{Path: path}), mr
+}
+
+func TestSnapshotRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "snapshots", "boot.json")
+	mostActive := cachekey.MostActive.Key()
+	sector := cachekey.Sector.Key("technology")
+	options := cachekey.OptionsMostActive.Key("oi")
+
+	src, srcRedis := newTestSnapshotter(t, path)
+	srcRedis.Set(mostActive, `[{"symbol":"AAPL"}]`)
+	srcRedis.SetTTL(mostActive, 30*time.Minute)
+	srcRedis.Set(sector, `{"name":"Technology"}`)
+	srcRedis.SetTTL(sector, time.Minute)
+	srcRedis.Set(options, `[]`)
+	srcRedis.Set("session:abc", "not cached data")
+	srcRedis.HSet(cachekey.Sector.Key("energy"), "name", "Energy")
+
+	saved, err := src.Save()
+	require.NoError(t, err)
+	assert.Equal(t, 3, saved, "only string values matching the patterns are saved")
+	_, err = os.Stat(path + ".tmp")
+	assert.True(t, os.IsNotExist(err), "the temporary file is renamed into place")
+
+	dst, dstRedis := newTestSnapshotter(t, path)
+	dstRedis.Set(options, `[{"contract":"fresh"}]`)
+
+	restored, err := dst.Load()
+	require.NoError(t, err)
+	assert.Equal(t, 2, restored)
+
+	value, _ := dstRedis.Get(mostActive)
+	assert.Equal(t, `[{"symbol":"AAPL"}]`, value)
+	assert.InDelta(t, float64(30*time.Minute), float64(dstRedis.TTL(mostActive)), float64(time.Second))
+	// Entries about to expire still live long enough for the next refresh
+	assert.Equal(t, 5*time.Minute, dstRedis.TTL(sector))
+	// Keys already in Redis are left alone
+	value, _ = dstRedis.Get(options)
+	assert.Equal(t, `[{"contract":"fresh"}]`, value)
+	assert.False(t, dstRedis.Exists("session:abc"))
+}
+
+func TestSnapshotLoad(t *testing.T) {
+	write := func(t *testing.T, path string, snap Snapshot) {
+		data, err := json.Marshal(snap)
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(path, data, 0644))
+	}
+
+	t.Run("Remaining TTL", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "boot.json")
+		write(t, path, Snapshot{
+			TakenAt: time.Now().Add(-20 * time.Minute),
+			Entries: map[string]Entry{"v1:stocks:most_active": {Value: "[]", TTL: time.Hour}},
+		})
+
+		s, mr := newTestSnapshotter(t, path)
+		restored, err := s.Load()
+		require.NoError(t, err)
+		assert.Equal(t, 1, restored)
+		assert.InDelta(t, float64(40*time.Minute), float64(mr.TTL("v1:stocks:most_active")), float64(time.Second))
+	})
+
+	t.Run("Too Old", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "boot.json")
+		write(t, path, Snapshot{
+			TakenAt: time.Now().Add(-25 * time.Hour),
+			Entries: map[string]Entry{"v1:stocks:most_active": {Value: "[]", TTL: time.Hour}},
+		})
+
+		s, mr := newTestSnapshotter(t, path)
+		restored, err := s.Load()
+		assert.ErrorContains(t, err, "older than 24h0m0s")
+		assert.Zero(t, restored)
+		assert.False(t, mr.Exists("v1:stocks:most_active"))
+	})
+
+	t.Run("Missing", func(t *testing.T) {
+		s, _ := newTestSnapshotter(t, filepath.Join(t.TempDir(), "boot.json"))
+		restored, err := s.Load()
+		assert.NoError(t, err)
+		assert.Zero(t, restored)
+	})
+
+	t.Run("Corrupt", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "boot.json")
+		require.NoError(t, os.WriteFile(path, []byte("{"), 0644))
+
+		s, _ := newTestSnapshotter(t, path)
+		_, err := s.Load()
+		assert.ErrorContains(t, err, "invalid snapshot")
+	})
+}
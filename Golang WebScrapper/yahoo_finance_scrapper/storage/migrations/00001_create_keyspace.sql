@@ -0,0 +1,12 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS keyspace (
+	db         INTEGER NOT NULL,
+	key        TEXT    NOT NULL,
+	type       TEXT    NOT NULL,
+	value      TEXT    NOT NULL,
+	expires_at INTEGER NOT NULL DEFAULT 0,
+	PRIMARY KEY (db, key)
+);
+
+-- +goose Down
+DROP TABLE keyspace;
@@ -0,0 +1,244 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"log"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/pressly/goose/v3"
+	_ "modernc.org/sqlite"
+)
+
+// migrations version the file's schema, applied in order whenever it is
+// opened.
+//
+//go:embed migrations/*.sql
+var migrations embed.FS
+
+// sqliteFile keeps the keyspace in one table, a row per key holding its
+// value as JSON: a string, a hash's field map, a list's or set's members,
+// or a sorted set's member scores. expires_at is a unix time, 0 for keys
+// without a TTL.
+type sqliteFile struct {
+	db *sql.DB
+}
+
+func openSQLite(path string) (*sqliteFile, error) {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %v", path, err)
+	}
+	// One writer at a time is all SQLite allows anyway
+	db.SetMaxOpenConns(1)
+	if err := migrate(context.Background(), db); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to migrate %s: %v", path, err)
+	}
+	return &sqliteFile{db: db}, nil
+}
+
+func migrate(ctx context.Context, db *sql.DB) error {
+	fsys, err := fs.Sub(migrations, "migrations")
+	if err != nil {
+		return err
+	}
+	provider, err := goose.NewProvider(goose.DialectSQLite3, db, fsys)
+	if err != nil {
+		return err
+	}
+	results, err := provider.Up(ctx)
+	if err != nil {
+		return err
+	}
+	for _, result := range results {
+		log.Printf("Applied storage migration %s", result.Source.Path)
+	}
+	return nil
+}
+
+func (f *sqliteFile) Close() error {
+	return f.db.Close()
+}
+
+type row struct {
+	db        int
+	key       string
+	typ       string
+	value     string
+	expiresAt int64
+}
+
+// dump reads every key of m as rows. Keys are read one at a time, so a
+// key written meanwhile may be caught before or after the write, never
+// half way.
+func dump(m *miniredis.Miniredis, now time.Time) ([]row, error) {
+	var rows []row
+	for i := 0; i < databases; i++ {
+		db := m.DB(i)
+		for _, key := range db.Keys() {
+			typ := db.Type(key)
+			var value interface{}
+			var err error
+			switch typ {
+			case "string":
+				value, err = db.Get(key)
+			case "hash":
+				var fields []string
+				fields, err = db.HKeys(key)
+				hash := make(map[string]string, len(fields))
+				for _, field := range fields {
+					hash[field] = db.HGet(key, field)
+				}
+				value = hash
+			case "list":
+				value, err = db.List(key)
+			case "set":
+				value, err = db.Members(key)
+			case "zset":
+				value, err = db.SortedSet(key)
+			default:
+				log.Printf("Not persisting %s: unsupported type %s", key, typ)
+				continue
+			}
+			if err == miniredis.ErrKeyNotFound {
+				// Deleted since Keys
+				continue
+			}
+			if err != nil {
+				return nil, fmt.Errorf("failed to read %s: %v", key, err)
+			}
+			data, err := json.Marshal(value)
+			if err != nil {
+				return nil, err
+			}
+
+			var expiresAt int64
+			if ttl := db.TTL(key); ttl > 0 {
+				expiresAt = now.Add(ttl).Unix()
+			}
+			rows = append(rows, row{db: i, key: key, typ: typ, value: string(data), expiresAt: expiresAt})
+		}
+	}
+	return rows, nil
+}
+
+// save replaces the file's keyspace with m's, returning how many keys it
+// wrote.
+func (f *sqliteFile) save(m *miniredis.Miniredis, now time.Time) (int, error) {
+	rows, err := dump(m, now)
+	if err != nil {
+		return 0, err
+	}
+
+	tx, err := f.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM keyspace`); err != nil {
+		return 0, err
+	}
+	stmt, err := tx.Prepare(`INSERT INTO keyspace (db, key, type, value, expires_at) VALUES (?, ?, ?, ?, ?)`)
+	if err != nil {
+		return 0, err
+	}
+	defer stmt.Close()
+	for _, r := range rows {
+		if _, err := stmt.Exec(r.db, r.key, r.typ, r.value, r.expiresAt); err != nil {
+			return 0, fmt.Errorf("failed to write %s: %v", r.key, err)
+		}
+	}
+	return len(rows), tx.Commit()
+}
+
+// restore loads the file's keys into m, skipping those expired by now,
+// and returns how many it loaded.
+func (f *sqliteFile) restore(m *miniredis.Miniredis, now time.Time) (int, error) {
+	rows, err := f.db.Query(`SELECT db, key, type, value, expires_at FROM keyspace`)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	restored := 0
+	for rows.Next() {
+		var r row
+		if err := rows.Scan(&r.db, &r.key, &r.typ, &r.value, &r.expiresAt); err != nil {
+			return restored, err
+		}
+		if r.db < 0 || r.db >= databases {
+			continue
+		}
+		var ttl time.Duration
+		if r.expiresAt > 0 {
+			if ttl = time.Unix(r.expiresAt, 0).Sub(now); ttl <= 0 {
+				continue
+			}
+		}
+		if err := load(m.DB(r.db), r); err != nil {
+			return restored, fmt.Errorf("failed to load %s: %v", r.key, err)
+		}
+		if ttl > 0 {
+			m.DB(r.db).SetTTL(r.key, ttl)
+		}
+		restored++
+	}
+	return restored, rows.Err()
+}
+
+func load(db *miniredis.RedisDB, r row) error {
+	switch r.typ {
+	case "string":
+		var value string
+		if err := json.Unmarshal([]byte(r.value), &value); err != nil {
+			return err
+		}
+		return db.Set(r.key, value)
+	case "hash":
+		var hash map[string]string
+		if err := json.Unmarshal([]byte(r.value), &hash); err != nil {
+			return err
+		}
+		for field, value := range hash {
+			db.HSet(r.key, field, value)
+		}
+	case "list":
+		var list []string
+		if err := json.Unmarshal([]byte(r.value), &list); err != nil {
+			return err
+		}
+		if len(list) > 0 {
+			_, err := db.Push(r.key, list...)
+			return err
+		}
+	case "set":
+		var members []string
+		if err := json.Unmarshal([]byte(r.value), &members); err != nil {
+			return err
+		}
+		if len(members) > 0 {
+			_, err := db.SetAdd(r.key, members...)
+			return err
+		}
+	case "zset":
+		var scores map[string]float64
+		if err := json.Unmarshal([]byte(r.value), &scores); err != nil {
+			return err
+		}
+		for member, score := range scores {
+			if _, err := db.ZAdd(r.key, score, member); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("unsupported type %s", r.typ)
+	}
+	return nil
+}
@@ -0,0 +1,140 @@
+// Package storage runs the all-in-one mode for deployments without a Redis
+// server. An embedded Redis-compatible server takes Redis's place on its
+// address, so every store and scraper works unchanged, and its keys are
+// persisted to a single SQLite file between restarts. Pair it with the
+// memory cache backend so cached scrapes stay out of the file.
+package storage
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+const (
+	DriverRedis  = "redis"
+	DriverSQLite = "sqlite"
+)
+
+// databases is how many Redis databases are persisted, Redis's default.
+const databases = 16
+
+// Option configures the embedded server. Addr is where it listens, the
+// address clients would otherwise find Redis at; the keyspace is written
+// to Path every FlushInterval and on Close.
+type Option struct {
+	Addr          string
+	Path          string
+	FlushInterval time.Duration
+}
+
+// Server is the embedded Redis-compatible server.
+type Server struct {
+	redis    *miniredis.Miniredis
+	file     *sqliteFile
+	interval time.Duration
+
+	stop chan struct{}
+	done sync.WaitGroup
+}
+
+// Validate checks a storage driver and cache backend can be combined.
+func Validate(driver, cacheBackend string) error {
+	switch driver {
+	case "", DriverRedis:
+		return nil
+	case DriverSQLite:
+		if cacheBackend == DriverRedis || cacheBackend == "" {
+			return fmt.Errorf("storage sqlite needs the memory or none cache, not redis")
+		}
+		return nil
+	}
+	return fmt.Errorf("storage must be %s or %s", DriverRedis, DriverSQLite)
+}
+
+// Start restores the keyspace saved at opts.Path and serves it on
+// opts.Addr.
+func Start(opts Option) (*Server, error) {
+	if opts.Path == "" {
+		opts.Path = "gofinance.db"
+	}
+	if opts.FlushInterval == 0 {
+		opts.FlushInterval = 30 * time.Second
+	}
+
+	file, err := openSQLite(opts.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	m := miniredis.NewMiniRedis()
+	restored, err := file.restore(m, time.Now())
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to restore %s: %v", opts.Path, err)
+	}
+	if err := m.StartAddr(opts.Addr); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to listen on %s: %v", opts.Addr, err)
+	}
+	log.Printf("Serving embedded storage on %s, restored %d keys from %s", opts.Addr, restored, opts.Path)
+
+	s := &Server{
+		redis:    m,
+		file:     file,
+		interval: opts.FlushInterval,
+		stop:     make(chan struct{}),
+	}
+	s.done.Add(1)
+	go s.run()
+	return s, nil
+}
+
+// Addr is the address the server listens on.
+func (s *Server) Addr() string {
+	return s.redis.Addr()
+}
+
+// run expires keys as time passes, since the embedded server only ages
+// TTLs when told to, and flushes the keyspace every interval.
+func (s *Server) run() {
+	defer s.done.Done()
+	tick := time.NewTicker(time.Second)
+	defer tick.Stop()
+
+	last := time.Now()
+	lastFlush := last
+	for {
+		select {
+		case now := <-tick.C:
+			s.redis.FastForward(now.Sub(last))
+			last = now
+			if now.Sub(lastFlush) >= s.interval {
+				lastFlush = now
+				if _, err := s.file.save(s.redis, now); err != nil {
+					log.Printf("Error flushing embedded storage: %v", err)
+				}
+			}
+		case <-s.stop:
+			return
+		}
+	}
+}
+
+// Close stops serving and writes the keyspace a final time.
+func (s *Server) Close() error {
+	close(s.stop)
+	s.done.Wait()
+	s.redis.Close()
+
+	saved, err := s.file.save(s.redis, time.Now())
+	if err != nil {
+		s.file.Close()
+		return fmt.Errorf("failed to flush embedded storage: %v", err)
+	}
+	log.Printf("Saved %d keys to embedded storage", saved)
+	return s.file.Close()
+}
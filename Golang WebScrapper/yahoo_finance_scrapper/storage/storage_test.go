@@ -0,0 +1,54 @@
+package storage
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidate(t *testing.T) {
+	assert.NoError(t, Validate("", "redis"))
+	assert.NoError(t, Validate(DriverRedis, "memory"))
+	assert.NoError(t, Validate(DriverSQLite, "memory"))
+	assert.NoError(t, Validate(DriverSQLite, "none"))
+	assert.Error(t, Validate(DriverSQLite, "redis"))
+	assert.Error(t, Validate("postgres", "memory"))
+}
+
+func TestServerPersists(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "gofinance.db")
+
+	s, err := Start(Option{Addr: "127.0.0.1:0", Path: path, FlushInterval: time.Hour})
+	assert.NoError(t, err)
+	rdb := redis.NewClient(&redis.Options{Addr: s.Addr()})
+	assert.NoError(t, rdb.Set(ctx, "eod:finalized:2024-01-02", "done", time.Hour).Err())
+	assert.NoError(t, rdb.Set(ctx, "expired", "gone", time.Second).Err())
+	assert.NoError(t, rdb.HSet(ctx, "eod:AAPL", "2024-01-02", `{"close":185.64}`).Err())
+	assert.NoError(t, rdb.ZAdd(ctx, "news:archive:published", redis.Z{Score: 1704200000, Member: "https://example.com/a"}).Err())
+	assert.NoError(t, rdb.RPush(ctx, "queue", "a", "b").Err())
+	assert.NoError(t, rdb.SAdd(ctx, "universe", "AAPL", "MSFT").Err())
+	rdb.Close()
+
+	// Keys expire as wall time passes
+	time.Sleep(1500 * time.Millisecond)
+	assert.NoError(t, s.Close())
+
+	s, err = Start(Option{Addr: "127.0.0.1:0", Path: path, FlushInterval: time.Hour})
+	assert.NoError(t, err)
+	defer s.Close()
+	rdb = redis.NewClient(&redis.Options{Addr: s.Addr()})
+	defer rdb.Close()
+
+	assert.Equal(t, "done", rdb.Get(ctx, "eod:finalized:2024-01-02").Val())
+	assert.Greater(t, rdb.TTL(ctx, "eod:finalized:2024-01-02").Val(), 50*time.Minute)
+	assert.Equal(t, int64(0), rdb.Exists(ctx, "expired").Val())
+	assert.Equal(t, `{"close":185.64}`, rdb.HGet(ctx, "eod:AAPL", "2024-01-02").Val())
+	assert.Equal(t, float64(1704200000), rdb.ZScore(ctx, "news:archive:published", "https://example.com/a").Val())
+	assert.Equal(t, []string{"a", "b"}, rdb.LRange(ctx, "queue", 0, -1).Val())
+	assert.ElementsMatch(t, []string{"AAPL", "MSFT"}, rdb.SMembers(ctx, "universe").Val())
+}
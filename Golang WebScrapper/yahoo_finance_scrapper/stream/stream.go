@@ -0,0 +1,115 @@
+package stream
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// FlushEvery is how many rows an encoder writes between flushes. Flushing
+// hands each batch to the connection, so a slow client blocks the writer
+// instead of the rows piling up in memory.
+var FlushEvery = 100
+
+// CSV writes records to the response as they are produced.
+type CSV struct {
+	c      *gin.Context
+	writer *csv.Writer
+	rows   int
+}
+
+// NewCSV starts a CSV attachment named filename.
+func NewCSV(c *gin.Context, filename string) *CSV {
+	c.Header("Content-Type", "text/csv")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
+	c.Status(http.StatusOK)
+
+	return &CSV{c: c, writer: csv.NewWriter(c.Writer)}
+}
+
+// Write writes one record, returning the context's error once the client
+// has gone away.
+func (w *CSV) Write(record []string) error {
+	if err := w.c.Request.Context().Err(); err != nil {
+		return err
+	}
+	if err := w.writer.Write(record); err != nil {
+		return fmt.Errorf("failed to write CSV record: %v", err)
+	}
+
+	w.rows++
+	if w.rows%FlushEvery == 0 {
+		return w.flush()
+	}
+	return nil
+}
+
+func (w *CSV) flush() error {
+	w.writer.Flush()
+	if err := w.writer.Error(); err != nil {
+		return fmt.Errorf("failed to flush CSV: %v", err)
+	}
+	w.c.Writer.Flush()
+	return nil
+}
+
+// Close flushes whatever is still buffered.
+func (w *CSV) Close() error {
+	return w.flush()
+}
+
+// JSON writes the usual {"status":"success","data":[...]} envelope one
+// element at a time.
+type JSON struct {
+	c    *gin.Context
+	rows int
+}
+
+// NewJSON opens the envelope.
+func NewJSON(c *gin.Context) (*JSON, error) {
+	c.Header("Content-Type", "application/json; charset=utf-8")
+	c.Status(http.StatusOK)
+
+	if _, err := c.Writer.WriteString(`{"status":"success","data":[`); err != nil {
+		return nil, fmt.Errorf("failed to open JSON stream: %v", err)
+	}
+	return &JSON{c: c}, nil
+}
+
+// Write appends one element to the data array, returning the context's
+// error once the client has gone away.
+func (w *JSON) Write(v interface{}) error {
+	if err := w.c.Request.Context().Err(); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to encode JSON row: %v", err)
+	}
+	if w.rows > 0 {
+		data = append([]byte{','}, data...)
+	}
+	if _, err := w.c.Writer.Write(data); err != nil {
+		return fmt.Errorf("failed to write JSON row: %v", err)
+	}
+
+	w.rows++
+	if w.rows%FlushEvery == 0 {
+		w.c.Writer.Flush()
+	}
+	return nil
+}
+
+// Close ends the data array and the envelope. A stream that stopped early
+// is left unterminated so clients can tell it was cut short.
+func (w *JSON) Close() error {
+	if _, err := w.c.Writer.WriteString("]}"); err != nil {
+		return fmt.Errorf("failed to close JSON stream: %v", err)
+	}
+	w.c.Writer.Flush()
+	return nil
+}
@@ -0,0 +1,73 @@
+package stream
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCSV(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("GET", "/", nil)
+
+	writer := NewCSV(c, "rows.csv")
+	assert.NoError(t, writer.Write([]string{"symbol", "price"}))
+	for i := 0; i < FlushEvery+1; i++ {
+		assert.NoError(t, writer.Write([]string{"AAPL", "1.5"}))
+	}
+	assert.True(t, w.Flushed)
+	assert.NoError(t, writer.Close())
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "attachment; filename=rows.csv", w.Header().Get("Content-Disposition"))
+	lines := strings.Split(strings.TrimSpace(w.Body.String()), "\n")
+	assert.Len(t, lines, FlushEvery+2)
+	assert.Equal(t, "symbol,price", lines[0])
+}
+
+func TestJSON(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("Envelope", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest("GET", "/", nil)
+
+		writer, err := NewJSON(c)
+		assert.NoError(t, err)
+		assert.NoError(t, writer.Write(gin.H{"symbol": "AAPL"}))
+		assert.NoError(t, writer.Write(gin.H{"symbol": "MSFT"}))
+		assert.NoError(t, writer.Close())
+
+		var body struct {
+			Status string              `json:"status"`
+			Data   []map[string]string `json:"data"`
+		}
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		assert.Equal(t, "success", body.Status)
+		assert.Equal(t, []map[string]string{{"symbol": "AAPL"}, {"symbol": "MSFT"}}, body.Data)
+	})
+
+	t.Run("Client Gone", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		ctx, cancel := context.WithCancel(context.Background())
+		c.Request = httptest.NewRequest("GET", "/", nil).WithContext(ctx)
+
+		writer, err := NewJSON(c)
+		assert.NoError(t, err)
+		assert.NoError(t, writer.Write(gin.H{"symbol": "AAPL"}))
+		cancel()
+		assert.ErrorIs(t, writer.Write(gin.H{"symbol": "MSFT"}), context.Canceled)
+		assert.NotContains(t, w.Body.String(), "MSFT")
+	})
+}
@@ -0,0 +1,129 @@
+package summarize
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-webscraper/newsarchive"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultDigestStories = 5
+	maxDigestStories     = 20
+	digestSentences      = 5
+)
+
+// topicQueries expand digest topics into archive search terms. Other
+// topics are searched as given.
+var topicQueries = map[string]string{
+	"tech":       "technology tech software semiconductor chip chips ai apple microsoft nvidia alphabet meta",
+	"energy":     "energy oil crude gas opec drilling",
+	"rates":      "fed rates inflation treasury yields powell",
+	"earnings":   "earnings revenue quarter guidance profit",
+	"crypto":     "crypto bitcoin ethereum blockchain",
+	"healthcare": "healthcare pharma biotech drug fda",
+	"banks":      "bank banks lending jpmorgan goldman",
+}
+
+// Story is one article in a digest.
+type Story struct {
+	Title     string `json:"title"`
+	Link      string `json:"link"`
+	Publisher string `json:"publisher,omitempty"`
+	Date      string `json:"date"`
+	Summary   string `json:"summary"`
+}
+
+// Digest combines the day's top stories on a topic.
+type Digest struct {
+	Topic   string  `json:"topic,omitempty"`
+	Since   string  `json:"since"`
+	Summary string  `json:"summary"`
+	Stories []Story `json:"stories"`
+}
+
+// BuildDigest picks the top archived stories on topic published since,
+// the most relevant first or the latest without a topic, and summarizes
+// them together.
+func BuildDigest(ctx context.Context, store *newsarchive.Store, summarizer Summarizer, topic string, since time.Time, limit int) (*Digest, error) {
+	text := topic
+	if query, ok := topicQueries[strings.ToLower(topic)]; ok {
+		text = query
+	}
+	results, _, err := store.Search(newsarchive.Query{Text: text, From: since, Limit: limit})
+	if err != nil {
+		return nil, err
+	}
+
+	digest := &Digest{Topic: topic, Since: since.Format(time.RFC3339), Stories: make([]Story, 0, len(results))}
+	leads := make([]string, 0, len(results))
+	for _, result := range results {
+		summary := result.Summary
+		if summary == "" {
+			if summary, err = summarizer.Summarize(ctx, result.Title, result.Snippet, 3); err != nil {
+				return nil, err
+			}
+		}
+		digest.Stories = append(digest.Stories, Story{
+			Title:     result.Title,
+			Link:      result.Link,
+			Publisher: result.Publisher,
+			Date:      result.DatePublished,
+			Summary:   summary,
+		})
+		// Each story's lead sentence, so the digest spans stories rather
+		// than retelling the longest one
+		if sentences := Sentences(summary); len(sentences) > 0 {
+			leads = append(leads, sentences[0])
+		}
+	}
+
+	if digest.Summary, err = summarizer.Summarize(ctx, topic, strings.Join(leads, " "), digestSentences); err != nil {
+		return nil, err
+	}
+	return digest, nil
+}
+
+// HandleDigest serves GET /api/news/digest?topic=tech&limit=5 from the
+// articles archived since dayStart of the current time.
+func HandleDigest(store *newsarchive.Store, summarizer Summarizer, dayStart func(time.Time) time.Time) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if summarizer == nil {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "summaries are disabled",
+			})
+			return
+		}
+
+		limit := defaultDigestStories
+		if value := c.Query("limit"); value != "" {
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 || n > maxDigestStories {
+				c.JSON(http.StatusBadRequest, gin.H{
+					"error": fmt.Sprintf("limit must be between 1 and %d", maxDigestStories),
+				})
+				return
+			}
+			limit = n
+		}
+
+		digest, err := BuildDigest(c.Request.Context(), store, summarizer, strings.TrimSpace(c.Query("topic")), dayStart(time.Now()), limit)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"status": "success",
+			"data":   digest,
+		})
+	}
+}
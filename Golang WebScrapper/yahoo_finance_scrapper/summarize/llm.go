@@ -0,0 +1,114 @@
+package summarize
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+)
+
+// maxPromptRunes bounds the article text sent per request.
+const maxPromptRunes = 12000
+
+// llm asks an OpenAI-compatible chat completions endpoint for summaries.
+type llm struct {
+	client   *http.Client
+	url      string
+	model    string
+	apiKey   string
+	fallback Extractive
+}
+
+func newLLM(opts Option) *llm {
+	return &llm{
+		client: &http.Client{Timeout: opts.Timeout},
+		url:    opts.URL,
+		model:  opts.Model,
+		apiKey: opts.APIKey,
+	}
+}
+
+type chatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type chatRequest struct {
+	Model       string        `json:"model"`
+	Messages    []chatMessage `json:"messages"`
+	MaxTokens   int           `json:"max_tokens"`
+	Temperature float64       `json:"temperature"`
+}
+
+type chatResponse struct {
+	Choices []struct {
+		Message chatMessage `json:"message"`
+	} `json:"choices"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// Summarize falls back to the extractive summary when the endpoint fails,
+// so an outage degrades summaries rather than dropping them.
+func (l *llm) Summarize(ctx context.Context, title, text string, sentences int) (string, error) {
+	summary, err := l.complete(ctx, title, text, sentences)
+	if err != nil {
+		log.Printf("Error summarizing %q, using an extractive summary: %v", title, err)
+		return l.fallback.Summarize(ctx, title, text, sentences)
+	}
+	return summary, nil
+}
+
+func (l *llm) complete(ctx context.Context, title, text string, sentences int) (string, error) {
+	if runes := []rune(text); len(runes) > maxPromptRunes {
+		text = string(runes[:maxPromptRunes])
+	}
+	body, err := json.Marshal(chatRequest{
+		Model: l.model,
+		Messages: []chatMessage{
+			{Role: "system", Content: fmt.Sprintf("Summarize the financial news article in at most %d plain sentences. State only facts from the article.", sentences)},
+			{Role: "user", Content: title + "\n\n" + text},
+		},
+		MaxTokens:   60 * sentences,
+		Temperature: 0.2,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, l.url, bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if l.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+l.apiKey)
+	}
+
+	resp, err := l.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var completion chatResponse
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&completion); err != nil {
+		return "", fmt.Errorf("status %d: %v", resp.StatusCode, err)
+	}
+	if completion.Error != nil {
+		return "", fmt.Errorf("status %d: %s", resp.StatusCode, completion.Error.Message)
+	}
+	if resp.StatusCode != http.StatusOK || len(completion.Choices) == 0 {
+		return "", fmt.Errorf("status %d with no summary", resp.StatusCode)
+	}
+	summary := strings.Join(strings.Fields(completion.Choices[0].Message.Content), " ")
+	if summary == "" {
+		return "", fmt.Errorf("empty summary")
+	}
+	return summary, nil
+}
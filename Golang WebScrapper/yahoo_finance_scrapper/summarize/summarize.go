@@ -0,0 +1,184 @@
+// Package summarize condenses article text into a few sentences. The
+// default extractive summarizer picks the sentences sharing the most terms
+// with the rest of the article; an OpenAI-compatible chat endpoint can be
+// plugged in instead, falling back to the extractive summary when it
+// fails.
+package summarize
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"go-webscraper/newsarchive"
+)
+
+const (
+	ProviderExtractive = "extractive"
+	ProviderLLM        = "llm"
+	ProviderNone       = "none"
+)
+
+// Summarizer condenses text, titled title, into at most sentences
+// sentences.
+type Summarizer interface {
+	Summarize(ctx context.Context, title, text string, sentences int) (string, error)
+}
+
+// Option selects the summarizer. URL, Model and APIKey configure the llm
+// provider's chat completions endpoint.
+type Option struct {
+	Provider string
+	URL      string
+	Model    string
+	APIKey   string
+	Timeout  time.Duration
+}
+
+// New returns the summarizer opts selects, or nil for the none provider.
+func New(opts Option) (Summarizer, error) {
+	switch opts.Provider {
+	case "", ProviderExtractive:
+		return Extractive{}, nil
+	case ProviderNone:
+		return nil, nil
+	case ProviderLLM:
+		if opts.URL == "" || opts.Model == "" {
+			return nil, fmt.Errorf("the llm summarizer needs a url and a model")
+		}
+		if opts.Timeout == 0 {
+			opts.Timeout = 20 * time.Second
+		}
+		return newLLM(opts), nil
+	}
+	return nil, fmt.Errorf("summarizer provider must be one of: %s, %s, %s", ProviderExtractive, ProviderLLM, ProviderNone)
+}
+
+// minSentenceWords keeps datelines and captions out of summaries when the
+// article has enough real sentences.
+const minSentenceWords = 5
+
+// abbreviations end in a period without ending the sentence.
+var abbreviations = map[string]bool{
+	"mr": true, "mrs": true, "ms": true, "dr": true, "st": true, "jr": true, "sr": true,
+	"inc": true, "corp": true, "co": true, "ltd": true, "vs": true, "no": true,
+	"jan": true, "feb": true, "mar": true, "apr": true, "aug": true, "sept": true,
+	"sep": true, "oct": true, "nov": true, "dec": true, "u.s": true, "u.k": true,
+}
+
+// Sentences splits text at sentence-ending punctuation followed by a
+// space and a capital, digit or quote, skipping common abbreviations and
+// initials.
+func Sentences(text string) []string {
+	text = strings.Join(strings.Fields(text), " ")
+	runes := []rune(text)
+
+	var sentences []string
+	start := 0
+	for i, r := range runes {
+		if r != '.' && r != '!' && r != '?' {
+			continue
+		}
+		end := i + 1
+		for end < len(runes) && strings.ContainsRune(`"')]`+"”’", runes[end]) {
+			end++
+		}
+		if end+1 >= len(runes) || runes[end] != ' ' {
+			continue
+		}
+		next := runes[end+1]
+		if !unicode.IsUpper(next) && !unicode.IsDigit(next) && !strings.ContainsRune(`"'`+"“‘", next) {
+			continue
+		}
+		if r == '.' {
+			word := strings.ToLower(lastWord(runes[start:i]))
+			if abbreviations[word] || len([]rune(word)) == 1 {
+				continue
+			}
+		}
+		sentences = append(sentences, strings.TrimSpace(string(runes[start:end])))
+		start = end + 1
+	}
+	if rest := strings.TrimSpace(string(runes[start:])); rest != "" {
+		sentences = append(sentences, rest)
+	}
+	return sentences
+}
+
+func lastWord(runes []rune) string {
+	i := len(runes)
+	for i > 0 && runes[i-1] != ' ' {
+		i--
+	}
+	return strings.TrimLeft(string(runes[i:]), `"'(`)
+}
+
+// Extractive summarizes without any external service.
+type Extractive struct{}
+
+// Summarize scores each sentence by how frequent its terms are across the
+// text, with title terms counting double, and returns the best ones in
+// their original order. The lead sentence gets a bonus since news leads
+// with the story.
+func (Extractive) Summarize(_ context.Context, title, text string, sentences int) (string, error) {
+	all := Sentences(text)
+	if len(all) <= sentences {
+		return strings.Join(all, " "), nil
+	}
+
+	frequency := make(map[string]float64)
+	for _, term := range newsarchive.Tokenize(text) {
+		frequency[term]++
+	}
+	for _, term := range newsarchive.Tokenize(title) {
+		frequency[term] *= 2
+	}
+
+	long := 0
+	for _, sentence := range all {
+		if len(strings.Fields(sentence)) >= minSentenceWords {
+			long++
+		}
+	}
+
+	type scored struct {
+		index int
+		score float64
+	}
+	candidates := make([]scored, 0, len(all))
+	for i, sentence := range all {
+		if long >= sentences && len(strings.Fields(sentence)) < minSentenceWords {
+			continue
+		}
+		terms := newsarchive.Tokenize(sentence)
+		if len(terms) == 0 {
+			continue
+		}
+		var score float64
+		for _, term := range terms {
+			score += frequency[term]
+		}
+		// Normalize so long sentences don't win on length alone
+		score /= math.Sqrt(float64(len(terms)))
+		if i == 0 {
+			score *= 1.5
+		}
+		candidates = append(candidates, scored{i, score})
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].score > candidates[j].score })
+	if len(candidates) > sentences {
+		candidates = candidates[:sentences]
+	}
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].index < candidates[j].index })
+
+	picked := make([]string, len(candidates))
+	for i, c := range candidates {
+		picked[i] = all[c.index]
+	}
+	return strings.Join(picked, " "), nil
+}
@@ -0,0 +1,90 @@
+package summarize
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const article = `The Federal Reserve held interest rates steady on Wednesday. ` +
+	`Officials said inflation had cooled but remained above the 2% target. ` +
+	`Photo: Getty. ` +
+	`Mr. Powell told reporters that rate cuts were not yet on the table. ` +
+	`Markets rallied after the decision, with the S&P 500 up 1.2%. ` +
+	`Treasury yields fell as traders priced in rate cuts later in the year.`
+
+func TestSentences(t *testing.T) {
+	sentences := Sentences(article)
+	assert.Len(t, sentences, 6)
+	assert.Equal(t, "Mr. Powell told reporters that rate cuts were not yet on the table.", sentences[3])
+
+	assert.Equal(t, []string{"Shares of U.S. Steel jumped.", "\"It's a deal,\" he said."},
+		Sentences("Shares of U.S. Steel jumped. \"It's a deal,\" he said."))
+	assert.Equal(t, []string{"Apple rose 2.5% to $227.55 today"}, Sentences("Apple rose 2.5% to $227.55 today"))
+}
+
+func TestExtractive(t *testing.T) {
+	summary, err := Extractive{}.Summarize(context.Background(), "Fed holds rates steady", article, 3)
+	assert.NoError(t, err)
+
+	picked := Sentences(summary)
+	assert.Len(t, picked, 3)
+	// The lead stays, captions never make it, and order is preserved
+	assert.Equal(t, "The Federal Reserve held interest rates steady on Wednesday.", picked[0])
+	assert.NotContains(t, summary, "Photo")
+
+	short, err := Extractive{}.Summarize(context.Background(), "", "One line only.", 3)
+	assert.NoError(t, err)
+	assert.Equal(t, "One line only.", short)
+}
+
+func TestNew(t *testing.T) {
+	s, err := New(Option{})
+	assert.NoError(t, err)
+	assert.IsType(t, Extractive{}, s)
+
+	s, err = New(Option{Provider: ProviderNone})
+	assert.NoError(t, err)
+	assert.Nil(t, s)
+
+	_, err = New(Option{Provider: ProviderLLM})
+	assert.Error(t, err)
+	_, err = New(Option{Provider: "magic"})
+	assert.Error(t, err)
+}
+
+func TestLLM(t *testing.T) {
+	fail := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+		var req chatRequest
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		assert.Equal(t, "test-model", req.Model)
+
+		if fail {
+			w.WriteHeader(http.StatusTooManyRequests)
+			w.Write([]byte(`{"error":{"message":"rate limited"}}`))
+			return
+		}
+		w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"The Fed held rates.\n Markets rallied."}}]}`))
+	}))
+	defer server.Close()
+
+	s, err := New(Option{Provider: ProviderLLM, URL: server.URL, Model: "test-model", APIKey: "secret", Timeout: time.Second})
+	assert.NoError(t, err)
+
+	summary, err := s.Summarize(context.Background(), "Fed holds rates steady", article, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, "The Fed held rates. Markets rallied.", summary)
+
+	// Failures fall back to an extractive summary
+	fail = true
+	summary, err = s.Summarize(context.Background(), "Fed holds rates steady", article, 2)
+	assert.NoError(t, err)
+	assert.Len(t, Sentences(summary), 2)
+}
@@ -0,0 +1,79 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go-webscraper/pkg/yahoo"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/gocolly/colly"
+	"github.com/redis/go-redis/extra/redisotel/v9"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func keepGlobals(t *testing.T) {
+	provider, propagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	t.Cleanup(func() {
+		otel.SetTracerProvider(provider)
+		otel.SetTextMapPropagator(propagator)
+	})
+}
+
+func TestSetupDisabled(t *testing.T) {
+	keepGlobals(t)
+	before := otel.GetTracerProvider()
+
+	shutdown, err := Setup(TracingOption{Enabled: false, Endpoint: "collector:4317"})
+	require.NoError(t, err)
+	assert.Equal(t, before, otel.GetTracerProvider(), "the no-op provider stays in place")
+	assert.NoError(t, shutdown(context.Background()))
+}
+
+func TestSpans(t *testing.T) {
+	keepGlobals(t)
+	exporter := tracetest.NewInMemoryExporter()
+	provider, err := install(TracingOption{ServiceName: "test", SampleRatio: 1}, exporter)
+	require.NoError(t, err)
+	defer provider.Shutdown(context.Background())
+
+	// gin
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(otelgin.Middleware("test"))
+	r.GET("/ping", func(c *gin.Context) { c.Status(http.StatusOK) })
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/ping", nil))
+
+	// redis
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer rdb.Close()
+	require.NoError(t, redisotel.InstrumentTracing(rdb))
+	require.NoError(t, rdb.Set(context.Background(), "key", "value", 0).Err())
+
+	// colly
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("<html></html>"))
+	}))
+	defer srv.Close()
+	c := colly.NewCollector()
+	yahoo.TraceCollector(context.Background(), c)
+	require.NoError(t, c.Visit(srv.URL))
+
+	require.NoError(t, provider.ForceFlush(context.Background()))
+	names := make(map[string]bool)
+	for _, span := range exporter.GetSpans() {
+		names[span.Name] = true
+	}
+	assert.True(t, names["/ping"] || names["GET /ping"], "gin span, got %v", names)
+	assert.True(t, names["set"], "redis span, got %v", names)
+	assert.True(t, names["colly.visit"], "colly span, got %v", names)
+}
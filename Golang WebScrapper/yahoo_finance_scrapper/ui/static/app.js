@@ -0,0 +1,135 @@
+"use strict";
+
+// Refresh as often as the server refreshes its stock caches by default.
+const REFRESH_MS = 5 * 60 * 1000;
+const HEADLINES = 20;
+
+async function api(path) {
+  const res = await fetch(path, { headers: { Accept: "application/json" } });
+  const body = await res.json().catch(() => ({}));
+  if (!res.ok) {
+    throw new Error(body.error || `${path} failed with ${res.status}`);
+  }
+  if (body.demo) {
+    document.getElementById("demo").hidden = false;
+  }
+  return body.data;
+}
+
+function el(tag, text, className) {
+  const node = document.createElement(tag);
+  if (text !== undefined) node.textContent = text;
+  if (className) node.className = className;
+  return node;
+}
+
+function signed(value, suffix = "") {
+  const n = Number(value) || 0;
+  return `${n > 0 ? "+" : ""}${n.toFixed(2)}${suffix}`;
+}
+
+function showError(container, err) {
+  container.replaceChildren(el("p", err.message, "error"));
+}
+
+function renderMovers(id, stocks) {
+  const table = document.getElementById(id);
+  table.replaceChildren();
+  for (const stock of (stocks || []).slice(0, 10)) {
+    const row = table.insertRow();
+    row.className = "clickable";
+    row.addEventListener("click", () => showChart(stock.symbol));
+    const symbol = row.insertCell();
+    symbol.textContent = stock.symbol;
+    symbol.title = stock.name;
+    row.appendChild(el("td", Number(stock.price).toFixed(2), "num"));
+    const direction = stock.change_percentage >= 0 ? "up" : "down";
+    row.appendChild(el("td", signed(stock.change_percentage, "%"), `num ${direction}`));
+  }
+}
+
+// showChart loads the symbol's server-rendered chart of stored prices.
+function showChart(symbol) {
+  const section = document.getElementById("chart-section");
+  const chart = document.getElementById("chart");
+  const error = document.getElementById("chart-error");
+  document.getElementById("chart-title").textContent = symbol;
+  chart.alt = `${symbol} price chart`;
+  chart.hidden = false;
+  error.hidden = true;
+  chart.onerror = () => {
+    chart.hidden = true;
+    error.hidden = false;
+  };
+  chart.src = `/api/chart/${encodeURIComponent(symbol)}.png?range=1mo`;
+  section.hidden = false;
+  section.scrollIntoView({ behavior: "smooth" });
+}
+
+// tileColor shades green for gains and red for losses, saturating at 3%.
+function tileColor(performance) {
+  const strength = Math.min(Math.abs(performance) / 3, 1);
+  const lightness = 45 - strength * 20;
+  return performance >= 0 ? `hsl(140, 60%, ${lightness}%)` : `hsl(0, 65%, ${lightness}%)`;
+}
+
+function renderHeatmap(sectors) {
+  const heatmap = document.getElementById("heatmap");
+  const list = Object.values(sectors || {}).sort((a, b) => b.performance - a.performance);
+  heatmap.replaceChildren(
+    ...list.map((sector) => {
+      const tile = el("div", undefined, "tile");
+      tile.style.background = tileColor(sector.performance);
+      tile.append(el("span", sector.name), el("strong", signed(sector.performance, "%")));
+      tile.title = `1M ${signed(sector.performance_1m, "%")}, market cap ${sector.market_cap || "n/a"}`;
+      return tile;
+    })
+  );
+}
+
+function renderNews(articles) {
+  const news = document.getElementById("news");
+  news.replaceChildren(
+    ...(articles || []).slice(0, HEADLINES).map((article) => {
+      const item = el("li");
+      let link;
+      try {
+        link = new URL(article.link);
+      } catch {
+        link = null;
+      }
+      if (link && (link.protocol === "https:" || link.protocol === "http:")) {
+        const a = el("a", article.title);
+        a.href = link.href;
+        a.target = "_blank";
+        a.rel = "noopener noreferrer";
+        item.appendChild(a);
+      } else {
+        item.appendChild(el("span", article.title));
+      }
+      item.appendChild(el("small", article.date || ""));
+      return item;
+    })
+  );
+}
+
+async function refresh() {
+  const overview = api("/api/stock?category=overview")
+    .then((data) => {
+      for (const id of ["most_active", "gainers", "losers"]) renderMovers(id, data[id]);
+    })
+    .catch((err) => showError(document.querySelector(".tables"), err));
+  const sectors = api("/api/sector?all=true")
+    .then(renderHeatmap)
+    .catch((err) => showError(document.getElementById("heatmap"), err));
+  const news = api("/api/news")
+    .then(renderNews)
+    .catch((err) => showError(document.getElementById("news"), err));
+
+  await Promise.all([overview, sectors, news]);
+  document.getElementById("updated").textContent = `Updated ${new Date().toLocaleTimeString()}`;
+}
+
+document.getElementById("refresh").addEventListener("click", refresh);
+refresh();
+setInterval(refresh, REFRESH_MS);
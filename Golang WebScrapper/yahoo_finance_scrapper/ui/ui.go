@@ -0,0 +1,36 @@
+package ui
+
+import (
+	"embed"
+	"io/fs"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// static is a single page that renders the market overview, a sector
+// heatmap and the news feed from the public API, so the server is usable
+// without a separate frontend.
+//
+//go:embed static
+var static embed.FS
+
+// Register serves the UI under /ui. Its tables come from /api endpoints
+// demo mode also serves, so it works in both; price charts need stored
+// history and show a placeholder in demo mode.
+func Register(r *gin.Engine) {
+	files, err := fs.Sub(static, "static")
+	if err != nil {
+		panic(err)
+	}
+
+	r.GET("/ui", func(c *gin.Context) {
+		c.Redirect(http.StatusMovedPermanently, "/ui/")
+	})
+	ui := r.Group("/ui")
+	ui.Use(func(c *gin.Context) {
+		c.Header("Cache-Control", "public, max-age=300")
+		c.Next()
+	})
+	ui.StaticFS("/", http.FS(files))
+}
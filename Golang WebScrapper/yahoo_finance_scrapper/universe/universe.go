@@ -0,0 +1,313 @@
+package universe
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+const listsKey = "universe:lists"
+
+// MaxSymbols bounds one list; the largest index lists run to a few
+// thousand names.
+const MaxSymbols = 5000
+
+var (
+	validName   = regexp.MustCompile(`^[a-z0-9_-]{1,32}$`)
+	validSymbol = regexp.MustCompile(`^[A-Z0-9.\-^=]{1,12}$`)
+)
+
+// List is a named set of symbols, e.g. "sp500", "nasdaq100" or a custom
+// list. The union of all lists is the universe the scheduler keeps fresh.
+type List struct {
+	Name        string    `json:"name"`
+	Description string    `json:"description,omitempty"`
+	Symbols     []string  `json:"symbols"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+type Store struct {
+	redis *redis.Client
+	ctx   context.Context
+}
+
+func NewStore(rdb *redis.Client) *Store {
+	return &Store{
+		redis: rdb,
+		ctx:   context.Background(),
+	}
+}
+
+func (s *Store) Save(list *List) error {
+	data, err := json.Marshal(list)
+	if err != nil {
+		return err
+	}
+	return s.redis.HSet(s.ctx, listsKey, list.Name, data).Err()
+}
+
+func (s *Store) Get(name string) (*List, error) {
+	data, err := s.redis.HGet(s.ctx, listsKey, name).Result()
+	if err != nil {
+		if err == redis.Nil {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var list List
+	if err := json.Unmarshal([]byte(data), &list); err != nil {
+		return nil, err
+	}
+	return &list, nil
+}
+
+func (s *Store) Delete(name string) error {
+	return s.redis.HDel(s.ctx, listsKey, name).Err()
+}
+
+func (s *Store) All() ([]*List, error) {
+	values, err := s.redis.HGetAll(s.ctx, listsKey).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	lists := make([]*List, 0, len(values))
+	for _, value := range values {
+		var list List
+		if err := json.Unmarshal([]byte(value), &list); err != nil {
+			continue
+		}
+		lists = append(lists, &list)
+	}
+	sort.Slice(lists, func(i, j int) bool {
+		return lists[i].Name < lists[j].Name
+	})
+	return lists, nil
+}
+
+// Symbols returns the sorted union of every list.
+func (s *Store) Symbols() ([]string, error) {
+	lists, err := s.All()
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	symbols := make([]string, 0)
+	for _, list := range lists {
+		for _, symbol := range list.Symbols {
+			if !seen[symbol] {
+				seen[symbol] = true
+				symbols = append(symbols, symbol)
+			}
+		}
+	}
+	sort.Strings(symbols)
+	return symbols, nil
+}
+
+// ParseCSV reads symbols from the "symbol" or "ticker" column when the
+// first row is a header naming one, otherwise from the first column. Blank
+// and duplicate symbols are skipped.
+func ParseCSV(r io.Reader) ([]string, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("invalid CSV: %v", err)
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("CSV is empty")
+	}
+
+	column, first := 0, 1
+	for i, field := range records[0] {
+		name := strings.ToLower(strings.TrimSpace(field))
+		if name == "symbol" || name == "ticker" {
+			column, first = i, 2
+			records = records[1:]
+			break
+		}
+	}
+
+	seen := make(map[string]bool)
+	symbols := make([]string, 0, len(records))
+	for row, record := range records {
+		if column >= len(record) {
+			continue
+		}
+		symbol := strings.ToUpper(strings.TrimSpace(record[column]))
+		if symbol == "" || seen[symbol] {
+			continue
+		}
+		if !validSymbol.MatchString(symbol) {
+			return nil, fmt.Errorf("invalid symbol %q on row %d", symbol, row+first)
+		}
+		seen[symbol] = true
+		symbols = append(symbols, symbol)
+	}
+	if len(symbols) > MaxSymbols {
+		return nil, fmt.Errorf("a list holds at most %d symbols", MaxSymbols)
+	}
+	return symbols, nil
+}
+
+func HandleListUniverse(store *Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		lists, err := store.All()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"status": "success",
+			"data":   lists,
+		})
+	}
+}
+
+func HandleGetList(store *Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		list, err := store.Get(c.Param("name"))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+		if list == nil {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "list not found",
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"status": "success",
+			"data":   list,
+		})
+	}
+}
+
+// HandleImportList replaces the named list with the symbols in the CSV
+// request body, creating it if needed. ?mode=append merges them into the
+// existing list instead.
+func HandleImportList(store *Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		name := c.Param("name")
+		if !validName.MatchString(name) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "list name must be 1-32 lowercase letters, digits, '-' or '_'",
+			})
+			return
+		}
+		mode := c.DefaultQuery("mode", "replace")
+		if mode != "replace" && mode != "append" {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "mode must be replace or append",
+			})
+			return
+		}
+
+		symbols, err := ParseCSV(http.MaxBytesReader(c.Writer, c.Request.Body, 1<<20))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		list, err := store.Get(name)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+		if list == nil {
+			list = &List{Name: name}
+		}
+		if mode == "append" {
+			seen := make(map[string]bool, len(list.Symbols))
+			for _, symbol := range list.Symbols {
+				seen[symbol] = true
+			}
+			for _, symbol := range symbols {
+				if !seen[symbol] {
+					list.Symbols = append(list.Symbols, symbol)
+				}
+			}
+			if len(list.Symbols) > MaxSymbols {
+				c.JSON(http.StatusBadRequest, gin.H{
+					"error": fmt.Sprintf("a list holds at most %d symbols", MaxSymbols),
+				})
+				return
+			}
+		} else {
+			list.Symbols = symbols
+		}
+		if description := c.Query("description"); description != "" {
+			list.Description = description
+		}
+		list.UpdatedAt = time.Now()
+
+		if err := store.Save(list); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		c.Set("audit_target", list.Name)
+		c.JSON(http.StatusOK, gin.H{
+			"status": "success",
+			"data":   list,
+		})
+	}
+}
+
+func HandleDeleteList(store *Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		name := c.Param("name")
+		list, err := store.Get(name)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+		if list == nil {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "list not found",
+			})
+			return
+		}
+		if err := store.Delete(name); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		c.Set("audit_target", name)
+		c.JSON(http.StatusOK, gin.H{
+			"status": "success",
+		})
+	}
+}
@@ -0,0 +1,32 @@
+package universe
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseCSV(t *testing.T) {
+	t.Run("HeaderColumn", func(t *testing.T) {
+		symbols, err := ParseCSV(strings.NewReader("Name,Symbol,Weight\nApple,aapl,7.1\nMicrosoft,MSFT,6.5\nApple,AAPL,7.1\n"))
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"AAPL", "MSFT"}, symbols)
+	})
+
+	t.Run("FirstColumn", func(t *testing.T) {
+		symbols, err := ParseCSV(strings.NewReader("BRK.B\n\n^GSPC,index\n"))
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"BRK.B", "^GSPC"}, symbols)
+	})
+
+	t.Run("InvalidSymbol", func(t *testing.T) {
+		_, err := ParseCSV(strings.NewReader("ticker\nAAPL\nnot a symbol\n"))
+		assert.EqualError(t, err, `invalid symbol "NOT A SYMBOL" on row 3`)
+	})
+
+	t.Run("Empty", func(t *testing.T) {
+		_, err := ParseCSV(strings.NewReader(""))
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,99 @@
+package upstream
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"go-webscraper/metrics"
+)
+
+// ErrPageTooLarge aborts a page past the configured size limit.
+var ErrPageTooLarge = errors.New("page exceeds upstream size limit")
+
+// guard bounds every page fetched through the transport: a page whose
+// body grows past maxBytes is aborted rather than buffered, and one not
+// fully read within timeout is cancelled, so a single pathological page
+// fails on its own instead of stalling or bloating the crawl around it.
+type guard struct {
+	base     http.RoundTripper
+	maxBytes int64
+	timeout  time.Duration
+}
+
+func newGuard(base http.RoundTripper, maxBytes int64, timeout time.Duration) *guard {
+	return &guard{base: base, maxBytes: maxBytes, timeout: timeout}
+}
+
+func (g *guard) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx, cancel := req.Context(), context.CancelFunc(func() {})
+	if g.timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, g.timeout)
+		req = req.WithContext(ctx)
+	}
+
+	resp, err := g.base.RoundTrip(req)
+	if err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			metrics.UpstreamAborts.WithLabelValues("deadline").Inc()
+		}
+		cancel()
+		return nil, err
+	}
+	if g.maxBytes > 0 && resp.ContentLength > g.maxBytes {
+		resp.Body.Close()
+		cancel()
+		metrics.UpstreamAborts.WithLabelValues("size").Inc()
+		return nil, fmt.Errorf("%w: %s is %d bytes", ErrPageTooLarge, req.URL, resp.ContentLength)
+	}
+
+	resp.Body = &guardedBody{
+		body:     resp.Body,
+		ctx:      ctx,
+		cancel:   cancel,
+		url:      req.URL.String(),
+		maxBytes: g.maxBytes,
+	}
+	return resp, nil
+}
+
+// guardedBody counts what is read of a page and holds its deadline until
+// closed.
+type guardedBody struct {
+	body     io.ReadCloser
+	ctx      context.Context
+	cancel   context.CancelFunc
+	url      string
+	maxBytes int64
+
+	read    int64
+	counted sync.Once
+}
+
+func (b *guardedBody) Read(p []byte) (int, error) {
+	n, err := b.body.Read(p)
+	b.read += int64(n)
+	if b.maxBytes > 0 && b.read > b.maxBytes {
+		b.abort("size")
+		return n, fmt.Errorf("%w: %s passed %d bytes", ErrPageTooLarge, b.url, b.maxBytes)
+	}
+	if err != nil && err != io.EOF && b.ctx.Err() == context.DeadlineExceeded {
+		b.abort("deadline")
+	}
+	return n, err
+}
+
+func (b *guardedBody) abort(reason string) {
+	b.counted.Do(func() {
+		metrics.UpstreamAborts.WithLabelValues(reason).Inc()
+	})
+}
+
+func (b *guardedBody) Close() error {
+	defer b.cancel()
+	return b.body.Close()
+}
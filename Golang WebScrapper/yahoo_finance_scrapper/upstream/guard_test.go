@@ -0,0 +1,68 @@
+package upstream
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGuard(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/big":
+			w.Write([]byte(strings.Repeat("x", 2048)))
+		case "/chunked":
+			for i := 0; i < 4; i++ {
+				w.Write([]byte(strings.Repeat("x", 512)))
+				w.(http.Flusher).Flush()
+			}
+		case "/slow":
+			w.Write([]byte("partial"))
+			w.(http.Flusher).Flush()
+			time.Sleep(200 * time.Millisecond)
+			w.Write([]byte("rest"))
+		default:
+			w.Write([]byte("ok"))
+		}
+	}))
+	defer srv.Close()
+
+	client := &http.Client{Transport: newGuard(http.DefaultTransport, 1024, 50*time.Millisecond)}
+	fetch := func(path string) (string, error) {
+		resp, err := client.Get(srv.URL + path)
+		if err != nil {
+			return "", err
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		return string(body), err
+	}
+
+	body, err := fetch("/")
+	assert.NoError(t, err)
+	assert.Equal(t, "ok", body)
+
+	_, err = fetch("/big")
+	assert.ErrorIs(t, err, ErrPageTooLarge)
+
+	_, err = fetch("/chunked")
+	assert.ErrorIs(t, err, ErrPageTooLarge)
+
+	_, err = fetch("/slow")
+	assert.Error(t, err)
+}
+
+func TestConfigureGuard(t *testing.T) {
+	defer Configure(Option{HTTP2: true})
+
+	assert.Error(t, Configure(Option{MaxPageBytes: -1}))
+
+	assert.NoError(t, Configure(Option{MaxPageBytes: 1 << 20, PageTimeout: time.Minute}))
+	assert.IsType(t, &guard{}, Transport())
+	assert.Equal(t, 0, NewCollector().MaxBodySize)
+}
@@ -0,0 +1,56 @@
+package upstream
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"go-webscraper/metrics"
+)
+
+// smoother is a leaky bucket in front of the transport: every request
+// takes the next free slot, one per interval across all collectors, so
+// parallel scrapes starting together reach Yahoo at an even cadence
+// instead of as a burst.
+type smoother struct {
+	base     http.RoundTripper
+	interval time.Duration
+
+	mu   sync.Mutex
+	next time.Time
+}
+
+func newSmoother(base http.RoundTripper, requestsPerMinute int) *smoother {
+	return &smoother{
+		base:     base,
+		interval: time.Minute / time.Duration(requestsPerMinute),
+	}
+}
+
+// reserve claims the next slot and returns how long to wait for it.
+func (s *smoother) reserve(now time.Time) time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.next.Before(now) {
+		s.next = now
+	}
+	slot := s.next
+	s.next = slot.Add(s.interval)
+	return slot.Sub(now)
+}
+
+func (s *smoother) RoundTrip(req *http.Request) (*http.Response, error) {
+	wait := s.reserve(time.Now())
+	metrics.UpstreamSmoothingWait.Observe(wait.Seconds())
+	if wait > 0 {
+		timer := time.NewTimer(wait)
+		defer timer.Stop()
+		select {
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		case <-timer.C:
+		}
+	}
+	return s.base.RoundTrip(req)
+}
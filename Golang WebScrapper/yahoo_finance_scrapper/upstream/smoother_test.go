@@ -0,0 +1,52 @@
+package upstream
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestSmoother(t *testing.T) {
+	t.Run("Even Cadence", func(t *testing.T) {
+		s := newSmoother(nil, 120)
+		now := time.Now()
+
+		// A burst of three gets consecutive half-second slots
+		assert.Equal(t, time.Duration(0), s.reserve(now))
+		assert.Equal(t, 500*time.Millisecond, s.reserve(now))
+		assert.Equal(t, time.Second, s.reserve(now))
+
+		// An idle bucket doesn't bank slots
+		assert.Equal(t, time.Duration(0), s.reserve(now.Add(time.Minute)))
+	})
+
+	t.Run("Cancelled While Waiting", func(t *testing.T) {
+		s := newSmoother(roundTripFunc(func(*http.Request) (*http.Response, error) {
+			t.Fatal("request reached the transport")
+			return nil, nil
+		}), 1)
+		s.reserve(time.Now())
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "https://finance.yahoo.com", nil)
+		_, err := s.RoundTrip(req)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+
+	t.Run("Configured", func(t *testing.T) {
+		defer Configure(Option{HTTP2: true})
+		assert.Error(t, Configure(Option{RequestsPerMinute: -1}))
+		assert.NoError(t, Configure(Option{RequestsPerMinute: 600}))
+		assert.IsType(t, &smoother{}, Transport())
+	})
+}
@@ -0,0 +1,179 @@
+package upstream
+
+import (
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"go-webscraper/metrics"
+)
+
+const (
+	// blockThreshold consecutive 429s or 403s from a domain mean Yahoo is
+	// throttling us; as many failures in a row mark a proxy unhealthy.
+	blockThreshold = 3
+	// latencyWeight is how much each response moves the average latency.
+	latencyWeight = 0.2
+)
+
+// DomainStatus is what a domain has answered since the process started.
+type DomainStatus struct {
+	Domain         string         `json:"domain"`
+	Requests       int64          `json:"requests"`
+	Statuses       map[string]int `json:"statuses"`
+	Errors         int64          `json:"errors"`
+	BlockStreak    int            `json:"block_streak"`
+	LastBlocked    *time.Time     `json:"last_blocked,omitempty"`
+	AvgLatencyMs   float64        `json:"avg_latency_ms"`
+	Throttled      bool           `json:"throttled"`
+	LastStatusCode int            `json:"last_status_code,omitempty"`
+}
+
+// ProxyStatus is how requests through one proxy, or "direct", have fared.
+type ProxyStatus struct {
+	Proxy         string     `json:"proxy"`
+	Requests      int64      `json:"requests"`
+	Failures      int64      `json:"failures"`
+	FailureStreak int        `json:"failure_streak"`
+	LastFailure   *time.Time `json:"last_failure,omitempty"`
+	Healthy       bool       `json:"healthy"`
+}
+
+// Summary answers whether Yahoo is throttling us right now.
+type Summary struct {
+	Throttled bool           `json:"throttled"`
+	Domains   []DomainStatus `json:"domains"`
+	Proxies   []ProxyStatus  `json:"proxies"`
+}
+
+// tracker sits directly on the HTTP transport, below the smoother and
+// guard, so latency excludes time spent waiting for a slot.
+type tracker struct {
+	base  http.RoundTripper
+	proxy func(*http.Request) (*url.URL, error)
+}
+
+func newTracker(base *http.Transport) *tracker {
+	return &tracker{base: base, proxy: base.Proxy}
+}
+
+// The stats outlive Configure, so a reconfigured transport keeps the
+// history of the one it replaced.
+var (
+	statsMu sync.Mutex
+	domains = make(map[string]*DomainStatus)
+	proxies = make(map[string]*ProxyStatus)
+)
+
+func (t *tracker) RoundTrip(req *http.Request) (*http.Response, error) {
+	proxy := "direct"
+	if t.proxy != nil {
+		if proxyURL, err := t.proxy(req); err == nil && proxyURL != nil {
+			proxy = proxyURL.Host
+		}
+	}
+
+	start := time.Now()
+	resp, err := t.base.RoundTrip(req)
+	status := 0
+	if err == nil {
+		status = resp.StatusCode
+	}
+	record(req.URL.Hostname(), proxy, status, time.Since(start), start)
+	return resp, err
+}
+
+// record counts one response, or a transport error when status is 0.
+func record(domain, proxy string, status int, latency time.Duration, at time.Time) {
+	blocked := status == http.StatusTooManyRequests || status == http.StatusForbidden
+	failed := status == 0 || blocked || status >= http.StatusInternalServerError
+
+	label := "error"
+	if status != 0 {
+		label = strconv.Itoa(status)
+	}
+	metrics.UpstreamResponses.WithLabelValues(domain, label).Inc()
+	if status != 0 {
+		metrics.UpstreamLatency.WithLabelValues(domain).Observe(latency.Seconds())
+	}
+
+	statsMu.Lock()
+	defer statsMu.Unlock()
+
+	d, ok := domains[domain]
+	if !ok {
+		d = &DomainStatus{Domain: domain, Statuses: make(map[string]int)}
+		domains[domain] = d
+	}
+	d.Requests++
+	if status == 0 {
+		d.Errors++
+	} else {
+		d.Statuses[label]++
+		d.LastStatusCode = status
+		ms := float64(latency) / float64(time.Millisecond)
+		if d.AvgLatencyMs == 0 {
+			d.AvgLatencyMs = ms
+		} else {
+			d.AvgLatencyMs += latencyWeight * (ms - d.AvgLatencyMs)
+		}
+		// Transport errors say nothing about throttling, so only
+		// responses move the streak
+		if blocked {
+			d.BlockStreak++
+			blockedAt := at
+			d.LastBlocked = &blockedAt
+		} else {
+			d.BlockStreak = 0
+		}
+	}
+	metrics.UpstreamBlockStreak.WithLabelValues(domain).Set(float64(d.BlockStreak))
+
+	p, ok := proxies[proxy]
+	if !ok {
+		p = &ProxyStatus{Proxy: proxy}
+		proxies[proxy] = p
+	}
+	p.Requests++
+	if failed {
+		p.Failures++
+		p.FailureStreak++
+		failedAt := at
+		p.LastFailure = &failedAt
+	} else {
+		p.FailureStreak = 0
+	}
+}
+
+// Status summarizes upstream health. Yahoo counts as throttling us when
+// any of its domains has answered blockThreshold 429s or 403s in a row.
+func Status() Summary {
+	statsMu.Lock()
+	defer statsMu.Unlock()
+
+	summary := Summary{
+		Domains: make([]DomainStatus, 0, len(domains)),
+		Proxies: make([]ProxyStatus, 0, len(proxies)),
+	}
+	for _, d := range domains {
+		status := *d
+		status.Statuses = make(map[string]int, len(d.Statuses))
+		for code, n := range d.Statuses {
+			status.Statuses[code] = n
+		}
+		status.Throttled = d.BlockStreak >= blockThreshold
+		summary.Throttled = summary.Throttled || status.Throttled
+		summary.Domains = append(summary.Domains, status)
+	}
+	for _, p := range proxies {
+		status := *p
+		status.Healthy = p.FailureStreak < blockThreshold
+		summary.Proxies = append(summary.Proxies, status)
+	}
+	sort.Slice(summary.Domains, func(i, j int) bool { return summary.Domains[i].Domain < summary.Domains[j].Domain })
+	sort.Slice(summary.Proxies, func(i, j int) bool { return summary.Proxies[i].Proxy < summary.Proxies[j].Proxy })
+	return summary
+}
@@ -0,0 +1,94 @@
+package upstream
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func resetStats() {
+	statsMu.Lock()
+	defer statsMu.Unlock()
+	domains = make(map[string]*DomainStatus)
+	proxies = make(map[string]*ProxyStatus)
+}
+
+func TestTracker(t *testing.T) {
+	resetStats()
+	defer resetStats()
+
+	code := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(code)
+	}))
+	defer server.Close()
+
+	tr := newTracker(&http.Transport{})
+	fetch := func() {
+		req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+		resp, err := tr.RoundTrip(req)
+		assert.NoError(t, err)
+		resp.Body.Close()
+	}
+
+	fetch()
+	code = http.StatusTooManyRequests
+	fetch()
+	fetch()
+	assert.False(t, Status().Throttled)
+
+	fetch()
+	summary := Status()
+	assert.True(t, summary.Throttled)
+	assert.Len(t, summary.Domains, 1)
+	domain := summary.Domains[0]
+	assert.Equal(t, "127.0.0.1", domain.Domain)
+	assert.Equal(t, int64(4), domain.Requests)
+	assert.Equal(t, map[string]int{"200": 1, "429": 3}, domain.Statuses)
+	assert.Equal(t, 3, domain.BlockStreak)
+	assert.NotNil(t, domain.LastBlocked)
+	assert.Greater(t, domain.AvgLatencyMs, 0.0)
+
+	assert.Equal(t, []ProxyStatus{{Proxy: "direct", Requests: 4, Failures: 3, FailureStreak: 3, LastFailure: summary.Proxies[0].LastFailure, Healthy: false}}, summary.Proxies)
+
+	// One good response ends the streak
+	code = http.StatusOK
+	fetch()
+	summary = Status()
+	assert.False(t, summary.Throttled)
+	assert.True(t, summary.Proxies[0].Healthy)
+}
+
+func TestRecordProxy(t *testing.T) {
+	resetStats()
+	defer resetStats()
+
+	now := time.Now()
+	record("query1.finance.yahoo.com", "proxy-a:3128", 0, 0, now)
+	record("query1.finance.yahoo.com", "proxy-a:3128", http.StatusBadGateway, time.Second, now)
+	record("query1.finance.yahoo.com", "proxy-a:3128", http.StatusServiceUnavailable, time.Second, now)
+	record("query1.finance.yahoo.com", "proxy-b:3128", http.StatusOK, time.Second, now)
+
+	summary := Status()
+	// Errors and 5xx fail the proxy but aren't throttling
+	assert.False(t, summary.Throttled)
+	assert.Equal(t, int64(1), summary.Domains[0].Errors)
+	assert.Equal(t, "proxy-a:3128", summary.Proxies[0].Proxy)
+	assert.False(t, summary.Proxies[0].Healthy)
+	assert.True(t, summary.Proxies[1].Healthy)
+}
+
+func TestTrackerProxyLabel(t *testing.T) {
+	proxyURL, _ := url.Parse("http://proxy.local:3128")
+	tr := newTracker(&http.Transport{Proxy: http.ProxyURL(proxyURL)})
+	assert.NotNil(t, tr.proxy)
+
+	req, _ := http.NewRequest(http.MethodGet, "https://finance.yahoo.com", nil)
+	got, err := tr.proxy(req)
+	assert.NoError(t, err)
+	assert.Equal(t, "proxy.local:3128", got.Host)
+}
@@ -0,0 +1,157 @@
+package upstream
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/gocolly/colly"
+)
+
+const DefaultUserAgent = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/91.0.4472.124 Safari/537.36"
+
+// Option tunes the transport every collector talks to Yahoo through. Zero
+// values take the defaults; an empty Proxy falls back to HTTP(S)_PROXY.
+// RequestsPerMinute spaces every outbound request evenly at that rate;
+// zero leaves requests unsmoothed. MaxPageBytes and PageTimeout abort any
+// single page that grows past that size or isn't read within that time;
+// zero leaves pages unbounded.
+type Option struct {
+	MaxIdleConns          int
+	MaxConnsPerHost       int
+	IdleConnTimeout       time.Duration
+	TLSHandshakeTimeout   time.Duration
+	ResponseHeaderTimeout time.Duration
+	HTTP2                 bool
+	Proxy                 string
+	UserAgent             string
+	RequestsPerMinute     int
+	MaxPageBytes          int64
+	PageTimeout           time.Duration
+}
+
+var (
+	mutex        sync.RWMutex
+	transport    http.RoundTripper = newTracker(newTransport(Option{HTTP2: true}, http.ProxyFromEnvironment))
+	userAgent                      = DefaultUserAgent
+	maxPageBytes int64
+)
+
+// Configure replaces the shared transport. Collectors created afterwards
+// pick it up; existing ones keep the transport they were built with.
+func Configure(opts Option) error {
+	proxy := http.ProxyFromEnvironment
+	if opts.Proxy != "" {
+		proxyURL, err := url.Parse(opts.Proxy)
+		if err != nil || proxyURL.Host == "" {
+			return fmt.Errorf("invalid upstream proxy %q", opts.Proxy)
+		}
+		proxy = http.ProxyURL(proxyURL)
+	}
+	if opts.RequestsPerMinute < 0 {
+		return fmt.Errorf("upstream requests per minute must not be negative")
+	}
+	if opts.MaxPageBytes < 0 || opts.PageTimeout < 0 {
+		return fmt.Errorf("upstream page limits must not be negative")
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	transport = newTracker(newTransport(opts, proxy))
+	// The page timeout starts once a request leaves the smoother, so
+	// waiting for a slot doesn't count against it
+	if opts.MaxPageBytes > 0 || opts.PageTimeout > 0 {
+		transport = newGuard(transport, opts.MaxPageBytes, opts.PageTimeout)
+	}
+	maxPageBytes = opts.MaxPageBytes
+	if opts.RequestsPerMinute > 0 {
+		transport = newSmoother(transport, opts.RequestsPerMinute)
+	}
+	userAgent = DefaultUserAgent
+	if opts.UserAgent != "" {
+		userAgent = opts.UserAgent
+	}
+	return nil
+}
+
+func newTransport(opts Option, proxy func(*http.Request) (*url.URL, error)) *http.Transport {
+	if opts.MaxIdleConns == 0 {
+		opts.MaxIdleConns = 100
+	}
+	if opts.MaxConnsPerHost == 0 {
+		opts.MaxConnsPerHost = 16
+	}
+	if opts.IdleConnTimeout == 0 {
+		opts.IdleConnTimeout = 90 * time.Second
+	}
+	if opts.TLSHandshakeTimeout == 0 {
+		opts.TLSHandshakeTimeout = 10 * time.Second
+	}
+	if opts.ResponseHeaderTimeout == 0 {
+		opts.ResponseHeaderTimeout = 30 * time.Second
+	}
+
+	return &http.Transport{
+		Proxy: proxy,
+		DialContext: (&net.Dialer{
+			Timeout:   30 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		MaxIdleConns:          opts.MaxIdleConns,
+		MaxIdleConnsPerHost:   opts.MaxConnsPerHost,
+		MaxConnsPerHost:       opts.MaxConnsPerHost,
+		IdleConnTimeout:       opts.IdleConnTimeout,
+		TLSHandshakeTimeout:   opts.TLSHandshakeTimeout,
+		ResponseHeaderTimeout: opts.ResponseHeaderTimeout,
+		ExpectContinueTimeout: 1 * time.Second,
+		ForceAttemptHTTP2:     opts.HTTP2,
+		TLSClientConfig: &tls.Config{
+			ClientSessionCache: tls.NewLRUClientSessionCache(0),
+		},
+	}
+}
+
+// UseTransport replaces the shared transport with rt as is, such as one
+// serving recorded pages, and returns a func restoring the previous one.
+// Like Configure, it only affects collectors created afterwards.
+func UseTransport(rt http.RoundTripper) (restore func()) {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	previous := transport
+	transport = rt
+	return func() {
+		mutex.Lock()
+		defer mutex.Unlock()
+		transport = previous
+	}
+}
+
+// Transport returns the shared transport.
+func Transport() http.RoundTripper {
+	mutex.RLock()
+	defer mutex.RUnlock()
+	return transport
+}
+
+// NewCollector builds a colly collector on the shared transport with the
+// configured user agent. Clones share the same backend, so every page of
+// a multi-page crawl reuses pooled connections.
+func NewCollector(options ...func(*colly.Collector)) *colly.Collector {
+	mutex.RLock()
+	ua, rt, limited := userAgent, transport, maxPageBytes > 0
+	mutex.RUnlock()
+
+	c := colly.NewCollector(append([]func(*colly.Collector){colly.UserAgent(ua)}, options...)...)
+	c.WithTransport(rt)
+	if limited {
+		// colly would otherwise silently truncate pages at its own limit
+		c.MaxBodySize = 0
+	}
+	return c
+}
@@ -0,0 +1,35 @@
+package upstream
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfigure(t *testing.T) {
+	defer Configure(Option{HTTP2: true})
+
+	t.Run("Invalid Proxy", func(t *testing.T) {
+		assert.Error(t, Configure(Option{Proxy: "::not a url"}))
+	})
+
+	t.Run("Tuned Transport", func(t *testing.T) {
+		assert.NoError(t, Configure(Option{MaxConnsPerHost: 4, Proxy: "http://proxy.local:3128", UserAgent: "gofinance-test"}))
+
+		tracked, ok := Transport().(*tracker)
+		assert.True(t, ok)
+		rt, ok := tracked.base.(*http.Transport)
+		assert.True(t, ok)
+		assert.Equal(t, 4, rt.MaxConnsPerHost)
+		assert.Equal(t, 100, rt.MaxIdleConns)
+		assert.NotNil(t, rt.TLSClientConfig.ClientSessionCache)
+
+		req, _ := http.NewRequest(http.MethodGet, "https://finance.yahoo.com", nil)
+		proxyURL, err := rt.Proxy(req)
+		assert.NoError(t, err)
+		assert.Equal(t, "proxy.local:3128", proxyURL.Host)
+
+		assert.Equal(t, "gofinance-test", NewCollector().UserAgent)
+	})
+}
@@ -0,0 +1,406 @@
+// Package usage counts API requests per API key, endpoint and day, for
+// operators looking for heavy consumers and for callers watching their own
+// consumption.
+package usage
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"go-webscraper/middleware"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+// Counts are kept one Redis key per day, so old days simply expire:
+//
+//	usage:<id>:<date>   hash of endpoint to the key's requests that day
+//	usage:keys:<date>   sorted set of key ids by requests that day
+//	usage:hints:<date>  hash of key id to the masked key
+//
+// API keys are only ever stored as ids and masks.
+const (
+	dateLayout = "2006-01-02"
+
+	// DefaultMaxKeys is how many distinct keys are counted a day unless
+	// configured otherwise.
+	DefaultMaxKeys = 10000
+
+	defaultDays  = 30
+	maxDays      = 366
+	defaultLimit = 20
+	maxLimit     = 1000
+)
+
+// ErrTooManyKeys is returned by Record for a key first seen after the
+// day's distinct keys reached the cap.
+var ErrTooManyKeys = errors.New("too many distinct API keys today")
+
+// validKey matches what API keys look like. Anyone can send any X-API-Key,
+// so anything else isn't counted.
+var validKey = regexp.MustCompile(`^[A-Za-z0-9._\-]{8,128}$`)
+
+func countsKey(id, date string) string {
+	return "usage:" + id + ":" + date
+}
+
+func keysKey(date string) string {
+	return "usage:keys:" + date
+}
+
+func hintsKey(date string) string {
+	return "usage:hints:" + date
+}
+
+// KeyID identifies apiKey without revealing it.
+func KeyID(apiKey string) string {
+	sum := sha256.Sum256([]byte(apiKey))
+	return hex.EncodeToString(sum[:8])
+}
+
+// mask keeps enough of apiKey for its owner to recognise it.
+func mask(apiKey string) string {
+	if len(apiKey) <= 8 {
+		return "****"
+	}
+	return apiKey[:4] + "****"
+}
+
+// Day is one key's requests on one UTC day.
+type Day struct {
+	Date      string           `json:"date"`
+	Requests  int64            `json:"requests"`
+	Endpoints map[string]int64 `json:"endpoints"`
+}
+
+// Report is one key's requests from From to To, both inclusive.
+type Report struct {
+	KeyID     string           `json:"key_id"`
+	Key       string           `json:"key"`
+	From      string           `json:"from"`
+	To        string           `json:"to"`
+	Requests  int64            `json:"requests"`
+	Endpoints map[string]int64 `json:"endpoints"`
+	Days      []Day            `json:"days"`
+}
+
+// Consumer is one key's total requests over a range.
+type Consumer struct {
+	KeyID    string `json:"key_id"`
+	Key      string `json:"key"`
+	Requests int64  `json:"requests"`
+}
+
+type Store struct {
+	redis     *redis.Client
+	ctx       context.Context
+	retainFor time.Duration
+	maxKeys   int64
+}
+
+// NewStore keeps counts for retainFor, counting up to maxKeys distinct
+// keys a day.
+func NewStore(rdb *redis.Client, retainFor time.Duration, maxKeys int) *Store {
+	if retainFor == 0 {
+		retainFor = 90 * 24 * time.Hour
+	}
+	if maxKeys == 0 {
+		maxKeys = DefaultMaxKeys
+	}
+
+	return &Store{
+		redis:     rdb,
+		ctx:       context.Background(),
+		retainFor: retainFor,
+		maxKeys:   int64(maxKeys),
+	}
+}
+
+// ValidKey reports whether apiKey looks like an API key.
+func ValidKey(apiKey string) bool {
+	return validKey.MatchString(apiKey)
+}
+
+// Record counts one request to endpoint made with apiKey at at. Keys that
+// don't look like API keys are refused, and so are new keys once the day
+// has counted maxKeys, so random keys can't grow Redis without bound.
+func (s *Store) Record(apiKey, endpoint string, at time.Time) error {
+	if !ValidKey(apiKey) {
+		return fmt.Errorf("invalid API key")
+	}
+	id := KeyID(apiKey)
+	date := at.UTC().Format(dateLayout)
+	// Counts outlive their day by the retention window
+	expireAt := at.UTC().Truncate(24 * time.Hour).Add(24*time.Hour + s.retainFor)
+
+	check := s.redis.Pipeline()
+	known := check.ZScore(s.ctx, keysKey(date), id)
+	count := check.ZCard(s.ctx, keysKey(date))
+	if _, err := check.Exec(s.ctx); err != nil && err != redis.Nil {
+		return err
+	}
+	if known.Err() == redis.Nil && count.Val() >= s.maxKeys {
+		return ErrTooManyKeys
+	}
+
+	pipe := s.redis.TxPipeline()
+	pipe.HIncrBy(s.ctx, countsKey(id, date), endpoint, 1)
+	pipe.ExpireAt(s.ctx, countsKey(id, date), expireAt)
+	pipe.ZIncrBy(s.ctx, keysKey(date), 1, id)
+	pipe.ExpireAt(s.ctx, keysKey(date), expireAt)
+	pipe.HSetNX(s.ctx, hintsKey(date), id, mask(apiKey))
+	pipe.ExpireAt(s.ctx, hintsKey(date), expireAt)
+	_, err := pipe.Exec(s.ctx)
+	return err
+}
+
+func dates(from, to time.Time) []string {
+	var result []string
+	for day := from; !day.After(to); day = day.AddDate(0, 0, 1) {
+		result = append(result, day.Format(dateLayout))
+	}
+	return result
+}
+
+// Report returns apiKey's requests by day and endpoint from from to to.
+func (s *Store) Report(apiKey string, from, to time.Time) (*Report, error) {
+	id := KeyID(apiKey)
+	report := &Report{
+		KeyID:     id,
+		Key:       mask(apiKey),
+		From:      from.Format(dateLayout),
+		To:        to.Format(dateLayout),
+		Endpoints: make(map[string]int64),
+		Days:      make([]Day, 0),
+	}
+
+	for _, date := range dates(from, to) {
+		counts, err := s.redis.HGetAll(s.ctx, countsKey(id, date)).Result()
+		if err != nil {
+			return nil, err
+		}
+		if len(counts) == 0 {
+			continue
+		}
+
+		day := Day{Date: date, Endpoints: make(map[string]int64, len(counts))}
+		for endpoint, value := range counts {
+			n, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				continue
+			}
+			day.Endpoints[endpoint] = n
+			day.Requests += n
+			report.Endpoints[endpoint] += n
+		}
+		report.Requests += day.Requests
+		report.Days = append(report.Days, day)
+	}
+	return report, nil
+}
+
+// Top returns the limit keys with the most requests from from to to,
+// heaviest first.
+func (s *Store) Top(from, to time.Time, limit int) ([]Consumer, error) {
+	totals := make(map[string]int64)
+	for _, date := range dates(from, to) {
+		scores, err := s.redis.ZRangeWithScores(s.ctx, keysKey(date), 0, -1).Result()
+		if err != nil {
+			return nil, err
+		}
+		for _, z := range scores {
+			totals[z.Member.(string)] += int64(z.Score)
+		}
+	}
+
+	consumers := make([]Consumer, 0, len(totals))
+	for id, requests := range totals {
+		consumers = append(consumers, Consumer{KeyID: id, Requests: requests})
+	}
+	sort.Slice(consumers, func(i, k int) bool {
+		if consumers[i].Requests != consumers[k].Requests {
+			return consumers[i].Requests > consumers[k].Requests
+		}
+		return consumers[i].KeyID < consumers[k].KeyID
+	})
+	if len(consumers) > limit {
+		consumers = consumers[:limit]
+	}
+
+	if err := s.hints(consumers, dates(from, to)); err != nil {
+		return nil, err
+	}
+	return consumers, nil
+}
+
+// hints fills in the consumers' masked keys from the days they were seen.
+func (s *Store) hints(consumers []Consumer, days []string) error {
+	missing := make(map[string]int, len(consumers))
+	for i, consumer := range consumers {
+		missing[consumer.KeyID] = i
+	}
+	for _, date := range days {
+		if len(missing) == 0 {
+			return nil
+		}
+		ids := make([]string, 0, len(missing))
+		for id := range missing {
+			ids = append(ids, id)
+		}
+		hints, err := s.redis.HMGet(s.ctx, hintsKey(date), ids...).Result()
+		if err != nil {
+			return err
+		}
+		for i, hint := range hints {
+			if hint, ok := hint.(string); ok {
+				consumers[missing[ids[i]]].Key = hint
+				delete(missing, ids[i])
+			}
+		}
+	}
+	return nil
+}
+
+// Track counts every request made with an API key against the route it
+// matched, once the handler has finished. Requests matching no route, and
+// keys Record refuses, aren't counted.
+func Track(store *Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		apiKey := middleware.APIKey(c)
+		if !ValidKey(apiKey) || c.FullPath() == "" {
+			return
+		}
+		err := store.Record(apiKey, c.Request.Method+" "+c.FullPath(), time.Now())
+		if err != nil && !errors.Is(err, ErrTooManyKeys) {
+			log.Printf("Error recording usage of %s: %v", c.FullPath(), err)
+		}
+	}
+}
+
+// parseRange reads ?from= and ?to=, YYYY-MM-DD UTC dates both inclusive,
+// defaulting to the last 30 days.
+func parseRange(c *gin.Context) (time.Time, time.Time, error) {
+	to := time.Now().UTC().Truncate(24 * time.Hour)
+	if value := c.Query("to"); value != "" {
+		date, err := time.Parse(dateLayout, value)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid to date: %s", value)
+		}
+		to = date
+	}
+
+	from := to.AddDate(0, 0, 1-defaultDays)
+	if value := c.Query("from"); value != "" {
+		date, err := time.Parse(dateLayout, value)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid from date: %s", value)
+		}
+		from = date
+	}
+
+	if from.After(to) {
+		return time.Time{}, time.Time{}, fmt.Errorf("from must not be after to")
+	}
+	if to.Sub(from) >= maxDays*24*time.Hour {
+		return time.Time{}, time.Time{}, fmt.Errorf("range must be at most %d days", maxDays)
+	}
+	return from, to, nil
+}
+
+// HandleUsage serves GET /admin/analytics/usage. With ?key= it reports
+// that key's requests by day and endpoint; without, it lists the heaviest
+// keys, up to ?limit=.
+func HandleUsage(store *Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		from, to, err := parseRange(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		if apiKey := c.Query("key"); apiKey != "" {
+			report, err := store.Report(apiKey, from, to)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{
+					"error": err.Error(),
+				})
+				return
+			}
+			c.JSON(http.StatusOK, gin.H{
+				"status": "success",
+				"data":   report,
+			})
+			return
+		}
+
+		limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultLimit)))
+		if err != nil || limit <= 0 || limit > maxLimit {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": fmt.Sprintf("limit must be between 1 and %d", maxLimit),
+			})
+			return
+		}
+
+		consumers, err := store.Top(from, to, limit)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"status": "success",
+			"from":   from.Format(dateLayout),
+			"to":     to.Format(dateLayout),
+			"data":   consumers,
+		})
+	}
+}
+
+// HandleMyUsage serves GET /api/me/usage, the calling API key's own
+// report.
+func HandleMyUsage(store *Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		apiKey := middleware.APIKey(c)
+		if apiKey == "" {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error": "an API key is required, as X-API-Key or ?api_key=",
+			})
+			return
+		}
+
+		from, to, err := parseRange(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		report, err := store.Report(apiKey, from, to)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"status": "success",
+			"data":   report,
+		})
+	}
+}
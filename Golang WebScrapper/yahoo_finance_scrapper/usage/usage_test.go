@@ -0,0 +1,136 @@
+package usage
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestStore(t *testing.T) *Store {
+	m := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: m.Addr()})
+	t.Cleanup(func() { rdb.Close() })
+	return NewStore(rdb, 0, 3)
+}
+
+func TestStore(t *testing.T) {
+	store := newTestStore(t)
+	day1 := time.Date(2026, 10, 14, 9, 0, 0, 0, time.UTC)
+	day2 := day1.AddDate(0, 0, 1)
+
+	for i := 0; i < 3; i++ {
+		require.NoError(t, store.Record("heavy-consumer-key", "GET /api/stock", day1))
+	}
+	require.NoError(t, store.Record("heavy-consumer-key", "GET /api/news", day2))
+	require.NoError(t, store.Record("light-key-12345", "GET /api/stock", day2))
+
+	report, err := store.Report("heavy-consumer-key", day1, day2)
+	require.NoError(t, err)
+	assert.Equal(t, "heav****", report.Key)
+	assert.Equal(t, int64(4), report.Requests)
+	assert.Equal(t, map[string]int64{"GET /api/stock": 3, "GET /api/news": 1}, report.Endpoints)
+	require.Len(t, report.Days, 2)
+	assert.Equal(t, "2026-10-14", report.Days[0].Date)
+	assert.Equal(t, int64(3), report.Days[0].Requests)
+
+	report, err = store.Report("heavy-consumer-key", day2, day2)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), report.Requests)
+
+	consumers, err := store.Top(day1, day2, 10)
+	require.NoError(t, err)
+	require.Len(t, consumers, 2)
+	assert.Equal(t, Consumer{KeyID: KeyID("heavy-consumer-key"), Key: "heav****", Requests: 4}, consumers[0])
+	assert.Equal(t, int64(1), consumers[1].Requests)
+
+	consumers, err = store.Top(day1, day2, 1)
+	require.NoError(t, err)
+	assert.Len(t, consumers, 1)
+}
+
+func TestRecordRefusesUnboundedKeys(t *testing.T) {
+	store := newTestStore(t)
+	day := time.Date(2026, 10, 14, 9, 0, 0, 0, time.UTC)
+
+	assert.Error(t, store.Record("short", "GET /api/stock", day))
+	assert.Error(t, store.Record("not a key; drop table", "GET /api/stock", day))
+
+	// The test store counts three distinct keys a day
+	for _, key := range []string{"first-key-1", "second-key-2", "third-key-3"} {
+		require.NoError(t, store.Record(key, "GET /api/stock", day))
+	}
+	assert.ErrorIs(t, store.Record("fourth-key-4", "GET /api/stock", day), ErrTooManyKeys)
+	require.NoError(t, store.Record("first-key-1", "GET /api/stock", day), "known keys are still counted")
+	require.NoError(t, store.Record("fourth-key-4", "GET /api/stock", day.AddDate(0, 0, 1)))
+
+	consumers, err := store.Top(day, day, 10)
+	require.NoError(t, err)
+	require.Len(t, consumers, 3)
+	assert.Equal(t, Consumer{KeyID: KeyID("first-key-1"), Key: "firs****", Requests: 2}, consumers[0])
+}
+
+func TestHandlers(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	store := newTestStore(t)
+
+	r := gin.New()
+	api := r.Group("/api")
+	api.Use(Track(store))
+	api.GET("/stock", func(c *gin.Context) { c.Status(http.StatusOK) })
+	api.GET("/me/usage", HandleMyUsage(store))
+	r.GET("/admin/analytics/usage", HandleUsage(store))
+
+	serve := func(target, apiKey string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		if apiKey != "" {
+			req.Header.Set("X-API-Key", apiKey)
+		}
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	serve("/api/stock", "my-secret-key")
+	serve("/api/stock", "my-secret-key")
+	serve("/api/stock", "")
+	serve("/api/missing", "my-secret-key")
+
+	var got struct {
+		Data Report `json:"data"`
+	}
+	w := serve("/api/me/usage", "my-secret-key")
+	require.Equal(t, http.StatusOK, w.Code)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &got))
+	assert.Equal(t, int64(2), got.Data.Requests)
+	assert.Equal(t, map[string]int64{"GET /api/stock": 2}, got.Data.Endpoints)
+	assert.NotContains(t, w.Body.String(), "my-secret-key")
+
+	assert.Equal(t, http.StatusUnauthorized, serve("/api/me/usage", "").Code)
+	assert.Equal(t, http.StatusBadRequest, serve("/api/me/usage?from=yesterday", "my-secret-key").Code)
+	assert.Equal(t, http.StatusBadRequest, serve("/api/me/usage?from=2026-10-10&to=2026-10-01", "my-secret-key").Code)
+
+	w = serve("/admin/analytics/usage?key=my-secret-key", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &got))
+	// The /me/usage calls made with the key are counted too, rejected or not
+	assert.Equal(t, int64(5), got.Data.Requests)
+	assert.Equal(t, int64(3), got.Data.Endpoints["GET /api/me/usage"])
+
+	var top struct {
+		Data []Consumer `json:"data"`
+	}
+	w = serve("/admin/analytics/usage", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &top))
+	require.NotEmpty(t, top.Data)
+	assert.Equal(t, KeyID("my-secret-key"), top.Data[0].KeyID)
+	assert.Equal(t, http.StatusBadRequest, serve("/admin/analytics/usage?limit=0", "").Code)
+}
@@ -0,0 +1,298 @@
+package watchlist
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+const listsKey = "watchlists"
+
+const MaxSymbols = 50
+
+var validSymbol = regexp.MustCompile(`^[A-Z0-9.\-^=]{1,12}$`)
+
+type Watchlist struct {
+	ID        string    `json:"id"`
+	UserID    string    `json:"-"`
+	Name      string    `json:"name"`
+	Symbols   []string  `json:"symbols"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// storedWatchlist keeps UserID in Redis while the API never exposes it.
+type storedWatchlist struct {
+	Watchlist
+	UserID string `json:"user_id"`
+}
+
+type Store struct {
+	redis *redis.Client
+	ctx   context.Context
+}
+
+func NewStore(rdb *redis.Client) *Store {
+	return &Store{
+		redis: rdb,
+		ctx:   context.Background(),
+	}
+}
+
+func (s *Store) Save(list *Watchlist) error {
+	data, err := json.Marshal(storedWatchlist{Watchlist: *list, UserID: list.UserID})
+	if err != nil {
+		return err
+	}
+	return s.redis.HSet(s.ctx, listsKey, list.ID, data).Err()
+}
+
+func (s *Store) Get(id string) (*Watchlist, error) {
+	data, err := s.redis.HGet(s.ctx, listsKey, id).Result()
+	if err != nil {
+		if err == redis.Nil {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var stored storedWatchlist
+	if err := json.Unmarshal([]byte(data), &stored); err != nil {
+		return nil, err
+	}
+	stored.Watchlist.UserID = stored.UserID
+	return &stored.Watchlist, nil
+}
+
+func (s *Store) Delete(id string) error {
+	return s.redis.HDel(s.ctx, listsKey, id).Err()
+}
+
+func (s *Store) All() ([]*Watchlist, error) {
+	values, err := s.redis.HGetAll(s.ctx, listsKey).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	lists := make([]*Watchlist, 0, len(values))
+	for _, value := range values {
+		var stored storedWatchlist
+		if err := json.Unmarshal([]byte(value), &stored); err != nil {
+			continue
+		}
+		stored.Watchlist.UserID = stored.UserID
+		lists = append(lists, &stored.Watchlist)
+	}
+	return lists, nil
+}
+
+func (s *Store) ListByUser(userID string) ([]*Watchlist, error) {
+	lists, err := s.All()
+	if err != nil {
+		return nil, err
+	}
+
+	owned := make([]*Watchlist, 0)
+	for _, list := range lists {
+		if list.UserID == userID {
+			owned = append(owned, list)
+		}
+	}
+	return owned, nil
+}
+
+// Owned loads the watchlist named by the :id route param, writing a 404
+// when it is missing or belongs to someone else. It returns nil if it has
+// already responded.
+func Owned(c *gin.Context, store *Store) *Watchlist {
+	list, err := store.Get(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return nil
+	}
+	if list == nil || list.UserID != c.GetString("user_id") {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "watchlist not found",
+		})
+		return nil
+	}
+	return list
+}
+
+// normalizeSymbols upper-cases, de-duplicates and validates symbols,
+// keeping the caller's order.
+func normalizeSymbols(symbols []string) ([]string, error) {
+	seen := make(map[string]bool, len(symbols))
+	out := make([]string, 0, len(symbols))
+	for _, symbol := range symbols {
+		symbol = strings.ToUpper(strings.TrimSpace(symbol))
+		if symbol == "" || seen[symbol] {
+			continue
+		}
+		if !validSymbol.MatchString(symbol) {
+			return nil, fmt.Errorf("invalid symbol: %s", symbol)
+		}
+		seen[symbol] = true
+		out = append(out, symbol)
+	}
+	if len(out) > MaxSymbols {
+		return nil, fmt.Errorf("a watchlist holds at most %d symbols", MaxSymbols)
+	}
+	return out, nil
+}
+
+func randomID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+type WatchlistRequest struct {
+	Name    string   `json:"name" binding:"required"`
+	Symbols []string `json:"symbols"`
+}
+
+func HandleCreate(store *Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req WatchlistRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		symbols, err := normalizeSymbols(req.Symbols)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		now := time.Now()
+		list := &Watchlist{
+			ID:        randomID(),
+			UserID:    c.GetString("user_id"),
+			Name:      strings.TrimSpace(req.Name),
+			Symbols:   symbols,
+			CreatedAt: now,
+			UpdatedAt: now,
+		}
+		if err := store.Save(list); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		c.Set("audit_target", list.ID)
+		c.JSON(http.StatusCreated, gin.H{
+			"status": "success",
+			"data":   list,
+		})
+	}
+}
+
+func HandleList(store *Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		lists, err := store.ListByUser(c.GetString("user_id"))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"status": "success",
+			"data":   lists,
+		})
+	}
+}
+
+func HandleGet(store *Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		list := Owned(c, store)
+		if list == nil {
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"status": "success",
+			"data":   list,
+		})
+	}
+}
+
+// HandleUpdate replaces a watchlist's name and symbols.
+func HandleUpdate(store *Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		list := Owned(c, store)
+		if list == nil {
+			return
+		}
+
+		var req WatchlistRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		symbols, err := normalizeSymbols(req.Symbols)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		list.Name = strings.TrimSpace(req.Name)
+		list.Symbols = symbols
+		list.UpdatedAt = time.Now()
+		if err := store.Save(list); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"status": "success",
+			"data":   list,
+		})
+	}
+}
+
+func HandleDelete(store *Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		list := Owned(c, store)
+		if list == nil {
+			return
+		}
+
+		if err := store.Delete(list.ID); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"status": "success",
+		})
+	}
+}
@@ -0,0 +1,24 @@
+package watchlist
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeSymbols(t *testing.T) {
+	symbols, err := normalizeSymbols([]string{" aapl", "MSFT", "AAPL", "", "brk.b"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"AAPL", "MSFT", "BRK.B"}, symbols)
+
+	_, err = normalizeSymbols([]string{"AAPL; DROP"})
+	assert.Error(t, err)
+
+	many := make([]string, MaxSymbols+1)
+	for i := range many {
+		many[i] = "S" + strings.Repeat("X", i%10) + string(rune('A'+i%26))
+	}
+	_, err = normalizeSymbols(many)
+	assert.Error(t, err)
+}
@@ -0,0 +1,212 @@
+package webhook
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"go-webscraper/pkg/egress"
+	"go-webscraper/scraper"
+)
+
+const SignatureHeader = "X-GoFinance-Signature"
+
+type Event struct {
+	ID        string      `json:"id"`
+	Source    string      `json:"source"`
+	Timestamp string      `json:"timestamp"`
+	Data      interface{} `json:"data"`
+}
+
+type Dispatcher struct {
+	store      *Store
+	client     *http.Client
+	maxRetries int
+	backoff    time.Duration
+}
+
+type DispatcherOption struct {
+	Timeout    time.Duration
+	MaxRetries int
+}
+
+func NewDispatcher(store *Store, opts DispatcherOption) *Dispatcher {
+	if opts.Timeout == 0 {
+		opts.Timeout = 10 * time.Second
+	}
+	if opts.MaxRetries == 0 {
+		opts.MaxRetries = 3
+	}
+
+	return &Dispatcher{
+		store:      store,
+		client:     egress.Client(opts.Timeout),
+		maxRetries: opts.MaxRetries,
+		backoff:    1 * time.Second,
+	}
+}
+
+// Publish pushes data from a refresh of source to every matching
+// subscription. Deliveries run in the background so a slow subscriber
+// never delays the scheduler.
+func (d *Dispatcher) Publish(source string, data interface{}) {
+	subs, err := d.store.BySource(source)
+	if err != nil {
+		log.Printf("Error loading webhook subscriptions for %s: %v", source, err)
+		return
+	}
+
+	for _, sub := range subs {
+		filtered := applyFilters(sub.Filters, data)
+		if isEmpty(filtered) {
+			continue
+		}
+
+		event := Event{
+			ID:        randomHex(8),
+			Source:    source,
+			Timestamp: time.Now().Format(time.RFC3339),
+			Data:      filtered,
+		}
+		go d.deliver(sub, event)
+	}
+}
+
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func (d *Dispatcher) deliver(sub *Subscription, event Event) {
+	body, err := json.Marshal(event)
+	if err != nil {
+		log.Printf("Error marshaling webhook event for %s: %v", sub.ID, err)
+		return
+	}
+
+	backoff := d.backoff
+	for attempt := 1; attempt <= d.maxRetries; attempt++ {
+		err = d.post(sub, event, body)
+		if err == nil {
+			return
+		}
+
+		log.Printf("Webhook delivery %s to %s failed (attempt %d/%d): %v", event.ID, sub.TargetURL, attempt, d.maxRetries, err)
+		if attempt < d.maxRetries {
+			time.Sleep(backoff)
+			backoff *= 2
+		}
+	}
+}
+
+func (d *Dispatcher) post(sub *Subscription, event Event, body []byte) error {
+	req, err := http.NewRequest(http.MethodPost, sub.TargetURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-GoFinance-Event", event.Source)
+	req.Header.Set("X-GoFinance-Delivery", event.ID)
+	req.Header.Set(SignatureHeader, Sign(sub.Secret, body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func applyFilters(filters Filters, data interface{}) interface{} {
+	switch v := data.(type) {
+	case []scraper.StockData:
+		if len(filters.Symbols) == 0 {
+			return v
+		}
+		stocks := make([]scraper.StockData, 0)
+		for _, stock := range v {
+			if containsFold(filters.Symbols, stock.Symbol) {
+				stocks = append(stocks, stock)
+			}
+		}
+		return stocks
+	case map[string]*scraper.SectorData:
+		if len(filters.Sectors) == 0 {
+			return v
+		}
+		sectors := make(map[string]*scraper.SectorData)
+		for name, sector := range v {
+			if containsFold(filters.Sectors, name) {
+				sectors[name] = sector
+			}
+		}
+		return sectors
+	case []scraper.Article:
+		terms := append(append([]string{}, filters.Symbols...), filters.Keywords...)
+		if len(terms) == 0 {
+			return v
+		}
+		articles := make([]scraper.Article, 0)
+		for _, article := range v {
+			text := strings.ToLower(article.Title + " " + article.Snippet)
+			for _, term := range terms {
+				if strings.Contains(text, strings.ToLower(term)) {
+					articles = append(articles, article)
+					break
+				}
+			}
+		}
+		return articles
+	case []scraper.EconomicEvent:
+		if len(filters.Keywords) == 0 {
+			return v
+		}
+		events := make([]scraper.EconomicEvent, 0)
+		for _, event := range v {
+			text := strings.ToLower(event.Event + " " + event.Country)
+			for _, term := range filters.Keywords {
+				if strings.Contains(text, strings.ToLower(term)) {
+					events = append(events, event)
+					break
+				}
+			}
+		}
+		return events
+	}
+	return data
+}
+
+func containsFold(values []string, s string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
+
+func isEmpty(data interface{}) bool {
+	switch v := data.(type) {
+	case []scraper.StockData:
+		return len(v) == 0
+	case map[string]*scraper.SectorData:
+		return len(v) == 0
+	case []scraper.Article:
+		return len(v) == 0
+	case []scraper.EconomicEvent:
+		return len(v) == 0
+	}
+	return data == nil
+}
@@ -0,0 +1,92 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go-webscraper/scraper"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSign(t *testing.T) {
+	body := []byte(`{"id":"abc"}`)
+	mac := hmac.New(sha256.New, []byte("secret"))
+	mac.Write(body)
+
+	assert.Equal(t, "sha256="+hex.EncodeToString(mac.Sum(nil)), Sign("secret", body))
+	assert.NotEqual(t, Sign("secret", body), Sign("other", body))
+}
+
+func TestApplyFilters(t *testing.T) {
+	stocks := []scraper.StockData{{Symbol: "AAPL"}, {Symbol: "MSFT"}}
+	assert.Equal(t, stocks, applyFilters(Filters{}, stocks))
+	assert.Equal(t, []scraper.StockData{{Symbol: "AAPL"}}, applyFilters(Filters{Symbols: []string{"aapl"}}, stocks))
+	assert.True(t, isEmpty(applyFilters(Filters{Symbols: []string{"TSLA"}}, stocks)))
+
+	sectors := map[string]*scraper.SectorData{"Technology": {Name: "Technology"}, "Energy": {Name: "Energy"}}
+	filtered := applyFilters(Filters{Sectors: []string{"energy"}}, sectors).(map[string]*scraper.SectorData)
+	assert.Len(t, filtered, 1)
+	assert.Contains(t, filtered, "Energy")
+
+	articles := []scraper.Article{
+		{Title: "Apple beats estimates", Snippet: "AAPL rallies"},
+		{Title: "Oil slides", Snippet: "Crude falls on supply"},
+	}
+	assert.Len(t, applyFilters(Filters{Symbols: []string{"AAPL"}}, articles), 1)
+	assert.Len(t, applyFilters(Filters{Keywords: []string{"CRUDE"}}, articles), 1)
+	assert.Len(t, applyFilters(Filters{Symbols: []string{"AAPL"}, Keywords: []string{"oil"}}, articles), 2)
+
+	events := []scraper.EconomicEvent{{Event: "CPI YoY", Country: "US"}, {Event: "GDP", Country: "JP"}}
+	assert.Equal(t, []scraper.EconomicEvent{{Event: "CPI YoY", Country: "US"}}, applyFilters(Filters{Keywords: []string{"cpi"}}, events))
+}
+
+func TestDeliverRetriesWithBackoff(t *testing.T) {
+	var attempts atomic.Int32
+	var signature string
+	var body []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if attempts.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		signature = r.Header.Get(SignatureHeader)
+		body, _ = io.ReadAll(r.Body)
+	}))
+	defer srv.Close()
+
+	d := NewDispatcher(nil, DispatcherOption{MaxRetries: 3})
+	// The test server listens on loopback, which the guarded client refuses
+	d.client = srv.Client()
+	d.backoff = 10 * time.Millisecond
+
+	start := time.Now()
+	d.deliver(&Subscription{ID: "s1", TargetURL: srv.URL, Secret: "secret"}, Event{ID: "e1", Source: "stocks"})
+
+	assert.Equal(t, int32(3), attempts.Load())
+	assert.GreaterOrEqual(t, time.Since(start), 30*time.Millisecond)
+	assert.Equal(t, Sign("secret", body), signature)
+}
+
+func TestDeliverGivesUpAfterMaxRetries(t *testing.T) {
+	var attempts atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	d := NewDispatcher(nil, DispatcherOption{MaxRetries: 2})
+	d.client = srv.Client()
+	d.backoff = time.Millisecond
+
+	d.deliver(&Subscription{ID: "s1", TargetURL: srv.URL}, Event{ID: "e1"})
+	assert.Equal(t, int32(2), attempts.Load())
+}
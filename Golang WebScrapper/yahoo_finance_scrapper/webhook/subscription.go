@@ -0,0 +1,261 @@
+package webhook
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"go-webscraper/pkg/egress"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+const subscriptionsKey = "webhook:subscriptions"
+
+var validSources = map[string]bool{
+	"stocks":   true,
+	"sectors":  true,
+	"news":     true,
+	"calendar": true,
+}
+
+type Filters struct {
+	Symbols  []string `json:"symbols,omitempty"`
+	Sectors  []string `json:"sectors,omitempty"`
+	Keywords []string `json:"keywords,omitempty"`
+}
+
+// Subscription pushes refreshed data from Source to TargetURL on behalf
+// of the user who created it.
+type Subscription struct {
+	ID        string    `json:"id"`
+	UserID    string    `json:"-"`
+	TargetURL string    `json:"target_url"`
+	Source    string    `json:"source"`
+	Filters   Filters   `json:"filters"`
+	Secret    string    `json:"secret,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// storedSubscription keeps UserID in Redis while the API never exposes it.
+type storedSubscription struct {
+	Subscription
+	UserID string `json:"user_id"`
+}
+
+type Store struct {
+	redis *redis.Client
+	ctx   context.Context
+}
+
+func NewStore(rdb *redis.Client) *Store {
+	return &Store{
+		redis: rdb,
+		ctx:   context.Background(),
+	}
+}
+
+func (s *Store) Save(sub *Subscription) error {
+	data, err := json.Marshal(storedSubscription{Subscription: *sub, UserID: sub.UserID})
+	if err != nil {
+		return err
+	}
+	return s.redis.HSet(s.ctx, subscriptionsKey, sub.ID, data).Err()
+}
+
+func (s *Store) Get(id string) (*Subscription, error) {
+	data, err := s.redis.HGet(s.ctx, subscriptionsKey, id).Result()
+	if err != nil {
+		if err == redis.Nil {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var stored storedSubscription
+	if err := json.Unmarshal([]byte(data), &stored); err != nil {
+		return nil, err
+	}
+	stored.Subscription.UserID = stored.UserID
+	return &stored.Subscription, nil
+}
+
+// Delete removes the subscription id if userID owns it, reporting whether
+// it was removed.
+func (s *Store) Delete(userID, id string) (bool, error) {
+	sub, err := s.Get(id)
+	if err != nil || sub == nil || sub.UserID != userID {
+		return false, err
+	}
+	n, err := s.redis.HDel(s.ctx, subscriptionsKey, id).Result()
+	return n > 0, err
+}
+
+func (s *Store) List() ([]*Subscription, error) {
+	values, err := s.redis.HGetAll(s.ctx, subscriptionsKey).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	subs := make([]*Subscription, 0, len(values))
+	for _, value := range values {
+		var stored storedSubscription
+		if err := json.Unmarshal([]byte(value), &stored); err != nil {
+			continue
+		}
+		stored.Subscription.UserID = stored.UserID
+		subs = append(subs, &stored.Subscription)
+	}
+	return subs, nil
+}
+
+func (s *Store) ListByUser(userID string) ([]*Subscription, error) {
+	subs, err := s.List()
+	if err != nil {
+		return nil, err
+	}
+
+	owned := make([]*Subscription, 0)
+	for _, sub := range subs {
+		if sub.UserID == userID {
+			owned = append(owned, sub)
+		}
+	}
+	return owned, nil
+}
+
+func (s *Store) BySource(source string) ([]*Subscription, error) {
+	subs, err := s.List()
+	if err != nil {
+		return nil, err
+	}
+
+	matched := make([]*Subscription, 0, len(subs))
+	for _, sub := range subs {
+		if sub.Source == source {
+			matched = append(matched, sub)
+		}
+	}
+	return matched, nil
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+type SubscriptionRequest struct {
+	TargetURL string  `json:"target_url" binding:"required"`
+	Source    string  `json:"source" binding:"required"`
+	Filters   Filters `json:"filters"`
+	Secret    string  `json:"secret"`
+}
+
+func HandleCreateSubscription(store *Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req SubscriptionRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"status": "error",
+				"error":  err.Error(),
+			})
+			return
+		}
+
+		if !validSources[req.Source] {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"status": "error",
+				"error":  fmt.Sprintf("invalid source: %s", req.Source),
+			})
+			return
+		}
+		// Deliveries come from inside our network, so never to it
+		if err := egress.CheckURL(c.Request.Context(), req.TargetURL); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"status": "error",
+				"error":  "target_url " + err.Error(),
+			})
+			return
+		}
+
+		if req.Secret == "" {
+			req.Secret = randomHex(32)
+		}
+
+		sub := &Subscription{
+			ID:        randomHex(8),
+			UserID:    c.GetString("user_id"),
+			TargetURL: req.TargetURL,
+			Source:    req.Source,
+			Filters:   req.Filters,
+			Secret:    req.Secret,
+			CreatedAt: time.Now(),
+		}
+		if err := store.Save(sub); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"status": "error",
+				"error":  "Failed to save subscription",
+			})
+			return
+		}
+
+		c.Set("audit_target", sub.ID)
+		// The secret is only ever returned on creation
+		c.JSON(http.StatusCreated, gin.H{
+			"status": "success",
+			"data":   sub,
+		})
+	}
+}
+
+func HandleListSubscriptions(store *Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		subs, err := store.ListByUser(c.GetString("user_id"))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"status": "error",
+				"error":  "Failed to list subscriptions",
+			})
+			return
+		}
+
+		for _, sub := range subs {
+			sub.Secret = ""
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"status": "success",
+			"data":   subs,
+		})
+	}
+}
+
+func HandleDeleteSubscription(store *Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		deleted, err := store.Delete(c.GetString("user_id"), c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"status": "error",
+				"error":  "Failed to delete subscription",
+			})
+			return
+		}
+		if !deleted {
+			c.JSON(http.StatusNotFound, gin.H{
+				"status": "error",
+				"error":  "subscription not found",
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"status": "success",
+		})
+	}
+}
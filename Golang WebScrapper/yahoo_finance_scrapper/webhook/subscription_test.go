@@ -0,0 +1,35 @@
+package webhook
+
+import (
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStoreScopesSubscriptionsToOwner(t *testing.T) {
+	mr := miniredis.RunT(t)
+	store := NewStore(redis.NewClient(&redis.Options{Addr: mr.Addr()}))
+
+	require.NoError(t, store.Save(&Subscription{ID: "a", UserID: "alice", Source: "stocks"}))
+	require.NoError(t, store.Save(&Subscription{ID: "b", UserID: "bob", Source: "stocks"}))
+
+	owned, err := store.ListByUser("alice")
+	require.NoError(t, err)
+	require.Len(t, owned, 1)
+	assert.Equal(t, "a", owned[0].ID)
+
+	deleted, err := store.Delete("alice", "b")
+	require.NoError(t, err)
+	assert.False(t, deleted)
+
+	deleted, err = store.Delete("bob", "b")
+	require.NoError(t, err)
+	assert.True(t, deleted)
+
+	all, err := store.BySource("stocks")
+	require.NoError(t, err)
+	assert.Len(t, all, 1)
+}